@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/config"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/headers"
 	"github.com/canonical/identity-platform-admin-ui/internal/kratos"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/mail"
@@ -86,8 +89,20 @@ func initializeEnv() (*config.EnvSpec, error) {
 }
 
 func initializeIdentityService(specs *config.EnvSpec, logger logging.LoggerInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, wpool pool.WorkerPoolInterface) *identities.Service {
+	kratosHeaders, err := headers.Parse(specs.KratosHeaders)
+
+	if err != nil {
+		logger.Fatalf("invalid KRATOS_HEADERS: %s", err)
+	}
+
 	// Set up Kratos client
-	kratosClient := kratos.NewClient(specs.KratosAdminURL, specs.Debug)
+	kratosClient := kratos.NewClient(
+		specs.KratosAdminURL,
+		specs.Debug,
+		time.Duration(specs.KratosConnectTimeoutSeconds)*time.Second,
+		time.Duration(specs.KratosResponseTimeoutSeconds)*time.Second,
+		kratosHeaders,
+	)
 
 	// Set up OpenFGA authorization
 	openfgaConfig := openfga.NewConfig(
@@ -112,8 +127,9 @@ func initializeIdentityService(specs *config.EnvSpec, logger logging.LoggerInter
 		specs.MailPassword,
 		specs.MailFromAddress,
 		specs.MailSendTimeoutSeconds,
+		specs.MailTemplatesDir,
 	)
 	mailService := mail.NewEmailService(mailConfig, tracer, monitor, logger)
 
-	return identities.NewService(kratosClient.IdentityAPI(), authorizer, mailService, tracer, monitor, logger)
+	return identities.NewService(kratosClient.IdentityAPI(), authorizer, mailService, wpool, audit.NewNoopAuditor(), nil, tracer, monitor, logger)
 }
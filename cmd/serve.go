@@ -19,6 +19,9 @@ import (
 
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/config"
+	"github.com/canonical/identity-platform-admin-ui/internal/events"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/headers"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	ih "github.com/canonical/identity-platform-admin-ui/internal/hydra"
 	k8s "github.com/canonical/identity-platform-admin-ui/internal/k8s"
 	ik "github.com/canonical/identity-platform-admin-ui/internal/kratos"
@@ -30,9 +33,12 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/pool"
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+	"github.com/canonical/identity-platform-admin-ui/pkg/groups"
+	"github.com/canonical/identity-platform-admin-ui/pkg/identities"
 	"github.com/canonical/identity-platform-admin-ui/pkg/idp"
 	"github.com/canonical/identity-platform-admin-ui/pkg/rules"
 	"github.com/canonical/identity-platform-admin-ui/pkg/schemas"
+	"github.com/canonical/identity-platform-admin-ui/pkg/status"
 	"github.com/canonical/identity-platform-admin-ui/pkg/ui"
 	"github.com/canonical/identity-platform-admin-ui/pkg/web"
 )
@@ -63,34 +69,72 @@ func serve() {
 		panic(fmt.Errorf("issues with environment sourcing: %s", err))
 	}
 
-	logger := logging.NewLogger(specs.LogLevel)
+	if err := specs.Validate(); err != nil {
+		panic(fmt.Errorf("invalid configuration:\n%s", err))
+	}
+
+	logger := logging.NewLogger(specs.LogLevel, specs.LogFormat)
 	monitor := prometheus.NewMonitor("identity-admin-ui", logger)
-	tracer := tracing.NewTracer(tracing.NewConfig(specs.TracingEnabled, specs.OtelGRPCEndpoint, specs.OtelHTTPEndpoint, logger))
+
+	tracingConfig := tracing.NewConfig(specs.TracingEnabled, specs.TracingSampleRatio, specs.OtelGRPCEndpoint, specs.OtelHTTPEndpoint, logger)
+
+	if err := tracingConfig.Validate(); err != nil {
+		logger.Fatalf("invalid tracing configuration: %s", err)
+	}
+
+	tracer := tracing.NewTracer(tracingConfig)
 
 	distFS, err := fs.Sub(jsFS, "ui/dist")
 	if err != nil {
 		logger.Fatalf("issue with ui files %s", err)
 	}
 
-	hydraAdminClient := ih.NewClient(specs.HydraAdminURL, specs.Debug)
+	openfgaConfig := openfga.NewConfig(
+		specs.ApiScheme,
+		specs.ApiHost,
+		specs.StoreId,
+		specs.ApiToken,
+		specs.ModelId,
+		specs.Debug,
+		tracer,
+		monitor,
+		logger,
+	)
+
+	if openfgaConfig != nil {
+		openfgaConfig.CheckCache = openfga.NewCheckCacheConfig(specs.OpenFGACheckCacheEnabled, specs.OpenFGACheckCacheSize, specs.OpenFGACheckCacheTTLSeconds)
+		openfgaConfig.WriteBatch = openfga.NewWriteBatchConfig(specs.OpenFGAWriteBatchEnabled, specs.OpenFGAWriteBatchWindowMS, specs.OpenFGAWriteBatchMaxSize)
+		openfgaConfig.Retry = openfga.NewRetryConfig(specs.OpenFGARetryMaxAttempts, specs.OpenFGARetryBaseDelayMS, specs.OpenFGARetryJitterMS)
+
+		if openfgaConfig.ReadPageSize, err = openfga.NewReadPageSize(specs.OpenFGAReadPageSize); err != nil {
+			logger.Fatalf("invalid OPENFGA_READ_PAGE_SIZE: %s", err)
+		}
+	}
+
+	kratosConnectTimeout := time.Duration(specs.KratosConnectTimeoutSeconds) * time.Second
+	kratosResponseTimeout := time.Duration(specs.KratosResponseTimeoutSeconds) * time.Second
+	hydraConnectTimeout := time.Duration(specs.HydraConnectTimeoutSeconds) * time.Second
+	hydraResponseTimeout := time.Duration(specs.HydraResponseTimeoutSeconds) * time.Second
+
+	kratosHeaders, err := headers.Parse(specs.KratosHeaders)
+
+	if err != nil {
+		logger.Fatalf("invalid KRATOS_HEADERS: %s", err)
+	}
+
+	hydraHeaders, err := headers.Parse(specs.HydraHeaders)
+
+	if err != nil {
+		logger.Fatalf("invalid HYDRA_HEADERS: %s", err)
+	}
+
+	hydraAdminClient := ih.NewClient(specs.HydraAdminURL, specs.Debug, hydraConnectTimeout, hydraResponseTimeout, hydraHeaders)
 	externalConfig := web.NewExternalClientsConfig(
 		hydraAdminClient,
-		ik.NewClient(specs.KratosAdminURL, specs.Debug),
-		ik.NewClient(specs.KratosPublicURL, specs.Debug),
+		ik.NewClient(specs.KratosAdminURL, specs.Debug, kratosConnectTimeout, kratosResponseTimeout, kratosHeaders),
+		ik.NewClient(specs.KratosPublicURL, specs.Debug, kratosConnectTimeout, kratosResponseTimeout, kratosHeaders),
 		io.NewClient(specs.OathkeeperPublicURL, specs.Debug),
-		openfga.NewClient(
-			openfga.NewConfig(
-				specs.ApiScheme,
-				specs.ApiHost,
-				specs.StoreId,
-				specs.ApiToken,
-				specs.ModelId,
-				specs.Debug,
-				tracer,
-				monitor,
-				logger,
-			),
-		),
+		openfga.NewClient(openfgaConfig),
 		nil,
 	)
 
@@ -116,6 +160,8 @@ func serve() {
 
 	rulesConfig := rules.NewConfig(specs.RulesConfigMapName, specs.RulesConfigFileName, specs.RulesConfigMapNamespace, k8sCoreV1, externalConfig.OathkeeperPublic().ApiApi())
 
+	groupsTrashConfig := groups.NewTrashConfig(specs.GroupsSoftDeleteEnabled, specs.GroupsTrashConfigMapName, specs.GroupsTrashConfigMapNamespace, k8sCoreV1)
+
 	uiConfig := &ui.Config{
 		DistFS:      distFS,
 		ContextPath: specs.ContextPath,
@@ -161,15 +207,80 @@ func serve() {
 		specs.OAuth2UserSessionTTLSeconds,
 		specs.OAuth2AuthCookiesEncryptionKey,
 		specs.OAuth2CodeGrantScopes,
-		ih.NewClient(specs.OIDCIssuer, specs.Debug),
+		ih.NewClient(specs.OIDCIssuer, specs.Debug, hydraConnectTimeout, hydraResponseTimeout, hydraHeaders),
 		hydraAdminClient,
 	)
 
-	mailConfig := mail.NewConfig(specs.MailHost, specs.MailPort, specs.MailUsername, specs.MailPassword, specs.MailFromAddress, specs.MailSendTimeoutSeconds)
+	if specs.APIKeysFile != "" {
+		keys, err := authentication.LoadAPIKeys(specs.APIKeysFile)
+
+		if err != nil {
+			logger.Fatalf("failed to load api keys: %s", err)
+		}
+
+		oauth2Config.APIKeys = keys
+	}
+
+	if len(specs.OAuth2TrustedIssuers) > 0 {
+		issuers, err := authentication.ParseTrustedIssuers(specs.OAuth2TrustedIssuers)
+
+		if err != nil {
+			logger.Fatalf("failed to parse trusted issuers: %s", err)
+		}
+
+		oauth2Config.TrustedIssuers = issuers
+	}
+
+	oauth2Config.ClaimsMapping = authentication.NewClaimsMapping(specs.OAuth2EmailClaim, specs.OAuth2GroupsClaim, specs.OAuth2RolesClaim)
+	oauth2Config.Leeway = time.Duration(specs.OAuth2JWTLeewaySeconds) * time.Second
+
+	mailConfig := mail.NewConfig(specs.MailHost, specs.MailPort, specs.MailUsername, specs.MailPassword, specs.MailFromAddress, specs.MailSendTimeoutSeconds, specs.MailTemplatesDir)
+	mailConfig.TestRateLimitPerMinute = specs.MailTestRateLimitPerMinute
+
+	webhookConfig := events.NewConfig(specs.WebhookURL, specs.WebhookSecret, specs.WebhookMaxRetries, specs.WebhookQueueSize, specs.WebhookTimeoutSeconds)
+
+	var dispatcher *events.WebhookDispatcher
+	if webhookConfig.Enabled() {
+		dispatcher = events.NewWebhookDispatcher(webhookConfig, tracer, monitor, logger)
+	}
+
+	identityTraits, err := identities.NewTraitsMapping(
+		specs.IdentityTraitsEmail,
+		specs.IdentityTraitsName,
+		specs.IdentityTraitsFirstName,
+		specs.IdentityTraitsLastName,
+		specs.IdentityTraitsNameStrategy,
+	)
+
+	if err != nil {
+		logger.Fatalf("invalid identity traits mapping: %s", err)
+	}
 
 	ollyConfig := web.NewO11yConfig(tracer, monitor, logger)
 
 	routerConfig := web.NewRouterConfig(specs.ContextPath, specs.PayloadValidationEnabled, idpConfig, schemasConfig, rulesConfig, uiConfig, externalConfig, oauth2Config, mailConfig, ollyConfig)
+	routerConfig.SetPIIRedaction(specs.LogRedactPII, specs.PIIKeys)
+	routerConfig.SetStatusConfig(status.NewConfig(specs.StatusRequiredDependencies))
+	routerConfig.SetRateLimitConfig(web.NewRateLimitConfig(specs.RateLimitRequestsPerSecond, specs.RateLimitBurst))
+	routerConfig.SetCORSConfig(web.NewCORSConfig(specs.CORSAllowedOrigins, specs.CORSAllowedMethods, specs.CORSAllowedHeaders, specs.CORSAllowCredentials))
+	routerConfig.SetGzipConfig(web.NewGzipConfig(specs.GzipEnabled, specs.GzipMinSizeBytes))
+	routerConfig.SetBodyLimitConfig(web.NewBodyLimitConfig(specs.RequestBodyMaxBytes))
+	if dispatcher != nil {
+		routerConfig.SetDispatcher(dispatcher)
+	}
+	routerConfig.SetIdentitiesConfig(
+		identities.NewSearchConfig(specs.IdentitySearchFields, specs.IdentitySearchMaxPages),
+		identityTraits,
+		time.Duration(specs.IdentitySchemaCacheTTLSeconds)*time.Second,
+		specs.IdentityAllowedSchemas,
+	)
+	routerConfig.SetPageSizeConfig(types.NewPageSizeConfig(specs.DefaultPageSize, specs.MaxPageSize))
+	routerConfig.SetCursorConfig(types.NewCursorConfig(specs.PaginationCursorsEnabled, specs.PaginationCursorTTLSeconds, specs.PaginationCursorThresholdBytes, specs.PaginationCursorMaxEntries))
+	routerConfig.SetAPIsConfig(web.NewAPIsConfig(specs.EnableGroups, specs.EnableRoles, specs.EnableEntitlements))
+	routerConfig.SetStatsTTL(time.Duration(specs.StatsCacheTTLSeconds) * time.Second)
+	routerConfig.SetGroupsConfig(groupsTrashConfig, specs.GroupsPatchRollback)
+	routerConfig.SetLogSamplingConfig(logging.NewSamplingConfig(specs.LogSamplingEnabled, specs.LogSamplingIntervalSeconds, specs.LogSamplingThreshold))
+	routerConfig.SetAuditLogSize(specs.AuditLogSize)
 
 	router := web.NewRouter(routerConfig, wpool)
 
@@ -202,6 +313,18 @@ func serve() {
 	// until the timeout deadline.
 	srv.Shutdown(ctx)
 
+	// let in-flight jobs (e.g. cascading deletions) finish within the same deadline
+	if err := wpool.Shutdown(ctx); err != nil {
+		logger.Errorf("worker pool not drained: %s", err)
+	}
+
+	// deliver the queued webhook events within the same deadline, the rest are dropped
+	if dispatcher != nil {
+		if err := dispatcher.Shutdown(ctx); err != nil {
+			logger.Errorf("webhook queue not drained: %s", err)
+		}
+	}
+
 	logger.Desugar().Sync()
 
 	// Optionally, you could run srv.Shutdown in a goroutine and block on
@@ -18,6 +18,8 @@ var versionCmd = &cobra.Command{
 	Long:  `Get the application's version.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("App Version: %s\n", version.Version)
+		fmt.Printf("Commit: %s\n", version.Commit)
+		fmt.Printf("Build Date: %s\n", version.BuildDate)
 	},
 }
 
@@ -0,0 +1,45 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+// resource types and action names are part of the audit log format, don't change existing values
+const (
+	IdentityResource = "identity"
+	GroupResource    = "group"
+	RoleResource     = "role"
+	SessionResource  = "session"
+
+	IdentityCreate             = "identity.create"
+	IdentityUpdate             = "identity.update"
+	IdentityDelete             = "identity.delete"
+	IdentityDeleteCredential   = "identity.delete_credential"
+	IdentityActivate           = "identity.activate"
+	IdentityDeactivate         = "identity.deactivate"
+	IdentityRevokeSessions     = "identity.revoke_sessions"
+	IdentityCreateRecoveryLink = "identity.create_recovery_link"
+	IdentityVerifyAddress      = "identity.verify_address"
+
+	GroupCreate            = "group.create"
+	GroupRename            = "group.rename"
+	GroupDelete            = "group.delete"
+	GroupRestore           = "group.restore"
+	GroupPurge             = "group.purge"
+	GroupAssignRoles       = "group.assign_roles"
+	GroupRemoveRoles       = "group.remove_roles"
+	GroupAssignPermissions = "group.assign_permissions"
+	GroupRemovePermissions = "group.remove_permissions"
+	GroupAssignIdentities  = "group.assign_identities"
+	GroupRemoveIdentities  = "group.remove_identities"
+	GroupReconcile         = "group.reconcile"
+
+	RoleCreate            = "role.create"
+	RoleClone             = "role.clone"
+	RoleDelete            = "role.delete"
+	RoleAssignPermissions = "role.assign_permissions"
+	RoleRemovePermissions = "role.remove_permissions"
+	RoleReconcile         = "role.reconcile"
+	RoleImport            = "role.import"
+
+	SessionDisable = "session.disable"
+)
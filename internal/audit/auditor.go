@@ -0,0 +1,105 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+
+	// anonymousPrincipal is used when no principal is attached to the context
+	anonymousPrincipal = "anonymous"
+)
+
+// OutcomeFromError maps the error returned by an operation to its outcome
+func OutcomeFromError(err error) Outcome {
+	if err != nil {
+		return OutcomeFailure
+	}
+
+	return OutcomeSuccess
+}
+
+// Event is a single audit record, Action is in the form <resource>.<operation>, e.g. identity.create
+type Event struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Principal    string    `json:"principal"`
+	RequestID    string    `json:"request_id,omitempty"`
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resource_type"`
+	ResourceID   string    `json:"resource_id"`
+	Outcome      Outcome   `json:"outcome"`
+}
+
+// Auditor writes every event as a JSON document through the logger
+type Auditor struct {
+	store StoreInterface
+
+	logger logging.LoggerInterface
+}
+
+// SetStore sets the store every event is added to on top of being logged, backing the audit log API
+func (a *Auditor) SetStore(s StoreInterface) {
+	a.store = s
+}
+
+func (a *Auditor) Record(ctx context.Context, action, resourceType, resourceID string, outcome Outcome) {
+	e := Event{
+		Timestamp:    time.Now().UTC(),
+		Principal:    anonymousPrincipal,
+		RequestID:    middleware.GetReqID(ctx),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Outcome:      outcome,
+	}
+
+	if principal := authentication.PrincipalFromContext(ctx); principal != nil {
+		e.Principal = principal.Identifier()
+	}
+
+	if a.store != nil {
+		a.store.Add(e)
+	}
+
+	event, err := json.Marshal(e)
+
+	if err != nil {
+		a.logger.Errorf("failed to marshal audit event %s on %s: %s", action, resourceID, err)
+		return
+	}
+
+	a.logger.Info(string(event))
+}
+
+// NewAuditor returns an Auditor, the logger needs to be at least at info level for events to show up
+func NewAuditor(logger logging.LoggerInterface) *Auditor {
+	a := new(Auditor)
+
+	a.logger = logger
+
+	return a
+}
+
+// NoopAuditor discards every event
+type NoopAuditor struct{}
+
+func (a *NoopAuditor) Record(ctx context.Context, action, resourceType, resourceID string, outcome Outcome) {
+}
+
+func NewNoopAuditor() *NoopAuditor {
+	return new(NoopAuditor)
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+func TestAuditorRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal authentication.PrincipalInterface
+		outcome   Outcome
+		expected  string
+	}{
+		{
+			name:      "with principal",
+			principal: &authentication.UserPrincipal{Email: "joe@example.com"},
+			outcome:   OutcomeSuccess,
+			expected:  "joe@example.com",
+		},
+		{
+			name:     "without principal",
+			outcome:  OutcomeFailure,
+			expected: anonymousPrincipal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+
+			ctx := context.Background()
+			if test.principal != nil {
+				ctx = authentication.PrincipalContext(ctx, test.principal)
+			}
+
+			NewAuditor(zap.New(core).Sugar()).Record(ctx, "identity.create", "identity", "test-1", test.outcome)
+
+			entries := logs.AllUntimed()
+
+			if !assert.Len(t, entries, 1) {
+				return
+			}
+
+			e := new(Event)
+			if err := json.Unmarshal([]byte(entries[0].Message), e); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			assert.Equal(t, test.expected, e.Principal)
+			assert.Equal(t, "identity.create", e.Action)
+			assert.Equal(t, "identity", e.ResourceType)
+			assert.Equal(t, "test-1", e.ResourceID)
+			assert.Equal(t, test.outcome, e.Outcome)
+		})
+	}
+}
+
+func TestOutcomeFromError(t *testing.T) {
+	assert.Equal(t, OutcomeSuccess, OutcomeFromError(nil))
+	assert.Equal(t, OutcomeFailure, OutcomeFromError(fmt.Errorf("error")))
+}
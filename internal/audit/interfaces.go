@@ -0,0 +1,17 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+import "context"
+
+// AuditorInterface records mutating operations, implementations decide where the events end up
+type AuditorInterface interface {
+	Record(ctx context.Context, action, resourceType, resourceID string, outcome Outcome)
+}
+
+// StoreInterface keeps the recorded events so they can be listed, newest first
+type StoreInterface interface {
+	Add(Event)
+	List(ctx context.Context, filter Filter, pageToken string, size int) ([]Event, string, error)
+}
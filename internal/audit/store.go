@@ -0,0 +1,115 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// ErrInvalidPageToken is returned when the page token of a list wasn't issued by the store
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Filter restricts the events listed, empty fields match every event
+type Filter struct {
+	Principal    string
+	Action       string
+	ResourceType string
+	ResourceID   string
+}
+
+func (f Filter) matches(e Event) bool {
+	return (f.Principal == "" || f.Principal == e.Principal) &&
+		(f.Action == "" || f.Action == e.Action) &&
+		(f.ResourceType == "" || f.ResourceType == e.ResourceType) &&
+		(f.ResourceID == "" || f.ResourceID == e.ResourceID)
+}
+
+type storedEvent struct {
+	seq   uint64
+	event Event
+}
+
+// MemoryStore keeps the latest events in a ring buffer, older events are dropped once it's full
+// and the whole log is lost on restart
+type MemoryStore struct {
+	events []storedEvent
+	// next is the position the next event is written to
+	next int
+	// seq numbers the events, page tokens are the seq of the last event of a page
+	seq uint64
+
+	mu sync.RWMutex
+}
+
+func (s *MemoryStore) Add(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+
+	if len(s.events) < cap(s.events) {
+		s.events = append(s.events, storedEvent{seq: s.seq, event: e})
+		return
+	}
+
+	s.events[s.next] = storedEvent{seq: s.seq, event: e}
+	s.next = (s.next + 1) % len(s.events)
+}
+
+// List returns up to size events matching filter, newest first, the events following the one
+// pageToken was issued for are returned when set, the returned token is empty on the last page
+func (s *MemoryStore) List(ctx context.Context, filter Filter, pageToken string, size int) ([]Event, string, error) {
+	var before uint64
+
+	if pageToken != "" {
+		t, err := strconv.ParseUint(pageToken, 10, 64)
+
+		if err != nil || t == 0 {
+			return nil, "", fmt.Errorf("%w %q", ErrInvalidPageToken, pageToken)
+		}
+
+		before = t
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]Event, 0, min(size, len(s.events)))
+	last := uint64(0)
+
+	// walk backwards from the latest event
+	for i := 1; i <= len(s.events); i++ {
+		stored := s.events[(s.next-i+len(s.events))%len(s.events)]
+
+		if before != 0 && stored.seq >= before {
+			continue
+		}
+
+		if !filter.matches(stored.event) {
+			continue
+		}
+
+		if len(events) == size {
+			return events, strconv.FormatUint(last, 10), nil
+		}
+
+		events = append(events, stored.event)
+		last = stored.seq
+	}
+
+	return events, "", nil
+}
+
+// NewMemoryStore returns a MemoryStore holding at most size events
+func NewMemoryStore(size int) *MemoryStore {
+	s := new(MemoryStore)
+
+	s.events = make([]storedEvent, 0, size)
+
+	return s
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+func TestMemoryStoreList(t *testing.T) {
+	events := []Event{
+		{Principal: "joe", Action: "group.create", ResourceType: "group", ResourceID: "admins"},
+		{Principal: "jane", Action: "role.create", ResourceType: "role", ResourceID: "viewer"},
+		{Principal: "joe", Action: "group.delete", ResourceType: "group", ResourceID: "admins"},
+		{Principal: "joe", Action: "role.delete", ResourceType: "role", ResourceID: "viewer"},
+		{Principal: "jane", Action: "group.create", ResourceType: "group", ResourceID: "devs"},
+	}
+
+	actions := func(events []Event) []string {
+		a := make([]string, 0, len(events))
+
+		for _, e := range events {
+			a = append(a, fmt.Sprintf("%s %s", e.Action, e.ResourceID))
+		}
+
+		return a
+	}
+
+	tests := []struct {
+		name     string
+		capacity int
+		filter   Filter
+		size     int
+		pages    [][]string
+	}{
+		{
+			name:     "newest first",
+			capacity: 10,
+			size:     10,
+			pages:    [][]string{{"group.create devs", "role.delete viewer", "group.delete admins", "role.create viewer", "group.create admins"}},
+		},
+		{
+			name:     "oldest events are dropped",
+			capacity: 3,
+			size:     10,
+			pages:    [][]string{{"group.create devs", "role.delete viewer", "group.delete admins"}},
+		},
+		{
+			name:     "paginated",
+			capacity: 10,
+			size:     2,
+			pages: [][]string{
+				{"group.create devs", "role.delete viewer"},
+				{"group.delete admins", "role.create viewer"},
+				{"group.create admins"},
+			},
+		},
+		{
+			name:     "last page is full",
+			capacity: 10,
+			filter:   Filter{Principal: "jane"},
+			size:     2,
+			pages:    [][]string{{"group.create devs", "role.create viewer"}},
+		},
+		{
+			name:     "filtered by actor and resource",
+			capacity: 10,
+			filter:   Filter{Principal: "joe", ResourceType: "group", ResourceID: "admins"},
+			size:     1,
+			pages:    [][]string{{"group.delete admins"}, {"group.create admins"}},
+		},
+		{
+			name:     "filtered by action",
+			capacity: 4,
+			filter:   Filter{Action: "group.create"},
+			size:     10,
+			pages:    [][]string{{"group.create devs"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewMemoryStore(test.capacity)
+
+			for _, e := range events {
+				s.Add(e)
+			}
+
+			token := ""
+
+			for i, page := range test.pages {
+				events, next, err := s.List(context.Background(), test.filter, token, test.size)
+
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				assert.Equal(t, page, actions(events))
+
+				if last := i == len(test.pages)-1; last != (next == "") {
+					t.Fatalf("expected next token to be empty only on the last page, got %q on page %d", next, i)
+				}
+
+				token = next
+			}
+		})
+	}
+}
+
+func TestMemoryStoreListInvalidToken(t *testing.T) {
+	for _, token := range []string{"0", "-1", "abc"} {
+		_, _, err := NewMemoryStore(1).List(context.Background(), Filter{}, token, 10)
+
+		if !errors.Is(err, ErrInvalidPageToken) {
+			t.Errorf("expected error to be %v got %v", ErrInvalidPageToken, err)
+		}
+	}
+}
+
+func TestAuditorStore(t *testing.T) {
+	store := NewMemoryStore(10)
+
+	auditor := NewAuditor(zap.NewNop().Sugar())
+	auditor.SetStore(store)
+
+	ctx := authentication.PrincipalContext(context.Background(), &authentication.UserPrincipal{Email: "joe@example.com"})
+
+	auditor.Record(ctx, GroupCreate, GroupResource, "admins", OutcomeSuccess)
+	auditor.Record(ctx, GroupAssignRoles, GroupResource, "admins", OutcomeSuccess)
+	auditor.Record(context.Background(), RoleDelete, RoleResource, "viewer", OutcomeFailure)
+
+	events, next, err := store.List(context.Background(), Filter{}, "", 10)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !assert.Len(t, events, 3) {
+		return
+	}
+
+	assert.Empty(t, next)
+	assert.Equal(t, []string{RoleDelete, GroupAssignRoles, GroupCreate}, []string{events[0].Action, events[1].Action, events[2].Action})
+	assert.Equal(t, anonymousPrincipal, events[0].Principal)
+	assert.Equal(t, "joe@example.com", events[2].Principal)
+	assert.Equal(t, OutcomeFailure, events[0].Outcome)
+	assert.False(t, events[0].Timestamp.Before(events[2].Timestamp))
+}
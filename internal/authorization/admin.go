@@ -13,6 +13,7 @@ import (
 )
 
 const PRIVILEGED_RELATION = "privileged"
+const ADMIN_RELATION = "admin"
 const ADMIN_OBJECT = "privileged:superuser"
 
 type AdminAuthorizer struct {
@@ -28,7 +29,7 @@ func (a *AdminAuthorizer) CreateAdmin(ctx context.Context, username string) erro
 	defer span.End()
 
 	user := fmt.Sprintf("user:%s", username)
-	err := a.client.WriteTuple(ctx, user, "admin", ADMIN_OBJECT)
+	err := a.client.WriteTuple(ctx, user, ADMIN_RELATION, ADMIN_OBJECT)
 	return err
 }
 
@@ -37,7 +38,7 @@ func (a *AdminAuthorizer) RemoveAdmin(ctx context.Context, username string) erro
 	defer span.End()
 
 	user := fmt.Sprintf("user:%s", username)
-	err := a.client.DeleteTuple(ctx, user, "admin", ADMIN_OBJECT)
+	err := a.client.DeleteTuple(ctx, user, ADMIN_RELATION, ADMIN_OBJECT)
 	return err
 }
 
@@ -46,7 +47,7 @@ func (a *AdminAuthorizer) CheckAdmin(ctx context.Context, username string) (bool
 	defer span.End()
 
 	user := fmt.Sprintf("user:%s", username)
-	allowed, err := a.client.Check(ctx, user, "admin", ADMIN_OBJECT)
+	allowed, err := a.client.Check(ctx, user, ADMIN_RELATION, ADMIN_OBJECT)
 
 	return allowed, err
 }
@@ -6,6 +6,7 @@ package authorization
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
@@ -19,6 +20,7 @@ type AdminContextKey string
 var adminContextKey AdminContextKey
 
 var ErrInvalidAuthModel = fmt.Errorf("Invalid authorization model schema")
+var ErrMissingAuthModelID = fmt.Errorf("authorization model ID missing")
 
 type Authorizer struct {
 	client AuthzClientInterface
@@ -78,6 +80,42 @@ func (a *Authorizer) ValidateModel(ctx context.Context) error {
 	return nil
 }
 
+// ReloadModel switches the active authorization model to modelID, the client validates the model
+// exists in the configured store before swapping it, checks already running keep the previous one
+func (a *Authorizer) ReloadModel(ctx context.Context, modelID string) error {
+	ctx, span := a.tracer.Start(ctx, "authorization.Authorizer.ReloadModel")
+	defer span.End()
+
+	if modelID == "" {
+		return ErrMissingAuthModelID
+	}
+
+	start := time.Now()
+
+	if err := a.client.ReloadModel(ctx, modelID); err != nil {
+		a.logger.Errorf("failed reloading authorization model %s: %s", modelID, err)
+		return err
+	}
+
+	a.logger.Infof("authorization model reloaded, active model is %s", modelID)
+
+	if m, err := a.monitor.GetAuthzModelReloadMetric(map[string]string{"model_id": modelID}); err == nil {
+		m.Observe(time.Since(start).Seconds())
+	} else {
+		a.logger.Debugf("error fetching metric: %s; keep going....", err)
+	}
+
+	return nil
+}
+
+// AuthzInfo returns the store and authorization model in use
+func (a *Authorizer) AuthzInfo(ctx context.Context) (*openfga.AuthzInfo, error) {
+	ctx, span := a.tracer.Start(ctx, "authorization.Authorizer.AuthzInfo")
+	defer span.End()
+
+	return a.client.AuthzInfo(ctx)
+}
+
 func (a *Authorizer) Admin() AdminAuthorizerInterface {
 	return &a.AdminAuthorizer
 }
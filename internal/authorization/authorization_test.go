@@ -0,0 +1,85 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_monitor.go -source=../monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_logger.go -source=../logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package authorization -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestAuthorizerReloadModelSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockMetric := NewMockMetricInterface(ctrl)
+	mockClient := NewMockAuthzClientInterface(ctrl)
+
+	modelID := "01HQ2JMD9F5RJ0S3Y9C4QX7V8T"
+
+	mockTracer.EXPECT().Start(gomock.Any(), "authorization.Authorizer.ReloadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockClient.EXPECT().ReloadModel(gomock.Any(), modelID).Times(1).Return(nil)
+	mockLogger.EXPECT().Infof(gomock.Any(), modelID).Times(1)
+	mockMonitor.EXPECT().GetAuthzModelReloadMetric(map[string]string{"model_id": modelID}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+
+	a := NewAuthorizer(mockClient, nil, mockTracer, mockMonitor, mockLogger)
+
+	if err := a.ReloadModel(context.TODO(), modelID); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+}
+
+func TestAuthorizerReloadModelFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockClient := NewMockAuthzClientInterface(ctrl)
+
+	modelID := "01HQ2JMD9F5RJ0S3Y9C4QX7V8T"
+
+	mockTracer.EXPECT().Start(gomock.Any(), "authorization.Authorizer.ReloadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockClient.EXPECT().ReloadModel(gomock.Any(), modelID).Times(1).Return(fmt.Errorf("error"))
+	mockLogger.EXPECT().Errorf(gomock.Any(), modelID, gomock.Any()).Times(1)
+	mockMonitor.EXPECT().GetAuthzModelReloadMetric(gomock.Any()).Times(0)
+
+	a := NewAuthorizer(mockClient, nil, mockTracer, mockMonitor, mockLogger)
+
+	if err := a.ReloadModel(context.TODO(), modelID); err == nil {
+		t.Fatalf("expected error not to be nil")
+	}
+}
+
+func TestAuthorizerReloadModelFailsWithoutModelID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockClient := NewMockAuthzClientInterface(ctrl)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "authorization.Authorizer.ReloadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockClient.EXPECT().ReloadModel(gomock.Any(), gomock.Any()).Times(0)
+
+	a := NewAuthorizer(mockClient, nil, mockTracer, mockMonitor, mockLogger)
+
+	if err := a.ReloadModel(context.TODO(), ""); err != ErrMissingAuthModelID {
+		t.Fatalf("expected error to be %v got %v", ErrMissingAuthModelID, err)
+	}
+}
@@ -21,6 +21,7 @@ const (
 	SCHEME_TYPE   = "scheme"
 	ROLE_TYPE     = "role"
 	GROUP_TYPE    = "group"
+	USER_TYPE     = "user"
 
 	CAN_VIEW   = "can_view"
 	CAN_EDIT   = "can_edit"
@@ -80,6 +81,15 @@ func (c IdentityConverter) MapV1(r *http.Request) []Permission {
 func (c IdentityConverter) MapV0(r *http.Request) []Permission {
 	id := chi.URLParam(r, "id")
 	var resourceId string
+
+	// sessions aren't modelled in OpenFGA, the session ID is not an identity one
+	// so sessions are checked against all the identities
+	isSession := strings.HasPrefix(r.URL.Path, "/api/v0/sessions")
+
+	if isSession {
+		id = ""
+	}
+
 	var contextualTuples []openfga.Tuple
 
 	if id == "" {
@@ -98,8 +108,35 @@ func (c IdentityConverter) MapV0(r *http.Request) []Permission {
 		*openfga.NewTuple(ADMIN_OBJECT, PRIVILEGED_RELATION, resourceId),
 	)
 
+	rel := relation(r)
+
+	// batch-get is a POST only to carry the IDs in the body, it doesn't create anything
+	if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/identities/batch-get") {
+		rel = CAN_VIEW
+	}
+
+	// batch-delete is a POST only to carry the IDs in the body
+	if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/identities/batch-delete") {
+		rel = CAN_DELETE
+	}
+
+	// moving an identity edits the groups, not the identity, those are checked by the handler
+	if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/move-group") {
+		rel = CAN_VIEW
+	}
+
+	// disabling a session changes the state of an identity rather than removing it
+	if isSession {
+		rel = CAN_EDIT
+	}
+
+	// verifying an address changes the identity, the handler restricts it further to admins
+	if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/verify-address") {
+		rel = CAN_EDIT
+	}
+
 	return []Permission{
-		{Relation: relation(r), ResourceID: resourceId, ContextualTuples: contextualTuples},
+		{Relation: rel, ResourceID: resourceId, ContextualTuples: contextualTuples},
 	}
 }
 
@@ -252,6 +289,8 @@ func (c SchemeConverter) MapV0(r *http.Request) []Permission {
 // GET /roles/{id}/entitlements/{e_id} --- not sure we need this?we need to know the type
 // DELETE /roles/{id}/entitlements/{e_id}
 // POST /roles/{id}/identities/{i_id} --- evaluate if needed, assigning identity to a role
+// GET /roles/{id}/export
+// POST /roles/import
 type RoleConverter struct{}
 
 func (c RoleConverter) TypeName() string {
@@ -366,6 +405,22 @@ func (c GroupConverter) MapV0(r *http.Request) []Permission {
 		*openfga.NewTuple(ADMIN_OBJECT, PRIVILEGED_RELATION, resourceId),
 	}
 
+	// POST /api/v0/groups/{id}/restore brings back a deleted group, same as creating it
+	if strings.HasSuffix(r.URL.Path, "/restore") && r.Method == http.MethodPost {
+		globalResource := fmt.Sprintf("%s:%s", c.TypeName(), GLOBAL_ACCESS_OBJECT_NAME)
+
+		return []Permission{
+			{
+				Relation:   CAN_CREATE,
+				ResourceID: globalResource,
+				ContextualTuples: []openfga.Tuple{
+					*openfga.NewTuple("user:*", CAN_VIEW, globalResource),
+					*openfga.NewTuple(ADMIN_OBJECT, PRIVILEGED_RELATION, globalResource),
+				},
+			},
+		}
+	}
+
 	// DELETE /api/v0/groups/{id}/entitlements/{e_id}
 	if entitlement_id != "" && r.Method == http.MethodDelete {
 		return []Permission{
@@ -445,3 +500,42 @@ func (c GroupConverter) MapV0(r *http.Request) []Permission {
 		{Relation: relation(r), ResourceID: resourceId, ContextualTuples: contextualTuples},
 	}
 }
+
+type PermissionConverter struct{}
+
+func (c PermissionConverter) MapV0(r *http.Request) []Permission {
+	// listing who holds a permission on an object requires being able to view the object
+	object, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v0/permissions/"), "/")
+
+	if object == "" {
+		return []Permission{
+			{Relation: ADMIN_RELATION, ResourceID: ADMIN_OBJECT},
+		}
+	}
+
+	return []Permission{
+		{
+			Relation:         CAN_VIEW,
+			ResourceID:       object,
+			ContextualTuples: []openfga.Tuple{*openfga.NewTuple(ADMIN_OBJECT, PRIVILEGED_RELATION, object)},
+		},
+	}
+}
+
+type CheckConverter struct{}
+
+func (c CheckConverter) MapV0(r *http.Request) []Permission {
+	// checks on behalf of arbitrary subjects disclose who can access what, reserved to the admins
+	return []Permission{
+		{Relation: ADMIN_RELATION, ResourceID: ADMIN_OBJECT},
+	}
+}
+
+type AdminConverter struct{}
+
+func (c AdminConverter) MapV0(r *http.Request) []Permission {
+	// administrative endpoints are reserved to the admins of the platform
+	return []Permission{
+		{Relation: ADMIN_RELATION, ResourceID: ADMIN_OBJECT},
+	}
+}
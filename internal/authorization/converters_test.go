@@ -55,6 +55,74 @@ func TestIdentityConverterMapV0ReturnsPermissions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "POST /api/v0/identities/batch-get",
+			input: input{method: http.MethodPost, endpoint: "/api/v0/identities/batch-get"},
+			output: []Permission{
+				{
+					Relation:   CAN_VIEW,
+					ResourceID: fmt.Sprintf("%s:%s", IDENTITY_TYPE, "__system__global"),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("user:*", CAN_VIEW, fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+					},
+				},
+			},
+		},
+		{
+			name:  "POST /api/v0/identities/batch-delete",
+			input: input{method: http.MethodPost, endpoint: "/api/v0/identities/batch-delete"},
+			output: []Permission{
+				{
+					Relation:   CAN_DELETE,
+					ResourceID: fmt.Sprintf("%s:%s", IDENTITY_TYPE, "__system__global"),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("user:*", CAN_VIEW, fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+					},
+				},
+			},
+		},
+		{
+			name:  "DELETE /api/v0/sessions/session-1234",
+			input: input{method: http.MethodDelete, endpoint: "/api/v0/sessions/session-1234", ID: "session-1234"},
+			output: []Permission{
+				{
+					Relation:   CAN_EDIT,
+					ResourceID: fmt.Sprintf("%s:%s", IDENTITY_TYPE, "__system__global"),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("user:*", CAN_VIEW, fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", IDENTITY_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+					},
+				},
+			},
+		},
+		{
+			name:  "POST /api/v0/identities/id-1234/verify-address",
+			input: input{method: http.MethodPost, endpoint: "/api/v0/identities/id-1234/verify-address", ID: "id-1234"},
+			output: []Permission{
+				{
+					Relation:   CAN_EDIT,
+					ResourceID: fmt.Sprintf("%s:%s", IDENTITY_TYPE, "id-1234"),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", IDENTITY_TYPE, "id-1234")),
+					},
+				},
+			},
+		},
+		{
+			name:  "POST /api/v0/identities/id-1234/move-group",
+			input: input{method: http.MethodPost, endpoint: "/api/v0/identities/id-1234/move-group", ID: "id-1234"},
+			output: []Permission{
+				{
+					Relation:   CAN_VIEW,
+					ResourceID: fmt.Sprintf("%s:%s", IDENTITY_TYPE, "id-1234"),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", IDENTITY_TYPE, "id-1234")),
+					},
+				},
+			},
+		},
 		{
 			name:  "GET /api/v0/identities/id-1234",
 			input: input{method: http.MethodGet, endpoint: "/api/v0/identities/id-1234", ID: "id-1234"},
@@ -827,6 +895,20 @@ func TestGroupConverterMapV0ReturnsPermissions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "POST /api/v0/groups/id-1234/restore",
+			input: input{method: http.MethodPost, endpoint: "/api/v0/groups/id-1234/restore", ID: "id-1234"},
+			output: []Permission{
+				{
+					Relation:   CAN_CREATE,
+					ResourceID: fmt.Sprintf("%s:%s", GROUP_TYPE, GLOBAL_ACCESS_OBJECT_NAME),
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("user:*", CAN_VIEW, fmt.Sprintf("%s:%s", GROUP_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+						*openfga.NewTuple("privileged:superuser", "privileged", fmt.Sprintf("%s:%s", GROUP_TYPE, GLOBAL_ACCESS_OBJECT_NAME)),
+					},
+				},
+			},
+		},
 		{
 			name:  "POST /api/v0/groups/id-1234/roles",
 			input: input{method: http.MethodPost, endpoint: "/api/v0/groups/id-1234/roles", ID: "id-1234"},
@@ -904,6 +986,58 @@ func TestGroupConverterMapV0ReturnsPermissions(t *testing.T) {
 	}
 }
 
+func TestPermissionConverterMapV0ReturnsPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		output   []Permission
+	}{
+		{
+			name:     "GET /api/v0/permissions/client:okta/can_edit/subjects",
+			endpoint: "/api/v0/permissions/client:okta/can_edit/subjects",
+			output: []Permission{
+				{
+					Relation:   CAN_VIEW,
+					ResourceID: "client:okta",
+					ContextualTuples: []openfga.Tuple{
+						*openfga.NewTuple("privileged:superuser", "privileged", "client:okta"),
+					},
+				},
+			},
+		},
+		{
+			name:     "GET /api/v0/permissions/",
+			endpoint: "/api/v0/permissions/",
+			output: []Permission{
+				{Relation: ADMIN_RELATION, ResourceID: ADMIN_OBJECT},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.endpoint, nil)
+
+			result := new(PermissionConverter).MapV0(r)
+
+			if !reflect.DeepEqual(result, test.output) {
+				t.Errorf("Map returned %v, expected %v", result, test.output)
+			}
+		})
+	}
+}
+
+func TestCheckConverterMapV0ReturnsPermissions(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/v0/check", nil)
+
+	result := new(CheckConverter).MapV0(r)
+	expected := []Permission{{Relation: ADMIN_RELATION, ResourceID: ADMIN_OBJECT}}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Map returned %v, expected %v", result, expected)
+	}
+}
+
 func TestIdentityConverterMapV1ReturnsPermissions(t *testing.T) {
 	type input struct {
 		method   string
@@ -16,6 +16,8 @@ type AuthorizerInterface interface {
 	Check(context.Context, string, string, string, ...openfga.Tuple) (bool, error)
 	FilterObjects(context.Context, string, string, string, []string) ([]string, error)
 	ValidateModel(context.Context) error
+	ReloadModel(context.Context, string) error
+	AuthzInfo(context.Context) (*openfga.AuthzInfo, error)
 	Admin() AdminAuthorizerInterface
 }
 
@@ -24,6 +26,8 @@ type AuthzClientInterface interface {
 	Check(context.Context, string, string, string, ...openfga.Tuple) (bool, error)
 	ReadModel(context.Context) (*fga.AuthorizationModel, error)
 	CompareModel(context.Context, fga.AuthorizationModel) (bool, error)
+	ReloadModel(context.Context, string) error
+	AuthzInfo(context.Context) (*openfga.AuthzInfo, error)
 	WriteTuple(ctx context.Context, user, relation, object string) error
 	DeleteTuple(ctx context.Context, user, relation, object string) error
 }
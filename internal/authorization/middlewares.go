@@ -14,6 +14,7 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 )
 
@@ -29,6 +30,9 @@ type Middleware struct {
 	SchemeConverter
 	RoleConverter
 	GroupConverter
+	PermissionConverter
+	CheckConverter
+	AdminConverter
 
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
@@ -53,6 +57,9 @@ func (mdw *Middleware) v0mapper(r *http.Request) []Permission {
 	if strings.HasPrefix(r.URL.Path, "/api/v0/identities") {
 		return mdw.IdentityConverter.MapV0(r)
 	}
+	if strings.HasPrefix(r.URL.Path, "/api/v0/sessions") {
+		return mdw.IdentityConverter.MapV0(r)
+	}
 	if strings.HasPrefix(r.URL.Path, "/api/v0/clients") {
 		return mdw.ClientConverter.MapV0(r)
 	}
@@ -71,6 +78,19 @@ func (mdw *Middleware) v0mapper(r *http.Request) []Permission {
 	if strings.HasPrefix(r.URL.Path, "/api/v0/groups") {
 		return mdw.GroupConverter.MapV0(r)
 	}
+	if strings.HasPrefix(r.URL.Path, "/api/v0/permissions") {
+		return mdw.PermissionConverter.MapV0(r)
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v0/check") {
+		return mdw.CheckConverter.MapV0(r)
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v0/admin") {
+		return mdw.AdminConverter.MapV0(r)
+	}
+	// the audit log discloses the actions of every user
+	if strings.HasPrefix(r.URL.Path, "/api/v0/audit") {
+		return mdw.AdminConverter.MapV0(r)
+	}
 
 	return []Permission{}
 }
@@ -95,20 +115,26 @@ func (mdw *Middleware) v1mapper(r *http.Request) []Permission {
 	return []Permission{}
 }
 
-func (mdw *Middleware) check(ctx context.Context, userID string, r *http.Request) (bool, error) {
+// check runs the checks mapped from r, tuples are sent as contextual tuples with each of them, as
+// are the request-scoped ones a previous middleware stored with openfga.ContextualTuplesContext
+func (mdw *Middleware) check(ctx context.Context, userID string, r *http.Request, tuples ...openfga.Tuple) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
 	defer cancel()
 
 	// TODO @shipperizer implement BatchCheck
 	for _, permission := range mdw.mapper(r) {
 		authorized, err := mdw.auth.Check(
-			ctx, userID, permission.Relation, permission.ResourceID, permission.ContextualTuples...,
+			ctx, userID, permission.Relation, permission.ResourceID, append(permission.ContextualTuples, tuples...)...,
 		)
 
 		select {
 		case <-ctx.Done():
 			return false, fmt.Errorf("issues connecting to OpenFGA server")
 		default:
+			if err == nil {
+				mdw.countDecision(permission.Relation, permission.ResourceID, authorized)
+			}
+
 			// stop at the first failed check
 			if !authorized || err != nil {
 				return false, err
@@ -119,9 +145,50 @@ func (mdw *Middleware) check(ctx context.Context, userID string, r *http.Request
 	return true, nil
 }
 
+// countDecision increments the authorization decisions counter, the object ID is reduced to its
+// type so the number of label combinations stays bounded
+func (mdw *Middleware) countDecision(relation, resourceID string, authorized bool) {
+	decision := "deny"
+
+	if authorized {
+		decision = "allow"
+	}
+
+	resourceType, _, _ := strings.Cut(resourceID, ":")
+
+	m, err := mdw.monitor.GetAuthorizationDecisionMetric(
+		map[string]string{"decision": decision, "resource_type": resourceType, "relation": relation},
+	)
+
+	if err != nil {
+		mdw.logger.Debugf("error fetching metric: %s; keep going....", err)
+		return
+	}
+
+	m.Inc()
+}
+
+// ScopedTuples turns the relations granted to an API key into contextual tuples, so checks are
+// evaluated with them without storing anything in OpenFGA, they add to the stored tuples of userID
+func ScopedTuples(userID string, principal authentication.PrincipalInterface) []openfga.Tuple {
+	servicePrincipal, ok := principal.(*authentication.ServicePrincipal)
+
+	if !ok {
+		return nil
+	}
+
+	tuples := make([]openfga.Tuple, 0, len(servicePrincipal.Relations))
+
+	for _, relation := range servicePrincipal.Relations {
+		tuples = append(tuples, *openfga.NewTuple(userID, relation.Relation, relation.Object))
+	}
+
+	return tuples
+}
+
 func (mdw *Middleware) skipRoute(r *http.Request) bool {
 	switch r.URL.Path {
-	case "/api/v0/status", "/api/v0/version", "/api/v0/metrics":
+	case "/api/v0/status", "/api/v0/status/live", "/api/v0/status/ready", "/api/v0/version", "/api/v0/metrics":
 		return true
 	case "/api/v0/auth", "/api/v0/auth/callback":
 		return true
@@ -133,9 +200,14 @@ func (mdw *Middleware) skipRoute(r *http.Request) bool {
 func (mwd *Middleware) error(message string, status int, w http.ResponseWriter) {
 	r := types.Response{
 		Status:  status,
+		Code:    types.CodeInternal,
 		Message: message,
 	}
 
+	if status == http.StatusForbidden {
+		r.Code = types.CodeForbidden
+	}
+
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(r)
 }
@@ -151,18 +223,20 @@ func (mdw *Middleware) Authorize() func(http.Handler) http.Handler {
 					return
 				}
 
+				logger := logging.FromContext(r.Context(), mdw.logger)
+
 				// if we got here then `principal` must be != nil
 				principal := authentication.PrincipalFromContext(r.Context())
 				if principal == nil {
 					// should never happen if authentication is configured correctly
-					mdw.logger.Error("principal not available in context, cannot proceed with authorization")
+					logger.Error("principal not available in context, cannot proceed with authorization")
 					mdw.error("unable to retrieve authenticated user", http.StatusInternalServerError, w)
 					return
 				}
 
 				isAdmin, err := mdw.auth.Admin().CheckAdmin(r.Context(), principal.Identifier())
 				if err != nil {
-					mdw.logger.Errorf("failed %s", err)
+					logger.Errorf("failed %s", err)
 					mdw.error("failed connecting with OpenFGA", http.StatusInternalServerError, w)
 
 					return
@@ -170,17 +244,17 @@ func (mdw *Middleware) Authorize() func(http.Handler) http.Handler {
 
 				ID := fmt.Sprintf("user:%s", principal.Identifier())
 				// TODO @shipperizer add context timeout
-				authorized, err := mdw.check(r.Context(), ID, r)
+				authorized, err := mdw.check(r.Context(), ID, r, ScopedTuples(ID, principal)...)
 
 				if err != nil {
-					mdw.logger.Errorf("failed %s", err)
+					logger.Errorf("failed %s", err)
 					mdw.error("failed connecting with OpenFGA", http.StatusInternalServerError, w)
 
 					return
 				}
 
 				if !authorized {
-					mdw.logger.Debugf("%s not authorized to perform operation", ID)
+					logger.Debugf("%s not authorized to perform operation", ID)
 					mdw.error("insufficient permissions to execute operation", http.StatusForbidden, w)
 
 					return
@@ -5,14 +5,17 @@ package authorization
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/mock/gomock"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 )
@@ -196,6 +199,7 @@ func TestMiddlewareAuthorize(t *testing.T) {
 			mockMonitor := NewMockMonitorInterface(ctrl)
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockAuthorizer := NewMockAuthorizerInterface(ctrl)
+			mockCounter := NewMockCounterInterface(ctrl)
 
 			router := chi.NewMux().With(
 				NewMiddleware(mockAuthorizer, mockMonitor, mockLogger).Authorize(),
@@ -228,6 +232,25 @@ func TestMiddlewareAuthorize(t *testing.T) {
 
 			gomock.InAnyOrder(calls)
 
+			decision := "allow"
+			// a denied check stops the evaluation, only the first decision is counted
+			counted := test.expect
+
+			if !test.output {
+				decision = "deny"
+				counted = test.expect[:1]
+			}
+
+			for _, check := range counted {
+				resourceType, _, _ := strings.Cut(check.ResourceID, ":")
+
+				mockMonitor.EXPECT().GetAuthorizationDecisionMetric(
+					map[string]string{"decision": decision, "resource_type": resourceType, "relation": check.Relation},
+				).Times(1).Return(mockCounter, nil)
+			}
+
+			mockCounter.EXPECT().Inc().Times(len(counted))
+
 			r := httptest.NewRequest(test.input.method, test.input.endpoint, nil)
 			r = r.WithContext(authentication.PrincipalContext(r.Context(), &authentication.UserPrincipal{Email: "admin"}))
 			r.Header.Set("Content-Type", "application/json")
@@ -249,6 +272,18 @@ func TestMiddlewareAuthorize(t *testing.T) {
 				t.Fatalf("expected HTTP status code 200 got %v", w.Result().StatusCode)
 			}
 
+			if !test.output {
+				rr := new(types.Response)
+
+				if err := json.NewDecoder(w.Result().Body).Decode(rr); err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				if rr.Code != types.CodeForbidden {
+					t.Fatalf("expected code to be %s got %s", types.CodeForbidden, rr.Code)
+				}
+			}
+
 		})
 	}
 }
@@ -286,6 +321,8 @@ func TestMiddlewareAuthorizeUseTokenHeader(t *testing.T) {
 
 	gomock.InAnyOrder(calls)
 
+	mockMonitor.EXPECT().GetAuthorizationDecisionMetric(gomock.Any()).Times(1).Return(nil, fmt.Errorf("metric not instantiated"))
+
 	r := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
 	r.Header.Set("Content-Type", "application/json")
 	r = r.WithContext(authentication.PrincipalContext(r.Context(), testPrincipal))
@@ -297,3 +334,69 @@ func TestMiddlewareAuthorizeUseTokenHeader(t *testing.T) {
 		t.Fatalf("expected HTTP status code 200 got %v", w.Result().StatusCode)
 	}
 }
+
+func TestMiddlewareAuthorizeServicePrincipalRelations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockAuthorizer := NewMockAuthorizerInterface(ctrl)
+
+	router := chi.NewMux().With(
+		NewMiddleware(mockAuthorizer, mockMonitor, mockLogger).Authorize(),
+	).(*chi.Mux)
+
+	new(API).RegisterEndpoints(router)
+
+	object := fmt.Sprintf("%s:%s", IDENTITY_TYPE, "__system__global")
+
+	testPrincipal := &authentication.ServicePrincipal{
+		Subject:   "apikey:ci-pipeline",
+		Relations: []authentication.ObjectRelation{{Relation: CAN_VIEW, Object: object}},
+	}
+
+	adminAuth := NewMockAdminAuthorizerInterface(ctrl)
+	adminAuth.EXPECT().CheckAdmin(gomock.Any(), "apikey:ci-pipeline").Return(false, nil)
+
+	mockAuthorizer.EXPECT().Admin().Times(1).Return(adminAuth)
+	mockAuthorizer.EXPECT().Check(gomock.Any(), "user:apikey:ci-pipeline", CAN_VIEW, object, gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, user, relation, object string, tuples ...openfga.Tuple) (bool, error) {
+			for _, tuple := range tuples {
+				if tuple == *openfga.NewTuple(user, relation, object) {
+					return true, nil
+				}
+			}
+
+			t.Errorf("expected scoped relation to be passed as contextual tuple, got %v", tuples)
+
+			return false, nil
+		},
+	)
+
+	mockCounter := NewMockCounterInterface(ctrl)
+	mockCounter.EXPECT().Inc().Times(1)
+	mockMonitor.EXPECT().GetAuthorizationDecisionMetric(
+		map[string]string{"decision": "allow", "resource_type": IDENTITY_TYPE, "relation": CAN_VIEW},
+	).Times(1).Return(mockCounter, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+	r = r.WithContext(authentication.PrincipalContext(r.Context(), testPrincipal))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", w.Result().StatusCode)
+	}
+}
+
+func TestMiddlewareMapperAuditIsAdminOnly(t *testing.T) {
+	mdw := new(Middleware)
+
+	result := mdw.mapper(httptest.NewRequest(http.MethodGet, "/api/v0/audit?actor=joe", nil))
+
+	if len(result) != 1 || result[0].Relation != ADMIN_RELATION || result[0].ResourceID != ADMIN_OBJECT {
+		t.Fatalf("expected the admin permission got %v", result)
+	}
+}
@@ -5,11 +5,19 @@ package config
 
 // EnvSpec is the basic environment configuration setup needed for the app to start
 type EnvSpec struct {
-	OtelGRPCEndpoint string `envconfig:"otel_grpc_endpoint"`
-	OtelHTTPEndpoint string `envconfig:"otel_http_endpoint"`
-	TracingEnabled   bool   `envconfig:"tracing_enabled" default:"true"`
+	OtelGRPCEndpoint   string  `envconfig:"otel_grpc_endpoint"`
+	OtelHTTPEndpoint   string  `envconfig:"otel_http_endpoint"`
+	TracingEnabled     bool    `envconfig:"tracing_enabled" default:"true"`
+	TracingSampleRatio float64 `envconfig:"tracing_sample_ratio" default:"1.0"`
 
-	LogLevel string `envconfig:"log_level" default:"error"`
+	LogLevel     string   `envconfig:"log_level" default:"error"`
+	LogFormat    string   `envconfig:"log_format" default:"json"`
+	LogRedactPII bool     `envconfig:"log_redact_pii" default:"false"`
+	PIIKeys      []string `envconfig:"pii_keys" default:"email,phone,name"`
+
+	LogSamplingEnabled         bool `envconfig:"log_sampling_enabled" default:"false"`
+	LogSamplingIntervalSeconds int  `envconfig:"log_sampling_interval_seconds" default:"60"`
+	LogSamplingThreshold       int  `envconfig:"log_sampling_threshold" default:"10"`
 
 	Port        int    `envconfig:"port" default:"8080"`
 	ContextPath string `envconfig:"context_path" default:"/"`
@@ -24,6 +32,16 @@ type EnvSpec struct {
 	HydraAdminURL       string `envconfig:"hydra_admin_url" required:"true"`
 	OathkeeperPublicURL string `envconfig:"oathkeeper_public_url" required:"true"`
 
+	// upstream call timeouts, a zero or negative value falls back to the client defaults
+	KratosConnectTimeoutSeconds  int `envconfig:"kratos_connect_timeout_seconds" default:"5"`
+	KratosResponseTimeoutSeconds int `envconfig:"kratos_response_timeout_seconds" default:"10"`
+	HydraConnectTimeoutSeconds   int `envconfig:"hydra_connect_timeout_seconds" default:"5"`
+	HydraResponseTimeoutSeconds  int `envconfig:"hydra_response_timeout_seconds" default:"10"`
+
+	// static headers attached to every upstream call, comma separated key=value pairs
+	KratosHeaders []string `envconfig:"kratos_headers"`
+	HydraHeaders  []string `envconfig:"hydra_headers"`
+
 	AuthenticationEnabled       bool     `envconfig:"authentication_enabled" default:"false" validate:"required"`
 	OIDCIssuer                  string   `envconfig:"oidc_issuer" validate:"required"`
 	OAuth2ClientId              string   `envconfig:"oauth2_client_id" validate:"required"`
@@ -35,6 +53,17 @@ type EnvSpec struct {
 
 	OAuth2AuthCookiesEncryptionKey  string `envconfig:"oauth2_auth_cookies_encryption_key" required:"true" validate:"required,min=32,max=32"`
 	AccessTokenVerificationStrategy string `envconfig:"access_token_verification_strategy" default:"jwks" validate:"oneof=jwks userinfo"`
+	APIKeysFile                     string `envconfig:"api_keys_file"`
+
+	// <client id>@<issuer> entries of the identity providers federated on top of OIDC_ISSUER
+	OAuth2TrustedIssuers []string `envconfig:"oauth2_trusted_issuers"`
+
+	OAuth2EmailClaim  string `envconfig:"oauth2_email_claim" default:"email"`
+	OAuth2GroupsClaim string `envconfig:"oauth2_groups_claim"`
+	OAuth2RolesClaim  string `envconfig:"oauth2_roles_claim"`
+
+	// clock skew tolerated on the exp and nbf claims of the tokens checked by the jwks strategy
+	OAuth2JWTLeewaySeconds int `envconfig:"oauth2_jwt_leeway_seconds" default:"30"`
 
 	IDPConfigMapName      string `envconfig:"idp_configmap_name" required:"true"`
 	IDPConfigMapNamespace string `envconfig:"idp_configmap_namespace" required:"true"`
@@ -55,12 +84,88 @@ type EnvSpec struct {
 	AuthorizationEnabled     bool `envconfig:"authorization_enabled" default:"false"`
 	PayloadValidationEnabled bool `envconfig:"payload_validation_enabled" default:"true"`
 
+	StatusRequiredDependencies []string `envconfig:"status_required_dependencies" default:"kratos,hydra,openfga"`
+
+	RateLimitRequestsPerSecond float64 `envconfig:"rate_limit_requests_per_second" default:"0"`
+	RateLimitBurst             int     `envconfig:"rate_limit_burst" default:"20"`
+
+	// no allowed origins means same origin only, no CORS headers are sent
+	CORSAllowedOrigins   []string `envconfig:"cors_allowed_origins"`
+	CORSAllowedMethods   []string `envconfig:"cors_allowed_methods" default:"HEAD,GET,POST,PUT,PATCH,DELETE"`
+	CORSAllowedHeaders   []string `envconfig:"cors_allowed_headers" default:"Accept,Authorization,Content-Type,If-Match,If-None-Match,X-Request-ID"`
+	CORSAllowCredentials bool     `envconfig:"cors_allow_credentials" default:"false"`
+
+	GzipEnabled      bool `envconfig:"gzip_enabled" default:"false"`
+	GzipMinSizeBytes int  `envconfig:"gzip_min_size_bytes" default:"1024"`
+
+	// 0 disables the limit, the CSV import keeps its own bigger cap
+	RequestBodyMaxBytes int64 `envconfig:"request_body_max_bytes" default:"262144"`
+
 	OpenFGAWorkersTotal int `envconfig:"openfga_workers_total" default:"150"`
 
-	MailHost               string `envconfig:"MAIL_HOST" required:"true"`
-	MailPort               int    `envconfig:"MAIL_PORT" required:"true"`
-	MailUsername           string `envconfig:"MAIL_USERNAME"`
-	MailPassword           string `envconfig:"MAIL_PASSWORD"`
-	MailFromAddress        string `envconfig:"MAIL_FROM_ADDRESS" required:"true"`
-	MailSendTimeoutSeconds int    `envconfig:"MAIL_SEND_TIMEOUT_SECONDS" default:"15"`
+	OpenFGACheckCacheEnabled    bool `envconfig:"openfga_check_cache_enabled" default:"false"`
+	OpenFGACheckCacheSize       int  `envconfig:"openfga_check_cache_size" default:"10000"`
+	OpenFGACheckCacheTTLSeconds int  `envconfig:"openfga_check_cache_ttl_seconds" default:"30"`
+
+	OpenFGAWriteBatchEnabled  bool `envconfig:"openfga_write_batch_enabled" default:"false"`
+	OpenFGAWriteBatchWindowMS int  `envconfig:"openfga_write_batch_window_ms" default:"20"`
+	OpenFGAWriteBatchMaxSize  int  `envconfig:"openfga_write_batch_max_size" default:"50"`
+
+	OpenFGARetryMaxAttempts int `envconfig:"openfga_retry_max_attempts" default:"3"`
+	OpenFGARetryBaseDelayMS int `envconfig:"openfga_retry_base_delay_ms" default:"50"`
+	OpenFGARetryJitterMS    int `envconfig:"openfga_retry_jitter_ms" default:"50"`
+
+	// 0 keeps the OpenFGA default page size
+	OpenFGAReadPageSize int `envconfig:"openfga_read_page_size" default:"0"`
+
+	IdentitySearchFields   []string `envconfig:"identity_search_fields" default:"email,name"`
+	IdentitySearchMaxPages int      `envconfig:"identity_search_max_pages" default:"10"`
+
+	IdentityTraitsEmail        string `envconfig:"identity_traits_email" default:"email"`
+	IdentityTraitsName         string `envconfig:"identity_traits_name" default:"name"`
+	IdentityTraitsFirstName    string `envconfig:"identity_traits_first_name"`
+	IdentityTraitsLastName     string `envconfig:"identity_traits_last_name"`
+	IdentityTraitsNameStrategy string `envconfig:"identity_traits_name_strategy" default:"split"`
+
+	IdentitySchemaCacheTTLSeconds int `envconfig:"identity_schema_cache_ttl_seconds" default:"60"`
+
+	// empty allows creating identities against every schema kratos offers
+	IdentityAllowedSchemas []string `envconfig:"identity_allowed_schemas"`
+
+	EnableGroups       bool `envconfig:"enable_groups" default:"true"`
+	EnableRoles        bool `envconfig:"enable_roles" default:"true"`
+	EnableEntitlements bool `envconfig:"enable_entitlements" default:"true"`
+
+	GroupsSoftDeleteEnabled       bool   `envconfig:"groups_soft_delete_enabled" default:"false"`
+	GroupsTrashConfigMapName      string `envconfig:"groups_trash_configmap_name"`
+	GroupsTrashConfigMapNamespace string `envconfig:"groups_trash_configmap_namespace"`
+	GroupsPatchRollback           bool   `envconfig:"groups_patch_rollback" default:"false"`
+
+	DefaultPageSize int64 `envconfig:"default_page_size" default:"100"`
+	MaxPageSize     int64 `envconfig:"max_page_size" default:"500"`
+
+	StatsCacheTTLSeconds int `envconfig:"stats_cache_ttl_seconds" default:"30"`
+
+	// events kept in memory for GET /api/v0/audit, 0 disables the endpoint
+	AuditLogSize int `envconfig:"audit_log_size" default:"1000"`
+
+	PaginationCursorsEnabled       bool `envconfig:"pagination_cursors_enabled" default:"false"`
+	PaginationCursorTTLSeconds     int  `envconfig:"pagination_cursor_ttl_seconds" default:"600"`
+	PaginationCursorThresholdBytes int  `envconfig:"pagination_cursor_threshold_bytes" default:"4096"`
+	PaginationCursorMaxEntries     int  `envconfig:"pagination_cursor_max_entries" default:"10000"`
+
+	MailHost                   string `envconfig:"MAIL_HOST" required:"true"`
+	MailPort                   int    `envconfig:"MAIL_PORT" required:"true"`
+	MailUsername               string `envconfig:"MAIL_USERNAME"`
+	MailPassword               string `envconfig:"MAIL_PASSWORD"`
+	MailFromAddress            string `envconfig:"MAIL_FROM_ADDRESS" required:"true"`
+	MailSendTimeoutSeconds     int    `envconfig:"MAIL_SEND_TIMEOUT_SECONDS" default:"15"`
+	MailTemplatesDir           string `envconfig:"MAIL_TEMPLATES_DIR"`
+	MailTestRateLimitPerMinute int    `envconfig:"MAIL_TEST_RATE_LIMIT_PER_MINUTE" default:"5"`
+
+	WebhookURL            string `envconfig:"webhook_url"`
+	WebhookSecret         string `envconfig:"webhook_secret"`
+	WebhookMaxRetries     int    `envconfig:"webhook_max_retries" default:"5"`
+	WebhookQueueSize      int    `envconfig:"webhook_queue_size" default:"100"`
+	WebhookTimeoutSeconds int    `envconfig:"webhook_timeout_seconds" default:"10"`
 }
@@ -0,0 +1,112 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	logLevels  = []string{"debug", "info", "warn", "warning", "error"}
+	logFormats = []string{"json", "console"}
+)
+
+// maxJWTLeewaySeconds keeps in sync with authentication.MaxLeeway
+const maxJWTLeewaySeconds = 300
+
+// Validate checks the values envconfig can't, every problem found is reported in the returned
+// error, one per line, so a misconfiguration can be fixed in one go
+func (s *EnvSpec) Validate() error {
+	errs := make([]error, 0)
+
+	for _, u := range []struct {
+		name  string
+		value string
+	}{
+		{"KRATOS_PUBLIC_URL", s.KratosPublicURL},
+		{"KRATOS_ADMIN_URL", s.KratosAdminURL},
+		{"HYDRA_ADMIN_URL", s.HydraAdminURL},
+		{"OATHKEEPER_PUBLIC_URL", s.OathkeeperPublicURL},
+	} {
+		if err := validateURL(u.name, u.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.Port < 1 || s.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", s.Port))
+	}
+
+	if !slices.Contains(logLevels, strings.ToLower(s.LogLevel)) {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of %s, got %q", strings.Join(logLevels, ", "), s.LogLevel))
+	}
+
+	if !slices.Contains(logFormats, strings.ToLower(s.LogFormat)) {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be one of %s, got %q", strings.Join(logFormats, ", "), s.LogFormat))
+	}
+
+	if s.OAuth2JWTLeewaySeconds < 0 || s.OAuth2JWTLeewaySeconds > maxJWTLeewaySeconds {
+		errs = append(errs, fmt.Errorf("OAUTH2_JWT_LEEWAY_SECONDS must be between 0 and %d, got %d", maxJWTLeewaySeconds, s.OAuth2JWTLeewaySeconds))
+	}
+
+	if s.GroupsSoftDeleteEnabled && (s.GroupsTrashConfigMapName == "" || s.GroupsTrashConfigMapNamespace == "") {
+		errs = append(errs, errors.New("GROUPS_TRASH_CONFIGMAP_NAME and GROUPS_TRASH_CONFIGMAP_NAMESPACE must be set when GROUPS_SOFT_DELETE_ENABLED is true"))
+	}
+
+	if s.WebhookURL != "" && s.WebhookSecret == "" {
+		errs = append(errs, errors.New("WEBHOOK_SECRET must be set when WEBHOOK_URL is set, payloads can't be signed with an empty key"))
+	}
+
+	for _, e := range []struct {
+		name  string
+		value string
+	}{
+		{"OTEL_GRPC_ENDPOINT", s.OtelGRPCEndpoint},
+		{"OTEL_HTTP_ENDPOINT", s.OtelHTTPEndpoint},
+	} {
+		if e.value == "" {
+			continue
+		}
+
+		if !isHostPort(e.value) {
+			errs = append(errs, fmt.Errorf("%s must be a host:port address such as jaeger:4317, got %q", e.name, e.value))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateURL checks value is an absolute URL, the upstream clients need both the scheme and the host
+func validateURL(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s must be set", name)
+	}
+
+	u, err := url.Parse(value)
+
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute URL such as http://host:port, got %q", name, value)
+	}
+
+	return nil
+}
+
+// isHostPort checks value is a host:port address with a numeric port, the exporters take no scheme
+func isHostPort(value string) bool {
+	host, port, err := net.SplitHostPort(value)
+
+	if err != nil || host == "" {
+		return false
+	}
+
+	_, err = strconv.ParseUint(port, 10, 16)
+
+	return err == nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func validSpec() *EnvSpec {
+	s := new(EnvSpec)
+
+	s.KratosPublicURL = "http://kratos:4433"
+	s.KratosAdminURL = "http://kratos:4434"
+	s.HydraAdminURL = "http://hydra:4445"
+	s.OathkeeperPublicURL = "http://oathkeeper:4455"
+	s.Port = 8080
+	s.LogLevel = "error"
+	s.LogFormat = "json"
+	s.TracingEnabled = true
+	s.OtelGRPCEndpoint = "jaeger:4317"
+
+	return s
+}
+
+func TestEnvSpecValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		change   func(*EnvSpec)
+		problems []string
+	}{
+		{
+			name:   "valid",
+			change: func(s *EnvSpec) {},
+		},
+		{
+			name:   "tracing disabled without endpoints",
+			change: func(s *EnvSpec) { s.TracingEnabled = false; s.OtelGRPCEndpoint = "" },
+		},
+		{
+			name:   "upper case log level",
+			change: func(s *EnvSpec) { s.LogLevel = "DEBUG" },
+		},
+		{
+			name:   "console log format",
+			change: func(s *EnvSpec) { s.LogFormat = "Console" },
+		},
+		{
+			name:     "unknown log format",
+			change:   func(s *EnvSpec) { s.LogFormat = "logfmt" },
+			problems: []string{"LOG_FORMAT must be one of json, console"},
+		},
+		{
+			name:     "jwt leeway above the cap",
+			change:   func(s *EnvSpec) { s.OAuth2JWTLeewaySeconds = 600 },
+			problems: []string{"OAUTH2_JWT_LEEWAY_SECONDS must be between 0 and 300"},
+		},
+		{
+			name:     "empty kratos url",
+			change:   func(s *EnvSpec) { s.KratosAdminURL = "" },
+			problems: []string{"KRATOS_ADMIN_URL must be set"},
+		},
+		{
+			name:     "relative hydra url",
+			change:   func(s *EnvSpec) { s.HydraAdminURL = "hydra:4445/admin" },
+			problems: []string{"HYDRA_ADMIN_URL must be an absolute URL"},
+		},
+		{
+			name:   "tracing without endpoint exports to stdout",
+			change: func(s *EnvSpec) { s.OtelGRPCEndpoint = "" },
+		},
+		{
+			name: "groups soft delete with trash",
+			change: func(s *EnvSpec) {
+				s.GroupsSoftDeleteEnabled = true
+				s.GroupsTrashConfigMapName = "deleted-groups"
+				s.GroupsTrashConfigMapNamespace = "default"
+			},
+		},
+		{
+			name:     "groups soft delete without trash namespace",
+			change:   func(s *EnvSpec) { s.GroupsSoftDeleteEnabled = true; s.GroupsTrashConfigMapName = "deleted-groups" },
+			problems: []string{"GROUPS_TRASH_CONFIGMAP_NAME and GROUPS_TRASH_CONFIGMAP_NAMESPACE must be set"},
+		},
+		{
+			name:   "webhook with secret",
+			change: func(s *EnvSpec) { s.WebhookURL = "http://hooks:8080"; s.WebhookSecret = "secret" },
+		},
+		{
+			name:     "webhook without secret",
+			change:   func(s *EnvSpec) { s.WebhookURL = "http://hooks:8080" },
+			problems: []string{"WEBHOOK_SECRET must be set when WEBHOOK_URL is set"},
+		},
+		{
+			name: "every problem is reported",
+			change: func(s *EnvSpec) {
+				s.KratosPublicURL = ""
+				s.Port = 70000
+				s.LogLevel = "verbose"
+				s.OtelGRPCEndpoint = "http://jaeger"
+			},
+			problems: []string{
+				"KRATOS_PUBLIC_URL must be set",
+				"PORT must be between 1 and 65535",
+				"LOG_LEVEL must be one of",
+				"OTEL_GRPC_ENDPOINT must be a host:port address",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := validSpec()
+			tt.change(s)
+
+			err := s.Validate()
+
+			if len(tt.problems) == 0 {
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected errors %v got nil", tt.problems)
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+
+			if len(lines) != len(tt.problems) {
+				t.Fatalf("expected %d problems got %d: %v", len(tt.problems), len(lines), err)
+			}
+
+			for i, problem := range tt.problems {
+				if !strings.HasPrefix(lines[i], problem) {
+					t.Errorf("expected problem %q got %q", problem, lines[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package events
+
+import "time"
+
+type Config struct {
+	// URL is the webhook endpoint, webhooks are disabled when empty
+	URL string
+	// Secret is the key used to sign the payloads
+	Secret     string
+	MaxRetries int
+	QueueSize  int
+	Timeout    time.Duration
+}
+
+// Enabled reports if a webhook endpoint is configured
+func (c *Config) Enabled() bool {
+	return c != nil && c.URL != ""
+}
+
+func NewConfig(url, secret string, maxRetries, queueSize, timeout int) *Config {
+	c := new(Config)
+
+	c.URL = url
+	c.Secret = secret
+	c.MaxRetries = maxRetries
+	c.QueueSize = queueSize
+	c.Timeout = time.Duration(timeout) * time.Second
+
+	return c
+}
@@ -0,0 +1,12 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package events
+
+import "context"
+
+// DispatcherInterface notifies external systems of changes, delivery happens in the background
+// and never affects the outcome of the operation that triggered it
+type DispatcherInterface interface {
+	Dispatch(ctx context.Context, eventType, groupID string, identities ...string)
+}
@@ -0,0 +1,298 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+)
+
+const (
+	GroupIdentitiesAssigned = "group.identities.assigned"
+	GroupIdentitiesRemoved  = "group.identities.removed"
+
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body, prefixed with sha256=
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+
+	defaultBackoff = 500 * time.Millisecond
+
+	// deliveryWorkers is the number of goroutines draining the queue, deliveries block on the
+	// webhook and its retries so they are kept off the shared worker pool
+	deliveryWorkers = 2
+
+	// reasons an event is dropped, reported by the webhook_dropped_events_total metric
+	dropQueueFull = "queue_full"
+	dropFailed    = "delivery_failed"
+	dropShutdown  = "shutdown"
+)
+
+// Event is the JSON payload sent to the webhook
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Timestamp  time.Time `json:"timestamp"`
+	RequestID  string    `json:"request_id,omitempty"`
+	GroupID    string    `json:"group_id"`
+	Identities []string  `json:"identities"`
+}
+
+// Sign returns the value of the SignatureHeader for the payload
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+// WebhookDispatcher POSTs signed events to the configured URL, deliveries are queued and run on
+// the dispatcher's own goroutines, failures are retried with exponential backoff, events are
+// dropped once QueueSize deliveries are pending
+type WebhookDispatcher struct {
+	config *Config
+	client *http.Client
+
+	queue   chan delivery
+	backoff time.Duration
+
+	// mu guards closed, Dispatch must not send on the queue once Shutdown closed it
+	mu     sync.RWMutex
+	closed bool
+
+	// stopped is cancelled when Shutdown gives up draining the queue, it aborts the pending
+	// deliveries and retries
+	stopped context.Context
+	stop    context.CancelFunc
+	workers sync.WaitGroup
+
+	tracer  trace.Tracer
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (d *WebhookDispatcher) Dispatch(ctx context.Context, eventType, groupID string, identities ...string) {
+	e := Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Timestamp:  time.Now().UTC(),
+		RequestID:  middleware.GetReqID(ctx),
+		GroupID:    groupID,
+		Identities: identities,
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		d.logger.Errorf("webhook dispatcher is shut down, dropping event %s %s on %s", e.ID, eventType, groupID)
+		d.dropped(dropShutdown)
+
+		return
+	}
+
+	// the request context is cancelled as soon as the response is written
+	select {
+	case d.queue <- delivery{ctx: context.WithoutCancel(ctx), event: e}:
+	default:
+		d.logger.Errorf("webhook queue is full, dropping event %s %s on %s", e.ID, eventType, groupID)
+		d.dropped(dropQueueFull)
+	}
+}
+
+// Shutdown stops accepting events and waits for the queued ones to be delivered, once ctx is done
+// the pending deliveries are aborted and the remaining events dropped, ctx error is then returned
+func (d *WebhookDispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.stop()
+		<-done
+
+		return ctx.Err()
+	}
+}
+
+// work delivers the queued events one at a time until the queue is closed
+func (d *WebhookDispatcher) work() {
+	defer d.workers.Done()
+
+	for item := range d.queue {
+		if d.stopped.Err() != nil {
+			d.logger.Errorf("webhook dispatcher is shut down, dropping event %s", item.event.ID)
+			d.dropped(dropShutdown)
+
+			continue
+		}
+
+		d.deliver(item.ctx, item.event)
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, e Event) {
+	ctx, span := d.tracer.Start(ctx, "events.WebhookDispatcher.deliver")
+	defer span.End()
+
+	// abort the delivery if Shutdown runs out of time
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(d.stopped, cancel)()
+
+	payload, err := json.Marshal(e)
+
+	if err != nil {
+		d.logger.Errorf("failed to marshal webhook event %s: %s", e.ID, err)
+		return
+	}
+
+	backoff := d.backoff
+
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(ctx, e.Type, payload)
+
+		if err == nil {
+			return
+		}
+
+		if !retry || attempt >= d.config.MaxRetries {
+			d.logger.Errorf("failed to deliver webhook event %s after %d attempts: %s", e.ID, attempt+1, err)
+			d.dropped(dropFailed)
+
+			return
+		}
+
+		d.logger.Debugf("webhook event %s delivery failed, retrying in %s: %s", e.ID, backoff, err)
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			d.logger.Errorf("webhook dispatcher is shut down, dropping event %s after %d attempts", e.ID, attempt+1)
+			d.dropped(dropShutdown)
+
+			return
+		}
+
+		backoff *= 2
+	}
+}
+
+// dropped counts an event that will never be delivered
+func (d *WebhookDispatcher) dropped(reason string) {
+	m, err := d.monitor.GetWebhookDroppedEventsMetric(map[string]string{"reason": reason})
+
+	if err != nil {
+		d.logger.Debugf("error fetching metric: %s; keep going....", err)
+		return
+	}
+
+	m.Inc()
+}
+
+// post sends the payload once, the returned bool reports if a failed delivery is worth retrying
+func (d *WebhookDispatcher) post(ctx context.Context, eventType string, payload []byte) (bool, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, d.config.URL, bytes.NewReader(payload))
+
+	if err != nil {
+		return false, err
+	}
+
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(EventHeader, eventType)
+	r.Header.Set(SignatureHeader, Sign(d.config.Secret, payload))
+
+	res, err := d.client.Do(r)
+
+	if err != nil {
+		return true, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return false, nil
+	}
+
+	retry := res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusTooManyRequests
+
+	return retry, fmt.Errorf("webhook responded with status %d", res.StatusCode)
+}
+
+// delivery is a queued event, ctx carries the values of the request that triggered it
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+func NewWebhookDispatcher(config *Config, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *WebhookDispatcher {
+	d := newWebhookDispatcher(config, tracer, monitor, logger)
+
+	d.workers.Add(deliveryWorkers)
+
+	for i := 0; i < deliveryWorkers; i++ {
+		go d.work()
+	}
+
+	return d
+}
+
+// newWebhookDispatcher builds a dispatcher without starting the goroutines draining the queue
+func newWebhookDispatcher(config *Config, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *WebhookDispatcher {
+	d := new(WebhookDispatcher)
+
+	d.config = config
+	d.client = &http.Client{Timeout: config.Timeout}
+	d.queue = make(chan delivery, config.QueueSize)
+	d.backoff = defaultBackoff
+	d.stopped, d.stop = context.WithCancel(context.Background())
+
+	d.tracer = tracer
+	d.monitor = monitor
+	d.logger = logger
+
+	return d
+}
+
+// NoopDispatcher discards every event, used when no webhook is configured
+type NoopDispatcher struct{}
+
+func (d *NoopDispatcher) Dispatch(ctx context.Context, eventType, groupID string, identities ...string) {
+}
+
+func NewNoopDispatcher() *NoopDispatcher {
+	return new(NoopDispatcher)
+}
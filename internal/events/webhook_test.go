@@ -0,0 +1,250 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package events -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package events -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestWebhookDispatcherDelivers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	received := make(chan *Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if signature := r.Header.Get(SignatureHeader); signature != Sign("secret", body) {
+			t.Errorf("expected signature to be %s got %s", Sign("secret", body), signature)
+		}
+
+		if eventType := r.Header.Get(EventHeader); eventType != GroupIdentitiesAssigned {
+			t.Errorf("expected event header to be %s got %s", GroupIdentitiesAssigned, eventType)
+		}
+
+		e := new(Event)
+		if err := json.Unmarshal(body, e); err != nil {
+			t.Errorf("expected error to be nil got %v", err)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		received <- e
+	}))
+	defer server.Close()
+
+	mockTracer.EXPECT().Start(gomock.Any(), "events.WebhookDispatcher.deliver").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	d := NewWebhookDispatcher(NewConfig(server.URL, "secret", 3, 10, 5), mockTracer, mockMonitor, mockLogger)
+	d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe", "james")
+
+	select {
+	case e := <-received:
+		if e.GroupID != "administrator" || len(e.Identities) != 2 {
+			t.Errorf("unexpected event payload %v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected event to be delivered")
+	}
+}
+
+func TestWebhookDispatcherRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		fails    bool
+	}{
+		{
+			name:     "succeeds after server errors",
+			statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			attempts: 3,
+		},
+		{
+			name:     "gives up after max retries",
+			statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			attempts: 3,
+			fails:    true,
+		},
+		{
+			name:     "client errors are not retried",
+			statuses: []int{http.StatusBadRequest},
+			attempts: 1,
+			fails:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statuses[attempts.Add(1)-1])
+			}))
+			defer server.Close()
+
+			d := newWebhookDispatcher(NewConfig(server.URL, "secret", 2, 10, 5), mockTracer, mockMonitor, mockLogger)
+			d.backoff = 0
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.fails {
+				mockCounter := monitoring.NewMockCounterInterface(ctrl)
+				mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+				mockMonitor.EXPECT().GetWebhookDroppedEventsMetric(map[string]string{"reason": "delivery_failed"}).Times(1).Return(mockCounter, nil)
+				mockCounter.EXPECT().Inc().Times(1)
+			}
+
+			d.deliver(context.Background(), Event{ID: "event", Type: GroupIdentitiesRemoved, GroupID: "administrator", Identities: []string{"joe"}})
+
+			if attempts.Load() != test.attempts {
+				t.Errorf("expected %d attempts got %d", test.attempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestWebhookDispatcherDropsEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+
+	// nothing drains the queue, the second event exceeds the queue size
+	d := newWebhookDispatcher(NewConfig("http://localhost", "secret", 2, 1, 5), mockTracer, mockMonitor, mockLogger)
+
+	mockCounter := monitoring.NewMockCounterInterface(ctrl)
+
+	mockLogger.EXPECT().Errorf("webhook queue is full, dropping event %s %s on %s", gomock.Any()).Times(1)
+	mockMonitor.EXPECT().GetWebhookDroppedEventsMetric(map[string]string{"reason": "queue_full"}).Times(1).Return(mockCounter, nil)
+	mockCounter.EXPECT().Inc().Times(1)
+
+	d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe")
+	d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe")
+
+	if len(d.queue) != 1 {
+		t.Errorf("expected 1 pending delivery got %d", len(d.queue))
+	}
+}
+
+func TestWebhookDispatcherShutdownDrainsQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCounter := monitoring.NewMockCounterInterface(ctrl)
+
+	var delivered atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	d := NewWebhookDispatcher(NewConfig(server.URL, "secret", 2, 10, 5), mockTracer, mockMonitor, mockLogger)
+
+	for i := 0; i < 5; i++ {
+		d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe")
+	}
+
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if delivered.Load() != 5 {
+		t.Errorf("expected 5 deliveries got %d", delivered.Load())
+	}
+
+	// events dispatched after the shutdown are dropped
+	mockLogger.EXPECT().Errorf("webhook dispatcher is shut down, dropping event %s %s on %s", gomock.Any()).Times(1)
+	mockMonitor.EXPECT().GetWebhookDroppedEventsMetric(map[string]string{"reason": "shutdown"}).Times(1).Return(mockCounter, nil)
+	mockCounter.EXPECT().Inc().Times(1)
+
+	d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe")
+}
+
+func TestWebhookDispatcherShutdownDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCounter := monitoring.NewMockCounterInterface(ctrl)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockLogger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+	// both workers are busy retrying the first 2 events, the third is still queued at the deadline
+	mockMonitor.EXPECT().GetWebhookDroppedEventsMetric(map[string]string{"reason": "shutdown"}).Times(3).Return(mockCounter, nil)
+	mockCounter.EXPECT().Inc().Times(3)
+
+	d := NewWebhookDispatcher(NewConfig(server.URL, "secret", 5, 10, 5), mockTracer, mockMonitor, mockLogger)
+
+	// the retries would take minutes with the default backoff
+	for i := 0; i < 3; i++ {
+		d.Dispatch(context.Background(), GroupIdentitiesAssigned, "administrator", "joe")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	if err := d.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected error to be %v got %v", context.DeadlineExceeded, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected shutdown to give up at the deadline, took %s", elapsed)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	expected := "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"
+
+	if signature := Sign("secret", []byte("{}")); signature != expected {
+		t.Errorf("expected signature to be %s got %s", expected, signature)
+	}
+}
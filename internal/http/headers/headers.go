@@ -0,0 +1,100 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package headers attaches static headers to the requests sent to the upstream services, e.g. the
+// credentials expected by an auth proxy sitting in front of them
+package headers
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// separators can't be part of a header name, see RFC 9110 section 5.6.2
+const separators = `"(),/:;<=>?@[\]{}`
+
+// Parse turns a list of key=value pairs into headers, a key can be repeated to set several values
+func Parse(pairs []string) (http.Header, error) {
+	h := make(http.Header)
+
+	for i, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if !found || !validName(key) {
+			// the pair is left out of the error, values are often credentials
+			return nil, fmt.Errorf("invalid header at position %d, expected a key=value pair", i+1)
+		}
+
+		// line breaks would let a value smuggle further headers in
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return nil, fmt.Errorf("invalid value for header %s", key)
+		}
+
+		h.Add(key, value)
+	}
+
+	return h, nil
+}
+
+func validName(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for _, c := range key {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(separators, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Transport sets Headers on every outgoing request, headers already set on the request are kept
+// so the ones added by the other transports of the chain, e.g. the request ID, are never overridden
+type Transport struct {
+	Base    http.RoundTripper
+	Headers http.Header
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if len(t.Headers) == 0 {
+		return t.base().RoundTrip(r)
+	}
+
+	// RoundTrippers must not modify the request they are given
+	r = r.Clone(r.Context())
+
+	for key, values := range t.Headers {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+
+		if _, ok := r.Header[key]; ok {
+			continue
+		}
+
+		r.Header[key] = values
+	}
+
+	return t.base().RoundTrip(r)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+
+	return t.Base
+}
+
+// NewTransport wraps base so that outgoing requests carry headers, base defaults to http.DefaultTransport
+func NewTransport(base http.RoundTripper, headers http.Header) *Transport {
+	t := new(Transport)
+	t.Base = base
+	t.Headers = headers
+
+	return t
+}
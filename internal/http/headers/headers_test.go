@@ -0,0 +1,110 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package headers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		pairs    []string
+		expected http.Header
+		err      bool
+	}{
+		{
+			name:     "no pairs",
+			expected: http.Header{},
+		},
+		{
+			name:     "pairs",
+			pairs:    []string{"x-proxy-token=secret", " X-Team = identity ", "X-Team=platform", "X-Empty="},
+			expected: http.Header{"X-Proxy-Token": {"secret"}, "X-Team": {"identity", "platform"}, "X-Empty": {""}},
+		},
+		{
+			name:     "value with equal sign",
+			pairs:    []string{"Authorization=Basic dXNlcjpwYXNz=="},
+			expected: http.Header{"Authorization": {"Basic dXNlcjpwYXNz=="}},
+		},
+		{
+			name:  "missing separator",
+			pairs: []string{"X-Proxy-Token"},
+			err:   true,
+		},
+		{
+			name:  "empty key",
+			pairs: []string{"=secret"},
+			err:   true,
+		},
+		{
+			name:  "invalid key",
+			pairs: []string{"X Proxy=secret"},
+			err:   true,
+		},
+		{
+			name:  "line break in value",
+			pairs: []string{"X-Proxy-Token=secret\r\nX-Admin: true"},
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := Parse(test.pairs)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not to be nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(h, test.expected) {
+				t.Fatalf("expected headers to be %v got %v", test.expected, h)
+			}
+		})
+	}
+}
+
+func TestTransportKeepsRequestHeaders(t *testing.T) {
+	var received http.Header
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header
+		}),
+	)
+	defer srv.Close()
+
+	c := new(http.Client)
+	c.Transport = NewTransport(nil, http.Header{"X-Proxy-Token": {"secret"}, "X-Request-Id": {"static"}})
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("X-Request-ID", "request-1")
+
+	if _, err := c.Do(req); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if v := received.Get("X-Proxy-Token"); v != "secret" {
+		t.Errorf("expected static header to be set got %q", v)
+	}
+
+	if v := received.Values("X-Request-ID"); !reflect.DeepEqual(v, []string{"request-1"}) {
+		t.Errorf("expected request header to be kept got %v", v)
+	}
+
+	if req.Header.Get("X-Proxy-Token") != "" {
+		t.Errorf("expected original request not to be modified")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package requestid correlates the work done for a single API request, the ID is read from or
+// generated for each incoming request and forwarded to the upstream services
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	Header = "X-Request-ID"
+
+	// SpanAttribute is the attribute carrying the ID on the request span
+	SpanAttribute = "http.request_id"
+
+	// IDs longer than this are replaced, they end up in every log line and upstream request
+	maxLength = 128
+)
+
+// FromContext returns the request ID stored in ctx, empty if there is none
+// the chi context key is reused so middleware.GetReqID keeps working
+func FromContext(ctx context.Context) string {
+	return middleware.GetReqID(ctx)
+}
+
+// NewContext returns a copy of ctx carrying the request ID
+func NewContext(ctx context.Context, ID string) context.Context {
+	return context.WithValue(ctx, middleware.RequestIDKey, ID)
+}
+
+// Middleware stores the X-Request-ID header of the request in its context, generating one when
+// missing or malformed, the ID is echoed back in the response and set on the request span
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			ID := r.Header.Get(Header)
+
+			if !valid(ID) {
+				ID = uuid.NewString()
+			}
+
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String(SpanAttribute, ID))
+			w.Header().Set(Header, ID)
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), ID)))
+		},
+	)
+}
+
+func valid(ID string) bool {
+	if ID == "" || len(ID) > maxLength {
+		return false
+	}
+
+	// printable ASCII only, the ID is copied verbatim into headers and logs
+	for _, c := range ID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Transport sets the X-Request-ID header on outgoing requests whose context carries a request ID
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if ID := FromContext(r.Context()); ID != "" && r.Header.Get(Header) == "" {
+		// RoundTrippers must not modify the request they are given
+		r = r.Clone(r.Context())
+		r.Header.Set(Header, ID)
+	}
+
+	return t.base().RoundTrip(r)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+
+	return t.Base
+}
+
+// NewTransport wraps base so that outgoing requests carry the request ID, base defaults to http.DefaultTransport
+func NewTransport(base http.RoundTripper) *Transport {
+	t := new(Transport)
+
+	t.Base = base
+
+	return t
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		preserved bool
+	}{
+		{name: "provided ID is preserved", header: "5c1b4a1e-ingress-42", preserved: true},
+		{name: "missing ID is generated"},
+		{name: "ID with spaces is replaced", header: "not a valid id"},
+		{name: "oversized ID is replaced", header: strings.Repeat("a", maxLength+1)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+			var contextID string
+
+			handler := Middleware(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					contextID = FromContext(r.Context())
+				}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+
+			if test.header != "" {
+				req.Header.Set(Header, test.header)
+			}
+
+			ctx, span := tracer.Start(req.Context(), "server")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req.WithContext(ctx))
+			span.End()
+
+			responseID := w.Result().Header.Get(Header)
+
+			if test.preserved && responseID != test.header {
+				t.Fatalf("expected request ID %s to be preserved got %s", test.header, responseID)
+			}
+
+			if !test.preserved {
+				if _, err := uuid.Parse(responseID); err != nil {
+					t.Fatalf("expected a generated UUID got %q", responseID)
+				}
+			}
+
+			if contextID != responseID {
+				t.Fatalf("expected context to carry %s got %s", responseID, contextID)
+			}
+
+			spans := recorder.Ended()
+
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span got %d", len(spans))
+			}
+
+			expected := attribute.String(SpanAttribute, responseID)
+			found := false
+
+			for _, a := range spans[0].Attributes() {
+				found = found || a == expected
+			}
+
+			if !found {
+				t.Errorf("expected span attribute %v in %v", expected, spans[0].Attributes())
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	received := make(chan string, 1)
+
+	upstream := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r.Header.Get(Header)
+		}),
+	)
+	defer upstream.Close()
+
+	c := &http.Client{Transport: NewTransport(nil)}
+
+	for _, ID := range []string{"5c1b4a1e-ingress-42", ""} {
+		req, _ := http.NewRequestWithContext(NewContext(context.Background(), ID), http.MethodGet, upstream.URL, nil)
+
+		res, err := c.Do(req)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+
+		res.Body.Close()
+
+		if header := <-received; header != ID {
+			t.Errorf("expected upstream to receive request ID %q got %q", ID, header)
+		}
+
+		if req.Header.Get(Header) != "" {
+			t.Errorf("expected the original request to be left untouched")
+		}
+	}
+}
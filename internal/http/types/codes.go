@@ -0,0 +1,43 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package types
+
+// error codes set in Response.Code, clients should branch on these rather than on Message
+// which is meant for humans and can change at any time
+const (
+	CodeInvalidPayload       = "request.invalid_payload"
+	CodeInvalidParameter     = "request.invalid_parameter"
+	CodeUnsupportedMediaType = "request.unsupported_media_type"
+	CodeNotImplemented       = "request.not_implemented"
+	CodePayloadTooLarge      = "request.payload_too_large"
+	CodeRateLimited          = "request.rate_limited"
+	CodeInternal             = "internal.error"
+
+	CodeUpstreamRateLimited = "upstream.rate_limited"
+	CodeUpstreamUnavailable = "upstream.unavailable"
+
+	CodeUnauthorized = "authn.unauthorized"
+	CodeForbidden    = "authz.forbidden"
+
+	CodeIdentityNotFound           = "identity.not_found"
+	CodeIdentityConflict           = "identity.conflict"
+	CodeIdentityPreconditionFailed = "identity.precondition_failed"
+	CodeIdentityInvalid            = "identity.invalid"
+	CodeIdentityInvalidTraits      = "identity.invalid_traits"
+
+	CodeSessionNotFound = "session.not_found"
+
+	CodeGroupNotFound          = "group.not_found"
+	CodeGroupNameConflict      = "group.name_conflict"
+	CodeGroupInvalidPermission = "group.invalid_permission"
+	CodeGroupNotMember         = "group.not_member"
+	CodeGroupTrashFull         = "group.trash_full"
+
+	CodeRoleNotFound          = "role.not_found"
+	CodeRoleNameConflict      = "role.name_conflict"
+	CodeRoleInvalidPermission = "role.invalid_permission"
+
+	CodePermissionInvalidType     = "permission.invalid_type"
+	CodePermissionInvalidRelation = "permission.invalid_relation"
+)
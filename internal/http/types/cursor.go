@@ -0,0 +1,136 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package types
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+)
+
+// cursorPrefix marks a pagination header holding a cursor ID, it can't clash with the base64
+// encoded token map as ":" is not part of the base64 alphabet
+const cursorPrefix = "cursor:"
+
+// ErrCursorNotFound is returned for cursors that expired or were never issued
+var ErrCursorNotFound = errors.New("pagination cursor not found or expired")
+
+// CursorStoreInterface maps short cursor IDs to pagination token maps
+type CursorStoreInterface interface {
+	Put(context.Context, map[string]string) (string, error)
+	Get(context.Context, string) (map[string]string, error)
+}
+
+// CursorConfig makes the TokenPaginator swap token maps whose encoding is longer than Threshold
+// for a cursor ID kept in Store
+type CursorConfig struct {
+	Store     CursorStoreInterface
+	Threshold int
+}
+
+// NewCursorConfig returns nil if cursors are disabled or ttl is not positive, tokens are then always
+// encoded in the header, otherwise cursors are kept in a MemoryCursorStore
+func NewCursorConfig(enabled bool, ttlSeconds, thresholdBytes, maxEntries int) *CursorConfig {
+	if !enabled || ttlSeconds <= 0 {
+		return nil
+	}
+
+	c := new(CursorConfig)
+
+	c.Store = NewMemoryCursorStore(time.Duration(ttlSeconds)*time.Second, maxEntries)
+	c.Threshold = thresholdBytes
+
+	return c
+}
+
+type cursorEntry struct {
+	tokens  map[string]string
+	expires time.Time
+}
+
+// MemoryCursorStore keeps cursors in memory for ttl, at most maxEntries are kept, the one closest
+// to expiry is dropped to make room, cursors are not shared across instances
+type MemoryCursorStore struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cursorEntry
+
+	now func() time.Time
+}
+
+// Put stores a copy of tokens and returns the ID of the new cursor
+func (s *MemoryCursorStore) Put(ctx context.Context, tokens map[string]string) (string, error) {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	ID := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	if len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+
+	s.entries[ID] = cursorEntry{tokens: maps.Clone(tokens), expires: now.Add(s.ttl)}
+
+	return ID, nil
+}
+
+// Get returns the tokens of cursor ID, ErrCursorNotFound if it expired
+func (s *MemoryCursorStore) Get(ctx context.Context, ID string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[ID]
+
+	if !ok || !s.now().Before(entry.expires) {
+		delete(s.entries, ID)
+		return nil, ErrCursorNotFound
+	}
+
+	return maps.Clone(entry.tokens), nil
+}
+
+// evict drops the expired entries, or the oldest one if none expired, needs the lock held
+func (s *MemoryCursorStore) evict(now time.Time) {
+	oldest := ""
+
+	for ID, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, ID)
+			continue
+		}
+
+		if oldest == "" || entry.expires.Before(s.entries[oldest].expires) {
+			oldest = ID
+		}
+	}
+
+	if len(s.entries) >= s.maxEntries && oldest != "" {
+		delete(s.entries, oldest)
+	}
+}
+
+func NewMemoryCursorStore(ttl time.Duration, maxEntries int) *MemoryCursorStore {
+	s := new(MemoryCursorStore)
+
+	s.ttl = ttl
+	s.maxEntries = max(maxEntries, 1)
+	s.entries = make(map[string]cursorEntry)
+	s.now = time.Now
+
+	return s
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package types
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCursorConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		ttlSeconds int
+		expected   bool
+	}{
+		{name: "disabled", enabled: false, ttlSeconds: 600, expected: false},
+		{name: "no ttl", enabled: true, ttlSeconds: 0, expected: false},
+		{name: "enabled", enabled: true, ttlSeconds: 600, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCursorConfig(test.enabled, test.ttlSeconds, 4096, 100)
+
+			if (c != nil) != test.expected {
+				t.Fatalf("expected config to be set %v got %v", test.expected, c)
+			}
+
+			if c != nil && c.Threshold != 4096 {
+				t.Fatalf("expected threshold to be 4096 got %d", c.Threshold)
+			}
+		})
+	}
+}
+
+func TestMemoryCursorStore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewMemoryCursorStore(time.Minute, 2)
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	tokens := map[string]string{"roles": "token-1"}
+
+	first, err := s.Put(ctx, tokens)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	// the stored map is a copy
+	tokens["roles"] = "changed"
+
+	if got, err := s.Get(ctx, first); err != nil || !reflect.DeepEqual(got, map[string]string{"roles": "token-1"}) {
+		t.Fatalf("expected stored tokens got %v, %v", got, err)
+	}
+
+	now = now.Add(30 * time.Second)
+	second, _ := s.Put(ctx, tokens)
+
+	// store is full, the entry closest to expiry is dropped
+	third, _ := s.Put(ctx, tokens)
+
+	if _, err := s.Get(ctx, first); !errors.Is(err, ErrCursorNotFound) {
+		t.Fatalf("expected evicted cursor to be missing got %v", err)
+	}
+
+	for _, ID := range []string{second, third} {
+		if _, err := s.Get(ctx, ID); err != nil {
+			t.Fatalf("expected cursor %s to be found got %v", ID, err)
+		}
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, err := s.Get(ctx, second); !errors.Is(err, ErrCursorNotFound) {
+		t.Fatalf("expected expired cursor to be missing got %v", err)
+	}
+}
@@ -4,6 +4,7 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -16,7 +17,9 @@ type Response struct {
 	Data    interface{} `json:"data"`
 	Message string      `json:"message"`
 	Status  int         `json:"status"`
-	Meta    *Pagination `json:"_meta"`
+	// Code is the machine readable counterpart of Message, only set on errors
+	Code string      `json:"code,omitempty"`
+	Meta *Pagination `json:"_meta"`
 }
 
 // NavigationTokens are parameters used to navigate `list` result endpoints
@@ -34,13 +37,59 @@ type Pagination struct {
 
 	// serialization only
 	NavigationTokens
+	Total *int64 `json:"total,omitempty"`
+}
+
+const (
+	DefaultPageSize    int64 = 100
+	DefaultMaxPageSize int64 = 500
+)
+
+var ErrInvalidPageSize = errors.New("size must be a positive integer")
+
+// PageSizeConfig bounds the page size clients can request from list endpoints
+type PageSizeConfig struct {
+	Default int64
+	Max     int64
+}
+
+// ParseSize resolves the size query parameter, a missing size falls back to Default
+// and sizes above Max are capped, non positive sizes are rejected with ErrInvalidPageSize
+func (c *PageSizeConfig) ParseSize(q url.Values) (int64, error) {
+	defaultSize, maxSize := DefaultPageSize, DefaultMaxPageSize
+
+	if c != nil {
+		defaultSize, maxSize = c.Default, c.Max
+	}
+
+	if !q.Has("size") {
+		return defaultSize, nil
+	}
+
+	size, err := strconv.ParseInt(q.Get("size"), 10, 64)
+
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("%w, got %q", ErrInvalidPageSize, q.Get("size"))
+	}
+
+	return min(size, maxSize), nil
+}
+
+func NewPageSizeConfig(defaultSize, maxSize int64) *PageSizeConfig {
+	c := new(PageSizeConfig)
+
+	c.Max = maxSize
+	// a default above the max would be capped on every request anyway
+	c.Default = min(defaultSize, maxSize)
+
+	return c
 }
 
 func NewPaginationWithDefaults() *Pagination {
 	p := new(Pagination)
 
 	p.PageToken = ""
-	p.Size = 100
+	p.Size = DefaultPageSize
 
 	return p
 }
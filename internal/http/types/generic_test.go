@@ -0,0 +1,47 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package types
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestPageSizeConfigParseSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *PageSizeConfig
+		query    string
+		expected int64
+		err      error
+	}{
+		{name: "missing size", config: NewPageSizeConfig(10, 50), query: "", expected: 10},
+		{name: "size within bounds", config: NewPageSizeConfig(10, 50), query: "size=1", expected: 1},
+		{name: "size equal to max", config: NewPageSizeConfig(10, 50), query: "size=50", expected: 50},
+		{name: "size above max", config: NewPageSizeConfig(10, 50), query: "size=1000", expected: 50},
+		{name: "empty size", config: NewPageSizeConfig(10, 50), query: "size=", err: ErrInvalidPageSize},
+		{name: "zero size", config: NewPageSizeConfig(10, 50), query: "size=0", err: ErrInvalidPageSize},
+		{name: "negative size", config: NewPageSizeConfig(10, 50), query: "size=-5", err: ErrInvalidPageSize},
+		{name: "non numeric size", config: NewPageSizeConfig(10, 50), query: "size=all", err: ErrInvalidPageSize},
+		{name: "default above max", config: NewPageSizeConfig(100, 50), query: "", expected: 50},
+		{name: "nil config", config: nil, query: "size=100000", expected: DefaultMaxPageSize},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(test.query)
+
+			size, err := test.config.ParseSize(q)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+
+			if size != test.expected {
+				t.Fatalf("expected size to be %v got %v", test.expected, size)
+			}
+		})
+	}
+}
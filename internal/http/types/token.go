@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"go.opentelemetry.io/otel/trace"
 
@@ -25,12 +26,38 @@ const (
 type TokenPaginator struct {
 	tokens map[string]string
 
+	cursors *CursorConfig
+
 	tracer tracing.TracingInterface
 	logger logging.LoggerInterface
 }
 
-// LoadFromString populates the TokenPaginator struct with pagination tokens from a string
+// SetCursorConfig makes the paginator keep long token maps server side, see CursorConfig
+func (p *TokenPaginator) SetCursorConfig(c *CursorConfig) {
+	p.cursors = c
+}
+
+// LoadFromString populates the TokenPaginator struct with pagination tokens from a string, either
+// the encoded token map or a cursor issued by PaginationHeader
 func (p *TokenPaginator) LoadFromString(ctx context.Context, s string) error {
+	if ID, ok := strings.CutPrefix(s, cursorPrefix); ok {
+		if p.cursors == nil {
+			p.logger.Errorf("issues loading cursor: %s", ErrCursorNotFound)
+			return ErrCursorNotFound
+		}
+
+		tokens, err := p.cursors.Store.Get(ctx, ID)
+
+		if err != nil {
+			p.logger.Errorf("issues loading cursor: %s", err)
+			return err
+		}
+
+		p.SetTokens(ctx, tokens)
+
+		return nil
+	}
+
 	tokenMap, err := base64.StdEncoding.DecodeString(s)
 
 	if err != nil {
@@ -105,7 +132,8 @@ func (p *TokenPaginator) GetAllTokens(ctx context.Context) map[string]string {
 	return p.tokens
 }
 
-// PaginationHeader returns a composite pagination token string to use as a header
+// PaginationHeader returns a composite pagination token string to use as a header, if a CursorConfig
+// is set and the encoded tokens are longer than its threshold a cursor is returned instead
 func (p *TokenPaginator) PaginationHeader(ctx context.Context) (string, error) {
 	_, span := p.tracer.Start(ctx, "types.TokenPaginator.PaginationHeader")
 	defer span.End()
@@ -121,7 +149,20 @@ func (p *TokenPaginator) PaginationHeader(ctx context.Context) (string, error) {
 		return "", err
 	}
 
-	return base64.StdEncoding.EncodeToString(tokenMap), nil
+	header := base64.StdEncoding.EncodeToString(tokenMap)
+
+	if p.cursors == nil || len(header) <= p.cursors.Threshold {
+		return header, nil
+	}
+
+	ID, err := p.cursors.Store.Put(ctx, p.tokens)
+
+	if err != nil {
+		p.logger.Errorf("issues storing cursor: %s", err)
+		return "", err
+	}
+
+	return cursorPrefix + ID, nil
 }
 
 func NewTokenPaginator(tracer trace.Tracer, logger logging.LoggerInterface) *TokenPaginator {
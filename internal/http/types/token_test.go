@@ -5,9 +5,11 @@ package types
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"go.opentelemetry.io/otel/trace"
@@ -107,3 +109,78 @@ func TestLoadFromRequestFailure_WrongHeaderValue(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestPaginationHeaderCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		cursor    bool
+	}{
+		{name: "short tokens are encoded", threshold: 4096, cursor: false},
+		{name: "long tokens use a cursor", threshold: 10, cursor: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			mockTracer := NewMockTracingInterface(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			cursors := NewCursorConfig(true, 60, test.threshold, 10)
+			tokens := map[string]string{"roles": "continuation-token-1", "groups": "continuation-token-2"}
+
+			p := NewTokenPaginator(mockTracer, mockLogger)
+			p.SetCursorConfig(cursors)
+			p.SetTokens(context.TODO(), tokens)
+
+			header, err := p.PaginationHeader(context.TODO())
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if strings.HasPrefix(header, cursorPrefix) != test.cursor {
+				t.Fatalf("expected cursor %v got header %s", test.cursor, header)
+			}
+
+			next := NewTokenPaginator(mockTracer, mockLogger)
+			next.SetCursorConfig(cursors)
+
+			if err := next.LoadFromString(context.TODO(), header); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(next.GetAllTokens(context.TODO()), tokens) {
+				t.Fatalf("expected tokens to be %v got %v", tokens, next.GetAllTokens(context.TODO()))
+			}
+		})
+	}
+}
+
+func TestLoadFromStringUnknownCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	mockTracer := NewMockTracingInterface(ctrl)
+	mockLogger := NewMockLoggerInterface(ctrl)
+
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(2)
+
+	p := NewTokenPaginator(mockTracer, mockLogger)
+
+	if err := p.LoadFromString(context.TODO(), cursorPrefix+"missing"); !errors.Is(err, ErrCursorNotFound) {
+		t.Fatalf("expected error to be %v got %v", ErrCursorNotFound, err)
+	}
+
+	p.SetCursorConfig(NewCursorConfig(true, 60, 0, 10))
+
+	if err := p.LoadFromString(context.TODO(), cursorPrefix+"missing"); !errors.Is(err, ErrCursorNotFound) {
+		t.Fatalf("expected error to be %v got %v", ErrCursorNotFound, err)
+	}
+
+	if len(p.GetAllTokens(context.TODO())) != 0 {
+		t.Fatalf("expected no tokens got %v", p.GetAllTokens(context.TODO()))
+	}
+}
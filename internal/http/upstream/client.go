@@ -0,0 +1,46 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+// Package upstream builds the HTTP clients used to call the upstream services, Kratos and Hydra
+package upstream
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/headers"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/requestid"
+)
+
+const (
+	DefaultConnectTimeout  = 5 * time.Second
+	DefaultResponseTimeout = 10 * time.Second
+)
+
+// NewHTTPClient bounds dialing to connectTimeout and the whole call, body included, to responseTimeout,
+// zero timeouts fall back to DefaultConnectTimeout and DefaultResponseTimeout
+// request contexts are still honoured so a cancelled request aborts the upstream call straight away
+// staticHeaders are set on every request, the request ID is never overridden by them
+func NewHTTPClient(connectTimeout, responseTimeout time.Duration, staticHeaders http.Header) *http.Client {
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+
+	if responseTimeout <= 0 {
+		responseTimeout = DefaultResponseTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = responseTimeout
+
+	c := new(http.Client)
+	c.Timeout = responseTimeout
+	c.Transport = otelhttp.NewTransport(requestid.NewTransport(headers.NewTransport(transport, staticHeaders)))
+
+	return c
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/requestid"
+)
+
+// slowServer never answers until the test is over
+func slowServer(t *testing.T) *httptest.Server {
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}),
+	)
+
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+
+	return srv
+}
+
+func get(ctx context.Context, c *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func TestHTTPClientResponseTimeout(t *testing.T) {
+	srv := slowServer(t)
+
+	c := NewHTTPClient(time.Second, 50*time.Millisecond, nil)
+
+	start := time.Now()
+	err := get(context.Background(), c, srv.URL)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected call to time out quickly, took %s", elapsed)
+	}
+
+	var netErr net.Error
+
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected a timeout error got %v", err)
+	}
+}
+
+func TestHTTPClientPropagatesContextCancellation(t *testing.T) {
+	srv := slowServer(t)
+
+	c := NewHTTPClient(time.Second, time.Minute, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := get(ctx, c, srv.URL)
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected call to be cancelled quickly, took %s", elapsed)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error got %v", err)
+	}
+}
+
+func TestNewHTTPClientDefaults(t *testing.T) {
+	c := NewHTTPClient(0, 0, nil)
+
+	if c.Timeout != DefaultResponseTimeout {
+		t.Fatalf("expected timeout to be %s got %s", DefaultResponseTimeout, c.Timeout)
+	}
+}
+
+func TestHTTPClientSetsStaticHeaders(t *testing.T) {
+	var received http.Header
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+		}),
+	)
+	defer srv.Close()
+
+	c := NewHTTPClient(time.Second, time.Second, http.Header{"X-Proxy-Token": {"secret"}})
+
+	ctx := requestid.NewContext(context.Background(), "request-1")
+
+	if err := get(ctx, c, srv.URL); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if v := received.Get("X-Proxy-Token"); v != "secret" {
+		t.Errorf("expected static header to be set got %q", v)
+	}
+
+	if v := received.Get(requestid.Header); v != "request-1" {
+		t.Errorf("expected request ID to be propagated got %q", v)
+	}
+}
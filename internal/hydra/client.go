@@ -5,9 +5,11 @@ package hydra
 
 import (
 	"net/http"
+	"time"
 
 	client "github.com/ory/hydra-client-go/v2"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/upstream"
 )
 
 type Client struct {
@@ -18,7 +20,13 @@ func (c *Client) OAuth2Api() client.OAuth2Api {
 	return c.c.OAuth2Api
 }
 
-func NewClient(url string, debug bool) *Client {
+func (c *Client) MetadataApi() client.MetadataApi {
+	return c.c.MetadataApi
+}
+
+// NewClient returns a hydra client, zero timeouts fall back to the upstream package defaults
+// staticHeaders, if any, are attached to every request, e.g. for an auth proxy in front of Hydra
+func NewClient(url string, debug bool, connectTimeout, responseTimeout time.Duration, staticHeaders http.Header) *Client {
 	c := new(Client)
 
 	configuration := client.NewConfiguration()
@@ -30,8 +38,7 @@ func NewClient(url string, debug bool) *Client {
 		},
 	}
 
-	configuration.HTTPClient = new(http.Client)
-	configuration.HTTPClient.Transport = otelhttp.NewTransport(http.DefaultTransport)
+	configuration.HTTPClient = upstream.NewHTTPClient(connectTimeout, responseTimeout, staticHeaders)
 
 	c.c = client.NewAPIClient(configuration)
 
@@ -0,0 +1,44 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package hydra
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/requestid"
+)
+
+func TestClientSetsStaticHeaders(t *testing.T) {
+	var received http.Header
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status": "ok"}`))
+		}),
+	)
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, time.Second, time.Second, http.Header{"X-Proxy-Token": {"secret"}})
+
+	ctx := requestid.NewContext(context.Background(), "request-1")
+
+	if _, _, err := c.MetadataApi().IsAlive(ctx).Execute(); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if v := received.Get("X-Proxy-Token"); v != "secret" {
+		t.Errorf("expected static header to be set got %q", v)
+	}
+
+	if v := received.Get(requestid.Header); v != "request-1" {
+		t.Errorf("expected request ID to be propagated got %q", v)
+	}
+}
@@ -5,9 +5,11 @@ package kratos
 
 import (
 	"net/http"
+	"time"
 
 	client "github.com/ory/kratos-client-go"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/upstream"
 )
 
 type Client struct {
@@ -18,7 +20,13 @@ func (c *Client) IdentityAPI() client.IdentityAPI {
 	return c.c.IdentityAPI
 }
 
-func NewClient(url string, debug bool) *Client {
+func (c *Client) MetadataAPI() client.MetadataAPI {
+	return c.c.MetadataAPI
+}
+
+// NewClient returns a kratos client, zero timeouts fall back to the upstream package defaults
+// staticHeaders, if any, are attached to every request, e.g. for an auth proxy in front of Kratos
+func NewClient(url string, debug bool, connectTimeout, responseTimeout time.Duration, staticHeaders http.Header) *Client {
 	c := new(Client)
 
 	configuration := client.NewConfiguration()
@@ -29,8 +37,7 @@ func NewClient(url string, debug bool) *Client {
 		},
 	}
 
-	configuration.HTTPClient = new(http.Client)
-	configuration.HTTPClient.Transport = otelhttp.NewTransport(http.DefaultTransport)
+	configuration.HTTPClient = upstream.NewHTTPClient(connectTimeout, responseTimeout, staticHeaders)
 
 	c.c = client.NewAPIClient(configuration)
 
@@ -0,0 +1,73 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// FromContext returns logger annotated with the request ID carried by ctx, zap loggers get a
+// request_id field, any other logger gets the ID prepended to each message
+func FromContext(ctx context.Context, logger LoggerInterface) LoggerInterface {
+	ID := middleware.GetReqID(ctx)
+
+	if ID == "" {
+		return logger
+	}
+
+	if l, ok := logger.(*zap.SugaredLogger); ok {
+		return l.With("request_id", ID)
+	}
+
+	return &requestLogger{prefix: fmt.Sprintf("[%s] ", ID), logger: logger}
+}
+
+type requestLogger struct {
+	prefix string
+	logger LoggerInterface
+}
+
+func (l *requestLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Errorf(l.prefix+format, args...)
+}
+
+func (l *requestLogger) Infof(format string, args ...interface{}) {
+	l.logger.Infof(l.prefix+format, args...)
+}
+
+func (l *requestLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warnf(l.prefix+format, args...)
+}
+
+func (l *requestLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf(l.prefix+format, args...)
+}
+
+func (l *requestLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatalf(l.prefix+format, args...)
+}
+
+func (l *requestLogger) Error(args ...interface{}) {
+	l.logger.Error(append([]interface{}{l.prefix}, args...)...)
+}
+
+func (l *requestLogger) Info(args ...interface{}) {
+	l.logger.Info(append([]interface{}{l.prefix}, args...)...)
+}
+
+func (l *requestLogger) Warn(args ...interface{}) {
+	l.logger.Warn(append([]interface{}{l.prefix}, args...)...)
+}
+
+func (l *requestLogger) Debug(args ...interface{}) {
+	l.logger.Debug(append([]interface{}{l.prefix}, args...)...)
+}
+
+func (l *requestLogger) Fatal(args ...interface{}) {
+	l.logger.Fatal(append([]interface{}{l.prefix}, args...)...)
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(core).Sugar()
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+
+	FromContext(ctx, logger).Infof("listing %s", "identities")
+	FromContext(ctx, NewRedactingLogger(logger)).Errorf("failed %s", "upstream")
+	FromContext(context.Background(), logger).Info("no request")
+
+	entries := logs.AllUntimed()
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries got %d", len(entries))
+	}
+
+	if fields := entries[0].ContextMap(); fields["request_id"] != "req-1" || entries[0].Message != "listing identities" {
+		t.Errorf("expected request_id field on %v", entries[0])
+	}
+
+	if entries[1].Message != "[req-1] failed upstream" {
+		t.Errorf("expected request ID prefix got %q", entries[1].Message)
+	}
+
+	if _, ok := entries[2].ContextMap()["request_id"]; ok {
+		t.Errorf("expected no request_id field without a request ID in the context")
+	}
+}
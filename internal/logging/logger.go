@@ -13,13 +13,25 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-// NewLogger creates a new default logger
+const (
+	// FormatJSON writes one JSON object per line, meant for log aggregation
+	FormatJSON = "json"
+	// FormatConsole writes human readable lines with colored levels, meant for local runs
+	FormatConsole = "console"
+)
+
+// NewLogger creates a new default logger writing to stdout in format, FormatJSON or FormatConsole,
+// any other value falls back to FormatJSON
 // it will need to be closed with
 // ```
 // defer logger.Desugar().Sync()
 // ```
 // to make sure all has been piped out before terminating
-func NewLogger(l string) *zap.SugaredLogger {
+func NewLogger(l, format string) *zap.SugaredLogger {
+	return newLogger(l, format, zapcore.AddSync(os.Stdout))
+}
+
+func newLogger(l, format string, out zapcore.WriteSyncer) *zap.SugaredLogger {
 	var lvl string
 
 	val := strings.ToLower(l)
@@ -56,7 +68,16 @@ func NewLogger(l string) *zap.SugaredLogger {
 		panic(err)
 	}
 
-	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg.EncoderConfig), zapcore.AddSync(os.Stdout), cfg.Level)
+	encoder := zapcore.NewJSONEncoder(cfg.EncoderConfig)
+
+	if strings.ToLower(format) == FormatConsole {
+		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+		encoder = zapcore.NewConsoleEncoder(cfg.EncoderConfig)
+	}
+
+	core := zapcore.NewCore(encoder, out, cfg.Level)
 
 	return zap.New(core).Sugar()
 
@@ -4,17 +4,55 @@
 package logging
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestDebugLogger(t *testing.T) {
 	assert := assert.New(t)
-	assert.NotPanics(func() { NewLogger("DEBUG") }, "No panic should have been thrown")
+	assert.NotPanics(func() { NewLogger("DEBUG", FormatJSON) }, "No panic should have been thrown")
 }
 
 func TestInvalidLevel(t *testing.T) {
 	assert := assert.New(t)
-	assert.NotPanics(func() { NewLogger("invalid") }, "No panic should have been thrown")
+	assert.NotPanics(func() { NewLogger("invalid", FormatJSON) }, "No panic should have been thrown")
+}
+
+func TestLoggerFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		json   bool
+	}{
+		{format: FormatJSON, json: true},
+		{format: "CONSOLE", json: false},
+		{format: "unknown", json: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var buf bytes.Buffer
+
+			logger := NewSamplingLogger(
+				NewRedactingLogger(newLogger("info", test.format, zapcore.AddSync(&buf))),
+				NewSamplingConfig(true, 60, 1),
+			)
+
+			logger.Errorf("error creating identity %s", "joe@example.com")
+			logger.Errorf("error creating identity %s", "joe@example.com")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+			assert.Len(lines, 1, "the repeated error should be sampled out")
+			assert.Contains(lines[0], "error creating identity j***@example.com")
+			assert.NotContains(lines[0], "joe@example.com")
+			assert.Equal(test.json, json.Valid([]byte(lines[0])))
+		})
+	}
 }
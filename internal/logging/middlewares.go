@@ -27,11 +27,6 @@ func (l *LogFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
 	entry.request = r
 	entry.buf = new(bytes.Buffer)
 
-	reqID := middleware.GetReqID(r.Context())
-	if reqID != "" {
-		fmt.Fprintf(entry.buf, "[%s] ", reqID)
-	}
-
 	fmt.Fprintf(entry.buf, "%s ", r.Method)
 
 	scheme := "http"
@@ -55,7 +50,7 @@ func (l *LogEntry) Write(status, bytes int, header http.Header, elapsed time.Dur
 
 	fmt.Fprintf(l.buf, "%v %03d %dB in %s", header, status, bytes, elapsed)
 
-	l.Logger.Debug(l.buf.String())
+	FromContext(l.request.Context(), l.Logger).Debug(l.buf.String())
 }
 
 // TODO @shipperizer see if implementing this or not
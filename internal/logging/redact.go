@@ -0,0 +1,313 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "***"
+
+var (
+	// DefaultSensitiveKeys are the trait keys whose values get masked when no keys are configured
+	DefaultSensitiveKeys = []string{"email", "phone", "name"}
+
+	emailRegex = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+	// nextPairRegex and nextFieldRegex spot the start of the following key=value pair and of the
+	// following field of a %v formatted struct or map, they end an unquoted value
+	nextPairRegex  = regexp.MustCompile(`^\s+[\w.\-]+=`)
+	nextFieldRegex = regexp.MustCompile(`^\s+[A-Za-z_][\w.\-]*:`)
+)
+
+const (
+	jsonForm = iota
+	pairForm
+	fieldForm
+)
+
+// Redactor masks personal data in log messages, values of the sensitive keys found in JSON,
+// key=value or %v formatted struct and map form are replaced and email addresses are masked
+// wherever they appear, keys are matched case insensitively
+type Redactor struct {
+	keys    []string
+	matcher *regexp.Regexp
+}
+
+// Redact returns the message with all the personal data masked
+func (r *Redactor) Redact(msg string) string {
+	var b strings.Builder
+
+	last := 0
+
+	for _, m := range r.matcher.FindAllStringSubmatchIndex(msg, -1) {
+		// the value of a previous key swallowed this one
+		if m[1] <= last {
+			continue
+		}
+
+		form, keyStart := jsonForm, m[2]
+
+		switch {
+		case m[4] >= 0:
+			form, keyStart = pairForm, m[4]
+		case m[6] >= 0:
+			form, keyStart = fieldForm, m[6]
+		}
+
+		if keyStart < last {
+			continue
+		}
+
+		end, replacement := scanValue(msg, m[1], form)
+
+		b.WriteString(msg[last:m[1]])
+		b.WriteString(replacement)
+
+		last = end
+	}
+
+	b.WriteString(msg[last:])
+
+	return MaskEmails(b.String())
+}
+
+// RedactArgs returns a copy of args where the values of the sensitive keys of maps, nested ones
+// included, are masked, it's applied before formatting so values spanning multiple words or
+// holding objects never reach the message
+func (r *Redactor) RedactArgs(args []interface{}) []interface{} {
+	redacted := make([]interface{}, 0, len(args))
+
+	for _, arg := range args {
+		redacted = append(redacted, r.redactValue(arg))
+	}
+
+	return redacted
+}
+
+func (r *Redactor) redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(value))
+
+		for k, item := range value {
+			if r.isSensitive(k) {
+				m[k] = redactedValue
+				continue
+			}
+
+			m[k] = r.redactValue(item)
+		}
+
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(value))
+
+		for _, item := range value {
+			s = append(s, r.redactValue(item))
+		}
+
+		return s
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) isSensitive(key string) bool {
+	for _, k := range r.keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scanValue finds the end of the value starting at start and returns it with its replacement,
+// JSON strings, objects and arrays are consumed whole, unquoted values run until the next key
+func scanValue(msg string, start, form int) (int, string) {
+	if start >= len(msg) {
+		return start, redactedValue
+	}
+
+	switch {
+	case msg[start] == '"':
+		end := scanQuoted(msg, start)
+
+		if form == jsonForm {
+			return end, fmt.Sprintf("%q", redactedValue)
+		}
+
+		return end, redactedValue
+	case msg[start] == '{' || msg[start] == '[':
+		end := scanNested(msg, start)
+
+		if form == jsonForm {
+			return end, fmt.Sprintf("%q", redactedValue)
+		}
+
+		return end, redactedValue
+	case form == fieldForm && strings.HasPrefix(msg[start:], "map["):
+		return scanNested(msg, start+len("map")), redactedValue
+	}
+
+	switch form {
+	case jsonForm:
+		end := start
+
+		for end < len(msg) && !strings.ContainsRune(",}] \t\n", rune(msg[end])) {
+			end++
+		}
+
+		return end, fmt.Sprintf("%q", redactedValue)
+	case pairForm:
+		return scanUnquoted(msg, start, ",;)}]\n", nextPairRegex), redactedValue
+	default:
+		return scanUnquoted(msg, start, "}]\n", nextFieldRegex), redactedValue
+	}
+}
+
+// scanQuoted returns the position following the closing quote of the string opened at start
+func scanQuoted(msg string, start int) int {
+	for i := start + 1; i < len(msg); i++ {
+		switch msg[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return len(msg)
+}
+
+// scanNested returns the position following the bracket closing the one at start, brackets
+// inside strings are ignored
+func scanNested(msg string, start int) int {
+	depth := 0
+
+	for i := start; i < len(msg); i++ {
+		switch msg[i] {
+		case '"':
+			i = scanQuoted(msg, i) - 1
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return len(msg)
+}
+
+// scanUnquoted returns the position of the first stop character or of the next key, so that
+// values made of several words are consumed whole
+func scanUnquoted(msg string, start int, stops string, next *regexp.Regexp) int {
+	for i := start; i < len(msg); i++ {
+		if strings.ContainsRune(stops, rune(msg[i])) {
+			return i
+		}
+
+		if (msg[i] == ' ' || msg[i] == '\t') && next.MatchString(msg[i:]) {
+			return i
+		}
+	}
+
+	return len(msg)
+}
+
+// MaskEmails keeps the first character of the local part and the domain of each
+// email address, e.g. joe@example.com becomes j***@example.com
+func MaskEmails(msg string) string {
+	return emailRegex.ReplaceAllString(msg, fmt.Sprintf("${1}%s@${2}", redactedValue))
+}
+
+// NewRedactor returns a Redactor for the keys passed in, DefaultSensitiveKeys are used if none is
+func NewRedactor(keys ...string) *Redactor {
+	if len(keys) == 0 {
+		keys = DefaultSensitiveKeys
+	}
+
+	quoted := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+
+	r := new(Redactor)
+	r.keys = keys
+	// matches "key": in JSON, key= in key=value pairs and key: in %v formatted structs and maps,
+	// the value following the match is found by scanValue
+	r.matcher = regexp.MustCompile(
+		fmt.Sprintf(
+			`(?i)(?:("(?:%[1]s)"\s*:\s*)|(\b(?:%[1]s)=)|(?:^|[\s{\[(,])((?:%[1]s):))`,
+			strings.Join(quoted, "|"),
+		),
+	)
+
+	return r
+}
+
+// RedactingLogger wraps a LoggerInterface and redacts every message before passing it on
+type RedactingLogger struct {
+	logger   LoggerInterface
+	redactor *Redactor
+}
+
+func (l *RedactingLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(l.redactor.Redact(fmt.Sprintf(format, l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(l.redactor.Redact(fmt.Sprintf(format, l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(l.redactor.Redact(fmt.Sprintf(format, l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(l.redactor.Redact(fmt.Sprintf(format, l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatal(l.redactor.Redact(fmt.Sprintf(format, l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Error(args ...interface{}) {
+	l.logger.Error(l.redactor.Redact(fmt.Sprint(l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Info(args ...interface{}) {
+	l.logger.Info(l.redactor.Redact(fmt.Sprint(l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Warn(args ...interface{}) {
+	l.logger.Warn(l.redactor.Redact(fmt.Sprint(l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Debug(args ...interface{}) {
+	l.logger.Debug(l.redactor.Redact(fmt.Sprint(l.redactor.RedactArgs(args)...)))
+}
+
+func (l *RedactingLogger) Fatal(args ...interface{}) {
+	l.logger.Fatal(l.redactor.Redact(fmt.Sprint(l.redactor.RedactArgs(args)...)))
+}
+
+// NewRedactingLogger returns a logger masking the values of the sensitive keys before they reach
+// the wrapped logger, DefaultSensitiveKeys are used if no key is passed
+func NewRedactingLogger(logger LoggerInterface, keys ...string) *RedactingLogger {
+	l := new(RedactingLogger)
+
+	l.logger = logger
+	l.redactor = NewRedactor(keys...)
+
+	return l
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactorMasksEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRedactor()
+
+	assert.Equal(
+		"identity j***@example.com already exists",
+		r.Redact("identity joe.doe@example.com already exists"),
+	)
+}
+
+func TestRedactorMasksSensitiveKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewRedactor()
+
+	assert.Equal(
+		`{"traits":{"email":"***","name":"***","phone": "***","role":"admin"}}`,
+		r.Redact(`{"traits":{"email":"joe@example.com","name":"Joe","phone": "+441234","role":"admin"}}`),
+	)
+	assert.Equal(
+		"failed creating identity name=*** email=***",
+		r.Redact("failed creating identity name=joe email=joe@example.com"),
+	)
+}
+
+func TestRedactorMasksWholeValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "json string with spaces",
+			input:    `{"name":"Joe Doe","role":"admin"}`,
+			expected: `{"name":"***","role":"admin"}`,
+		},
+		{
+			name:     "json phone with spaces",
+			input:    `{"phone":"+44 1234 5678"}`,
+			expected: `{"phone":"***"}`,
+		},
+		{
+			name:     "json string with escaped quotes",
+			input:    `{"name":"Joe \"JD\" Doe","role":"admin"}`,
+			expected: `{"name":"***","role":"admin"}`,
+		},
+		{
+			name:     "json object",
+			input:    `{"traits":{"name":{"first":"Joe","last":"Doe"},"role":"admin"}}`,
+			expected: `{"traits":{"name":"***","role":"admin"}}`,
+		},
+		{
+			name:     "json array",
+			input:    `{"phone":["+44 1234", "+44 5678"],"role":"admin"}`,
+			expected: `{"phone":"***","role":"admin"}`,
+		},
+		{
+			name:     "json keys are case insensitive",
+			input:    `{"Email":"joe@example.com","NAME":"Joe Doe"}`,
+			expected: `{"Email":"***","NAME":"***"}`,
+		},
+		{
+			name:     "key value with spaces",
+			input:    "failed creating identity name=Joe Doe role=admin",
+			expected: "failed creating identity name=*** role=admin",
+		},
+		{
+			name:     "quoted key value",
+			input:    `failed creating identity name="Joe Doe" role=admin`,
+			expected: "failed creating identity name=*** role=admin",
+		},
+		{
+			name:     "go struct",
+			input:    "{Email:foo@bar Name:Joe Doe Role:admin}",
+			expected: "{Email:*** Name:*** Role:admin}",
+		},
+		{
+			name:     "go map",
+			input:    "map[email:joe@example.com name:map[first:Joe last:Doe] role:admin]",
+			expected: "map[email:*** name:*** role:admin]",
+		},
+	}
+
+	r := NewRedactor()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, r.Redact(test.input))
+		})
+	}
+}
+
+func TestRedactingLoggerMasksArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	core, logs := observer.New(zap.DebugLevel)
+	logger := NewRedactingLogger(zap.New(core).Sugar())
+
+	traits := map[string]interface{}{
+		"Name": map[string]interface{}{"first": "Joe", "last": "Doe"},
+		"role": "admin",
+	}
+
+	logger.Errorf("error updating traits %v", traits)
+	logger.Error([]interface{}{map[string]interface{}{"phone": "+44 1234 5678"}})
+
+	entries := logs.AllUntimed()
+
+	assert.Len(entries, 2)
+	assert.Equal("error updating traits map[Name:*** role:admin]", entries[0].Message)
+	assert.Equal("[map[phone:***]]", entries[1].Message)
+	assert.Equal(map[string]interface{}{"first": "Joe", "last": "Doe"}, traits["Name"])
+}
+
+func TestRedactingLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	core, logs := observer.New(zap.DebugLevel)
+	logger := NewRedactingLogger(zap.New(core).Sugar())
+
+	logger.Errorf("error creating identity %s", "joe@example.com")
+	logger.Error(`{"email":"joe@example.com"}`)
+
+	entries := logs.AllUntimed()
+
+	assert.Len(entries, 2)
+	assert.Equal("error creating identity j***@example.com", entries[0].Message)
+	assert.Equal(`{"email":"***"}`, entries[1].Message)
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxSampledMessages bounds the distinct messages tracked in a window, messages past it are never suppressed
+const maxSampledMessages = 1000
+
+// SamplingConfig sets how many identical errors are logged per interval before being suppressed
+type SamplingConfig struct {
+	Interval  time.Duration
+	Threshold int
+}
+
+// NewSamplingConfig returns nil if sampling is disabled or interval and threshold are not positive
+func NewSamplingConfig(enabled bool, intervalSeconds, threshold int) *SamplingConfig {
+	if !enabled || intervalSeconds <= 0 || threshold <= 0 {
+		return nil
+	}
+
+	c := new(SamplingConfig)
+
+	c.Interval = time.Duration(intervalSeconds) * time.Second
+	c.Threshold = threshold
+
+	return c
+}
+
+// SamplingLogger wraps a LoggerInterface and only passes on the first Threshold occurrences of an
+// error message in each Interval, the number of suppressed occurrences is logged along with the
+// first error of the next interval, other levels are never sampled
+type SamplingLogger struct {
+	logger LoggerInterface
+
+	interval  time.Duration
+	threshold int
+
+	mu     sync.Mutex
+	start  time.Time
+	counts map[string]int
+
+	now func() time.Time
+}
+
+// sample counts msg in the current window and reports if it should be logged
+func (l *SamplingLogger) sample(msg string) bool {
+	l.mu.Lock()
+
+	now := l.now()
+	var suppressed map[string]int
+
+	if now.Sub(l.start) >= l.interval {
+		for m, count := range l.counts {
+			if count > l.threshold {
+				if suppressed == nil {
+					suppressed = make(map[string]int)
+				}
+
+				suppressed[m] = count - l.threshold
+			}
+		}
+
+		l.start = now
+		l.counts = make(map[string]int)
+	}
+
+	allowed := true
+
+	if count, ok := l.counts[msg]; ok || len(l.counts) < maxSampledMessages {
+		l.counts[msg] = count + 1
+		allowed = count < l.threshold
+	}
+
+	l.mu.Unlock()
+
+	for m, count := range suppressed {
+		l.logger.Errorf("suppressed %d identical errors in the last %s: %s", count, l.interval, m)
+	}
+
+	return allowed
+}
+
+func (l *SamplingLogger) Errorf(format string, args ...interface{}) {
+	if msg := fmt.Sprintf(format, args...); l.sample(msg) {
+		l.logger.Error(msg)
+	}
+}
+
+func (l *SamplingLogger) Infof(format string, args ...interface{}) {
+	l.logger.Infof(format, args...)
+}
+
+func (l *SamplingLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warnf(format, args...)
+}
+
+func (l *SamplingLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debugf(format, args...)
+}
+
+func (l *SamplingLogger) Fatalf(format string, args ...interface{}) {
+	l.logger.Fatalf(format, args...)
+}
+
+func (l *SamplingLogger) Error(args ...interface{}) {
+	if msg := fmt.Sprint(args...); l.sample(msg) {
+		l.logger.Error(msg)
+	}
+}
+
+func (l *SamplingLogger) Info(args ...interface{}) {
+	l.logger.Info(args...)
+}
+
+func (l *SamplingLogger) Warn(args ...interface{}) {
+	l.logger.Warn(args...)
+}
+
+func (l *SamplingLogger) Debug(args ...interface{}) {
+	l.logger.Debug(args...)
+}
+
+func (l *SamplingLogger) Fatal(args ...interface{}) {
+	l.logger.Fatal(args...)
+}
+
+// NewSamplingLogger returns a logger sampling the errors passed to logger, logger is returned as is
+// if cfg is nil
+func NewSamplingLogger(logger LoggerInterface, cfg *SamplingConfig) LoggerInterface {
+	if cfg == nil {
+		return logger
+	}
+
+	l := new(SamplingLogger)
+
+	l.logger = logger
+	l.interval = cfg.Interval
+	l.threshold = cfg.Threshold
+	l.counts = make(map[string]int)
+	l.now = time.Now
+	l.start = l.now()
+
+	return l
+}
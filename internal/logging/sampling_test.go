@@ -0,0 +1,73 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingLoggerSuppressesAfterThreshold(t *testing.T) {
+	assert := assert.New(t)
+
+	core, logs := observer.New(zap.DebugLevel)
+	now := time.Now()
+
+	logger := NewSamplingLogger(zap.New(core).Sugar(), NewSamplingConfig(true, 60, 3)).(*SamplingLogger)
+	logger.now = func() time.Time { return now }
+	logger.start = now
+
+	for i := 0; i < 5; i++ {
+		logger.Errorf("openfga %s", "unavailable")
+	}
+
+	logger.Error("kratos unavailable")
+	logger.Info("not sampled")
+	logger.Info("not sampled")
+
+	entries := logs.TakeAll()
+
+	assert.Len(entries, 6)
+
+	for _, entry := range entries[:3] {
+		assert.Equal("openfga unavailable", entry.Message)
+	}
+
+	assert.Equal("kratos unavailable", entries[3].Message)
+
+	// next window, the count of the suppressed errors is logged first
+	now = now.Add(time.Minute)
+	logger.Errorf("openfga %s", "unavailable")
+
+	entries = logs.TakeAll()
+
+	assert.Len(entries, 2)
+	assert.Equal("suppressed 2 identical errors in the last 1m0s: openfga unavailable", entries[0].Message)
+	assert.Equal("openfga unavailable", entries[1].Message)
+}
+
+func TestSamplingLoggerDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	core, logs := observer.New(zap.DebugLevel)
+	base := zap.New(core).Sugar()
+
+	assert.Nil(NewSamplingConfig(false, 60, 3))
+	assert.Nil(NewSamplingConfig(true, 0, 3))
+	assert.Nil(NewSamplingConfig(true, 60, 0))
+
+	logger := NewSamplingLogger(base, nil)
+
+	assert.Equal(base, logger)
+
+	for i := 0; i < 5; i++ {
+		logger.Error("openfga unavailable")
+	}
+
+	assert.Len(logs.AllUntimed(), 5)
+}
@@ -12,6 +12,8 @@ import (
 
 type EmailServiceInterface interface {
 	Send(context.Context, string, string, *template.Template, any) error
+	SendTemplate(context.Context, string, string, any) error
+	SendTest(context.Context, string) error
 }
 
 type MailClientInterface interface {
@@ -5,6 +5,7 @@ package mail
 
 import (
 	"context"
+	"fmt"
 	"html/template"
 	"time"
 
@@ -15,6 +16,11 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
 )
 
+const (
+	testSubject = "Identity Platform Admin UI test email"
+	testBody    = "This is a test email sent by the Identity Platform Admin UI to check the mail configuration, no action is needed."
+)
+
 type Config struct {
 	Host        string `validate:"required"`
 	Port        int    `validate:"required"`
@@ -22,9 +28,13 @@ type Config struct {
 	Password    string
 	FromAddress string `validate:"required"`
 	SendTimeout time.Duration
+	// TemplatesDir overrides the embedded email templates when set
+	TemplatesDir string
+	// TestRateLimitPerMinute caps the test emails each admin can send, 0 disables the limit
+	TestRateLimitPerMinute int
 }
 
-func NewConfig(host string, port int, username, password, from string, sendTimeout int) *Config {
+func NewConfig(host string, port int, username, password, from string, sendTimeout int, templatesDir string) *Config {
 	c := new(Config)
 
 	c.Host = host
@@ -33,13 +43,15 @@ func NewConfig(host string, port int, username, password, from string, sendTimeo
 	c.Password = password
 	c.FromAddress = from
 	c.SendTimeout = time.Duration(sendTimeout) * time.Second
+	c.TemplatesDir = templatesDir
 
 	return c
 }
 
 type EmailService struct {
-	from   string
-	client MailClientInterface
+	from      string
+	client    MailClientInterface
+	templates map[string]*EmailTemplate
 
 	tracer  trace.Tracer
 	monitor monitoring.MonitorInterface
@@ -69,6 +81,61 @@ func (e *EmailService) Send(ctx context.Context, to, subject string, template *t
 	return e.client.DialAndSendWithContext(ctx, msg)
 }
 
+// SendTemplate renders the subject and the body of the templateName email with templateArgs
+// and sends it to the recipient
+func (e *EmailService) SendTemplate(ctx context.Context, to, templateName string, templateArgs any) error {
+	ctx, span := e.tracer.Start(ctx, "mail.EmailService.SendTemplate")
+	defer span.End()
+
+	emailTemplate, ok := e.templates[templateName]
+	if !ok {
+		return fmt.Errorf("template %s not found", templateName)
+	}
+
+	subject, body, err := emailTemplate.Render(templateArgs)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.NewMsg()
+
+	if err := msg.From(e.from); err != nil {
+		return err
+	}
+
+	msg.SetBodyString(mail.TypeTextHTML, body)
+
+	if err := msg.To(to); err != nil {
+		return err
+	}
+
+	msg.Subject(subject)
+
+	return e.client.DialAndSendWithContext(ctx, msg)
+}
+
+// SendTest sends a fixed plain text email to the recipient, used to check the mail configuration
+func (e *EmailService) SendTest(ctx context.Context, to string) error {
+	ctx, span := e.tracer.Start(ctx, "mail.EmailService.SendTest")
+	defer span.End()
+
+	msg := mail.NewMsg()
+
+	if err := msg.From(e.from); err != nil {
+		return err
+	}
+
+	msg.SetBodyString(mail.TypeTextPlain, testBody)
+
+	if err := msg.To(to); err != nil {
+		return err
+	}
+
+	msg.Subject(testSubject)
+
+	return e.client.DialAndSendWithContext(ctx, msg)
+}
+
 func NewEmailService(config *Config, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *EmailService {
 	s := new(EmailService)
 	s.from = config.FromAddress
@@ -97,6 +164,13 @@ func NewEmailService(config *Config, tracer trace.Tracer, monitor monitoring.Mon
 		logger.Fatalf("failed to create email client: %s", err)
 	}
 
+	// a missing or broken template must stop the application before any email is sent
+	s.templates, err = LoadTemplates(config.TemplatesDir)
+
+	if err != nil {
+		logger.Fatalf("failed to load email templates: %s", err)
+	}
+
 	s.monitor = monitor
 	s.tracer = tracer
 	s.logger = logger
@@ -9,6 +9,7 @@ import (
 	"html/template"
 	"testing"
 
+	"github.com/wneessen/go-mail"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
 )
@@ -117,3 +118,161 @@ func TestEmailService_Send(t *testing.T) {
 		})
 	}
 }
+
+func TestEmailService_SendTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	emailTemplates, err := LoadTemplates("")
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		to           string
+		templateName string
+		setupMocks   func(*MockMailClientInterface)
+		errMsg       string
+	}{
+		{
+			name:         "Success",
+			to:           "to@example.com",
+			templateName: UserCreationInviteTemplate,
+			setupMocks: func(c *MockMailClientInterface) {
+				c.EXPECT().DialAndSendWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, msgs ...*mail.Msg) error {
+						if subject := msgs[0].GetGenHeader(mail.HeaderSubject); len(subject) != 1 || subject[0] != "Complete your registration" {
+							t.Errorf("unexpected subject %v", subject)
+						}
+
+						return nil
+					},
+				)
+			},
+		},
+		{
+			name:         "TemplateNotFound",
+			to:           "to@example.com",
+			templateName: "unknown",
+			errMsg:       "template unknown not found",
+			setupMocks:   func(c *MockMailClientInterface) {},
+		},
+		{
+			name:         "SendError",
+			to:           "to@example.com",
+			templateName: UserCreationInviteTemplate,
+			errMsg:       "test-error",
+			setupMocks: func(c *MockMailClientInterface) {
+				c.EXPECT().DialAndSendWithContext(gomock.Any(), gomock.Any()).Return(errors.New("test-error"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockTracer := NewMockTracer(ctrl)
+			mockCtx := context.TODO()
+			mockTracer.EXPECT().Start(gomock.Any(), "mail.EmailService.SendTemplate").Return(mockCtx, trace.SpanFromContext(mockCtx)).AnyTimes()
+
+			mockClient := NewMockMailClientInterface(ctrl)
+
+			e := &EmailService{
+				from:      "from@example.com",
+				client:    mockClient,
+				templates: emailTemplates,
+				tracer:    mockTracer,
+				monitor:   NewMockMonitorInterface(ctrl),
+				logger:    NewMockLoggerInterface(ctrl),
+			}
+
+			tt.setupMocks(mockClient)
+
+			err := e.SendTemplate(context.TODO(), tt.to, tt.templateName, UserCreationInviteArgs{Email: tt.to})
+
+			if tt.errMsg == "" && err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if tt.errMsg != "" && (err == nil || err.Error() != tt.errMsg) {
+				t.Errorf("expected error %s got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestEmailService_SendTest(t *testing.T) {
+	tests := []struct {
+		name       string
+		to         string
+		setupMocks func(*testing.T, *MockMailClientInterface)
+		errMsg     string
+	}{
+		{
+			name: "Success",
+			to:   "to@example.com",
+			setupMocks: func(t *testing.T, c *MockMailClientInterface) {
+				c.EXPECT().DialAndSendWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, msgs ...*mail.Msg) error {
+						if subject := msgs[0].GetGenHeader(mail.HeaderSubject); len(subject) != 1 || subject[0] != testSubject {
+							t.Errorf("unexpected subject %v", subject)
+						}
+
+						if to := msgs[0].GetToString(); len(to) != 1 || to[0] != "<to@example.com>" {
+							t.Errorf("unexpected recipients %v", to)
+						}
+
+						return nil
+					},
+				)
+			},
+		},
+		{
+			name:       "ToError",
+			to:         "invalid to address",
+			errMsg:     "failed to parse mail address \"invalid to address\": mail: no angle-addr",
+			setupMocks: func(t *testing.T, c *MockMailClientInterface) {},
+		},
+		{
+			name:   "SendError",
+			to:     "to@example.com",
+			errMsg: "535 authentication failed",
+			setupMocks: func(t *testing.T, c *MockMailClientInterface) {
+				c.EXPECT().DialAndSendWithContext(gomock.Any(), gomock.Any()).Return(errors.New("535 authentication failed"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTracer := NewMockTracer(ctrl)
+			mockTracer.EXPECT().Start(gomock.Any(), "mail.EmailService.SendTest").Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			mockClient := NewMockMailClientInterface(ctrl)
+
+			e := &EmailService{
+				from:    "from@example.com",
+				client:  mockClient,
+				tracer:  mockTracer,
+				monitor: NewMockMonitorInterface(ctrl),
+				logger:  NewMockLoggerInterface(ctrl),
+			}
+
+			tt.setupMocks(t, mockClient)
+
+			err := e.SendTest(context.TODO(), tt.to)
+
+			if tt.errMsg == "" && err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if tt.errMsg != "" && (err == nil || err.Error() != tt.errMsg) {
+				t.Errorf("expected error %s got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
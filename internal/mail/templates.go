@@ -4,27 +4,47 @@
 package mail
 
 import (
+	"bytes"
 	"embed"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"os"
+	"path"
 	"strings"
+	textTemplate "text/template"
+)
+
+const (
+	// UserCreationInviteTemplate is the name of the email sent to newly created identities
+	UserCreationInviteTemplate = "user-invite"
+
+	subjectTemplateSuffix = ".subject.txt"
+	bodyTemplateSuffix    = ".html"
 )
 
 var (
 	//go:embed html/user-invite.html
 	UserCreationInvite embed.FS
+
+	//go:embed html/*.html html/*.subject.txt
+	defaultTemplates embed.FS
 )
 
 var (
 	templates = map[embed.FS]string{
 		UserCreationInvite: "html/user-invite.html",
 	}
+
+	// TemplateNames lists the templates the EmailService is able to render
+	TemplateNames = []string{UserCreationInviteTemplate}
 )
 
 type UserCreationInviteArgs struct {
 	InviteUrl    string
 	RecoveryCode string
 	Email        string
+	Traits       map[string]interface{}
 }
 
 func LoadTemplate(templateFS embed.FS) (*template.Template, error) {
@@ -36,3 +56,57 @@ func LoadTemplate(templateFS embed.FS) (*template.Template, error) {
 	templateName := strings.SplitN(templatePattern, "/", 2)[1]
 	return template.New(templateName).ParseFS(templateFS, templatePattern)
 }
+
+// EmailTemplate holds the subject, rendered as plain text, and the HTML body of an email
+type EmailTemplate struct {
+	subject *textTemplate.Template
+	body    *template.Template
+}
+
+// Render returns the subject and the body of the email filled in with data
+func (t *EmailTemplate) Render(data any) (string, string, error) {
+	subject := new(bytes.Buffer)
+	if err := t.subject.Execute(subject, data); err != nil {
+		return "", "", err
+	}
+
+	body := new(bytes.Buffer)
+	if err := t.body.Execute(body, data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), body.String(), nil
+}
+
+// LoadTemplates parses all the TemplateNames, from dir if not empty or from the embedded defaults
+// otherwise, a configured dir must provide both <name>.subject.txt and <name>.html for each template
+func LoadTemplates(dir string) (map[string]*EmailTemplate, error) {
+	var fsys fs.FS = defaultTemplates
+	root := "html"
+
+	if dir != "" {
+		fsys = os.DirFS(dir)
+		root = "."
+	}
+
+	emailTemplates := make(map[string]*EmailTemplate, len(TemplateNames))
+
+	for _, name := range TemplateNames {
+		subjectFile := path.Join(root, name+subjectTemplateSuffix)
+		bodyFile := path.Join(root, name+bodyTemplateSuffix)
+
+		subject, err := textTemplate.New(name+subjectTemplateSuffix).ParseFS(fsys, subjectFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load subject template %s: %s", name, err)
+		}
+
+		body, err := template.New(name+bodyTemplateSuffix).ParseFS(fsys, bodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load body template %s: %s", name, err)
+		}
+
+		emailTemplates[name] = &EmailTemplate{subject: subject, body: body}
+	}
+
+	return emailTemplates, nil
+}
@@ -5,6 +5,9 @@ package mail
 
 import (
 	"embed"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -58,3 +61,76 @@ func TestLoadTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadTemplatesDefault(t *testing.T) {
+	emailTemplates, err := LoadTemplates("")
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for _, name := range TemplateNames {
+		if _, ok := emailTemplates[name]; !ok {
+			t.Errorf("expected template %s to be loaded", name)
+		}
+	}
+
+	subject, body, err := emailTemplates[UserCreationInviteTemplate].Render(
+		UserCreationInviteArgs{InviteUrl: "https://example.com/recovery", RecoveryCode: "123456", Email: "joe@example.com"},
+	)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if subject != "Complete your registration" {
+		t.Errorf("expected default subject, got %s", subject)
+	}
+
+	if !strings.Contains(body, "https://example.com/recovery") || !strings.Contains(body, "123456") {
+		t.Errorf("expected body to contain the recovery link and code")
+	}
+}
+
+func TestLoadTemplatesFromDir(t *testing.T) {
+	dir := t.TempDir()
+
+	os.WriteFile(filepath.Join(dir, "user-invite.subject.txt"), []byte("Welcome to Acme, {{ index .Traits \"name\" }}"), 0644)
+	os.WriteFile(filepath.Join(dir, "user-invite.html"), []byte(`<a href="{{ .InviteUrl }}">{{ index .Traits "name" }}</a>`), 0644)
+
+	emailTemplates, err := LoadTemplates(dir)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	subject, body, err := emailTemplates[UserCreationInviteTemplate].Render(
+		UserCreationInviteArgs{
+			InviteUrl: "https://example.com/recovery?a=1&b=2",
+			Traits:    map[string]interface{}{"name": "<Joe>"},
+		},
+	)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	// subject is plain text, body gets HTML escaping
+	if subject != "Welcome to Acme, <Joe>" {
+		t.Errorf("unexpected subject %s", subject)
+	}
+
+	if body != `<a href="https://example.com/recovery?a=1&amp;b=2">&lt;Joe&gt;</a>` {
+		t.Errorf("unexpected body %s", body)
+	}
+}
+
+func TestLoadTemplatesFailsWithMissingFile(t *testing.T) {
+	dir := t.TempDir()
+
+	os.WriteFile(filepath.Join(dir, "user-invite.html"), []byte(`{{ .InviteUrl }}`), 0644)
+
+	if _, err := LoadTemplates(dir); err == nil {
+		t.Fatalf("expected error not to be nil")
+	}
+}
@@ -6,8 +6,18 @@ package monitoring
 type MonitorInterface interface {
 	GetService() string
 	GetResponseTimeMetric(map[string]string) (MetricInterface, error)
+	GetAuthzModelReloadMetric(map[string]string) (MetricInterface, error)
+	GetOpenFGACallMetric(map[string]string) (MetricInterface, error)
+	GetOpenFGACheckCacheMetric(map[string]string) (CounterInterface, error)
+	GetOpenFGARetryMetric(map[string]string) (CounterInterface, error)
+	GetAuthorizationDecisionMetric(map[string]string) (CounterInterface, error)
+	GetWebhookDroppedEventsMetric(map[string]string) (CounterInterface, error)
 }
 
 type MetricInterface interface {
 	Observe(float64)
 }
+
+type CounterInterface interface {
+	Inc()
+}
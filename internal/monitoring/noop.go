@@ -17,6 +17,10 @@ type NoopMetricInterface struct{}
 
 func (m *NoopMetricInterface) Observe(float64) {}
 
+type NoopCounterInterface struct{}
+
+func (m *NoopCounterInterface) Inc() {}
+
 func NewNoopMonitor(service string, logger logging.LoggerInterface) *NoopMonitor {
 	m := new(NoopMonitor)
 	m.service = service
@@ -37,3 +41,27 @@ func (m *NoopMonitor) SetDependencyAvailability(map[string]string, float64) erro
 func (m *NoopMonitor) GetResponseTimeMetric(tags map[string]string) (MetricInterface, error) {
 	return new(NoopMetricInterface), nil
 }
+
+func (m *NoopMonitor) GetAuthzModelReloadMetric(tags map[string]string) (MetricInterface, error) {
+	return new(NoopMetricInterface), nil
+}
+
+func (m *NoopMonitor) GetOpenFGACallMetric(tags map[string]string) (MetricInterface, error) {
+	return new(NoopMetricInterface), nil
+}
+
+func (m *NoopMonitor) GetOpenFGACheckCacheMetric(tags map[string]string) (CounterInterface, error) {
+	return new(NoopCounterInterface), nil
+}
+
+func (m *NoopMonitor) GetOpenFGARetryMetric(tags map[string]string) (CounterInterface, error) {
+	return new(NoopCounterInterface), nil
+}
+
+func (m *NoopMonitor) GetAuthorizationDecisionMetric(tags map[string]string) (CounterInterface, error) {
+	return new(NoopCounterInterface), nil
+}
+
+func (m *NoopMonitor) GetWebhookDroppedEventsMetric(tags map[string]string) (CounterInterface, error) {
+	return new(NoopCounterInterface), nil
+}
@@ -15,7 +15,14 @@ import (
 type Monitor struct {
 	service string
 
-	responseTime *prometheus.HistogramVec
+	responseTime     *prometheus.HistogramVec
+	authzModelReload *prometheus.HistogramVec
+	openfgaCall      *prometheus.HistogramVec
+
+	openfgaCheckCache *prometheus.CounterVec
+	openfgaRetry      *prometheus.CounterVec
+	authzDecision     *prometheus.CounterVec
+	webhookDropped    *prometheus.CounterVec
 
 	logger logging.LoggerInterface
 }
@@ -32,6 +39,54 @@ func (m *Monitor) GetResponseTimeMetric(tags map[string]string) (monitoring.Metr
 	return m.responseTime.With(tags), nil
 }
 
+func (m *Monitor) GetAuthzModelReloadMetric(tags map[string]string) (monitoring.MetricInterface, error) {
+	if m.authzModelReload == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.authzModelReload.With(tags), nil
+}
+
+func (m *Monitor) GetOpenFGACallMetric(tags map[string]string) (monitoring.MetricInterface, error) {
+	if m.openfgaCall == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.openfgaCall.With(tags), nil
+}
+
+func (m *Monitor) GetOpenFGACheckCacheMetric(tags map[string]string) (monitoring.CounterInterface, error) {
+	if m.openfgaCheckCache == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.openfgaCheckCache.With(tags), nil
+}
+
+func (m *Monitor) GetOpenFGARetryMetric(tags map[string]string) (monitoring.CounterInterface, error) {
+	if m.openfgaRetry == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.openfgaRetry.With(tags), nil
+}
+
+func (m *Monitor) GetAuthorizationDecisionMetric(tags map[string]string) (monitoring.CounterInterface, error) {
+	if m.authzDecision == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.authzDecision.With(tags), nil
+}
+
+func (m *Monitor) GetWebhookDroppedEventsMetric(tags map[string]string) (monitoring.CounterInterface, error) {
+	if m.webhookDropped == nil {
+		return nil, fmt.Errorf("metric not instantiated")
+	}
+
+	return m.webhookDropped.With(tags), nil
+}
+
 func (m *Monitor) registerHistograms() {
 	histograms := make([]*prometheus.HistogramVec, 0)
 
@@ -48,14 +103,32 @@ func (m *Monitor) registerHistograms() {
 		[]string{"route", "status"},
 	)
 
-	histograms = append(histograms, m.responseTime)
+	m.authzModelReload = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "authz_model_reload_seconds",
+			Help:        "authz_model_reload_seconds",
+			ConstLabels: labels,
+		},
+		[]string{"model_id"},
+	)
+
+	m.openfgaCall = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "openfga_call_duration_seconds",
+			Help:        "openfga_call_duration_seconds",
+			ConstLabels: labels,
+		},
+		[]string{"method", "outcome"},
+	)
+
+	histograms = append(histograms, m.responseTime, m.authzModelReload, m.openfgaCall)
 
 	for _, histogram := range histograms {
 		err := prometheus.Register(histogram)
 
 		switch err.(type) {
 		case nil:
-			return
+			continue
 		case prometheus.AlreadyRegisteredError:
 			m.logger.Debugf("metric %v already registered", histogram)
 		default:
@@ -64,6 +137,66 @@ func (m *Monitor) registerHistograms() {
 	}
 }
 
+func (m *Monitor) registerCounters() {
+	counters := make([]*prometheus.CounterVec, 0)
+
+	labels := map[string]string{
+		"service": m.service,
+	}
+
+	m.openfgaCheckCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "openfga_check_cache_total",
+			Help:        "openfga_check_cache_total",
+			ConstLabels: labels,
+		},
+		[]string{"result"},
+	)
+
+	m.openfgaRetry = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "openfga_retries_total",
+			Help:        "openfga_retries_total",
+			ConstLabels: labels,
+		},
+		[]string{"method", "outcome"},
+	)
+
+	// object IDs are left out of the labels to keep the cardinality bounded
+	m.authzDecision = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "authorization_decisions_total",
+			Help:        "authorization_decisions_total",
+			ConstLabels: labels,
+		},
+		[]string{"decision", "resource_type", "relation"},
+	)
+
+	m.webhookDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:        "webhook_dropped_events_total",
+			Help:        "webhook_dropped_events_total",
+			ConstLabels: labels,
+		},
+		[]string{"reason"},
+	)
+
+	counters = append(counters, m.openfgaCheckCache, m.openfgaRetry, m.authzDecision, m.webhookDropped)
+
+	for _, counter := range counters {
+		err := prometheus.Register(counter)
+
+		switch err.(type) {
+		case nil:
+			continue
+		case prometheus.AlreadyRegisteredError:
+			m.logger.Debugf("metric %v already registered", counter)
+		default:
+			m.logger.Errorf("metric %v could not be registered", counter)
+		}
+	}
+}
+
 func NewMonitor(service string, logger logging.LoggerInterface) *Monitor {
 	m := new(Monitor)
 
@@ -71,6 +204,7 @@ func NewMonitor(service string, logger logging.LoggerInterface) *Monitor {
 	m.logger = logger
 
 	m.registerHistograms()
+	m.registerCounters()
 
 	return m
 }
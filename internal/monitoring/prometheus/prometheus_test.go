@@ -0,0 +1,190 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+)
+
+func TestOpenFGACallMetricIsRegisteredAndObserved(t *testing.T) {
+	m := NewMonitor("test-openfga", logging.NewNoopLogger())
+
+	metric, err := m.GetOpenFGACallMetric(map[string]string{"method": "Check", "outcome": "success"})
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	metric.Observe(0.25)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "openfga_call_duration_seconds" {
+			continue
+		}
+
+		for _, sample := range family.GetMetric() {
+			labels := make(map[string]string)
+
+			for _, label := range sample.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["method"] != "Check" || labels["outcome"] != "success" || labels["service"] != "test-openfga" {
+				continue
+			}
+
+			if count := sample.GetHistogram().GetSampleCount(); count != 1 {
+				t.Fatalf("expected 1 observation got %v", count)
+			}
+
+			if sum := sample.GetHistogram().GetSampleSum(); sum != 0.25 {
+				t.Fatalf("expected observations sum to be 0.25 got %v", sum)
+			}
+
+			return
+		}
+	}
+
+	t.Fatal("openfga_call_duration_seconds not found in the default registry")
+}
+
+func TestOpenFGARetryMetricIsRegisteredAndIncremented(t *testing.T) {
+	m := NewMonitor("test-openfga-retry", logging.NewNoopLogger())
+
+	for _, outcome := range []string{"retried", "retried", "failed"} {
+		counter, err := m.GetOpenFGARetryMetric(map[string]string{"method": "Check", "outcome": outcome})
+
+		if err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+
+		counter.Inc()
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	counts := make(map[string]float64)
+
+	for _, family := range families {
+		if family.GetName() != "openfga_retries_total" {
+			continue
+		}
+
+		for _, sample := range family.GetMetric() {
+			for _, label := range sample.GetLabel() {
+				if label.GetName() == "outcome" {
+					counts[label.GetValue()] = sample.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	if counts["retried"] != 2 || counts["failed"] != 1 {
+		t.Fatalf("expected 2 retried and 1 failed got %v", counts)
+	}
+}
+
+func TestOpenFGACheckCacheMetricIsRegisteredAndIncremented(t *testing.T) {
+	m := NewMonitor("test-openfga-cache", logging.NewNoopLogger())
+
+	for _, result := range []string{"hit", "hit", "miss"} {
+		counter, err := m.GetOpenFGACheckCacheMetric(map[string]string{"result": result})
+
+		if err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+
+		counter.Inc()
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	counts := make(map[string]float64)
+
+	for _, family := range families {
+		if family.GetName() != "openfga_check_cache_total" {
+			continue
+		}
+
+		for _, sample := range family.GetMetric() {
+			labels := make(map[string]string)
+
+			for _, label := range sample.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["service"] == "test-openfga-cache" {
+				counts[labels["result"]] = sample.GetCounter().GetValue()
+			}
+		}
+	}
+
+	if counts["hit"] != 2 || counts["miss"] != 1 {
+		t.Fatalf("expected 2 hits and 1 miss got %v", counts)
+	}
+}
+
+func TestAuthorizationDecisionMetricIsRegisteredAndIncremented(t *testing.T) {
+	m := NewMonitor("test-authz-decision", logging.NewNoopLogger())
+
+	for _, decision := range []string{"allow", "allow", "deny"} {
+		counter, err := m.GetAuthorizationDecisionMetric(
+			map[string]string{"decision": decision, "resource_type": "identity", "relation": "can_view"},
+		)
+
+		if err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+
+		counter.Inc()
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	counts := make(map[string]float64)
+
+	for _, family := range families {
+		if family.GetName() != "authorization_decisions_total" {
+			continue
+		}
+
+		for _, sample := range family.GetMetric() {
+			labels := make(map[string]string)
+
+			for _, label := range sample.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["service"] == "test-authz-decision" && labels["resource_type"] == "identity" && labels["relation"] == "can_view" {
+				counts[labels["decision"]] = sample.GetCounter().GetValue()
+			}
+		}
+	}
+
+	if counts["allow"] != 2 || counts["deny"] != 1 {
+		t.Fatalf("expected 2 allowed and 1 denied got %v", counts)
+	}
+}
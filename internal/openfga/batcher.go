@@ -0,0 +1,214 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+)
+
+const (
+	requestPending int32 = iota
+	requestFlushing
+	requestCancelled
+)
+
+type writeRequest struct {
+	ctx    context.Context
+	tuples []Tuple
+	result chan error
+
+	// state moves from requestPending to either requestFlushing or requestCancelled, whichever
+	// happens first, so a request is never both written and reported as cancelled
+	state atomic.Int32
+}
+
+// claim marks the request as being written, false if it was cancelled first
+func (r *writeRequest) claim() bool {
+	return r.state.CompareAndSwap(requestPending, requestFlushing)
+}
+
+// Cancel leaves the request out of its batch, false if the batch is already being written,
+// the outcome of the write is then sent on result as usual
+func (r *writeRequest) Cancel() bool {
+	return r.state.CompareAndSwap(requestPending, requestCancelled)
+}
+
+// writeBatcher coalesces tuple writes issued within a short window into a single write, a batch
+// is flushed when the window expires or when it reaches maxSize tuples, whichever comes first
+type writeBatcher struct {
+	mu sync.Mutex
+
+	window  time.Duration
+	maxSize int
+
+	current []*writeRequest
+	size    int
+	// sealed batches are full and waiting to be flushed, in submission order
+	sealed [][]*writeRequest
+	timer  *time.Timer
+
+	// flushMu serializes flushes and exclusive operations so they reach OpenFGA in submission order
+	flushMu sync.Mutex
+
+	write  func(context.Context, ...Tuple) error
+	tracer tracing.TracingInterface
+}
+
+// Write queues tuples for the next batch, the request result receives the outcome of the write
+// once the batch has been flushed, or ctx error if ctx is done before the batch is flushed
+func (b *writeBatcher) Write(ctx context.Context, tuples ...Tuple) *writeRequest {
+	r := &writeRequest{ctx: ctx, tuples: tuples, result: make(chan error, 1)}
+
+	b.mu.Lock()
+
+	// keep batches under maxSize, a request bigger than maxSize gets a batch of its own
+	if len(b.current) > 0 && b.size+len(tuples) > b.maxSize {
+		b.seal()
+	}
+
+	b.current = append(b.current, r)
+	b.size += len(tuples)
+
+	if b.size >= b.maxSize {
+		b.seal()
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, b.Flush)
+	}
+
+	full := len(b.sealed) > 0
+
+	b.mu.Unlock()
+
+	if full {
+		go b.Flush()
+	}
+
+	return r
+}
+
+// Flush writes all the pending batches
+func (b *writeBatcher) Flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	for _, batch := range b.take() {
+		b.flush(batch)
+	}
+}
+
+// Exclusive flushes the pending batches then runs fn, writes queued while fn runs are only flushed
+// after it returns, used by deletes so they are never reordered with writes on the same objects
+func (b *writeBatcher) Exclusive(fn func() error) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	for _, batch := range b.take() {
+		b.flush(batch)
+	}
+
+	return fn()
+}
+
+// seal moves the current batch to the sealed ones, needs mu to be held
+func (b *writeBatcher) seal() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+
+	if len(b.current) == 0 {
+		return
+	}
+
+	b.sealed = append(b.sealed, b.current)
+	b.current = nil
+	b.size = 0
+}
+
+func (b *writeBatcher) take() [][]*writeRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seal()
+
+	batches := b.sealed
+	b.sealed = nil
+
+	return batches
+}
+
+func (b *writeBatcher) flush(batch []*writeRequest) {
+	claimed := make([]*writeRequest, 0, len(batch))
+	links := make([]trace.Link, 0, len(batch))
+
+	for _, r := range batch {
+		// requests whose context is done are dropped, their callers already gave up on them
+		if err := r.ctx.Err(); err != nil {
+			if r.Cancel() {
+				r.result <- err
+			}
+
+			continue
+		}
+
+		if !r.claim() {
+			continue
+		}
+
+		claimed = append(claimed, r)
+
+		if sc := trace.SpanContextFromContext(r.ctx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+
+	if len(claimed) == 0 {
+		return
+	}
+
+	// the batch outlives the requests that filled it, don't tie it to any of their contexts,
+	// link its span to theirs instead
+	ctx, span := b.tracer.Start(context.Background(), "openfga.writeBatcher.flush", trace.WithLinks(links...))
+	defer span.End()
+
+	tuples := make([]Tuple, 0)
+
+	for _, r := range claimed {
+		tuples = append(tuples, r.tuples...)
+	}
+
+	err := b.write(ctx, tuples...)
+
+	if err == nil || len(claimed) == 1 {
+		for _, r := range claimed {
+			r.result <- err
+		}
+
+		return
+	}
+
+	// writes are transactional, retry each request alone so a single bad tuple
+	// only fails the request it belongs to
+	for _, r := range claimed {
+		r.result <- b.write(ctx, r.tuples...)
+	}
+}
+
+func newWriteBatcher(window time.Duration, maxSize int, write func(context.Context, ...Tuple) error, tracer tracing.TracingInterface) *writeBatcher {
+	b := new(writeBatcher)
+
+	b.window = window
+	b.maxSize = maxSize
+	b.write = write
+	b.tracer = tracer
+
+	return b
+}
@@ -0,0 +1,277 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+)
+
+type recordingWriter struct {
+	mu    sync.Mutex
+	calls [][]Tuple
+	err   func([]Tuple) error
+}
+
+func (w *recordingWriter) write(ctx context.Context, tuples ...Tuple) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.calls = append(w.calls, tuples)
+
+	if w.err != nil {
+		return w.err(tuples)
+	}
+
+	return nil
+}
+
+func (w *recordingWriter) Calls() [][]Tuple {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.calls
+}
+
+func waitResult(t *testing.T, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("write was never flushed")
+	}
+
+	return nil
+}
+
+func TestWriteBatcherCoalescesWithinWindow(t *testing.T) {
+	w := new(recordingWriter)
+	b := newWriteBatcher(20*time.Millisecond, 10, w.write, tracing.NewNoopTracer())
+
+	first := b.Write(context.Background(), *NewTuple("user:joe", "member", "group:1")).result
+	second := b.Write(context.Background(), *NewTuple("user:jane", "member", "group:1")).result
+
+	for _, result := range []<-chan error{first, second} {
+		if err := waitResult(t, result); err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+
+	expected := [][]Tuple{
+		{*NewTuple("user:joe", "member", "group:1"), *NewTuple("user:jane", "member", "group:1")},
+	}
+
+	if !reflect.DeepEqual(w.Calls(), expected) {
+		t.Fatalf("expected writes to be %v got %v", expected, w.Calls())
+	}
+}
+
+func TestWriteBatcherFlushesOnMaxSize(t *testing.T) {
+	w := new(recordingWriter)
+	// the window never expires during the test, only the size can trigger the flushes
+	b := newWriteBatcher(time.Hour, 2, w.write, tracing.NewNoopTracer())
+
+	results := []<-chan error{
+		b.Write(context.Background(), *NewTuple("user:joe", "member", "group:1")).result,
+		b.Write(context.Background(), *NewTuple("user:jane", "member", "group:1")).result,
+		b.Write(context.Background(), *NewTuple("user:bob", "member", "group:1"), *NewTuple("user:bob", "member", "group:2")).result,
+	}
+
+	for _, result := range results {
+		if err := waitResult(t, result); err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+
+	if calls := w.Calls(); len(calls) != 2 || len(calls[0]) != 2 || len(calls[1]) != 2 {
+		t.Fatalf("expected 2 writes of 2 tuples got %v", calls)
+	}
+}
+
+func TestWriteBatcherReportsPerWriteErrors(t *testing.T) {
+	bad := *NewTuple("user:joe", "member", "group:missing")
+
+	w := new(recordingWriter)
+	w.err = func(tuples []Tuple) error {
+		for _, tuple := range tuples {
+			if tuple == bad {
+				return fmt.Errorf("invalid tuple")
+			}
+		}
+
+		return nil
+	}
+
+	b := newWriteBatcher(time.Hour, 10, w.write, tracing.NewNoopTracer())
+
+	ok := b.Write(context.Background(), *NewTuple("user:jane", "member", "group:1")).result
+	failed := b.Write(context.Background(), bad).result
+
+	b.Flush()
+
+	if err := waitResult(t, ok); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if err := waitResult(t, failed); err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+
+	// the batch, then each request on its own
+	if calls := w.Calls(); len(calls) != 3 {
+		t.Fatalf("expected 3 writes got %v", calls)
+	}
+}
+
+func TestWriteBatcherExclusiveKeepsOrdering(t *testing.T) {
+	w := new(recordingWriter)
+	b := newWriteBatcher(time.Hour, 10, w.write, tracing.NewNoopTracer())
+
+	before := b.Write(context.Background(), *NewTuple("user:joe", "member", "group:1")).result
+
+	var after <-chan error
+
+	err := b.Exclusive(
+		func() error {
+			if calls := w.Calls(); len(calls) != 1 {
+				t.Errorf("expected pending writes to be flushed before the delete got %v", calls)
+			}
+
+			// writes queued while the delete runs are held back until it is done
+			after = b.Write(context.Background(), *NewTuple("user:joe", "member", "group:1")).result
+			go b.Flush()
+
+			time.Sleep(10 * time.Millisecond)
+
+			if calls := w.Calls(); len(calls) != 1 {
+				t.Errorf("expected writes queued during the delete to wait got %v", calls)
+			}
+
+			return nil
+		},
+	)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for _, result := range []<-chan error{before, after} {
+		if err := waitResult(t, result); err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+
+	if calls := w.Calls(); len(calls) != 2 {
+		t.Fatalf("expected 2 writes got %v", calls)
+	}
+}
+
+func TestWriteBatcherDropsCancelledRequests(t *testing.T) {
+	w := new(recordingWriter)
+	b := newWriteBatcher(time.Hour, 10, w.write, tracing.NewNoopTracer())
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cancelled := b.Write(ctx, *NewTuple("user:joe", "member", "group:1"))
+	abandoned := b.Write(context.Background(), *NewTuple("user:bob", "member", "group:1"))
+	kept := b.Write(context.Background(), *NewTuple("user:jane", "member", "group:1"))
+
+	cancel()
+
+	if !abandoned.Cancel() {
+		t.Fatal("expected pending request to be cancelled")
+	}
+
+	b.Flush()
+
+	if err := waitResult(t, cancelled.result); err != context.Canceled {
+		t.Fatalf("expected error to be %v got %v", context.Canceled, err)
+	}
+
+	if err := waitResult(t, kept.result); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	expected := [][]Tuple{{*NewTuple("user:jane", "member", "group:1")}}
+
+	if !reflect.DeepEqual(w.Calls(), expected) {
+		t.Fatalf("expected writes to be %v got %v", expected, w.Calls())
+	}
+
+	if kept.Cancel() {
+		t.Fatal("expected flushed request not to be cancelled")
+	}
+}
+
+func TestWriteBatcherLinksCallerSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	w := new(recordingWriter)
+	b := newWriteBatcher(time.Hour, 10, w.write, tracer)
+
+	first, firstSpan := tracer.Start(context.Background(), "first")
+	second, secondSpan := tracer.Start(context.Background(), "second")
+
+	results := []<-chan error{
+		b.Write(first, *NewTuple("user:joe", "member", "group:1")).result,
+		b.Write(second, *NewTuple("user:jane", "member", "group:1")).result,
+	}
+
+	b.Flush()
+
+	for _, result := range results {
+		if err := waitResult(t, result); err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+
+	firstSpan.End()
+	secondSpan.End()
+
+	for _, span := range recorder.Ended() {
+		if span.Name() != "openfga.writeBatcher.flush" {
+			continue
+		}
+
+		links := span.Links()
+
+		if len(links) != 2 || links[0].SpanContext.SpanID() != firstSpan.SpanContext().SpanID() || links[1].SpanContext.SpanID() != secondSpan.SpanContext().SpanID() {
+			t.Fatalf("expected batch span to link the caller spans got %v", links)
+		}
+
+		if span.Parent().IsValid() {
+			t.Fatalf("expected batch span to have no parent got %v", span.Parent())
+		}
+
+		return
+	}
+
+	t.Fatal("expected batch span to be recorded")
+}
+
+func TestNewWriteBatchConfig(t *testing.T) {
+	if c := NewWriteBatchConfig(false, 20, 50); c != nil {
+		t.Fatalf("expected disabled config to be nil got %v", c)
+	}
+
+	if c := NewWriteBatchConfig(true, 0, 50); c != nil {
+		t.Fatalf("expected config without window to be nil got %v", c)
+	}
+
+	c := NewWriteBatchConfig(true, 20, 500)
+
+	if c.Window != 20*time.Millisecond || c.MaxSize != maxWriteTuples {
+		t.Fatalf("expected window of 20ms and size capped to %v got %v", maxWriteTuples, c)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type checkKey struct {
+	user     string
+	relation string
+	object   string
+	modelID  string
+}
+
+type checkEntry struct {
+	key     checkKey
+	allowed bool
+	expires time.Time
+}
+
+// checkCache is a LRU cache of Check results with a TTL on each entry, entries are indexed by
+// object so writes can drop all the cached checks on the objects they touch
+type checkCache struct {
+	mu sync.Mutex
+
+	size int
+	ttl  time.Duration
+
+	lru     *list.List
+	entries map[checkKey]*list.Element
+	objects map[string]map[checkKey]struct{}
+
+	// generation is bumped on every invalidation, results of checks started before it are discarded
+	generation uint64
+
+	now func() time.Time
+}
+
+// Generation returns the current generation, to be passed to Set once the check completes
+func (c *checkCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *checkCache) Get(key checkKey) (bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+
+	if !ok {
+		return false, false
+	}
+
+	entry := element.Value.(*checkEntry)
+
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+		return false, false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return entry.allowed, true
+}
+
+// Set caches the result of a check, nothing is stored if the cache was invalidated after generation
+func (c *checkCache) Set(key checkKey, allowed bool, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.lru.PushFront(&checkEntry{key: key, allowed: allowed, expires: c.now().Add(c.ttl)})
+
+	if _, ok := c.objects[key.object]; !ok {
+		c.objects[key.object] = make(map[checkKey]struct{})
+	}
+
+	c.objects[key.object][key] = struct{}{}
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// InvalidateObjects drops every cached check on the objects passed
+func (c *checkCache) InvalidateObjects(objects ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	for _, object := range objects {
+		for key := range c.objects[object] {
+			c.remove(c.entries[key])
+		}
+	}
+}
+
+// Purge drops all the cached checks
+func (c *checkCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.lru.Init()
+	c.entries = make(map[checkKey]*list.Element)
+	c.objects = make(map[string]map[checkKey]struct{})
+}
+
+func (c *checkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *checkCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*checkEntry)
+
+	delete(c.entries, entry.key)
+	delete(c.objects[entry.key.object], entry.key)
+
+	if len(c.objects[entry.key.object]) == 0 {
+		delete(c.objects, entry.key.object)
+	}
+}
+
+func newCheckCache(size int, ttl time.Duration) *checkCache {
+	c := new(checkCache)
+
+	c.size = size
+	c.ttl = ttl
+	c.lru = list.New()
+	c.entries = make(map[checkKey]*list.Element)
+	c.objects = make(map[string]map[checkKey]struct{})
+	c.now = time.Now
+
+	return c
+}
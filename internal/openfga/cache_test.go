@@ -0,0 +1,117 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckCacheGetSet(t *testing.T) {
+	now := time.Now()
+
+	c := newCheckCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	key := checkKey{user: "user:joe", relation: "can_view", object: "group:admins", modelID: "model"}
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected empty cache to miss")
+	}
+
+	c.Set(key, true, c.Generation())
+
+	if allowed, ok := c.Get(key); !ok || !allowed {
+		t.Fatalf("expected cached check to be allowed got %v %v", allowed, ok)
+	}
+
+	other := key
+	other.modelID = "new-model"
+
+	if _, ok := c.Get(other); ok {
+		t.Fatal("expected check on a different model to miss")
+	}
+
+	now = now.Add(time.Minute)
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected expired check to miss")
+	}
+
+	if c.Len() != 0 {
+		t.Fatalf("expected expired check to be removed got %v entries", c.Len())
+	}
+}
+
+func TestCheckCacheEviction(t *testing.T) {
+	c := newCheckCache(2, time.Minute)
+
+	first := checkKey{user: "user:joe", relation: "can_view", object: "group:1"}
+	second := checkKey{user: "user:joe", relation: "can_view", object: "group:2"}
+	third := checkKey{user: "user:joe", relation: "can_view", object: "group:3"}
+
+	c.Set(first, true, c.Generation())
+	c.Set(second, true, c.Generation())
+
+	// first becomes the most recently used
+	c.Get(first)
+
+	c.Set(third, true, c.Generation())
+
+	if _, ok := c.Get(second); ok {
+		t.Fatal("expected least recently used check to be evicted")
+	}
+
+	for _, key := range []checkKey{first, third} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("expected %v to be cached", key)
+		}
+	}
+}
+
+func TestCheckCacheInvalidateObjects(t *testing.T) {
+	c := newCheckCache(10, time.Minute)
+
+	view := checkKey{user: "user:joe", relation: "can_view", object: "group:1"}
+	edit := checkKey{user: "user:jane", relation: "can_edit", object: "group:1"}
+	other := checkKey{user: "user:joe", relation: "can_view", object: "group:2"}
+
+	for _, key := range []checkKey{view, edit, other} {
+		c.Set(key, true, c.Generation())
+	}
+
+	c.InvalidateObjects("group:1")
+
+	for _, key := range []checkKey{view, edit} {
+		if _, ok := c.Get(key); ok {
+			t.Fatalf("expected %v to be invalidated", key)
+		}
+	}
+
+	if _, ok := c.Get(other); !ok {
+		t.Fatal("expected check on an untouched object to be kept")
+	}
+}
+
+func TestCheckCacheSetAfterInvalidationIsDiscarded(t *testing.T) {
+	c := newCheckCache(10, time.Minute)
+
+	key := checkKey{user: "user:joe", relation: "can_view", object: "group:1"}
+
+	// a check started before a write completes after it
+	generation := c.Generation()
+	c.InvalidateObjects("group:1")
+	c.Set(key, true, generation)
+
+	if _, ok := c.Get(key); ok {
+		t.Fatal("expected stale check result to be discarded")
+	}
+
+	c.Set(key, false, c.Generation())
+	c.Purge()
+
+	if c.Len() != 0 {
+		t.Fatalf("expected purge to empty the cache got %v entries", c.Len())
+	}
+}
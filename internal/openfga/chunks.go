@@ -0,0 +1,70 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxTuplesPerWrite is the number of tuples OpenFGA accepts in a single write request
+const MaxTuplesPerWrite = 100
+
+// WriteTuplesInChunks writes tuples MaxTuplesPerWrite at a time, a single write is transactional
+// but a sequence of them is not, so if a chunk fails the chunks written before it are deleted
+// again, a failed rollback is joined to the returned error
+func WriteTuplesInChunks(ctx context.Context, w TupleWriterInterface, tuples ...Tuple) error {
+	for start := 0; start < len(tuples); start += MaxTuplesPerWrite {
+		if err := w.WriteTuples(ctx, tuples[start:min(start+MaxTuplesPerWrite, len(tuples))]...); err != nil {
+			// roll back even if the write failed because ctx is done
+			if rerr := DeleteTuplesInChunks(context.WithoutCancel(ctx), w, tuples[:start]...); rerr != nil {
+				return errors.Join(err, fmt.Errorf("failed rolling back %d tuples: %w", start, rerr))
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTuplesInChunks deletes tuples MaxTuplesPerWrite at a time, it stops at the first chunk
+// failing, the chunks deleted before it are not restored
+func DeleteTuplesInChunks(ctx context.Context, w TupleWriterInterface, tuples ...Tuple) error {
+	for start := 0; start < len(tuples); start += MaxTuplesPerWrite {
+		if err := w.DeleteTuples(ctx, tuples[start:min(start+MaxTuplesPerWrite, len(tuples))]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReplaceTuplesInChunks writes added and then deletes removed, both MaxTuplesPerWrite at a time,
+// if a delete fails the removed tuples deleted so far are written back and added is deleted so
+// that the store is left as it was found, a failed rollback is joined to the returned error
+func ReplaceTuplesInChunks(ctx context.Context, w TupleWriterInterface, removed, added []Tuple) error {
+	if err := WriteTuplesInChunks(ctx, w, added...); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(removed); start += MaxTuplesPerWrite {
+		if err := w.DeleteTuples(ctx, removed[start:min(start+MaxTuplesPerWrite, len(removed))]...); err != nil {
+			rctx := context.WithoutCancel(ctx)
+
+			if rerr := WriteTuplesInChunks(rctx, w, removed[:start]...); rerr != nil {
+				return errors.Join(err, fmt.Errorf("failed restoring %d tuples: %w", start, rerr))
+			}
+
+			if rerr := DeleteTuplesInChunks(rctx, w, added...); rerr != nil {
+				return errors.Join(err, fmt.Errorf("failed rolling back %d tuples: %w", len(added), rerr))
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+func chunkTuples(n int) []Tuple {
+	tuples := make([]Tuple, 0, n)
+
+	for i := 0; i < n; i++ {
+		tuples = append(tuples, *NewTuple("user:joe", "can_view", fmt.Sprintf("client:%v", i)))
+	}
+
+	return tuples
+}
+
+func TestWriteTuplesInChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		// failAt is the 1-based write call that fails, 0 makes all of them succeed
+		failAt    int
+		deleteErr error
+		tuples    int
+		writes    int
+		deletes   int
+	}{
+		{
+			name:   "single chunk",
+			tuples: 10,
+			writes: 1,
+		},
+		{
+			name:   "several chunks",
+			tuples: 2*MaxTuplesPerWrite + 1,
+			writes: 3,
+		},
+		{
+			name:   "first chunk fails, nothing to roll back",
+			tuples: 2*MaxTuplesPerWrite + 1,
+			failAt: 1,
+			writes: 1,
+		},
+		{
+			name:    "last chunk fails, previous ones are rolled back",
+			tuples:  2*MaxTuplesPerWrite + 1,
+			failAt:  3,
+			writes:  3,
+			deletes: 2,
+		},
+		{
+			name:      "rollback fails",
+			tuples:    2*MaxTuplesPerWrite + 1,
+			failAt:    2,
+			deleteErr: fmt.Errorf("delete failed"),
+			writes:    2,
+			deletes:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockWriter := NewMockTupleWriterInterface(ctrl)
+
+			writeErr := fmt.Errorf("write failed")
+			writes := 0
+			deleted := 0
+
+			mockWriter.EXPECT().WriteTuples(gomock.Any(), gomock.Any()).Times(test.writes).DoAndReturn(
+				func(ctx context.Context, tuples ...Tuple) error {
+					writes++
+
+					if len(tuples) > MaxTuplesPerWrite {
+						t.Errorf("expected at most %v tuples per write got %v", MaxTuplesPerWrite, len(tuples))
+					}
+
+					if writes == test.failAt {
+						return writeErr
+					}
+
+					return nil
+				},
+			)
+			mockWriter.EXPECT().DeleteTuples(gomock.Any(), gomock.Any()).Times(test.deletes).DoAndReturn(
+				func(ctx context.Context, tuples ...Tuple) error {
+					deleted += len(tuples)
+
+					return test.deleteErr
+				},
+			)
+
+			err := WriteTuplesInChunks(context.Background(), mockWriter, chunkTuples(test.tuples)...)
+
+			if test.failAt == 0 {
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, writeErr) {
+				t.Fatalf("expected error to be %v got %v", writeErr, err)
+			}
+
+			if test.deleteErr != nil {
+				if !errors.Is(err, test.deleteErr) {
+					t.Fatalf("expected error to include %v got %v", test.deleteErr, err)
+				}
+
+				return
+			}
+
+			if expected := (test.failAt - 1) * MaxTuplesPerWrite; deleted != expected {
+				t.Fatalf("expected %v tuples rolled back got %v", expected, deleted)
+			}
+		})
+	}
+}
+
+func TestDeleteTuplesInChunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWriter := NewMockTupleWriterInterface(ctrl)
+
+	deleted := 0
+
+	mockWriter.EXPECT().DeleteTuples(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+		func(ctx context.Context, tuples ...Tuple) error {
+			if len(tuples) > MaxTuplesPerWrite {
+				t.Errorf("expected at most %v tuples per delete got %v", MaxTuplesPerWrite, len(tuples))
+			}
+
+			deleted += len(tuples)
+
+			return nil
+		},
+	)
+
+	if err := DeleteTuplesInChunks(context.Background(), mockWriter, chunkTuples(2*MaxTuplesPerWrite+1)...); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if deleted != 2*MaxTuplesPerWrite+1 {
+		t.Fatalf("expected %v tuples deleted got %v", 2*MaxTuplesPerWrite+1, deleted)
+	}
+}
+
+func TestReplaceTuplesInChunks(t *testing.T) {
+	tests := []struct {
+		name string
+		// failAt is the 1-based delete call that fails, 0 makes all of them succeed
+		failAt   int
+		writes   int
+		deletes  int
+		restored int
+	}{
+		{
+			name:    "replaced",
+			writes:  3,
+			deletes: 3,
+		},
+		{
+			name:    "first delete fails, added tuples are rolled back",
+			failAt:  1,
+			writes:  3,
+			deletes: 1 + 3,
+		},
+		{
+			name:     "last delete fails, removed tuples are written back",
+			failAt:   3,
+			writes:   3 + 2,
+			deletes:  3 + 3,
+			restored: 2 * MaxTuplesPerWrite,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockWriter := NewMockTupleWriterInterface(ctrl)
+
+			deleteErr := fmt.Errorf("delete failed")
+			deletes := 0
+			written := 0
+
+			mockWriter.EXPECT().WriteTuples(gomock.Any(), gomock.Any()).Times(test.writes).DoAndReturn(
+				func(ctx context.Context, tuples ...Tuple) error {
+					written += len(tuples)
+
+					return nil
+				},
+			)
+			mockWriter.EXPECT().DeleteTuples(gomock.Any(), gomock.Any()).Times(test.deletes).DoAndReturn(
+				func(ctx context.Context, tuples ...Tuple) error {
+					deletes++
+
+					if len(tuples) > MaxTuplesPerWrite {
+						t.Errorf("expected at most %v tuples per delete got %v", MaxTuplesPerWrite, len(tuples))
+					}
+
+					if deletes == test.failAt {
+						return deleteErr
+					}
+
+					return nil
+				},
+			)
+
+			err := ReplaceTuplesInChunks(context.Background(), mockWriter, chunkTuples(2*MaxTuplesPerWrite+1), chunkTuples(2*MaxTuplesPerWrite+1))
+
+			if test.failAt == 0 {
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, deleteErr) {
+				t.Fatalf("expected error to be %v got %v", deleteErr, err)
+			}
+
+			if expected := 2*MaxTuplesPerWrite + 1 + test.restored; written != expected {
+				t.Fatalf("expected %v tuples written got %v", expected, written)
+			}
+		})
+	}
+}
@@ -5,10 +5,12 @@ package openfga
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	openfga "github.com/openfga/go-sdk"
@@ -21,9 +23,30 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 )
 
+var ErrModelNotFound = fmt.Errorf("authorization model not found in the store")
+
 type Client struct {
 	c OpenFGACoreClientInterface
 
+	// storeID is the store the client is bound to
+	storeID string
+
+	// modelID overrides the model set in the configuration once reloaded, every operation
+	// reads it once so requests already in flight keep the model they started with
+	modelID atomic.Pointer[string]
+
+	// cache holds the results of Check calls, nil when caching is disabled
+	cache *checkCache
+
+	// batcher coalesces tuple writes, nil when batching is disabled
+	batcher *writeBatcher
+
+	// retrier repeats calls failing with transient errors, nil when retries are disabled
+	retrier *retrier
+
+	// readPageSize is sent with every ReadTuples call, 0 leaves the page size to OpenFGA
+	readPageSize int32
+
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
@@ -43,6 +66,7 @@ func (c *Client) SetStoreID(ctx context.Context, storeID string) error {
 	client.SetStoreId(storeID)
 
 	c.c = client
+	c.storeID = storeID
 
 	return nil
 }
@@ -57,10 +81,140 @@ func (c *Client) SetAuthorizationModelID(ctx context.Context, modelID string) er
 	client.SetAuthorizationModelId(modelID)
 
 	c.c = client
+	c.modelID.Store(&modelID)
+	c.purgeCache()
+
+	return nil
+}
+
+// ReloadModel makes modelID the authorization model used by the following operations,
+// the model is validated against the configured store before switching
+func (c *Client) ReloadModel(ctx context.Context, modelID string) error {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.ReloadModel")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	r := c.c.ReadAuthorizationModel(ctx).Options(
+		client.ClientReadAuthorizationModelOptions{AuthorizationModelId: &modelID},
+	)
+
+	authModel, err := c.c.ReadAuthorizationModelExecute(r)
+
+	var notFoundErr openfga.FgaApiNotFoundError
+	var validationErr openfga.FgaApiValidationError
+	var invalidErr client.FgaInvalidError
+
+	if errors.As(err, &notFoundErr) || errors.As(err, &validationErr) || errors.As(err, &invalidErr) {
+		return ErrModelNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if authModel.AuthorizationModel == nil || authModel.AuthorizationModel.GetId() != modelID {
+		return ErrModelNotFound
+	}
+
+	c.modelID.Store(&modelID)
+	c.purgeCache()
 
 	return nil
 }
 
+// activeModelID returns the reloaded model ID, nil if the one in the configuration is in use
+func (c *Client) activeModelID() *string {
+	return c.modelID.Load()
+}
+
+// invalidate drops the cached checks on the objects touched by tuples, the whole cache is
+// purged when a tuple can grant access transitively through a group or role
+func (c *Client) invalidate(tuples ...Tuple) {
+	if c.cache == nil {
+		return
+	}
+
+	objects := make([]string, 0, len(tuples))
+
+	for _, tuple := range tuples {
+		if isTransitive(tuple) {
+			c.cache.Purge()
+			return
+		}
+
+		objects = append(objects, tuple.Object)
+	}
+
+	c.cache.InvalidateObjects(objects...)
+}
+
+// isTransitive reports whether tuple can change checks on objects other than its own,
+// usersets and memberships of groups and roles propagate to whatever they are granted,
+// admins of privileged objects hold every relation through admin from privileged
+func isTransitive(tuple Tuple) bool {
+	if strings.Contains(tuple.User, "#") || tuple.Relation == "admin" {
+		return true
+	}
+
+	for _, prefix := range []string{"group:", "role:", "privileged:"} {
+		if strings.HasPrefix(tuple.Object, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) purgeCache() {
+	if c.cache != nil {
+		c.cache.Purge()
+	}
+}
+
+// countCache increments the cache counter for result, either hit or miss
+func (c *Client) countCache(result string) {
+	m, err := c.monitor.GetOpenFGACheckCacheMetric(map[string]string{"result": result})
+
+	if err != nil {
+		c.logger.Debugf("error fetching metric: %s; keep going....", err)
+		return
+	}
+
+	m.Inc()
+}
+
+// countRetry increments the retry counter for method, outcome is either retried or failed
+func (c *Client) countRetry(method, outcome string) {
+	m, err := c.monitor.GetOpenFGARetryMetric(map[string]string{"method": method, "outcome": outcome})
+
+	if err != nil {
+		c.logger.Debugf("error fetching metric: %s; keep going....", err)
+		return
+	}
+
+	m.Inc()
+}
+
+// observe records the duration of an OpenFGA call labelled by method and outcome
+func (c *Client) observe(method string, start time.Time, err error) {
+	outcome := "success"
+
+	if err != nil {
+		outcome = "error"
+	}
+
+	m, merr := c.monitor.GetOpenFGACallMetric(map[string]string{"method": method, "outcome": outcome})
+
+	if merr != nil {
+		c.logger.Debugf("error fetching metric: %s; keep going....", merr)
+		return
+	}
+
+	m.Observe(time.Since(start).Seconds())
+}
+
 // ########################## Store Operations #######################################
 func (c *Client) CreateStore(ctx context.Context, name string) (string, error) {
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.CreateStore")
@@ -87,7 +241,13 @@ func (c *Client) ReadModel(ctx context.Context) (*openfga.AuthorizationModel, er
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	authModel, err := c.c.ReadAuthorizationModelExecute(c.c.ReadAuthorizationModel(ctx))
+	r := c.c.ReadAuthorizationModel(ctx)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientReadAuthorizationModelOptions{AuthorizationModelId: modelID})
+	}
+
+	authModel, err := c.c.ReadAuthorizationModelExecute(r)
 
 	if err != nil {
 		return nil, err
@@ -142,6 +302,10 @@ func (c *Client) CompareModel(ctx context.Context, model openfga.AuthorizationMo
 
 // ########################## Write Operations #######################################
 func (c *Client) WriteTuple(ctx context.Context, user, relation, object string) error {
+	if c.batcher != nil {
+		return c.WriteTuples(ctx, *NewTuple(user, relation, object))
+	}
+
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.WriteTuple")
 	defer span.End()
 
@@ -156,12 +320,26 @@ func (c *Client) WriteTuple(ctx context.Context, user, relation, object string)
 	}
 
 	r = r.Body(body)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientWriteOptions{AuthorizationModelId: modelID})
+	}
+
 	_, err := c.c.WriteExecute(r)
+	c.invalidate(*NewTuple(user, relation, object))
 
 	return err
 }
 
 func (c *Client) DeleteTuple(ctx context.Context, user, relation, object string) error {
+	if c.batcher != nil {
+		return c.batcher.Exclusive(func() error { return c.deleteTuple(ctx, user, relation, object) })
+	}
+
+	return c.deleteTuple(ctx, user, relation, object)
+}
+
+func (c *Client) deleteTuple(ctx context.Context, user, relation, object string) error {
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.DeleteTuple")
 	defer span.End()
 
@@ -172,12 +350,55 @@ func (c *Client) DeleteTuple(ctx context.Context, user, relation, object string)
 		},
 	}
 	r = r.Body(body)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientWriteOptions{AuthorizationModelId: modelID})
+	}
+
 	_, err := c.c.WriteExecute(r)
+	c.invalidate(*NewTuple(user, relation, object))
 
 	return err
 }
 
+// WriteTuples waits for the tuples to be written, when batching is enabled they are flushed
+// together with the writes of concurrent requests. If ctx is done before the batch is flushed the
+// tuples are left out of it and ctx error is returned, once the batch is being written the outcome
+// of the write is returned instead
 func (c *Client) WriteTuples(ctx context.Context, tuples ...Tuple) error {
+	if c.batcher == nil {
+		return c.writeTuples(ctx, tuples...)
+	}
+
+	r := c.batcher.Write(ctx, tuples...)
+
+	select {
+	case err := <-r.result:
+		return err
+	case <-ctx.Done():
+		if r.Cancel() {
+			return ctx.Err()
+		}
+
+		return <-r.result
+	}
+}
+
+// WriteTuplesAsync returns a channel receiving the outcome of the write, without batching the
+// write happens before returning, with batching the tuples are dropped if ctx is done before
+// the batch is flushed
+func (c *Client) WriteTuplesAsync(ctx context.Context, tuples ...Tuple) <-chan error {
+	if c.batcher != nil {
+		return c.batcher.Write(ctx, tuples...).result
+	}
+
+	result := make(chan error, 1)
+	result <- c.writeTuples(ctx, tuples...)
+
+	return result
+}
+
+func (c *Client) writeTuples(ctx context.Context, tuples ...Tuple) error {
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.WriteTuples")
 	defer span.End()
 
@@ -193,12 +414,34 @@ func (c *Client) WriteTuples(ctx context.Context, tuples ...Tuple) error {
 	}
 
 	r = r.Body(body)
-	_, err := c.c.WriteExecute(r)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientWriteOptions{AuthorizationModelId: modelID})
+	}
+
+	err := c.retrier.Do(ctx, "WriteTuples", retryWrite, func() error {
+		start := time.Now()
+		_, err := c.c.WriteExecute(r)
+		c.observe("WriteTuples", start, err)
+
+		return err
+	})
+	c.invalidate(tuples...)
 
 	return err
 }
 
+// DeleteTuples flushes the pending batched writes first, so a delete is never reordered
+// with writes issued before it
 func (c *Client) DeleteTuples(ctx context.Context, tuples ...Tuple) error {
+	if c.batcher != nil {
+		return c.batcher.Exclusive(func() error { return c.deleteTuples(ctx, tuples...) })
+	}
+
+	return c.deleteTuples(ctx, tuples...)
+}
+
+func (c *Client) deleteTuples(ctx context.Context, tuples ...Tuple) error {
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.DeleteTuples")
 	defer span.End()
 
@@ -214,7 +457,70 @@ func (c *Client) DeleteTuples(ctx context.Context, tuples ...Tuple) error {
 	}
 
 	r = r.Body(body)
-	_, err := c.c.WriteExecute(r)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientWriteOptions{AuthorizationModelId: modelID})
+	}
+
+	err := c.retrier.Do(ctx, "DeleteTuples", retryWrite, func() error {
+		start := time.Now()
+		_, err := c.c.WriteExecute(r)
+		c.observe("DeleteTuples", start, err)
+
+		return err
+	})
+	c.invalidate(tuples...)
+
+	return err
+}
+
+// WriteAndDeleteTuples applies writes and deletes in a single OpenFGA write request, either all
+// of them are applied or none is, pending batched writes are flushed first like for DeleteTuples
+func (c *Client) WriteAndDeleteTuples(ctx context.Context, writes, deletes []Tuple) error {
+	if c.batcher != nil {
+		return c.batcher.Exclusive(func() error { return c.writeAndDeleteTuples(ctx, writes, deletes) })
+	}
+
+	return c.writeAndDeleteTuples(ctx, writes, deletes)
+}
+
+func (c *Client) writeAndDeleteTuples(ctx context.Context, writes, deletes []Tuple) error {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.WriteAndDeleteTuples")
+	defer span.End()
+
+	ws := make([]openfga.TupleKey, 0)
+
+	for _, tuple := range writes {
+		ws = append(ws, *openfga.NewTupleKey(tuple.Values()))
+	}
+
+	ds := make([]openfga.TupleKeyWithoutCondition, 0)
+
+	for _, tuple := range deletes {
+		ds = append(ds, *openfga.NewTupleKeyWithoutCondition(tuple.Values()))
+	}
+
+	r := c.c.Write(ctx)
+	body := client.ClientWriteRequest{
+		Writes:  ws,
+		Deletes: ds,
+	}
+
+	r = r.Body(body)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientWriteOptions{AuthorizationModelId: modelID})
+	}
+
+	err := c.retrier.Do(ctx, "WriteAndDeleteTuples", retryWrite, func() error {
+		start := time.Now()
+		_, err := c.c.WriteExecute(r)
+		c.observe("WriteAndDeleteTuples", start, err)
+
+		return err
+	})
+	c.invalidate(writes...)
+	c.invalidate(deletes...)
 
 	return err
 }
@@ -222,10 +528,48 @@ func (c *Client) DeleteTuples(ctx context.Context, tuples ...Tuple) error {
 // ########################## Write Operations #######################################
 
 // ########################## Check Operations #######################################
+
+// Check performs a check, HigherConsistency set on ctx via ConsistencyContext skips the check cache
+// tuples are sent as contextual tuples along with the ones stored in ctx by ContextualTuplesContext
+// TODO: forward the consistency preference to OpenFGA on Check and BatchCheck, the option is not
+// available in the pinned go-sdk (v0.3.4) and needs an upgrade to a release supporting it
 func (c *Client) Check(ctx context.Context, user, relation, object string, tuples ...Tuple) (bool, error) {
+	consistency := ConsistencyFromContext(ctx)
+
+	if scoped := ContextualTuplesFromContext(ctx); len(scoped) > 0 {
+		tuples = append(scoped[:len(scoped):len(scoped)], tuples...)
+	}
+
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.Check")
 	defer span.End()
 
+	// contextual tuples change the outcome of the check, those are never cached, neither are
+	// checks asking for higher consistency as they must reflect the latest writes
+	cacheable := c.cache != nil && len(tuples) == 0 && consistency != HigherConsistency
+
+	var (
+		key        checkKey
+		generation uint64
+	)
+
+	if cacheable {
+		modelID, err := c.currentModelID()
+
+		if err != nil {
+			cacheable = false
+		} else {
+			key = checkKey{user: user, relation: relation, object: object, modelID: modelID}
+
+			if allowed, ok := c.cache.Get(key); ok {
+				c.countCache("hit")
+				return allowed, nil
+			}
+
+			c.countCache("miss")
+			generation = c.cache.Generation()
+		}
+	}
+
 	contextualTuples := make([]client.ClientContextualTupleKey, len(tuples))
 	for i, t := range tuples {
 		contextualTuples[i] = client.ClientContextualTupleKey{
@@ -244,25 +588,77 @@ func (c *Client) Check(ctx context.Context, user, relation, object string, tuple
 
 	r = r.Body(body)
 
-	check, err := c.c.CheckExecute(r)
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientCheckOptions{AuthorizationModelId: modelID})
+	}
+
+	var check *client.ClientCheckResponse
+
+	err := c.retrier.Do(ctx, "Check", retryRead, func() error {
+		var err error
+
+		start := time.Now()
+		check, err = c.c.CheckExecute(r)
+		c.observe("Check", start, err)
+
+		return err
+	})
+
 	if err != nil {
 		c.logger.Infof("body args: %s %s %s", user, relation, object)
 		c.logger.Errorf("issues performing check operation: %s", err)
 		return false, err
 	}
 
+	if cacheable {
+		c.cache.Set(key, check.GetAllowed(), generation)
+	}
+
 	return check.GetAllowed(), nil
 }
 func (c *Client) BatchCheck(ctx context.Context, tuples ...Tuple) (bool, error) {
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.BatchCheck")
 	defer span.End()
 
-	modelID, err := c.c.GetAuthorizationModelId()
+	results, err := c.batchCheck(ctx, tuples...)
+
+	if results == nil {
+		return false, err
+	}
+
+	allowed := true
+
+	for _, result := range results {
+		allowed = allowed && result.Allowed
+	}
+
+	if !allowed {
+		if err == nil {
+			err = fmt.Errorf("error while performing Check operation:")
+		}
 
-	if err != nil {
 		return false, err
 	}
 
+	return allowed, nil
+}
+
+// BatchCheckDetailed checks all the tuples and returns the outcome for each one of them
+// in the same order they were passed in, a tuple whose check failed is reported as not allowed
+func (c *Client) BatchCheckDetailed(ctx context.Context, tuples ...Tuple) ([]CheckResult, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.BatchCheckDetailed")
+	defer span.End()
+
+	return c.batchCheck(ctx, tuples...)
+}
+
+func (c *Client) batchCheck(ctx context.Context, tuples ...Tuple) ([]CheckResult, error) {
+	modelID, err := c.currentModelID()
+
+	if err != nil {
+		return nil, err
+	}
+
 	body := client.ClientBatchCheckBody{}
 
 	for _, t := range tuples {
@@ -284,28 +680,45 @@ func (c *Client) BatchCheck(ctx context.Context, tuples ...Tuple) (bool, error)
 
 	r := c.c.BatchCheck(ctx).Options(options).Body(body)
 
+	start := time.Now()
 	data, err := c.c.BatchCheckExecute(r)
+	c.observe("BatchCheck", start, err)
 
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	allowed := true
+	results := make([]CheckResult, 0, len(tuples))
 	errString := make([]string, 0)
 	errString = append(errString, "error while performing Check operation:")
 
-	for _, check := range *data {
-		allowed = allowed && *check.Allowed
+	// responses are returned in the same order as the requests
+	for i, check := range *data {
+		if i >= len(tuples) {
+			break
+		}
+
+		results = append(results, CheckResult{Tuple: tuples[i], Allowed: check.GetAllowed()})
+
 		if check.Error != nil {
 			errString = append(errString, fmt.Sprintf("* %s", check.Error))
 		}
 	}
 
-	if !allowed {
-		return false, fmt.Errorf(strings.Join(errString, "\n"))
+	if len(errString) > 1 {
+		return results, fmt.Errorf(strings.Join(errString, "\n"))
 	}
 
-	return allowed, nil
+	return results, nil
+}
+
+// currentModelID returns the reloaded model ID if any, the one in the configuration otherwise
+func (c *Client) currentModelID() (string, error) {
+	if modelID := c.activeModelID(); modelID != nil {
+		return *modelID, nil
+	}
+
+	return c.c.GetAuthorizationModelId()
 }
 
 // ########################## Check Operations #######################################
@@ -315,6 +728,23 @@ func (c *Client) ReadTuples(ctx context.Context, user, relation, object, continu
 	ctx, span := c.tracer.Start(ctx, "openfga.Client.ReadTuples")
 	defer span.End()
 
+	return c.readTuples(ctx, user, relation, object, continuationToken, c.readPageSize)
+}
+
+// ReadTuplesPage is ReadTuples asking OpenFGA for pages of pageSize tuples, capped at MaxReadPageSize,
+// a non positive pageSize falls back to the configured read page size
+func (c *Client) ReadTuplesPage(ctx context.Context, user, relation, object, continuationToken string, pageSize int32) (*client.ClientReadResponse, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.ReadTuplesPage")
+	defer span.End()
+
+	if pageSize <= 0 {
+		pageSize = c.readPageSize
+	}
+
+	return c.readTuples(ctx, user, relation, object, continuationToken, min(pageSize, MaxReadPageSize))
+}
+
+func (c *Client) readTuples(ctx context.Context, user, relation, object, continuationToken string, pageSize int32) (*client.ClientReadResponse, error) {
 	r := c.c.Read(ctx)
 
 	body := client.ClientReadRequest{
@@ -323,8 +753,25 @@ func (c *Client) ReadTuples(ctx context.Context, user, relation, object, continu
 		Object:   &object,
 	}
 
-	r = r.Body(body).Options(client.ClientReadOptions{ContinuationToken: &continuationToken})
-	res, err := c.c.ReadExecute(r)
+	options := client.ClientReadOptions{ContinuationToken: &continuationToken}
+
+	if pageSize > 0 {
+		options.PageSize = &pageSize
+	}
+
+	r = r.Body(body).Options(options)
+
+	var res *client.ClientReadResponse
+
+	err := c.retrier.Do(ctx, "ReadTuples", retryRead, func() error {
+		var err error
+
+		start := time.Now()
+		res, err = c.c.ReadExecute(r)
+		c.observe("ReadTuples", start, err)
+
+		return err
+	})
 
 	// TODO @shipperizer do we want to log in here or simply return the error?
 
@@ -343,7 +790,23 @@ func (c *Client) ListObjects(ctx context.Context, user, relation, objectType str
 		Type:     objectType,
 	}
 	r = r.Body(body)
-	objectsResponse, err := c.c.ListObjectsExecute(r)
+
+	if modelID := c.activeModelID(); modelID != nil {
+		r = r.Options(client.ClientListObjectsOptions{AuthorizationModelId: modelID})
+	}
+
+	var objectsResponse *client.ClientListObjectsResponse
+
+	err := c.retrier.Do(ctx, "ListObjects", retryRead, func() error {
+		var err error
+
+		start := time.Now()
+		objectsResponse, err = c.c.ListObjectsExecute(r)
+		c.observe("ListObjects", start, err)
+
+		return err
+	})
+
 	if err != nil {
 		c.logger.Errorf("issues performing list operation: %s", err)
 		return nil, err
@@ -389,9 +852,23 @@ func NewClient(cfg *Config) *Client {
 	}
 
 	c.c = fga
+	c.storeID = cfg.StoreID
 	c.tracer = cfg.Tracer
 	c.monitor = cfg.Monitor
 	c.logger = cfg.Logger
 
+	if cfg.CheckCache != nil {
+		c.cache = newCheckCache(cfg.CheckCache.Size, cfg.CheckCache.TTL)
+	}
+
+	if cfg.WriteBatch != nil {
+		c.batcher = newWriteBatcher(cfg.WriteBatch.Window, cfg.WriteBatch.MaxSize, c.writeTuples, c.tracer)
+	}
+
+	c.readPageSize = cfg.ReadPageSize
+
+	// RetryParams are left unset so the SDK never retries on its own, retries are all handled here
+	c.retrier = newRetrier(cfg.Retry, c.countRetry)
+
 	return c
 }
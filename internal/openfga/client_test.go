@@ -5,9 +5,11 @@ package openfga
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	openfga "github.com/openfga/go-sdk"
@@ -20,7 +22,7 @@ import (
 
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_client.go -source=./interfaces.go
-//go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_openfga_client.go github.com/openfga/go-sdk/client SdkClientListObjectsRequestInterface,SdkClientReadRequestInterface,SdkClientWriteRequestInterface,SdkClientBatchCheckRequestInterface
+//go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_openfga_client.go github.com/openfga/go-sdk/client SdkClientListObjectsRequestInterface,SdkClientReadRequestInterface,SdkClientWriteRequestInterface,SdkClientBatchCheckRequestInterface,SdkClientReadAuthorizationModelRequestInterface,SdkClientCheckRequestInterface
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
 
@@ -105,6 +107,7 @@ func TestClientListObjectsSuccess(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
 			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 			mockRequest := NewMockSdkClientListObjectsRequestInterface(ctrl)
 
@@ -126,6 +129,8 @@ func TestClientListObjectsSuccess(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ListObjects").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 			mockOpenFGAClient.EXPECT().ListObjects(gomock.Any()).Return(mockRequest)
 			mockRequest.EXPECT().Body(body).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ListObjects", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 			mockOpenFGAClient.EXPECT().ListObjectsExecute(mockRequest).Times(1).Return(&expected, nil)
 
 			r, err := c.ListObjects(context.TODO(), test.input.user, test.input.relation, test.input.object)
@@ -149,6 +154,7 @@ func TestClientListObjectsFails(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockTracer := NewMockTracer(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
 	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 	mockRequest := NewMockSdkClientListObjectsRequestInterface(ctrl)
 
@@ -169,6 +175,8 @@ func TestClientListObjectsFails(t *testing.T) {
 	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ListObjects").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 	mockOpenFGAClient.EXPECT().ListObjects(gomock.Any()).Return(mockRequest)
 	mockRequest.EXPECT().Body(body).Return(mockRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ListObjects", "outcome": "error"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 	mockOpenFGAClient.EXPECT().ListObjectsExecute(mockRequest).Times(1).Return(nil, fmt.Errorf("error"))
 
 	r, err := c.ListObjects(context.TODO(), "user:me", "member", "group")
@@ -230,6 +238,7 @@ func TestClientReadTuplesSuccess(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
 			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 			mockRequest := NewMockSdkClientReadRequestInterface(ctrl)
 
@@ -252,6 +261,8 @@ func TestClientReadTuplesSuccess(t *testing.T) {
 			mockOpenFGAClient.EXPECT().Read(gomock.Any()).Return(mockRequest)
 			mockRequest.EXPECT().Body(body).Return(mockRequest)
 			mockRequest.EXPECT().Options(client.ClientReadOptions{ContinuationToken: &test.input.cToken}).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ReadTuples", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 			mockOpenFGAClient.EXPECT().ReadExecute(mockRequest).Times(1).Return(&expected, nil)
 
 			r, err := c.ReadTuples(context.TODO(), test.input.user, test.input.relation, test.input.object, test.input.cToken)
@@ -275,6 +286,7 @@ func TestClientReadTuplesFails(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockTracer := NewMockTracer(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
 	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 	mockRequest := NewMockSdkClientReadRequestInterface(ctrl)
 
@@ -300,6 +312,8 @@ func TestClientReadTuplesFails(t *testing.T) {
 	mockOpenFGAClient.EXPECT().Read(gomock.Any()).Return(mockRequest)
 	mockRequest.EXPECT().Body(body).Return(mockRequest)
 	mockRequest.EXPECT().Options(client.ClientReadOptions{ContinuationToken: &cToken}).Return(mockRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ReadTuples", "outcome": "error"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 	mockOpenFGAClient.EXPECT().ReadExecute(mockRequest).Times(1).Return(nil, fmt.Errorf("error"))
 
 	r, err := c.ReadTuples(context.TODO(), user, relation, oType, cToken)
@@ -313,6 +327,113 @@ func TestClientReadTuplesFails(t *testing.T) {
 	}
 }
 
+func TestClientReadTuplesPageSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockRequest := NewMockSdkClientReadRequestInterface(ctrl)
+
+	c := Client{
+		c:            mockOpenFGAClient,
+		readPageSize: 100,
+		tracer:       mockTracer,
+		monitor:      mockMonitor,
+		logger:       mockLogger,
+	}
+
+	cToken := "xyz"
+	pageSize := int32(100)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ReadTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockOpenFGAClient.EXPECT().Read(gomock.Any()).Return(mockRequest)
+	mockRequest.EXPECT().Body(gomock.Any()).Return(mockRequest)
+	mockRequest.EXPECT().Options(client.ClientReadOptions{ContinuationToken: &cToken, PageSize: &pageSize}).Return(mockRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ReadTuples", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+	mockOpenFGAClient.EXPECT().ReadExecute(mockRequest).Times(1).Return(&client.ClientReadResponse{}, nil)
+
+	if _, err := c.ReadTuples(context.TODO(), "role:administrator#assignee", "", "client:", cToken); err != nil {
+		t.Errorf("error while calling ReadTuples %s", err)
+	}
+}
+
+func TestClientReadTuplesPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int32
+		expected int32
+	}{
+		{name: "requested size", pageSize: 20, expected: 20},
+		{name: "configured size", pageSize: 0, expected: 50},
+		{name: "capped size", pageSize: 500, expected: MaxReadPageSize},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockRequest := NewMockSdkClientReadRequestInterface(ctrl)
+
+			c := Client{
+				c:            mockOpenFGAClient,
+				readPageSize: 50,
+				tracer:       mockTracer,
+				monitor:      mockMonitor,
+				logger:       mockLogger,
+			}
+
+			cToken := "xyz"
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ReadTuplesPage").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGAClient.EXPECT().Read(gomock.Any()).Return(mockRequest)
+			mockRequest.EXPECT().Body(gomock.Any()).Return(mockRequest)
+			mockRequest.EXPECT().Options(client.ClientReadOptions{ContinuationToken: &cToken, PageSize: &test.expected}).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "ReadTuples", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+			mockOpenFGAClient.EXPECT().ReadExecute(mockRequest).Times(1).Return(&client.ClientReadResponse{}, nil)
+
+			if _, err := c.ReadTuplesPage(context.TODO(), "group:admins#member", "assignee", "role:", cToken, test.pageSize); err != nil {
+				t.Errorf("error while calling ReadTuplesPage %s", err)
+			}
+		})
+	}
+}
+
+func TestNewReadPageSize(t *testing.T) {
+	for _, test := range []struct {
+		size     int
+		expected int32
+		err      bool
+	}{
+		{size: 0, expected: 0},
+		{size: 1, expected: 1},
+		{size: MaxReadPageSize, expected: MaxReadPageSize},
+		{size: MaxReadPageSize + 1, err: true},
+		{size: -1, err: true},
+	} {
+		size, err := NewReadPageSize(test.size)
+
+		if (err != nil) != test.err {
+			t.Errorf("expected error %v for size %d got %v", test.err, test.size, err)
+		}
+
+		if size != test.expected {
+			t.Errorf("expected page size %d got %d", test.expected, size)
+		}
+	}
+}
+
 func TestClientWriteTuplesSuccess(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -343,6 +464,7 @@ func TestClientWriteTuplesSuccess(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
 			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 			mockRequest := NewMockSdkClientWriteRequestInterface(ctrl)
 
@@ -366,6 +488,8 @@ func TestClientWriteTuplesSuccess(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.WriteTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 			mockOpenFGAClient.EXPECT().Write(gomock.Any()).Return(mockRequest)
 			mockRequest.EXPECT().Body(body).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "WriteTuples", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 			mockOpenFGAClient.EXPECT().WriteExecute(mockRequest).Times(1).Return(nil, nil)
 
 			if err := c.WriteTuples(context.TODO(), test.input...); err != nil {
@@ -384,6 +508,7 @@ func TestClientWriteTuplesFails(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockTracer := NewMockTracer(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
 	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 	mockRequest := NewMockSdkClientWriteRequestInterface(ctrl)
 
@@ -403,6 +528,8 @@ func TestClientWriteTuplesFails(t *testing.T) {
 	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.WriteTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 	mockOpenFGAClient.EXPECT().Write(gomock.Any()).Return(mockRequest)
 	mockRequest.EXPECT().Body(body).Return(mockRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "WriteTuples", "outcome": "error"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 	mockOpenFGAClient.EXPECT().WriteExecute(mockRequest).Times(1).Return(nil, fmt.Errorf("error"))
 
 	if err := c.WriteTuples(context.TODO(), *tuple); err == nil {
@@ -440,6 +567,7 @@ func TestClientDeleteTuplesSuccess(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
 			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 			mockRequest := NewMockSdkClientWriteRequestInterface(ctrl)
 
@@ -463,6 +591,8 @@ func TestClientDeleteTuplesSuccess(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.DeleteTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 			mockOpenFGAClient.EXPECT().Write(gomock.Any()).Return(mockRequest)
 			mockRequest.EXPECT().Body(body).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "DeleteTuples", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 			mockOpenFGAClient.EXPECT().WriteExecute(mockRequest).Times(1).Return(nil, nil)
 
 			if err := c.DeleteTuples(context.TODO(), test.input...); err != nil {
@@ -481,6 +611,7 @@ func TestClientDeleteTuplesFails(t *testing.T) {
 	mockLogger := NewMockLoggerInterface(ctrl)
 	mockTracer := NewMockTracer(ctrl)
 	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
 	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 	mockRequest := NewMockSdkClientWriteRequestInterface(ctrl)
 
@@ -500,6 +631,8 @@ func TestClientDeleteTuplesFails(t *testing.T) {
 	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.DeleteTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 	mockOpenFGAClient.EXPECT().Write(gomock.Any()).Return(mockRequest)
 	mockRequest.EXPECT().Body(body).Return(mockRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "DeleteTuples", "outcome": "error"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 	mockOpenFGAClient.EXPECT().WriteExecute(mockRequest).Times(1).Return(nil, fmt.Errorf("error"))
 
 	if err := c.DeleteTuples(context.TODO(), *tuple); err == nil {
@@ -507,6 +640,57 @@ func TestClientDeleteTuplesFails(t *testing.T) {
 	}
 }
 
+func TestClientWriteAndDeleteTuples(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		outcome string
+	}{
+		{name: "success", outcome: "success"},
+		{name: "failure", err: fmt.Errorf("error"), outcome: "error"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+			c := Client{
+				c:       mockOpenFGAClient,
+				tracer:  mockTracer,
+				monitor: mockMonitor,
+				logger:  mockLogger,
+			}
+
+			write := NewTuple("user:joe", "member", "group:viewer")
+			remove := NewTuple("user:joe", "member", "group:editor")
+
+			body := client.ClientWriteRequest{
+				Writes:  []openfga.TupleKey{*openfga.NewTupleKey(write.Values())},
+				Deletes: []openfga.TupleKeyWithoutCondition{*openfga.NewTupleKeyWithoutCondition(remove.Values())},
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.WriteAndDeleteTuples").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGAClient.EXPECT().Write(gomock.Any()).Times(1).Return(mockRequest)
+			mockRequest.EXPECT().Body(body).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "WriteAndDeleteTuples", "outcome": test.outcome}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+			mockOpenFGAClient.EXPECT().WriteExecute(mockRequest).Times(1).Return(nil, test.err)
+
+			if err := c.WriteAndDeleteTuples(context.TODO(), []Tuple{*write}, []Tuple{*remove}); err != test.err {
+				t.Errorf("expected error to be %v got %v", test.err, err)
+			}
+		})
+	}
+}
+
 func TestClientWriteBatchCheckSuccess(t *testing.T) {
 
 	allowedResponse := openfga.CheckResponse{}
@@ -565,6 +749,7 @@ func TestClientWriteBatchCheckSuccess(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
 			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
 			mockRequest := NewMockSdkClientBatchCheckRequestInterface(ctrl)
 
@@ -594,6 +779,8 @@ func TestClientWriteBatchCheckSuccess(t *testing.T) {
 			mockOpenFGAClient.EXPECT().BatchCheck(gomock.Any()).Return(mockRequest)
 			mockRequest.EXPECT().Options(client.ClientBatchCheckOptions{AuthorizationModelId: &modelID}).Return(mockRequest)
 			mockRequest.EXPECT().Body(body).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "BatchCheck", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
 			mockOpenFGAClient.EXPECT().BatchCheckExecute(mockRequest).Times(1).DoAndReturn(
 				func(client.SdkClientBatchCheckRequestInterface) (*client.ClientBatchCheckResponse, error) {
 					res := client.ClientBatchCheckResponse{}
@@ -628,3 +815,657 @@ func TestClientWriteBatchCheckSuccess(t *testing.T) {
 		})
 	}
 }
+
+func TestClientBatchCheckDetailed(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []Tuple
+		allowed  []bool
+		errors   []error
+		expected []CheckResult
+		err      bool
+	}{
+		{
+			name: "multiple tuples all allowed",
+			input: []Tuple{
+				*NewTuple("user:me", "can_edit", "role:administrator"),
+				*NewTuple("user:me", "can_view", "group:editor"),
+			},
+			allowed: []bool{true, true},
+			errors:  []error{nil, nil},
+			expected: []CheckResult{
+				{Tuple: *NewTuple("user:me", "can_edit", "role:administrator"), Allowed: true},
+				{Tuple: *NewTuple("user:me", "can_view", "group:editor"), Allowed: true},
+			},
+		},
+		{
+			name: "multiple tuples with failing condition",
+			input: []Tuple{
+				*NewTuple("user:me", "can_edit", "role:administrator"),
+				*NewTuple("user:me", "can_view", "group:editor"),
+			},
+			allowed: []bool{false, true},
+			errors:  []error{nil, nil},
+			expected: []CheckResult{
+				{Tuple: *NewTuple("user:me", "can_edit", "role:administrator"), Allowed: false},
+				{Tuple: *NewTuple("user:me", "can_view", "group:editor"), Allowed: true},
+			},
+		},
+		{
+			name: "check error",
+			input: []Tuple{
+				*NewTuple("user:me", "can_edit", "role:administrator"),
+				*NewTuple("user:me", "can_view", "group:editor"),
+			},
+			allowed: []bool{true, false},
+			errors:  []error{nil, fmt.Errorf("error")},
+			expected: []CheckResult{
+				{Tuple: *NewTuple("user:me", "can_edit", "role:administrator"), Allowed: true},
+				{Tuple: *NewTuple("user:me", "can_view", "group:editor"), Allowed: false},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockRequest := NewMockSdkClientBatchCheckRequestInterface(ctrl)
+
+			c := Client{
+				c:       mockOpenFGAClient,
+				tracer:  mockTracer,
+				monitor: mockMonitor,
+				logger:  mockLogger,
+			}
+			modelID := "testModel12345"
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.BatchCheckDetailed").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGAClient.EXPECT().GetAuthorizationModelId().Return(modelID, nil)
+			mockOpenFGAClient.EXPECT().BatchCheck(gomock.Any()).Return(mockRequest)
+			mockRequest.EXPECT().Options(client.ClientBatchCheckOptions{AuthorizationModelId: &modelID}).Return(mockRequest)
+			mockRequest.EXPECT().Body(gomock.Any()).Return(mockRequest)
+			mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "BatchCheck", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+			mockOpenFGAClient.EXPECT().BatchCheckExecute(mockRequest).Times(1).DoAndReturn(
+				func(client.SdkClientBatchCheckRequestInterface) (*client.ClientBatchCheckResponse, error) {
+					res := client.ClientBatchCheckResponse{}
+
+					for i, allowed := range test.allowed {
+						check := openfga.CheckResponse{}
+						check.SetAllowed(allowed)
+
+						res = append(
+							res,
+							client.ClientBatchCheckSingleResponse{
+								ClientCheckResponse: client.ClientCheckResponse{CheckResponse: check},
+								Error:               test.errors[i],
+							},
+						)
+					}
+
+					return &res, nil
+				},
+			)
+
+			results, err := c.BatchCheckDetailed(context.TODO(), test.input...)
+
+			if test.err && err == nil {
+				t.Errorf("expected error while calling BatchCheckDetailed")
+			}
+
+			if !test.err && err != nil {
+				t.Errorf("error while calling BatchCheckDetailed %s", err)
+			}
+
+			if !reflect.DeepEqual(results, test.expected) {
+				t.Errorf("expected results to be %v got %v", test.expected, results)
+			}
+		})
+	}
+}
+
+func TestClientReloadModelSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockReadModelRequest := NewMockSdkClientReadAuthorizationModelRequestInterface(ctrl)
+	mockCheckRequest := NewMockSdkClientCheckRequestInterface(ctrl)
+
+	c := Client{
+		c:       mockOpenFGAClient,
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+
+	modelID := "01HQ2JMD9F5RJ0S3Y9C4QX7V8T"
+
+	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ReloadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockOpenFGAClient.EXPECT().ReadAuthorizationModel(gomock.Any()).Return(mockReadModelRequest)
+	mockReadModelRequest.EXPECT().Options(client.ClientReadAuthorizationModelOptions{AuthorizationModelId: &modelID}).Return(mockReadModelRequest)
+	mockOpenFGAClient.EXPECT().ReadAuthorizationModelExecute(mockReadModelRequest).Times(1).Return(
+		&client.ClientReadAuthorizationModelResponse{AuthorizationModel: &openfga.AuthorizationModel{Id: modelID}},
+		nil,
+	)
+
+	if err := c.ReloadModel(context.TODO(), modelID); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	// checks issued after the reload are pinned to the new model
+	mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.Check").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockOpenFGAClient.EXPECT().Check(gomock.Any()).Return(mockCheckRequest)
+	mockCheckRequest.EXPECT().Body(gomock.Any()).Return(mockCheckRequest)
+	mockCheckRequest.EXPECT().Options(client.ClientCheckOptions{AuthorizationModelId: &modelID}).Return(mockCheckRequest)
+	mockMonitor.EXPECT().GetOpenFGACallMetric(map[string]string{"method": "Check", "outcome": "success"}).Times(1).Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).Times(1)
+	mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(1).Return(&client.ClientCheckResponse{}, nil)
+
+	if _, err := c.Check(context.TODO(), "user:me", "can_view", "group:1"); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+}
+
+func TestClientReloadModelFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *client.ClientReadAuthorizationModelResponse
+		err      error
+		expected error
+	}{
+		{
+			name:     "model not found",
+			err:      openfga.FgaApiNotFoundError{},
+			expected: ErrModelNotFound,
+		},
+		{
+			name:     "model with a different id",
+			response: &client.ClientReadAuthorizationModelResponse{AuthorizationModel: &openfga.AuthorizationModel{Id: "01HPSTRTWY7SPT0W1357KRT4AE"}},
+			expected: ErrModelNotFound,
+		},
+		{
+			name:     "openfga error",
+			err:      fmt.Errorf("error"),
+			expected: fmt.Errorf("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockRequest := NewMockSdkClientReadAuthorizationModelRequestInterface(ctrl)
+
+			c := Client{
+				c:       mockOpenFGAClient,
+				tracer:  mockTracer,
+				monitor: mockMonitor,
+				logger:  mockLogger,
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ReloadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGAClient.EXPECT().ReadAuthorizationModel(gomock.Any()).Return(mockRequest)
+			mockRequest.EXPECT().Options(gomock.Any()).Return(mockRequest)
+			mockOpenFGAClient.EXPECT().ReadAuthorizationModelExecute(mockRequest).Times(1).Return(test.response, test.err)
+
+			err := c.ReloadModel(context.TODO(), "01HQ2JMD9F5RJ0S3Y9C4QX7V8T")
+
+			if err == nil || err.Error() != test.expected.Error() {
+				t.Fatalf("expected error %v got %v", test.expected, err)
+			}
+
+			if c.activeModelID() != nil {
+				t.Fatalf("expected active model to be unchanged")
+			}
+		})
+	}
+}
+
+func TestClientCheckCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockHits := monitoring.NewMockCounterInterface(ctrl)
+	mockMisses := monitoring.NewMockCounterInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockCheckRequest := NewMockSdkClientCheckRequestInterface(ctrl)
+	mockWriteRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+	c := Client{
+		c:       mockOpenFGAClient,
+		cache:   newCheckCache(10, time.Minute),
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+
+	allowed := openfga.CheckResponse{}
+	allowed.SetAllowed(true)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockOpenFGAClient.EXPECT().GetAuthorizationModelId().AnyTimes().Return("model", nil)
+	mockOpenFGAClient.EXPECT().Check(gomock.Any()).AnyTimes().Return(mockCheckRequest)
+	mockCheckRequest.EXPECT().Body(gomock.Any()).AnyTimes().Return(mockCheckRequest)
+	mockOpenFGAClient.EXPECT().Write(gomock.Any()).AnyTimes().Return(mockWriteRequest)
+	mockWriteRequest.EXPECT().Body(gomock.Any()).AnyTimes().Return(mockWriteRequest)
+	mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(1).Return(nil, nil)
+
+	mockMonitor.EXPECT().GetOpenFGACheckCacheMetric(map[string]string{"result": "hit"}).Times(1).Return(mockHits, nil)
+	mockMonitor.EXPECT().GetOpenFGACheckCacheMetric(map[string]string{"result": "miss"}).Times(2).Return(mockMisses, nil)
+	mockHits.EXPECT().Inc().Times(1)
+	mockMisses.EXPECT().Inc().Times(2)
+
+	// miss, hit, miss after the write invalidated group:1 and a contextual check which is never cached
+	mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(3).Return(&client.ClientCheckResponse{CheckResponse: allowed}, nil)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := c.Check(context.TODO(), "user:joe", "can_view", "group:1"); !ok || err != nil {
+			t.Fatalf("expected check to be allowed got %v %v", ok, err)
+		}
+	}
+
+	if err := c.WriteTuples(context.TODO(), *NewTuple("user:jane", "member", "group:1")); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if ok, err := c.Check(context.TODO(), "user:joe", "can_view", "group:1"); !ok || err != nil {
+		t.Fatalf("expected check to be allowed got %v %v", ok, err)
+	}
+
+	if ok, err := c.Check(context.TODO(), "user:joe", "can_view", "group:2", *NewTuple("user:joe", "can_view", "group:2")); !ok || err != nil {
+		t.Fatalf("expected check to be allowed got %v %v", ok, err)
+	}
+
+	if c.cache.Len() != 1 {
+		t.Fatalf("expected only the check without contextual tuples to be cached got %v", c.cache.Len())
+	}
+}
+
+func TestClientCheckCacheRevokedTransitiveAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		revoked Tuple
+	}{
+		// joe can view the application through group:g#member
+		{name: "group membership", revoked: *NewTuple("user:joe", "member", "group:g")},
+		// joe can view the application through admin from privileged
+		{name: "admin", revoked: *NewTuple("user:joe", "admin", "privileged:superuser")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testClientCheckCacheRevoked(t, test.revoked)
+		})
+	}
+}
+
+func testClientCheckCacheRevoked(t *testing.T, revoked Tuple) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockCounter := monitoring.NewMockCounterInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockCheckRequest := NewMockSdkClientCheckRequestInterface(ctrl)
+	mockWriteRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+	c := Client{
+		c:       mockOpenFGAClient,
+		cache:   newCheckCache(10, time.Minute),
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+
+	allowed := openfga.CheckResponse{}
+	allowed.SetAllowed(true)
+	denied := openfga.CheckResponse{}
+	denied.SetAllowed(false)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockMonitor.EXPECT().GetOpenFGACheckCacheMetric(gomock.Any()).AnyTimes().Return(mockCounter, nil)
+	mockCounter.EXPECT().Inc().AnyTimes()
+	mockOpenFGAClient.EXPECT().GetAuthorizationModelId().AnyTimes().Return("model", nil)
+	mockOpenFGAClient.EXPECT().Check(gomock.Any()).AnyTimes().Return(mockCheckRequest)
+	mockCheckRequest.EXPECT().Body(gomock.Any()).AnyTimes().Return(mockCheckRequest)
+	mockOpenFGAClient.EXPECT().Write(gomock.Any()).AnyTimes().Return(mockWriteRequest)
+	mockWriteRequest.EXPECT().Body(gomock.Any()).AnyTimes().Return(mockWriteRequest)
+	mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(1).Return(nil, nil)
+
+	gomock.InOrder(
+		mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(1).Return(&client.ClientCheckResponse{CheckResponse: allowed}, nil),
+		mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(1).Return(&client.ClientCheckResponse{CheckResponse: denied}, nil),
+	)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := c.Check(context.TODO(), "user:joe", "can_view", "application:app"); !ok || err != nil {
+			t.Fatalf("expected check to be allowed got %v %v", ok, err)
+		}
+	}
+
+	if err := c.DeleteTuple(context.TODO(), revoked.User, revoked.Relation, revoked.Object); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if ok, err := c.Check(context.TODO(), "user:joe", "can_view", "application:app"); ok || err != nil {
+		t.Fatalf("expected check to be denied after the access was revoked got %v %v", ok, err)
+	}
+}
+
+func TestIsTransitive(t *testing.T) {
+	tests := []struct {
+		tuple    Tuple
+		expected bool
+	}{
+		{tuple: *NewTuple("user:joe", "member", "group:g"), expected: true},
+		{tuple: *NewTuple("user:joe", "assignee", "role:r"), expected: true},
+		{tuple: *NewTuple("group:g#member", "can_view", "application:app"), expected: true},
+		{tuple: *NewTuple("user:joe", "admin", "privileged:superuser"), expected: true},
+		{tuple: *NewTuple("privileged:superuser", "privileged", "identity:joe"), expected: false},
+		{tuple: *NewTuple("user:joe", "can_view", "application:app"), expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tuple.User+" "+test.tuple.Object, func(t *testing.T) {
+			if isTransitive(test.tuple) != test.expected {
+				t.Fatalf("expected %v got %v", test.expected, !test.expected)
+			}
+		})
+	}
+}
+
+func TestClientCheckHigherConsistencySkipsCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockCheckRequest := NewMockSdkClientCheckRequestInterface(ctrl)
+
+	c := Client{
+		c:       mockOpenFGAClient,
+		cache:   newCheckCache(10, time.Minute),
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+
+	allowed := openfga.CheckResponse{}
+	allowed.SetAllowed(true)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockOpenFGAClient.EXPECT().GetAuthorizationModelId().AnyTimes().Return("model", nil)
+	mockOpenFGAClient.EXPECT().Check(gomock.Any()).Times(2).Return(mockCheckRequest)
+	mockCheckRequest.EXPECT().Body(gomock.Any()).Times(2).Return(mockCheckRequest)
+	mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(2).Return(&client.ClientCheckResponse{CheckResponse: allowed}, nil)
+
+	ctx := ConsistencyContext(context.TODO(), HigherConsistency)
+
+	for i := 0; i < 2; i++ {
+		if ok, err := c.Check(ctx, "user:joe", "can_view", "group:1"); !ok || err != nil {
+			t.Fatalf("expected check to be allowed got %v %v", ok, err)
+		}
+	}
+
+	if c.cache.Len() != 0 {
+		t.Fatalf("expected higher consistency checks not to be cached got %v", c.cache.Len())
+	}
+}
+
+func TestClientCheckContextualTuples(t *testing.T) {
+	tests := []struct {
+		name     string
+		scoped   []Tuple
+		explicit []Tuple
+	}{
+		{
+			name:     "explicit tuples",
+			explicit: []Tuple{*NewTuple("user:joe", "member", "group:1")},
+		},
+		{
+			name:   "request scoped tuples",
+			scoped: []Tuple{*NewTuple("user:joe", "can_view", "client:okta"), *NewTuple("user:joe", "member", "group:2")},
+		},
+		{
+			name:     "request scoped and explicit tuples",
+			scoped:   []Tuple{*NewTuple("user:joe", "can_view", "client:okta")},
+			explicit: []Tuple{*NewTuple("user:joe", "member", "group:1")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockMetric := monitoring.NewMockMetricInterface(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockCheckRequest := NewMockSdkClientCheckRequestInterface(ctrl)
+
+			c := Client{
+				c:       mockOpenFGAClient,
+				cache:   newCheckCache(10, time.Minute),
+				tracer:  mockTracer,
+				monitor: mockMonitor,
+				logger:  mockLogger,
+			}
+
+			allowed := openfga.CheckResponse{}
+			allowed.SetAllowed(true)
+
+			expected := make([]client.ClientContextualTupleKey, 0)
+
+			for _, tuple := range append(test.scoped, test.explicit...) {
+				expected = append(expected, client.ClientContextualTupleKey{User: tuple.User, Relation: tuple.Relation, Object: tuple.Object})
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.Check").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+			mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+			mockOpenFGAClient.EXPECT().Check(gomock.Any()).Times(1).Return(mockCheckRequest)
+			mockCheckRequest.EXPECT().Body(
+				client.ClientCheckRequest{User: "user:joe", Relation: "can_edit", Object: "client:okta", ContextualTuples: expected},
+			).Times(1).Return(mockCheckRequest)
+			mockOpenFGAClient.EXPECT().CheckExecute(mockCheckRequest).Times(1).Return(&client.ClientCheckResponse{CheckResponse: allowed}, nil)
+
+			ctx := context.TODO()
+
+			if test.scoped != nil {
+				ctx = ContextualTuplesContext(ctx, test.scoped[:1]...)
+				ctx = ContextualTuplesContext(ctx, test.scoped[1:]...)
+			}
+
+			if ok, err := c.Check(ctx, "user:joe", "can_edit", "client:okta", test.explicit...); !ok || err != nil {
+				t.Fatalf("expected check to be allowed got %v %v", ok, err)
+			}
+
+			// checks with contextual tuples are never cached
+			if c.cache.Len() != 0 {
+				t.Fatalf("expected the check not to be cached got %v", c.cache.Len())
+			}
+		})
+	}
+}
+
+func TestClientWriteTuplesRetriesTransientErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockRetries := monitoring.NewMockCounterInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockWriteRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+	c := &Client{
+		c:       mockOpenFGAClient,
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+	c.retrier = newRetrier(NewRetryConfig(3, 1, 0), c.countRetry)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockOpenFGAClient.EXPECT().Write(gomock.Any()).Times(1).Return(mockWriteRequest)
+	mockWriteRequest.EXPECT().Body(gomock.Any()).Times(1).Return(mockWriteRequest)
+
+	mockMonitor.EXPECT().GetOpenFGARetryMetric(map[string]string{"method": "WriteTuples", "outcome": "retried"}).Times(1).Return(mockRetries, nil)
+	mockRetries.EXPECT().Inc().Times(1)
+
+	gomock.InOrder(
+		mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(1).Return(nil, fakeStatusError(429)),
+		mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(1).Return(nil, nil),
+	)
+
+	if err := c.WriteTuples(context.TODO(), *NewTuple("user:joe", "member", "group:1")); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+}
+
+func TestClientWriteTuplesDoesNotRetryAmbiguousErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockWriteRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+	c := &Client{
+		c:       mockOpenFGAClient,
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+	c.retrier = newRetrier(NewRetryConfig(3, 1, 0), c.countRetry)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockMonitor.EXPECT().GetOpenFGARetryMetric(gomock.Any()).Times(0)
+	mockOpenFGAClient.EXPECT().Write(gomock.Any()).Times(1).Return(mockWriteRequest)
+	mockWriteRequest.EXPECT().Body(gomock.Any()).Times(1).Return(mockWriteRequest)
+
+	// a 500 might have been raised after the write was applied
+	mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(1).Return(nil, fakeStatusError(500))
+
+	if err := c.WriteTuples(context.TODO(), *NewTuple("user:joe", "member", "group:1")); err != fakeStatusError(500) {
+		t.Fatalf("expected error to be %v got %v", fakeStatusError(500), err)
+	}
+}
+
+func TestParseConsistency(t *testing.T) {
+	for _, c := range []string{"", "MINIMIZE_LATENCY", "HIGHER_CONSISTENCY"} {
+		if consistency, err := ParseConsistency(c); err != nil || string(consistency) != c {
+			t.Errorf("expected %q to be valid got %v %v", c, consistency, err)
+		}
+	}
+
+	if _, err := ParseConsistency("STRONG"); !errors.Is(err, ErrInvalidConsistency) {
+		t.Errorf("expected error to be %v got %v", ErrInvalidConsistency, err)
+	}
+}
+
+func TestClientWriteBatchingFlushesBeforeDeletes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockMetric := monitoring.NewMockMetricInterface(ctrl)
+	mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+	mockWriteRequest := NewMockSdkClientWriteRequestInterface(ctrl)
+
+	c := &Client{
+		c:       mockOpenFGAClient,
+		tracer:  mockTracer,
+		monitor: mockMonitor,
+		logger:  mockLogger,
+	}
+	c.batcher = newWriteBatcher(time.Hour, 10, c.writeTuples, mockTracer)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockTracer.EXPECT().Start(gomock.Any(), "openfga.writeBatcher.flush", gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor.EXPECT().GetOpenFGACallMetric(gomock.Any()).AnyTimes().Return(mockMetric, nil)
+	mockMetric.EXPECT().Observe(gomock.Any()).AnyTimes()
+	mockOpenFGAClient.EXPECT().Write(gomock.Any()).Times(2).Return(mockWriteRequest)
+	mockOpenFGAClient.EXPECT().WriteExecute(mockWriteRequest).Times(2).Return(nil, nil)
+
+	gomock.InOrder(
+		mockWriteRequest.EXPECT().Body(gomock.Any()).DoAndReturn(
+			func(body client.ClientWriteRequest) client.SdkClientWriteRequestInterface {
+				if len(body.Writes) != 2 || len(body.Deletes) != 0 {
+					t.Errorf("expected the 2 batched writes first got %v", body)
+				}
+
+				return mockWriteRequest
+			},
+		),
+		mockWriteRequest.EXPECT().Body(gomock.Any()).DoAndReturn(
+			func(body client.ClientWriteRequest) client.SdkClientWriteRequestInterface {
+				if len(body.Deletes) != 1 {
+					t.Errorf("expected the delete after the writes got %v", body)
+				}
+
+				return mockWriteRequest
+			},
+		),
+	)
+
+	first := c.WriteTuplesAsync(context.TODO(), *NewTuple("user:joe", "member", "group:1"))
+	second := c.WriteTuplesAsync(context.TODO(), *NewTuple("user:jane", "member", "group:1"))
+
+	if err := c.DeleteTuples(context.TODO(), *NewTuple("user:joe", "member", "group:1")); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for _, result := range []<-chan error{first, second} {
+		if err := <-result; err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+}
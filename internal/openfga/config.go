@@ -4,6 +4,9 @@
 package openfga
 
 import (
+	"fmt"
+	"time"
+
 	validator "github.com/go-playground/validator/v10"
 
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
@@ -19,6 +22,18 @@ type Config struct {
 	AuthModelID string `validate:"required"`
 	Debug       bool
 
+	// CheckCache caches Check results in memory, disabled when nil
+	CheckCache *CheckCacheConfig
+
+	// WriteBatch coalesces concurrent tuple writes, disabled when nil
+	WriteBatch *WriteBatchConfig
+
+	// Retry repeats calls failing with transient errors, disabled when nil
+	Retry *RetryConfig
+
+	// ReadPageSize is the number of tuples requested per ReadTuples page, 0 uses the OpenFGA default
+	ReadPageSize int32
+
 	Tracer  tracing.TracingInterface
 	Monitor monitoring.MonitorInterface
 	Logger  logging.LoggerInterface
@@ -46,3 +61,82 @@ func NewConfig(apiScheme, apiHost, storeID, apiToken, authModelID string, debug
 
 	return c
 }
+
+// CheckCacheConfig sets the maximum number of cached Check results and how long each of them is valid for
+type CheckCacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+// NewCheckCacheConfig returns nil if the cache is disabled or size and ttl are not positive
+func NewCheckCacheConfig(enabled bool, size, ttlSeconds int) *CheckCacheConfig {
+	if !enabled || size <= 0 || ttlSeconds <= 0 {
+		return nil
+	}
+
+	c := new(CheckCacheConfig)
+
+	c.Size = size
+	c.TTL = time.Duration(ttlSeconds) * time.Second
+
+	return c
+}
+
+// MaxReadPageSize is the biggest page size OpenFGA accepts on a read
+const MaxReadPageSize = 100
+
+// NewReadPageSize validates size against MaxReadPageSize, 0 keeps the OpenFGA default page size
+func NewReadPageSize(size int) (int32, error) {
+	if size < 0 || size > MaxReadPageSize {
+		return 0, fmt.Errorf("read page size must be between 0 and %d, got %d", MaxReadPageSize, size)
+	}
+
+	return int32(size), nil
+}
+
+// maxWriteTuples is the number of tuples OpenFGA accepts in a single write by default
+const maxWriteTuples = 100
+
+// WriteBatchConfig sets how long writes are buffered for and the number of tuples flushing a batch early
+type WriteBatchConfig struct {
+	Window  time.Duration
+	MaxSize int
+}
+
+// NewWriteBatchConfig returns nil if batching is disabled or window and size are not positive,
+// maxSize is capped to the tuples OpenFGA accepts in a single write
+func NewWriteBatchConfig(enabled bool, windowMilliseconds, maxSize int) *WriteBatchConfig {
+	if !enabled || windowMilliseconds <= 0 || maxSize <= 0 {
+		return nil
+	}
+
+	c := new(WriteBatchConfig)
+
+	c.Window = time.Duration(windowMilliseconds) * time.Millisecond
+	c.MaxSize = min(maxSize, maxWriteTuples)
+
+	return c
+}
+
+// RetryConfig sets how many times a call is attempted, the delay before the first retry, doubled at
+// each following one, and the maximum random delay added on top of it
+type RetryConfig struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	Jitter      time.Duration
+}
+
+// NewRetryConfig returns nil if maxAttempts allows no retries or baseDelay is not positive
+func NewRetryConfig(maxAttempts, baseDelayMilliseconds, jitterMilliseconds int) *RetryConfig {
+	if maxAttempts <= 1 || baseDelayMilliseconds <= 0 {
+		return nil
+	}
+
+	c := new(RetryConfig)
+
+	c.MaxAttempts = maxAttempts
+	c.BaseDelay = time.Duration(baseDelayMilliseconds) * time.Millisecond
+	c.Jitter = time.Duration(max(jitterMilliseconds, 0)) * time.Millisecond
+
+	return c
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConsistency is returned when a consistency preference is not one of the known ones
+var ErrInvalidConsistency = errors.New("invalid consistency")
+
+// Consistency is the trade off between latency and freshness requested for a check
+type Consistency string
+
+const (
+	// ConsistencyUnspecified leaves the choice to the client, same as MinimizeLatency
+	ConsistencyUnspecified Consistency = ""
+	// MinimizeLatency allows results to be served from the check cache
+	MinimizeLatency Consistency = "MINIMIZE_LATENCY"
+	// HigherConsistency always asks OpenFGA, skipping the check cache
+	HigherConsistency Consistency = "HIGHER_CONSISTENCY"
+)
+
+type consistencyKey struct{}
+
+// ParseConsistency validates a consistency preference, the values match the OpenFGA API ones
+func ParseConsistency(c string) (Consistency, error) {
+	switch consistency := Consistency(c); consistency {
+	case ConsistencyUnspecified, MinimizeLatency, HigherConsistency:
+		return consistency, nil
+	default:
+		return ConsistencyUnspecified, fmt.Errorf("%w %q", ErrInvalidConsistency, c)
+	}
+}
+
+// ConsistencyContext returns a context carrying the consistency preference for the checks made with it
+func ConsistencyContext(ctx context.Context, c Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, c)
+}
+
+// ConsistencyFromContext returns the consistency preference stored in ctx, if any
+func ConsistencyFromContext(ctx context.Context) Consistency {
+	c, _ := ctx.Value(consistencyKey{}).(Consistency)
+
+	return c
+}
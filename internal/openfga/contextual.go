@@ -0,0 +1,30 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+)
+
+type contextualTuplesKey struct{}
+
+// ContextualTuplesContext returns a context carrying tuples on top of the ones already in ctx, every
+// check made with it sends them to OpenFGA as contextual tuples, this is how request-time grants
+// (e.g. a time-bound access) reach the checks of the authorization middleware
+func ContextualTuplesContext(ctx context.Context, tuples ...Tuple) context.Context {
+	current := ContextualTuplesFromContext(ctx)
+
+	merged := make([]Tuple, 0, len(current)+len(tuples))
+	merged = append(merged, current...)
+	merged = append(merged, tuples...)
+
+	return context.WithValue(ctx, contextualTuplesKey{}, merged)
+}
+
+// ContextualTuplesFromContext returns the contextual tuples stored in ctx, if any
+func ContextualTuplesFromContext(ctx context.Context) []Tuple {
+	tuples, _ := ctx.Value(contextualTuplesKey{}).([]Tuple)
+
+	return tuples
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// crockford is the base32 alphabet of ULIDs, used by OpenFGA for model IDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// AuthzInfo identifies the store and authorization model the client is bound to
+type AuthzInfo struct {
+	StoreID        string    `json:"store_id"`
+	ModelID        string    `json:"model_id"`
+	ModelCreatedAt time.Time `json:"model_created_at"`
+}
+
+// AuthzInfo reads the active authorization model from OpenFGA, failing if the store or the model
+// are gone, the creation time is the one OpenFGA encodes in the model ULID
+func (c *Client) AuthzInfo(ctx context.Context) (*AuthzInfo, error) {
+	ctx, span := c.tracer.Start(ctx, "openfga.Client.AuthzInfo")
+	defer span.End()
+
+	model, err := c.ReadModel(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if model == nil {
+		return nil, ErrModelNotFound
+	}
+
+	createdAt, err := ulidTime(model.GetId())
+
+	if err != nil {
+		return nil, err
+	}
+
+	info := new(AuthzInfo)
+
+	info.StoreID = c.storeID
+	info.ModelID = model.GetId()
+	info.ModelCreatedAt = createdAt
+
+	return info, nil
+}
+
+// ulidTime decodes the millisecond timestamp held by the first 10 characters of a ULID
+func ulidTime(id string) (time.Time, error) {
+	if len(id) != 26 || id[0] > '7' {
+		return time.Time{}, fmt.Errorf("invalid ULID %s", id)
+	}
+
+	var ms int64
+
+	for _, r := range strings.ToUpper(id[:10]) {
+		v := strings.IndexRune(crockford, r)
+
+		if v < 0 {
+			return time.Time{}, fmt.Errorf("invalid ULID %s", id)
+		}
+
+		ms = ms<<5 | int64(v)
+	}
+
+	return time.UnixMilli(ms).UTC(), nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestClientAuthzInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *client.ClientReadAuthorizationModelResponse
+		err      error
+		expected error
+	}{
+		{
+			name:     "model found",
+			response: &client.ClientReadAuthorizationModelResponse{AuthorizationModel: &openfga.AuthorizationModel{Id: "01HQ2JMD9F5RJ0S3Y9C4QX7V8T"}},
+		},
+		{
+			name:     "model missing from the response",
+			response: &client.ClientReadAuthorizationModelResponse{},
+			expected: ErrModelNotFound,
+		},
+		{
+			name:     "openfga unreachable",
+			err:      fmt.Errorf("connection refused"),
+			expected: fmt.Errorf("connection refused"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockOpenFGAClient := NewMockOpenFGACoreClientInterface(ctrl)
+			mockReadModelRequest := NewMockSdkClientReadAuthorizationModelRequestInterface(ctrl)
+
+			c := Client{
+				c:       mockOpenFGAClient,
+				storeID: "01HQ2J8G3X4D8ZK7N2Y5V6W9QA",
+				tracer:  mockTracer,
+				logger:  mockLogger,
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.AuthzInfo").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockTracer.EXPECT().Start(gomock.Any(), "openfga.Client.ReadModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGAClient.EXPECT().ReadAuthorizationModel(gomock.Any()).Return(mockReadModelRequest)
+			mockOpenFGAClient.EXPECT().ReadAuthorizationModelExecute(mockReadModelRequest).Times(1).Return(test.response, test.err)
+
+			info, err := c.AuthzInfo(context.TODO())
+
+			if test.expected != nil {
+				if err == nil || err.Error() != test.expected.Error() {
+					t.Fatalf("expected error to be %v got %v", test.expected, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			expected := AuthzInfo{
+				StoreID:        "01HQ2J8G3X4D8ZK7N2Y5V6W9QA",
+				ModelID:        "01HQ2JMD9F5RJ0S3Y9C4QX7V8T",
+				ModelCreatedAt: time.UnixMilli(1708409894191).UTC(),
+			}
+
+			if *info != expected {
+				t.Fatalf("expected info to be %v got %v", expected, *info)
+			}
+		})
+	}
+}
+
+func TestULIDTime(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected time.Time
+		invalid  bool
+	}{
+		{id: "01HQ2JMD9F5RJ0S3Y9C4QX7V8T", expected: time.UnixMilli(1708409894191).UTC()},
+		{id: "01hq2jmd9f5rj0s3y9c4qx7v8t", expected: time.UnixMilli(1708409894191).UTC()},
+		{id: "00000000000000000000000000", expected: time.UnixMilli(0).UTC()},
+		{id: "81HQ2JMD9F5RJ0S3Y9C4QX7V8T", invalid: true},
+		{id: "01HQ2JMD9U5RJ0S3Y9C4QX7V8T", invalid: true},
+		{id: "01HQ2JMD9F", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			ts, err := ulidTime(test.id)
+
+			if test.invalid {
+				if err == nil {
+					t.Fatalf("expected error not to be nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !ts.Equal(test.expected) {
+				t.Fatalf("expected time to be %v got %v", test.expected, ts)
+			}
+		})
+	}
+}
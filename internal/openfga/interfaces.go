@@ -40,6 +40,12 @@ type OpenFGAClientInterface interface {
 	Check(context.Context, string, string, string, ...Tuple) (bool, error)
 }
 
+// TupleWriterInterface is the subset of the client used by WriteTuplesInChunks and DeleteTuplesInChunks
+type TupleWriterInterface interface {
+	WriteTuples(context.Context, ...Tuple) error
+	DeleteTuples(context.Context, ...Tuple) error
+}
+
 type ListPermissionsFiltersInterface interface {
 	WithFilter() any
 }
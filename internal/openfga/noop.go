@@ -42,6 +42,16 @@ func (c *NoopClient) BatchCheck(ctx context.Context, tuples ...Tuple) (bool, err
 	return true, nil
 }
 
+func (c *NoopClient) BatchCheckDetailed(ctx context.Context, tuples ...Tuple) ([]CheckResult, error) {
+	results := make([]CheckResult, 0, len(tuples))
+
+	for _, t := range tuples {
+		results = append(results, CheckResult{Tuple: t, Allowed: true})
+	}
+
+	return results, nil
+}
+
 func (c *NoopClient) WriteTuple(ctx context.Context, user, relation, object string) error {
 	return nil
 }
@@ -58,6 +68,10 @@ func (c *NoopClient) DeleteTuples(ctx context.Context, tuples ...Tuple) error {
 	return nil
 }
 
+func (c *NoopClient) WriteAndDeleteTuples(ctx context.Context, writes, deletes []Tuple) error {
+	return nil
+}
+
 func (c *NoopClient) ReadModel(ctx context.Context) (*openfga.AuthorizationModel, error) {
 	return new(openfga.AuthorizationModel), nil
 }
@@ -66,6 +80,14 @@ func (c *NoopClient) WriteModel(ctx context.Context, model []byte) (string, erro
 	return "", nil
 }
 
+func (c *NoopClient) ReloadModel(ctx context.Context, modelID string) error {
+	return nil
+}
+
+func (c *NoopClient) AuthzInfo(ctx context.Context) (*AuthzInfo, error) {
+	return new(AuthzInfo), nil
+}
+
 func (c *NoopClient) CompareModel(ctx context.Context, model openfga.AuthorizationModel) (bool, error) {
 	return true, nil
 }
@@ -73,3 +95,7 @@ func (c *NoopClient) CompareModel(ctx context.Context, model openfga.Authorizati
 func (c *NoopClient) ReadTuples(ctx context.Context, user, relation, object, continuationToken string) (*client.ClientReadResponse, error) {
 	return new(client.ClientReadResponse), nil
 }
+
+func (c *NoopClient) ReadTuplesPage(ctx context.Context, user, relation, object, continuationToken string, pageSize int32) (*client.ClientReadResponse, error) {
+	return new(client.ClientReadResponse), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"sort"
+)
+
+// PaginateObjects returns a page of at most size objects following the token, ListObjects has no
+// continuation token so pages are cut from the sorted result and the token is the last object of
+// the page, an empty token means there are no more pages
+// if size is not positive all the objects are returned untouched
+func PaginateObjects(objects []string, size int64, token string) ([]string, string) {
+	if size <= 0 {
+		return objects, ""
+	}
+
+	sorted := make([]string, len(objects))
+	copy(sorted, objects)
+	sort.Strings(sorted)
+
+	start := 0
+
+	if token != "" {
+		start = sort.Search(len(sorted), func(i int) bool { return sorted[i] > token })
+	}
+
+	end := len(sorted)
+
+	if int64(end-start) > size {
+		end = start + int(size)
+	}
+
+	page := sorted[start:end]
+
+	if end == len(sorted) || len(page) == 0 {
+		return page, ""
+	}
+
+	return page, page[len(page)-1]
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginateObjects(t *testing.T) {
+	objects := []string{"c", "a", "e", "b", "d"}
+
+	tests := []struct {
+		name          string
+		size          int64
+		token         string
+		expected      []string
+		expectedToken string
+	}{
+		{
+			name:     "no size returns everything untouched",
+			size:     0,
+			expected: []string{"c", "a", "e", "b", "d"},
+		},
+		{
+			name:          "first page",
+			size:          2,
+			expected:      []string{"a", "b"},
+			expectedToken: "b",
+		},
+		{
+			name:          "middle page",
+			size:          2,
+			token:         "b",
+			expected:      []string{"c", "d"},
+			expectedToken: "d",
+		},
+		{
+			name:     "last page",
+			size:     2,
+			token:    "d",
+			expected: []string{"e"},
+		},
+		{
+			name:     "page size matching the remaining objects",
+			size:     3,
+			token:    "b",
+			expected: []string{"c", "d", "e"},
+		},
+		{
+			name:     "token past the last object",
+			size:     2,
+			token:    "z",
+			expected: []string{},
+		},
+		{
+			name:          "token of a removed object",
+			size:          2,
+			token:         "bb",
+			expected:      []string{"c", "d"},
+			expectedToken: "d",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, token := PaginateObjects(objects, test.size, test.token)
+
+			if !reflect.DeepEqual(page, test.expected) {
+				t.Errorf("expected page %v got %v", test.expected, page)
+			}
+
+			if token != test.expectedToken {
+				t.Errorf("expected token %q got %q", test.expectedToken, token)
+			}
+		})
+	}
+}
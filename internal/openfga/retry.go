@@ -0,0 +1,140 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// statusError is implemented by the OpenFGA SDK errors carrying the HTTP status of the response
+type statusError interface {
+	ResponseStatusCode() int
+}
+
+// retryPolicy decides if an error is worth another attempt
+type retryPolicy func(error) bool
+
+// retryRead is used for operations safe to repeat, rate limiting, server side and network errors are retried
+func retryRead(err error) bool {
+	var sErr statusError
+
+	if errors.As(err, &sErr) {
+		switch sErr.ResponseStatusCode() {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
+
+// retryWrite is used for writes and deletes, only errors guaranteeing the request was not processed
+// are retried, anything else might have been applied already
+func retryWrite(err error) bool {
+	var sErr statusError
+
+	if !errors.As(err, &sErr) {
+		return false
+	}
+
+	switch sErr.ResponseStatusCode() {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// retrier runs OpenFGA calls again on transient errors, waiting an exponential backoff with jitter
+// between attempts, it never waits past the deadline of the context of the call
+type retrier struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	jitter      time.Duration
+
+	// count increments the retry counter of method, outcome is either retried or failed
+	count func(method, outcome string)
+}
+
+// Do runs fn up to maxAttempts times while policy deems its error transient
+func (r *retrier) Do(ctx context.Context, method string, policy retryPolicy, fn func() error) error {
+	if r == nil {
+		return fn()
+	}
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !policy(err) {
+			return err
+		}
+
+		if attempt >= r.maxAttempts {
+			break
+		}
+
+		delay := r.backoff(attempt)
+
+		// a call cancelled or running out of time before the next attempt would fail anyway
+		if ctx.Err() != nil {
+			break
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+
+		r.count(method, "retried")
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			r.count(method, "failed")
+
+			return err
+		case <-timer.C:
+		}
+	}
+
+	r.count(method, "failed")
+
+	return err
+}
+
+// backoff returns the delay before attempt+1, baseDelay doubled at each attempt plus a random jitter
+func (r *retrier) backoff(attempt int) time.Duration {
+	delay := r.baseDelay << (attempt - 1)
+
+	if r.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(r.jitter)))
+	}
+
+	return delay
+}
+
+func newRetrier(cfg *RetryConfig, count func(method, outcome string)) *retrier {
+	if cfg == nil {
+		return nil
+	}
+
+	r := new(retrier)
+
+	r.maxAttempts = cfg.MaxAttempts
+	r.baseDelay = cfg.BaseDelay
+	r.jitter = cfg.Jitter
+	r.count = count
+
+	return r
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeStatusError int
+
+func (e fakeStatusError) Error() string {
+	return fmt.Sprintf("status %d", int(e))
+}
+
+func (e fakeStatusError) ResponseStatusCode() int {
+	return int(e)
+}
+
+func TestRetryPolicies(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		read  bool
+		write bool
+	}{
+		{name: "rate limited", err: fakeStatusError(429), read: true, write: true},
+		{name: "unavailable", err: fakeStatusError(503), read: true, write: true},
+		{name: "internal error", err: fakeStatusError(500), read: true},
+		{name: "gateway timeout", err: fakeStatusError(504), read: true},
+		{name: "not implemented", err: fakeStatusError(501)},
+		{name: "validation", err: fakeStatusError(400)},
+		{name: "wrapped", err: fmt.Errorf("failed: %w", fakeStatusError(429)), read: true, write: true},
+		{name: "network", err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}, read: true},
+		{name: "other", err: fmt.Errorf("error")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if retryRead(test.err) != test.read {
+				t.Errorf("expected read retry to be %v", test.read)
+			}
+
+			if retryWrite(test.err) != test.write {
+				t.Errorf("expected write retry to be %v", test.write)
+			}
+		})
+	}
+}
+
+func TestRetrierDo(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     []error
+		timeout  time.Duration
+		calls    int
+		err      error
+		outcomes []string
+	}{
+		{
+			name:     "success after transient errors",
+			errs:     []error{fakeStatusError(503), fakeStatusError(429), nil},
+			calls:    3,
+			outcomes: []string{"retried", "retried"},
+		},
+		{
+			name:     "attempts exhausted",
+			errs:     []error{fakeStatusError(503), fakeStatusError(503), fakeStatusError(503), nil},
+			calls:    3,
+			err:      fakeStatusError(503),
+			outcomes: []string{"retried", "retried", "failed"},
+		},
+		{
+			name:     "permanent error",
+			errs:     []error{fakeStatusError(400), nil},
+			calls:    1,
+			err:      fakeStatusError(400),
+			outcomes: []string{},
+		},
+		{
+			name:     "deadline shorter than the backoff",
+			errs:     []error{fakeStatusError(503), nil},
+			timeout:  time.Millisecond,
+			calls:    1,
+			err:      fakeStatusError(503),
+			outcomes: []string{"failed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outcomes := make([]string, 0)
+
+			r := newRetrier(
+				NewRetryConfig(3, 10, 0),
+				func(method, outcome string) {
+					if method != "Check" {
+						t.Errorf("expected method to be Check got %s", method)
+					}
+
+					outcomes = append(outcomes, outcome)
+				},
+			)
+
+			ctx := context.Background()
+
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			calls := 0
+
+			err := r.Do(ctx, "Check", retryRead, func() error {
+				err := test.errs[calls]
+				calls++
+
+				return err
+			})
+
+			if err != test.err {
+				t.Errorf("expected error to be %v got %v", test.err, err)
+			}
+
+			if calls != test.calls {
+				t.Errorf("expected %d calls got %d", test.calls, calls)
+			}
+
+			if !reflect.DeepEqual(outcomes, test.outcomes) {
+				t.Errorf("expected outcomes to be %v got %v", test.outcomes, outcomes)
+			}
+		})
+	}
+}
+
+func TestRetrierDisabled(t *testing.T) {
+	if NewRetryConfig(1, 100, 50) != nil || NewRetryConfig(3, 0, 50) != nil {
+		t.Fatal("expected retries to be disabled")
+	}
+
+	r := newRetrier(nil, nil)
+	calls := 0
+
+	err := r.Do(context.Background(), "Check", retryRead, func() error {
+		calls++
+		return fakeStatusError(503)
+	})
+
+	if err != fakeStatusError(503) || calls != 1 {
+		t.Errorf("expected a single call got %d %v", calls, err)
+	}
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	r := newRetrier(NewRetryConfig(5, 100, 10), nil)
+
+	for attempt, base := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		delay := r.backoff(attempt + 1)
+
+		if delay < base || delay >= base+10*time.Millisecond {
+			t.Errorf("expected delay of attempt %d to be in [%v, %v) got %v", attempt+1, base, base+10*time.Millisecond, delay)
+		}
+	}
+}
@@ -362,6 +362,37 @@ func (s *OpenFGAStore) ListPermissionsWithFilters(ctx context.Context, ID string
 	return permissions, tMap, fmt.Errorf(eMsg)
 }
 
+// ListAllPermissions returns all the permissions associated to a specific entity, walking every type
+// and continuation token sequentially, it doesn't use the worker pool so it's safe to call from a pool job
+func (s *OpenFGAStore) ListAllPermissions(ctx context.Context, ID string) ([]Permission, error) {
+	ctx, span := s.tracer.Start(ctx, "openfga.OpenFGAStore.ListAllPermissions")
+	defer span.End()
+
+	permissions := make([]Permission, 0)
+
+	for _, t := range s.permissionTypes() {
+		token := ""
+
+		for {
+			p, next, err := s.listPermissionsByType(ctx, ID, "", t, token)
+
+			if err != nil {
+				return nil, err
+			}
+
+			permissions = append(permissions, p...)
+
+			if next == "" || next == token {
+				break
+			}
+
+			token = next
+		}
+	}
+
+	return permissions, nil
+}
+
 func (s *OpenFGAStore) listPermissionsFunc(ctx context.Context, ID, relation, ofgaType, cToken string) func() any {
 	return func() any {
 		p, token, err := s.listPermissionsByType(
@@ -27,7 +27,7 @@ import (
 
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_client.go -source=./interfaces.go
-//go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_openfga_client.go github.com/openfga/go-sdk/client SdkClientListObjectsRequestInterface,SdkClientReadRequestInterface,SdkClientWriteRequestInterface,SdkClientBatchCheckRequestInterface
+//go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_openfga_client.go github.com/openfga/go-sdk/client SdkClientListObjectsRequestInterface,SdkClientReadRequestInterface,SdkClientWriteRequestInterface,SdkClientBatchCheckRequestInterface,SdkClientReadAuthorizationModelRequestInterface,SdkClientCheckRequestInterface
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_pool.go -source=../../internal/pool/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package openfga -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
@@ -962,6 +962,98 @@ func TestStoreListPermissions(t *testing.T) {
 	}
 }
 
+func TestStoreListAllPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		ID       string
+		expected error
+	}{
+		{
+			name:     "error",
+			ID:       "group:administrator#member",
+			expected: fmt.Errorf("error"),
+		},
+		{
+			name: "all pages walked",
+			ID:   "group:administrator#member",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+			mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+			store := NewOpenFGAStore(mockOpenFGA, mockWorkerPool, mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			if test.expected != nil {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+				mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), test.ID, "", "group:", "").Times(1).Return(nil, test.expected)
+
+				if _, err := store.ListAllPermissions(context.Background(), test.ID); err != test.expected {
+					t.Fatalf("expected error to be %v got %v", test.expected, err)
+				}
+
+				return
+			}
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), test.ID, "", gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, continuationToken string) (*client.ClientReadResponse, error) {
+					r := new(client.ClientReadResponse)
+					r.SetTuples([]openfga.Tuple{})
+					r.SetContinuationToken("")
+
+					if object != "role:" {
+						return r, nil
+					}
+
+					// roles are split across two pages
+					switch continuationToken {
+					case "":
+						r.SetTuples([]openfga.Tuple{*openfga.NewTuple(*openfga.NewTupleKey(user, "can_view", "role:first"), time.Now())})
+						r.SetContinuationToken("page-2")
+					case "page-2":
+						r.SetTuples(
+							[]openfga.Tuple{
+								*openfga.NewTuple(*openfga.NewTupleKey(user, "can_edit", "role:second"), time.Now()),
+								*openfga.NewTuple(*openfga.NewTupleKey(user, "assignee", "role:third"), time.Now()),
+							},
+						)
+					default:
+						t.Errorf("unexpected continuation token %s", continuationToken)
+					}
+
+					return r, nil
+				},
+			)
+
+			permissions, err := store.ListAllPermissions(context.Background(), test.ID)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			expected := []Permission{
+				{Relation: "can_view", Object: "role:first"},
+				{Relation: "can_edit", Object: "role:second"},
+			}
+
+			if !reflect.DeepEqual(permissions, expected) {
+				t.Fatalf("expected permissions to be %v got %v", expected, permissions)
+			}
+		})
+	}
+}
+
 func TestStoreListPermissionsWithPermissions(t *testing.T) {
 	type input struct {
 		ID             string
@@ -19,15 +19,21 @@ type Permission struct {
 // reason to have it is to hide underlying library complexity
 // in case we want to swap it
 type Tuple struct {
-	User     string
-	Relation string
-	Object   string
+	User     string `json:"user"`
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
 }
 
 func (t *Tuple) Values() (string, string, string) {
 	return t.User, t.Relation, t.Object
 }
 
+// CheckResult is the outcome of a single check in a batch
+type CheckResult struct {
+	Tuple   Tuple
+	Allowed bool
+}
+
 func NewTuple(user, relation, object string) *Tuple {
 	t := new(Tuple)
 
@@ -0,0 +1,32 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidObject is returned when an object reference is not in the <type>:<id> form
+// or its type is not one of the known ones
+var ErrInvalidObject = errors.New("invalid object")
+
+// ValidateObject checks object is a <type>:<id> reference with type among knownTypes,
+// it is meant to be called before writing tuples so malformed references are rejected
+// with a clear error instead of failing downstream
+func ValidateObject(object string, knownTypes ...string) error {
+	oType, oID, found := strings.Cut(object, ":")
+
+	if !found || oType == "" || oID == "" || strings.ContainsAny(object, " \t\n#") {
+		return fmt.Errorf("%w %q, expected <type>:<id>", ErrInvalidObject, object)
+	}
+
+	if !slices.Contains(knownTypes, oType) {
+		return fmt.Errorf("%w %q, unknown type %s", ErrInvalidObject, object, oType)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package openfga
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateObject(t *testing.T) {
+	knownTypes := []string{"role", "group", "client"}
+
+	tests := []struct {
+		name   string
+		object string
+		valid  bool
+	}{
+		{name: "valid", object: "client:okta", valid: true},
+		{name: "valid with colon in id", object: "role:admin:viewer", valid: true},
+		{name: "missing colon", object: "clientokta"},
+		{name: "missing type", object: ":okta"},
+		{name: "missing id", object: "client:"},
+		{name: "empty", object: ""},
+		{name: "unknown type", object: "rule:allow"},
+		{name: "userset", object: "group:admins#member"},
+		{name: "whitespace", object: "client: okta"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateObject(test.object, knownTypes...)
+
+			if test.valid && err != nil {
+				t.Fatalf("expected %s to be valid, got %v", test.object, err)
+			}
+
+			if !test.valid && !errors.Is(err, ErrInvalidObject) {
+				t.Fatalf("expected ErrInvalidObject for %q, got %v", test.object, err)
+			}
+		})
+	}
+}
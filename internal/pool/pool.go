@@ -15,6 +15,8 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 )
 
+var ErrPoolShutdown = fmt.Errorf("WorkerPool is shut down, not accepting jobs")
+
 type WorkerPool struct {
 	workers int
 
@@ -25,26 +27,77 @@ type WorkerPool struct {
 
 	wg sync.WaitGroup
 
+	// closed stops Submit from taking new jobs, pending tracks the jobs submitted and not yet executed
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
 }
 
+// Stop aborts the queued jobs and waits for the workers to exit, use Shutdown to let them finish
 func (p *WorkerPool) Stop() {
+	p.close()
 	p.shutdownFunc(fmt.Errorf("shutting down"))
 	p.wg.Wait()
 }
 
+// Shutdown stops accepting jobs and waits for the outstanding ones to complete before stopping
+// the workers, if ctx expires first the remaining jobs are aborted and ctx error is returned
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.close()
+
+	drained := make(chan struct{})
+
+	go func() {
+		p.pending.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.Stop()
+
+		return nil
+	case <-ctx.Done():
+		// don't wait for the workers, a job stuck past the deadline would block the caller
+		p.shutdownFunc(fmt.Errorf("shutdown deadline exceeded"))
+
+		return ctx.Err()
+	}
+}
+
 func (p *WorkerPool) Submit(command any, results chan *Result[any], wg *sync.WaitGroup) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return "", ErrPoolShutdown
+	}
+
 	_job := newJob(command, results, wg)
+
+	p.pending.Add(1)
+
 	select {
 	case p.jobs <- _job:
 		return _job.ID(), nil
 	default:
+		p.pending.Done()
+
 		return "", fmt.Errorf("WorkerPool queue is full")
 	}
 }
 
+func (p *WorkerPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+}
+
 func (p *WorkerPool) consume(ID uuid.UUID) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -70,19 +123,30 @@ func (p *WorkerPool) consume(ID uuid.UUID) {
 }
 
 func (p *WorkerPool) execute(jobID uuid.UUID, command any, results chan *Result[any], wg *sync.WaitGroup) {
+	defer p.pending.Done()
 
-	defer wg.Done()
+	// results and wg are optional, fire and forget jobs pass nil for both
+	if wg != nil {
+		defer wg.Done()
+	}
 
 	select {
 	case <-p.shutdownCtx.Done():
 		p.logger.Info(jobID, " aborting execution")
 	default:
+		var value any
+
 		switch commandFunc := command.(type) {
 		case func():
 			commandFunc()
-			results <- NewResult[any](jobID, true)
+			value = true
 		case func() any:
-			results <- NewResult[any](jobID, commandFunc())
+			value = commandFunc()
+		}
+
+		// sending on a nil channel would block the worker forever
+		if results != nil {
+			results <- NewResult[any](jobID, value)
 		}
 	}
 }
@@ -155,3 +155,150 @@ func TestWorkerPool_Stop(t *testing.T) {
 	}
 
 }
+
+func TestWorkerPool_SubmitWithoutResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracer := NewMockTracer(ctrl)
+	monitor := NewMockMonitorInterface(ctrl)
+	logger := NewMockLoggerInterface(ctrl)
+
+	tracer.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	// single worker, if fire and forget jobs were blocking it the second job would never run
+	wpool := NewWorkerPool(
+		1,
+		tracer,
+		monitor,
+		logger,
+	)
+
+	done := make(chan bool, 2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := wpool.Submit(func() any { done <- true; return nil }, nil, nil); err != nil {
+			t.Fatalf("Unable to submit task")
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-time.After(time.Millisecond * 500):
+			t.Fatalf("Timeout occurred")
+		case <-done:
+		}
+	}
+
+	wpool.Stop()
+}
+
+func TestWorkerPool_Shutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracer := NewMockTracer(ctrl)
+	monitor := NewMockMonitorInterface(ctrl)
+	logger := NewMockLoggerInterface(ctrl)
+
+	tracer.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	wpool := NewWorkerPool(
+		1,
+		tracer,
+		monitor,
+		logger,
+	)
+
+	started := make(chan bool)
+	release := make(chan bool)
+	completed := false
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	_, err := wpool.Submit(
+		func() {
+			started <- true
+			<-release
+			completed = true
+		},
+		nil,
+		&wg,
+	)
+
+	if err != nil {
+		t.Fatalf("Unable to submit task")
+	}
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	shutdown := make(chan error)
+
+	go func() {
+		shutdown <- wpool.Shutdown(ctx)
+	}()
+
+	// give Shutdown the time to close the pool
+	time.Sleep(time.Millisecond * 100)
+
+	if _, err := wpool.Submit(func() {}, nil, nil); err != ErrPoolShutdown {
+		t.Fatalf("expected error to be %v got %v", ErrPoolShutdown, err)
+	}
+
+	select {
+	case <-shutdown:
+		t.Fatalf("expected Shutdown to wait for the outstanding job")
+	default:
+	}
+
+	close(release)
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	wg.Wait()
+
+	if !completed {
+		t.Fatalf("expected job submitted before shutdown to complete")
+	}
+}
+
+func TestWorkerPool_ShutdownDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tracer := NewMockTracer(ctrl)
+	monitor := NewMockMonitorInterface(ctrl)
+	logger := NewMockLoggerInterface(ctrl)
+
+	tracer.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Info(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Debug(gomock.Any(), gomock.Any()).AnyTimes()
+
+	wpool := NewWorkerPool(
+		1,
+		tracer,
+		monitor,
+		logger,
+	)
+
+	release := make(chan bool)
+	defer close(release)
+
+	if _, err := wpool.Submit(func() { <-release }, nil, nil); err != nil {
+		t.Fatalf("Unable to submit task")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	if err := wpool.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected error to be %v got %v", context.DeadlineExceeded, err)
+	}
+}
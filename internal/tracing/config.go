@@ -4,6 +4,8 @@
 package tracing
 
 import (
+	"fmt"
+
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 )
 
@@ -13,15 +15,27 @@ type Config struct {
 	Logger           logging.LoggerInterface
 
 	Enabled bool
+	// SampleRatio is the fraction of traces sampled, 1 samples everything and 0 nothing
+	SampleRatio float64
+}
+
+// Validate checks the sample ratio is within [0, 1]
+func (c *Config) Validate() error {
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1, got %v", c.SampleRatio)
+	}
+
+	return nil
 }
 
-func NewConfig(enabled bool, otelGRPCEndpoint, otelHTTPEndpoint string, logger logging.LoggerInterface) *Config {
+func NewConfig(enabled bool, sampleRatio float64, otelGRPCEndpoint, otelHTTPEndpoint string, logger logging.LoggerInterface) *Config {
 	c := new(Config)
 
 	c.OtelGRPCEndpoint = otelGRPCEndpoint
 	c.OtelHTTPEndpoint = otelHTTPEndpoint
 	c.Logger = logger
 	c.Enabled = enabled
+	c.SampleRatio = sampleRatio
 
 	return c
 }
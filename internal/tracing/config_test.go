@@ -0,0 +1,34 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL
+
+package tracing
+
+import "testing"
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		ratio float64
+		valid bool
+	}{
+		{name: "never", ratio: 0, valid: true},
+		{name: "ratio", ratio: 0.25, valid: true},
+		{name: "always", ratio: 1, valid: true},
+		{name: "negative", ratio: -0.1, valid: false},
+		{name: "above one", ratio: 1.5, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := NewConfig(true, test.ratio, "", "", nil).Validate()
+
+			if test.valid && err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !test.valid && err == nil {
+				t.Fatalf("expected error not to be nil")
+			}
+		})
+	}
+}
@@ -29,9 +29,9 @@ type Tracer struct {
 	logger logging.LoggerInterface
 }
 
-func (t *Tracer) init(service string, e sdktrace.SpanExporter) {
+func (t *Tracer) init(service string, e sdktrace.SpanExporter, sampler sdktrace.Sampler) {
 	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithBatcher(e),
 		sdktrace.WithResource(
 			t.buildResource(service),
@@ -44,6 +44,16 @@ func (t *Tracer) init(service string, e sdktrace.SpanExporter) {
 	t.tracer = otel.Tracer(service)
 }
 
+// sampler keeps the AlwaysSample behaviour for a ratio of 1, TraceIDRatioBased treats values
+// outside of [0, 1] as the closest bound
+func (t *Tracer) sampler(ratio float64) sdktrace.Sampler {
+	if ratio >= 1 {
+		return sdktrace.AlwaysSample()
+	}
+
+	return sdktrace.TraceIDRatioBased(ratio)
+}
+
 func (t *Tracer) gitRevision(settings []debug.BuildSetting) string {
 	for _, setting := range settings {
 		if setting.Key == "vcs.revision" {
@@ -116,6 +126,8 @@ func NewTracer(cfg *Config) *Tracer {
 			),
 		)
 	} else {
+		t.logger.Warn("no OTEL_GRPC_ENDPOINT or OTEL_HTTP_ENDPOINT set, traces are printed to stdout, set TRACING_ENABLED=false to disable tracing")
+
 		exporter, err = stdouttrace.New(
 			stdouttrace.WithPrettyPrint(),
 		)
@@ -128,7 +140,11 @@ func NewTracer(cfg *Config) *Tracer {
 
 	// set tracer provider and propagator properly, this is to ensure all
 	// instrumentation library could run well
-	t.init("github.com/canonical/identity-platform-admin-ui", exporter)
+	sampler := t.sampler(cfg.SampleRatio)
+
+	t.logger.Infof("tracing sampler: %s", sampler.Description())
+
+	t.init("github.com/canonical/identity-platform-admin-ui", exporter, sampler)
 
 	return t
 }
@@ -3,4 +3,10 @@
 
 package version
 
-const Version = "1.22.1" // x-release-please-version
+// build metadata, Commit and BuildDate are meant to be set at build time via
+// -ldflags "-X github.com/canonical/identity-platform-admin-ui/internal/version.Commit=..."
+var (
+	Version   = "1.22.1" // x-release-please-version
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
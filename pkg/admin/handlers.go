@@ -0,0 +1,580 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	netmail "net/mail"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+	"github.com/canonical/identity-platform-admin-ui/pkg/groups"
+	"github.com/canonical/identity-platform-admin-ui/pkg/roles"
+)
+
+type ReloadAuthzModelRequest struct {
+	ModelID string `json:"model_id"`
+}
+
+// ReconcileRequest is the payload of the reconcile endpoints, Owner is the ID of the user the
+// group or role was created by
+type ReconcileRequest struct {
+	Owner string `json:"owner"`
+}
+
+type reconcileFunc func(context.Context, string, string) ([]openfga.Tuple, error)
+
+// PurgeResult is the outcome of a purge of the soft deleted groups
+type PurgeResult struct {
+	Purged int `json:"purged"`
+}
+
+// TestMailRequest is the payload of the mail test endpoint
+type TestMailRequest struct {
+	To string `json:"to"`
+}
+
+// API exposes the administrative operations, access is restricted to the platform admins
+// by the authorization middleware
+type API struct {
+	authorizer AuthzModelReloaderInterface
+	schemas    SchemaCacheInvalidatorInterface
+	mail       mail.EmailServiceInterface
+	// mailLimits are the middlewares restricting the mail test endpoint
+	mailLimits []func(http.Handler) http.Handler
+	groups     GroupReconcilerInterface
+	roles      RoleReconcilerInterface
+	purger     GroupPurgerInterface
+	auditLog   AuditLogInterface
+	pageSize   *types.PageSizeConfig
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+// SetSchemaCache sets the cache emptied by the schemas refresh endpoint
+func (a *API) SetSchemaCache(c SchemaCacheInvalidatorInterface) {
+	a.schemas = c
+}
+
+// SetMailService sets the service sending the email of the mail test endpoint, limits are
+// applied to that endpoint only, needs to be called before RegisterEndpoints
+func (a *API) SetMailService(s mail.EmailServiceInterface, limits ...func(http.Handler) http.Handler) {
+	a.mail = s
+	a.mailLimits = limits
+}
+
+// SetReconcilers sets the services restoring the creator tuples of groups and roles
+func (a *API) SetReconcilers(groups GroupReconcilerInterface, roles RoleReconcilerInterface) {
+	a.groups = groups
+	a.roles = roles
+}
+
+// SetGroupPurger sets the service dropping the soft deleted groups
+func (a *API) SetGroupPurger(p GroupPurgerInterface) {
+	a.purger = p
+}
+
+// SetAuditLog sets the store listed by the audit log endpoint
+func (a *API) SetAuditLog(l AuditLogInterface) {
+	a.auditLog = l
+}
+
+// SetPageSizeConfig sets the default and maximum page size of the audit log endpoint
+func (a *API) SetPageSizeConfig(c *types.PageSizeConfig) {
+	a.pageSize = c
+}
+
+func (a *API) RegisterEndpoints(mux *chi.Mux) {
+	mux.Post("/api/v0/admin/authz/model", a.handleReloadAuthzModel)
+	mux.Get("/api/v0/admin/authz/info", a.handleAuthzInfo)
+	mux.Post("/api/v0/admin/schemas/refresh", a.handleRefreshSchemas)
+	mux.With(a.mailLimits...).Post("/api/v0/admin/mail/test", a.handleTestMail)
+	mux.Post("/api/v0/admin/groups/purge", a.handlePurgeGroups)
+	mux.Post("/api/v0/admin/groups/{id}/reconcile", a.handleReconcileGroup)
+	mux.Post("/api/v0/admin/roles/{id}/reconcile", a.handleReconcileRole)
+	mux.Get("/api/v0/audit", a.handleListAudit)
+}
+
+// handleListAudit lists the recorded audit events newest first, filtered by actor, action,
+// resource_type and resource_id, the next page is read passing back the _meta next token
+func (a *API) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleListAudit")
+	defer span.End()
+
+	if a.auditLog == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Audit log not available",
+				Status:  http.StatusNotImplemented,
+				Code:    types.CodeNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	q := r.URL.Query()
+
+	size, err := a.pageSize.ParseSize(q)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	filter := audit.Filter{
+		Principal:    q.Get("actor"),
+		Action:       q.Get("action"),
+		ResourceType: q.Get("resource_type"),
+		ResourceID:   q.Get("resource_id"),
+	}
+
+	events, next, err := a.auditLog.List(ctx, filter, q.Get("page_token"), int(size))
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		if errors.Is(err, audit.ErrInvalidPageToken) {
+			status = http.StatusBadRequest
+			code = types.CodeInvalidParameter
+		} else {
+			a.logger.Errorf("failed listing audit events: %s", err)
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data: events,
+			Meta: &types.Pagination{
+				NavigationTokens: types.NavigationTokens{Next: next},
+				Size:             size,
+			},
+			Message: "List of audit events",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+// handlePurgeGroups drops the groups soft deleted more than older_than ago, a Go duration
+// such as 720h, a zero duration purges every soft deleted group
+func (a *API) handlePurgeGroups(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handlePurgeGroups")
+	defer span.End()
+
+	if a.purger == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Group purge not available",
+				Status:  http.StatusNotImplemented,
+				Code:    types.CodeNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	olderThan, err := time.ParseDuration(r.URL.Query().Get("older_than"))
+
+	if err != nil || olderThan < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "older_than must be a non negative duration such as 720h",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	purged, err := a.purger.PurgeDeletedGroups(ctx, olderThan)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		if errors.Is(err, groups.ErrSoftDeleteDisabled) {
+			status = http.StatusNotImplemented
+			code = types.CodeNotImplemented
+		} else {
+			a.logger.Errorf("failed purging deleted groups: %s", err)
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    PurgeResult{Purged: purged},
+			Message: fmt.Sprintf("Purged %d deleted groups", purged),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleReconcileGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleReconcileGroup")
+	defer span.End()
+
+	if a.groups == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Group reconciliation not available",
+				Status:  http.StatusNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	a.reconcile(ctx, w, r, "group", a.groups.ReconcileGroup, groups.ErrGroupNotFound, types.CodeGroupNotFound)
+}
+
+func (a *API) handleReconcileRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleReconcileRole")
+	defer span.End()
+
+	if a.roles == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Role reconciliation not available",
+				Status:  http.StatusNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	a.reconcile(ctx, w, r, "role", a.roles.ReconcileRole, roles.ErrRoleNotFound, types.CodeRoleNotFound)
+}
+
+// reconcile runs fn for the {id} URL parameter and the owner in the payload, notFound is the
+// error fn returns for an unknown object, reported with notFoundCode
+func (a *API) reconcile(ctx context.Context, w http.ResponseWriter, r *http.Request, kind string, fn reconcileFunc, notFound error, notFoundCode string) {
+	ID := chi.URLParam(r, "id")
+
+	defer r.Body.Close()
+
+	request := new(ReconcileRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	if request.Owner == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "owner is required",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	restored, err := fn(ctx, ID, request.Owner)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		if errors.Is(err, notFound) {
+			status = http.StatusNotFound
+			code = notFoundCode
+		} else {
+			a.logger.Errorf("failed reconciling %s %s: %s", kind, ID, err)
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    restored,
+			Message: fmt.Sprintf("Restored %d tuples of %s %s", len(restored), kind, ID),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleTestMail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleTestMail")
+	defer span.End()
+
+	if a.mail == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Mail service not available",
+				Status:  http.StatusNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	defer r.Body.Close()
+
+	request := new(TestMailRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+			},
+		)
+
+		return
+	}
+
+	if _, err := netmail.ParseAddress(request.To); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: fmt.Sprintf("invalid recipient address %q", request.To),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	if err := a.mail.SendTest(ctx, request.To); err != nil {
+		a.logger.Errorf("failed sending test email: %s", err)
+
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadGateway,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: fmt.Sprintf("Test email sent to %s", request.To),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleRefreshSchemas(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleRefreshSchemas")
+	defer span.End()
+
+	if a.schemas == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Schema cache not available",
+				Status:  http.StatusNotImplemented,
+			},
+		)
+
+		return
+	}
+
+	a.schemas.InvalidateSchemaCache(ctx)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: "Schema cache invalidated",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleAuthzInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleAuthzInfo")
+	defer span.End()
+
+	info, err := a.authorizer.AuthzInfo(ctx)
+
+	if err != nil {
+		a.logger.Errorf("failed reading authorization info: %s", err)
+
+		status := http.StatusBadGateway
+
+		if errors.Is(err, openfga.ErrModelNotFound) {
+			status = http.StatusNotFound
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    []openfga.AuthzInfo{*info},
+			Message: "Authorization info",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleReloadAuthzModel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "admin.API.handleReloadAuthzModel")
+	defer span.End()
+
+	defer r.Body.Close()
+
+	request := new(ReloadAuthzModelRequest)
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+			},
+		)
+
+		return
+	}
+
+	err := a.authorizer.ReloadModel(ctx, request.ModelID)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+
+		switch {
+		case errors.Is(err, authorization.ErrMissingAuthModelID):
+			status = http.StatusBadRequest
+		case errors.Is(err, openfga.ErrModelNotFound):
+			status = http.StatusNotFound
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    []ReloadAuthzModelRequest{*request},
+			Message: "Authorization model reloaded",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func NewAPI(authorizer AuthzModelReloaderInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
+	a := new(API)
+
+	a.authorizer = authorizer
+
+	a.tracer = tracer
+	a.monitor = monitor
+	a.logger = logger
+
+	return a
+}
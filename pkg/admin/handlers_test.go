@@ -0,0 +1,795 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/pkg/groups"
+	"github.com/canonical/identity-platform-admin-ui/pkg/roles"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package admin -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package admin -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package admin -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package admin -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestHandleReloadAuthzModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		modelID  string
+		err      error
+		expected int
+	}{
+		{
+			name:     "reloaded",
+			payload:  `{"model_id":"01HQ2JMD9F5RJ0S3Y9C4QX7V8T"}`,
+			modelID:  "01HQ2JMD9F5RJ0S3Y9C4QX7V8T",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "missing model ID",
+			payload:  `{}`,
+			err:      authorization.ErrMissingAuthModelID,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "model not in the store",
+			payload:  `{"model_id":"01HQ2JMD9F5RJ0S3Y9C4QX7V8T"}`,
+			modelID:  "01HQ2JMD9F5RJ0S3Y9C4QX7V8T",
+			err:      openfga.ErrModelNotFound,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "openfga unreachable",
+			payload:  `{"model_id":"01HQ2JMD9F5RJ0S3Y9C4QX7V8T"}`,
+			modelID:  "01HQ2JMD9F5RJ0S3Y9C4QX7V8T",
+			err:      fmt.Errorf("connection refused"),
+			expected: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/admin/authz/model", strings.NewReader(test.payload))
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleReloadAuthzModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockReloader.EXPECT().ReloadModel(gomock.Any(), test.modelID).Times(1).Return(test.err)
+
+			mux := chi.NewMux()
+			NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Status != test.expected {
+				t.Fatalf("expected response status %v got %v", test.expected, rr.Status)
+			}
+		})
+	}
+}
+
+func TestHandleReloadAuthzModelBadPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/admin/authz/model", strings.NewReader("not json"))
+	w := httptest.NewRecorder()
+
+	mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleReloadAuthzModel").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockReloader.EXPECT().ReloadModel(gomock.Any(), gomock.Any()).Times(0)
+
+	mux := chi.NewMux()
+	NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	if w.Result().StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %v got %v", http.StatusBadRequest, w.Result().StatusCode)
+	}
+}
+
+func TestHandleAuthzInfo(t *testing.T) {
+	info := &openfga.AuthzInfo{
+		StoreID:        "01HQ2J8G3X4D8ZK7N2Y5V6W9QA",
+		ModelID:        "01HQ2JMD9F5RJ0S3Y9C4QX7V8T",
+		ModelCreatedAt: time.Date(2024, 2, 21, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		info     *openfga.AuthzInfo
+		err      error
+		expected int
+	}{
+		{
+			name:     "bound store",
+			info:     info,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "model not in the store",
+			err:      openfga.ErrModelNotFound,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "openfga unreachable",
+			err:      fmt.Errorf("connection refused"),
+			expected: http.StatusBadGateway,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/admin/authz/info", nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleAuthzInfo").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockReloader.EXPECT().AuthzInfo(gomock.Any()).Times(1).Return(test.info, test.err)
+
+			if test.err != nil {
+				mockLogger.EXPECT().Errorf(gomock.Any(), test.err).Times(1)
+			}
+
+			mux := chi.NewMux()
+			NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, res.StatusCode)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			data, _ := json.Marshal(rr.Data)
+			result := make([]openfga.AuthzInfo, 0)
+
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(result) != 1 || result[0] != *info {
+				t.Fatalf("expected info to be %v got %v", *info, result)
+			}
+		})
+	}
+}
+
+func TestHandleRefreshSchemas(t *testing.T) {
+	tests := []struct {
+		name     string
+		cache    bool
+		expected int
+	}{
+		{
+			name:     "cache invalidated",
+			cache:    true,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "no cache",
+			expected: http.StatusNotImplemented,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/admin/schemas/refresh", nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleRefreshSchemas").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+
+			if test.cache {
+				mockCache := NewMockSchemaCacheInvalidatorInterface(ctrl)
+				mockCache.EXPECT().InvalidateSchemaCache(gomock.Any()).Times(1)
+
+				api.SetSchemaCache(mockCache)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleReconcile(t *testing.T) {
+	restored := []openfga.Tuple{*openfga.NewTuple("user:joe", "member", "group:admins")}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		body     string
+		noSvc    bool
+		err      error
+		expected int
+		code     string
+	}{
+		{
+			name:     "group reconciled",
+			endpoint: "/api/v0/admin/groups/admins/reconcile",
+			body:     `{"owner": "joe"}`,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "role reconciled",
+			endpoint: "/api/v0/admin/roles/viewer/reconcile",
+			body:     `{"owner": "joe"}`,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "group not found",
+			endpoint: "/api/v0/admin/groups/admins/reconcile",
+			body:     `{"owner": "joe"}`,
+			err:      groups.ErrGroupNotFound,
+			expected: http.StatusNotFound,
+			code:     types.CodeGroupNotFound,
+		},
+		{
+			name:     "role not found",
+			endpoint: "/api/v0/admin/roles/viewer/reconcile",
+			body:     `{"owner": "joe"}`,
+			err:      roles.ErrRoleNotFound,
+			expected: http.StatusNotFound,
+			code:     types.CodeRoleNotFound,
+		},
+		{
+			name:     "openfga error",
+			endpoint: "/api/v0/admin/groups/admins/reconcile",
+			body:     `{"owner": "joe"}`,
+			err:      fmt.Errorf("error"),
+			expected: http.StatusInternalServerError,
+			code:     types.CodeInternal,
+		},
+		{
+			name:     "missing owner",
+			endpoint: "/api/v0/admin/roles/viewer/reconcile",
+			body:     `{}`,
+			expected: http.StatusBadRequest,
+			code:     types.CodeInvalidParameter,
+		},
+		{
+			name:     "no reconcilers",
+			endpoint: "/api/v0/admin/groups/admins/reconcile",
+			body:     `{"owner": "joe"}`,
+			noSvc:    true,
+			expected: http.StatusNotImplemented,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+			mockGroups := NewMockGroupReconcilerInterface(ctrl)
+			mockRoles := NewMockRoleReconcilerInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, test.endpoint, strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.code != types.CodeInvalidParameter && !test.noSvc {
+				var r []openfga.Tuple
+
+				if test.err == nil {
+					r = restored
+				}
+
+				mockGroups.EXPECT().ReconcileGroup(gomock.Any(), "admins", "joe").AnyTimes().Return(r, test.err)
+				mockRoles.EXPECT().ReconcileRole(gomock.Any(), "viewer", "joe").AnyTimes().Return(r, test.err)
+			}
+
+			api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+
+			if !test.noSvc {
+				api.SetReconcilers(mockGroups, mockRoles)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, w.Code)
+			}
+
+			tuples := make([]openfga.Tuple, 0)
+			rr := types.Response{Data: &tuples}
+
+			if err := json.NewDecoder(w.Result().Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %q got %q", test.code, rr.Code)
+			}
+
+			if test.expected == http.StatusOK && !reflect.DeepEqual(tuples, restored) {
+				t.Errorf("expected restored tuples to be %v got %v", restored, tuples)
+			}
+		})
+	}
+}
+
+func TestHandlePurgeGroups(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		olderThan time.Duration
+		purged    int
+		noSvc     bool
+		err       error
+		expected  int
+		code      string
+	}{
+		{
+			name:      "groups purged",
+			query:     "?older_than=720h",
+			olderThan: 720 * time.Hour,
+			purged:    2,
+			expected:  http.StatusOK,
+		},
+		{
+			name:     "missing duration",
+			expected: http.StatusBadRequest,
+			code:     types.CodeInvalidParameter,
+		},
+		{
+			name:     "negative duration",
+			query:    "?older_than=-1h",
+			expected: http.StatusBadRequest,
+			code:     types.CodeInvalidParameter,
+		},
+		{
+			name:      "soft delete disabled",
+			query:     "?older_than=1h",
+			olderThan: time.Hour,
+			err:       groups.ErrSoftDeleteDisabled,
+			expected:  http.StatusNotImplemented,
+			code:      types.CodeNotImplemented,
+		},
+		{
+			name:      "trash error",
+			query:     "?older_than=1h",
+			olderThan: time.Hour,
+			err:       fmt.Errorf("error"),
+			expected:  http.StatusInternalServerError,
+			code:      types.CodeInternal,
+		},
+		{
+			name:     "no purger",
+			query:    "?older_than=1h",
+			noSvc:    true,
+			expected: http.StatusNotImplemented,
+			code:     types.CodeNotImplemented,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+			mockPurger := NewMockGroupPurgerInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/admin/groups/purge"+test.query, nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handlePurgeGroups").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.code != types.CodeInvalidParameter && !test.noSvc {
+				mockPurger.EXPECT().PurgeDeletedGroups(gomock.Any(), test.olderThan).Times(1).Return(test.purged, test.err)
+			}
+
+			api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+
+			if !test.noSvc {
+				api.SetGroupPurger(mockPurger)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, w.Code)
+			}
+
+			result := new(PurgeResult)
+			rr := types.Response{Data: result}
+
+			if err := json.NewDecoder(w.Result().Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %q got %q", test.code, rr.Code)
+			}
+
+			if result.Purged != test.purged {
+				t.Errorf("expected %d purged groups got %d", test.purged, result.Purged)
+			}
+		})
+	}
+}
+
+func TestHandleListAudit(t *testing.T) {
+	events := []audit.Event{
+		{Principal: "joe", Action: audit.GroupDelete, ResourceType: audit.GroupResource, ResourceID: "admins", Outcome: audit.OutcomeSuccess},
+		{Principal: "joe", Action: audit.GroupCreate, ResourceType: audit.GroupResource, ResourceID: "admins", Outcome: audit.OutcomeSuccess},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		filter   audit.Filter
+		token    string
+		size     int
+		events   []audit.Event
+		next     string
+		noLog    bool
+		err      error
+		expected int
+		code     string
+	}{
+		{
+			name:     "filtered",
+			query:    "?actor=joe&action=group.delete&resource_type=group&resource_id=admins&size=1",
+			filter:   audit.Filter{Principal: "joe", Action: audit.GroupDelete, ResourceType: audit.GroupResource, ResourceID: "admins"},
+			size:     1,
+			events:   events[:1],
+			next:     "3",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "next page",
+			query:    "?page_token=3",
+			token:    "3",
+			size:     int(types.DefaultPageSize),
+			events:   events,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "invalid size",
+			query:    "?size=0",
+			expected: http.StatusBadRequest,
+			code:     types.CodeInvalidParameter,
+		},
+		{
+			name:     "invalid page token",
+			query:    "?page_token=abc",
+			token:    "abc",
+			size:     int(types.DefaultPageSize),
+			err:      audit.ErrInvalidPageToken,
+			expected: http.StatusBadRequest,
+			code:     types.CodeInvalidParameter,
+		},
+		{
+			name:     "store error",
+			size:     int(types.DefaultPageSize),
+			err:      fmt.Errorf("error"),
+			expected: http.StatusInternalServerError,
+			code:     types.CodeInternal,
+		},
+		{
+			name:     "no audit log",
+			noLog:    true,
+			expected: http.StatusNotImplemented,
+			code:     types.CodeNotImplemented,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+			mockAuditLog := NewMockAuditLogInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/audit"+test.query, nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleListAudit").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.size > 0 {
+				mockAuditLog.EXPECT().List(gomock.Any(), test.filter, test.token, test.size).Times(1).Return(test.events, test.next, test.err)
+			}
+
+			api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+
+			if !test.noLog {
+				api.SetAuditLog(mockAuditLog)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, w.Code)
+			}
+
+			result := make([]audit.Event, 0)
+			rr := types.Response{Data: &result}
+
+			if err := json.NewDecoder(w.Result().Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %q got %q", test.code, rr.Code)
+			}
+
+			if test.code != "" {
+				return
+			}
+
+			if !reflect.DeepEqual(result, test.events) {
+				t.Errorf("expected events %v got %v", test.events, result)
+			}
+
+			if rr.Meta == nil || rr.Meta.Next != test.next || rr.Meta.Size != int64(test.size) {
+				t.Errorf("expected next token %q and size %d got %v", test.next, test.size, rr.Meta)
+			}
+		})
+	}
+}
+
+// TestHandleListAuditRecorded checks the actions recorded by the auditor are listed newest first
+func TestHandleListAuditRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockLogger.EXPECT().Info(gomock.Any()).AnyTimes()
+
+	store := audit.NewMemoryStore(10)
+
+	auditor := audit.NewAuditor(mockLogger)
+	auditor.SetStore(store)
+
+	auditor.Record(context.Background(), audit.GroupCreate, audit.GroupResource, "admins", audit.OutcomeSuccess)
+	auditor.Record(context.Background(), audit.RoleCreate, audit.RoleResource, "viewer", audit.OutcomeSuccess)
+	auditor.Record(context.Background(), audit.GroupDelete, audit.GroupResource, "admins", audit.OutcomeFailure)
+
+	api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+	api.SetAuditLog(store)
+
+	mux := chi.NewMux()
+	api.RegisterEndpoints(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v0/audit", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v got %v", http.StatusOK, w.Code)
+	}
+
+	result := make([]audit.Event, 0)
+	rr := types.Response{Data: &result}
+
+	if err := json.NewDecoder(w.Result().Body).Decode(&rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	actions := make([]string, 0, len(result))
+
+	for _, e := range result {
+		actions = append(actions, e.Action)
+	}
+
+	if expected := []string{audit.GroupDelete, audit.RoleCreate, audit.GroupCreate}; !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("expected actions %v got %v", expected, actions)
+	}
+}
+
+func TestHandleTestMail(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		noMail   bool
+		limited  bool
+		sendErr  error
+		sent     bool
+		expected int
+	}{
+		{
+			name:     "sent",
+			body:     `{"to": "joe@example.com"}`,
+			sent:     true,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "smtp error",
+			body:     `{"to": "joe@example.com"}`,
+			sent:     true,
+			sendErr:  fmt.Errorf("535 authentication failed"),
+			expected: http.StatusBadGateway,
+		},
+		{
+			name:     "invalid recipient",
+			body:     `{"to": "joe"}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "bad payload",
+			body:     `{"to":`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "no mail service",
+			body:     `{"to": "joe@example.com"}`,
+			noMail:   true,
+			expected: http.StatusNotImplemented,
+		},
+		{
+			name:     "rate limited",
+			body:     `{"to": "joe@example.com"}`,
+			limited:  true,
+			expected: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockReloader := NewMockAuthzModelReloaderInterface(ctrl)
+			mockMail := mail.NewMockEmailServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/admin/mail/test", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleTestMail").AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			if test.sent {
+				mockMail.EXPECT().SendTest(gomock.Any(), "joe@example.com").Times(1).Return(test.sendErr)
+			}
+
+			api := NewAPI(mockReloader, mockTracer, mockMonitor, mockLogger)
+
+			limits := make([]func(http.Handler) http.Handler, 0)
+
+			if test.limited {
+				limits = append(limits, func(http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTooManyRequests) })
+				})
+			}
+
+			if !test.noMail {
+				api.SetMailService(mockMail, limits...)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != test.expected {
+				t.Fatalf("expected status %v got %v", test.expected, w.Code)
+			}
+
+			if !test.limited {
+				return
+			}
+
+			// limits only apply to the mail test endpoint
+			mockTracer.EXPECT().Start(gomock.Any(), "admin.API.handleRefreshSchemas").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			w = httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v0/admin/schemas/refresh", nil))
+
+			if w.Code == http.StatusTooManyRequests {
+				t.Fatal("expected other admin endpoints not to be rate limited")
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// AuthzModelReloaderInterface swaps the authorization model in use without a restart and
+// reports which store and model are in use
+type AuthzModelReloaderInterface interface {
+	ReloadModel(context.Context, string) error
+	AuthzInfo(context.Context) (*openfga.AuthzInfo, error)
+}
+
+// SchemaCacheInvalidatorInterface drops the cached identity schemas so they are read again
+type SchemaCacheInvalidatorInterface interface {
+	InvalidateSchemaCache(context.Context)
+}
+
+// GroupReconcilerInterface writes back the creator tuples missing on a group
+type GroupReconcilerInterface interface {
+	ReconcileGroup(context.Context, string, string) ([]openfga.Tuple, error)
+}
+
+// RoleReconcilerInterface writes back the creator tuples missing on a role
+type RoleReconcilerInterface interface {
+	ReconcileRole(context.Context, string, string) ([]openfga.Tuple, error)
+}
+
+// GroupPurgerInterface permanently drops the soft deleted groups older than a retention period
+type GroupPurgerInterface interface {
+	PurgeDeletedGroups(context.Context, time.Duration) (int, error)
+}
+
+// AuditLogInterface lists the recorded audit events newest first
+type AuditLogInterface interface {
+	List(context.Context, audit.Filter, string, int) ([]audit.Event, string, error)
+}
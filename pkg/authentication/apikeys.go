@@ -0,0 +1,153 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package authentication
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+)
+
+const (
+	// APIKeySubjectPrefix namespaces the subjects of API keys, the relations of a key are sent as
+	// contextual tuples for its subject and must never attach to a real user or OAuth2 client
+	APIKeySubjectPrefix = "apikey:"
+
+	// MaxAPIKeyRelations keeps the contextual tuples of a check within the 20 OpenFGA accepts,
+	// the authorization converters add up to 2 of their own
+	MaxAPIKeyRelations = 18
+)
+
+// ErrInvalidAPIKey is returned when a key doesn't match any of the configured ones
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// ObjectRelation is an OpenFGA relation granted to a service principal on an object, eg can_view on group:admins
+type ObjectRelation struct {
+	Relation string `json:"relation"`
+	Object   string `json:"object"`
+}
+
+// APIKey maps a long lived key to the service principal subject and the relations it holds
+type APIKey struct {
+	Key       string           `json:"key"`
+	Subject   string           `json:"subject"`
+	Relations []ObjectRelation `json:"relations"`
+}
+
+type apiKeyEntry struct {
+	digest    [sha256.Size]byte
+	subject   string
+	relations []ObjectRelation
+}
+
+type APIKeyVerifier struct {
+	keys []apiKeyEntry
+
+	tracer trace.Tracer
+	logger logging.LoggerInterface
+}
+
+// Verify returns the ServicePrincipal associated with rawKey, every configured key is compared
+// against the digest of rawKey in constant time so the lookup doesn't leak which key matched
+// the subject of the principal carries APIKeySubjectPrefix
+func (v *APIKeyVerifier) Verify(ctx context.Context, rawKey string) (*ServicePrincipal, error) {
+	_, span := v.tracer.Start(ctx, "authentication.APIKeyVerifier.Verify")
+	defer span.End()
+
+	digest := sha256.Sum256([]byte(rawKey))
+
+	var match *apiKeyEntry
+
+	for i := range v.keys {
+		if subtle.ConstantTimeCompare(digest[:], v.keys[i].digest[:]) == 1 {
+			match = &v.keys[i]
+		}
+	}
+
+	if match == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	principal := new(ServicePrincipal)
+	principal.Subject = APIKeySubjectPrefix + match.subject
+	principal.Relations = append([]ObjectRelation(nil), match.relations...)
+
+	return principal, nil
+}
+
+// LoadAPIKeys reads the API keys from a JSON file containing a list of APIKey objects, keys and
+// subjects must be unique and a key can hold at most MaxAPIKeyRelations relations
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	raw, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKey, 0)
+
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("invalid api keys file %s: %w", path, err)
+	}
+
+	subjects := make(map[string]bool)
+	digests := make(map[[sha256.Size]byte]bool)
+
+	for i, key := range keys {
+		if key.Key == "" || key.Subject == "" {
+			return nil, fmt.Errorf("api key %d in %s is missing key or subject", i, path)
+		}
+
+		if subjects[key.Subject] {
+			return nil, fmt.Errorf("duplicate api key subject %s in %s", key.Subject, path)
+		}
+
+		// only one of two identical keys could ever match, the other subject would be unreachable
+		digest := sha256.Sum256([]byte(key.Key))
+
+		if digests[digest] {
+			return nil, fmt.Errorf("api key of subject %s in %s is already used by another subject", key.Subject, path)
+		}
+
+		if len(key.Relations) > MaxAPIKeyRelations {
+			return nil, fmt.Errorf("api key of subject %s in %s has %d relations, at most %d are allowed", key.Subject, path, len(key.Relations), MaxAPIKeyRelations)
+		}
+
+		subjects[key.Subject] = true
+		digests[digest] = true
+	}
+
+	return keys, nil
+}
+
+// NewAPIKeyVerifier only keeps the SHA-256 digest of the keys in memory
+func NewAPIKeyVerifier(keys []APIKey, tracer trace.Tracer, logger logging.LoggerInterface) *APIKeyVerifier {
+	v := new(APIKeyVerifier)
+
+	v.keys = make([]apiKeyEntry, 0, len(keys))
+
+	for _, key := range keys {
+		v.keys = append(
+			v.keys,
+			apiKeyEntry{
+				digest:    sha256.Sum256([]byte(key.Key)),
+				subject:   key.Subject,
+				relations: key.Relations,
+			},
+		)
+	}
+
+	v.tracer = tracer
+	v.logger = logger
+
+	return v
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package authentication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyVerifierVerify(t *testing.T) {
+	keys := []APIKey{
+		{Key: "ci-key", Subject: "ci-pipeline", Relations: []ObjectRelation{{Relation: "can_view", Object: "group:admins"}}},
+		{Key: "backup-key", Subject: "backup"},
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected *ServicePrincipal
+		err      error
+	}{
+		{
+			name:     "scoped key",
+			key:      "ci-key",
+			expected: &ServicePrincipal{Subject: "apikey:ci-pipeline", Relations: []ObjectRelation{{Relation: "can_view", Object: "group:admins"}}},
+		},
+		{
+			name:     "key without relations",
+			key:      "backup-key",
+			expected: &ServicePrincipal{Subject: "apikey:backup"},
+		},
+		{
+			name: "unknown key",
+			key:  "ci-key-2",
+			err:  ErrInvalidAPIKey,
+		},
+		{
+			name: "empty key",
+			key:  "",
+			err:  ErrInvalidAPIKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTracer := NewMockTracer(ctrl)
+			mockLogger := NewMockLoggerInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "authentication.APIKeyVerifier.Verify").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			principal, err := NewAPIKeyVerifier(keys, mockTracer, mockLogger).Verify(context.TODO(), test.key)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+
+			if test.expected == nil {
+				if principal != nil {
+					t.Fatalf("expected principal to be nil got %v", principal)
+				}
+
+				return
+			}
+
+			if principal.Subject != test.expected.Subject || len(principal.Relations) != len(test.expected.Relations) {
+				t.Fatalf("expected principal to be %v got %v", test.expected, principal)
+			}
+
+			if len(principal.Relations) > 0 && !reflect.DeepEqual(principal.Relations, test.expected.Relations) {
+				t.Fatalf("expected relations to be %v got %v", test.expected.Relations, principal.Relations)
+			}
+		})
+	}
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []APIKey
+		err      bool
+	}{
+		{
+			name:    "valid",
+			content: `[{"key": "ci-key", "subject": "ci-pipeline", "relations": [{"relation": "can_view", "object": "group:admins"}]}]`,
+			expected: []APIKey{
+				{Key: "ci-key", Subject: "ci-pipeline", Relations: []ObjectRelation{{Relation: "can_view", Object: "group:admins"}}},
+			},
+		},
+		{
+			name:    "missing key",
+			content: `[{"subject": "ci-pipeline"}]`,
+			err:     true,
+		},
+		{
+			name:    "duplicate subject",
+			content: `[{"key": "a", "subject": "ci-pipeline"}, {"key": "b", "subject": "ci-pipeline"}]`,
+			err:     true,
+		},
+		{
+			name:    "duplicate key",
+			content: `[{"key": "a", "subject": "ci-pipeline"}, {"key": "a", "subject": "backup"}]`,
+			err:     true,
+		},
+		{
+			name:    "too many relations",
+			content: fmt.Sprintf(`[{"key": "a", "subject": "ci-pipeline", "relations": [%s]}]`, strings.Repeat(`{"relation": "can_view", "object": "group:admins"},`, MaxAPIKeyRelations)+`{"relation": "can_view", "object": "group:viewers"}`),
+			err:     true,
+		},
+		{
+			name:    "malformed",
+			content: `{"key": "a"`,
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys.json")
+
+			if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+				t.Fatalf("failed writing keys file: %s", err)
+			}
+
+			keys, err := LoadAPIKeys(path)
+
+			if test.err != (err != nil) {
+				t.Fatalf("expected error %v got %v", test.err, err)
+			}
+
+			if !test.err && !reflect.DeepEqual(keys, test.expected) {
+				t.Fatalf("expected keys to be %v got %v", test.expected, keys)
+			}
+		})
+	}
+}
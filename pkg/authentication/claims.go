@@ -0,0 +1,122 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package authentication
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClaimsMapping drives the conversion between ID token claims and UserPrincipal, Email is the
+// claim holding the identifier, Groups and Roles the optional claims preloading the group and role
+// memberships of the user, nested claims are reached with dot separated paths such as realm_access.roles
+type ClaimsMapping struct {
+	Email  string
+	Groups string
+	Roles  string
+}
+
+// DefaultClaimsMapping reads the email claim only, as done by NewUserPrincipalFromClaims
+func DefaultClaimsMapping() *ClaimsMapping {
+	m := new(ClaimsMapping)
+
+	m.Email = "email"
+
+	return m
+}
+
+// NewClaimsMapping returns a ClaimsMapping, the email claim is used when email is empty, groups
+// and roles are not read when empty
+func NewClaimsMapping(email, groups, roles string) *ClaimsMapping {
+	m := DefaultClaimsMapping()
+
+	if email != "" {
+		m.Email = email
+	}
+
+	m.Groups = groups
+	m.Roles = roles
+
+	return m
+}
+
+// ToUserPrincipal maps the claims to a UserPrincipal, a nil mapping behaves as DefaultClaimsMapping
+func (m *ClaimsMapping) ToUserPrincipal(c ReadableClaims) (*UserPrincipal, error) {
+	principal, err := NewUserPrincipalFromClaims(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil || (m.Email == "email" && m.Groups == "" && m.Roles == "") {
+		return principal, nil
+	}
+
+	claims := make(map[string]interface{})
+
+	if err := c.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if m.Email != "email" {
+		email, ok := claimValue(claims, m.Email).(string)
+
+		if !ok {
+			return nil, fmt.Errorf("claim %s is missing or not a string", m.Email)
+		}
+
+		if err := checkReservedIdentifier(email); err != nil {
+			return nil, err
+		}
+
+		principal.Email = email
+	}
+
+	if m.Groups != "" {
+		principal.Groups = stringsClaim(claimValue(claims, m.Groups))
+	}
+
+	if m.Roles != "" {
+		principal.Roles = stringsClaim(claimValue(claims, m.Roles))
+	}
+
+	return principal, nil
+}
+
+// claimValue returns the value at the dot separated path, nil if any of the segments is missing
+func claimValue(claims map[string]interface{}, path string) interface{} {
+	var value interface{} = claims
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+
+		if !ok {
+			return nil
+		}
+
+		value = object[key]
+	}
+
+	return value
+}
+
+// stringsClaim reads a list claim, a single string counts as a list of one, non string items are skipped
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package authentication
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+type jsonClaims []byte
+
+func (c jsonClaims) Claims(v interface{}) error {
+	return json.Unmarshal(c, v)
+}
+
+func TestClaimsMappingToUserPrincipal(t *testing.T) {
+	claims := jsonClaims(`{
+		"sub": "mock-subject",
+		"email": "default@example.com",
+		"upn": "joe@example.com",
+		"roles": ["viewer", "admin", 1],
+		"team": "platform",
+		"service": "apikey:ci-pipeline",
+		"realm_access": {"roles": ["auditor"], "email": "realm@example.com"}
+	}`)
+
+	for _, tt := range []struct {
+		name    string
+		mapping *ClaimsMapping
+		email   string
+		groups  []string
+		roles   []string
+		err     bool
+	}{
+		{
+			name:  "nil mapping",
+			email: "default@example.com",
+		},
+		{
+			name:    "default mapping",
+			mapping: NewClaimsMapping("", "", ""),
+			email:   "default@example.com",
+		},
+		{
+			name:    "custom email claim",
+			mapping: NewClaimsMapping("upn", "", ""),
+			email:   "joe@example.com",
+		},
+		{
+			name:    "groups and roles claims",
+			mapping: NewClaimsMapping("", "team", "roles"),
+			email:   "default@example.com",
+			groups:  []string{"platform"},
+			roles:   []string{"viewer", "admin"},
+		},
+		{
+			name:    "nested claims",
+			mapping: NewClaimsMapping("realm_access.email", "", "realm_access.roles"),
+			email:   "realm@example.com",
+			roles:   []string{"auditor"},
+		},
+		{
+			name:    "missing roles claim",
+			mapping: NewClaimsMapping("", "", "resource_access.roles"),
+			email:   "default@example.com",
+		},
+		{
+			name:    "email claim in the api keys namespace",
+			mapping: NewClaimsMapping("service", "", ""),
+			err:     true,
+		},
+		{
+			name:    "missing email claim",
+			mapping: NewClaimsMapping("mail", "", ""),
+			err:     true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tt.mapping.ToUserPrincipal(claims)
+
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error got principal %v", principal)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if principal.Subject != "mock-subject" {
+				t.Errorf("expected subject to be mock-subject got %s", principal.Subject)
+			}
+
+			if principal.Identifier() != tt.email {
+				t.Errorf("expected identifier to be %s got %s", tt.email, principal.Identifier())
+			}
+
+			if !reflect.DeepEqual(principal.Groups, tt.groups) {
+				t.Errorf("expected groups to be %v got %v", tt.groups, principal.Groups)
+			}
+
+			if !reflect.DeepEqual(principal.Roles, tt.roles) {
+				t.Errorf("expected roles to be %v got %v", tt.roles, principal.Roles)
+			}
+		})
+	}
+}
+
+func TestPrincipalFromClaimsRejectsAPIKeySubjects(t *testing.T) {
+	if _, err := NewServicePrincipalFromClaims(jsonClaims(`{"sub": "apikey:ci-pipeline"}`)); !errors.Is(err, ErrReservedSubject) {
+		t.Errorf("expected error to be %v got %v", ErrReservedSubject, err)
+	}
+
+	if _, err := NewUserPrincipalFromClaims(jsonClaims(`{"sub": "joe", "email": "apikey:ci-pipeline"}`)); !errors.Is(err, ErrReservedSubject) {
+		t.Errorf("expected error to be %v got %v", ErrReservedSubject, err)
+	}
+
+	if _, err := NewServicePrincipalFromClaims(jsonClaims(`{"sub": "hydra-client"}`)); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+}
+
+func TestJWKSTokenVerifier_VerifyIDTokenClaimsMapping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockProvider := NewMockProviderInterface(ctrl)
+
+	mockProvider.EXPECT().Verifier(&oidc.Config{ClientID: "mock-client-id", SkipExpiryCheck: true}).Return(
+		oidc.NewVerifier("", nil, &oidc.Config{
+			ClientID:                   "mock-client-id",
+			SkipExpiryCheck:            true,
+			SkipIssuerCheck:            true,
+			InsecureSkipSignatureCheck: true,
+		}),
+	)
+
+	verifier := NewJWKSTokenVerifier(mockProvider, "mock-client-id", mockTracer, mockLogger, mockMonitor)
+	verifier.SetClaimsMapping(NewClaimsMapping("preferred_username", "groups", "roles"))
+
+	// signatures are not checked, only the claims matter
+	payload, _ := json.Marshal(
+		map[string]interface{}{
+			"sub":                "mock-subject",
+			"aud":                "mock-client-id",
+			"preferred_username": "joe@example.com",
+			"groups":             []string{"admins"},
+			"roles":              []string{"viewer"},
+			"exp":                time.Now().Add(time.Hour).Unix(),
+		},
+	)
+
+	token := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+
+	principal, err := verifier.VerifyIDToken(context.TODO(), token)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if principal.Identifier() != "joe@example.com" {
+		t.Errorf("expected identifier to be joe@example.com got %s", principal.Identifier())
+	}
+
+	if !reflect.DeepEqual(principal.Groups, []string{"admins"}) || !reflect.DeepEqual(principal.Roles, []string{"viewer"}) {
+		t.Errorf("expected groups [admins] and roles [viewer] got %v and %v", principal.Groups, principal.Roles)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.opentelemetry.io/otel/trace"
@@ -38,6 +39,20 @@ type Config struct {
 	scopes                      []string                     `validate:"required,dive,required"`
 	hydraPublicAPIClient        clients.HydraClientInterface `validate:"required"`
 	hydraAdminAPIClient         clients.HydraClientInterface `validate:"required"`
+
+	// APIKeys are the long lived keys accepted as bearer tokens for service principals
+	APIKeys []APIKey
+
+	// TrustedIssuers maps the issuers federated on top of the main one to the client ID
+	// their tokens are issued to, only honoured by the jwks verification strategy
+	TrustedIssuers map[string]string
+
+	// ClaimsMapping sets the ID token claims read into the UserPrincipal, nil reads the email claim only
+	ClaimsMapping *ClaimsMapping
+
+	// Leeway is the clock skew tolerated on the token exp and nbf claims by the jwks verification
+	// strategy, capped to MaxLeeway
+	Leeway time.Duration
 }
 
 func NewAuthenticationConfig(
@@ -60,6 +75,7 @@ func NewAuthenticationConfig(
 	c.scopes = scopes
 	c.AuthCookieTTLSeconds = authCookiesTTLSeconds
 	c.UserSessionCookieTTLSeconds = userSessionCookieTTLSeconds
+	c.Leeway = DefaultLeeway
 
 	c.hydraPublicAPIClient = hydraPublicAPIClient
 	c.hydraAdminAPIClient = hydraAdminAPIClient
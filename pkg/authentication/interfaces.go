@@ -23,6 +23,11 @@ type TokenVerifier interface {
 	VerifyIDToken(context.Context, string) (*UserPrincipal, error)
 }
 
+type APIKeyVerifierInterface interface {
+	// Verify a raw API key, returns the ServicePrincipal it is mapped to
+	Verify(context.Context, string) (*ServicePrincipal, error)
+}
+
 type ProviderInterface interface {
 	// Endpoint returns a set of endpoints from the well-known openid configuration
 	Endpoint() oauth2.Endpoint
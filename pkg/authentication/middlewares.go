@@ -21,6 +21,7 @@ type Middleware struct {
 	allowListedEndpoints map[string]bool
 	oauth2               OAuth2ContextInterface
 	cookieManager        AuthCookieManagerInterface
+	apiKeys              APIKeyVerifierInterface
 
 	tracer tracing.TracingInterface
 	logger logging.LoggerInterface
@@ -44,11 +45,49 @@ func (m *Middleware) isAllowListed(r *http.Request) bool {
 	return ok
 }
 
+// SetAPIKeyVerifier enables API key authentication through the Authorization: Bearer header,
+// keys are checked before falling back to the OAuth2 access token verification
+func (m *Middleware) SetAPIKeyVerifier(verifier APIKeyVerifierInterface) {
+	m.apiKeys = verifier
+}
+
 func (m *Middleware) OAuth2AuthenticationChain() []func(http.Handler) http.Handler {
-	return []func(http.Handler) http.Handler{
-		m.oAuth2BearerAuthentication,
-		m.oAuth2CookieAuthentication,
+	chain := make([]func(http.Handler) http.Handler, 0, 3)
+
+	if m.apiKeys != nil {
+		chain = append(chain, m.apiKeyAuthentication)
 	}
+
+	return append(chain, m.oAuth2BearerAuthentication, m.oAuth2CookieAuthentication)
+}
+
+func (m *Middleware) apiKeyAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := m.tracer.Start(r.Context(), "authentication.Middleware.apiKeyAuthentication")
+		defer span.End()
+
+		if m.isAllowListed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rawKey, found := m.getBearerToken(r.Header)
+
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		servicePrincipal, err := m.apiKeys.Verify(ctx, rawKey)
+
+		// not an API key, let the access token verification deal with it
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(PrincipalContext(ctx, servicePrincipal)))
+	})
 }
 
 func (m *Middleware) oAuth2BearerAuthentication(next http.Handler) http.Handler {
@@ -61,6 +100,12 @@ func (m *Middleware) oAuth2BearerAuthentication(next http.Handler) http.Handler
 			return
 		}
 
+		// principal != nil means API key authentication set the principal already
+		if PrincipalFromContext(r.Context()) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		var (
 			servicePrincipal *ServicePrincipal
 			err              error
@@ -217,6 +262,7 @@ func (m *Middleware) unauthorizedResponse(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusUnauthorized)
 	_ = json.NewEncoder(w).Encode(types.Response{
 		Status:  http.StatusUnauthorized,
+		Code:    types.CodeUnauthorized,
 		Message: fmt.Sprintf("unauthorized: %s", err.Error()),
 	})
 }
@@ -481,6 +481,118 @@ func TestMiddleware_OAuth2AuthenticationMiddlewareSuccess(t *testing.T) {
 	}
 }
 
+func TestMiddleware_APIKeyAuthentication(t *testing.T) {
+	relations := []ObjectRelation{{Relation: "can_view", Object: "group:admins"}}
+
+	tests := []struct {
+		name       string
+		header     string
+		setupMocks func(*MockAPIKeyVerifierInterface, *MockTokenVerifier, *MockOAuth2ContextInterface, *MockTracer)
+		expected   int
+		subject    string
+	}{
+		{
+			name:   "valid api key",
+			header: "Bearer ci-key",
+			setupMocks: func(keys *MockAPIKeyVerifierInterface, verifier *MockTokenVerifier, oauth2 *MockOAuth2ContextInterface, tracer *MockTracer) {
+				keys.EXPECT().Verify(gomock.Any(), "ci-key").Return(&ServicePrincipal{Subject: "apikey:ci-pipeline", Relations: relations}, nil)
+				verifier.EXPECT().VerifyAccessToken(gomock.Any(), gomock.Any()).Times(0)
+				tracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+						return ctx, trace.SpanFromContext(ctx)
+					},
+				)
+			},
+			expected: http.StatusOK,
+			subject:  "apikey:ci-pipeline",
+		},
+		{
+			name:   "not an api key falls back to access token",
+			header: "Bearer access-token",
+			setupMocks: func(keys *MockAPIKeyVerifierInterface, verifier *MockTokenVerifier, oauth2 *MockOAuth2ContextInterface, tracer *MockTracer) {
+				keys.EXPECT().Verify(gomock.Any(), "access-token").Return(nil, ErrInvalidAPIKey)
+				verifier.EXPECT().VerifyAccessToken(gomock.Any(), "access-token").Return(&ServicePrincipal{Subject: "hydra-client"}, nil)
+				oauth2.EXPECT().Verifier().Return(verifier)
+				tracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+						return ctx, trace.SpanFromContext(ctx)
+					},
+				)
+			},
+			expected: http.StatusOK,
+			subject:  "hydra-client",
+		},
+		{
+			name:   "access token claiming the api key namespace",
+			header: "Bearer access-token",
+			setupMocks: func(keys *MockAPIKeyVerifierInterface, verifier *MockTokenVerifier, oauth2 *MockOAuth2ContextInterface, tracer *MockTracer) {
+				keys.EXPECT().Verify(gomock.Any(), "access-token").Return(nil, ErrInvalidAPIKey)
+				verifier.EXPECT().VerifyAccessToken(gomock.Any(), "access-token").Return(nil, ErrReservedSubject)
+				oauth2.EXPECT().Verifier().Return(verifier)
+				tracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+					func(ctx context.Context, _ string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+						return ctx, trace.SpanFromContext(ctx)
+					},
+				)
+			},
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tracer := NewMockTracer(ctrl)
+			logger := NewMockLoggerInterface(ctrl)
+			keys := NewMockAPIKeyVerifierInterface(ctrl)
+			verifier := NewMockTokenVerifier(ctrl)
+			oauth2Ctx := NewMockOAuth2ContextInterface(ctrl)
+			cookieManager := NewMockAuthCookieManagerInterface(ctrl)
+
+			tt.setupMocks(keys, verifier, oauth2Ctx, tracer)
+
+			// unauthorized responses clear the cookies
+			cookieManager.EXPECT().ClearIDTokenCookie(gomock.Any()).AnyTimes()
+			cookieManager.EXPECT().ClearAccessTokenCookie(gomock.Any()).AnyTimes()
+			cookieManager.EXPECT().ClearRefreshTokenCookie(gomock.Any()).AnyTimes()
+
+			var principal PrincipalInterface
+
+			mainHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal = PrincipalFromContext(r.Context())
+			})
+
+			middleware := NewAuthenticationMiddleware(oauth2Ctx, cookieManager, tracer, logger)
+			middleware.SetAPIKeyVerifier(keys)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/groups", nil)
+			req.Header.Set("Authorization", tt.header)
+
+			mockResponse := httptest.NewRecorder()
+
+			applyMiddlewares(mainHandler, middleware.OAuth2AuthenticationChain()...).ServeHTTP(mockResponse, req)
+
+			if mockResponse.Code != tt.expected {
+				t.Fatalf("expected status to be %d got %d", tt.expected, mockResponse.Code)
+			}
+
+			if tt.subject == "" {
+				if principal != nil {
+					t.Fatalf("expected no principal got %v", principal)
+				}
+
+				return
+			}
+
+			if principal == nil || principal.Identifier() != tt.subject {
+				t.Fatalf("expected principal %s got %v", tt.subject, principal)
+			}
+		})
+	}
+}
+
 func applyMiddlewares(handler http.Handler, ms ...func(http.Handler) http.Handler) http.Handler {
 	for i := len(ms) - 1; i >= 0; i-- {
 		handler = ms[i](handler)
@@ -172,9 +172,37 @@ func NewOAuth2Context(config *Config, getProvider OIDCProviderSupplier, tracer t
 	var verifier TokenVerifier
 	switch config.verificationStrategy {
 	case "jwks":
-		verifier = NewJWKSTokenVerifier(provider, config.clientID, tracer, logger, monitor)
+		if len(config.TrustedIssuers) == 0 {
+			v := NewJWKSTokenVerifier(provider, config.clientID, tracer, logger, monitor)
+			v.SetClaimsMapping(config.ClaimsMapping)
+			v.SetLeeway(config.Leeway)
+			verifier = v
+			break
+		}
+
+		issuers := map[string]IssuerConfig{config.issuer: {Provider: provider, ClientID: config.clientID}}
+
+		for issuer, clientID := range config.TrustedIssuers {
+			p, err := getProvider(ctx, issuer)
+			if err != nil {
+				o.logger.Fatalf("Unable to fetch provider info for trusted issuer %s, error: %v", issuer, err.Error())
+			}
+
+			issuers[issuer] = IssuerConfig{Provider: p, ClientID: clientID}
+		}
+
+		v := NewMultiIssuerJWKSTokenVerifier(config.issuer, issuers, tracer, logger, monitor)
+		v.SetClaimsMapping(config.ClaimsMapping)
+		v.SetLeeway(config.Leeway)
+		verifier = v
 	case "userinfo":
-		verifier = NewUserinfoTokenVerifier(provider, config.clientID, tracer, logger, monitor)
+		if len(config.TrustedIssuers) > 0 {
+			o.logger.Warn("trusted issuers are ignored by the userinfo verification strategy")
+		}
+
+		v := NewUserinfoTokenVerifier(provider, config.clientID, tracer, logger, monitor)
+		v.SetClaimsMapping(config.ClaimsMapping)
+		verifier = v
 	default:
 		o.logger.Fatalf("OAuth2VerificationStrategy value is not valid, expected one of 'jwks, userinfo', got %v", config.verificationStrategy)
 	}
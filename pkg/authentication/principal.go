@@ -3,12 +3,21 @@
 
 package authentication
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 type principalContextKey int
 
 var PrincipalContextKey principalContextKey
 
+// ErrReservedSubject is returned when a principal built from claims falls in the API keys namespace,
+// it would inherit the relations granted to the key
+var ErrReservedSubject = errors.New("subject is reserved for api keys")
+
 type UserPrincipal struct {
 	Subject   string `json:"sub"`
 	Name      string `json:"name"`
@@ -16,6 +25,13 @@ type UserPrincipal struct {
 	SessionID string `json:"sid"`
 	Nonce     string `json:"nonce"`
 
+	// Groups and Roles are preloaded from the claims set in ClaimsMapping, nil when not configured
+	Groups []string `json:"-"`
+	Roles  []string `json:"-"`
+
+	// Namespace prefixes the identifier of principals from a federated issuer, empty for the main one
+	Namespace string `json:"-"`
+
 	RawAccessToken  string `json:"-"`
 	RawIdToken      string `json:"-"`
 	RawRefreshToken string `json:"-"`
@@ -38,12 +54,18 @@ func (u *UserPrincipal) IDToken() string {
 }
 
 func (u *UserPrincipal) Identifier() string {
-	return u.Email
+	return namespaced(u.Namespace, u.Email)
 }
 
 type ServicePrincipal struct {
 	Subject        string `json:"sub"`
 	RawAccessToken string `json:"-"`
+
+	// Namespace prefixes the identifier of principals from a federated issuer, empty for the main one
+	Namespace string `json:"-"`
+
+	// Relations are granted to the principal on top of its stored tuples, only populated for API keys
+	Relations []ObjectRelation `json:"-"`
 }
 
 func (s *ServicePrincipal) Session() string {
@@ -63,7 +85,17 @@ func (s *ServicePrincipal) IDToken() string {
 }
 
 func (s *ServicePrincipal) Identifier() string {
-	return s.Subject
+	return namespaced(s.Namespace, s.Subject)
+}
+
+// namespaced keeps identifiers issued by different providers apart, the same email or subject
+// can belong to different people on each of them
+func namespaced(namespace, identifier string) string {
+	if namespace == "" {
+		return identifier
+	}
+
+	return namespace + "/" + identifier
 }
 
 func NewUserPrincipalFromClaims(c ReadableClaims) (*UserPrincipal, error) {
@@ -71,6 +103,11 @@ func NewUserPrincipalFromClaims(c ReadableClaims) (*UserPrincipal, error) {
 	if err := c.Claims(a); err != nil {
 		return nil, err
 	}
+
+	if err := checkReservedIdentifier(a.Identifier()); err != nil {
+		return nil, err
+	}
+
 	return a, nil
 }
 
@@ -80,9 +117,23 @@ func NewServicePrincipalFromClaims(c ReadableClaims) (*ServicePrincipal, error)
 		return nil, err
 	}
 
+	if err := checkReservedIdentifier(a.Identifier()); err != nil {
+		return nil, err
+	}
+
 	return a, nil
 }
 
+// checkReservedIdentifier rejects identifiers in the APIKeySubjectPrefix namespace, only
+// APIKeyVerifier builds principals there
+func checkReservedIdentifier(identifier string) error {
+	if strings.HasPrefix(identifier, APIKeySubjectPrefix) {
+		return fmt.Errorf("%w: %s", ErrReservedSubject, identifier)
+	}
+
+	return nil
+}
+
 func PrincipalContext(ctx context.Context, principal PrincipalInterface) context.Context {
 	parent := ctx
 	if ctx == nil {
@@ -5,7 +5,12 @@ package authentication
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"go.opentelemetry.io/otel/trace"
@@ -16,11 +21,40 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 )
 
+// ErrUnknownIssuer is returned when a token is issued by a provider that isn't configured
+var ErrUnknownIssuer = errors.New("token issuer is not trusted")
+
+const (
+	// DefaultLeeway is the clock skew tolerated on the exp and nbf claims unless SetLeeway is called
+	DefaultLeeway = 30 * time.Second
+	// MaxLeeway bounds the clock skew SetLeeway accepts, a longer one would keep expired tokens usable
+	MaxLeeway = 5 * time.Minute
+)
+
+// IssuerConfig pairs a provider with the client ID its tokens are expected to be issued to
+type IssuerConfig struct {
+	Provider ProviderInterface
+	ClientID string
+}
+
 type JWKSTokenVerifier struct {
+	// verifier checks every token when a single provider is configured
 	verifier providerVerifierInterface
-	logger   logging.LoggerInterface
-	tracer   tracing.TracingInterface
-	monitor  monitoring.MonitorInterface
+	// verifiers maps each trusted issuer to its verifier when federating multiple providers
+	verifiers map[string]providerVerifierInterface
+	// namespaces maps each trusted issuer to the namespace of its principals, empty for the main issuer
+	namespaces map[string]string
+
+	claims *ClaimsMapping
+
+	// leeway is the clock skew tolerated on the exp and nbf claims, checked here rather than by
+	// the oidc verifiers which allow no skew on exp
+	leeway time.Duration
+	now    func() time.Time
+
+	logger  logging.LoggerInterface
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
 }
 
 func verifyJWT(ctx context.Context, rawJwt string, verifier providerVerifierInterface) (*oidc.IDToken, error) {
@@ -32,28 +66,177 @@ func verifyJWT(ctx context.Context, rawJwt string, verifier providerVerifierInte
 	return i, nil
 }
 
+// ParseTrustedIssuers parses <client id>@<issuer> entries into an issuer to client ID map
+func ParseTrustedIssuers(entries []string) (map[string]string, error) {
+	issuers := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		clientID, issuer, found := strings.Cut(entry, "@")
+
+		if !found || clientID == "" || issuer == "" {
+			return nil, fmt.Errorf("invalid trusted issuer %q, expected <client id>@<issuer>", entry)
+		}
+
+		issuers[issuer] = clientID
+	}
+
+	return issuers, nil
+}
+
+// unverifiedIssuer reads the iss claim without checking the signature, it's only used to pick
+// the verifier, which then validates the whole token issuer included
+func unverifiedIssuer(rawJwt string) (string, error) {
+	parts := strings.Split(rawJwt, ".")
+
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed jwt, expected 3 parts got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return "", fmt.Errorf("malformed jwt payload: %v", err)
+	}
+
+	c := struct {
+		Issuer string `json:"iss"`
+	}{}
+
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return "", fmt.Errorf("malformed jwt claims: %v", err)
+	}
+
+	return c.Issuer, nil
+}
+
+// issuerNamespace strips the scheme and trailing slash off an issuer, the result is used to
+// namespace its principals
+func issuerNamespace(issuer string) string {
+	if _, rest, found := strings.Cut(issuer, "://"); found {
+		issuer = rest
+	}
+
+	return strings.TrimSuffix(issuer, "/")
+}
+
+// selectVerifier returns the verifier for the token issuer along with the namespace of its principals
+func (j *JWKSTokenVerifier) selectVerifier(rawJwt string) (providerVerifierInterface, string, error) {
+	if j.verifiers == nil {
+		return j.verifier, "", nil
+	}
+
+	issuer, err := unverifiedIssuer(rawJwt)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	issuer = strings.TrimSuffix(issuer, "/")
+	verifier, ok := j.verifiers[issuer]
+
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnknownIssuer, issuer)
+	}
+
+	return verifier, j.namespaces[issuer], nil
+}
+
+func (j *JWKSTokenVerifier) verify(ctx context.Context, rawJwt string) (*oidc.IDToken, string, error) {
+	verifier, namespace, err := j.selectVerifier(rawJwt)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	t, err := verifyJWT(ctx, rawJwt, verifier)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := j.checkTimes(t); err != nil {
+		return nil, "", err
+	}
+
+	return t, namespace, nil
+}
+
+// checkTimes rejects tokens expired or not yet valid by more than the leeway, a token without
+// an exp claim is expired, an expired token fails with oidc.TokenExpiredError as it would
+// with the oidc verifier so that the session refresh keeps working
+func (j *JWKSTokenVerifier) checkTimes(t *oidc.IDToken) error {
+	now := j.now()
+
+	if t.Expiry.Add(j.leeway).Before(now) {
+		return &oidc.TokenExpiredError{Expiry: t.Expiry}
+	}
+
+	c := struct {
+		NotBefore *float64 `json:"nbf"`
+	}{}
+
+	if err := t.Claims(&c); err != nil {
+		return err
+	}
+
+	if c.NotBefore == nil {
+		return nil
+	}
+
+	if nbf := time.Unix(int64(*c.NotBefore), 0); now.Add(j.leeway).Before(nbf) {
+		return fmt.Errorf("oidc: current time %v before the nbf (not before) time: %v", now, nbf)
+	}
+
+	return nil
+}
+
 func (j *JWKSTokenVerifier) VerifyAccessToken(ctx context.Context, rawAccessToken string) (*ServicePrincipal, error) {
 	_, span := j.tracer.Start(ctx, "authentication.JWKSTokenVerifier.VerifyAccessToken")
 	defer span.End()
 
-	t, err := verifyJWT(ctx, rawAccessToken, j.verifier)
+	t, namespace, err := j.verify(ctx, rawAccessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewServicePrincipalFromClaims(t)
+	principal, err := NewServicePrincipalFromClaims(t)
+	if err != nil {
+		return nil, err
+	}
+
+	principal.Namespace = namespace
+
+	return principal, nil
 }
 
 func (j *JWKSTokenVerifier) VerifyIDToken(ctx context.Context, rawIDToken string) (*UserPrincipal, error) {
 	_, span := j.tracer.Start(ctx, "authentication.JWKSTokenVerifier.VerifyIDToken")
 	defer span.End()
 
-	t, err := verifyJWT(ctx, rawIDToken, j.verifier)
+	t, namespace, err := j.verify(ctx, rawIDToken)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewUserPrincipalFromClaims(t)
+	principal, err := j.claims.ToUserPrincipal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	principal.Namespace = namespace
+
+	return principal, nil
+}
+
+// SetClaimsMapping sets the claims read into the UserPrincipal, the email claim only is read by default
+func (j *JWKSTokenVerifier) SetClaimsMapping(m *ClaimsMapping) {
+	j.claims = m
+}
+
+// SetLeeway sets the clock skew tolerated on the exp and nbf claims, capped to MaxLeeway,
+// a negative leeway is treated as 0
+func (j *JWKSTokenVerifier) SetLeeway(leeway time.Duration) {
+	j.leeway = min(max(leeway, 0), MaxLeeway)
 }
 
 func NewJWKSTokenVerifier(provider ProviderInterface, clientID string, tracer trace.Tracer, logger logging.LoggerInterface, monitor monitoring.MonitorInterface) *JWKSTokenVerifier {
@@ -61,8 +244,40 @@ func NewJWKSTokenVerifier(provider ProviderInterface, clientID string, tracer tr
 	j.tracer = tracer
 	j.logger = logger
 	j.monitor = monitor
+	j.leeway = DefaultLeeway
+	j.now = time.Now
 
-	j.verifier = provider.Verifier(&oidc.Config{ClientID: clientID})
+	// expiry is checked by checkTimes with the leeway
+	j.verifier = provider.Verifier(&oidc.Config{ClientID: clientID, SkipExpiryCheck: true})
+
+	return j
+}
+
+// NewMultiIssuerJWKSTokenVerifier returns a verifier trusting tokens from each of the issuers, the
+// token iss claim selects the provider and client ID it's verified against, other issuers are rejected.
+// Principals from issuers other than primary are identified as <issuer without scheme>/<identifier>
+// so that the same email on two providers doesn't map to the same OpenFGA user
+func NewMultiIssuerJWKSTokenVerifier(primary string, issuers map[string]IssuerConfig, tracer trace.Tracer, logger logging.LoggerInterface, monitor monitoring.MonitorInterface) *JWKSTokenVerifier {
+	j := new(JWKSTokenVerifier)
+	j.tracer = tracer
+	j.logger = logger
+	j.monitor = monitor
+	j.leeway = DefaultLeeway
+	j.now = time.Now
+
+	j.verifiers = make(map[string]providerVerifierInterface, len(issuers))
+	j.namespaces = make(map[string]string, len(issuers))
+
+	primary = strings.TrimSuffix(primary, "/")
+
+	for issuer, config := range issuers {
+		issuer = strings.TrimSuffix(issuer, "/")
+		j.verifiers[issuer] = config.Provider.Verifier(&oidc.Config{ClientID: config.ClientID, SkipExpiryCheck: true})
+
+		if issuer != primary {
+			j.namespaces[issuer] = issuerNamespace(issuer)
+		}
+	}
 
 	return j
 }
@@ -72,6 +287,8 @@ type UserinfoTokenVerifier struct {
 	provider ProviderInterface
 	verifier providerVerifierInterface
 
+	claims *ClaimsMapping
+
 	logger  logging.LoggerInterface
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
@@ -124,7 +341,12 @@ func (u *UserinfoTokenVerifier) VerifyIDToken(ctx context.Context, rawIDToken st
 		return nil, err
 	}
 
-	return NewUserPrincipalFromClaims(t)
+	return u.claims.ToUserPrincipal(t)
+}
+
+// SetClaimsMapping sets the claims read into the UserPrincipal, the email claim only is read by default
+func (u *UserinfoTokenVerifier) SetClaimsMapping(m *ClaimsMapping) {
+	u.claims = m
 }
 
 func NewUserinfoTokenVerifier(provider ProviderInterface, clientID string, tracer trace.Tracer, logger logging.LoggerInterface, monitor monitoring.MonitorInterface) *UserinfoTokenVerifier {
@@ -63,6 +63,8 @@ func (s *Service) GetClient(ctx context.Context, clientID string) (*ServiceRespo
 		ret.ServiceError = se
 	}
 
+	hideClientSecret(c)
+
 	ret.Resp = c
 	return ret, nil
 }
@@ -132,6 +134,9 @@ func (s *Service) UpdateClient(ctx context.Context, client *hClient.OAuth2Client
 		}
 		ret.ServiceError = se
 	}
+
+	hideClientSecret(c)
+
 	ret.Resp = c
 	return ret, nil
 }
@@ -156,6 +161,11 @@ func (s *Service) ListClients(ctx context.Context, cs *ListClientsRequest) (*Ser
 		}
 		ret.ServiceError = se
 	}
+
+	for i := range c {
+		hideClientSecret(&c[i])
+	}
+
 	ret.Resp = c
 
 	if navTokens, err := types.ParseLinkTokens(resp.Header); err != nil {
@@ -177,6 +187,14 @@ func (s *Service) UnmarshalClient(data []byte) (*hClient.OAuth2Client, error) {
 	return c, nil
 }
 
+// hideClientSecret drops the client secret, hydra only hands it out on creation
+// and it must not be echoed back on any other call
+func hideClientSecret(c *hClient.OAuth2Client) {
+	if c != nil {
+		c.ClientSecret = nil
+	}
+}
+
 func (s *Service) parseServiceError(r *http.Response) (*ErrorOAuth2, error) {
 	// The hydra client does not return any errors, we need to parse the response body and create our
 	// own objects.
@@ -40,6 +40,7 @@ func TestGetClientSuccess(t *testing.T) {
 
 	c := hClient.NewOAuth2Client()
 	c.SetClientId(clientId)
+	c.SetClientSecret("secret")
 	clientReq := hClient.OAuth2ApiGetOAuth2ClientRequest{
 		ApiService: mockHydraOAuth2Api,
 	}
@@ -58,6 +59,9 @@ func TestGetClientSuccess(t *testing.T) {
 	if !reflect.DeepEqual(resp.Resp, c) {
 		t.Fatalf("expected data to be %+v, got: %+v", c, resp.Resp)
 	}
+	if resp.Resp.(*hClient.OAuth2Client).ClientSecret != nil {
+		t.Fatal("expected client secret not to be returned")
+	}
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
@@ -369,6 +373,7 @@ func TestListClientSuccess(t *testing.T) {
 	const clientId = "client_id"
 	c := hClient.NewOAuth2Client()
 	c.SetClientId(clientId)
+	c.SetClientSecret("secret")
 	cs := []OAuth2Client{*c}
 	clientReq := hClient.OAuth2ApiListOAuth2ClientsRequest{
 		ApiService: mockHydraOAuth2Api,
@@ -407,6 +412,10 @@ func TestListClientSuccess(t *testing.T) {
 		t.Fatalf("expected data to be %+v, got: %+v", cs, resp.Resp)
 	}
 
+	if resp.Resp.([]OAuth2Client)[0].ClientSecret != nil {
+		t.Fatal("expected client secret not to be returned")
+	}
+
 	if resp.Tokens.Next != "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ" || resp.Tokens.Prev != "eyJvZmZzZXQiOiItMjUwIiwidiI6Mn0" {
 		t.Fatalf("pagination links invalid, expected %v got %v", []string{"eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ", "eyJvZmZzZXQiOiItMjUwIiwidiI6Mn0"}, []string{resp.Tokens.Next, resp.Tokens.Prev})
 	}
@@ -0,0 +1,200 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	authz "github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+)
+
+const (
+	// MembershipDirect marks an identity assigned to the group itself
+	MembershipDirect = "direct"
+	// MembershipTransitive marks an identity member of the group through a nested group
+	MembershipTransitive = "transitive"
+
+	// exportBatchSize is the number of groups expanded concurrently by ExportMemberships, it bounds
+	// the memberships held in memory at any time to the ones of a single batch
+	exportBatchSize = 10
+)
+
+// Membership is a single row of the group memberships export
+type Membership struct {
+	Group    string `json:"group"`
+	Identity string `json:"identity"`
+	Type     string `json:"membership_type"`
+}
+
+type expandMembershipsResult struct {
+	group       string
+	memberships []Membership
+	err         error
+}
+
+// ExportMemberships walks all the groups userID can view and calls write for each of their memberships,
+// nested groups are expanded so identities reached through them are reported as transitive
+// groups are expanded concurrently on the worker pool, a batch at a time, while write is only ever
+// called from the calling goroutine, in group order
+func (s *Service) ExportMemberships(ctx context.Context, userID string, write func(Membership) error) error {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.ExportMemberships")
+	defer span.End()
+
+	groups, err := s.ofga.ListObjects(ctx, authz.UserForTuple(userID), authz.CAN_VIEW_RELATION, "group")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+
+	slices.Sort(groups)
+
+	for start := 0; start < len(groups); start += exportBatchSize {
+		batch := groups[start:min(start+exportBatchSize, len(groups))]
+
+		expanded, err := s.expandMembershipsBatch(ctx, batch)
+
+		if err != nil {
+			return err
+		}
+
+		for _, group := range batch {
+			for _, membership := range expanded[group] {
+				if err := write(membership); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) expandMembershipsBatch(ctx context.Context, batch []string) (map[string][]Membership, error) {
+	results := make(chan *pool.Result[any], len(batch))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(batch))
+
+	var submitErr error
+
+	for _, group := range batch {
+		// a rejected task never runs, release it here or Wait would block forever
+		if _, err := s.wpool.Submit(s.expandMembershipsFunc(ctx, group), results, &wg); err != nil {
+			wg.Done()
+
+			s.logger.Errorf("failed submitting expansion of group %s: %s", group, err)
+			submitErr = err
+		}
+	}
+
+	wg.Wait()
+	close(results)
+
+	if submitErr != nil {
+		return nil, submitErr
+	}
+
+	expanded := make(map[string][]Membership, len(batch))
+
+	for r := range results {
+		v := r.Value.(expandMembershipsResult)
+
+		if v.err != nil {
+			return nil, fmt.Errorf("failed expanding group %s: %w", v.group, v.err)
+		}
+
+		expanded[v.group] = v.memberships
+	}
+
+	return expanded, nil
+}
+
+func (s *Service) expandMembershipsFunc(ctx context.Context, group string) func() any {
+	return func() any {
+		direct, transitive, err := s.expandIdentities(ctx, group)
+
+		memberships := make([]Membership, 0, len(direct)+len(transitive))
+
+		for _, identity := range direct {
+			memberships = append(memberships, Membership{Group: group, Identity: identity, Type: MembershipDirect})
+		}
+
+		for _, identity := range transitive {
+			memberships = append(memberships, Membership{Group: group, Identity: identity, Type: MembershipTransitive})
+		}
+
+		return expandMembershipsResult{
+			group:       group,
+			memberships: memberships,
+			err:         err,
+		}
+	}
+}
+
+// MembershipWriter streams memberships in one of the export formats
+type MembershipWriter interface {
+	ContentType() string
+	Write(Membership) error
+	Flush() error
+}
+
+type csvMembershipWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvMembershipWriter) ContentType() string {
+	return "text/csv"
+}
+
+func (c *csvMembershipWriter) Write(m Membership) error {
+	return c.w.Write([]string{m.Group, m.Identity, m.Type})
+}
+
+func (c *csvMembershipWriter) Flush() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+type ndjsonMembershipWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonMembershipWriter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (n *ndjsonMembershipWriter) Write(m Membership) error {
+	// Encode terminates every value with a newline
+	return n.enc.Encode(m)
+}
+
+func (n *ndjsonMembershipWriter) Flush() error {
+	return nil
+}
+
+// NewMembershipWriter returns a writer producing NDJSON if accept asks for application/json, CSV
+// with a header row otherwise, rows are buffered in small chunks and handed over to w as they fill up
+func NewMembershipWriter(w io.Writer, accept string) (MembershipWriter, error) {
+	if strings.Contains(accept, "application/json") || strings.Contains(accept, "application/x-ndjson") {
+		return &ndjsonMembershipWriter{enc: json.NewEncoder(w)}, nil
+	}
+
+	c := csv.NewWriter(w)
+
+	if err := c.Write([]string{"group", "identity", "membership_type"}); err != nil {
+		return nil, err
+	}
+
+	return &csvMembershipWriter{w: c}, nil
+}
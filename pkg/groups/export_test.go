@@ -0,0 +1,248 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	authz "github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/events"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+)
+
+func TestServiceExportMemberships(t *testing.T) {
+	members := map[string][]string{
+		"group:administrator": {"user:joe", "group:it-admin#member"},
+		"group:it-admin":      {"user:test", "user:joe"},
+		"group:viewer":        {},
+	}
+
+	expected := []Membership{
+		{Group: "administrator", Identity: "user:joe", Type: MembershipDirect},
+		{Group: "administrator", Identity: "user:test", Type: MembershipTransitive},
+		{Group: "it-admin", Identity: "user:test", Type: MembershipDirect},
+		{Group: "it-admin", Identity: "user:joe", Type: MembershipDirect},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+	workerPool := NewMockWorkerPoolInterface(ctrl)
+
+	svc := NewService(mockOpenFGA, workerPool, audit.NewNoopAuditor(), events.NewNoopDispatcher(), mockTracer, mockMonitor, mockLogger)
+
+	setupMockSubmit(workerPool, nil)
+
+	mockTracer.EXPECT().Start(gomock.Any(), "groups.Service.ExportMemberships").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockOpenFGA.EXPECT().ListObjects(gomock.Any(), "user:administrator", "can_view", "group").Times(1).Return([]string{"viewer", "it-admin", "administrator"}, nil)
+	mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), "", authz.MEMBER_RELATION, gomock.Any(), "").AnyTimes().DoAndReturn(
+		func(ctx context.Context, user, relation, object, continuationToken string) (*client.ClientReadResponse, error) {
+			tuples := []openfga.Tuple{}
+			for _, m := range members[object] {
+				tuples = append(tuples, *openfga.NewTuple(*openfga.NewTupleKey(m, relation, object), time.Now()))
+			}
+
+			r := new(client.ClientReadResponse)
+			r.SetTuples(tuples)
+			r.SetContinuationToken("")
+
+			return r, nil
+		},
+	)
+
+	memberships := make([]Membership, 0)
+
+	err := svc.ExportMemberships(context.Background(), "administrator", func(m Membership) error {
+		memberships = append(memberships, m)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(memberships, expected) {
+		t.Errorf("expected memberships to be %v got %v", expected, memberships)
+	}
+}
+
+func TestServiceExportMembershipsBatches(t *testing.T) {
+	groups := make([]string, 0)
+
+	for i := 0; i < exportBatchSize*2+1; i++ {
+		groups = append(groups, fmt.Sprintf("group-%02d", i))
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+	workerPool := NewMockWorkerPoolInterface(ctrl)
+
+	svc := NewService(mockOpenFGA, workerPool, audit.NewNoopAuditor(), events.NewNoopDispatcher(), mockTracer, mockMonitor, mockLogger)
+
+	call, _ := setupMockSubmit(workerPool, nil)
+	call.Times(len(groups))
+
+	mockTracer.EXPECT().Start(gomock.Any(), "groups.Service.ExportMemberships").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockOpenFGA.EXPECT().ListObjects(gomock.Any(), "user:administrator", "can_view", "group").Times(1).Return(groups, nil)
+	mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), "", authz.MEMBER_RELATION, gomock.Any(), "").Times(len(groups)).DoAndReturn(
+		func(ctx context.Context, user, relation, object, continuationToken string) (*client.ClientReadResponse, error) {
+			r := new(client.ClientReadResponse)
+			r.SetTuples([]openfga.Tuple{*openfga.NewTuple(*openfga.NewTupleKey("user:joe", relation, object), time.Now())})
+			r.SetContinuationToken("")
+
+			return r, nil
+		},
+	)
+
+	written := make([]string, 0)
+
+	err := svc.ExportMemberships(context.Background(), "administrator", func(m Membership) error {
+		written = append(written, m.Group)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(written, groups) {
+		t.Errorf("expected a row per group in order got %v", written)
+	}
+}
+
+func TestServiceExportMembershipsFails(t *testing.T) {
+	tests := []struct {
+		name      string
+		listErr   error
+		readErr   error
+		writeErr  error
+		submitErr error
+	}{
+		{name: "list groups", listErr: fmt.Errorf("error")},
+		{name: "expand group", readErr: fmt.Errorf("error")},
+		{name: "write row", writeErr: fmt.Errorf("broken pipe")},
+		{name: "pool full", submitErr: fmt.Errorf("WorkerPool queue is full")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+			workerPool := NewMockWorkerPoolInterface(ctrl)
+
+			svc := NewService(mockOpenFGA, workerPool, audit.NewNoopAuditor(), events.NewNoopDispatcher(), mockTracer, mockMonitor, mockLogger)
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), "groups.Service.ExportMemberships").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGA.EXPECT().ListObjects(gomock.Any(), "user:administrator", "can_view", "group").Times(1).Return([]string{"administrator"}, test.listErr)
+
+			if test.submitErr != nil {
+				workerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", test.submitErr)
+			} else {
+				setupMockSubmit(workerPool, nil)
+			}
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), "", authz.MEMBER_RELATION, "group:administrator", "").AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, continuationToken string) (*client.ClientReadResponse, error) {
+					if test.readErr != nil {
+						return nil, test.readErr
+					}
+
+					r := new(client.ClientReadResponse)
+					r.SetTuples([]openfga.Tuple{*openfga.NewTuple(*openfga.NewTupleKey("user:joe", relation, object), time.Now())})
+					r.SetContinuationToken("")
+
+					return r, nil
+				},
+			)
+
+			err := svc.ExportMemberships(context.Background(), "administrator", func(m Membership) error {
+				return test.writeErr
+			})
+
+			for _, expected := range []error{test.listErr, test.readErr, test.writeErr, test.submitErr} {
+				if expected != nil && !errors.Is(err, expected) {
+					t.Errorf("expected error to be %v got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMembershipWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		expected    string
+	}{
+		{
+			name:        "csv by default",
+			accept:      "",
+			contentType: "text/csv",
+			expected:    "group,identity,membership_type\nadministrator,user:joe,direct\n\"it,admin\",user:test,transitive\n",
+		},
+		{
+			name:        "ndjson",
+			accept:      "application/json",
+			contentType: "application/x-ndjson",
+			expected: `{"group":"administrator","identity":"user:joe","membership_type":"direct"}` + "\n" +
+				`{"group":"it,admin","identity":"user:test","membership_type":"transitive"}` + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			w, err := NewMembershipWriter(buf, test.accept)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if w.ContentType() != test.contentType {
+				t.Errorf("expected content type to be %s got %s", test.contentType, w.ContentType())
+			}
+
+			w.Write(Membership{Group: "administrator", Identity: "user:joe", Type: MembershipDirect})
+			w.Write(Membership{Group: "it,admin", Identity: "user:test", Type: MembershipTransitive})
+
+			if err := w.Flush(); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if buf.String() != test.expected {
+				t.Errorf("expected output to be %q got %q", test.expected, buf.String())
+			}
+		})
+	}
+}
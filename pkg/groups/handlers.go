@@ -5,14 +5,18 @@ package groups
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 	"github.com/canonical/identity-platform-admin-ui/internal/validation"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
@@ -36,8 +40,29 @@ type Permission struct {
 }
 
 type UpdatePermissionsRequest struct {
-	// validate slice is not nil, and each item is not nil
-	Permissions []Permission `json:"permissions" validate:"required,dive,required"`
+	// validate one of the slices is not nil, and each item is not nil
+	Permissions []Permission `json:"permissions" validate:"required_without=Entitlements,dive,required"`
+	// Entitlements lists permissions in their URN form, e.g. can_edit::client:okta
+	Entitlements []string `json:"entitlements,omitempty" validate:"required_without=Permissions,dive,required"`
+}
+
+// all returns the structured permissions followed by the ones parsed from the entitlement URNs,
+// a URN missing its relation or its object returns an ErrInvalidPermission
+func (r *UpdatePermissionsRequest) all() ([]Permission, error) {
+	permissions := make([]Permission, 0, len(r.Permissions)+len(r.Entitlements))
+	permissions = append(permissions, r.Permissions...)
+
+	for _, entitlement := range r.Entitlements {
+		urn := authorization.NewURNFromURLParam(entitlement)
+
+		if urn == nil || urn.Relation() == "" || urn.Object() == "" {
+			return nil, fmt.Errorf("%w: malformed entitlement %q, expected <relation>%s<type>:<id>", ErrInvalidPermission, entitlement, authorization.PERMISSION_SEPARATOR)
+		}
+
+		permissions = append(permissions, Permission{Relation: urn.Relation(), Object: urn.Object()})
+	}
+
+	return permissions, nil
 }
 
 type Group struct {
@@ -49,25 +74,51 @@ type UpdateIdentitiesRequest struct {
 	Identities []string `json:"identities" validate:"required,dive,required"`
 }
 
+// MoveIdentityRequest is the payload of a move of an identity between groups
+type MoveIdentityRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DeletePreview lists the tuples a delete would remove, returned when dry_run is set
+type DeletePreview struct {
+	Tuples []ofga.Tuple `json:"tuples"`
+	Count  int          `json:"count"`
+}
+
 // API is the core HTTP object that implements all the HTTP and business logic for the groups
 // HTTP API functionality
 type API struct {
 	apiKey           string
 	service          ServiceInterface
 	payloadValidator validation.PayloadValidatorInterface
+	pageSize         *types.PageSizeConfig
+	cursors          *types.CursorConfig
 
 	logger  logging.LoggerInterface
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
 }
 
+// SetPageSizeConfig sets the default and maximum page size of the list endpoint
+func (a *API) SetPageSizeConfig(c *types.PageSizeConfig) {
+	a.pageSize = c
+}
+
+// SetCursorConfig makes the paginated endpoints swap long pagination tokens for a cursor
+func (a *API) SetCursorConfig(c *types.CursorConfig) {
+	a.cursors = c
+}
+
 // RegisterEndpoints hooks up all the endpoints to the server mux passed via the arg
 func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/groups", a.handleList)
+	mux.Get("/api/v0/groups/export", a.handleExport)
 	mux.Get("/api/v0/groups/{id:.+}", a.handleDetail)
 	mux.Post("/api/v0/groups", a.handleCreate)
 	mux.Patch("/api/v0/groups/{id:.+}", a.handleUpdate)
 	mux.Delete("/api/v0/groups/{id:.+}", a.handleRemove)
+	mux.Post("/api/v0/groups/{id:.+}/restore", a.handleRestore)
 	mux.Get("/api/v0/groups/{id:.+}/roles", a.handleListRoles)
 	mux.Post("/api/v0/groups/{id:.+}/roles", a.handleAssignRoles)
 	mux.Delete("/api/v0/groups/{id:.+}/roles/{r_id:.+}", a.handleRemoveRole)
@@ -77,6 +128,7 @@ func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/groups/{id:.+}/identities", a.handleListIdentities)
 	mux.Patch("/api/v0/groups/{id:.+}/identities", a.handleAssignIdentities)
 	mux.Delete("/api/v0/groups/{id:.+}/identities/{i_id:.+}", a.handleRemoveIdentities)
+	mux.Post("/api/v0/identities/{id:.+}/move-group", a.handleMoveIdentity)
 }
 
 func (a *API) RegisterValidation(v validation.ValidationRegistryInterface) {
@@ -91,14 +143,40 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 
 	principal := authentication.PrincipalFromContext(r.Context())
 
-	groups, err := a.service.ListGroups(
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	size, err := a.pageSize.ParseSize(r.URL.Query())
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	groups, pageToken, err := a.service.ListGroups(
 		r.Context(),
 		principal.Identifier(),
+		r.URL.Query().Get("prefix"),
+		size,
+		paginator.GetToken(r.Context(), GROUP_TOKEN_KEY),
 	)
 
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -108,6 +186,16 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paginator.SetToken(r.Context(), GROUP_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
 	w.WriteHeader(http.StatusOK)
 
 	json.NewEncoder(w).Encode(
@@ -119,6 +207,58 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// handleExport streams the memberships of all the groups visible to the caller, as CSV by default
+// or as NDJSON when the client accepts application/json
+func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
+	principal := authentication.PrincipalFromContext(r.Context())
+
+	writer, err := NewMembershipWriter(w, r.Header.Get("Accept"))
+
+	if err != nil {
+		a.logger.Errorf("error preparing memberships export: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", writer.ContentType())
+
+	started := false
+
+	err = a.service.ExportMemberships(
+		r.Context(),
+		principal.Identifier(),
+		func(m Membership) error {
+			started = true
+
+			return writer.Write(m)
+		},
+	)
+
+	// once rows have been sent the status can't be changed anymore, the export is left truncated
+	if err != nil && !started {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	if err != nil {
+		a.logger.Errorf("memberships export interrupted: %s", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		a.logger.Errorf("error flushing memberships export: %s", err)
+	}
+}
+
 func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -130,6 +270,7 @@ func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -145,6 +286,7 @@ func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Group not found",
 				Status:  http.StatusNotFound,
+				Code:    types.CodeGroupNotFound,
 			},
 		)
 		return
@@ -172,6 +314,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -185,6 +328,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -198,6 +342,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Group ID field is not allowed to be passed in",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
 			},
 		)
 
@@ -211,6 +356,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -230,19 +376,77 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-// handleUpdate is not implemented by choice, product might decide to do it to enhance
-// group metadata, we do not support anything on top of simple ID attribute and this is
-// not changeable right now due to coupled implementation with OpenFGA
+// handleUpdate renames a group, the only attribute available is the name which is also the ID
+// so all the tuples referencing the group are moved over to the new name
 func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
 	ID := chi.URLParam(r, "id")
 
-	w.Header().Set("Content-Type", "application/json")
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
 
-	w.WriteHeader(http.StatusNotImplemented)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing request payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	group := new(Group)
+	if err := json.Unmarshal(body, group); err != nil || group.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	group, err = a.service.RenameGroup(r.Context(), ID, group.Name)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		if errors.Is(err, ErrGroupExists) {
+			status = http.StatusConflict
+			code = types.CodeGroupNameConflict
+		}
+
+		if errors.Is(err, ErrGroupNotFound) {
+			status = http.StatusNotFound
+			code = types.CodeGroupNotFound
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Message: fmt.Sprintf("use POST /api/v0/groups/%s/entitlements to assign permissions", ID),
-			Status:  http.StatusNotImplemented,
+			Data:    []Group{*group},
+			Message: fmt.Sprintf("Renamed group %s to %s", ID, group.Name),
+			Status:  http.StatusOK,
 		},
 	)
 }
@@ -253,12 +457,98 @@ func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
 
 	ID := chi.URLParam(r, "id")
 
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+		a.handlePreviewRemove(w, r, ID)
+		return
+	}
+
 	err := a.service.DeleteGroup(r.Context(), ID)
 
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+		message := err.Error()
+
+		if errors.Is(err, ErrTrashFull) {
+			status = http.StatusInsufficientStorage
+			code = types.CodeGroupTrashFull
+			message = fmt.Sprintf("%s, purge the trash through POST /api/v0/admin/groups/purge before deleting the group", err)
+		}
+
+		rr := types.Response{
+			Status:  status,
+			Code:    code,
+			Message: message,
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: fmt.Sprintf("Deleted group %s", ID),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleRestore(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ID := chi.URLParam(r, "id")
+
+	group, err := a.service.RestoreGroup(r.Context(), ID)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		switch {
+		case errors.Is(err, ErrGroupExists):
+			status = http.StatusConflict
+			code = types.CodeGroupNameConflict
+		case errors.Is(err, ErrGroupNotFound):
+			status = http.StatusNotFound
+			code = types.CodeGroupNotFound
+		case errors.Is(err, ErrSoftDeleteDisabled):
+			status = http.StatusNotImplemented
+			code = types.CodeNotImplemented
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    []Group{*group},
+			Message: fmt.Sprintf("Restored group %s", ID),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handlePreviewRemove(w http.ResponseWriter, r *http.Request, ID string) {
+	tuples, err := a.service.PreviewDeleteGroup(r.Context(), ID)
+
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -271,7 +561,8 @@ func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Message: fmt.Sprintf("Deleted group %s", ID),
+			Data:    DeletePreview{Tuples: tuples, Count: len(tuples)},
+			Message: fmt.Sprintf("Dry run, deleting group %s would remove %d tuples", ID, len(tuples)),
 			Status:  http.StatusOK,
 		},
 	)
@@ -283,20 +574,64 @@ func (a *API) handleListPermission(w http.ResponseWriter, r *http.Request) {
 	ID := chi.URLParam(r, "id")
 
 	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
 
 	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
 		a.logger.Error(err)
 	}
 
+	// all=true drains every per type page in a single call
+	autoPaginate, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	// types=client,group only reads the listed object types
+	pTypes := make([]string, 0)
+
+	if filter := r.URL.Query().Get("types"); filter != "" {
+		pTypes = strings.Split(filter, ",")
+	}
+
+	// relation=can_edit only reads the permissions granted through that relation
+	relation := r.URL.Query().Get("relation")
+
 	permissions, pageTokens, err := a.service.ListPermissions(
 		r.Context(),
 		ID,
 		paginator.GetAllTokens(r.Context()),
+		autoPaginate,
+		pTypes,
+		relation,
 	)
 
+	if errors.Is(err, ErrInvalidPermissionRelation) {
+		rr := types.Response{
+			Status:  http.StatusBadRequest,
+			Code:    types.CodePermissionInvalidRelation,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	if errors.Is(err, ErrInvalidPermissionType) {
+		rr := types.Response{
+			Status:  http.StatusBadRequest,
+			Code:    types.CodePermissionInvalidType,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -332,14 +667,44 @@ func (a *API) handleListRoles(w http.ResponseWriter, r *http.Request) {
 
 	ID := chi.URLParam(r, "id")
 
-	roles, err := a.service.ListRoles(
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	// without a size the roles are not paginated, unless a page token is sent
+	var size int64
+
+	if r.URL.Query().Has("size") {
+		var err error
+
+		if size, err = a.pageSize.ParseSize(r.URL.Query()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(
+				types.Response{
+					Message: err.Error(),
+					Status:  http.StatusBadRequest,
+					Code:    types.CodeInvalidParameter,
+				},
+			)
+
+			return
+		}
+	}
+
+	roles, pageToken, err := a.service.ListRoles(
 		r.Context(),
 		ID,
+		size,
+		paginator.GetToken(r.Context(), ROLE_TOKEN_KEY),
 	)
 
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -349,6 +714,16 @@ func (a *API) handleListRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paginator.SetToken(r.Context(), ROLE_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
 	w.WriteHeader(http.StatusOK)
 
 	json.NewEncoder(w).Encode(
@@ -374,6 +749,7 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -388,6 +764,7 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -395,12 +772,30 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	err = a.service.AssignPermissions(r.Context(), ID, permissions.Permissions...)
+	assignments, err := permissions.all()
+
+	if err == nil {
+		err = a.service.AssignPermissions(r.Context(), ID, assignments...)
+	}
+
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeGroupInvalidPermission,
+			},
+		)
+
+		return
+	}
 
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -431,6 +826,7 @@ func (a *API) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing entitlement ID",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -443,10 +839,24 @@ func (a *API) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
 		Permission{Relation: permissionURN.Relation(), Object: permissionURN.Object()},
 	)
 
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeGroupInvalidPermission,
+			},
+		)
+
+		return
+	}
+
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -480,6 +890,7 @@ func (a *API) handleAssignRoles(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -493,6 +904,7 @@ func (a *API) handleAssignRoles(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -500,10 +912,11 @@ func (a *API) handleAssignRoles(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	canAssign, err := a.service.CanAssignRoles(r.Context(), principal.Identifier(), roles.Roles...)
+	canAssign, denied, err := a.service.CanAssignRoles(r.Context(), principal.Identifier(), roles.Roles...)
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -515,7 +928,8 @@ func (a *API) handleAssignRoles(w http.ResponseWriter, r *http.Request) {
 	if !canAssign {
 		rr := types.Response{
 			Status:  http.StatusForbidden,
-			Message: fmt.Sprintf("user %s is not allowed to assign specified roles", principal.Identifier()),
+			Code:    types.CodeForbidden,
+			Message: fmt.Sprintf("user %s is not allowed to assign roles %s", principal.Identifier(), strings.Join(denied, ", ")),
 		}
 
 		w.WriteHeader(http.StatusForbidden)
@@ -529,6 +943,7 @@ func (a *API) handleAssignRoles(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -559,6 +974,7 @@ func (a *API) handleRemoveRole(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -582,7 +998,14 @@ func (a *API) handleListIdentities(w http.ResponseWriter, r *http.Request) {
 
 	ID := chi.URLParam(r, "id")
 
+	if expand, _ := strconv.ParseBool(r.URL.Query().Get("expand")); expand {
+		a.handleListIdentitiesTransitive(w, r, ID)
+
+		return
+	}
+
 	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
 
 	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
 		a.logger.Error(err)
@@ -597,6 +1020,7 @@ func (a *API) handleListIdentities(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -627,6 +1051,33 @@ func (a *API) handleListIdentities(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (a *API) handleListIdentitiesTransitive(w http.ResponseWriter, r *http.Request, ID string) {
+	identities, err := a.service.ListIdentitiesTransitive(r.Context(), ID)
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    identities,
+			Message: "List of identities",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
 func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -642,6 +1093,7 @@ func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -655,6 +1107,7 @@ func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -662,10 +1115,11 @@ func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	canAssign, err := a.service.CanAssignIdentities(r.Context(), principal.Identifier(), identities.Identities...)
+	canAssign, denied, err := a.service.CanAssignIdentities(r.Context(), principal.Identifier(), identities.Identities...)
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -677,7 +1131,8 @@ func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 	if !canAssign {
 		rr := types.Response{
 			Status:  http.StatusForbidden,
-			Message: fmt.Sprintf("user %s is not allowed to assign specified identities", principal.Identifier()),
+			Code:    types.CodeForbidden,
+			Message: fmt.Sprintf("user %s is not allowed to assign identities %s", principal.Identifier(), strings.Join(denied, ", ")),
 		}
 
 		w.WriteHeader(http.StatusForbidden)
@@ -691,6 +1146,7 @@ func (a *API) handleAssignIdentities(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -725,6 +1181,7 @@ func (a *API) handleRemoveIdentities(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -743,6 +1200,92 @@ func (a *API) handleRemoveIdentities(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (a *API) handleMoveIdentity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	identityID := chi.URLParam(r, "id")
+	principal := authentication.PrincipalFromContext(r.Context())
+
+	defer r.Body.Close()
+
+	move := new(MoveIdentityRequest)
+
+	if err := json.NewDecoder(r.Body).Decode(move); err != nil || move.From == "" || move.To == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload, from and to groups are required",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	canMove, err := a.service.CanMoveIdentity(r.Context(), principal.Identifier(), move.From, move.To)
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(rr)
+		return
+	}
+
+	if !canMove {
+		rr := types.Response{
+			Status:  http.StatusForbidden,
+			Code:    types.CodeForbidden,
+			Message: fmt.Sprintf("user %s is not allowed to move identities from group %s to group %s", principal.Identifier(), move.From, move.To),
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(rr)
+		return
+	}
+
+	if err := a.service.MoveIdentity(r.Context(), identityID, move.From, move.To); err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		switch {
+		case errors.Is(err, ErrSameGroup):
+			status = http.StatusBadRequest
+			code = types.CodeInvalidPayload
+		case errors.Is(err, ErrGroupNotFound):
+			status = http.StatusNotFound
+			code = types.CodeGroupNotFound
+		case errors.Is(err, ErrNotMember):
+			status = http.StatusConflict
+			code = types.CodeGroupNotMember
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: fmt.Sprintf("Moved identity %s from group %s to group %s", identityID, move.From, move.To),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
 // NewAPI returns an API object responsible for all the roles HTTP handlers
 func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
 	a := new(API)
@@ -23,6 +23,7 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 )
 
@@ -112,7 +113,8 @@ func TestHandleList(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/api/v0/groups", nil)
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
-			mockService.EXPECT().ListGroups(gomock.Any(), gomock.Any()).Return(test.expected.groups, test.expected.err)
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListGroups(gomock.Any(), gomock.Any(), "", types.DefaultPageSize, "").Return(test.expected.groups, "", test.expected.err)
 
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
@@ -177,6 +179,184 @@ func TestHandleList(t *testing.T) {
 //     "status": 200
 // }
 
+func TestHandleListPaginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	paginator := types.NewTokenPaginator(mockTracer, mockLogger)
+	paginator.SetToken(context.TODO(), GROUP_TOKEN_KEY, "administrator")
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	pageHeader, _ := paginator.PaginationHeader(context.TODO())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/groups?size=2", nil)
+	req.Header.Set(types.PAGINATION_HEADER, pageHeader)
+	req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+	mockService.EXPECT().ListGroups(gomock.Any(), "test-user", "", int64(2), "administrator").Return([]string{"devops", "global"}, "global", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	next := types.NewTokenPaginator(mockTracer, mockLogger)
+
+	if err := next.LoadFromString(context.TODO(), res.Header.Get(types.PAGINATION_HEADER)); err != nil {
+		t.Fatalf("expected continuation token in headers, got %v", err)
+	}
+
+	if token := next.GetToken(context.TODO(), GROUP_TOKEN_KEY); token != "global" {
+		t.Errorf("expected continuation token to be global got %s", token)
+	}
+}
+
+func TestHandleListPrefix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/groups?prefix=Dev", nil)
+	req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+	mockService.EXPECT().ListGroups(gomock.Any(), "test-user", "Dev", types.DefaultPageSize, "").Return([]string{"devops"}, "", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	rr := new(types.Response)
+
+	if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(rr.Data, []any{"devops"}) {
+		t.Errorf("expected data to be [devops] got %v", rr.Data)
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		err         error
+		rows        bool
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "csv",
+			rows:        true,
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "group,identity,membership_type\nadministrator,user:joe,direct\n",
+		},
+		{
+			name:        "ndjson",
+			accept:      "application/json",
+			rows:        true,
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson",
+			body:        `{"group":"administrator","identity":"user:joe","membership_type":"direct"}` + "\n",
+		},
+		{
+			name:        "error before any row",
+			err:         fmt.Errorf("error"),
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+		},
+		{
+			name:        "error after the first row",
+			err:         fmt.Errorf("error"),
+			rows:        true,
+			status:      http.StatusOK,
+			contentType: "text/csv",
+			body:        "group,identity,membership_type\nadministrator,user:joe,direct\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockService.EXPECT().ExportMemberships(gomock.Any(), "test-user", gomock.Any()).Times(1).DoAndReturn(
+				func(ctx context.Context, userID string, write func(Membership) error) error {
+					if test.rows {
+						write(Membership{Group: "administrator", Identity: "user:joe", Type: MembershipDirect})
+					}
+
+					return test.err
+				},
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/groups/export", nil)
+			req.Header.Set("Accept", test.accept)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if ct := res.Header.Get("Content-Type"); ct != test.contentType {
+				t.Errorf("expected content type to be %s got %s", test.contentType, ct)
+			}
+
+			data, _ := io.ReadAll(res.Body)
+
+			if test.body != "" && string(data) != test.body {
+				t.Errorf("expected body to be %q got %q", test.body, string(data))
+			}
+		})
+	}
+}
+
 func TestHandleDetail(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -287,25 +467,61 @@ func TestHandleUpdate(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		body     string
 		expected error
 		output   *types.Response
 	}{
+		{
+			name:     "renamed",
+			input:    "administrator",
+			body:     `{"name":"admins"}`,
+			expected: nil,
+			output: &types.Response{
+				Message: "Renamed group administrator to admins",
+				Status:  http.StatusOK,
+			},
+		},
+		{
+			name:     "name already in use",
+			input:    "administrator",
+			body:     `{"name":"admins"}`,
+			expected: ErrGroupExists,
+			output: &types.Response{
+				Message: ErrGroupExists.Error(),
+				Status:  http.StatusConflict,
+				Code:    types.CodeGroupNameConflict,
+			},
+		},
 		{
 			name:     "unknown group",
 			input:    "unknown",
-			expected: fmt.Errorf("group does not exist"),
+			body:     `{"name":"admins"}`,
+			expected: ErrGroupNotFound,
 			output: &types.Response{
-				Message: "use POST /api/v0/groups/unknown/entitlements to assign permissions",
-				Status:  http.StatusNotImplemented,
+				Message: ErrGroupNotFound.Error(),
+				Status:  http.StatusNotFound,
+				Code:    types.CodeGroupNotFound,
 			},
 		},
 		{
-			name:     "found",
+			name:     "failure",
 			input:    "administrator",
-			expected: nil,
+			body:     `{"name":"admins"}`,
+			expected: fmt.Errorf("error"),
+			output: &types.Response{
+				Message: "error",
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		},
+		{
+			name:  "missing name",
+			input: "administrator",
+			body:  `{}`,
 			output: &types.Response{
-				Message: "use POST /api/v0/groups/administrator/entitlements to assign permissions",
-				Status:  http.StatusNotImplemented,
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		},
 	}
@@ -320,9 +536,18 @@ func TestHandleUpdate(t *testing.T) {
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 			mockService := NewMockServiceInterface(ctrl)
 
-			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/groups/%s", test.input), nil)
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/groups/%s", test.input), strings.NewReader(test.body))
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
+			if test.output.Status != http.StatusBadRequest {
+				var group *Group = nil
+				if test.expected == nil {
+					group = &Group{ID: "admins", Name: "admins"}
+				}
+
+				mockService.EXPECT().RenameGroup(gomock.Any(), test.input, "admins").Return(group, test.expected)
+			}
+
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
 			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
@@ -345,6 +570,7 @@ func TestHandleUpdate(t *testing.T) {
 			type Response struct {
 				Message string `json:"message"`
 				Status  int    `json:"status"`
+				Code    string `json:"code"`
 			}
 
 			rr := new(Response)
@@ -361,6 +587,10 @@ func TestHandleUpdate(t *testing.T) {
 				t.Errorf("invalid result, expected: %v, got: %v", test.output.Status, rr.Status)
 			}
 
+			if rr.Code != test.output.Code {
+				t.Errorf("invalid code, expected: %v, got: %v", test.output.Code, rr.Code)
+			}
+
 		})
 	}
 }
@@ -441,7 +671,7 @@ func TestHandleListPermissionsSuccess(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.LoadFromRequest").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.PaginationHeader").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 
-			mockService.EXPECT().ListPermissions(gomock.Any(), groupID, map[string]string{}).Return(test.expected.permissions, test.expected.cTokens, nil)
+			mockService.EXPECT().ListPermissions(gomock.Any(), groupID, map[string]string{}, false, []string{}, "").Return(test.expected.permissions, test.expected.cTokens, nil)
 
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
@@ -523,6 +753,75 @@ func TestHandleListPermissionsSuccess(t *testing.T) {
 //     "status": 200
 // }
 
+func TestHandleListPermissionsFilterTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		types    []string
+		relation string
+		err      error
+		status   int
+	}{
+		{
+			name:   "filtered",
+			query:  "types=client,provider",
+			types:  []string{"client", "provider"},
+			status: http.StatusOK,
+		},
+		{
+			name:   "unknown type",
+			query:  "types=unknown",
+			types:  []string{"unknown"},
+			err:    fmt.Errorf("%w unknown", ErrInvalidPermissionType),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "filtered relation",
+			query:    "relation=can_edit",
+			types:    []string{},
+			relation: "can_edit",
+			status:   http.StatusOK,
+		},
+		{
+			name:     "unknown relation",
+			query:    "relation=owner",
+			types:    []string{},
+			relation: "owner",
+			err:      fmt.Errorf("%w owner", ErrInvalidPermissionRelation),
+			status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			groupID := "administrator"
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/groups/%s/entitlements?%s", groupID, test.query), nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListPermissions(gomock.Any(), groupID, map[string]string{}, false, test.types, test.relation).Return([]string{}, map[string]string{}, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Result().StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
 func TestHandleListRolesSuccess(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -569,7 +868,9 @@ func TestHandleListRolesSuccess(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/groups/%s/roles", groupID), nil)
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
-			mockService.EXPECT().ListRoles(gomock.Any(), groupID).Return(test.expected, nil)
+			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.LoadFromRequest").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.PaginationHeader").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListRoles(gomock.Any(), groupID, int64(0), "").Return(test.expected, "", nil)
 
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
@@ -632,6 +933,74 @@ func TestHandleListRolesSuccess(t *testing.T) {
 //	    "status": 200
 //	}
 
+func TestHandleListRolesPaginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	tokens, _ := json.Marshal(map[string]string{ROLE_TOKEN_KEY: "page-2"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/groups/administrator/roles?size=2", nil)
+	req.Header.Set(types.PAGINATION_HEADER, base64.StdEncoding.EncodeToString(tokens))
+	req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+	mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.LoadFromRequest").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.PaginationHeader").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockService.EXPECT().ListRoles(gomock.Any(), "administrator", int64(2), "page-2").Return([]string{"viewer", "devops"}, "page-3", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	header, err := base64.StdEncoding.DecodeString(res.Header.Get(types.PAGINATION_HEADER))
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	next := map[string]string{}
+
+	if err := json.Unmarshal(header, &next); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if next[ROLE_TOKEN_KEY] != "page-3" {
+		t.Errorf("expected next token to be page-3 got %q", next[ROLE_TOKEN_KEY])
+	}
+}
+
+func TestHandleListRolesBadSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTracer := NewMockTracer(ctrl)
+	mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.LoadFromRequest").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/groups/administrator/roles?size=0", nil)
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(NewMockServiceInterface(ctrl), mockTracer, monitoring.NewMockMonitorInterface(ctrl), NewMockLoggerInterface(ctrl)).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected HTTP status code 400 got %v", w.Code)
+	}
+}
+
 func TestHandleRemovePermissionBadPermissionFormat(t *testing.T) {
 	type input struct {
 		groupID      string
@@ -901,6 +1270,23 @@ func TestHandleAssignPermissions(t *testing.T) {
 				Status:  http.StatusInternalServerError,
 			},
 		},
+		{
+			name:     "malformed object",
+			expected: fmt.Errorf("%w: %w", ErrInvalidPermission, ofga.ErrInvalidObject),
+			input: input{
+				groupID: "administrator",
+				permissions: []Permission{
+					{
+						Relation: "can_view",
+						Object:   "clientokta",
+					},
+				},
+			},
+			output: &types.Response{
+				Message: "invalid permission: invalid object",
+				Status:  http.StatusBadRequest,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -970,20 +1356,50 @@ func TestHandleAssignPermissions(t *testing.T) {
 	}
 }
 
-func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
-
+func TestHandleAssignPermissionsURN(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    string
-		expected error
+		payload  string
+		assigned []Permission
 		output   *types.Response
 	}{
 		{
-			name:     "no permissions",
-			expected: nil,
-			input:    "administrator",
+			name:    "entitlement URNs",
+			payload: `{"entitlements":["can_edit::client:okta","can_view::client:github-canonical"]}`,
+			assigned: []Permission{
+				{Relation: "can_edit", Object: "client:okta"},
+				{Relation: "can_view", Object: "client:github-canonical"},
+			},
 			output: &types.Response{
-				Message: "Error parsing JSON payload",
+				Message: "Updated permissions for group administrator",
+				Status:  http.StatusCreated,
+			},
+		},
+		{
+			name:    "structured and URN forms",
+			payload: `{"permissions":[{"relation":"can_delete","object":"client:okta"}],"entitlements":["can_edit::client:okta"]}`,
+			assigned: []Permission{
+				{Relation: "can_delete", Object: "client:okta"},
+				{Relation: "can_edit", Object: "client:okta"},
+			},
+			output: &types.Response{
+				Message: "Updated permissions for group administrator",
+				Status:  http.StatusCreated,
+			},
+		},
+		{
+			name:    "missing separator",
+			payload: `{"entitlements":["can_edit:client:okta"]}`,
+			output: &types.Response{
+				Message: `invalid permission: malformed entitlement "can_edit:client:okta", expected <relation>::<type>:<id>`,
+				Status:  http.StatusBadRequest,
+			},
+		},
+		{
+			name:    "missing relation",
+			payload: `{"entitlements":["::client:okta"]}`,
+			output: &types.Response{
+				Message: `invalid permission: malformed entitlement "::client:okta", expected <relation>::<type>:<id>`,
 				Status:  http.StatusBadRequest,
 			},
 		},
@@ -999,9 +1415,13 @@ func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
 			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
 			mockService := NewMockServiceInterface(ctrl)
 
-			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/groups/%s/entitlements", test.input), nil)
+			req := httptest.NewRequest(http.MethodPatch, "/api/v0/groups/administrator/entitlements", strings.NewReader(test.payload))
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
+			if test.assigned != nil {
+				mockService.EXPECT().AssignPermissions(gomock.Any(), "administrator", test.assigned).Return(nil)
+			}
+
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
 			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
@@ -1010,9 +1430,10 @@ func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
 
 			res := w.Result()
 			defer res.Body.Close()
-			data, err := io.ReadAll(res.Body)
 
-			if err != nil {
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
 				t.Errorf("expected error to be nil got %v", err)
 			}
 
@@ -1020,12 +1441,69 @@ func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
 				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
 			}
 
-			// duplicate types.Response attribute we care and assign the proper type instead of interface{}
-			type Response struct {
-				Data    []string          `json:"data"`
-				Message string            `json:"message"`
-				Status  int               `json:"status"`
-				Meta    *types.Pagination `json:"_meta"`
+			if rr.Message != test.output.Message {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Message, rr.Message)
+			}
+		})
+	}
+}
+
+func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    string
+		expected error
+		output   *types.Response
+	}{
+		{
+			name:     "no permissions",
+			expected: nil,
+			input:    "administrator",
+			output: &types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/groups/%s/entitlements", test.input), nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.output.Status {
+				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
+			}
+
+			// duplicate types.Response attribute we care and assign the proper type instead of interface{}
+			type Response struct {
+				Data    []string          `json:"data"`
+				Message string            `json:"message"`
+				Status  int               `json:"status"`
+				Meta    *types.Pagination `json:"_meta"`
 			}
 
 			rr := new(Response)
@@ -1074,6 +1552,15 @@ func TestHandleRemove(t *testing.T) {
 				Status:  http.StatusInternalServerError,
 			},
 		},
+		{
+			name:     "trash full",
+			input:    "administrator",
+			expected: ErrTrashFull,
+			output: &types.Response{
+				Message: "groups trash is full, purge the trash through POST /api/v0/admin/groups/purge before deleting the group",
+				Status:  http.StatusInsufficientStorage,
+			},
+		},
 		{
 			name:     "found",
 			input:    "administrator",
@@ -1497,7 +1984,7 @@ func TestHandleAssignIdentities(t *testing.T) {
 				},
 			},
 			output: &types.Response{
-				Message: "user test-user is not allowed to assign specified identities",
+				Message: "user test-user is not allowed to assign identities joe",
 				Status:  http.StatusForbidden,
 			},
 		},
@@ -1536,7 +2023,12 @@ func TestHandleAssignIdentities(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/groups/%s/identities", test.input.groupID), bytes.NewReader(payload))
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
-			mockService.EXPECT().CanAssignIdentities(gomock.Any(), "test-user", test.input.identities).Return(test.expectedCheck, test.expectedCheckErr)
+			denied := []string{}
+			if !test.expectedCheck {
+				denied = test.input.identities[:1]
+			}
+
+			mockService.EXPECT().CanAssignIdentities(gomock.Any(), "test-user", test.input.identities).Return(test.expectedCheck, denied, test.expectedCheckErr)
 			if test.expectedCheck {
 				mockService.EXPECT().AssignIdentities(gomock.Any(), test.input.groupID, test.input.identities).Return(test.expected)
 			}
@@ -1719,7 +2211,7 @@ func TestHandleAssignRoles(t *testing.T) {
 				},
 			},
 			output: &types.Response{
-				Message: "user test-user is not allowed to assign specified roles",
+				Message: "user test-user is not allowed to assign roles viewer",
 				Status:  http.StatusForbidden,
 			},
 		},
@@ -1758,7 +2250,12 @@ func TestHandleAssignRoles(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v0/groups/%s/roles", test.input.groupID), bytes.NewReader(payload))
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
-			mockService.EXPECT().CanAssignRoles(gomock.Any(), "test-user", test.input.roles).Return(test.expectedCheck, test.expectedCheckErr)
+			denied := []string{}
+			if !test.expectedCheck {
+				denied = test.input.roles[:1]
+			}
+
+			mockService.EXPECT().CanAssignRoles(gomock.Any(), "test-user", test.input.roles).Return(test.expectedCheck, denied, test.expectedCheckErr)
 			if test.expectedCheck {
 				mockService.EXPECT().AssignRoles(gomock.Any(), test.input.groupID, test.input.roles).Return(test.expected)
 			}
@@ -2066,6 +2563,98 @@ func TestHandleListIdentitiesSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleListIdentitiesExpanded(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected error
+		output   *types.Response
+	}{
+		{
+			name:     "success",
+			expected: nil,
+			output: &types.Response{
+				Data:    []string{"user:joe", "user:ceo"},
+				Message: "List of identities",
+				Status:  http.StatusOK,
+			},
+		},
+		{
+			name:     "error",
+			expected: fmt.Errorf("error"),
+			output: &types.Response{
+				Message: "error",
+				Status:  http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			groupID := "administrator"
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/groups/%s/identities?expand=true", groupID), nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			var identities []string = nil
+			if test.expected == nil {
+				identities = []string{"user:joe", "user:ceo"}
+			}
+
+			mockService.EXPECT().ListIdentitiesTransitive(gomock.Any(), groupID).Return(identities, test.expected)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.output.Status {
+				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
+			}
+
+			// duplicate types.Response attribute we care and assign the proper type instead of interface{}
+			type Response struct {
+				Data    []string `json:"data"`
+				Message string   `json:"message"`
+				Status  int      `json:"status"`
+			}
+
+			rr := new(Response)
+
+			if err := json.Unmarshal(data, rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if test.expected == nil && !reflect.DeepEqual(rr.Data, test.output.Data) {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Data, rr.Data)
+			}
+
+			if rr.Message != test.output.Message {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Message, rr.Message)
+			}
+
+			if rr.Status != test.output.Status {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Status, rr.Status)
+			}
+		})
+	}
+}
+
 func TestRegisterValidation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2092,3 +2681,307 @@ func TestRegisterValidation(t *testing.T) {
 	// second registration of `apiKey` causes logger.Fatal invocation
 	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterValidation(mockValidationRegistry)
 }
+
+func TestHandleRemoveDryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		tuples   []ofga.Tuple
+		expected error
+		status   int
+	}{
+		{
+			name:     "error",
+			expected: fmt.Errorf("error"),
+			status:   http.StatusInternalServerError,
+		},
+		{
+			name: "preview",
+			tuples: []ofga.Tuple{
+				*ofga.NewTuple("user:joe", "member", "group:administrator"),
+				*ofga.NewTuple("group:administrator#member", "can_view", "client:okta"),
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v0/groups/administrator?dry_run=true", nil)
+
+			mockService.EXPECT().PreviewDeleteGroup(gomock.Any(), "administrator").Return(test.tuples, test.expected)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data    DeletePreview `json:"data"`
+				Message string        `json:"message"`
+				Status  int           `json:"status"`
+			}
+
+			rr := new(Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if test.expected != nil {
+				return
+			}
+
+			if rr.Data.Count != len(test.tuples) || !reflect.DeepEqual(rr.Data.Tuples, test.tuples) {
+				t.Errorf("expected preview to be %v got %v", test.tuples, rr.Data)
+			}
+		})
+	}
+}
+
+func TestHandleListPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int64
+	}{
+		{name: "missing size uses the default", query: "", expected: 10},
+		{name: "size below max", query: "size=20", expected: 20},
+		{name: "size equal to max", query: "size=50", expected: 50},
+		{name: "size above max is capped", query: "size=51", expected: 50},
+		{name: "zero size", query: "size=0"},
+		{name: "negative size", query: "size=-1"},
+		{name: "non numeric size", query: "size=ten"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/groups?"+test.query, nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			if test.expected > 0 {
+				mockService.EXPECT().ListGroups(gomock.Any(), "test-user", "", test.expected, "").Return([]string{}, "", nil)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			api := NewAPI(mockService, mockTracer, mockMonitor, mockLogger)
+			api.SetPageSizeConfig(types.NewPageSizeConfig(10, 50))
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			expectedStatus := http.StatusOK
+			if test.expected == 0 {
+				expectedStatus = http.StatusBadRequest
+			}
+
+			if res.StatusCode != expectedStatus {
+				t.Fatalf("expected HTTP status code %v got %v", expectedStatus, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleRestore(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{name: "restored", status: http.StatusOK},
+		{name: "live group with the same name", err: ErrGroupExists, status: http.StatusConflict, code: types.CodeGroupNameConflict},
+		{name: "not in the trash", err: ErrGroupNotFound, status: http.StatusNotFound, code: types.CodeGroupNotFound},
+		{name: "soft delete disabled", err: ErrSoftDeleteDisabled, status: http.StatusNotImplemented, code: types.CodeNotImplemented},
+		{name: "error", err: fmt.Errorf("error"), status: http.StatusInternalServerError, code: types.CodeInternal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/groups/administrator/restore", nil)
+
+			var group *Group
+
+			if test.err == nil {
+				group = &Group{ID: "administrator", Name: "administrator"}
+			}
+
+			mockService.EXPECT().RestoreGroup(gomock.Any(), "administrator").Return(group, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code %q got %q", test.code, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandleMoveIdentity(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		canMove    bool
+		checkErr   error
+		moveErr    error
+		expectMove bool
+		status     int
+		code       string
+	}{
+		{
+			name:       "moved",
+			payload:    `{"from":"editor","to":"viewer"}`,
+			canMove:    true,
+			expectMove: true,
+			status:     http.StatusOK,
+		},
+		{
+			name:    "missing target",
+			payload: `{"from":"editor"}`,
+			status:  http.StatusBadRequest,
+			code:    types.CodeInvalidPayload,
+		},
+		{
+			name:    "not allowed",
+			payload: `{"from":"editor","to":"viewer"}`,
+			status:  http.StatusForbidden,
+			code:    types.CodeForbidden,
+		},
+		{
+			name:     "check fails",
+			payload:  `{"from":"editor","to":"viewer"}`,
+			checkErr: fmt.Errorf("error"),
+			status:   http.StatusInternalServerError,
+			code:     types.CodeInternal,
+		},
+		{
+			name:       "group not found",
+			payload:    `{"from":"editor","to":"viewer"}`,
+			canMove:    true,
+			expectMove: true,
+			moveErr:    fmt.Errorf("%w: viewer", ErrGroupNotFound),
+			status:     http.StatusNotFound,
+			code:       types.CodeGroupNotFound,
+		},
+		{
+			name:       "not a member",
+			payload:    `{"from":"editor","to":"viewer"}`,
+			canMove:    true,
+			expectMove: true,
+			moveErr:    ErrNotMember,
+			status:     http.StatusConflict,
+			code:       types.CodeGroupNotMember,
+		},
+		{
+			name:       "same group",
+			payload:    `{"from":"editor","to":"editor"}`,
+			canMove:    true,
+			expectMove: true,
+			moveErr:    ErrSameGroup,
+			status:     http.StatusBadRequest,
+			code:       types.CodeInvalidPayload,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			move := new(MoveIdentityRequest)
+			json.Unmarshal([]byte(test.payload), move)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/joe/move-group", strings.NewReader(test.payload))
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			if test.status != http.StatusBadRequest || test.expectMove {
+				mockService.EXPECT().CanMoveIdentity(gomock.Any(), "test-user", move.From, move.To).Times(1).Return(test.canMove, test.checkErr)
+			}
+
+			if test.expectMove {
+				mockService.EXPECT().MoveIdentity(gomock.Any(), "joe", move.From, move.To).Times(1).Return(test.moveErr)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code %q got %q", test.code, rr.Code)
+			}
+		})
+	}
+}
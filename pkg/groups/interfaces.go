@@ -5,6 +5,7 @@ package groups
 
 import (
 	"context"
+	"time"
 
 	"github.com/openfga/go-sdk/client"
 
@@ -13,29 +14,47 @@ import (
 
 // ServiceInterface is the interface that each business logic service needs to implement
 type ServiceInterface interface {
-	ListGroups(context.Context, string) ([]string, error) // list of groups, continuation token, error
+	ListGroups(context.Context, string, string, int64, string) ([]string, string, error) // list of groups, continuation token, error
 	GetGroup(context.Context, string, string) (*Group, error)
 	CreateGroup(context.Context, string, string) (*Group, error)
+	RenameGroup(context.Context, string, string) (*Group, error)
 	DeleteGroup(context.Context, string) error
-	ListRoles(context.Context, string) ([]string, error)
+	RestoreGroup(context.Context, string) (*Group, error)
+	PreviewDeleteGroup(context.Context, string) ([]ofga.Tuple, error)
+	ListRoles(context.Context, string, int64, string) ([]string, string, error)
 	AssignRoles(context.Context, string, ...string) error
 	RemoveRoles(context.Context, string, ...string) error
-	ListPermissions(context.Context, string, map[string]string) ([]string, map[string]string, error)
+	ListPermissions(context.Context, string, map[string]string, bool, []string, string) ([]string, map[string]string, error)
 	AssignPermissions(context.Context, string, ...Permission) error
 	RemovePermissions(context.Context, string, ...Permission) error
 	ListIdentities(context.Context, string, string) ([]string, string, error)
+	ListIdentitiesTransitive(context.Context, string) ([]string, error)
+	ExportMemberships(context.Context, string, func(Membership) error) error
 	AssignIdentities(context.Context, string, ...string) error
 	RemoveIdentities(context.Context, string, ...string) error
-	CanAssignRoles(context.Context, string, ...string) (bool, error)
-	CanAssignIdentities(context.Context, string, ...string) (bool, error)
+	MoveIdentity(context.Context, string, string, string) error
+	CanMoveIdentity(context.Context, string, string, string) (bool, error)
+	CanAssignRoles(context.Context, string, ...string) (bool, []string, error)
+	CanAssignIdentities(context.Context, string, ...string) (bool, []string, error)
+}
+
+// TrashInterface stores soft deleted groups until they are restored
+type TrashInterface interface {
+	Put(context.Context, *DeletedGroup) error
+	Get(context.Context, string) (*DeletedGroup, error)
+	Remove(context.Context, string) error
+	Purge(context.Context, time.Time) ([]string, error)
 }
 
 // OpenFGAClientInterface is the interface used to decouple the OpenFGA store implementation
 type OpenFGAClientInterface interface {
 	ListObjects(context.Context, string, string, string) ([]string, error)
 	ReadTuples(context.Context, string, string, string, string) (*client.ClientReadResponse, error)
+	ReadTuplesPage(context.Context, string, string, string, string, int32) (*client.ClientReadResponse, error)
 	WriteTuples(context.Context, ...ofga.Tuple) error
 	DeleteTuples(context.Context, ...ofga.Tuple) error
+	WriteAndDeleteTuples(context.Context, []ofga.Tuple, []ofga.Tuple) error
 	Check(context.Context, string, string, string, ...ofga.Tuple) (bool, error)
 	BatchCheck(context.Context, ...ofga.Tuple) (bool, error)
+	BatchCheckDetailed(context.Context, ...ofga.Tuple) ([]ofga.CheckResult, error)
 }
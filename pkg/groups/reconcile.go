@@ -0,0 +1,82 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"context"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	authz "github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// ReconcileGroup writes back the tuples CreateGroup gives owner on the group, e.g. after a creation
+// that failed half way, tuples still in place are left alone so reconciling a consistent group is
+// a no-op, the restored tuples are returned
+// fails with ErrGroupNotFound if no tuple references the group
+func (s *Service) ReconcileGroup(ctx context.Context, name, owner string) ([]ofga.Tuple, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.ReconcileGroup")
+	defer span.End()
+
+	group := authz.GroupForTuple(name)
+
+	r, err := s.ofga.ReadTuples(ctx, "", "", group, "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(r.GetTuples()) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	user := authz.UserForTuple(owner)
+
+	// keep in sync with CreateGroup
+	missing, err := s.missingTuples(
+		ctx,
+		*ofga.NewTuple(user, authz.MEMBER_RELATION, group),
+		*ofga.NewTuple(user, authz.CAN_VIEW_RELATION, group),
+	)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(missing) == 0 {
+		return missing, nil
+	}
+
+	err = s.ofga.WriteTuples(ctx, missing...)
+
+	s.auditor.Record(ctx, audit.GroupReconcile, audit.GroupResource, name, audit.OutcomeFromError(err))
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+// missingTuples returns the tuples not stored in OpenFGA, writing an existing tuple fails
+func (s *Service) missingTuples(ctx context.Context, tuples ...ofga.Tuple) ([]ofga.Tuple, error) {
+	missing := make([]ofga.Tuple, 0, len(tuples))
+
+	for _, t := range tuples {
+		r, err := s.ofga.ReadTuples(ctx, t.User, t.Relation, t.Object, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(r.GetTuples()) == 0 {
+			missing = append(missing, t)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	authz "github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/events"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+func TestServiceReconcileGroup(t *testing.T) {
+	member := *ofga.NewTuple("user:joe", authz.MEMBER_RELATION, "group:administrator")
+	viewer := *ofga.NewTuple("user:joe", authz.CAN_VIEW_RELATION, "group:administrator")
+	jane := *ofga.NewTuple("user:jane", authz.MEMBER_RELATION, "group:administrator")
+
+	tests := []struct {
+		name        string
+		stored      []ofga.Tuple
+		writeErr    error
+		expected    []ofga.Tuple
+		expectedErr error
+	}{
+		{
+			name:     "missing creator tuples are restored",
+			stored:   []ofga.Tuple{viewer, jane},
+			expected: []ofga.Tuple{member},
+		},
+		{
+			name:     "consistent group is left alone",
+			stored:   []ofga.Tuple{member, viewer},
+			expected: []ofga.Tuple{},
+		},
+		{
+			name:        "group not found",
+			expectedErr: ErrGroupNotFound,
+		},
+		{
+			name:        "write fails",
+			stored:      []ofga.Tuple{jane},
+			writeErr:    fmt.Errorf("error"),
+			expectedErr: fmt.Errorf("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			svc := NewService(mockOpenFGA, NewMockWorkerPoolInterface(ctrl), audit.NewNoopAuditor(), events.NewNoopDispatcher(), mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "groups.Service.ReconcileGroup").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, cToken string) (*client.ClientReadResponse, error) {
+					ts := []openfga.Tuple{}
+
+					for _, tuple := range test.stored {
+						if tuple.Object != object || (user != "" && tuple.User != user) || (relation != "" && tuple.Relation != relation) {
+							continue
+						}
+
+						ts = append(ts, *openfga.NewTuple(*openfga.NewTupleKey(tuple.Values()), time.Now()))
+					}
+
+					r := new(client.ClientReadResponse)
+					r.SetTuples(ts)
+					r.SetContinuationToken("")
+
+					return r, nil
+				},
+			)
+
+			if test.writeErr != nil || len(test.expected) > 0 {
+				mockOpenFGA.EXPECT().WriteTuples(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(ctx context.Context, tuples ...ofga.Tuple) error {
+						if test.writeErr == nil && !reflect.DeepEqual(tuples, test.expected) {
+							t.Errorf("expected tuples to be %v got %v", test.expected, tuples)
+						}
+
+						return test.writeErr
+					},
+				)
+			}
+
+			restored, err := svc.ReconcileGroup(context.Background(), "administrator", "joe")
+
+			if test.expectedErr != nil {
+				if err == nil || (errors.Is(test.expectedErr, ErrGroupNotFound) && !errors.Is(err, ErrGroupNotFound)) {
+					t.Fatalf("expected error to be %v got %v", test.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(restored, test.expected) {
+				t.Fatalf("expected restored tuples to be %v got %v", test.expected, restored)
+			}
+		})
+	}
+}
@@ -5,13 +5,18 @@ package groups
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	v1 "github.com/canonical/rebac-admin-ui-handlers/v1"
 	"github.com/canonical/rebac-admin-ui-handlers/v1/resources"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/events"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 
@@ -24,6 +29,35 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/pool"
 )
 
+const (
+	// MaxAutoPaginatePermissions caps the permissions collected by an auto paginated ListPermissions
+	MaxAutoPaginatePermissions = 10000
+	// MaxExpansionDepth is the deepest level of nested groups expanded by ListIdentitiesTransitive
+	MaxExpansionDepth = 10
+)
+
+var (
+	// ErrGroupExists is returned when the target name of a rename is already in use
+	ErrGroupExists = errors.New("group already exists")
+	// ErrGroupNotFound is returned when no tuple references the group
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrInvalidPermissionType is returned when filtering permissions on an unknown object type
+	ErrInvalidPermissionType = errors.New("invalid permission type")
+
+	// ErrInvalidPermissionRelation is returned when filtering permissions on an unknown relation
+	ErrInvalidPermissionRelation = errors.New("invalid permission relation")
+	// ErrInvalidPermission is returned when a permission has no relation or its object is not a <type>:<id> reference
+	ErrInvalidPermission = errors.New("invalid permission")
+	// ErrSoftDeleteDisabled is returned when restoring or purging groups without a TrashInterface set
+	ErrSoftDeleteDisabled = errors.New("soft delete is not enabled")
+	// ErrSameGroup is returned when moving an identity to the group it is moved from
+	ErrSameGroup = errors.New("source and target groups are the same")
+	// ErrNotMember is returned when moving an identity out of a group it is not a direct member of
+	ErrNotMember = errors.New("identity is not a member of the group")
+	// ErrTrashFull is returned when a deleted group doesn't fit in the trash
+	ErrTrashFull = errors.New("groups trash is full")
+)
+
 type listPermissionsResult struct {
 	permissions []string
 	token       string
@@ -35,15 +69,21 @@ type listPermissionsResult struct {
 type Service struct {
 	ofga OpenFGAClientInterface
 
-	wpool pool.WorkerPoolInterface
+	wpool      pool.WorkerPoolInterface
+	auditor    audit.AuditorInterface
+	dispatcher events.DispatcherInterface
+	trash      TrashInterface
 
 	tracer  trace.Tracer
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
 }
 
-// ListGroups returns all the groups a specific user can see (using "can_view" OpenFGA relation)
-func (s *Service) ListGroups(ctx context.Context, userID string) ([]string, error) {
+// ListGroups returns the groups a specific user can see (using "can_view" OpenFGA relation), a page of
+// at most size groups following the continuation token is returned if size is positive, all of them otherwise
+// if prefix is not empty only groups whose name starts with it (case-insensitive) are returned, the filter
+// is applied before paginating so that pages and continuation tokens are consistent
+func (s *Service) ListGroups(ctx context.Context, userID, prefix string, size int64, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.ListGroups")
 	defer span.End()
 
@@ -51,46 +91,153 @@ func (s *Service) ListGroups(ctx context.Context, userID string) ([]string, erro
 
 	if err != nil {
 		s.logger.Error(err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
-	return groups, nil
+	groups = filterByPrefix(groups, prefix)
+
+	groups, token := ofga.PaginateObjects(groups, size, continuationToken)
+
+	return groups, token, nil
 }
 
-// ListRoles returns all the roles associated to a specific group
-func (s *Service) ListRoles(ctx context.Context, ID string) ([]string, error) {
+// CountGroups returns the number of groups a specific user can see
+func (s *Service) CountGroups(ctx context.Context, userID string) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.CountGroups")
+	defer span.End()
+
+	groups, err := s.ofga.ListObjects(ctx, authz.UserForTuple(userID), authz.CAN_VIEW_RELATION, "group")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return 0, err
+	}
+
+	return int64(len(groups)), nil
+}
+
+// filterByPrefix keeps the names starting with prefix, ignoring case
+func filterByPrefix(names []string, prefix string) []string {
+	if prefix == "" {
+		return names
+	}
+
+	prefix = strings.ToLower(prefix)
+	filtered := make([]string, 0, len(names))
+
+	for _, name := range names {
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			filtered = append(filtered, name)
+		}
+	}
+
+	return filtered
+}
+
+// ListRoles returns the roles assigned to a specific group, without size and continuationToken all
+// of them are returned at once, otherwise a page of at most size roles is read from the assignee
+// tuples and the OpenFGA continuation token of the next page is returned
+func (s *Service) ListRoles(ctx context.Context, ID string, size int64, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.ListRoles")
 	defer span.End()
 
-	roles, err := s.ofga.ListObjects(ctx, authz.GroupMemberForTuple(ID), authz.ASSIGNEE_RELATION, "role")
+	if size <= 0 && continuationToken == "" {
+		roles, err := s.ofga.ListObjects(ctx, authz.GroupMemberForTuple(ID), authz.ASSIGNEE_RELATION, "role")
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, "", err
+		}
+
+		return roles, "", nil
+	}
+
+	r, err := s.ofga.ReadTuplesPage(
+		ctx,
+		authz.GroupMemberForTuple(ID),
+		authz.ASSIGNEE_RELATION,
+		"role:",
+		continuationToken,
+		int32(min(size, ofga.MaxReadPageSize)),
+	)
 
 	if err != nil {
 		s.logger.Error(err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
-	return roles, nil
+	roles := make([]string, 0, len(r.GetTuples()))
+
+	for _, t := range r.GetTuples() {
+		roles = append(roles, strings.TrimPrefix(t.Key.Object, "role:"))
+	}
+
+	return roles, r.GetContinuationToken(), nil
 }
 
-// ListPermissions returns all the permissions associated to a specific group
-func (s *Service) ListPermissions(ctx context.Context, ID string, continuationTokens map[string]string) ([]string, map[string]string, error) {
+// ListPermissions returns all the permissions associated to a specific group, if autoPaginate is set
+// every per type continuation token is drained until exhaustion or until MaxAutoPaginatePermissions
+// permissions are collected, in which case the remaining tokens are returned
+// pTypes restricts the lookup to a subset of the object types, all of them are read when empty,
+// relation restricts it to a single permission relation, all of them are read when empty
+func (s *Service) ListPermissions(ctx context.Context, ID string, continuationTokens map[string]string, autoPaginate bool, pTypes []string, relation string) ([]string, map[string]string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.ListPermissions")
 	defer span.End()
 
+	pTypes, err := s.filterPermissionTypes(pTypes)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if relation != "" && !slices.Contains(s.permissionRelations(), relation) {
+		return nil, nil, fmt.Errorf("%w %s", ErrInvalidPermissionRelation, relation)
+	}
+
+	permissions, tMap, err := s.listPermissions(ctx, ID, pTypes, relation, continuationTokens)
+
+	for autoPaginate && err == nil && len(permissions) < MaxAutoPaginatePermissions {
+		pending := make([]string, 0)
+
+		for t, token := range tMap {
+			if token != "" {
+				pending = append(pending, t)
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+
+		var p []string
+		var tokens map[string]string
+
+		p, tokens, err = s.listPermissions(ctx, ID, pending, relation, tMap)
+		permissions = append(permissions, p...)
+
+		for t, token := range tokens {
+			tMap[t] = token
+		}
+	}
+
+	return permissions, tMap, err
+}
+
+func (s *Service) listPermissions(ctx context.Context, ID string, pTypes []string, relation string, continuationTokens map[string]string) ([]string, map[string]string, error) {
 	// keep it a buffered channel, if set to unbuffered we would need a goroutine
 	// to consume from it before pushing to it
 	// https://go.dev/ref/spec#Send_statements
 	// A send on an unbuffered channel can proceed if a receiver is ready.
 	// A send on a buffered channel can proceed if there is room in the buffer
-	results := make(chan *pool.Result[any], len(s.permissionTypes()))
+	results := make(chan *pool.Result[any], len(pTypes))
 
 	wg := sync.WaitGroup{}
-	wg.Add(len(s.permissionTypes()))
+	wg.Add(len(pTypes))
 
 	// TODO @shipperizer use a background operator
-	for _, t := range s.permissionTypes() {
+	for _, t := range pTypes {
 		s.wpool.Submit(
-			s.listPermissionsFunc(ctx, ID, t, continuationTokens[t]),
+			s.listPermissionsFunc(ctx, ID, t, relation, continuationTokens[t]),
 			results,
 			&wg,
 		)
@@ -177,6 +324,8 @@ func (s *Service) CreateGroup(ctx context.Context, userID, groupName string) (*G
 		*ofga.NewTuple(user, authz.CAN_VIEW_RELATION, group),
 	)
 
+	s.auditor.Record(ctx, audit.GroupCreate, audit.GroupResource, groupName, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return nil, err
@@ -204,6 +353,8 @@ func (s *Service) AssignRoles(ctx context.Context, ID string, roles ...string) e
 
 	err := s.ofga.WriteTuples(ctx, rs...)
 
+	s.auditor.Record(ctx, audit.GroupAssignRoles, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -212,13 +363,14 @@ func (s *Service) AssignRoles(ctx context.Context, ID string, roles ...string) e
 	return nil
 }
 
-func (s *Service) CanAssignRoles(ctx context.Context, userID string, roles ...string) (bool, error) {
+// CanAssignRoles checks if the user can view all the roles, returning the ones that are not accessible
+func (s *Service) CanAssignRoles(ctx context.Context, userID string, roles ...string) (bool, []string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.CanAssignRoles")
 	defer span.End()
 
 	cardinality := len(roles)
 	if cardinality == 0 {
-		return true, nil
+		return true, nil, nil
 	}
 
 	rs := make([]ofga.Tuple, 0, cardinality)
@@ -227,14 +379,22 @@ func (s *Service) CanAssignRoles(ctx context.Context, userID string, roles ...st
 		rs = append(rs, *ofga.NewTuple(authz.UserForTuple(userID), authz.CAN_VIEW_RELATION, authz.RoleForTuple(role)))
 	}
 
-	check, err := s.ofga.BatchCheck(ctx, rs...)
+	checks, err := s.ofga.BatchCheckDetailed(ctx, rs...)
 
 	if err != nil {
 		s.logger.Error(err.Error())
-		return false, err
+		return false, nil, err
+	}
+
+	denied := make([]string, 0)
+
+	for i, check := range checks {
+		if !check.Allowed {
+			denied = append(denied, roles[i])
+		}
 	}
 
-	return check, nil
+	return len(denied) == 0, denied, nil
 }
 
 // RemoveRoles drops roles from a group
@@ -253,6 +413,8 @@ func (s *Service) RemoveRoles(ctx context.Context, ID string, roles ...string) e
 
 	err := s.ofga.DeleteTuples(ctx, rs...)
 
+	s.auditor.Record(ctx, audit.GroupRemoveRoles, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -273,11 +435,17 @@ func (s *Service) AssignPermissions(ctx context.Context, ID string, permissions
 	ps := make([]ofga.Tuple, 0)
 
 	for _, p := range permissions {
+		if err := s.validatePermission(p); err != nil {
+			return err
+		}
+
 		ps = append(ps, *ofga.NewTuple(authz.GroupMemberForTuple(ID), p.Relation, p.Object))
 	}
 
 	err := s.ofga.WriteTuples(ctx, ps...)
 
+	s.auditor.Record(ctx, audit.GroupAssignPermissions, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -298,11 +466,17 @@ func (s *Service) RemovePermissions(ctx context.Context, ID string, permissions
 	ps := make([]ofga.Tuple, 0)
 
 	for _, p := range permissions {
+		if err := s.validatePermission(p); err != nil {
+			return err
+		}
+
 		ps = append(ps, *ofga.NewTuple(authz.GroupMemberForTuple(ID), p.Relation, p.Object))
 	}
 
 	err := s.ofga.DeleteTuples(ctx, ps...)
 
+	s.auditor.Record(ctx, audit.GroupRemovePermissions, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -311,11 +485,22 @@ func (s *Service) RemovePermissions(ctx context.Context, ID string, permissions
 	return nil
 }
 
-// DeleteGroup deletes a group and all the related tuples
+// DeleteGroup deletes a group and all the related tuples, with soft delete enabled the tuples are
+// stashed in the trash first so that RestoreGroup can bring the group back, the group is left
+// untouched when it can't be stashed, ErrTrashFull is returned when the trash has no room for it
 func (s *Service) DeleteGroup(ctx context.Context, ID string) error {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.DeleteGroup")
 	defer span.End()
 
+	if s.trash != nil {
+		if err := s.stashGroup(ctx, ID); err != nil {
+			s.logger.Error(err.Error())
+			s.auditor.Record(ctx, audit.GroupDelete, audit.GroupResource, ID, audit.OutcomeFailure)
+
+			return err
+		}
+	}
+
 	// keep it a buffered channel, if set to unbuffered we would need a goroutine
 	// to consume from it before pushing to it
 	// https://go.dev/ref/spec#Send_statements
@@ -330,21 +515,22 @@ func (s *Service) DeleteGroup(ctx context.Context, ID string) error {
 	wg := sync.WaitGroup{}
 	wg.Add(jobs)
 
+	submitErrs := make([]error, 0)
+
 	// TODO @shipperizer use a background operator
 	for _, t := range s.permissionTypes() {
-		s.wpool.Submit(
-			s.removePermissionsFunc(ctx, ID, t),
-			results,
-			&wg,
-		)
+		// a rejected task never runs, release it here or Wait would block forever
+		if _, err := s.wpool.Submit(s.removePermissionsFunc(ctx, ID, t), results, &wg); err != nil {
+			wg.Done()
+			submitErrs = append(submitErrs, err)
+		}
 	}
 
 	for _, t := range directRelations {
-		s.wpool.Submit(
-			s.removeDirectAssociationsFunc(ctx, ID, t),
-			results,
-			&wg,
-		)
+		if _, err := s.wpool.Submit(s.removeDirectAssociationsFunc(ctx, ID, t), results, &wg); err != nil {
+			wg.Done()
+			submitErrs = append(submitErrs, err)
+		}
 	}
 
 	// wait for tasks to finish
@@ -353,10 +539,208 @@ func (s *Service) DeleteGroup(ctx context.Context, ID string) error {
 	// close result channel
 	close(results)
 
-	// TODO: @barco collect errors from results chan and return composite error or single summing up
+	if err := errors.Join(append(submitErrs, cascadeError(results))...); err != nil {
+		s.logger.Errorf("group %s deletion failed, tuples may be left behind, %s", ID, err)
+		s.auditor.Record(ctx, audit.GroupDelete, audit.GroupResource, ID, audit.OutcomeFailure)
+
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.GroupDelete, audit.GroupResource, ID, audit.OutcomeSuccess)
+
 	return nil
 }
 
+// RestoreGroup re-creates a soft deleted group writing back the tuples stashed by DeleteGroup,
+// restoring fails with ErrGroupExists if a group with the same name has been created since
+func (s *Service) RestoreGroup(ctx context.Context, name string) (*Group, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.RestoreGroup")
+	defer span.End()
+
+	if s.trash == nil {
+		return nil, ErrSoftDeleteDisabled
+	}
+
+	r, err := s.ofga.ReadTuples(ctx, "", "", authz.GroupForTuple(name), "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(r.GetTuples()) > 0 {
+		return nil, ErrGroupExists
+	}
+
+	deleted, err := s.trash.Get(ctx, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a group can hold more tuples than a single write accepts, a failed chunk rolls back the others
+	if err := ofga.WriteTuplesInChunks(ctx, s.ofga, deleted.Tuples...); err != nil {
+		s.logger.Error(err.Error())
+		s.auditor.Record(ctx, audit.GroupRestore, audit.GroupResource, name, audit.OutcomeFailure)
+
+		return nil, err
+	}
+
+	// the group is back at this point, a stale trash entry only means it can be restored again
+	if err := s.trash.Remove(ctx, name); err != nil {
+		s.logger.Errorf("failed removing restored group %s from the trash: %s", name, err)
+	}
+
+	s.auditor.Record(ctx, audit.GroupRestore, audit.GroupResource, name, audit.OutcomeSuccess)
+
+	return &Group{ID: name, Name: name}, nil
+}
+
+// PurgeDeletedGroups permanently drops the groups soft deleted more than olderThan ago, they can't
+// be restored afterwards, the number of groups purged is returned
+func (s *Service) PurgeDeletedGroups(ctx context.Context, olderThan time.Duration) (int, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.PurgeDeletedGroups")
+	defer span.End()
+
+	if s.trash == nil {
+		return 0, ErrSoftDeleteDisabled
+	}
+
+	purged, err := s.trash.Purge(ctx, time.Now().UTC().Add(-olderThan))
+
+	if err != nil {
+		s.logger.Errorf("failed purging deleted groups: %s", err)
+		return 0, err
+	}
+
+	for _, name := range purged {
+		s.auditor.Record(ctx, audit.GroupPurge, audit.GroupResource, name, audit.OutcomeSuccess)
+	}
+
+	return len(purged), nil
+}
+
+// PreviewDeleteGroup returns the tuples DeleteGroup would remove without deleting any of them
+func (s *Service) PreviewDeleteGroup(ctx context.Context, ID string) ([]ofga.Tuple, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.PreviewDeleteGroup")
+	defer span.End()
+
+	tuples, err := s.deletionTuples(ctx, ID)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	return tuples, nil
+}
+
+func (s *Service) stashGroup(ctx context.Context, ID string) error {
+	tuples, err := s.deletionTuples(ctx, ID)
+
+	if err != nil {
+		return err
+	}
+
+	// nothing to bring back, deleting a group that doesn't exist is a noop
+	if len(tuples) == 0 {
+		return nil
+	}
+
+	return s.trash.Put(ctx, &DeletedGroup{Name: ID, DeletedAt: time.Now().UTC(), Tuples: tuples})
+}
+
+// deletionTuples returns the tuples removed when deleting group ID
+func (s *Service) deletionTuples(ctx context.Context, ID string) ([]ofga.Tuple, error) {
+	tuples := make([]ofga.Tuple, 0)
+
+	for _, t := range s.permissionTypes() {
+		ts, err := s.readAllTuples(ctx, authz.GroupMemberForTuple(ID), "", fmt.Sprintf("%s:", t))
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples = append(tuples, ts...)
+	}
+
+	for _, relation := range s.directRelations() {
+		ts, err := s.readAllTuples(ctx, "", relation, authz.GroupForTuple(ID))
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples = append(tuples, ts...)
+	}
+
+	return tuples, nil
+}
+
+// RenameGroup moves all the direct associations and permissions of group ID over to a group called name
+// OpenFGA has no rename and no transaction spanning multiple writes, so tuples for the new name are written
+// first and the old ones removed after, both in chunks, if the removal fails the old tuples already removed
+// are written back and the new ones deleted to roll back
+func (s *Service) RenameGroup(ctx context.Context, ID, name string) (*Group, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.RenameGroup")
+	defer span.End()
+
+	// renaming a group to its own name is a no-op, as long as the group exists
+	if ID == name {
+		exists, err := s.groupNameTaken(ctx, ID)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err
+		}
+
+		if !exists {
+			return nil, ErrGroupNotFound
+		}
+
+		return &Group{ID: name, Name: name}, nil
+	}
+
+	taken, err := s.groupNameTaken(ctx, name)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if taken {
+		return nil, ErrGroupExists
+	}
+
+	tuples, err := s.groupTuples(ctx, ID)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(tuples) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	renamed := make([]ofga.Tuple, 0, len(tuples))
+
+	for _, t := range tuples {
+		renamed = append(renamed, *s.renameTuple(t, ID, name))
+	}
+
+	if err := ofga.ReplaceTuplesInChunks(ctx, s.ofga, tuples, renamed); err != nil {
+		s.logger.Errorf("failed renaming group %s to %s: %s", ID, name, err)
+		s.auditor.Record(ctx, audit.GroupRename, audit.GroupResource, ID, audit.OutcomeFailure)
+
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.GroupRename, audit.GroupResource, ID, audit.OutcomeSuccess)
+
+	return &Group{ID: name, Name: name}, nil
+}
+
 // ListIdentities returns all the identities (users for now) assigned to a group
 func (s *Service) ListIdentities(ctx context.Context, ID, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.ListIdentities")
@@ -383,6 +767,80 @@ func (s *Service) ListIdentities(ctx context.Context, ID, continuationToken stri
 	return identities, r.GetContinuationToken(), nil
 }
 
+// ListIdentitiesTransitive returns all the identities (users for now) assigned to a group, expanding
+// nested group memberships up to MaxExpansionDepth levels, each identity is returned only once
+func (s *Service) ListIdentitiesTransitive(ctx context.Context, ID string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.ListIdentitiesTransitive")
+	defer span.End()
+
+	direct, transitive, err := s.expandIdentities(ctx, ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(direct, transitive...), nil
+}
+
+// expandIdentities walks the group hierarchy under ID breadth first, identities assigned to ID are
+// returned as direct, the ones only reached through nested groups as transitive
+func (s *Service) expandIdentities(ctx context.Context, ID string) ([]string, []string, error) {
+	direct := make([]string, 0)
+	transitive := make([]string, 0)
+	seen := make(map[string]bool)
+	visited := map[string]bool{ID: true}
+	queue := []string{ID}
+	depth := map[string]int{ID: 0}
+
+	for len(queue) > 0 {
+		group := queue[0]
+		queue = queue[1:]
+
+		tuples, err := s.readAllTuples(ctx, "", authz.MEMBER_RELATION, authz.GroupForTuple(group))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, nil, err
+		}
+
+		for _, t := range tuples {
+			if strings.HasPrefix(t.User, "user:") && !seen[t.User] {
+				seen[t.User] = true
+
+				if group == ID {
+					direct = append(direct, t.User)
+				} else {
+					transitive = append(transitive, t.User)
+				}
+
+				continue
+			}
+
+			if !strings.HasPrefix(t.User, "group:") || !strings.HasSuffix(t.User, fmt.Sprintf("#%s", authz.MEMBER_RELATION)) {
+				continue
+			}
+
+			nested := strings.TrimSuffix(strings.TrimPrefix(t.User, "group:"), fmt.Sprintf("#%s", authz.MEMBER_RELATION))
+
+			// visited set protects against cycles in the group hierarchy
+			if visited[nested] {
+				continue
+			}
+
+			if depth[group] >= MaxExpansionDepth {
+				s.logger.Warnf("group %s nested deeper than %d levels under %s, not expanding it", nested, MaxExpansionDepth, ID)
+				continue
+			}
+
+			visited[nested] = true
+			depth[nested] = depth[group] + 1
+			queue = append(queue, nested)
+		}
+	}
+
+	return direct, transitive, nil
+}
+
 // AssignIdentities assigns identities to a group, right now using the type user which is disconnected
 // form the identity type
 func (s *Service) AssignIdentities(ctx context.Context, ID string, identities ...string) error {
@@ -397,21 +855,26 @@ func (s *Service) AssignIdentities(ctx context.Context, ID string, identities ..
 
 	err := s.ofga.WriteTuples(ctx, ids...)
 
+	s.auditor.Record(ctx, audit.GroupAssignIdentities, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
 	}
 
+	s.dispatcher.Dispatch(ctx, events.GroupIdentitiesAssigned, ID, identities...)
+
 	return nil
 }
 
-func (s *Service) CanAssignIdentities(ctx context.Context, userID string, identities ...string) (bool, error) {
+// CanAssignIdentities checks if the user can view all the identities, returning the ones that are not accessible
+func (s *Service) CanAssignIdentities(ctx context.Context, userID string, identities ...string) (bool, []string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.CanAssignIdentities")
 	defer span.End()
 
 	cardinality := len(identities)
 	if cardinality == 0 {
-		return true, nil
+		return true, nil, nil
 	}
 
 	rs := make([]ofga.Tuple, 0, cardinality)
@@ -420,14 +883,22 @@ func (s *Service) CanAssignIdentities(ctx context.Context, userID string, identi
 		rs = append(rs, *ofga.NewTuple(authz.UserForTuple(userID), authz.CAN_VIEW_RELATION, authz.IdentityForTuple(identity)))
 	}
 
-	check, err := s.ofga.BatchCheck(ctx, rs...)
+	checks, err := s.ofga.BatchCheckDetailed(ctx, rs...)
 
 	if err != nil {
 		s.logger.Error(err.Error())
-		return false, err
+		return false, nil, err
 	}
 
-	return check, nil
+	denied := make([]string, 0)
+
+	for i, check := range checks {
+		if !check.Allowed {
+			denied = append(denied, identities[i])
+		}
+	}
+
+	return len(denied) == 0, denied, nil
 }
 
 // RemoveIdentities removes identities from a group
@@ -443,22 +914,123 @@ func (s *Service) RemoveIdentities(ctx context.Context, ID string, identities ..
 
 	err := s.ofga.DeleteTuples(ctx, ids...)
 
+	s.auditor.Record(ctx, audit.GroupRemoveIdentities, audit.GroupResource, ID, audit.OutcomeFromError(err))
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+
+	s.dispatcher.Dispatch(ctx, events.GroupIdentitiesRemoved, ID, identities...)
+
+	return nil
+}
+
+// MoveIdentity removes identity from fromGroup and assigns it to toGroup in a single OpenFGA write, so
+// the identity is never left in both or neither of the groups, if it is already a member of toGroup
+// it is only removed from fromGroup
+func (s *Service) MoveIdentity(ctx context.Context, identity, fromGroup, toGroup string) error {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.MoveIdentity")
+	defer span.End()
+
+	if fromGroup == toGroup {
+		return ErrSameGroup
+	}
+
+	for _, group := range []string{fromGroup, toGroup} {
+		r, err := s.ofga.ReadTuples(ctx, "", "", authz.GroupForTuple(group), "")
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return err
+		}
+
+		if len(r.GetTuples()) == 0 {
+			return fmt.Errorf("%w: %s", ErrGroupNotFound, group)
+		}
+	}
+
+	member := authz.UserForTuple(identity)
+
+	r, err := s.ofga.ReadTuples(ctx, member, authz.MEMBER_RELATION, authz.GroupForTuple(fromGroup), "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+
+	if len(r.GetTuples()) == 0 {
+		return ErrNotMember
+	}
+
+	r, err = s.ofga.ReadTuples(ctx, member, authz.MEMBER_RELATION, authz.GroupForTuple(toGroup), "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+
+	writes := make([]ofga.Tuple, 0, 1)
+
+	if len(r.GetTuples()) == 0 {
+		writes = append(writes, *ofga.NewTuple(member, authz.MEMBER_RELATION, authz.GroupForTuple(toGroup)))
+	}
+
+	deletes := []ofga.Tuple{*ofga.NewTuple(member, authz.MEMBER_RELATION, authz.GroupForTuple(fromGroup))}
+
+	err = s.ofga.WriteAndDeleteTuples(ctx, writes, deletes)
+
+	s.auditor.Record(ctx, audit.GroupRemoveIdentities, audit.GroupResource, fromGroup, audit.OutcomeFromError(err))
+	s.auditor.Record(ctx, audit.GroupAssignIdentities, audit.GroupResource, toGroup, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
 	}
 
+	s.dispatcher.Dispatch(ctx, events.GroupIdentitiesRemoved, fromGroup, identity)
+	s.dispatcher.Dispatch(ctx, events.GroupIdentitiesAssigned, toGroup, identity)
+
 	return nil
 }
 
+// CanMoveIdentity checks if the user can edit both groups involved in a move, admins are always allowed
+func (s *Service) CanMoveIdentity(ctx context.Context, userID, fromGroup, toGroup string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "groups.Service.CanMoveIdentity")
+	defer span.End()
+
+	for _, group := range []string{toGroup, fromGroup} {
+		object := authz.GroupForTuple(group)
+
+		allowed, err := s.ofga.Check(
+			ctx,
+			authz.UserForTuple(userID),
+			authz.CAN_EDIT,
+			object,
+			*ofga.NewTuple(authz.ADMIN_OBJECT, authz.PRIVILEGED_RELATION, object),
+		)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return false, err
+		}
+
+		if !allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // TODO @shipperizer make this more scalable by pushing to a channel and using goroutine pool
 // potentially create a background operator that can pipe results to an on demand channel and works off a
 // set amount of goroutines
-func (s *Service) listPermissionsByType(ctx context.Context, ID, pType, continuationToken string) ([]string, string, error) {
+func (s *Service) listPermissionsByType(ctx context.Context, ID, pType, relation, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.listPermissionsByType")
 	defer span.End()
 
-	r, err := s.ofga.ReadTuples(ctx, authz.GroupMemberForTuple(ID), "", fmt.Sprintf("%s:", pType), continuationToken)
+	r, err := s.ofga.ReadTuples(ctx, authz.GroupMemberForTuple(ID), relation, fmt.Sprintf("%s:", pType), continuationToken)
 
 	if err != nil {
 		s.logger.Error(err.Error())
@@ -479,7 +1051,10 @@ func (s *Service) listPermissionsByType(ctx context.Context, ID, pType, continua
 	return permissions, r.GetContinuationToken(), nil
 }
 
-func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string) {
+// removePermissionsByType reads all the permissions of the given type before deleting them in a
+// single write, a cancelled context stops the reads and skips the write, an issued write is not
+// cancelled so the permissions of a type are either all removed or all kept
+func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string) error {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.removePermissionsByType")
 	defer span.End()
 
@@ -487,11 +1062,15 @@ func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string)
 	memberRelation := authz.GroupMemberForTuple(ID)
 	permissions := make([]ofga.Tuple, 0)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		r, err := s.ofga.ReadTuples(ctx, memberRelation, "", fmt.Sprintf("%s:", pType), cToken)
 
 		if err != nil {
 			s.logger.Errorf("error when retrieving tuples for %s %s", memberRelation, pType)
-			return
+			return err
 		}
 
 		for _, t := range r.Tuples {
@@ -507,23 +1086,35 @@ func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string)
 		break
 	}
 
-	if err := s.ofga.DeleteTuples(ctx, permissions...); err != nil {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.ofga.DeleteTuples(context.WithoutCancel(ctx), permissions...); err != nil {
 		s.logger.Error(err.Error())
+		return err
 	}
+
+	return nil
 }
 
-func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation string) {
+// removeDirectAssociations follows the same all or nothing approach as removePermissionsByType
+func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation string) error {
 	ctx, span := s.tracer.Start(ctx, "groups.Service.removeDirectAssociations")
 	defer span.End()
 
 	cToken := ""
 	directs := make([]ofga.Tuple, 0)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		r, err := s.ofga.ReadTuples(ctx, "", relation, authz.GroupForTuple(ID), cToken)
 
 		if err != nil {
 			s.logger.Errorf("error when retrieving tuples for %s group, %s relation", relation, ID)
-			return
+			return err
 		}
 
 		for _, t := range r.Tuples {
@@ -539,20 +1130,116 @@ func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation str
 	}
 
 	if len(directs) == 0 {
-		return
+		return nil
 	}
 
-	if err := s.ofga.DeleteTuples(ctx, directs...); err != nil {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.ofga.DeleteTuples(context.WithoutCancel(ctx), directs...); err != nil {
 		s.logger.Error(err.Error())
+		return err
 	}
+
+	return nil
 }
 
-func (s *Service) listPermissionsFunc(ctx context.Context, groupID, ofgaType, cToken string) func() any {
+// groupTuples returns all the tuples where the group is either the object (direct associations)
+// or the group#member userset is the user (permissions and role assignments)
+func (s *Service) groupTuples(ctx context.Context, ID string) ([]ofga.Tuple, error) {
+	tuples, err := s.readAllTuples(ctx, "", "", authz.GroupForTuple(ID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range s.permissionTypes() {
+		ts, err := s.readAllTuples(ctx, authz.GroupMemberForTuple(ID), "", fmt.Sprintf("%s:", t))
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples = append(tuples, ts...)
+	}
+
+	return tuples, nil
+}
+
+// groupNameTaken tells if any tuple references a group called name, either as the object or
+// through the group#member userset as the user, a group left with permissions only still exists
+func (s *Service) groupNameTaken(ctx context.Context, name string) (bool, error) {
+	r, err := s.ofga.ReadTuples(ctx, "", "", authz.GroupForTuple(name), "")
+
+	if err != nil {
+		return false, err
+	}
+
+	if len(r.GetTuples()) > 0 {
+		return true, nil
+	}
+
+	for _, t := range s.permissionTypes() {
+		r, err := s.ofga.ReadTuples(ctx, authz.GroupMemberForTuple(name), "", fmt.Sprintf("%s:", t), "")
+
+		if err != nil {
+			return false, err
+		}
+
+		if len(r.GetTuples()) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *Service) readAllTuples(ctx context.Context, user, relation, object string) ([]ofga.Tuple, error) {
+	cToken := ""
+	tuples := make([]ofga.Tuple, 0)
+
+	for {
+		r, err := s.ofga.ReadTuples(ctx, user, relation, object, cToken)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range r.GetTuples() {
+			tuples = append(tuples, *ofga.NewTuple(t.Key.User, t.Key.Relation, t.Key.Object))
+		}
+
+		// if there are more pages, keep going with the loop
+		if cToken = r.GetContinuationToken(); cToken == "" {
+			break
+		}
+	}
+
+	return tuples, nil
+}
+
+func (s *Service) renameTuple(t ofga.Tuple, ID, name string) *ofga.Tuple {
+	user, object := t.User, t.Object
+
+	if user == authz.GroupMemberForTuple(ID) {
+		user = authz.GroupMemberForTuple(name)
+	}
+
+	if object == authz.GroupForTuple(ID) {
+		object = authz.GroupForTuple(name)
+	}
+
+	return ofga.NewTuple(user, t.Relation, object)
+}
+
+func (s *Service) listPermissionsFunc(ctx context.Context, groupID, ofgaType, relation, cToken string) func() any {
 	return func() any {
 		p, token, err := s.listPermissionsByType(
 			ctx,
 			groupID,
 			ofgaType,
+			relation,
 			cToken,
 		)
 
@@ -565,33 +1252,93 @@ func (s *Service) listPermissionsFunc(ctx context.Context, groupID, ofgaType, cT
 	}
 }
 
-func (s *Service) removePermissionsFunc(ctx context.Context, groupID, ofgaType string) func() {
-	return func() {
-		s.removePermissionsByType(ctx, groupID, ofgaType)
+func (s *Service) removePermissionsFunc(ctx context.Context, groupID, ofgaType string) func() any {
+	return func() any {
+		return s.removePermissionsByType(ctx, groupID, ofgaType)
 	}
 }
 
-func (s *Service) removeDirectAssociationsFunc(ctx context.Context, groupID, relation string) func() {
-	return func() {
-		s.removeDirectAssociations(ctx, groupID, relation)
+func (s *Service) removeDirectAssociationsFunc(ctx context.Context, groupID, relation string) func() any {
+	return func() any {
+		return s.removeDirectAssociations(ctx, groupID, relation)
 	}
 }
 
+// cascadeError joins the errors reported by the cascade jobs, nil if all of them succeeded, a
+// failed job doesn't stop the others so the deletion goes as far as it can
+func cascadeError(results chan *pool.Result[any]) error {
+	errs := make([]error, 0)
+
+	for r := range results {
+		if err, ok := r.Value.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePermission checks the object is a <type>:<id> reference to a known type and the relation is set
+func (s *Service) validatePermission(p Permission) error {
+	if p.Relation == "" {
+		return fmt.Errorf("%w: missing relation on %s", ErrInvalidPermission, p.Object)
+	}
+
+	if err := ofga.ValidateObject(p.Object, s.permissionTypes()...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPermission, err)
+	}
+
+	return nil
+}
+
 func (s *Service) permissionTypes() []string {
 	return []string{"group", "role", "identity", "scheme", "provider", "client"}
 }
 
+// permissionRelations are the relations a group can be granted on the permission types
+func (s *Service) permissionRelations() []string {
+	return []string{authz.CAN_CREATE, authz.CAN_DELETE, authz.CAN_EDIT, authz.CAN_VIEW}
+}
+
+// filterPermissionTypes validates pTypes against the known object types, duplicates are dropped
+func (s *Service) filterPermissionTypes(pTypes []string) ([]string, error) {
+	if len(pTypes) == 0 {
+		return s.permissionTypes(), nil
+	}
+
+	filtered := make([]string, 0, len(pTypes))
+
+	for _, t := range pTypes {
+		if !slices.Contains(s.permissionTypes(), t) {
+			return nil, fmt.Errorf("%w %s", ErrInvalidPermissionType, t)
+		}
+
+		if !slices.Contains(filtered, t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
 func (s *Service) directRelations() []string {
 	return []string{"privileged", "member", "can_create", "can_delete", "can_edit", "can_view"}
 }
 
+// SetTrash turns soft delete on, deleted groups are kept in trash until restored
+func (s *Service) SetTrash(trash TrashInterface) {
+	s.trash = trash
+}
+
 // NewService returns the implementation of the business logic for the groups API
-func NewService(ofga OpenFGAClientInterface, wpool pool.WorkerPoolInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+func NewService(ofga OpenFGAClientInterface, wpool pool.WorkerPoolInterface, auditor audit.AuditorInterface, dispatcher events.DispatcherInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
 	s := new(Service)
 
 	s.ofga = ofga
 
 	s.wpool = wpool
+	s.auditor = auditor
+	s.dispatcher = dispatcher
 
 	s.monitor = monitor
 	s.tracer = tracer
@@ -603,6 +1350,9 @@ func NewService(ofga OpenFGAClientInterface, wpool pool.WorkerPoolInterface, tra
 type V1Service struct {
 	core ServiceInterface
 
+	// rollbackPatches undoes the additions of a PatchGroupIdentities call when its removals fail
+	rollbackPatches bool
+
 	tracer  trace.Tracer
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
@@ -618,7 +1368,7 @@ func (s *V1Service) ListGroups(ctx context.Context, params *resources.GetGroupsP
 		return nil, v1.NewAuthorizationError("unauthorized")
 	}
 
-	groups, err := s.core.ListGroups(ctx, principal.Identifier())
+	groups, _, err := s.core.ListGroups(ctx, principal.Identifier(), "", 0, "")
 	if err != nil {
 		return nil, v1.NewUnknownError(fmt.Sprintf("failed to list groups for user %s: %v", principal.Identifier(), err))
 	}
@@ -681,14 +1431,34 @@ func (s *V1Service) GetGroup(ctx context.Context, groupId string) (*resources.Gr
 	}, nil
 }
 
-// UpdateGroup updates the given resources.Group.
-//
-// Note: this is not implemented yet.
+// UpdateGroup updates the given resources.Group, the only supported change is the name
+// which renames the group identified by the Id attribute
 func (s *V1Service) UpdateGroup(ctx context.Context, group *resources.Group) (*resources.Group, error) {
-	_, span := s.tracer.Start(ctx, "groups.V1Service.UpdateGroup")
+	ctx, span := s.tracer.Start(ctx, "groups.V1Service.UpdateGroup")
 	defer span.End()
 
-	return nil, v1.NewNotImplementedError("service not implemented")
+	if group == nil || group.Id == nil || *group.Id == "" {
+		return nil, v1.NewValidationError("group id is required")
+	}
+
+	renamed, err := s.core.RenameGroup(ctx, *group.Id, group.Name)
+
+	if errors.Is(err, ErrGroupExists) {
+		return nil, v1.NewValidationError(fmt.Sprintf("group %s already exists", group.Name))
+	}
+
+	if errors.Is(err, ErrGroupNotFound) {
+		return nil, v1.NewNotFoundError(fmt.Sprintf("group %s not found", *group.Id))
+	}
+
+	if err != nil {
+		return nil, v1.NewUnknownError(fmt.Sprintf("failed to rename group %s to %s: %v", *group.Id, group.Name, err))
+	}
+
+	return &resources.Group{
+		Id:   &renamed.ID,
+		Name: renamed.Name,
+	}, nil
 }
 
 // DeleteGroup deletes a single group by its ID.
@@ -751,16 +1521,6 @@ func (s *V1Service) PatchGroupIdentities(ctx context.Context, groupId string, id
 	defer span.End()
 
 	var additions, removals []string
-	for _, identity := range identityPatches {
-		if identity.Op == "add" {
-			additions = append(additions, identity.Identity)
-		}
-
-		if identity.Op == "remove" {
-			removals = append(removals, identity.Identity)
-		}
-	}
-
 	for _, identityPatch := range identityPatches {
 		switch identityPatch.Op {
 		case "add":
@@ -780,19 +1540,47 @@ func (s *V1Service) PatchGroupIdentities(ctx context.Context, groupId string, id
 
 	if len(removals) > 0 {
 		if err := s.core.RemoveIdentities(ctx, groupId, removals...); err != nil {
-			return false, v1.NewUnknownError(fmt.Sprintf("failed to remove identities from group %s: %v", groupId, err))
+			if len(additions) == 0 {
+				return false, v1.NewUnknownError(fmt.Sprintf("failed to remove identities from group %s: %v", groupId, err))
+			}
+
+			return false, s.partialIdentitiesPatchError(ctx, groupId, additions, removals, err)
 		}
 	}
 
 	return true, nil
 }
 
+// partialIdentitiesPatchError reports a patch whose additions went through while its removals failed,
+// the additions are kept unless rollbackPatches is set, in which case they are removed again
+func (s *V1Service) partialIdentitiesPatchError(ctx context.Context, groupId string, additions, removals []string, removeErr error) error {
+	failed := fmt.Sprintf("failed: remove %v: %v", removals, removeErr)
+
+	if !s.rollbackPatches {
+		return v1.NewUnknownError(
+			fmt.Sprintf("identities of group %s partially patched, succeeded: add %v, %s", groupId, additions, failed),
+		)
+	}
+
+	if err := s.core.RemoveIdentities(ctx, groupId, additions...); err != nil {
+		s.logger.Errorf("failed to roll back identities added to group %s: %v", groupId, err)
+
+		return v1.NewUnknownError(
+			fmt.Sprintf("identities of group %s partially patched, succeeded: add %v, %s, rollback failed: %v", groupId, additions, failed, err),
+		)
+	}
+
+	return v1.NewUnknownError(
+		fmt.Sprintf("failed to patch identities of group %s, rolled back: add %v, %s", groupId, additions, failed),
+	)
+}
+
 // GetGroupRoles returns a page of resources.Role associated with the given group.
 func (s *V1Service) GetGroupRoles(ctx context.Context, groupId string, params *resources.GetGroupsItemRolesParams) (*resources.PaginatedResponse[resources.Role], error) {
 	ctx, span := s.tracer.Start(ctx, "groups.V1Service.GetGroupRoles")
 	defer span.End()
 
-	roles, err := s.core.ListRoles(ctx, groupId)
+	roles, _, err := s.core.ListRoles(ctx, groupId, 0, "")
 	if err != nil {
 		return nil, v1.NewUnknownError(fmt.Sprintf("failed to list roles for group %s: %v", groupId, err))
 	}
@@ -851,7 +1639,7 @@ func (s *V1Service) GetGroupEntitlements(ctx context.Context, groupId string, pa
 		s.logger.Error(fmt.Sprintf("failed to parse the page token: %v", err))
 	}
 
-	permissions, pageTokens, err := s.core.ListPermissions(ctx, groupId, paginator.GetAllTokens(ctx))
+	permissions, pageTokens, err := s.core.ListPermissions(ctx, groupId, paginator.GetAllTokens(ctx), false, nil, "")
 	if err != nil {
 		return nil, v1.NewUnknownError(fmt.Sprintf("failed to list permissions for group %s: %v", groupId, err))
 	}
@@ -933,3 +1721,9 @@ func NewV1Service(svc ServiceInterface, tracer trace.Tracer, monitor monitoring.
 
 	return s
 }
+
+// SetPatchRollback makes PatchGroupIdentities roll back the identities it added when removing the
+// others fails, by default the additions are kept and reported as succeeded in the returned error
+func (s *V1Service) SetPatchRollback(enabled bool) {
+	s.rollbackPatches = enabled
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// maxTrashBytes keeps the ConfigMap data below the 1 MiB size limit of kubernetes objects,
+// leaving room for the metadata
+const maxTrashBytes = 1000 * 1024
+
+// DeletedGroup is what a soft delete leaves behind, the tuples removed with the group
+type DeletedGroup struct {
+	Name      string       `json:"name"`
+	DeletedAt time.Time    `json:"deleted_at"`
+	Tuples    []ofga.Tuple `json:"tuples"`
+}
+
+// TrashConfig enables soft delete of groups, deleted groups are kept in the Name ConfigMap
+type TrashConfig struct {
+	K8s       coreV1.CoreV1Interface
+	Name      string
+	Namespace string
+
+	enabled bool
+}
+
+func (c *TrashConfig) Enabled() bool {
+	return c != nil && c.enabled
+}
+
+func NewTrashConfig(enabled bool, name, namespace string, k8s coreV1.CoreV1Interface) *TrashConfig {
+	c := new(TrashConfig)
+
+	c.enabled = enabled
+	c.Name = name
+	c.Namespace = namespace
+	c.K8s = k8s
+
+	return c
+}
+
+// ConfigMapTrash keeps soft deleted groups as serialized DeletedGroup objects in a ConfigMap,
+// one key per group, the ConfigMap has to exist beforehand
+type ConfigMapTrash struct {
+	k8s         coreV1.CoreV1Interface
+	cmName      string
+	cmNamespace string
+
+	tracer  trace.Tracer
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+// Put stores a deleted group, replacing a previous deletion of a group with the same name
+// all the groups share the ConfigMap, on a conflict with a concurrent update it's read again,
+// ErrTrashFull is returned when the group would push the ConfigMap over maxTrashBytes
+func (t *ConfigMapTrash) Put(ctx context.Context, group *DeletedGroup) error {
+	ctx, span := t.tracer.Start(ctx, "groups.ConfigMapTrash.Put")
+	defer span.End()
+
+	blob, err := json.Marshal(group)
+
+	if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := t.k8s.ConfigMaps(t.cmNamespace).Get(ctx, t.cmName, metaV1.GetOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+
+		cm.Data[t.key(group.Name)] = string(blob)
+
+		if size := configMapSize(cm.Data, cm.BinaryData); size > maxTrashBytes {
+			return fmt.Errorf("%w: storing group %s needs %d bytes, limit is %d", ErrTrashFull, group.Name, size, maxTrashBytes)
+		}
+
+		_, err = t.k8s.ConfigMaps(t.cmNamespace).Update(ctx, cm, metaV1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// Get returns the deleted group called name, ErrGroupNotFound if there is none
+func (t *ConfigMapTrash) Get(ctx context.Context, name string) (*DeletedGroup, error) {
+	ctx, span := t.tracer.Start(ctx, "groups.ConfigMapTrash.Get")
+	defer span.End()
+
+	cm, err := t.k8s.ConfigMaps(t.cmNamespace).Get(ctx, t.cmName, metaV1.GetOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	blob, ok := cm.Data[t.key(name)]
+
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+
+	group := new(DeletedGroup)
+
+	if err := json.Unmarshal([]byte(blob), group); err != nil {
+		return nil, fmt.Errorf("failed decoding deleted group %s: %w", name, err)
+	}
+
+	return group, nil
+}
+
+// Remove drops the deleted group called name, removing a missing group is not an error, like Put
+// it reads the ConfigMap again on a conflict
+func (t *ConfigMapTrash) Remove(ctx context.Context, name string) error {
+	ctx, span := t.tracer.Start(ctx, "groups.ConfigMapTrash.Remove")
+	defer span.End()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := t.k8s.ConfigMaps(t.cmNamespace).Get(ctx, t.cmName, metaV1.GetOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		if _, ok := cm.Data[t.key(name)]; !ok {
+			return nil
+		}
+
+		delete(cm.Data, t.key(name))
+
+		_, err = t.k8s.ConfigMaps(t.cmNamespace).Update(ctx, cm, metaV1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// Purge drops the groups deleted before cutoff and returns their names, the ConfigMap is updated
+// at its read version so concurrent purges don't drop a group twice, on a conflict the ConfigMap
+// is read again and only the groups still there are purged
+func (t *ConfigMapTrash) Purge(ctx context.Context, cutoff time.Time) ([]string, error) {
+	ctx, span := t.tracer.Start(ctx, "groups.ConfigMapTrash.Purge")
+	defer span.End()
+
+	var purged []string
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		purged = make([]string, 0)
+
+		cm, err := t.k8s.ConfigMaps(t.cmNamespace).Get(ctx, t.cmName, metaV1.GetOptions{})
+
+		if err != nil {
+			return err
+		}
+
+		for key, blob := range cm.Data {
+			group := new(DeletedGroup)
+
+			if err := json.Unmarshal([]byte(blob), group); err != nil {
+				t.logger.Errorf("failed decoding deleted group %s, skipping it: %s", key, err)
+				continue
+			}
+
+			if !group.DeletedAt.Before(cutoff) {
+				continue
+			}
+
+			delete(cm.Data, key)
+			purged = append(purged, group.Name)
+		}
+
+		if len(purged) == 0 {
+			return nil
+		}
+
+		_, err = t.k8s.ConfigMaps(t.cmNamespace).Update(ctx, cm, metaV1.UpdateOptions{})
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+// configMapSize returns the bytes taken by the keys and values of a ConfigMap
+func configMapSize(data map[string]string, binaryData map[string][]byte) int {
+	size := 0
+
+	for k, v := range data {
+		size += len(k) + len(v)
+	}
+
+	for k, v := range binaryData {
+		size += len(k) + len(v)
+	}
+
+	return size
+}
+
+// key encodes the group name, ConfigMap keys only allow alphanumerics, '-', '_' and '.'
+func (t *ConfigMapTrash) key(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+func NewConfigMapTrash(config *TrashConfig, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *ConfigMapTrash {
+	t := new(ConfigMapTrash)
+
+	t.k8s = config.K8s
+	t.cmName = config.Name
+	t.cmNamespace = config.Namespace
+
+	t.tracer = tracer
+	t.monitor = monitor
+	t.logger = logger
+
+	return t
+}
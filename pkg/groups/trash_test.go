@@ -0,0 +1,264 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package groups
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+func TestConfigMapTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+
+	cm := &v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "deleted-groups", Namespace: "default"}}
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockCoreV1.EXPECT().ConfigMaps("default").AnyTimes().Return(mockConfigMapV1)
+	mockConfigMapV1.EXPECT().Get(gomock.Any(), "deleted-groups", gomock.Any()).AnyTimes().DoAndReturn(
+		func(context.Context, string, metaV1.GetOptions) (*v1.ConfigMap, error) {
+			return cm.DeepCopy(), nil
+		},
+	)
+	mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, configMap *v1.ConfigMap, opts metaV1.UpdateOptions) (*v1.ConfigMap, error) {
+			cm = configMap
+			return configMap, nil
+		},
+	)
+
+	trash := NewConfigMapTrash(NewTrashConfig(true, "deleted-groups", "default", mockCoreV1), mockTracer, mockMonitor, mockLogger)
+
+	group := &DeletedGroup{
+		Name:      "it admins/emea",
+		DeletedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+		Tuples:    []ofga.Tuple{*ofga.NewTuple("user:joe", "member", "group:it admins/emea")},
+	}
+
+	if err := trash.Put(context.Background(), group); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for key := range cm.Data {
+		if errs := validation.IsConfigMapKey(key); errs != nil {
+			t.Fatalf("expected %q to be a valid ConfigMap key: %v", key, errs)
+		}
+	}
+
+	stashed, err := trash.Get(context.Background(), group.Name)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(stashed, group) {
+		t.Fatalf("expected stashed group to be %v got %v", group, stashed)
+	}
+
+	if err := trash.Remove(context.Background(), group.Name); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if _, err := trash.Get(context.Background(), group.Name); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("expected error to be %v got %v", ErrGroupNotFound, err)
+	}
+}
+
+func TestConfigMapTrashFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{Name: "deleted-groups", Namespace: "default"},
+		Data:       map[string]string{"large": strings.Repeat("x", maxTrashBytes-10)},
+	}
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockCoreV1.EXPECT().ConfigMaps("default").AnyTimes().Return(mockConfigMapV1)
+	mockConfigMapV1.EXPECT().Get(gomock.Any(), "deleted-groups", gomock.Any()).Times(1).Return(cm, nil)
+	mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	trash := NewConfigMapTrash(NewTrashConfig(true, "deleted-groups", "default", mockCoreV1), mockTracer, mockMonitor, mockLogger)
+
+	group := &DeletedGroup{
+		Name:   "administrator",
+		Tuples: []ofga.Tuple{*ofga.NewTuple("user:joe", "member", "group:administrator")},
+	}
+
+	if err := trash.Put(context.Background(), group); !errors.Is(err, ErrTrashFull) {
+		t.Fatalf("expected error to be %v got %v", ErrTrashFull, err)
+	}
+}
+
+func TestConfigMapTrashPurge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+
+	now := time.Date(2024, 6, 30, 10, 0, 0, 0, time.UTC)
+	cm := &v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "deleted-groups", Namespace: "default"}}
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockCoreV1.EXPECT().ConfigMaps("default").AnyTimes().Return(mockConfigMapV1)
+	mockConfigMapV1.EXPECT().Get(gomock.Any(), "deleted-groups", gomock.Any()).AnyTimes().DoAndReturn(
+		func(context.Context, string, metaV1.GetOptions) (*v1.ConfigMap, error) {
+			return cm.DeepCopy(), nil
+		},
+	)
+
+	trash := NewConfigMapTrash(NewTrashConfig(true, "deleted-groups", "default", mockCoreV1), mockTracer, mockMonitor, mockLogger)
+
+	mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(ctx context.Context, configMap *v1.ConfigMap, opts metaV1.UpdateOptions) (*v1.ConfigMap, error) {
+			cm = configMap
+			return configMap, nil
+		},
+	)
+
+	expired := &DeletedGroup{Name: "expired", DeletedAt: now.Add(-48 * time.Hour)}
+	retained := &DeletedGroup{Name: "retained", DeletedAt: now.Add(-1 * time.Hour)}
+
+	for _, group := range []*DeletedGroup{expired, retained} {
+		if err := trash.Put(context.Background(), group); err != nil {
+			t.Fatalf("expected error to be nil got %v", err)
+		}
+	}
+
+	// the first update conflicts with a concurrent purge, the ConfigMap is read again
+	mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(
+		nil, apierrors.NewConflict(v1.Resource("configmaps"), "deleted-groups", errors.New("the object has been modified")),
+	)
+	mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(ctx context.Context, configMap *v1.ConfigMap, opts metaV1.UpdateOptions) (*v1.ConfigMap, error) {
+			cm = configMap
+			return configMap, nil
+		},
+	)
+
+	purged, err := trash.Purge(context.Background(), now.Add(-24*time.Hour))
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(purged, []string{"expired"}) {
+		t.Fatalf("expected purged groups to be [expired] got %v", purged)
+	}
+
+	if _, err := trash.Get(context.Background(), expired.Name); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("expected error to be %v got %v", ErrGroupNotFound, err)
+	}
+
+	if _, err := trash.Get(context.Background(), retained.Name); err != nil {
+		t.Fatalf("expected retained group to be kept got %v", err)
+	}
+
+	// nothing left to purge, the ConfigMap is not written
+	purged, err = trash.Purge(context.Background(), now.Add(-24*time.Hour))
+
+	if err != nil || len(purged) != 0 {
+		t.Fatalf("expected no purged group and no error got %v, %v", purged, err)
+	}
+}
+
+func TestConfigMapTrashRetriesOnConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+
+	cm := &v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: "deleted-groups", Namespace: "default"}}
+	conflict := apierrors.NewConflict(v1.Resource("configmaps"), "deleted-groups", errors.New("the object has been modified"))
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockCoreV1.EXPECT().ConfigMaps("default").AnyTimes().Return(mockConfigMapV1)
+	mockConfigMapV1.EXPECT().Get(gomock.Any(), "deleted-groups", gomock.Any()).AnyTimes().DoAndReturn(
+		func(context.Context, string, metaV1.GetOptions) (*v1.ConfigMap, error) {
+			return cm.DeepCopy(), nil
+		},
+	)
+
+	update := func(ctx context.Context, configMap *v1.ConfigMap, opts metaV1.UpdateOptions) (*v1.ConfigMap, error) {
+		cm = configMap
+		return configMap, nil
+	}
+
+	trash := NewConfigMapTrash(NewTrashConfig(true, "deleted-groups", "default", mockCoreV1), mockTracer, mockMonitor, mockLogger)
+
+	group := &DeletedGroup{Name: "administrator", DeletedAt: time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)}
+
+	// a concurrent delete of another group updated the ConfigMap first, Put reads it again
+	gomock.InOrder(
+		mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, conflict),
+		mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(update),
+	)
+
+	if err := trash.Put(context.Background(), group); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if _, err := trash.Get(context.Background(), group.Name); err != nil {
+		t.Fatalf("expected group to be stashed got %v", err)
+	}
+
+	gomock.InOrder(
+		mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, conflict),
+		mockConfigMapV1.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(update),
+	)
+
+	if err := trash.Remove(context.Background(), group.Name); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if _, err := trash.Get(context.Background(), group.Name); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("expected error to be %v got %v", ErrGroupNotFound, err)
+	}
+}
+
+func TestTrashConfigDisabledByDefault(t *testing.T) {
+	var c *TrashConfig
+
+	if c.Enabled() {
+		t.Fatal("expected nil config to be disabled")
+	}
+
+	if NewTrashConfig(false, "deleted-groups", "default", nil).Enabled() {
+		t.Fatal("expected config to be disabled")
+	}
+}
@@ -50,7 +50,13 @@ func (p *PayloadValidator) Validate(ctx context.Context, method, endpoint string
 	}
 
 	if p.isUpdateGroup(method, endpoint) {
-		// TODO: @barco to implement when the UpdateGroup is implemented
+		group := new(Group)
+		if err := json.Unmarshal(body, group); err != nil {
+			p.logger.Error("Json parsing error: ", err)
+			return ctx, nil, fmt.Errorf("failed to parse JSON body")
+		}
+
+		err = p.validator.Struct(group)
 		validated = true
 	}
 
@@ -103,7 +109,8 @@ func (p *PayloadValidator) isCreateGroup(method, endpoint string) bool {
 }
 
 func (p *PayloadValidator) isUpdateGroup(method, endpoint string) bool {
-	return method == http.MethodPatch && strings.HasPrefix(endpoint, "/")
+	return method == http.MethodPatch && strings.HasPrefix(endpoint, "/") &&
+		!p.isAssignPermissions(method, endpoint) && !p.isAssignIdentities(method, endpoint)
 }
 
 func (p *PayloadValidator) isAssignRoles(method, endpoint string) bool {
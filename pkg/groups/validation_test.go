@@ -116,9 +116,8 @@ func TestValidate(t *testing.T) {
 			method:   http.MethodPatch,
 			endpoint: "/mock-id",
 			body: func() []byte {
-				id := "mock-id"
 				r := new(Group)
-				r.ID = id
+				r.Name = "mock-new-name"
 
 				marshal, _ := json.Marshal(r)
 				return marshal
@@ -229,6 +228,36 @@ func TestValidate(t *testing.T) {
 			expectedResult: validator.ValidationErrors{},
 			expectedError:  nil,
 		},
+		{
+			name:     "AssignPermissionsURN",
+			method:   http.MethodPatch,
+			endpoint: "/mock-id/entitlements",
+			body: func() []byte {
+				return []byte(`{"entitlements":["can_edit::client:okta"]}`)
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:     "AssignPermissionsEmptyURN",
+			method:   http.MethodPatch,
+			endpoint: "/mock-id/entitlements",
+			body: func() []byte {
+				return []byte(`{"entitlements":[""]}`)
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
+		{
+			name:     "AssignPermissionsNoPermissions",
+			method:   http.MethodPatch,
+			endpoint: "/mock-id/entitlements",
+			body: func() []byte {
+				return []byte(`{}`)
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
 		{
 			name:     "AssignPermissionsFailure",
 			method:   http.MethodPatch,
@@ -0,0 +1,108 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	kClient "github.com/ory/kratos-client-go"
+)
+
+// CredentialInfo is the non secret part of a credential of an identity, Devices is only set for
+// webauthn credentials, an identity has TOTP set up when a totp credential is listed
+type CredentialInfo struct {
+	Type        string           `json:"type"`
+	Identifiers []string         `json:"identifiers,omitempty"`
+	CreatedAt   *time.Time       `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time       `json:"updated_at,omitempty"`
+	Devices     []WebAuthnDevice `json:"devices,omitempty"`
+}
+
+// WebAuthnDevice is a security key or passkey registered by the identity
+type WebAuthnDevice struct {
+	ID           string     `json:"id,omitempty"`
+	DisplayName  string     `json:"display_name"`
+	AddedAt      *time.Time `json:"added_at,omitempty"`
+	Passwordless bool       `json:"passwordless"`
+}
+
+type CredentialsData struct {
+	Credentials []CredentialInfo
+	Error       *kClient.GenericError
+}
+
+// ListIdentityCredentials returns the credentials of the identity sorted by type, only the webauthn
+// configuration is requested from kratos and only the device labels are read out of it, so no
+// hash, TOTP secret, recovery code or token is ever loaded
+func (s *Service) ListIdentityCredentials(ctx context.Context, ID string) (*CredentialsData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.ListIdentityCredentials")
+	defer span.End()
+
+	identity, rr, err := s.kratos.GetIdentityExecute(
+		s.kratos.GetIdentity(ctx, ID).IncludeCredential([]string{CredentialTypeWebAuthn}),
+	)
+
+	data := new(CredentialsData)
+	data.Credentials = []CredentialInfo{}
+
+	if err != nil {
+		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+
+		return data, err
+	}
+
+	for t, c := range identity.GetCredentials() {
+		info := CredentialInfo{
+			Type:        t,
+			Identifiers: c.Identifiers,
+			CreatedAt:   c.CreatedAt,
+			UpdatedAt:   c.UpdatedAt,
+		}
+
+		if t == CredentialTypeWebAuthn {
+			info.Devices = webAuthnDevices(c.Config)
+		}
+
+		data.Credentials = append(data.Credentials, info)
+	}
+
+	sort.Slice(data.Credentials, func(i, j int) bool { return data.Credentials[i].Type < data.Credentials[j].Type })
+
+	return data, nil
+}
+
+// webAuthnDevices reads the devices out of a webauthn credential configuration, public keys,
+// user handles and authenticator data are left out
+func webAuthnDevices(config map[string]interface{}) []WebAuthnDevice {
+	devices := make([]WebAuthnDevice, 0)
+
+	credentials, _ := config["credentials"].([]interface{})
+
+	for _, c := range credentials {
+		credential, ok := c.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		device := WebAuthnDevice{}
+
+		device.ID, _ = credential["id"].(string)
+		device.DisplayName, _ = credential["display_name"].(string)
+		device.Passwordless, _ = credential["is_passwordless"].(bool)
+
+		if addedAt, ok := credential["added_at"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, addedAt); err == nil {
+				device.AddedAt = &t
+			}
+		}
+
+		devices = append(devices, device)
+	}
+
+	return devices
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	kClient "github.com/ory/kratos-client-go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+)
+
+// sampleCredentials is shaped like the credentials kratos returns with include_credential set
+const sampleCredentials = `{
+	"password": {
+		"type": "password",
+		"identifiers": ["test@example.com"],
+		"config": {"hashed_password": "$argon2id$v=19$m=65536,t=3,p=4$secret-hash"},
+		"created_at": "2024-01-01T10:00:00Z",
+		"updated_at": "2024-01-02T10:00:00Z"
+	},
+	"totp": {
+		"type": "totp",
+		"identifiers": ["test-1"],
+		"config": {"totp_url": "otpauth://totp/test?secret=SECRETSEED"},
+		"created_at": "2024-01-03T10:00:00Z"
+	},
+	"webauthn": {
+		"type": "webauthn",
+		"identifiers": ["test-1"],
+		"config": {
+			"credentials": [
+				{
+					"id": "a2V5LTE=",
+					"public_key": "cHVibGljLWtleS1zZWNyZXQ=",
+					"attestation_type": "none",
+					"authenticator": {"aaguid": "AAGUID", "sign_count": 4},
+					"display_name": "YubiKey 5",
+					"added_at": "2024-01-04T10:00:00Z",
+					"is_passwordless": false
+				},
+				{
+					"id": "a2V5LTI=",
+					"public_key": "b3RoZXItcHVibGljLWtleQ==",
+					"display_name": "Laptop passkey",
+					"added_at": "2024-01-05T10:00:00Z",
+					"is_passwordless": true
+				}
+			],
+			"user_handle": "dXNlci1oYW5kbGU="
+		},
+		"created_at": "2024-01-04T10:00:00Z"
+	}
+}`
+
+func TestListIdentityCredentialsSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	credentials := make(map[string]kClient.IdentityCredentials)
+
+	if err := json.Unmarshal([]byte(sampleCredentials), &credentials); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	identity.SetCredentials(credentials)
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			// use reflect as the include_credential parameter is not exported
+			include := reflect.ValueOf(r).FieldByName("includeCredential")
+
+			if include.IsNil() || include.Elem().Len() != 1 || include.Elem().Index(0).String() != CredentialTypeWebAuthn {
+				t.Errorf("expected only %s credentials to be included", CredentialTypeWebAuthn)
+			}
+
+			return identity, new(http.Response), nil
+		},
+	)
+
+	data, err := NewService(mockKratosIdentityAPI, mockAuthz, nil, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentityCredentials(ctx, credID)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	credentialTypes := make([]string, 0)
+
+	for _, c := range data.Credentials {
+		credentialTypes = append(credentialTypes, c.Type)
+	}
+
+	if !reflect.DeepEqual(credentialTypes, []string{CredentialTypePassword, CredentialTypeTOTP, CredentialTypeWebAuthn}) {
+		t.Fatalf("expected credentials to be sorted by type, got %v", credentialTypes)
+	}
+
+	if created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC); !data.Credentials[0].CreatedAt.Equal(created) {
+		t.Errorf("expected password created_at to be %v got %v", created, data.Credentials[0].CreatedAt)
+	}
+
+	if len(data.Credentials[1].Devices) != 0 {
+		t.Errorf("expected no devices for totp got %v", data.Credentials[1].Devices)
+	}
+
+	addedAt := time.Date(2024, 1, 5, 10, 0, 0, 0, time.UTC)
+	expected := []WebAuthnDevice{
+		{ID: "a2V5LTE=", DisplayName: "YubiKey 5", Passwordless: false},
+		{ID: "a2V5LTI=", DisplayName: "Laptop passkey", AddedAt: &addedAt, Passwordless: true},
+	}
+
+	devices := data.Credentials[2].Devices
+
+	if len(devices) != len(expected) {
+		t.Fatalf("expected %d devices got %v", len(expected), devices)
+	}
+
+	for i, d := range devices {
+		if d.ID != expected[i].ID || d.DisplayName != expected[i].DisplayName || d.Passwordless != expected[i].Passwordless {
+			t.Errorf("expected device %v got %v", expected[i], d)
+		}
+	}
+
+	if !devices[1].AddedAt.Equal(addedAt) {
+		t.Errorf("expected added_at to be %v got %v", addedAt, devices[1].AddedAt)
+	}
+
+	payload, err := json.Marshal(data.Credentials)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	for _, secret := range []string{"argon2id", "SECRETSEED", "otpauth", "public_key", "cHVibGljLWtleS1zZWNyZXQ=", "user_handle", "AAGUID", "config"} {
+		if strings.Contains(string(payload), secret) {
+			t.Errorf("expected %s not to be returned, got %s", secret, payload)
+		}
+	}
+}
+
+func TestListIdentityCredentialsFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(rr).Encode(map[string]interface{}{"error": map[string]interface{}{"code": http.StatusNotFound, "message": "id not found"}})
+
+			return nil, rr.Result(), fmt.Errorf("error")
+		},
+	)
+
+	data, err := NewService(mockKratosIdentityAPI, mockAuthz, nil, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentityCredentials(ctx, credID)
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+
+	if len(data.Credentials) != 0 {
+		t.Errorf("expected no credentials got %v", data.Credentials)
+	}
+
+	if data.Error == nil || data.Error.GetCode() != http.StatusNotFound {
+		t.Errorf("expected error code to be %v got %v", http.StatusNotFound, data.Error)
+	}
+}
+
+func TestHandleListCredentials(t *testing.T) {
+	gerr := new(kClient.GenericError)
+	gerr.SetCode(http.StatusNotFound)
+	gerr.SetReason("id not found")
+
+	tests := []struct {
+		name     string
+		data     *CredentialsData
+		err      error
+		expected int
+	}{
+		{
+			name: "success",
+			data: &CredentialsData{
+				Credentials: []CredentialInfo{
+					{Type: CredentialTypeTOTP},
+					{Type: CredentialTypeWebAuthn, Devices: []WebAuthnDevice{{DisplayName: "YubiKey 5"}}},
+				},
+			},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "kratos error",
+			data:     &CredentialsData{Credentials: []CredentialInfo{}, Error: gerr},
+			err:      fmt.Errorf("error"),
+			expected: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			credID := "test-1"
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/identities/%s/credentials", credID), nil)
+
+			mockService.EXPECT().ListIdentityCredentials(gomock.Any(), credID).Return(test.data, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			credentials, ok := rr.Data.([]interface{})
+
+			if !ok || len(credentials) != len(test.data.Credentials) {
+				t.Errorf("expected %d credentials got %v", len(test.data.Credentials), rr.Data)
+			}
+		})
+	}
+}
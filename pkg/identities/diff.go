@@ -0,0 +1,108 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+)
+
+const (
+	TraitAdded   = "added"
+	TraitChanged = "changed"
+	TraitRemoved = "removed"
+
+	// redactedTrait replaces the values of the PII traits in a diff
+	redactedTrait = "***"
+)
+
+// TraitChange is the change of a single top level trait, Old is unset for added traits and New
+// for removed ones
+type TraitChange struct {
+	Key string      `json:"key"`
+	Op  string      `json:"op"`
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// SetPIIKeys sets the traits whose values are masked in the changes returned by UpdateIdentity,
+// logging.DefaultSensitiveKeys are used when keys is empty
+func (s *Service) SetPIIKeys(keys []string) {
+	s.piiKeys = keys
+}
+
+// diffTraits compares the top level traits of before and after, nested traits are compared as a
+// whole, the changes are sorted by key and the values of the piiKeys traits are masked
+func diffTraits(before, after interface{}, piiKeys []string) []TraitChange {
+	if len(piiKeys) == 0 {
+		piiKeys = logging.DefaultSensitiveKeys
+	}
+
+	old := traitsMap(before)
+	updated := traitsMap(after)
+
+	changes := make([]TraitChange, 0)
+
+	for key, value := range updated {
+		previous, ok := old[key]
+
+		switch {
+		case !ok:
+			changes = append(changes, TraitChange{Key: key, Op: TraitAdded, New: value})
+		case !reflect.DeepEqual(previous, value):
+			changes = append(changes, TraitChange{Key: key, Op: TraitChanged, Old: previous, New: value})
+		}
+	}
+
+	for key, value := range old {
+		if _, ok := updated[key]; !ok {
+			changes = append(changes, TraitChange{Key: key, Op: TraitRemoved, Old: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	for i, change := range changes {
+		if !slices.ContainsFunc(piiKeys, func(k string) bool { return strings.EqualFold(k, change.Key) }) {
+			continue
+		}
+
+		if change.Old != nil {
+			changes[i].Old = redactedTrait
+		}
+
+		if change.New != nil {
+			changes[i].New = redactedTrait
+		}
+	}
+
+	return changes
+}
+
+// traitsMap normalizes traits through JSON so that values decoded from kratos and from a request
+// compare equal, traits that are not a JSON object are returned as an empty map
+func traitsMap(traits interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+
+	if traits == nil {
+		return m
+	}
+
+	raw, err := json.Marshal(traits)
+
+	if err != nil {
+		return m
+	}
+
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return make(map[string]interface{})
+	}
+
+	return m
+}
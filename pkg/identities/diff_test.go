@@ -0,0 +1,81 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffTraits(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   interface{}
+		after    interface{}
+		piiKeys  []string
+		expected []TraitChange
+	}{
+		{
+			name:     "unchanged traits",
+			before:   map[string]string{"name": "Joe", "team": "identity"},
+			after:    map[string]interface{}{"team": "identity", "name": "Joe"},
+			expected: []TraitChange{},
+		},
+		{
+			name:   "changed and unchanged traits",
+			before: map[string]interface{}{"team": "identity", "level": 2, "name": "Joe"},
+			after:  map[string]interface{}{"team": "platform", "level": 2.0, "name": "Joe"},
+			expected: []TraitChange{
+				{Key: "team", Op: TraitChanged, Old: "identity", New: "platform"},
+			},
+		},
+		{
+			name:   "added, removed and nested traits",
+			before: map[string]interface{}{"address": map[string]interface{}{"city": "London"}, "team": "identity"},
+			after:  map[string]interface{}{"address": map[string]interface{}{"city": "Berlin"}, "level": 3},
+			expected: []TraitChange{
+				{Key: "address", Op: TraitChanged, Old: map[string]interface{}{"city": "London"}, New: map[string]interface{}{"city": "Berlin"}},
+				{Key: "level", Op: TraitAdded, New: float64(3)},
+				{Key: "team", Op: TraitRemoved, Old: "identity"},
+			},
+		},
+		{
+			name:   "default PII traits are masked",
+			before: map[string]interface{}{"email": "joe@example.com", "team": "identity"},
+			after:  map[string]interface{}{"email": "joe.doe@example.com", "phone": "+441234", "team": "identity"},
+			expected: []TraitChange{
+				{Key: "email", Op: TraitChanged, Old: redactedTrait, New: redactedTrait},
+				{Key: "phone", Op: TraitAdded, New: redactedTrait},
+			},
+		},
+		{
+			name:    "configured PII traits are masked",
+			before:  map[string]interface{}{"email": "joe@example.com", "team": "identity"},
+			after:   map[string]interface{}{"email": "joe.doe@example.com", "team": "platform"},
+			piiKeys: []string{"Team"},
+			expected: []TraitChange{
+				{Key: "email", Op: TraitChanged, Old: "joe@example.com", New: "joe.doe@example.com"},
+				{Key: "team", Op: TraitChanged, Old: redactedTrait, New: redactedTrait},
+			},
+		},
+		{
+			name:   "no previous traits",
+			before: nil,
+			after:  map[string]interface{}{"team": "identity"},
+			expected: []TraitChange{
+				{Key: "team", Op: TraitAdded, New: "identity"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := diffTraits(test.before, test.after, test.piiKeys)
+
+			if !reflect.DeepEqual(changes, test.expected) {
+				t.Fatalf("expected changes to be %v got %v", test.expected, changes)
+			}
+		})
+	}
+}
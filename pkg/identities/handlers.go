@@ -4,13 +4,25 @@
 package identities
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	kClient "github.com/ory/kratos-client-go"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
@@ -28,10 +40,81 @@ type UpdateIdentityRequest struct {
 	kClient.UpdateIdentityBody
 }
 
+// UpdateIdentityResponse is the response of an identity update, Changes are the traits the update
+// added, changed or removed
+type UpdateIdentityResponse struct {
+	types.Response
+	Changes []TraitChange `json:"changes"`
+}
+
+// UpdateIdentityStateRequest is the payload of the state endpoint
+type UpdateIdentityStateRequest struct {
+	State string `json:"state"`
+}
+
+// CreateRecoveryLinkRequest is the optional payload of the recovery link endpoint,
+// ExpiresIn is a duration such as 30m, kratos default lifespan is used when empty
+type CreateRecoveryLinkRequest struct {
+	ExpiresIn string `json:"expires_in"`
+}
+
+// VerifyAddressRequest is the payload of the verify address endpoint, Address is one of the
+// verifiable addresses of the identity
+type VerifyAddressRequest struct {
+	Address string `json:"address"`
+}
+
+// GetIdentitiesResponse is the payload of the batch read, IDs that couldn't be read are in Errors
+type GetIdentitiesResponse struct {
+	Identities map[string]kClient.Identity       `json:"identities"`
+	Errors     map[string]GetIdentitiesErrorItem `json:"errors"`
+}
+
+// GetIdentitiesErrorItem is the outcome of an ID that couldn't be read in a batch
+type GetIdentitiesErrorItem struct {
+	Message string `json:"message,omitempty"`
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+}
+
+// CreateIdentityResponseItem is the per-entry outcome of a batch creation
+type CreateIdentityResponseItem struct {
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message,omitempty"`
+	Status  int    `json:"status"`
+}
+
+// DeleteIdentityResponseItem is the outcome of an ID in a batch deletion
+type DeleteIdentityResponseItem struct {
+	Message string `json:"message,omitempty"`
+	Status  int    `json:"status"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ImportIdentityResponseItem is the per-row outcome of a CSV import, rows are numbered
+// as in the file, the header being row 1
+type ImportIdentityResponseItem struct {
+	Row int `json:"row"`
+	CreateIdentityResponseItem
+}
+
+type importRow struct {
+	row  int
+	body kClient.CreateIdentityBody
+}
+
+// EffectivePermissionsPageToken is the decoded page_token of the effective entitlements endpoint
+type EffectivePermissionsPageToken struct {
+	Offset int64 `json:"offset"`
+}
+
 type API struct {
 	apiKey           string
 	service          ServiceInterface
+	effective        EffectivePermissionsServiceInterface
 	payloadValidator validation.PayloadValidatorInterface
+	pageSize         *types.PageSizeConfig
+	traits           *TraitsMapping
 
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
@@ -42,11 +125,42 @@ func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/identities", a.handleList)
 	mux.Get("/api/v0/identities/{id:.+}", a.handleDetail)
 	mux.Post("/api/v0/identities", a.handleCreate)
+	mux.Post("/api/v0/identities/batch", a.handleCreateBatch)
+	mux.Post("/api/v0/identities/batch-get", a.handleGetBatch)
+	mux.Post("/api/v0/identities/batch-delete", a.handleDeleteBatch)
+	mux.Post("/api/v0/identities/import", a.handleImport)
 	mux.Put("/api/v0/identities/{id:.+}", a.handleUpdate)
 	// mux.Patch("/api/v0/identities/{id:.+}", a.handlePartialUpdate)
 	mux.Delete("/api/v0/identities/{id:.+}", a.handleRemove)
 	// mux.Delete("/api/v0/identities/{id:.+}/sessions", a.handleSessionRemove)
-	// mux.Delete("/api/v0/identities/{id:.+}/credentials/{type}", a.handleCrededntialRemove)
+	mux.Get("/api/v0/identities/{id:.+}/credentials", a.handleListCredentials)
+	mux.Delete("/api/v0/identities/{id:.+}/credentials/{type}", a.handleCredentialRemove)
+	mux.Patch("/api/v0/identities/{id:.+}/state", a.handleUpdateState)
+	mux.Patch("/api/v0/identities/{id:.+}/traits", a.handlePatchTraits)
+	mux.Post("/api/v0/identities/{id:.+}/recovery-link", a.handleCreateRecoveryLink)
+	mux.Post("/api/v0/identities/{id:.+}/recovery-codes", a.handleGenerateRecoveryCodes)
+	mux.Post("/api/v0/identities/{id:.+}/verify-address", a.handleVerifyAddress)
+	mux.Delete("/api/v0/sessions/{id}", a.handleSessionDisable)
+
+	if a.effective != nil {
+		mux.Get("/api/v0/identities/{id:.+}/effective-entitlements", a.handleEffectiveEntitlements)
+	}
+}
+
+// SetEffectivePermissionsService enables the effective entitlements endpoint, needs to be called
+// before RegisterEndpoints
+func (a *API) SetEffectivePermissionsService(svc EffectivePermissionsServiceInterface) {
+	a.effective = svc
+}
+
+// SetPageSizeConfig sets the default and maximum page size of the list endpoint
+func (a *API) SetPageSizeConfig(c *types.PageSizeConfig) {
+	a.pageSize = c
+}
+
+// SetTraitsMapping sets the traits read when sorting the list endpoint by email
+func (a *API) SetTraitsMapping(m *TraitsMapping) {
+	a.traits = m
 }
 
 func (a *API) RegisterValidation(v validation.ValidationRegistryInterface) {
@@ -62,29 +176,118 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 
 	pagination := types.ParsePagination(r.URL.Query())
 
-	credID := r.URL.Query().Get("credID")
+	size, err := a.pageSize.ParseSize(r.URL.Query())
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	pagination.Size = size
+
+	filter := ListIdentitiesFilter{
+		CredID:   r.URL.Query().Get("credID"),
+		SchemaID: r.URL.Query().Get("schema_id"),
+		State:    r.URL.Query().Get("state"),
+	}
+
+	if filter.State != "" && !IsValidIdentityState(filter.State) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: fmt.Sprintf("invalid state %s, allowed values are %s and %s", filter.State, IdentityStateActive, IdentityStateInactive),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
 
-	ids, err := a.service.ListIdentities(r.Context(), pagination.Size, pagination.PageToken, credID)
+	// only the fetched page is sorted, see IdentitySort
+	order, err := ParseIdentitySort(r.URL.Query().Get("sort"), a.traits)
 
 	if err != nil {
-		rr := a.error(ids.Error)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
 
-		w.WriteHeader(rr.Status)
-		json.NewEncoder(w).Encode(rr)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+
+	if query != "" && (filter.CredID != "" || !filter.IsEmpty()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "q can't be combined with credID, schema_id or state",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	// ungrouped=true scans kratos pages server side like q, see ListUngroupedIdentities
+	ungrouped, _ := strconv.ParseBool(r.URL.Query().Get("ungrouped"))
+
+	if ungrouped && (query != "" || filter.CredID != "" || !filter.IsEmpty()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "ungrouped can't be combined with q, credID, schema_id or state",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	var ids *IdentityData
+
+	if ungrouped {
+		ids, err = a.service.ListUngroupedIdentities(r.Context(), pagination.Size, pagination.PageToken)
+	} else if query != "" {
+		ids, err = a.service.SearchIdentities(r.Context(), query, pagination.Size, pagination.PageToken)
+	} else {
+		ids, err = a.service.ListIdentities(r.Context(), pagination.Size, pagination.PageToken, filter)
+	}
+
+	if err != nil {
+		a.writeError(w, ids.Error)
 
 		return
 	}
 
+	order.Sort(ids.Identities)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data: ids.Identities,
+			Data: ProjectTraits(a.redact(r.Context(), ids.Identities), a.traitFields(r)),
 			Meta: &types.Pagination{
 				NavigationTokens: types.NavigationTokens{
 					Next: ids.Tokens.Next,
 					Prev: ids.Tokens.Prev,
 				},
-				Size: pagination.Size,
+				Size:  pagination.Size,
+				Total: ids.Total,
 			},
 			Message: "List of identities",
 			Status:  http.StatusOK,
@@ -99,18 +302,28 @@ func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 	ids, err := a.service.GetIdentity(r.Context(), credID)
 
 	if err != nil {
-		rr := a.error(ids.Error)
-
-		w.WriteHeader(rr.Status)
-		json.NewEncoder(w).Encode(rr)
+		a.writeError(w, ids.Error)
 
 		return
 	}
 
+	if len(ids.Identities) > 0 {
+		etag := IdentityETag(&ids.Identities[0])
+		w.Header().Set("ETag", etag)
+
+		// same version as the If-Match of the updates, an unchanged identity isn't sent again
+		if ifNoneMatch(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Type")
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data:    ids.Identities,
+			Data:    a.redact(r.Context(), ids.Identities),
 			Message: "Identity detail",
 			Status:  http.StatusOK,
 		},
@@ -129,6 +342,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -142,6 +356,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -149,13 +364,23 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	if identity.MetadataAdmin != nil && !authorization.IsAdminFromContext(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "metadata_admin can only be set by admins",
+				Status:  http.StatusForbidden,
+				Code:    types.CodeForbidden,
+			},
+		)
+
+		return
+	}
+
 	ids, err := a.service.CreateIdentity(r.Context(), &identity.CreateIdentityBody)
 
 	if err != nil {
-		rr := a.error(ids.Error)
-
-		w.WriteHeader(rr.Status)
-		json.NewEncoder(w).Encode(rr)
+		a.writeError(w, ids.Error)
 
 		return
 	}
@@ -168,6 +393,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: err.Error(),
 				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
 			},
 		)
 
@@ -177,18 +403,16 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data:    ids.Identities,
+			Data:    a.redact(r.Context(), ids.Identities),
 			Message: "Created identity",
 			Status:  http.StatusCreated,
 		},
 	)
 }
 
-func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
+func (a *API) handleCreateBatch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	credID := chi.URLParam(r, "id")
-
 	defer r.Body.Close()
 	body, err := io.ReadAll(r.Body)
 
@@ -198,19 +422,21 @@ func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
 		return
 	}
 
-	identity := new(UpdateIdentityRequest)
-	if err := json.Unmarshal(body, identity); err != nil {
+	identities := make([]CreateIdentityRequest, 0)
+	if err := json.Unmarshal(body, &identities); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -218,68 +444,949 @@ func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
 
 	}
 
-	ids, err := a.service.UpdateIdentity(r.Context(), credID, &identity.UpdateIdentityBody)
+	bodies := make([]kClient.CreateIdentityBody, 0, len(identities))
+	setsMetadataAdmin := false
+
+	for _, identity := range identities {
+		bodies = append(bodies, identity.CreateIdentityBody)
+		setsMetadataAdmin = setsMetadataAdmin || identity.MetadataAdmin != nil
+	}
+
+	if setsMetadataAdmin && !authorization.IsAdminFromContext(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "metadata_admin can only be set by admins",
+				Status:  http.StatusForbidden,
+				Code:    types.CodeForbidden,
+			},
+		)
+
+		return
+	}
+
+	results, err := a.service.CreateIdentities(r.Context(), bodies)
 
 	if err != nil {
-		rr := a.error(ids.Error)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
 
-		w.WriteHeader(rr.Status)
-		json.NewEncoder(w).Encode(rr)
+		return
+	}
+
+	items := make([]CreateIdentityResponseItem, 0, len(results))
+
+	for _, result := range results {
+		item := CreateIdentityResponseItem{Status: http.StatusCreated}
+
+		if result.Error != nil {
+			item = a.errorItem(result.Error)
+		}
+
+		// identity can be set together with an error if only the follow up steps failed
+		if result.Identity != nil {
+			item.ID = result.Identity.Id
+		}
+
+		items = append(items, item)
+	}
+
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    items,
+			Message: "Batch identities creation",
+			Status:  http.StatusMultiStatus,
+		},
+	)
+}
+
+func (a *API) handleGetBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	IDs := make([]string, 0)
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&IDs); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	identities, errs, err := a.service.GetIdentities(r.Context(), IDs)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
 
 		return
 	}
 
+	response := GetIdentitiesResponse{
+		Identities: make(map[string]kClient.Identity, len(identities)),
+		Errors:     make(map[string]GetIdentitiesErrorItem, len(errs)),
+	}
+
+	for ID, identity := range identities {
+		response.Identities[ID] = a.redact(r.Context(), []kClient.Identity{identity})[0]
+	}
+
+	for ID, e := range errs {
+		rr := a.error(e)
+
+		response.Errors[ID] = GetIdentitiesErrorItem{Message: rr.Message, Status: rr.Status, Code: rr.Code}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data:    ids.Identities,
-			Message: "Updated identity",
+			Data:    response,
+			Message: "Identities batch read",
 			Status:  http.StatusOK,
 		},
 	)
 }
 
-func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
+func (a *API) handleDeleteBatch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	credID := chi.URLParam(r, "id")
 
-	identities, err := a.service.DeleteIdentity(r.Context(), credID)
+	IDs := make([]string, 0)
 
-	if err != nil {
-		rr := a.error(identities.Error)
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&IDs); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
 
-		w.WriteHeader(rr.Status)
-		json.NewEncoder(w).Encode(rr)
+		return
+	}
+
+	results, err := a.service.DeleteIdentities(r.Context(), IDs)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
 
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	items := make(map[string]DeleteIdentityResponseItem, len(results))
+
+	for ID, result := range results {
+		if result.Error == nil {
+			items[ID] = DeleteIdentityResponseItem{Status: http.StatusOK}
+
+			continue
+		}
+
+		rr := a.error(result.Error)
+
+		items[ID] = DeleteIdentityResponseItem{Message: rr.Message, Status: rr.Status, Code: rr.Code}
+	}
+
+	w.WriteHeader(http.StatusMultiStatus)
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data:    identities.Identities,
-			Message: "Identity Deleted",
-			Status:  http.StatusOK,
+			Data:    items,
+			Message: "Batch identities deletion",
+			Status:  http.StatusMultiStatus,
 		},
 	)
 }
 
-// TODO @shipperizer encapsulate kClient.GenericError into a service error to remove library dependency
-func (a *API) error(e *kClient.GenericError) types.Response {
-	r := types.Response{
-		Status: http.StatusInternalServerError,
+func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "text/csv" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Request payload must be text/csv",
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    types.CodeUnsupportedMediaType,
+			},
+		)
+
+		return
 	}
 
-	if e.Reason != nil {
-		r.Message = *e.Reason
+	schemaID := r.URL.Query().Get("schema_id")
+
+	if schemaID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "schema_id query parameter is required",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
 	}
 
-	if e.Code != nil {
-		r.Status = int(*e.Code)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxImportSize))
+
+	if err != nil {
+		status := http.StatusBadRequest
+		message := "Error parsing request payload"
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+			message = fmt.Sprintf("Request payload exceeds %v bytes", MaxImportSize)
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: message,
+				Status:  status,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
 	}
 
-	return r
+	rows, items, err := a.parseImport(body, schemaID)
 
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	// go through the batch path, chunking to respect its size limit
+	for start := 0; start < len(rows); start += MaxBatchSize {
+		chunk := rows[start:min(start+MaxBatchSize, len(rows))]
+
+		bodies := make([]kClient.CreateIdentityBody, 0, len(chunk))
+
+		for _, row := range chunk {
+			bodies = append(bodies, row.body)
+		}
+
+		results, err := a.service.CreateIdentities(r.Context(), bodies)
+
+		for i, row := range chunk {
+			item := ImportIdentityResponseItem{Row: row.row}
+
+			switch {
+			case err != nil:
+				item.CreateIdentityResponseItem = CreateIdentityResponseItem{Message: err.Error(), Status: http.StatusInternalServerError}
+			case results[i].Error != nil:
+				item.CreateIdentityResponseItem = a.errorItem(results[i].Error)
+			default:
+				item.CreateIdentityResponseItem = CreateIdentityResponseItem{Status: http.StatusCreated}
+			}
+
+			// identity can be set together with an error if only the follow up steps failed
+			if err == nil && results[i].Identity != nil {
+				item.ID = results[i].Identity.Id
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Row < items[j].Row })
+
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    items,
+			Message: "Identities import",
+			Status:  http.StatusMultiStatus,
+		},
+	)
+}
+
+// parseImport reads the CSV header as trait names and builds an identity for each row,
+// malformed rows are reported back instead of aborting the whole import
+func (a *API) parseImport(data []byte, schemaID string) ([]importRow, []ImportIdentityResponseItem, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+
+		if header[i] == "" {
+			return nil, nil, fmt.Errorf("CSV header has an empty column name at position %v", i+1)
+		}
+	}
+
+	rows := make([]importRow, 0)
+	items := make([]ImportIdentityResponseItem, 0)
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("failed to read CSV: %v", err)
+			}
+
+			items = append(items, a.importErrorItem(parseErr.StartLine, parseErr.Err.Error()))
+
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if len(record) != len(header) {
+			items = append(items, a.importErrorItem(line, fmt.Sprintf("expected %v fields, got %v", len(header), len(record))))
+
+			continue
+		}
+
+		traits := make(map[string]interface{})
+
+		for i, value := range record {
+			if value = strings.TrimSpace(value); value != "" {
+				traits[header[i]] = value
+			}
+		}
+
+		if len(traits) == 0 {
+			items = append(items, a.importErrorItem(line, "row has no traits"))
+
+			continue
+		}
+
+		rows = append(rows, importRow{row: line, body: *kClient.NewCreateIdentityBody(schemaID, traits)})
+	}
+
+	return rows, items, nil
+}
+
+func (a *API) importErrorItem(row int, message string) ImportIdentityResponseItem {
+	return ImportIdentityResponseItem{
+		Row: row,
+		CreateIdentityResponseItem: CreateIdentityResponseItem{
+			Message: message,
+			Status:  http.StatusBadRequest,
+		},
+	}
+}
+
+func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	credID := chi.URLParam(r, "id")
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing request payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	identity := new(UpdateIdentityRequest)
+	if err := json.Unmarshal(body, identity); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+
+	}
+
+	if identity.MetadataAdmin != nil && !authorization.IsAdminFromContext(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "metadata_admin can only be set by admins",
+				Status:  http.StatusForbidden,
+				Code:    types.CodeForbidden,
+			},
+		)
+
+		return
+	}
+
+	// If-Match is optional, without it the update goes through unconditionally
+	ids, err := a.service.UpdateIdentity(r.Context(), credID, &identity.UpdateIdentityBody, r.Header.Get("If-Match"))
+
+	if err != nil {
+		a.writeError(w, ids.Error)
+
+		return
+	}
+
+	if len(ids.Identities) > 0 {
+		w.Header().Set("ETag", IdentityETag(&ids.Identities[0]))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		UpdateIdentityResponse{
+			Response: types.Response{
+				Data:    a.redact(r.Context(), ids.Identities),
+				Message: "Updated identity",
+				Status:  http.StatusOK,
+			},
+			Changes: ids.Changes,
+		},
+	)
+}
+
+func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	credID := chi.URLParam(r, "id")
+
+	identities, err := a.service.DeleteIdentity(r.Context(), credID)
+
+	if err != nil {
+		a.writeError(w, identities.Error)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    a.redact(r.Context(), identities.Identities),
+			Message: "Identity Deleted",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleSessionDisable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	sessionID := chi.URLParam(r, "id")
+
+	data, err := a.service.DeleteSession(r.Context(), sessionID)
+
+	if err != nil {
+		rr := a.error(data.Error)
+
+		// the kratos 404 refers to the session, not to an identity
+		if rr.Status == http.StatusNotFound {
+			rr.Code = types.CodeSessionNotFound
+		}
+
+		w.WriteHeader(rr.Status)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: "Session disabled",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleListCredentials(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	credID := chi.URLParam(r, "id")
+
+	credentials, err := a.service.ListIdentityCredentials(r.Context(), credID)
+
+	if err != nil {
+		a.writeError(w, credentials.Error)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    credentials.Credentials,
+			Message: "Identity Credentials",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleCredentialRemove(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	credID := chi.URLParam(r, "id")
+	credentialType := chi.URLParam(r, "type")
+
+	identities, err := a.service.DeleteIdentityCredential(r.Context(), credID, credentialType)
+
+	if err != nil {
+		a.writeError(w, identities.Error)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    a.redact(r.Context(), identities.Identities),
+			Message: "Identity Credential Deleted",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleUpdateState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	credID := chi.URLParam(r, "id")
+
+	// only honoured when deactivating
+	revokeSessions, _ := strconv.ParseBool(r.URL.Query().Get("revoke_sessions"))
+
+	state := new(UpdateIdentityStateRequest)
+
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(state); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	identities, err := a.service.SetIdentityState(r.Context(), credID, state.State, revokeSessions)
+
+	if err != nil {
+		a.writeError(w, identities.Error)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    a.redact(r.Context(), identities.Identities),
+			Message: fmt.Sprintf("Identity state set to %s", state.State),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handlePatchTraits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json-patch+json" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Request payload must be application/json-patch+json",
+				Status:  http.StatusUnsupportedMediaType,
+				Code:    types.CodeUnsupportedMediaType,
+			},
+		)
+
+		return
+	}
+
+	ID := chi.URLParam(r, "id")
+
+	patches := make([]kClient.JsonPatch, 0)
+
+	if err := json.NewDecoder(r.Body).Decode(&patches); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	identities, err := a.service.PatchIdentityTraits(r.Context(), ID, patches)
+
+	if err != nil {
+		a.writeError(w, identities.Error)
+
+		return
+	}
+
+	w.Header().Set("ETag", IdentityETag(&identities.Identities[0]))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    a.redact(r.Context(), identities.Identities),
+			Message: "Updated identity traits",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+// TODO @shipperizer encapsulate kClient.GenericError into a service error to remove library dependency
+func (a *API) handleCreateRecoveryLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ID := chi.URLParam(r, "id")
+
+	request := new(CreateRecoveryLinkRequest)
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+
+	// the body is optional, without it kratos uses its configured lifespan
+	if err == nil && len(bytes.TrimSpace(body)) > 0 {
+		err = json.Unmarshal(body, request)
+	}
+
+	var expiresIn time.Duration
+
+	if err == nil && request.ExpiresIn != "" {
+		expiresIn, err = time.ParseDuration(request.ExpiresIn)
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload, expires_in must be a duration like 30m or 2h",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	link, err := a.service.CreateRecoveryLink(r.Context(), ID, expiresIn)
+
+	if err != nil {
+		a.writeError(w, link.Error)
+
+		return
+	}
+
+	// the link carries a recovery token, never cache it
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    link.RecoveryLink,
+			Message: "Recovery link created",
+			Status:  http.StatusCreated,
+		},
+	)
+}
+
+// handleGenerateRecoveryCodes is not implemented as kratos can't issue lookup secrets on behalf of
+// a user, its admin API only imports password and oidc credentials and the lookup_secret ones are
+// set up by the user through a settings flow, the recovery link endpoint covers break-glass access
+func (a *API) handleGenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ID := chi.URLParam(r, "id")
+
+	w.WriteHeader(http.StatusNotImplemented)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: fmt.Sprintf("Recovery codes can't be issued for identity %s, kratos only lets users set up lookup secrets themselves, use the recovery link endpoint instead", ID),
+			Status:  http.StatusNotImplemented,
+			Code:    types.CodeNotImplemented,
+		},
+	)
+}
+
+// TODO @shipperizer encapsulate kClient.GenericError into a service error to remove library dependency
+func (a *API) handleVerifyAddress(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ID := chi.URLParam(r, "id")
+
+	// verifying on behalf of a user skips the ownership proof of the address, admins only
+	if !authorization.IsAdminFromContext(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "only admins can verify addresses",
+				Status:  http.StatusForbidden,
+				Code:    types.CodeForbidden,
+			},
+		)
+
+		return
+	}
+
+	request := new(VerifyAddressRequest)
+
+	defer r.Body.Close()
+	err := json.NewDecoder(r.Body).Decode(request)
+
+	if err != nil || strings.TrimSpace(request.Address) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload, address is required",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	ids, err := a.service.VerifyAddress(r.Context(), ID, strings.TrimSpace(request.Address))
+
+	if err != nil {
+		a.writeError(w, ids.Error)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    a.redact(r.Context(), ids.Identities),
+			Message: "Verified identity address",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleEffectiveEntitlements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ID := chi.URLParam(r, "id")
+
+	pagination := types.ParsePagination(r.URL.Query())
+	offset := a.offsetDecode(pagination.PageToken)
+
+	permissions, err := a.effective.GetEffectivePermissions(r.Context(), ID)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	total := int64(len(permissions))
+	start := min(offset, total)
+	end := min(start+pagination.Size, total)
+
+	meta := &types.Pagination{Size: pagination.Size, Total: &total}
+
+	if end < total {
+		meta.Next = a.offsetEncode(end)
+	}
+
+	if start > 0 {
+		meta.Prev = a.offsetEncode(max(start-pagination.Size, 0))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    permissions[start:end],
+			Meta:    meta,
+			Message: "List of effective entitlements",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) offsetEncode(offset int64) string {
+	token, err := json.Marshal(EffectivePermissionsPageToken{Offset: offset})
+
+	if err != nil {
+		a.logger.Warnf("bad page token encoding, defaulting to an empty one: %s", err)
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+func (a *API) offsetDecode(pageToken string) int64 {
+	if pageToken == "" {
+		return 0
+	}
+
+	pt := new(EffectivePermissionsPageToken)
+	rawPt, err := base64.RawURLEncoding.DecodeString(pageToken)
+
+	if err == nil {
+		err = json.Unmarshal(rawPt, pt)
+	}
+
+	if err != nil || pt.Offset < 0 {
+		a.logger.Warnf("bad page token, defaulting to the first page: %v", err)
+		return 0
+	}
+
+	return pt.Offset
+}
+
+// redact strips metadata_admin from the identities unless the principal is an admin
+// traitFields parses the comma separated list of traits requested with the fields query parameter
+func (a *API) traitFields(r *http.Request) []string {
+	fields := make([]string, 0)
+
+	for _, field := range strings.Split(r.URL.Query().Get("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func (a *API) redact(ctx context.Context, identities []kClient.Identity) []kClient.Identity {
+	if authorization.IsAdminFromContext(ctx) {
+		return identities
+	}
+
+	redacted := make([]kClient.Identity, 0, len(identities))
+
+	for _, identity := range identities {
+		identity.MetadataAdmin = nil
+		redacted = append(redacted, identity)
+	}
+
+	return redacted
+}
+
+func (a *API) error(e *kClient.GenericError) types.Response {
+	r := types.Response{
+		Status: http.StatusInternalServerError,
+	}
+
+	if e.Reason != nil {
+		r.Message = *e.Reason
+	}
+
+	if e.Code != nil {
+		r.Status = int(*e.Code)
+	}
+
+	r.Code = a.errorCode(r.Status)
+
+	// failed traits validation lists every offending JSON path
+	if traitsErrors, ok := e.Details[traitsErrorsDetail].([]TraitsError); ok {
+		r.Code = types.CodeIdentityInvalidTraits
+		r.Data = traitsErrors
+	}
+
+	return r
+
+}
+
+// errorCode maps the status of a kratos error to the code returned to clients
+// writeError sends the response matching e, the Retry-After header kratos sent along with a 429 or a
+// 503 is passed on to the client
+func (a *API) writeError(w http.ResponseWriter, e *kClient.GenericError) {
+	rr := a.error(e)
+
+	if retryAfter, ok := e.Details[retryAfterDetail].(string); ok {
+		w.Header().Set("Retry-After", retryAfter)
+	}
+
+	w.WriteHeader(rr.Status)
+	json.NewEncoder(w).Encode(rr)
+}
+
+func (a *API) errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return types.CodeIdentityInvalid
+	case http.StatusForbidden:
+		return types.CodeForbidden
+	case http.StatusNotFound:
+		return types.CodeIdentityNotFound
+	case http.StatusConflict:
+		return types.CodeIdentityConflict
+	case http.StatusPreconditionFailed:
+		return types.CodeIdentityPreconditionFailed
+	case http.StatusTooManyRequests:
+		return types.CodeUpstreamRateLimited
+	case http.StatusServiceUnavailable:
+		return types.CodeUpstreamUnavailable
+	default:
+		return types.CodeInternal
+	}
+}
+
+func (a *API) errorItem(e *kClient.GenericError) CreateIdentityResponseItem {
+	rr := a.error(e)
+
+	return CreateIdentityResponseItem{
+		Message: rr.Message,
+		Status:  rr.Status,
+	}
 }
 
 func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
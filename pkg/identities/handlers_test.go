@@ -5,6 +5,7 @@ package identities
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,10 +14,14 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
 	gomock "go.uber.org/mock/gomock"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 
 	kClient "github.com/ory/kratos-client-go"
@@ -49,7 +54,7 @@ func TestHandleListSuccess(t *testing.T) {
 	values.Add("size", "100")
 	req.URL.RawQuery = values.Encode()
 
-	mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", "").Return(
+	mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", ListIdentitiesFilter{}).Return(
 		&IdentityData{
 			Identities: identities,
 			Tokens: types.NavigationTokens{
@@ -115,6 +120,281 @@ func TestHandleListSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleListTotal(t *testing.T) {
+	total := int64(42)
+
+	tests := []struct {
+		name  string
+		total *int64
+	}{
+		{name: "with total", total: &total},
+		{name: "without total", total: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+
+			mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", ListIdentitiesFilter{}).Return(
+				&IdentityData{Identities: make([]kClient.Identity, 0), Total: test.total},
+				nil,
+			)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			// decode in a map to tell apart a missing total from a zero value
+			rr := struct {
+				Meta map[string]interface{} `json:"_meta"`
+			}{}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			value, ok := rr.Meta["total"]
+
+			if test.total == nil && ok {
+				t.Fatalf("expected total to be absent, got %v", value)
+			}
+
+			if test.total != nil && (!ok || value.(float64) != float64(*test.total)) {
+				t.Fatalf("expected total to be %v, got %v", *test.total, value)
+			}
+		})
+	}
+}
+
+func TestHandleListFields(t *testing.T) {
+	traits := map[string]interface{}{
+		"email": "joe@example.com",
+		"name":  map[string]interface{}{"first": "Joe", "last": "Doe"},
+		"phone": "555-0100",
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "no fields",
+			query:    "",
+			expected: traits,
+		},
+		{
+			name:     "email and name",
+			query:    "?fields=email,name",
+			expected: map[string]interface{}{"email": "joe@example.com", "name": map[string]interface{}{"first": "Joe", "last": "Doe"}},
+		},
+		{
+			name:     "nested and unknown",
+			query:    "?fields=name.first,%20unknown,,address.city",
+			expected: map[string]interface{}{"name": map[string]interface{}{"first": "Joe"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities"+test.query, nil)
+
+			identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", traits)
+
+			mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", ListIdentitiesFilter{}).Return(
+				&IdentityData{Identities: []kClient.Identity{*identity}},
+				nil,
+			)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+			}
+
+			rr := struct {
+				Data []kClient.Identity `json:"data"`
+			}{}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(rr.Data) != 1 || rr.Data[0].Id != "test" {
+				t.Fatalf("expected the identity to be returned got %v", rr.Data)
+			}
+
+			if !reflect.DeepEqual(rr.Data[0].Traits, test.expected) {
+				t.Errorf("expected traits to be %v got %v", test.expected, rr.Data[0].Traits)
+			}
+		})
+	}
+}
+
+func TestHandleListSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+		status   int
+	}{
+		{
+			name:     "kratos order",
+			query:    "",
+			expected: []string{"b", "c", "a"},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "ascending email",
+			query:    "?sort=email",
+			expected: []string{"a", "b", "c"},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "descending creation",
+			query:    "?sort=-created_at",
+			expected: []string{"c", "a", "b"},
+			status:   http.StatusOK,
+		},
+		{
+			name:   "unknown field",
+			query:  "?sort=-password",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities"+test.query, nil)
+
+			now := time.Now()
+			identities := make([]kClient.Identity, 0)
+
+			for _, i := range []struct {
+				id      string
+				email   string
+				created time.Time
+			}{
+				{"b", "Bob@example.com", now.Add(-2 * time.Hour)},
+				{"c", "carl@example.com", now},
+				{"a", "alice@example.com", now.Add(-time.Hour)},
+			} {
+				identity := kClient.NewIdentity(i.id, "test.json", "https://test.com/test.json", map[string]interface{}{"email": i.email})
+				identity.SetCreatedAt(i.created)
+				identities = append(identities, *identity)
+			}
+
+			if test.status == http.StatusOK {
+				mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", ListIdentitiesFilter{}).Return(
+					&IdentityData{Identities: identities},
+					nil,
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			rr := struct {
+				Data []kClient.Identity `json:"data"`
+			}{}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			ids := make([]string, 0, len(rr.Data))
+
+			for _, identity := range rr.Data {
+				ids = append(ids, identity.Id)
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("expected identities to be %v got %v", test.expected, ids)
+			}
+		})
+	}
+}
+
+func TestHandleListFailsWithInvalidState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+	values := req.URL.Query()
+	values.Add("schema_id", "test.json")
+	values.Add("state", "suspended")
+	req.URL.RawQuery = values.Encode()
+
+	mockService.EXPECT().ListIdentities(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected HTTP status code 400 got %v", res.StatusCode)
+	}
+}
+
 func TestHandleListFailAndPropagatesKratosError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -134,7 +414,7 @@ func TestHandleListFailAndPropagatesKratosError(t *testing.T) {
 	gerr.SetMessage("teapot error")
 	gerr.SetReason("teapot is broken")
 
-	mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", "").Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, fmt.Errorf("error"))
+	mockService.EXPECT().ListIdentities(gomock.Any(), int64(100), "", ListIdentitiesFilter{}).Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, fmt.Errorf("error"))
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
@@ -202,6 +482,10 @@ func TestHandleDetailSuccess(t *testing.T) {
 		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
 	}
 
+	if etag := res.Header.Get("ETag"); etag != IdentityETag(identity) {
+		t.Errorf("expected ETag header to be %s got %s", IdentityETag(identity), etag)
+	}
+
 	rr := new(types.Response)
 	if err := json.Unmarshal(data, rr); err != nil {
 		t.Errorf("expected error to be nil got %v", err)
@@ -243,6 +527,71 @@ func TestHandleDetailSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleDetailIfNoneMatch(t *testing.T) {
+	identity := kClient.NewIdentity("test-1", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	identity.SetUpdatedAt(time.Now())
+
+	etag := IdentityETag(identity)
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    int
+	}{
+		{name: "matching", ifNoneMatch: etag, expected: http.StatusNotModified},
+		{name: "matching in list", ifNoneMatch: `W/"stale", ` + etag, expected: http.StatusNotModified},
+		{name: "matching strong form", ifNoneMatch: strings.TrimPrefix(etag, "W/"), expected: http.StatusNotModified},
+		{name: "not matching", ifNoneMatch: `W/"stale"`, expected: http.StatusOK},
+		{name: "missing", ifNoneMatch: "", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities/test-1", nil)
+
+			if test.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", test.ifNoneMatch)
+			}
+
+			mockService.EXPECT().GetIdentity(gomock.Any(), "test-1").Return(&IdentityData{Identities: []kClient.Identity{*identity}}, nil)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, _ := io.ReadAll(res.Body)
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+
+			if res.Header.Get("ETag") != etag {
+				t.Errorf("expected ETag header to be %s got %s", etag, res.Header.Get("ETag"))
+			}
+
+			if test.expected == http.StatusNotModified && len(data) != 0 {
+				t.Errorf("expected no body got %s", data)
+			}
+
+			if test.expected == http.StatusOK && len(data) == 0 {
+				t.Errorf("expected the identity in the body")
+			}
+		})
+	}
+}
+
 func TestHandleDetailFailAndPropagatesKratosError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -292,6 +641,64 @@ func TestHandleDetailFailAndPropagatesKratosError(t *testing.T) {
 	if rr.Status != int(*gerr.Code) {
 		t.Errorf("expected code to be %v got %v", *gerr.Code, rr.Status)
 	}
+
+	if rr.Code != types.CodeIdentityNotFound {
+		t.Errorf("expected error code to be %s got %s", types.CodeIdentityNotFound, rr.Code)
+	}
+}
+
+func TestHandleDetailPropagatesKratosRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/identities/test-1", nil)
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(gomock.Any(), "test-1").Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Retry-After", "30")
+			rr.WriteHeader(http.StatusTooManyRequests)
+
+			return nil, rr.Result(), fmt.Errorf("429 Too Many Requests")
+		},
+	)
+
+	svc := NewService(mockKratosIdentityAPI, mockAuthz, nil, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(svc, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected HTTP status code 429 got %v", res.StatusCode)
+	}
+
+	if res.Header.Get("Retry-After") != "30" {
+		t.Fatalf("expected Retry-After to be 30 got %q", res.Header.Get("Retry-After"))
+	}
+
+	rr := new(types.Response)
+	if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if rr.Code != types.CodeUpstreamRateLimited {
+		t.Errorf("expected error code to be %s got %s", types.CodeUpstreamRateLimited, rr.Code)
+	}
 }
 
 func TestHandleCreateSuccess(t *testing.T) {
@@ -430,6 +837,69 @@ func TestHandleCreateFailAndPropagatesKratosError(t *testing.T) {
 	}
 }
 
+func TestHandleCreateInvalidTraits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	identityBody := kClient.NewCreateIdentityBodyWithDefaults()
+	identityBody.SchemaId = "test.json"
+	identityBody.Traits = map[string]interface{}{"name": 1}
+
+	payload, _ := json.Marshal(identityBody)
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/identities", bytes.NewReader(payload))
+
+	verr := &TraitsValidationError{
+		Errors: []TraitsError{
+			{Path: "/traits/email", Message: "is required"},
+			{Path: "/traits/name", Message: "must be of type string"},
+		},
+	}
+
+	gerr := kClient.NewGenericErrorWithDefaults()
+	gerr.SetCode(http.StatusBadRequest)
+	gerr.SetReason(verr.Error())
+	gerr.SetDetails(map[string]interface{}{traitsErrorsDetail: verr.Errors})
+
+	mockService.EXPECT().CreateIdentity(gomock.Any(), gomock.Any()).Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, verr)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected HTTP status code 400 got %v", res.StatusCode)
+	}
+
+	type Response struct {
+		Data   []TraitsError `json:"data"`
+		Code   string        `json:"code"`
+		Status int           `json:"status"`
+	}
+
+	rr := new(Response)
+	if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if rr.Code != types.CodeIdentityInvalidTraits {
+		t.Errorf("expected code to be %s got %s", types.CodeIdentityInvalidTraits, rr.Code)
+	}
+
+	if !reflect.DeepEqual(rr.Data, verr.Errors) {
+		t.Errorf("expected data to be %v got %v", verr.Errors, rr.Data)
+	}
+}
+
 func TestHandleCreateFailBadRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -469,7 +939,7 @@ func TestHandleCreateFailBadRequest(t *testing.T) {
 	}
 }
 
-func TestHandleUpdateSuccess(t *testing.T) {
+func TestHandleCreateBatchPartialSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -478,19 +948,24 @@ func TestHandleUpdateSuccess(t *testing.T) {
 	mockMonitor := NewMockMonitorInterface(ctrl)
 	mockService := NewMockServiceInterface(ctrl)
 
-	credID := "test-1"
-	identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
-	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	identityBody := kClient.NewCreateIdentityBodyWithDefaults()
 	identityBody.SchemaId = identity.SchemaId
-	identityBody.SetState("active")
 	identityBody.Traits = map[string]interface{}{"name": "name"}
 	identityBody.AdditionalProperties = map[string]interface{}{"name": "name"}
 
-	payload, _ := json.Marshal(identityBody)
+	payload, _ := json.Marshal([]*kClient.CreateIdentityBody{identityBody, identityBody})
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/batch", bytes.NewReader(payload))
 
-	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v0/identities/%s", credID), bytes.NewReader(payload))
+	gerr := new(kClient.GenericError)
+	gerr.SetCode(http.StatusConflict)
+	gerr.SetMessage("id already exists")
+	gerr.SetReason("conflict")
 
-	mockService.EXPECT().UpdateIdentity(gomock.Any(), credID, identityBody).Return(&IdentityData{Identities: []kClient.Identity{*identity}}, nil)
+	mockService.EXPECT().CreateIdentities(gomock.Any(), []kClient.CreateIdentityBody{*identityBody, *identityBody}).Return(
+		[]CreateIdentityResult{{Identity: identity}, {Error: gerr}},
+		nil,
+	)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
@@ -506,52 +981,214 @@ func TestHandleUpdateSuccess(t *testing.T) {
 		t.Errorf("expected error to be nil got %v", err)
 	}
 
-	if res.StatusCode != http.StatusOK {
-		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	if res.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("expected HTTP status code 207 got %v", res.StatusCode)
 	}
 
-	rr := new(types.Response)
+	rr := new(struct {
+		Data   []CreateIdentityResponseItem `json:"data"`
+		Status int                          `json:"status"`
+	})
+
 	if err := json.Unmarshal(data, rr); err != nil {
 		t.Errorf("expected error to be nil got %v", err)
 	}
 
-	IDs := make([]kClient.Identity, 0)
+	expected := []CreateIdentityResponseItem{
+		{ID: identity.Id, Status: http.StatusCreated},
+		{Message: *gerr.Reason, Status: http.StatusConflict},
+	}
 
-	// types.Response.Data is an interface, this means that all needs to be cast step by step
-	for _, ii := range rr.Data.([]interface{}) {
-		identity := new(kClient.Identity)
+	if !reflect.DeepEqual(rr.Data, expected) {
+		t.Fatalf("invalid result, expected: %v, got: %v", expected, rr.Data)
+	}
+}
 
-		i, ok := ii.(map[string]interface{})
+func TestHandleGetBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		IDs        []string
+		serviceErr error
+		status     int
+	}{
+		{
+			name:    "found and missing",
+			payload: `["joe", "unknown"]`,
+			IDs:     []string{"joe", "unknown"},
+			status:  http.StatusOK,
+		},
+		{
+			name:       "service rejects the IDs",
+			payload:    `["joe"]`,
+			IDs:        []string{"joe"},
+			serviceErr: fmt.Errorf("too many identity IDs passed"),
+			status:     http.StatusBadRequest,
+		},
+		{
+			name:    "bad payload",
+			payload: `{"id": "joe"}`,
+			status:  http.StatusBadRequest,
+		},
+	}
 
-		if !ok {
-			t.Errorf("cannot cast to map[string]interface{}")
-		}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-		identity.Id = i["id"].(string)
-		identity.SchemaId = i["schema_id"].(string)
-		identity.SchemaUrl = i["schema_url"].(string)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
 
-		traits := make(map[string]string, 0)
+			identity := kClient.NewIdentity("joe", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+			identity.MetadataAdmin = map[string]interface{}{"ticket": "INC-1"}
 
-		for k, v := range i["traits"].(map[string]interface{}) {
-			traits[k] = v.(string)
-		}
+			gerr := kClient.NewGenericErrorWithDefaults()
+			gerr.SetCode(http.StatusNotFound)
+			gerr.SetReason("identity not found")
 
-		identity.Traits = traits
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/batch-get", strings.NewReader(test.payload))
 
-		IDs = append(IDs, *identity)
+			if test.IDs != nil {
+				mockService.EXPECT().GetIdentities(gomock.Any(), test.IDs).Return(
+					map[string]kClient.Identity{"joe": *identity},
+					map[string]*kClient.GenericError{"unknown": gerr},
+					test.serviceErr,
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			data := new(GetIdentitiesResponse)
+			rr := types.Response{Data: data}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if i, ok := data.Identities["joe"]; !ok || i.Id != "joe" {
+				t.Errorf("expected identity joe to be returned got %v", data.Identities)
+			}
+
+			if data.Identities["joe"].MetadataAdmin != nil {
+				t.Errorf("expected metadata_admin to be redacted")
+			}
+
+			expected := GetIdentitiesErrorItem{Message: "identity not found", Status: http.StatusNotFound, Code: types.CodeIdentityNotFound}
+
+			if data.Errors["unknown"] != expected {
+				t.Errorf("expected error for unknown to be %v got %v", expected, data.Errors["unknown"])
+			}
+		})
 	}
+}
 
-	if len(IDs) != 1 {
-		t.Fatalf("invalid result, expected only 1 identity, got %v", IDs)
+func TestHandleDeleteBatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		IDs        []string
+		serviceErr error
+		status     int
+	}{
+		{
+			name:    "deleted and failed",
+			payload: `["joe", "unknown"]`,
+			IDs:     []string{"joe", "unknown"},
+			status:  http.StatusMultiStatus,
+		},
+		{
+			name:       "service rejects the IDs",
+			payload:    `["joe"]`,
+			IDs:        []string{"joe"},
+			serviceErr: fmt.Errorf("too many identity IDs passed"),
+			status:     http.StatusBadRequest,
+		},
+		{
+			name:    "bad payload",
+			payload: `{"id": "joe"}`,
+			status:  http.StatusBadRequest,
+		},
 	}
 
-	if !reflect.DeepEqual(IDs[0], *identity) {
-		t.Fatalf("invalid result, expected: %v, got: %v", *identity, IDs[0])
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			gerr := kClient.NewGenericErrorWithDefaults()
+			gerr.SetCode(http.StatusNotFound)
+			gerr.SetReason("identity not found")
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/batch-delete", strings.NewReader(test.payload))
+
+			if test.IDs != nil {
+				mockService.EXPECT().DeleteIdentities(gomock.Any(), test.IDs).Return(
+					map[string]DeleteIdentityResult{"joe": {Deleted: true}, "unknown": {Error: gerr}},
+					test.serviceErr,
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.status != http.StatusMultiStatus {
+				return
+			}
+
+			data := make(map[string]DeleteIdentityResponseItem)
+			rr := types.Response{Data: &data}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if data["joe"].Status != http.StatusOK {
+				t.Errorf("expected joe to be deleted got %v", data["joe"])
+			}
+
+			expected := DeleteIdentityResponseItem{Message: "identity not found", Status: http.StatusNotFound, Code: types.CodeIdentityNotFound}
+
+			if data["unknown"] != expected {
+				t.Errorf("expected error for unknown to be %v got %v", expected, data["unknown"])
+			}
+		})
 	}
 }
 
-func TestHandleUpdateFailAndPropagatesKratosError(t *testing.T) {
+func TestHandleImport(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -560,21 +1197,27 @@ func TestHandleUpdateFailAndPropagatesKratosError(t *testing.T) {
 	mockMonitor := NewMockMonitorInterface(ctrl)
 	mockService := NewMockServiceInterface(ctrl)
 
-	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
-	identityBody.SchemaId = "test.json"
-	identityBody.Traits = map[string]interface{}{"name": "name"}
-	identityBody.SetState("active")
-	identityBody.AdditionalProperties = map[string]interface{}{"name": "name"}
+	payload := "email,name\njoe@example.com,Joe\nmalformed\nsusan@example.com,\n,\n"
 
-	payload, err := json.Marshal(identityBody)
-	req := httptest.NewRequest(http.MethodPut, "/api/v0/identities/test", bytes.NewReader(payload))
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/import?schema_id=default", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	identity := kClient.NewIdentity("test", "default", "https://test.com/test.json", map[string]string{"email": "joe@example.com"})
 
 	gerr := new(kClient.GenericError)
 	gerr.SetCode(http.StatusConflict)
-	gerr.SetMessage("id already exists")
 	gerr.SetReason("conflict")
 
-	mockService.EXPECT().UpdateIdentity(gomock.Any(), "test", identityBody).Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, fmt.Errorf("error"))
+	mockService.EXPECT().CreateIdentities(
+		gomock.Any(),
+		[]kClient.CreateIdentityBody{
+			*kClient.NewCreateIdentityBody("default", map[string]interface{}{"email": "joe@example.com", "name": "Joe"}),
+			*kClient.NewCreateIdentityBody("default", map[string]interface{}{"email": "susan@example.com"}),
+		},
+	).Return(
+		[]CreateIdentityResult{{Identity: identity}, {Error: gerr}},
+		nil,
+	)
 
 	w := httptest.NewRecorder()
 	mux := chi.NewMux()
@@ -590,7 +1233,233 @@ func TestHandleUpdateFailAndPropagatesKratosError(t *testing.T) {
 		t.Errorf("expected error to be nil got %v", err)
 	}
 
-	if res.StatusCode != http.StatusConflict {
+	if res.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("expected HTTP status code 207 got %v", res.StatusCode)
+	}
+
+	rr := new(struct {
+		Data   []ImportIdentityResponseItem `json:"data"`
+		Status int                          `json:"status"`
+	})
+
+	if err := json.Unmarshal(data, rr); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	expected := []ImportIdentityResponseItem{
+		{Row: 2, CreateIdentityResponseItem: CreateIdentityResponseItem{ID: identity.Id, Status: http.StatusCreated}},
+		{Row: 3, CreateIdentityResponseItem: CreateIdentityResponseItem{Message: "expected 2 fields, got 1", Status: http.StatusBadRequest}},
+		{Row: 4, CreateIdentityResponseItem: CreateIdentityResponseItem{Message: *gerr.Reason, Status: http.StatusConflict}},
+		{Row: 5, CreateIdentityResponseItem: CreateIdentityResponseItem{Message: "row has no traits", Status: http.StatusBadRequest}},
+	}
+
+	if !reflect.DeepEqual(rr.Data, expected) {
+		t.Fatalf("invalid result, expected: %v, got: %v", expected, rr.Data)
+	}
+}
+
+func TestHandleImportFails(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		url         string
+		payload     string
+		status      int
+	}{
+		{
+			name:        "wrong content type",
+			contentType: "application/json",
+			url:         "/api/v0/identities/import?schema_id=default",
+			payload:     "email\njoe@example.com\n",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "missing schema",
+			contentType: "text/csv",
+			url:         "/api/v0/identities/import",
+			payload:     "email\njoe@example.com\n",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "missing header",
+			contentType: "text/csv",
+			url:         "/api/v0/identities/import?schema_id=default",
+			payload:     "",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "payload too large",
+			contentType: "text/csv",
+			url:         "/api/v0/identities/import?schema_id=default",
+			payload:     "email\n" + strings.Repeat("joe@example.com\n", MaxImportSize/16+1),
+			status:      http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPost, test.url, strings.NewReader(test.payload))
+			req.Header.Set("Content-Type", test.contentType)
+
+			mockService.EXPECT().CreateIdentities(gomock.Any(), gomock.Any()).Times(0)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleUpdateSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	credID := "test-1"
+	identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SchemaId = identity.SchemaId
+	identityBody.SetState("active")
+	identityBody.Traits = map[string]interface{}{"name": "name"}
+	identityBody.AdditionalProperties = map[string]interface{}{"name": "name"}
+
+	payload, _ := json.Marshal(identityBody)
+
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v0/identities/%s", credID), bytes.NewReader(payload))
+
+	changes := []TraitChange{{Key: "team", Op: TraitChanged, Old: "identity", New: "platform"}}
+
+	mockService.EXPECT().UpdateIdentity(gomock.Any(), credID, identityBody, "").Return(&IdentityData{Identities: []kClient.Identity{*identity}, Changes: changes}, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	rr := new(types.Response)
+	if err := json.Unmarshal(data, rr); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	IDs := make([]kClient.Identity, 0)
+
+	// types.Response.Data is an interface, this means that all needs to be cast step by step
+	for _, ii := range rr.Data.([]interface{}) {
+		identity := new(kClient.Identity)
+
+		i, ok := ii.(map[string]interface{})
+
+		if !ok {
+			t.Errorf("cannot cast to map[string]interface{}")
+		}
+
+		identity.Id = i["id"].(string)
+		identity.SchemaId = i["schema_id"].(string)
+		identity.SchemaUrl = i["schema_url"].(string)
+
+		traits := make(map[string]string, 0)
+
+		for k, v := range i["traits"].(map[string]interface{}) {
+			traits[k] = v.(string)
+		}
+
+		identity.Traits = traits
+
+		IDs = append(IDs, *identity)
+	}
+
+	if len(IDs) != 1 {
+		t.Fatalf("invalid result, expected only 1 identity, got %v", IDs)
+	}
+
+	if !reflect.DeepEqual(IDs[0], *identity) {
+		t.Fatalf("invalid result, expected: %v, got: %v", *identity, IDs[0])
+	}
+
+	response := new(UpdateIdentityResponse)
+	if err := json.Unmarshal(data, response); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(response.Changes, changes) {
+		t.Fatalf("invalid changes, expected: %v, got: %v", changes, response.Changes)
+	}
+}
+
+func TestHandleUpdateFailAndPropagatesKratosError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SchemaId = "test.json"
+	identityBody.Traits = map[string]interface{}{"name": "name"}
+	identityBody.SetState("active")
+	identityBody.AdditionalProperties = map[string]interface{}{"name": "name"}
+
+	payload, err := json.Marshal(identityBody)
+	req := httptest.NewRequest(http.MethodPut, "/api/v0/identities/test", bytes.NewReader(payload))
+
+	gerr := new(kClient.GenericError)
+	gerr.SetCode(http.StatusConflict)
+	gerr.SetMessage("id already exists")
+	gerr.SetReason("conflict")
+
+	mockService.EXPECT().UpdateIdentity(gomock.Any(), "test", identityBody, "").Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, fmt.Errorf("error"))
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if res.StatusCode != http.StatusConflict {
 		t.Fatalf("expected HTTP status code 409 got %v", res.StatusCode)
 	}
 
@@ -608,6 +1477,58 @@ func TestHandleUpdateFailAndPropagatesKratosError(t *testing.T) {
 	}
 }
 
+func TestHandleUpdatePreconditionFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	credID := "test-1"
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SetState("active")
+	identityBody.Traits = map[string]interface{}{"name": "name"}
+
+	payload, _ := json.Marshal(identityBody)
+
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v0/identities/%s", credID), bytes.NewReader(payload))
+	req.Header.Set("If-Match", `W/"stale"`)
+
+	gerr := kClient.NewGenericErrorWithDefaults()
+	gerr.SetCode(http.StatusPreconditionFailed)
+	gerr.SetReason("identity test-1 was modified, ETag doesn't match")
+
+	mockService.EXPECT().UpdateIdentity(gomock.Any(), credID, gomock.Any(), `W/"stale"`).Return(&IdentityData{Identities: []kClient.Identity{}, Error: gerr}, fmt.Errorf("error"))
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("expected HTTP status code 412 got %v", res.StatusCode)
+	}
+
+	if etag := res.Header.Get("ETag"); etag != "" {
+		t.Errorf("expected no ETag header got %s", etag)
+	}
+
+	rr := new(types.Response)
+	if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+		t.Errorf("expected error to be nil got %v", err)
+	}
+
+	if rr.Code != types.CodeIdentityPreconditionFailed {
+		t.Errorf("expected error code to be %s got %s", types.CodeIdentityPreconditionFailed, rr.Code)
+	}
+}
+
 func TestHandleUpdateFailBadRequest(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -647,6 +1568,107 @@ func TestHandleUpdateFailBadRequest(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateMetadataAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		admin    bool
+		metadata interface{}
+		status   int
+	}{
+		{
+			name:     "admin sets metadata_admin",
+			admin:    true,
+			metadata: map[string]interface{}{"ticket": "INC-1"},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "non admin can't set metadata_admin",
+			metadata: map[string]interface{}{"ticket": "INC-1"},
+			status:   http.StatusForbidden,
+		},
+		{
+			name:   "non admin gets metadata_admin redacted",
+			status: http.StatusOK,
+		},
+		{
+			name:   "admin gets metadata_admin",
+			admin:  true,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			credID := "test-1"
+			identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+			identity.MetadataAdmin = map[string]interface{}{"ticket": "INC-1"}
+			identity.MetadataPublic = map[string]interface{}{"team": "identity"}
+
+			identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+			identityBody.SchemaId = identity.SchemaId
+			identityBody.SetState("active")
+			identityBody.Traits = map[string]interface{}{"name": "name"}
+			identityBody.MetadataAdmin = test.metadata
+			identityBody.MetadataPublic = map[string]interface{}{"team": "identity"}
+
+			payload, _ := json.Marshal(identityBody)
+
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v0/identities/%s", credID), bytes.NewReader(payload))
+			req = req.WithContext(authorization.IsAdminContext(req.Context(), test.admin))
+
+			if test.status == http.StatusOK {
+				mockService.EXPECT().UpdateIdentity(gomock.Any(), credID, gomock.Any(), "").Return(&IdentityData{Identities: []kClient.Identity{*identity}}, nil)
+			} else {
+				mockService.EXPECT().UpdateIdentity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			IDs := make([]kClient.Identity, 0)
+			rr := types.Response{Data: &IDs}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if IDs[0].MetadataPublic == nil {
+				t.Fatalf("expected metadata_public to be returned")
+			}
+
+			if test.admin && IDs[0].MetadataAdmin == nil {
+				t.Fatalf("expected metadata_admin to be returned to admins")
+			}
+
+			if !test.admin && IDs[0].MetadataAdmin != nil {
+				t.Fatalf("expected metadata_admin to be redacted, got %v", IDs[0].MetadataAdmin)
+			}
+		})
+	}
+}
+
 func TestHandleRemoveSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -744,6 +1766,230 @@ func TestHandleRemoveFailAndPropagatesKratosError(t *testing.T) {
 	}
 }
 
+func TestHandleSessionDisable(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *kClient.GenericError
+		status int
+		code   string
+	}{
+		{
+			name:   "session disabled",
+			status: http.StatusOK,
+		},
+		{
+			name: "unknown session",
+			err: func() *kClient.GenericError {
+				gerr := kClient.NewGenericErrorWithDefaults()
+				gerr.SetCode(http.StatusNotFound)
+				gerr.SetReason("session not found")
+
+				return gerr
+			}(),
+			status: http.StatusNotFound,
+			code:   types.CodeSessionNotFound,
+		},
+		{
+			name: "kratos unavailable",
+			err: func() *kClient.GenericError {
+				gerr := kClient.NewGenericErrorWithDefaults()
+				gerr.SetCode(http.StatusServiceUnavailable)
+				gerr.SetReason("kratos unavailable")
+
+				return gerr
+			}(),
+			status: http.StatusServiceUnavailable,
+			code:   types.CodeUpstreamUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v0/sessions/session-1", nil)
+
+			var err error
+
+			if test.err != nil {
+				err = fmt.Errorf("error")
+			}
+
+			mockService.EXPECT().DeleteSession(gomock.Any(), "session-1").Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: test.err}, err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %q got %q", test.code, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandleCredentialRemoveSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	credID := "test-1"
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v0/identities/%s/credentials/%s", credID, CredentialTypeTOTP), nil)
+
+	mockService.EXPECT().DeleteIdentityCredential(gomock.Any(), credID, CredentialTypeTOTP).Return(&IdentityData{Identities: make([]kClient.Identity, 0)}, nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+}
+
+func TestHandleCredentialRemoveFailsWithUnknownType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	credID := "test-1"
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v0/identities/%s/credentials/fingerprint", credID), nil)
+
+	gerr := new(kClient.GenericError)
+	gerr.SetCode(http.StatusBadRequest)
+	gerr.SetReason("invalid credential type fingerprint")
+
+	mockService.EXPECT().DeleteIdentityCredential(gomock.Any(), credID, "fingerprint").Return(&IdentityData{Identities: make([]kClient.Identity, 0), Error: gerr}, fmt.Errorf("error"))
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected HTTP status code 400 got %v", res.StatusCode)
+	}
+}
+
+func TestHandleUpdateState(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		state          string
+		revokeSessions bool
+		err            *kClient.GenericError
+		expected       int
+	}{
+		{
+			name:     "deactivate",
+			body:     `{"state":"inactive"}`,
+			state:    IdentityStateInactive,
+			expected: http.StatusOK,
+		},
+		{
+			name:           "deactivate and revoke sessions",
+			query:          "?revoke_sessions=true",
+			body:           `{"state":"inactive"}`,
+			state:          IdentityStateInactive,
+			revokeSessions: true,
+			expected:       http.StatusOK,
+		},
+		{
+			name:  "unknown state",
+			body:  `{"state":"suspended"}`,
+			state: "suspended",
+			err: func() *kClient.GenericError {
+				gerr := new(kClient.GenericError)
+				gerr.SetCode(http.StatusBadRequest)
+				gerr.SetReason("invalid identity state suspended")
+
+				return gerr
+			}(),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed payload",
+			body:     `{"state":`,
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			credID := "test-1"
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/identities/%s/state%s", credID, test.query), strings.NewReader(test.body))
+
+			if test.state != "" {
+				data := &IdentityData{Identities: make([]kClient.Identity, 0), Error: test.err}
+
+				var err error
+				if test.err != nil {
+					err = fmt.Errorf("error")
+				}
+
+				mockService.EXPECT().SetIdentityState(gomock.Any(), credID, test.state, test.revokeSessions).Return(data, err)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+		})
+	}
+}
+
 func TestRegisterValidation(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -770,3 +2016,576 @@ func TestRegisterValidation(t *testing.T) {
 	// second registration of `apiKey` causes logger.Fatal invocation
 	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterValidation(mockValidationRegistry)
 }
+
+func TestHandlePatchTraits(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		patches     []kClient.JsonPatch
+		err         *kClient.GenericError
+		expected    int
+	}{
+		{
+			name:        "replace",
+			contentType: "application/json-patch+json",
+			body:        `[{"op":"replace","path":"/traits/name","value":"Jane"}]`,
+			patches:     []kClient.JsonPatch{*kClient.NewJsonPatch("replace", "/traits/name")},
+			expected:    http.StatusOK,
+		},
+		{
+			name:        "protected path",
+			contentType: "application/json-patch+json",
+			body:        `[{"op":"remove","path":"/credentials/password"}]`,
+			patches:     []kClient.JsonPatch{*kClient.NewJsonPatch("remove", "/credentials/password")},
+			err: func() *kClient.GenericError {
+				gerr := new(kClient.GenericError)
+				gerr.SetCode(http.StatusBadRequest)
+				gerr.SetReason(ErrProtectedPath.Error())
+
+				return gerr
+			}(),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:        "wrong content type",
+			contentType: "application/json",
+			body:        `[{"op":"replace","path":"/traits/name","value":"Jane"}]`,
+			expected:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "missing op",
+			contentType: "application/json-patch+json",
+			body:        `[{"path":"/traits/name"}]`,
+			expected:    http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			credID := "test-1"
+			req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v0/identities/%s/traits", credID), strings.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+
+			if test.patches != nil {
+				identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "Jane"})
+				data := &IdentityData{Identities: []kClient.Identity{*identity}, Error: test.err}
+
+				var err error
+				if test.err != nil {
+					data.Identities = []kClient.Identity{}
+					err = fmt.Errorf("error")
+				}
+
+				mockService.EXPECT().PatchIdentityTraits(gomock.Any(), credID, gomock.Any()).DoAndReturn(
+					func(ctx context.Context, ID string, patches []kClient.JsonPatch) (*IdentityData, error) {
+						if len(patches) != len(test.patches) || patches[0].Op != test.patches[0].Op || patches[0].Path != test.patches[0].Path {
+							t.Errorf("expected patches to be %v got %v", test.patches, patches)
+						}
+
+						return data, err
+					},
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+
+			if test.expected == http.StatusOK && res.Header.Get("ETag") == "" {
+				t.Fatalf("expected ETag header to be set")
+			}
+		})
+	}
+}
+
+func TestHandleListSearch(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		search   bool
+		expected int
+	}{
+		{
+			name:     "search",
+			query:    "?q=joe&size=10&page_token=page-1",
+			search:   true,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "search combined with filters",
+			query:    "?q=joe&state=active",
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/identities%s", test.query), nil)
+
+			mockService.EXPECT().ListIdentities(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			if test.search {
+				identities := []kClient.Identity{*kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "joe"})}
+
+				mockService.EXPECT().SearchIdentities(gomock.Any(), "joe", int64(10), "page-1").Return(
+					&IdentityData{Identities: identities, Tokens: types.NavigationTokens{Next: "page-3"}},
+					nil,
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+
+			if !test.search {
+				return
+			}
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Meta == nil || rr.Meta.Next != "page-3" {
+				t.Fatalf("expected next token to be page-3 got %v", rr.Meta)
+			}
+		})
+	}
+}
+
+func TestHandleGenerateRecoveryCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v0/identities/test-1/recovery-codes", nil)
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected HTTP status code %v got %v", http.StatusNotImplemented, w.Code)
+	}
+
+	rr := new(types.Response)
+
+	if err := json.NewDecoder(w.Result().Body).Decode(rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if rr.Code != types.CodeNotImplemented {
+		t.Fatalf("expected code to be %s got %s", types.CodeNotImplemented, rr.Code)
+	}
+}
+
+func TestHandleCreateRecoveryLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		expiresIn time.Duration
+		status    int
+		serviceOK bool
+	}{
+		{
+			name:      "no body",
+			status:    http.StatusCreated,
+			serviceOK: true,
+		},
+		{
+			name:      "custom expiry",
+			body:      `{"expires_in": "30m"}`,
+			expiresIn: 30 * time.Minute,
+			status:    http.StatusCreated,
+			serviceOK: true,
+		},
+		{
+			name:   "invalid duration",
+			body:   `{"expires_in": "tomorrow"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:      "service error",
+			body:      `{"expires_in": "72h"}`,
+			expiresIn: 72 * time.Hour,
+			status:    http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			identityID := "test-1"
+			link := kClient.NewRecoveryLinkForIdentity("https://kratos/self-service/recovery?token=secret")
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v0/identities/%s/recovery-link", identityID), strings.NewReader(test.body))
+
+			switch {
+			case test.serviceOK:
+				mockService.EXPECT().CreateRecoveryLink(gomock.Any(), identityID, test.expiresIn).Return(&RecoveryLinkData{RecoveryLink: link}, nil)
+			case test.expiresIn != 0:
+				gerr := new(kClient.GenericError)
+				gerr.SetCode(http.StatusBadRequest)
+				gerr.SetMessage("recovery link expiry must be between 1m0s and 24h0m0s")
+
+				mockService.EXPECT().CreateRecoveryLink(gomock.Any(), identityID, test.expiresIn).Return(&RecoveryLinkData{Error: gerr}, fmt.Errorf("error"))
+			default:
+				mockService.EXPECT().CreateRecoveryLink(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if !test.serviceOK {
+				return
+			}
+
+			if res.Header.Get("Cache-Control") != "no-store" {
+				t.Fatalf("expected Cache-Control to be no-store got %q", res.Header.Get("Cache-Control"))
+			}
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			data, _ := json.Marshal(rr.Data)
+			result := new(kClient.RecoveryLinkForIdentity)
+
+			if err := json.Unmarshal(data, result); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if result.RecoveryLink != link.RecoveryLink {
+				t.Fatalf("expected recovery link to be %s got %s", link.RecoveryLink, result.RecoveryLink)
+			}
+		})
+	}
+}
+
+func TestHandleVerifyAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		admin     bool
+		status    int
+		serviceOK bool
+	}{
+		{
+			name:      "admin",
+			body:      `{"address": "joe@example.com"}`,
+			admin:     true,
+			status:    http.StatusOK,
+			serviceOK: true,
+		},
+		{
+			name:   "not an admin",
+			body:   `{"address": "joe@example.com"}`,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "missing address",
+			body:   `{}`,
+			admin:  true,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "address of another identity",
+			body:   `{"address": "jane@example.com"}`,
+			admin:  true,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			identity := kClient.NewIdentity("test-1", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "joe@example.com"})
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v0/identities/%s/verify-address", identity.Id), strings.NewReader(test.body))
+			req = req.WithContext(authorization.IsAdminContext(req.Context(), test.admin))
+
+			switch {
+			case test.serviceOK:
+				mockService.EXPECT().VerifyAddress(gomock.Any(), identity.Id, "joe@example.com").Return(&IdentityData{Identities: []kClient.Identity{*identity}}, nil)
+			case test.admin && test.status == http.StatusBadRequest && test.body != `{}`:
+				gerr := new(kClient.GenericError)
+				gerr.SetCode(http.StatusBadRequest)
+				gerr.SetMessage("address doesn't belong to the identity: jane@example.com")
+
+				mockService.EXPECT().VerifyAddress(gomock.Any(), identity.Id, "jane@example.com").Return(&IdentityData{Identities: []kClient.Identity{}, Error: gerr}, ErrAddressNotFound)
+			default:
+				mockService.EXPECT().VerifyAddress(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if !test.serviceOK {
+				return
+			}
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			data, _ := json.Marshal(rr.Data)
+			result := make([]kClient.Identity, 0)
+
+			if err := json.Unmarshal(data, &result); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(result) != 1 || result[0].Id != identity.Id {
+				t.Fatalf("expected identity %s got %v", identity.Id, result)
+			}
+		})
+	}
+}
+
+func TestHandleEffectiveEntitlements(t *testing.T) {
+	permissions := []EffectivePermission{
+		{Relation: "can_view", Object: "client:okta", Sources: []PermissionSource{{Type: PermissionSourceRole, ID: "viewer"}}},
+		{Relation: "can_edit", Object: "group:devops", Sources: []PermissionSource{{Type: PermissionSourceDirect}}},
+		{Relation: "can_view", Object: "group:devops", Sources: []PermissionSource{{Type: PermissionSourceGroup, ID: "it-admin"}}},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		err      error
+		status   int
+		expected []EffectivePermission
+		next     bool
+		prev     bool
+	}{
+		{
+			name:     "first page",
+			query:    "?size=2",
+			status:   http.StatusOK,
+			expected: permissions[:2],
+			next:     true,
+		},
+		{
+			name:     "last page",
+			query:    "?size=2&page_token=eyJvZmZzZXQiOjJ9",
+			status:   http.StatusOK,
+			expected: permissions[2:],
+			prev:     true,
+		},
+		{
+			name:     "bad page token",
+			query:    "?page_token=not-a-token",
+			status:   http.StatusOK,
+			expected: permissions,
+		},
+		{
+			name:   "service error",
+			err:    fmt.Errorf("error"),
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+			mockEffective := NewMockEffectivePermissionsServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities/joe/effective-entitlements"+test.query, nil)
+
+			mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.err != nil {
+				mockEffective.EXPECT().GetEffectivePermissions(gomock.Any(), "joe").Return(nil, test.err)
+			} else {
+				mockEffective.EXPECT().GetEffectivePermissions(gomock.Any(), "joe").Return(permissions, nil)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+
+			api := NewAPI(mockService, mockTracer, mockMonitor, mockLogger)
+			api.SetEffectivePermissionsService(mockEffective)
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			rr := new(types.Response)
+			result := make([]EffectivePermission, 0)
+			rr.Data = &result
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expected effective permissions to be %v got %v", test.expected, result)
+			}
+
+			if *rr.Meta.Total != int64(len(permissions)) {
+				t.Fatalf("expected total to be %v got %v", len(permissions), *rr.Meta.Total)
+			}
+
+			if test.next != (rr.Meta.Next != "") || test.prev != (rr.Meta.Prev != "") {
+				t.Fatalf("unexpected navigation tokens %v", rr.Meta.NavigationTokens)
+			}
+		})
+	}
+}
+
+func TestHandleListPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int64
+	}{
+		{name: "missing size uses the default", query: "", expected: 10},
+		{name: "size below max", query: "size=20", expected: 20},
+		{name: "size equal to max", query: "size=50", expected: 50},
+		{name: "size above max is capped", query: "size=51", expected: 50},
+		{name: "zero size", query: "size=0"},
+		{name: "negative size", query: "size=-1"},
+		{name: "non numeric size", query: "size=ten"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities?"+test.query, nil)
+
+			if test.expected > 0 {
+				mockService.EXPECT().ListIdentities(gomock.Any(), test.expected, "", ListIdentitiesFilter{}).Return(&IdentityData{Identities: []kClient.Identity{}}, nil)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			api := NewAPI(mockService, mockTracer, mockMonitor, mockLogger)
+			api.SetPageSizeConfig(types.NewPageSizeConfig(10, 50))
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if test.expected == 0 {
+				if res.StatusCode != http.StatusBadRequest {
+					t.Fatalf("expected HTTP status code 400 got %v", res.StatusCode)
+				}
+
+				return
+			}
+
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Meta.Size != test.expected {
+				t.Fatalf("expected page size %v got %v", test.expected, rr.Meta.Size)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ package identities
 
 import (
 	"context"
+	"time"
 
+	"github.com/openfga/go-sdk/client"
 	kClient "github.com/ory/kratos-client-go"
 
 	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
@@ -17,12 +19,33 @@ type AuthorizerInterface interface {
 }
 
 type ServiceInterface interface {
-	ListIdentities(context.Context, int64, string, string) (*IdentityData, error)
+	ListIdentities(context.Context, int64, string, ListIdentitiesFilter) (*IdentityData, error)
+	SearchIdentities(context.Context, string, int64, string) (*IdentityData, error)
+	ListUngroupedIdentities(context.Context, int64, string) (*IdentityData, error)
 	GetIdentity(context.Context, string) (*IdentityData, error)
+	GetIdentities(context.Context, []string) (map[string]kClient.Identity, map[string]*kClient.GenericError, error)
 	CreateIdentity(context.Context, *kClient.CreateIdentityBody) (*IdentityData, error)
-	UpdateIdentity(context.Context, string, *kClient.UpdateIdentityBody) (*IdentityData, error)
+	CreateIdentities(context.Context, []kClient.CreateIdentityBody) ([]CreateIdentityResult, error)
+	UpdateIdentity(context.Context, string, *kClient.UpdateIdentityBody, string) (*IdentityData, error)
+	PatchIdentityTraits(context.Context, string, []kClient.JsonPatch) (*IdentityData, error)
 	DeleteIdentity(context.Context, string) (*IdentityData, error)
+	DeleteIdentities(context.Context, []string) (map[string]DeleteIdentityResult, error)
+	ListIdentityCredentials(context.Context, string) (*CredentialsData, error)
+	DeleteIdentityCredential(context.Context, string, string) (*IdentityData, error)
+	SetIdentityState(context.Context, string, string, bool) (*IdentityData, error)
+	DeleteSession(context.Context, string) (*IdentityData, error)
 	SendUserCreationEmail(context.Context, *kClient.Identity) error
+	CreateRecoveryLink(context.Context, string, time.Duration) (*RecoveryLinkData, error)
+	VerifyAddress(context.Context, string, string) (*IdentityData, error)
+}
+
+// OpenFGAClientInterface is the interface used to read the group memberships of the identities
+type OpenFGAClientInterface interface {
+	ReadTuples(context.Context, string, string, string, string) (*client.ClientReadResponse, error)
+}
+
+type EffectivePermissionsServiceInterface interface {
+	GetEffectivePermissions(context.Context, string) ([]EffectivePermission, error)
 }
 
 type OpenFGAStoreInterface interface {
@@ -33,6 +56,7 @@ type OpenFGAStoreInterface interface {
 	AssignGroups(context.Context, string, ...string) error
 	UnassignGroups(context.Context, string, ...string) error
 	ListPermissions(context.Context, string, map[string]string) ([]ofga.Permission, map[string]string, error)
+	ListAllPermissions(context.Context, string) ([]ofga.Permission, error)
 	AssignPermissions(context.Context, string, ...ofga.Permission) error
 	UnassignPermissions(context.Context, string, ...ofga.Permission) error
 }
@@ -0,0 +1,157 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/canonical/rebac-admin-ui-handlers/v1/resources"
+)
+
+const (
+	// NameStrategySplit reads the full name from a single trait, the last word is the last name
+	NameStrategySplit = "split"
+	// NameStrategySeparate reads first and last name from two distinct traits
+	NameStrategySeparate = "separate"
+)
+
+// TraitsMapping drives the conversion between kratos traits and resources.Identity, Email is the
+// trait holding the email, Name the full name used by NameStrategySplit, FirstName and LastName
+// the traits used by NameStrategySeparate
+type TraitsMapping struct {
+	Email        string
+	Name         string
+	FirstName    string
+	LastName     string
+	NameStrategy string
+}
+
+// DefaultTraitsMapping matches the email and name traits, the name being split on the last space
+func DefaultTraitsMapping() *TraitsMapping {
+	m := new(TraitsMapping)
+
+	m.Email = "email"
+	m.Name = "name"
+	m.NameStrategy = NameStrategySplit
+
+	return m
+}
+
+// NewTraitsMapping returns a TraitsMapping, defaults are used for the empty email, name and strategy,
+// an error is returned for an unknown strategy or if NameStrategySeparate lacks one of its traits
+func NewTraitsMapping(email, name, firstName, lastName, strategy string) (*TraitsMapping, error) {
+	m := DefaultTraitsMapping()
+
+	if email != "" {
+		m.Email = email
+	}
+
+	if name != "" {
+		m.Name = name
+	}
+
+	if strategy != "" {
+		m.NameStrategy = strategy
+	}
+
+	m.FirstName = firstName
+	m.LastName = lastName
+
+	switch m.NameStrategy {
+	case NameStrategySplit:
+	case NameStrategySeparate:
+		if m.FirstName == "" || m.LastName == "" {
+			return nil, fmt.Errorf("name strategy %s needs both first and last name traits", NameStrategySeparate)
+		}
+	default:
+		return nil, fmt.Errorf("unknown name strategy %s", m.NameStrategy)
+	}
+
+	return m, nil
+}
+
+// ToIdentity maps the traits of the kratos identity ID to a resources.Identity, traits can be
+// either a map[string]string or a map[string]interface{} as decoded from JSON
+func (m *TraitsMapping) ToIdentity(ID string, traits interface{}) resources.Identity {
+	i := resources.Identity{
+		Id: &ID,
+	}
+
+	values := stringTraits(traits)
+
+	if email, ok := values[m.Email]; ok {
+		i.Email = email
+	}
+
+	if m.NameStrategy == NameStrategySeparate {
+		name, okName := values[m.FirstName]
+		surname, okSurname := values[m.LastName]
+
+		if okName && okSurname {
+			i.FirstName = &name
+			i.LastName = &surname
+		}
+
+		return i
+	}
+
+	fullname, ok := values[m.Name]
+
+	if !ok {
+		return i
+	}
+
+	surnameIndex := strings.LastIndex(fullname, " ")
+
+	if surnameIndex > 0 {
+		name := strings.Trim(fullname[0:surnameIndex], " ")
+		surname := strings.Trim(fullname[surnameIndex:], " ")
+
+		i.FirstName = &name
+		i.LastName = &surname
+	}
+
+	return i
+}
+
+// ToTraits maps identity to kratos traits, names are only set if both first and last name are
+func (m *TraitsMapping) ToTraits(identity *resources.Identity) map[string]interface{} {
+	traits := make(map[string]interface{})
+
+	traits[m.Email] = identity.Email
+
+	if identity.FirstName == nil || identity.LastName == nil {
+		return traits
+	}
+
+	if m.NameStrategy == NameStrategySeparate {
+		traits[m.FirstName] = *identity.FirstName
+		traits[m.LastName] = *identity.LastName
+	} else {
+		traits[m.Name] = fmt.Sprintf("%s %s", *identity.FirstName, *identity.LastName)
+	}
+
+	return traits
+}
+
+// stringTraits returns the string traits, other values are left out
+func stringTraits(traits interface{}) map[string]string {
+	switch t := traits.(type) {
+	case map[string]string:
+		return t
+	case map[string]interface{}:
+		values := make(map[string]string, len(t))
+
+		for k, v := range t {
+			if s, ok := v.(string); ok {
+				values[k] = s
+			}
+		}
+
+		return values
+	default:
+		return map[string]string{}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/canonical/rebac-admin-ui-handlers/v1/resources"
+	kClient "github.com/ory/kratos-client-go"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+)
+
+func TestNewTraitsMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		fullname  string
+		firstName string
+		lastName  string
+		strategy  string
+		expected  *TraitsMapping
+	}{
+		{
+			name:     "defaults",
+			expected: DefaultTraitsMapping(),
+		},
+		{
+			name:      "separate",
+			email:     "mail",
+			firstName: "given_name",
+			lastName:  "family_name",
+			strategy:  NameStrategySeparate,
+			expected:  &TraitsMapping{Email: "mail", Name: "name", FirstName: "given_name", LastName: "family_name", NameStrategy: NameStrategySeparate},
+		},
+		{
+			name:      "separate without last name",
+			firstName: "given_name",
+			strategy:  NameStrategySeparate,
+		},
+		{
+			name:     "unknown strategy",
+			strategy: "reverse",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := NewTraitsMapping(test.email, test.fullname, test.firstName, test.lastName, test.strategy)
+
+			if test.expected == nil {
+				if err == nil {
+					t.Fatal("expected error not to be nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(m, test.expected) {
+				t.Fatalf("expected mapping to be %v got %v", test.expected, m)
+			}
+		})
+	}
+}
+
+func TestV1ServiceTraitsMapping(t *testing.T) {
+	name := "Mary Ann"
+	surname := "Smith"
+
+	tests := []struct {
+		name     string
+		mapping  *TraitsMapping
+		traits   map[string]interface{}
+		expected resources.Identity
+	}{
+		{
+			name:    "default mapping",
+			mapping: nil,
+			traits:  map[string]interface{}{"email": "mary@example.com", "name": "Mary Ann Smith"},
+			expected: resources.Identity{
+				Email:     "mary@example.com",
+				FirstName: &name,
+				LastName:  &surname,
+			},
+		},
+		{
+			name:    "custom mapping",
+			mapping: &TraitsMapping{Email: "mail", FirstName: "given_name", LastName: "family_name", NameStrategy: NameStrategySeparate},
+			traits:  map[string]interface{}{"mail": "mary@example.com", "given_name": "Mary Ann", "family_name": "Smith"},
+			expected: resources.Identity{
+				Email:     "mary@example.com",
+				FirstName: &name,
+				LastName:  &surname,
+			},
+		},
+		{
+			name:    "custom mapping missing last name",
+			mapping: &TraitsMapping{Email: "mail", FirstName: "given_name", LastName: "family_name", NameStrategy: NameStrategySeparate},
+			traits:  map[string]interface{}{"mail": "mary@example.com", "given_name": "Mary Ann"},
+			expected: resources.Identity{
+				Email: "mary@example.com",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+			identity := kClient.NewIdentity("test-1", "test", "https://test.com/test.json", test.traits)
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, identity.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+
+			cfg := new(Config)
+			cfg.TraitsMapping = test.mapping
+
+			svc := NewV1Service(cfg, NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger))
+
+			result, err := svc.GetIdentity(ctx, identity.Id)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			test.expected.Id = &identity.Id
+
+			if !reflect.DeepEqual(*result, test.expected) {
+				t.Fatalf("expected identity to be %v got %v", test.expected, *result)
+			}
+
+			if test.expected.FirstName == nil {
+				return
+			}
+
+			// traits sent to kratos on create and update are the ones read back
+			if traits := svc.traits.ToTraits(result); !reflect.DeepEqual(traits, test.traits) {
+				t.Fatalf("expected traits to be %v got %v", test.traits, traits)
+			}
+		})
+	}
+}
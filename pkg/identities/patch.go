@@ -0,0 +1,311 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	kClient "github.com/ory/kratos-client-go"
+)
+
+const traitsPath = "/traits"
+
+var (
+	// ErrProtectedPath is returned when a patch operation points outside of the identity traits
+	ErrProtectedPath = errors.New("patch path is protected, only /traits can be patched")
+	// ErrInvalidPatch is returned when a patch operation can't be applied to the document
+	ErrInvalidPatch = errors.New("invalid patch")
+)
+
+// checkPatchPaths makes sure every operation, including the source of move and copy, only touches the traits
+func checkPatchPaths(patches []kClient.JsonPatch) error {
+	for _, patch := range patches {
+		paths := []string{patch.Path}
+
+		if patch.Op == "move" || patch.Op == "copy" {
+			paths = append(paths, patch.GetFrom())
+		}
+
+		for _, path := range paths {
+			if path != traitsPath && !strings.HasPrefix(path, traitsPath+"/") {
+				return fmt.Errorf("%w: %s", ErrProtectedPath, path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyPatch applies the RFC 6902 operations in order to a copy of doc, either all of them
+// succeed or an error is returned and doc is left untouched
+func applyPatch(doc interface{}, patches []kClient.JsonPatch) (interface{}, error) {
+	doc, err := deepCopy(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patch := range patches {
+		path, err := parsePointer(patch.Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch patch.Op {
+		case "add":
+			doc, err = addValue(doc, path, patch.Value)
+		case "remove":
+			doc, err = removeValue(doc, path)
+		case "replace":
+			doc, err = replaceValue(doc, path, patch.Value)
+		case "move", "copy":
+			doc, err = moveValue(doc, patch.GetFrom(), path, patch.Op == "move")
+		case "test":
+			err = testValue(doc, path, patch.Value)
+		default:
+			err = fmt.Errorf("%w: unknown operation %s", ErrInvalidPatch, patch.Op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// parsePointer splits a RFC 6901 JSON pointer in its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: malformed path %s", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// update walks doc down to the parent of the last token and hands it to fn, the container returned
+// by fn replaces the parent as arrays might get reallocated
+func update(doc interface{}, tokens []string, fn func(interface{}, string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := getChild(doc, tokens[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = update(child, tokens[1:], fn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		container[tokens[0]] = child
+	case []interface{}:
+		// getChild already validated the index
+		idx, _ := strconv.Atoi(tokens[0])
+		container[idx] = child
+	}
+
+	return doc, nil
+}
+
+func getChild(doc interface{}, token string) (interface{}, error) {
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		value, ok := container[token]
+
+		if !ok {
+			return nil, fmt.Errorf("%w: %s not found", ErrInvalidPatch, token)
+		}
+
+		return value, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(container)-1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return container[idx], nil
+	default:
+		return nil, fmt.Errorf("%w: %s not found", ErrInvalidPatch, token)
+	}
+}
+
+func getValue(doc interface{}, tokens []string) (interface{}, error) {
+	var err error
+
+	for _, token := range tokens {
+		if doc, err = getChild(doc, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// arrayIndex parses token as an index between 0 and max included
+func arrayIndex(token string, max int) (int, error) {
+	idx, err := strconv.Atoi(token)
+
+	// leading zeros are not allowed by RFC 6901
+	if err != nil || idx < 0 || idx > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %s", ErrInvalidPatch, token)
+	}
+
+	return idx, nil
+}
+
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	return update(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+
+			return container, nil
+		case []interface{}:
+			if token == "-" {
+				return append(container, value), nil
+			}
+
+			idx, err := arrayIndex(token, len(container))
+
+			if err != nil {
+				return nil, err
+			}
+
+			container = append(container, nil)
+			copy(container[idx+1:], container[idx:])
+			container[idx] = value
+
+			return container, nil
+		default:
+			return nil, fmt.Errorf("%w: can't add %s", ErrInvalidPatch, token)
+		}
+	})
+}
+
+func removeValue(doc interface{}, tokens []string) (interface{}, error) {
+	return update(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		if _, err := getChild(parent, token); err != nil {
+			return nil, err
+		}
+
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			delete(container, token)
+
+			return container, nil
+		case []interface{}:
+			idx, _ := strconv.Atoi(token)
+
+			return append(container[:idx], container[idx+1:]...), nil
+		}
+
+		return parent, nil
+	})
+}
+
+func replaceValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	return update(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		if _, err := getChild(parent, token); err != nil {
+			return nil, err
+		}
+
+		switch container := parent.(type) {
+		case map[string]interface{}:
+			container[token] = value
+		case []interface{}:
+			idx, _ := strconv.Atoi(token)
+			container[idx] = value
+		}
+
+		return parent, nil
+	})
+}
+
+func moveValue(doc interface{}, from string, tokens []string, move bool) (interface{}, error) {
+	source, err := parsePointer(from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := getValue(doc, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !move {
+		if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+
+		return addValue(doc, tokens, value)
+	}
+
+	if len(tokens) > len(source) && reflect.DeepEqual(tokens[:len(source)], source) {
+		return nil, fmt.Errorf("%w: can't move %s into one of its children", ErrInvalidPatch, from)
+	}
+
+	if doc, err = removeValue(doc, source); err != nil {
+		return nil, err
+	}
+
+	return addValue(doc, tokens, value)
+}
+
+func testValue(doc interface{}, tokens []string, expected interface{}) error {
+	value, err := getValue(doc, tokens)
+
+	if err != nil {
+		return err
+	}
+
+	// normalize numbers and nested types to what encoding/json produces
+	if expected, err = deepCopy(expected); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		return fmt.Errorf("%w: test failed on /%s", ErrInvalidPatch, strings.Join(tokens, "/"))
+	}
+
+	return nil
+}
+
+// deepCopy round trips v through JSON, the result only contains maps, slices and JSON scalar types
+func deepCopy(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var c interface{}
+
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
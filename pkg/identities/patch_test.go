@@ -0,0 +1,220 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	kClient "github.com/ory/kratos-client-go"
+)
+
+func jsonPatch(op, path string, value interface{}) kClient.JsonPatch {
+	p := kClient.NewJsonPatch(op, path)
+
+	if value != nil {
+		p.SetValue(value)
+	}
+
+	return *p
+}
+
+func jsonPatchFrom(op, from, path string) kClient.JsonPatch {
+	p := kClient.NewJsonPatch(op, path)
+	p.SetFrom(from)
+
+	return *p
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := map[string]interface{}{
+		"traits": map[string]interface{}{
+			"email":  "joe@example.com",
+			"name":   map[string]interface{}{"first": "Joe", "last": "Doe"},
+			"phones": []interface{}{"1", "2"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		patches  []kClient.JsonPatch
+		expected map[string]interface{}
+		err      error
+	}{
+		{
+			name:    "add",
+			patches: []kClient.JsonPatch{jsonPatch("add", "/traits/nickname", "jd"), jsonPatch("add", "/traits/phones/0", "0"), jsonPatch("add", "/traits/phones/-", "3")},
+			expected: map[string]interface{}{
+				"email":    "joe@example.com",
+				"nickname": "jd",
+				"name":     map[string]interface{}{"first": "Joe", "last": "Doe"},
+				"phones":   []interface{}{"0", "1", "2", "3"},
+			},
+		},
+		{
+			name:    "replace",
+			patches: []kClient.JsonPatch{jsonPatch("replace", "/traits/name/first", "Jane"), jsonPatch("replace", "/traits/phones/1", "22")},
+			expected: map[string]interface{}{
+				"email":  "joe@example.com",
+				"name":   map[string]interface{}{"first": "Jane", "last": "Doe"},
+				"phones": []interface{}{"1", "22"},
+			},
+		},
+		{
+			name:    "remove",
+			patches: []kClient.JsonPatch{jsonPatch("remove", "/traits/name/last", nil), jsonPatch("remove", "/traits/phones/0", nil)},
+			expected: map[string]interface{}{
+				"email":  "joe@example.com",
+				"name":   map[string]interface{}{"first": "Joe"},
+				"phones": []interface{}{"2"},
+			},
+		},
+		{
+			name:    "move and copy",
+			patches: []kClient.JsonPatch{jsonPatchFrom("move", "/traits/name/first", "/traits/first"), jsonPatchFrom("copy", "/traits/email", "/traits/backup")},
+			expected: map[string]interface{}{
+				"email":  "joe@example.com",
+				"backup": "joe@example.com",
+				"first":  "Joe",
+				"name":   map[string]interface{}{"last": "Doe"},
+				"phones": []interface{}{"1", "2"},
+			},
+		},
+		{
+			name:    "failed test rolls back everything",
+			patches: []kClient.JsonPatch{jsonPatch("replace", "/traits/email", "jane@example.com"), jsonPatch("test", "/traits/email", "joe@example.com")},
+			err:     ErrInvalidPatch,
+		},
+		{
+			name:    "replace missing key",
+			patches: []kClient.JsonPatch{jsonPatch("replace", "/traits/missing", "value")},
+			err:     ErrInvalidPatch,
+		},
+		{
+			name:    "array index out of range",
+			patches: []kClient.JsonPatch{jsonPatch("remove", "/traits/phones/2", nil)},
+			err:     ErrInvalidPatch,
+		},
+		{
+			name:    "unknown operation",
+			patches: []kClient.JsonPatch{jsonPatch("merge", "/traits/email", "jane@example.com")},
+			err:     ErrInvalidPatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := applyPatch(doc, test.patches)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			if traits := result.(map[string]interface{})["traits"]; !reflect.DeepEqual(traits, test.expected) {
+				t.Fatalf("expected traits to be %v got %v", test.expected, traits)
+			}
+
+			if doc["traits"].(map[string]interface{})["email"] != "joe@example.com" {
+				t.Fatalf("expected original document to be untouched")
+			}
+		})
+	}
+}
+
+func TestCheckPatchPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		patches []kClient.JsonPatch
+		err     error
+	}{
+		{
+			name:    "traits",
+			patches: []kClient.JsonPatch{jsonPatch("replace", "/traits/email", "jane@example.com"), jsonPatch("replace", "/traits", map[string]interface{}{})},
+		},
+		{
+			name:    "credentials",
+			patches: []kClient.JsonPatch{jsonPatch("remove", "/credentials/password", nil)},
+			err:     ErrProtectedPath,
+		},
+		{
+			name:    "traits prefix",
+			patches: []kClient.JsonPatch{jsonPatch("replace", "/traitsx", "value")},
+			err:     ErrProtectedPath,
+		},
+		{
+			name:    "copy from protected path",
+			patches: []kClient.JsonPatch{jsonPatchFrom("copy", "/metadata_admin", "/traits/metadata")},
+			err:     ErrProtectedPath,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := checkPatchPaths(test.patches); !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateTraits(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"traits": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"email": map[string]interface{}{"type": "string"},
+					"age":   map[string]interface{}{"type": "integer"},
+					"role":  map[string]interface{}{"type": "string", "enum": []interface{}{"admin", "user"}},
+				},
+				"required":             []interface{}{"email"},
+				"additionalProperties": false,
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		traits interface{}
+		err    error
+	}{
+		{
+			name:   "valid",
+			traits: map[string]interface{}{"email": "joe@example.com", "age": float64(30), "role": "admin"},
+		},
+		{
+			name:   "missing required",
+			traits: map[string]interface{}{"age": float64(30)},
+			err:    ErrTraitsSchemaMismatch,
+		},
+		{
+			name:   "wrong type",
+			traits: map[string]interface{}{"email": "joe@example.com", "age": 30.5},
+			err:    ErrTraitsSchemaMismatch,
+		},
+		{
+			name:   "additional property",
+			traits: map[string]interface{}{"email": "joe@example.com", "nickname": "jd"},
+			err:    ErrTraitsSchemaMismatch,
+		},
+		{
+			name:   "not in enum",
+			traits: map[string]interface{}{"email": "joe@example.com", "role": "owner"},
+			err:    ErrTraitsSchemaMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := validateTraits(schema, test.traits); !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultSchemaKey is the single flight key of the ConfigMap read
+const defaultSchemaKey = "default"
+
+// defaultSchemaCache keeps the default schema ID read from the ConfigMap for ttl, concurrent misses
+// share a single read, a ttl of 0 disables caching
+type defaultSchemaCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	ID      string
+	expires time.Time
+	// generation is bumped on invalidation so reads started before it are not cached
+	generation uint64
+
+	flight singleflight.Group
+
+	now func() time.Time
+}
+
+// get returns the cached schema ID or the one returned by load
+func (c *defaultSchemaCache) get(ctx context.Context, load func(context.Context) (string, error)) (string, error) {
+	c.mu.Lock()
+
+	if c.ID != "" && c.now().Before(c.expires) {
+		ID := c.ID
+		c.mu.Unlock()
+
+		return ID, nil
+	}
+
+	generation := c.generation
+	c.mu.Unlock()
+
+	v, err, _ := c.flight.Do(
+		defaultSchemaKey,
+		func() (interface{}, error) {
+			ID, err := load(ctx)
+
+			if err != nil {
+				return "", err
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.ttl > 0 && c.generation == generation {
+				c.ID = ID
+				c.expires = c.now().Add(c.ttl)
+			}
+
+			return ID, nil
+		},
+	)
+
+	return v.(string), err
+}
+
+// invalidate drops the cached schema ID, the next get reads the ConfigMap again
+func (c *defaultSchemaCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ID = ""
+	c.generation++
+	c.flight.Forget(defaultSchemaKey)
+}
+
+func newDefaultSchemaCache(ttl time.Duration) *defaultSchemaCache {
+	c := new(defaultSchemaCache)
+
+	c.ttl = ttl
+	c.now = time.Now
+
+	return c
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+)
+
+func TestDefaultSchemaCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		err      error
+		elapsed  time.Duration
+		expected int
+	}{
+		{
+			name:     "cached",
+			ttl:      time.Minute,
+			expected: 1,
+		},
+		{
+			name:     "expired",
+			ttl:      time.Minute,
+			elapsed:  time.Minute,
+			expected: 2,
+		},
+		{
+			name:     "caching disabled",
+			ttl:      0,
+			expected: 2,
+		},
+		{
+			name:     "errors are not cached",
+			ttl:      time.Minute,
+			err:      fmt.Errorf("configmap not found"),
+			expected: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Now()
+
+			c := newDefaultSchemaCache(test.ttl)
+			c.now = func() time.Time { return now }
+
+			reads := 0
+			load := func(context.Context) (string, error) {
+				reads++
+				return "default", test.err
+			}
+
+			c.get(context.Background(), load)
+
+			now = now.Add(test.elapsed)
+
+			ID, err := c.get(context.Background(), load)
+
+			if err != test.err {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+
+			if test.err == nil && ID != "default" {
+				t.Fatalf("expected schema to be default got %s", ID)
+			}
+
+			if reads != test.expected {
+				t.Fatalf("expected %d reads got %d", test.expected, reads)
+			}
+		})
+	}
+}
+
+func TestV1ServiceDefaultSchemaSingleRead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	cfg := new(Config)
+	cfg.K8s = mockCoreV1
+	cfg.Name = "schemas"
+	cfg.Namespace = "default"
+	cfg.SchemaCacheTTL = time.Minute
+
+	cm := new(corev1.ConfigMap)
+	cm.Data = map[string]string{DEFAULT_SCHEMA: "test"}
+
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockCoreV1.EXPECT().ConfigMaps(cfg.Namespace).Times(2).Return(mockConfigMapV1)
+	// the first read is held until all the callers are waiting on it
+	mockConfigMapV1.EXPECT().Get(ctx, cfg.Name, gomock.Any()).Times(2).DoAndReturn(
+		func(context.Context, string, interface{}) (*corev1.ConfigMap, error) {
+			<-release
+			return cm, nil
+		},
+	)
+
+	svc := NewV1Service(
+		cfg,
+		NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
+	)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if ID, err := svc.getDefaultSchema(ctx); err != nil || ID != "test" {
+				t.Errorf("expected schema to be test got %s, %v", ID, err)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// cached from now on
+	if _, err := svc.getDefaultSchema(ctx); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	svc.InvalidateSchemaCache(ctx)
+
+	if _, err := svc.getDefaultSchema(ctx); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+}
@@ -5,11 +5,19 @@ package identities
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	v1 "github.com/canonical/rebac-admin-ui-handlers/v1"
 	"github.com/canonical/rebac-admin-ui-handlers/v1/resources"
@@ -18,33 +26,161 @@ import (
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	coreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/mail"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
 	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
 )
 
 // TODO @shipperizer unify this value with schemas/service.go
 const (
-	DEFAULT_SCHEMA           = "default.schema"
-	userCreationEmailSubject = "Complete your registration"
+	DEFAULT_SCHEMA = "default.schema"
+
+	totalCountHeader = "X-Total-Count"
+
+	// MaxBatchSize is the maximum number of identities accepted in a single batch
+	MaxBatchSize = 100
+	// BatchConcurrency is the maximum number of entries of a batch in flight against Kratos
+	BatchConcurrency = 10
+	// MaxImportSize is the maximum size in bytes of a CSV file accepted by the import endpoint
+	MaxImportSize = 1 << 20
+
+	IdentityStateActive   = "active"
+	IdentityStateInactive = "inactive"
+
+	CredentialTypeTOTP         = "totp"
+	CredentialTypeWebAuthn     = "webauthn"
+	CredentialTypeLookupSecret = "lookup_secret"
+	CredentialTypeOIDC         = "oidc"
+	CredentialTypePassword     = "password"
+
+	// DefaultSearchMaxPages caps the kratos pages scanned by a single SearchIdentities call
+	DefaultSearchMaxPages = 10
+
+	// MinRecoveryLinkExpiry and MaxRecoveryLinkExpiry bound the lifespan of a recovery link, kratos
+	// requires it to be in the future and a zero value falls back to the kratos configured lifespan
+	MinRecoveryLinkExpiry = time.Minute
+	MaxRecoveryLinkExpiry = 24 * time.Hour
+
+	// countPageSize is the page size used to count identities when kratos doesn't send X-Total-Count
+	countPageSize = 500
+
+	// schemaCacheTTL bounds how long a fetched identity schema is reused, schemas can be edited at runtime
+	schemaCacheTTL = 5 * time.Minute
 )
 
+// ErrSchemaNotAllowed is returned when an identity is created against a schema outside of the allowed ones
+var ErrSchemaNotAllowed = errors.New("identity schema not allowed")
+
+// ErrNotProcessed is reported for batch entries whose job never ran, e.g. dropped by a pool shutdown
+var ErrNotProcessed = errors.New("identity was not processed")
+
+// DefaultSearchFields are the traits matched by SearchIdentities when none are configured
+var DefaultSearchFields = []string{"email", "name"}
+
 type Service struct {
-	kratos kClient.IdentityAPI
-	authz  AuthorizerInterface
-	email  mail.EmailServiceInterface
+	kratos  kClient.IdentityAPI
+	authz   AuthorizerInterface
+	email   mail.EmailServiceInterface
+	wpool   pool.WorkerPoolInterface
+	auditor audit.AuditorInterface
+	search  *SearchConfig
+	ofga    OpenFGAClientInterface
+	// piiKeys are the traits masked in the changes returned by UpdateIdentity
+	piiKeys []string
+	// allowedSchemas are the schemas identities can be created against, all of them when empty
+	allowedSchemas []string
+
+	// identity schemas used to validate traits, keyed by schema ID
+	schemas   map[string]cachedSchema
+	schemasMu sync.Mutex
 
 	tracer  trace.Tracer
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
 }
 
+type cachedSchema struct {
+	schema  map[string]interface{}
+	expires time.Time
+}
+
+// SearchConfig drives SearchIdentities, Fields are trait names matched against the query, nested
+// traits use dots, e.g. name.first, and MaxPages caps the kratos pages scanned per request
+type SearchConfig struct {
+	Fields   []string
+	MaxPages int
+}
+
+// NewSearchConfig returns a SearchConfig, DefaultSearchFields and DefaultSearchMaxPages are used for empty values
+func NewSearchConfig(fields []string, maxPages int) *SearchConfig {
+	c := new(SearchConfig)
+
+	c.Fields = fields
+	c.MaxPages = maxPages
+
+	if len(c.Fields) == 0 {
+		c.Fields = DefaultSearchFields
+	}
+
+	if c.MaxPages <= 0 {
+		c.MaxPages = DefaultSearchMaxPages
+	}
+
+	return c
+}
+
+// RecoveryLinkData holds the one-time link created by CreateRecoveryLink, the link embeds a
+// recovery token so it must never be logged
+type RecoveryLinkData struct {
+	RecoveryLink *kClient.RecoveryLinkForIdentity
+	Error        *kClient.GenericError
+}
+
 type IdentityData struct {
 	Identities []kClient.Identity
 	Tokens     types.NavigationTokens
-	Error      *kClient.GenericError
+	// Total is only set when kratos returns the count of all the identities
+	// and no schema or state filter is applied, as those are not reflected in the count
+	Total *int64
+	Error *kClient.GenericError
+	// Changes are the trait changes made by UpdateIdentity
+	Changes []TraitChange
+}
+
+// CreateIdentityResult holds the outcome of a single creation inside a batch
+// Error is set alongside Identity when the identity got created but the follow up
+// steps (entitlements, invitation email) failed
+type CreateIdentityResult struct {
+	Identity *kClient.Identity
+	Error    *kClient.GenericError
+}
+
+// DeleteIdentityResult holds the outcome of a single deletion inside a batch
+// Error is set with Deleted true when the identity got deleted but its entitlements
+// couldn't be removed
+type DeleteIdentityResult struct {
+	Deleted bool
+	Error   *kClient.GenericError
+}
+
+type createIdentityResult struct {
+	index  int
+	result CreateIdentityResult
+}
+
+type deleteIdentityResult struct {
+	ID     string
+	result DeleteIdentityResult
+}
+
+type getIdentityResult struct {
+	ID   string
+	data *IdentityData
+	err  error
 }
 
 // TODO @shipperizer verify during integration test if this is actually the format
@@ -62,9 +198,32 @@ func (s *Service) buildListRequest(ctx context.Context, size int64, token, credI
 	return r
 }
 
+// parseTotalCount reads the total number of items from the response headers, nil if missing or not valid
+func (s *Service) parseTotalCount(headers http.Header) *int64 {
+	value := headers.Get(totalCountHeader)
+
+	if value == "" {
+		return nil
+	}
+
+	total, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		s.logger.Warnf("failed parsing %s header: %s", totalCountHeader, err)
+		return nil
+	}
+
+	return &total
+}
+
 func (s *Service) parseError(r *http.Response) *kClient.GenericError {
 	gerr := KratosError{Error: kClient.NewGenericErrorWithDefaults()}
 
+	if r == nil {
+		gerr.Error.SetCode(http.StatusInternalServerError)
+		return gerr.Error
+	}
+
 	defer r.Body.Close()
 	body, _ := io.ReadAll(r.Body)
 
@@ -73,38 +232,380 @@ func (s *Service) parseError(r *http.Response) *kClient.GenericError {
 		gerr.Error.SetCode(http.StatusInternalServerError)
 	}
 
+	// kratos, or a proxy in front of it, doesn't always send a kratos error along with a 429 or a 503,
+	// keep the status and the Retry-After header so clients back off instead of retrying straight away
+	if r.StatusCode == http.StatusTooManyRequests || r.StatusCode == http.StatusServiceUnavailable {
+		gerr.Error.SetCode(int64(r.StatusCode))
+
+		if gerr.Error.GetReason() == "" {
+			gerr.Error.SetReason(fmt.Sprintf("kratos: %s", http.StatusText(r.StatusCode)))
+		}
+
+		if retryAfter := r.Header.Get("Retry-After"); retryAfter != "" {
+			details := gerr.Error.GetDetails()
+
+			if details == nil {
+				details = make(map[string]interface{})
+			}
+
+			details[retryAfterDetail] = retryAfter
+			gerr.Error.SetDetails(details)
+		}
+	}
+
 	return gerr.Error
 }
 
-func (s *Service) ListIdentities(ctx context.Context, size int64, token, credID string) (*IdentityData, error) {
+// IdentityETag returns a weak ETag for the identity, derived from its last update time or from the
+// whole identity when Kratos doesn't report it
+func IdentityETag(identity *kClient.Identity) string {
+	var version []byte
+
+	if updatedAt, ok := identity.GetUpdatedAtOk(); ok {
+		version = []byte(fmt.Sprintf("%s:%d", identity.Id, updatedAt.UnixNano()))
+	} else {
+		version, _ = json.Marshal(identity)
+	}
+
+	hash := sha256.Sum256(version)
+
+	return fmt.Sprintf("W/\"%s\"", hex.EncodeToString(hash[:16]))
+}
+
+// ifNoneMatch reports if the If-None-Match header value lists etag, "*" matches any ETag,
+// the comparison is weak as the header is used for caching only
+func ifNoneMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValidIdentityState checks the value against the states known to Kratos
+func IsValidIdentityState(state string) bool {
+	switch state {
+	case IdentityStateActive, IdentityStateInactive:
+		return true
+	default:
+		return false
+	}
+}
+
+// ListIdentitiesFilter narrows down the identities returned by ListIdentities, empty fields match everything
+type ListIdentitiesFilter struct {
+	CredID   string
+	SchemaID string
+	State    string
+}
+
+// IsEmpty returns true if none of the filters applied client side are set
+// CredID is not considered as kratos takes care of it
+func (f ListIdentitiesFilter) IsEmpty() bool {
+	return f.SchemaID == "" && f.State == ""
+}
+
+func (f ListIdentitiesFilter) apply(identities []kClient.Identity) []kClient.Identity {
+	filtered := make([]kClient.Identity, 0)
+
+	for _, identity := range identities {
+		if f.SchemaID != "" && identity.SchemaId != f.SchemaID {
+			continue
+		}
+
+		if f.State != "" && identity.GetState() != f.State {
+			continue
+		}
+
+		filtered = append(filtered, identity)
+	}
+
+	return filtered
+}
+
+// ProjectTraits strips from the traits of each identity everything but fields, nested traits are selected
+// with dotted paths (e.g. name.first), fields not found in the traits are ignored, identities are returned
+// untouched if fields is empty
+func ProjectTraits(identities []kClient.Identity, fields []string) []kClient.Identity {
+	if len(fields) == 0 {
+		return identities
+	}
+
+	paths := make([][]string, 0, len(fields))
+
+	for _, field := range fields {
+		paths = append(paths, strings.Split(field, "."))
+	}
+
+	// nested paths first, a parent requested as a whole then replaces the partial copy
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) > len(paths[j]) })
+
+	projected := make([]kClient.Identity, 0, len(identities))
+
+	for _, identity := range identities {
+		if traits, ok := identity.Traits.(map[string]interface{}); ok {
+			selected := make(map[string]interface{})
+
+			for _, path := range paths {
+				if value, ok := lookupTrait(traits, path); ok {
+					setTrait(selected, path, value)
+				}
+			}
+
+			identity.Traits = selected
+		}
+
+		projected = append(projected, identity)
+	}
+
+	return projected
+}
+
+func lookupTrait(traits map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := traits[path[0]]
+
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+
+	nested, ok := value.(map[string]interface{})
+
+	if !ok {
+		return nil, false
+	}
+
+	return lookupTrait(nested, path[1:])
+}
+
+func setTrait(traits map[string]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		traits[path[0]] = value
+		return
+	}
+
+	nested, ok := traits[path[0]].(map[string]interface{})
+
+	if !ok {
+		nested = make(map[string]interface{})
+		traits[path[0]] = nested
+	}
+
+	setTrait(nested, path[1:], value)
+}
+
+// ListIdentities returns a page of identities, kratos-client doesn't support filtering by schema or state
+// so when those filters are set pages are fetched until `size` identities match, there are no more pages
+// or s.search.MaxPages pages have been scanned, the returned next token points to the page after the
+// last one fetched, all the matches of that page are returned even when they overflow `size` as the
+// token can't point in the middle of a page
+func (s *Service) ListIdentities(ctx context.Context, size int64, token string, filter ListIdentitiesFilter) (*IdentityData, error) {
 	ctx, span := s.tracer.Start(ctx, "identities.Service.ListIdentities")
 	defer span.End()
 
-	identities, rr, err := s.kratos.ListIdentitiesExecute(
-		s.buildListRequest(ctx, size, token, credID),
-	)
-
 	data := new(IdentityData)
+	data.Identities = make([]kClient.Identity, 0)
+
+	for page := 0; page < s.search.MaxPages; page++ {
+		identities, rr, err := s.kratos.ListIdentitiesExecute(
+			s.buildListRequest(ctx, size, token, filter.CredID),
+		)
+
+		if err != nil {
+			s.logger.Error(err)
+			data.Error = s.parseError(rr)
+
+			return data, err
+		}
+
+		navTokens, err := types.ParseLinkTokens(rr.Header)
+
+		if err != nil {
+			s.logger.Warnf("failed parsing link header: %s", err)
+		}
+
+		if page == 0 {
+			data.Tokens.Prev = navTokens.Prev
+
+			// kratos counts all the identities, the value would be misleading once the pages get filtered
+			if filter.IsEmpty() {
+				data.Total = s.parseTotalCount(rr.Header)
+			}
+		}
+
+		data.Tokens.Next = navTokens.Next
+
+		if filter.IsEmpty() {
+			data.Identities = append(data.Identities, identities...)
+			break
+		}
+
+		data.Identities = append(data.Identities, filter.apply(identities)...)
+
+		if int64(len(data.Identities)) >= size || navTokens.Next == "" {
+			break
+		}
+
+		token = navTokens.Next
+	}
+
+	data.Identities = withVerification(data.Identities)
+
+	return data, nil
+}
+
+// CountIdentities returns the number of identities, read from the X-Total-Count header of a single
+// identity page, if kratos doesn't send it all the pages are scanned
+func (s *Service) CountIdentities(ctx context.Context) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.CountIdentities")
+	defer span.End()
+
+	data, err := s.ListIdentities(ctx, 1, "", ListIdentitiesFilter{})
 
 	if err != nil {
-		s.logger.Error(err)
-		data.Error = s.parseError(rr)
+		return 0, err
 	}
 
-	if navTokens, err := types.ParseLinkTokens(rr.Header); err != nil {
-		s.logger.Warnf("failed parsing link header: %s", err)
-	} else {
-		data.Tokens = navTokens
+	if data.Total != nil {
+		return *data.Total, nil
 	}
 
-	data.Identities = identities
+	count := int64(0)
+	token := ""
+
+	for {
+		data, err := s.ListIdentities(ctx, countPageSize, token, ListIdentitiesFilter{})
 
-	// TODO @shipperizer check if identities is defaulting to empty slice inside kratos-client
-	if data.Identities == nil {
-		data.Identities = make([]kClient.Identity, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		count += int64(len(data.Identities))
+
+		if data.Tokens.Next == "" {
+			return count, nil
+		}
+
+		token = data.Tokens.Next
 	}
+}
 
-	return data, err
+// SearchIdentities returns the identities with at least one of the configured traits containing query,
+// case insensitive. Kratos has no trait search so pages are fetched and filtered here until at least
+// `size` identities match, there are no more pages or MaxPages pages have been scanned, whichever
+// comes first, the returned next token points to the page after the last one scanned
+func (s *Service) SearchIdentities(ctx context.Context, query string, size int64, token string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.SearchIdentities")
+	defer span.End()
+
+	data := new(IdentityData)
+	data.Identities = make([]kClient.Identity, 0)
+
+	query = strings.ToLower(query)
+
+	for page := 0; page < s.search.MaxPages; page++ {
+		identities, rr, err := s.kratos.ListIdentitiesExecute(
+			s.buildListRequest(ctx, size, token, ""),
+		)
+
+		if err != nil {
+			s.logger.Error(err)
+			data.Error = s.parseError(rr)
+
+			return data, err
+		}
+
+		navTokens, err := types.ParseLinkTokens(rr.Header)
+
+		if err != nil {
+			s.logger.Warnf("failed parsing link header: %s", err)
+		}
+
+		if page == 0 {
+			data.Tokens.Prev = navTokens.Prev
+		}
+
+		data.Tokens.Next = navTokens.Next
+
+		for _, identity := range identities {
+			if s.matchTraits(identity.Traits, query) {
+				data.Identities = append(data.Identities, identity)
+			}
+		}
+
+		if int64(len(data.Identities)) >= size || navTokens.Next == "" {
+			break
+		}
+
+		token = navTokens.Next
+	}
+
+	data.Identities = withVerification(data.Identities)
+
+	return data, nil
+}
+
+func (s *Service) matchTraits(traits interface{}, query string) bool {
+	values, ok := traits.(map[string]interface{})
+
+	if !ok {
+		// traits not decoded by kratos-client, e.g. map[string]string, go through JSON to normalize them
+		normalized, err := deepCopy(traits)
+
+		if err != nil {
+			return false
+		}
+
+		if values, ok = normalized.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+
+	for _, field := range s.search.Fields {
+		var value interface{} = values
+
+		for _, key := range strings.Split(field, ".") {
+			object, ok := value.(map[string]interface{})
+
+			if !ok {
+				value = nil
+				break
+			}
+
+			value = object[key]
+		}
+
+		if containsString(value, query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsString looks for query in value, objects and arrays are searched recursively
+func containsString(value interface{}, query string) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(strings.ToLower(v), query)
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsString(item, query) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if containsString(item, query) {
+				return true
+			}
+		}
+	}
+
+	return false
 }
 
 func (s *Service) GetIdentity(ctx context.Context, ID string) (*IdentityData, error) {
@@ -123,7 +624,7 @@ func (s *Service) GetIdentity(ctx context.Context, ID string) (*IdentityData, er
 	}
 
 	if identity != nil {
-		data.Identities = []kClient.Identity{*identity}
+		data.Identities = withVerification([]kClient.Identity{*identity})
 	} else {
 		data.Identities = []kClient.Identity{}
 	}
@@ -131,6 +632,93 @@ func (s *Service) GetIdentity(ctx context.Context, ID string) (*IdentityData, er
 	return data, err
 }
 
+// GetIdentities reads the identities concurrently through the worker pool, IDs that can't be read,
+// e.g. because the identity doesn't exist, end up in the errors map rather than failing the call
+func (s *Service) GetIdentities(ctx context.Context, IDs []string) (map[string]kClient.Identity, map[string]*kClient.GenericError, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.GetIdentities")
+	defer span.End()
+
+	if len(IDs) == 0 {
+		err := fmt.Errorf("no identity IDs passed")
+
+		s.logger.Error(err)
+
+		return nil, nil, err
+	}
+
+	if len(IDs) > MaxBatchSize {
+		err := fmt.Errorf("too many identity IDs passed, maximum batch size is %v", MaxBatchSize)
+
+		s.logger.Error(err)
+
+		return nil, nil, err
+	}
+
+	unique := make([]string, 0, len(IDs))
+
+	for _, ID := range IDs {
+		if !slices.Contains(unique, ID) {
+			unique = append(unique, ID)
+		}
+	}
+
+	identities := make(map[string]kClient.Identity)
+	errs := make(map[string]*kClient.GenericError)
+
+	// buffered so workers never block on sending, see OpenFGAStore.ListPermissions
+	results := make(chan *pool.Result[any], len(unique))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(unique))
+
+	for _, ID := range unique {
+		ID := ID
+
+		if _, err := s.wpool.Submit(
+			func() any {
+				data, err := s.GetIdentity(ctx, ID)
+
+				return getIdentityResult{ID: ID, data: data, err: err}
+			},
+			results,
+			&wg,
+		); err != nil {
+			// job never made it to the pool, wg won't be released by it
+			wg.Done()
+
+			s.logger.Errorf("failed submitting read of identity %s: %s", ID, err)
+
+			errs[ID] = kClient.NewGenericErrorWithDefaults()
+			errs[ID].SetCode(http.StatusServiceUnavailable)
+			errs[ID].SetMessage(err.Error())
+			errs[ID].SetReason(err.Error())
+		}
+	}
+
+	wg.Wait()
+	close(results)
+
+	for r := range results {
+		v := r.Value.(getIdentityResult)
+
+		if v.err == nil && len(v.data.Identities) > 0 {
+			identities[v.ID] = v.data.Identities[0]
+
+			continue
+		}
+
+		e := v.data.Error
+
+		if e == nil {
+			e = s.parseError(nil)
+		}
+
+		errs[v.ID] = e
+	}
+
+	return identities, errs, nil
+}
+
 func (s *Service) CreateIdentity(ctx context.Context, bodyID *kClient.CreateIdentityBody) (*IdentityData, error) {
 	ctx, span := s.tracer.Start(ctx, "identities.Service.CreateIdentity")
 	defer span.End()
@@ -148,6 +736,16 @@ func (s *Service) CreateIdentity(ctx context.Context, bodyID *kClient.CreateIden
 		return data, err
 	}
 
+	if err := s.checkSchemaAllowed(bodyID.SchemaId); err != nil {
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
+		return s.badRequest(err), err
+	}
+
+	if err := s.checkTraits(ctx, bodyID.SchemaId, bodyID.Traits); err != nil {
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
+		return s.badRequest(err), err
+	}
+
 	identity, rr, err := s.kratos.CreateIdentityExecute(
 		s.kratos.CreateIdentity(ctx).CreateIdentityBody(*bodyID),
 	)
@@ -162,143 +760,797 @@ func (s *Service) CreateIdentity(ctx context.Context, bodyID *kClient.CreateIden
 
 	if err != nil {
 		s.logger.Error(err)
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
 		data.Error = s.parseError(rr)
 		return data, err
 	}
 
-	s.authz.SetCreateIdentityEntitlements(ctx, identity.Id)
+	s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, identity.Id, audit.OutcomeSuccess)
+
+	s.authz.SetCreateIdentityEntitlements(ctx, identity.Id)
+
+	return data, err
+}
+
+// CreateIdentities creates all the identities passed in on the worker pool, BatchConcurrency at a time
+// so that a batch neither floods Kratos nor the pool queue, a failure on a single entry doesn't abort
+// the whole batch
+// each created identity gets its entitlements set and the invitation email sent, same as a single creation
+// results are returned in the same order as the input
+func (s *Service) CreateIdentities(ctx context.Context, bodies []kClient.CreateIdentityBody) ([]CreateIdentityResult, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.CreateIdentities")
+	defer span.End()
+
+	if len(bodies) == 0 {
+		err := fmt.Errorf("no identities data passed")
+
+		s.logger.Error(err)
+
+		return nil, err
+	}
+
+	if len(bodies) > MaxBatchSize {
+		err := fmt.Errorf("too many identities passed, maximum batch size is %v", MaxBatchSize)
+
+		s.logger.Error(err)
+
+		return nil, err
+	}
+
+	// every slot starts as failed, a job dropped by the pool without running sends no result
+	outcomes := make([]CreateIdentityResult, len(bodies))
+
+	for i := range outcomes {
+		outcomes[i] = CreateIdentityResult{Error: unavailableError(ErrNotProcessed)}
+	}
+
+	jobs := make([]func() any, len(bodies))
+
+	for i := range bodies {
+		i := i
+
+		jobs[i] = func() any {
+			return createIdentityResult{index: i, result: s.createBatchIdentity(ctx, &bodies[i])}
+		}
+	}
+
+	results := s.runBatch(
+		jobs,
+		func(i int, err error) {
+			s.logger.Errorf("failed submitting creation of identity %d: %s", i, err)
+
+			outcomes[i] = CreateIdentityResult{Error: unavailableError(err)}
+		},
+	)
+
+	for r := range results {
+		v := r.Value.(createIdentityResult)
+
+		outcomes[v.index] = v.result
+	}
+
+	return outcomes, nil
+}
+
+// runBatch submits jobs to the worker pool in waves of BatchConcurrency, each wave completes before
+// the next one is submitted, jobs the pool rejects are passed to rejected along with their index
+// the returned channel holds the results and is closed once every job is done
+func (s *Service) runBatch(jobs []func() any, rejected func(int, error)) chan *pool.Result[any] {
+	// buffered so workers never block on sending, see OpenFGAStore.ListPermissions
+	results := make(chan *pool.Result[any], len(jobs))
+
+	for start := 0; start < len(jobs); start += BatchConcurrency {
+		end := min(start+BatchConcurrency, len(jobs))
+
+		wg := sync.WaitGroup{}
+		wg.Add(end - start)
+
+		for i := start; i < end; i++ {
+			if _, err := s.wpool.Submit(jobs[i], results, &wg); err != nil {
+				// job never made it to the pool, wg won't be released by it
+				wg.Done()
+				rejected(i, err)
+			}
+		}
+
+		wg.Wait()
+	}
+
+	close(results)
+
+	return results
+}
+
+// unavailableError reports a batch entry that never ran, either rejected or dropped by the pool
+func unavailableError(err error) *kClient.GenericError {
+	gerr := kClient.NewGenericErrorWithDefaults()
+	gerr.SetCode(http.StatusServiceUnavailable)
+	gerr.SetMessage(err.Error())
+	gerr.SetReason(err.Error())
+
+	return gerr
+}
+
+func (s *Service) createBatchIdentity(ctx context.Context, body *kClient.CreateIdentityBody) CreateIdentityResult {
+	result := CreateIdentityResult{}
+
+	if err := s.checkSchemaAllowed(body.SchemaId); err != nil {
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
+		result.Error = s.badRequest(err).Error
+
+		return result
+	}
+
+	if err := s.checkTraits(ctx, body.SchemaId, body.Traits); err != nil {
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
+		result.Error = s.badRequest(err).Error
+
+		return result
+	}
+
+	identity, rr, err := s.kratos.CreateIdentityExecute(
+		s.kratos.CreateIdentity(ctx).CreateIdentityBody(*body),
+	)
+
+	if err != nil {
+		s.logger.Error(err)
+		s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, "", audit.OutcomeFailure)
+		result.Error = s.parseError(rr)
+
+		return result
+	}
+
+	s.auditor.Record(ctx, audit.IdentityCreate, audit.IdentityResource, identity.Id, audit.OutcomeSuccess)
+
+	result.Identity = identity
+
+	if err := s.authz.SetCreateIdentityEntitlements(ctx, identity.Id); err != nil {
+		s.logger.Errorf("failed setting entitlements for identity %s: %s", identity.Id, err)
+		result.Error = s.followUpError(fmt.Sprintf("identity created but failed setting entitlements: %s", err))
+
+		return result
+	}
+
+	if err := s.SendUserCreationEmail(ctx, identity); err != nil {
+		s.logger.Errorf("failed sending creation email for identity %s: %s", identity.Id, err)
+		result.Error = s.followUpError(fmt.Sprintf("identity created but failed sending email: %s", err))
+	}
+
+	return result
+}
+
+func (s *Service) followUpError(message string) *kClient.GenericError {
+	gerr := kClient.NewGenericErrorWithDefaults()
+	gerr.SetCode(http.StatusInternalServerError)
+	gerr.SetMessage(message)
+	gerr.SetReason(message)
+
+	return gerr
+}
+
+func (s *Service) SendUserCreationEmail(ctx context.Context, identity *kClient.Identity) error {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.SendUserCreationEmail")
+	defer span.End()
+
+	code, link, err := s.generateRecoveryInfo(ctx, identity.Id)
+	if err != nil {
+		return err
+	}
+
+	traits, _ := identity.Traits.(map[string]interface{})
+
+	emailAddress := ""
+	if e, ok := traits["email"]; ok {
+		emailAddress = e.(string)
+	}
+
+	if emailAddress == "" {
+		return fmt.Errorf("\"email\" address not found in identity traits")
+	}
+
+	userCreationInviteArgs := mail.UserCreationInviteArgs{
+		Email:        emailAddress,
+		InviteUrl:    link,
+		RecoveryCode: code,
+		Traits:       traits,
+	}
+
+	err = s.email.SendTemplate(ctx, emailAddress, mail.UserCreationInviteTemplate, userCreationInviteArgs)
+
+	return err
+}
+
+// CreateRecoveryLink creates a one-time recovery link for the identity valid for expiresIn,
+// zero uses the lifespan configured in kratos
+func (s *Service) CreateRecoveryLink(ctx context.Context, identityID string, expiresIn time.Duration) (*RecoveryLinkData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.CreateRecoveryLink")
+	defer span.End()
+
+	data := new(RecoveryLinkData)
+
+	if expiresIn != 0 && (expiresIn < MinRecoveryLinkExpiry || expiresIn > MaxRecoveryLinkExpiry) {
+		err := fmt.Errorf("recovery link expiry must be between %s and %s", MinRecoveryLinkExpiry, MaxRecoveryLinkExpiry)
+
+		data.Error = s.badRequest(err).Error
+
+		return data, err
+	}
+
+	body := kClient.NewCreateRecoveryLinkForIdentityBody(identityID)
+
+	if expiresIn != 0 {
+		body.SetExpiresIn(expiresIn.String())
+	}
+
+	link, rr, err := s.kratos.CreateRecoveryLinkForIdentityExecute(
+		s.kratos.CreateRecoveryLinkForIdentity(ctx).CreateRecoveryLinkForIdentityBody(*body),
+	)
+
+	// only the action is recorded, the link carries the recovery token
+	s.auditor.Record(ctx, audit.IdentityCreateRecoveryLink, audit.IdentityResource, identityID, audit.OutcomeFromError(err))
+
+	if err != nil {
+		s.logger.Errorf("failed creating recovery link for identity %s: %s", identityID, err)
+		data.Error = s.parseError(rr)
+
+		return data, err
+	}
+
+	data.RecoveryLink = link
+
+	return data, nil
+}
+
+func (s *Service) generateRecoveryInfo(ctx context.Context, identityId string) (string, string, error) {
+	request := kClient.CreateRecoveryCodeForIdentityBody{IdentityId: identityId}
+	recoveryInfo, response, err := s.kratos.CreateRecoveryCodeForIdentity(ctx).
+		CreateRecoveryCodeForIdentityBody(request).
+		Execute()
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return "", "", fmt.Errorf("unable to create recovery code for Identity %v, status code %d", identityId, response.StatusCode)
+	}
+
+	return recoveryInfo.RecoveryCode, recoveryInfo.RecoveryLink, nil
+}
+
+// UpdateIdentity replaces the identity with bodyID, if ifMatch is set the current identity is fetched
+// first and the update is rejected with a 412 when its ETag differs, as another update went through
+// metadata_admin and metadata_public are optional, when missing the current values are kept
+func (s *Service) UpdateIdentity(ctx context.Context, ID string, bodyID *kClient.UpdateIdentityBody, ifMatch string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.UpdateIdentity")
+	defer span.End()
+	if ID == "" {
+		err := fmt.Errorf("no identity ID passed")
+
+		data := new(IdentityData)
+		data.Identities = []kClient.Identity{}
+		data.Error = s.parseError(nil)
+		data.Error.SetMessage(err.Error())
+
+		s.logger.Error(err)
+
+		return data, err
+	}
+
+	if bodyID == nil {
+		err := fmt.Errorf("no identity body passed")
+
+		data := new(IdentityData)
+		data.Identities = []kClient.Identity{}
+		data.Error = s.parseError(nil)
+		data.Error.SetMessage(err.Error())
+
+		s.logger.Error(err)
+
+		return data, err
+	}
+
+	if err := s.checkTraits(ctx, bodyID.SchemaId, bodyID.Traits); err != nil {
+		return s.badRequest(err), err
+	}
+
+	var current *IdentityData
+
+	if ifMatch != "" {
+		data, err := s.checkIdentityVersion(ctx, ID, ifMatch)
+
+		if err != nil {
+			return data, err
+		}
+
+		current = data
+	}
+
+	// the current identity is needed for the trait changes of the update
+	if current == nil {
+		data, err := s.GetIdentity(ctx, ID)
+
+		if err != nil {
+			return data, err
+		}
+
+		current = data
+	}
+
+	body := *bodyID
+
+	// kratos replaces the whole identity, metadata left out of the body is carried over
+	// from the current identity instead of being wiped
+	if body.MetadataAdmin == nil {
+		body.MetadataAdmin = current.Identities[0].MetadataAdmin
+	}
+
+	if body.MetadataPublic == nil {
+		body.MetadataPublic = current.Identities[0].MetadataPublic
+	}
+
+	identity, rr, err := s.kratos.UpdateIdentityExecute(
+		s.kratos.UpdateIdentity(ctx, ID).UpdateIdentityBody(body),
+	)
+
+	s.auditor.Record(ctx, audit.IdentityUpdate, audit.IdentityResource, ID, audit.OutcomeFromError(err))
+
+	data := new(IdentityData)
+
+	if err != nil {
+		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+	}
+
+	if identity != nil {
+		data.Identities = []kClient.Identity{*identity}
+		data.Changes = diffTraits(current.Identities[0].Traits, identity.Traits, s.piiKeys)
+	} else {
+		data.Identities = []kClient.Identity{}
+	}
+
+	return data, err
+}
+
+// PatchIdentityTraits applies the RFC 6902 patches to the identity traits, paths are JSON pointers into
+// the identity and must live under /traits, the patched traits are checked against the identity schema
+// and written back as a single replace of /traits, leaving credentials and metadata untouched
+func (s *Service) PatchIdentityTraits(ctx context.Context, ID string, patches []kClient.JsonPatch) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.PatchIdentityTraits")
+	defer span.End()
+
+	if err := checkPatchPaths(patches); err != nil {
+		return s.badRequest(err), err
+	}
+
+	current, err := s.GetIdentity(ctx, ID)
+
+	if err != nil {
+		return current, err
+	}
+
+	identity := current.Identities[0]
+
+	doc, err := applyPatch(map[string]interface{}{"traits": identity.Traits}, patches)
+
+	if err != nil {
+		return s.badRequest(err), err
+	}
+
+	traits := doc.(map[string]interface{})["traits"]
+
+	schema, rr, err := s.identitySchema(ctx, identity.SchemaId)
+
+	if err != nil {
+		s.logger.Error(err)
+
+		data := new(IdentityData)
+		data.Identities = []kClient.Identity{}
+		data.Error = s.parseError(rr)
+
+		return data, err
+	}
+
+	if err := validateTraits(schema, traits); err != nil {
+		return s.badRequest(err), err
+	}
+
+	patch := kClient.NewJsonPatch("replace", traitsPath)
+	patch.SetValue(traits)
+
+	patched, rr, err := s.kratos.PatchIdentityExecute(
+		s.kratos.PatchIdentity(ctx, ID).JsonPatch([]kClient.JsonPatch{*patch}),
+	)
+
+	s.auditor.Record(ctx, audit.IdentityUpdate, audit.IdentityResource, ID, audit.OutcomeFromError(err))
+
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+
+	if err != nil {
+		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+
+		return data, err
+	}
+
+	data.Identities = []kClient.Identity{*patched}
+
+	return data, nil
+}
+
+// identitySchema fetches the identity schema from kratos, schemas are cached for schemaCacheTTL
+// and the returned response is nil when served from the cache
+func (s *Service) identitySchema(ctx context.Context, ID string) (map[string]interface{}, *http.Response, error) {
+	s.schemasMu.Lock()
+	cached, ok := s.schemas[ID]
+	s.schemasMu.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.schema, nil, nil
+	}
+
+	schema, rr, err := s.kratos.GetIdentitySchemaExecute(
+		s.kratos.GetIdentitySchema(ctx, ID),
+	)
+
+	if err != nil {
+		return nil, rr, err
+	}
+
+	s.schemasMu.Lock()
+	s.schemas[ID] = cachedSchema{schema: schema, expires: time.Now().Add(schemaCacheTTL)}
+	s.schemasMu.Unlock()
+
+	return schema, rr, nil
+}
+
+// checkTraits validates traits against their identity schema before they reach kratos so clients
+// get every failing path at once, if the schema can't be loaded validation is left to kratos
+func (s *Service) checkTraits(ctx context.Context, schemaID string, traits interface{}) error {
+	if schemaID == "" {
+		return nil
+	}
+
+	schema, _, err := s.identitySchema(ctx, schemaID)
+
+	if err != nil {
+		s.logger.Warnf("unable to load identity schema %s, leaving traits validation to kratos: %s", schemaID, err)
+		return nil
+	}
+
+	return validateTraits(schema, traits)
+}
+
+// checkSchemaAllowed fails with ErrSchemaNotAllowed if schemaID is not one of the allowed schemas
+func (s *Service) checkSchemaAllowed(schemaID string) error {
+	if len(s.allowedSchemas) == 0 || slices.Contains(s.allowedSchemas, schemaID) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q, allowed schemas are %s", ErrSchemaNotAllowed, schemaID, strings.Join(s.allowedSchemas, ", "))
+}
+
+// SetAllowedSchemas restricts the schemas identities can be created against, regardless of the
+// ones kratos offers, an empty list allows all of them
+func (s *Service) SetAllowedSchemas(schemas []string) {
+	s.allowedSchemas = schemas
+}
+
+func (s *Service) badRequest(err error) *IdentityData {
+	s.logger.Error(err)
+
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+	data.Error = kClient.NewGenericErrorWithDefaults()
+	data.Error.SetCode(http.StatusBadRequest)
+	data.Error.SetMessage(err.Error())
+	data.Error.SetReason(err.Error())
+
+	var verr *TraitsValidationError
+	if errors.As(err, &verr) {
+		data.Error.SetDetails(map[string]interface{}{traitsErrorsDetail: verr.Errors})
+	}
+
+	return data
+}
+
+func (s *Service) DeleteIdentity(ctx context.Context, ID string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.DeleteIdentity")
+	defer span.End()
+
+	rr, err := s.kratos.DeleteIdentityExecute(
+		s.kratos.DeleteIdentity(ctx, ID),
+	)
+
+	s.auditor.Record(ctx, audit.IdentityDelete, audit.IdentityResource, ID, audit.OutcomeFromError(err))
+
+	data := new(IdentityData)
+
+	data.Identities = []kClient.Identity{}
+	if err != nil {
+		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+		return data, err
+	}
+
+	s.authz.SetDeleteIdentityEntitlements(ctx, ID)
+
+	return data, err
+}
+
+// DeleteIdentities deletes the identities concurrently through the worker pool, BatchConcurrency at a time, a failure on a
+// single ID doesn't abort the batch, every deleted identity gets its entitlements removed
+// regardless of the outcome of the other deletions
+// the returned map holds the outcome of every unique ID passed in
+func (s *Service) DeleteIdentities(ctx context.Context, IDs []string) (map[string]DeleteIdentityResult, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.DeleteIdentities")
+	defer span.End()
+
+	if len(IDs) == 0 {
+		err := fmt.Errorf("no identity IDs passed")
+
+		s.logger.Error(err)
+
+		return nil, err
+	}
+
+	if len(IDs) > MaxBatchSize {
+		err := fmt.Errorf("too many identity IDs passed, maximum batch size is %v", MaxBatchSize)
+
+		s.logger.Error(err)
+
+		return nil, err
+	}
+
+	unique := make([]string, 0, len(IDs))
+
+	for _, ID := range IDs {
+		if !slices.Contains(unique, ID) {
+			unique = append(unique, ID)
+		}
+	}
+
+	// every ID starts as failed, a job dropped by the pool without running sends no result
+	outcomes := make(map[string]DeleteIdentityResult, len(unique))
+
+	for _, ID := range unique {
+		outcomes[ID] = DeleteIdentityResult{Error: unavailableError(ErrNotProcessed)}
+	}
+
+	jobs := make([]func() any, len(unique))
+
+	for i, ID := range unique {
+		ID := ID
+
+		jobs[i] = func() any {
+			return deleteIdentityResult{ID: ID, result: s.deleteBatchIdentity(ctx, ID)}
+		}
+	}
+
+	results := s.runBatch(
+		jobs,
+		func(i int, err error) {
+			s.logger.Errorf("failed submitting deletion of identity %s: %s", unique[i], err)
+
+			outcomes[unique[i]] = DeleteIdentityResult{Error: unavailableError(err)}
+		},
+	)
+
+	for r := range results {
+		v := r.Value.(deleteIdentityResult)
 
-	return data, err
+		outcomes[v.ID] = v.result
+	}
+
+	return outcomes, nil
 }
 
-func (s *Service) SendUserCreationEmail(ctx context.Context, identity *kClient.Identity) error {
-	ctx, span := s.tracer.Start(ctx, "identities.Service.SendUserCreationEmail")
-	defer span.End()
+func (s *Service) deleteBatchIdentity(ctx context.Context, ID string) DeleteIdentityResult {
+	result := DeleteIdentityResult{}
 
-	template, err := mail.LoadTemplate(mail.UserCreationInvite)
-	if err != nil {
-		return err
-	}
+	rr, err := s.kratos.DeleteIdentityExecute(
+		s.kratos.DeleteIdentity(ctx, ID),
+	)
+
+	s.auditor.Record(ctx, audit.IdentityDelete, audit.IdentityResource, ID, audit.OutcomeFromError(err))
 
-	code, link, err := s.generateRecoveryInfo(ctx, identity.Id)
 	if err != nil {
-		return err
-	}
+		s.logger.Error(err)
+		result.Error = s.parseError(rr)
 
-	emailAddress := ""
-	if e, ok := identity.Traits.(map[string]interface{})["email"]; ok {
-		emailAddress = e.(string)
+		return result
 	}
 
-	if emailAddress == "" {
-		return fmt.Errorf("\"email\" address not found in identity traits")
-	}
+	result.Deleted = true
 
-	userCreationInviteArgs := mail.UserCreationInviteArgs{
-		Email:        emailAddress,
-		InviteUrl:    link,
-		RecoveryCode: code,
+	// the identity is gone from kratos, its tuples must go too even if the request got cancelled
+	if err := s.authz.SetDeleteIdentityEntitlements(context.WithoutCancel(ctx), ID); err != nil {
+		s.logger.Errorf("failed removing entitlements of identity %s: %s", ID, err)
+		result.Error = s.followUpError(fmt.Sprintf("identity deleted but failed removing entitlements: %s", err))
 	}
 
-	err = s.email.Send(ctx, emailAddress, userCreationEmailSubject, template, userCreationInviteArgs)
-
-	return err
+	return result
 }
 
-func (s *Service) generateRecoveryInfo(ctx context.Context, identityId string) (string, string, error) {
-	request := kClient.CreateRecoveryCodeForIdentityBody{IdentityId: identityId}
-	recoveryInfo, response, err := s.kratos.CreateRecoveryCodeForIdentity(ctx).
-		CreateRecoveryCodeForIdentityBody(request).
-		Execute()
+// checkIdentityVersion fetches the identity and compares its ETag with ifMatch, "*" matches any version
+func (s *Service) checkIdentityVersion(ctx context.Context, ID, ifMatch string) (*IdentityData, error) {
+	current, err := s.GetIdentity(ctx, ID)
 
 	if err != nil {
-		return "", "", err
+		return current, err
 	}
 
-	if response.StatusCode != http.StatusCreated {
-		return "", "", fmt.Errorf("unable to create recovery code for Identity %v, status code %d", identityId, response.StatusCode)
+	if ifMatch == "*" || ifMatch == IdentityETag(&current.Identities[0]) {
+		return current, nil
 	}
 
-	return recoveryInfo.RecoveryCode, recoveryInfo.RecoveryLink, nil
+	err = fmt.Errorf("identity %s was modified, ETag doesn't match", ID)
+
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+	data.Error = kClient.NewGenericErrorWithDefaults()
+	data.Error.SetCode(http.StatusPreconditionFailed)
+	data.Error.SetMessage(err.Error())
+	data.Error.SetReason(err.Error())
+
+	s.logger.Error(err)
+
+	return data, err
 }
 
-func (s *Service) UpdateIdentity(ctx context.Context, ID string, bodyID *kClient.UpdateIdentityBody) (*IdentityData, error) {
-	ctx, span := s.tracer.Start(ctx, "identities.Service.UpdateIdentity")
+// SetIdentityState flips the identity between the active and inactive states, when deactivating
+// with revokeSessions set all the sessions of the identity are deleted so the change is immediate
+func (s *Service) SetIdentityState(ctx context.Context, ID, state string, revokeSessions bool) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.SetIdentityState")
 	defer span.End()
-	if ID == "" {
-		err := fmt.Errorf("no identity ID passed")
 
-		data := new(IdentityData)
-		data.Identities = []kClient.Identity{}
-		data.Error = s.parseError(nil)
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+
+	if !IsValidIdentityState(state) {
+		err := fmt.Errorf("invalid identity state %s", state)
+
+		data.Error = kClient.NewGenericErrorWithDefaults()
+		data.Error.SetCode(http.StatusBadRequest)
 		data.Error.SetMessage(err.Error())
+		data.Error.SetReason(err.Error())
 
 		s.logger.Error(err)
 
 		return data, err
 	}
 
-	if bodyID == nil {
-		err := fmt.Errorf("no identity body passed")
+	patch := kClient.NewJsonPatch("replace", "/state")
+	patch.SetValue(state)
 
-		data := new(IdentityData)
-		data.Identities = []kClient.Identity{}
-		data.Error = s.parseError(nil)
-		data.Error.SetMessage(err.Error())
+	identity, rr, err := s.kratos.PatchIdentityExecute(
+		s.kratos.PatchIdentity(ctx, ID).JsonPatch([]kClient.JsonPatch{*patch}),
+	)
+
+	action := audit.IdentityActivate
+	if state == IdentityStateInactive {
+		action = audit.IdentityDeactivate
+	}
 
+	s.auditor.Record(ctx, action, audit.IdentityResource, ID, audit.OutcomeFromError(err))
+
+	if err != nil {
 		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+		return data, err
+	}
+
+	data.Identities = []kClient.Identity{*identity}
+
+	if state != IdentityStateInactive || !revokeSessions {
+		return data, nil
+	}
 
+	rr, err = s.kratos.DeleteIdentitySessionsExecute(
+		s.kratos.DeleteIdentitySessions(ctx, ID),
+	)
+
+	// kratos answers 404 when the identity has no session to delete
+	if err != nil && (rr == nil || rr.StatusCode != http.StatusNotFound) {
+		s.logger.Error(err)
+		s.auditor.Record(ctx, audit.IdentityRevokeSessions, audit.IdentityResource, ID, audit.OutcomeFailure)
+		data.Error = s.parseError(rr)
 		return data, err
 	}
 
-	identity, rr, err := s.kratos.UpdateIdentityExecute(
-		s.kratos.UpdateIdentity(ctx, ID).UpdateIdentityBody(*bodyID),
+	s.auditor.Record(ctx, audit.IdentityRevokeSessions, audit.IdentityResource, ID, audit.OutcomeSuccess)
+
+	return data, nil
+}
+
+// DeleteSession disables a single session, unlike the revocation done by SetIdentityState the
+// other sessions of the identity stay valid
+func (s *Service) DeleteSession(ctx context.Context, sessionID string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.DeleteSession")
+	defer span.End()
+
+	rr, err := s.kratos.DisableSessionExecute(
+		s.kratos.DisableSession(ctx, sessionID),
 	)
 
+	s.auditor.Record(ctx, audit.SessionDisable, audit.SessionResource, sessionID, audit.OutcomeFromError(err))
+
 	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
 
 	if err != nil {
 		s.logger.Error(err)
 		data.Error = s.parseError(rr)
-	}
 
-	if identity != nil {
-		data.Identities = []kClient.Identity{*identity}
-	} else {
-		data.Identities = []kClient.Identity{}
+		return data, err
 	}
 
-	return data, err
+	return data, nil
 }
 
-func (s *Service) DeleteIdentity(ctx context.Context, ID string) (*IdentityData, error) {
-	ctx, span := s.tracer.Start(ctx, "identities.Service.DeleteIdentity")
+// IsValidCredentialType checks the value against the credential types that can be removed from an identity
+func IsValidCredentialType(credentialType string) bool {
+	switch credentialType {
+	case CredentialTypeTOTP, CredentialTypeWebAuthn, CredentialTypeLookupSecret, CredentialTypeOIDC, CredentialTypePassword:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeleteIdentityCredential removes a credential of the specified type from the identity
+// used to reset second factors like totp when a user loses access to them
+func (s *Service) DeleteIdentityCredential(ctx context.Context, ID, credentialType string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.DeleteIdentityCredential")
 	defer span.End()
 
-	rr, err := s.kratos.DeleteIdentityExecute(
-		s.kratos.DeleteIdentity(ctx, ID),
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+
+	if !IsValidCredentialType(credentialType) {
+		err := fmt.Errorf("invalid credential type %s", credentialType)
+
+		data.Error = kClient.NewGenericErrorWithDefaults()
+		data.Error.SetCode(http.StatusBadRequest)
+		data.Error.SetMessage(err.Error())
+		data.Error.SetReason(err.Error())
+
+		s.logger.Error(err)
+
+		return data, err
+	}
+
+	rr, err := s.kratos.DeleteIdentityCredentialsExecute(
+		s.kratos.DeleteIdentityCredentials(ctx, ID, credentialType),
 	)
 
-	data := new(IdentityData)
+	s.auditor.Record(ctx, audit.IdentityDeleteCredential, audit.IdentityResource, ID, audit.OutcomeFromError(err))
 
-	data.Identities = []kClient.Identity{}
 	if err != nil {
 		s.logger.Error(err)
 		data.Error = s.parseError(rr)
 		return data, err
 	}
 
-	s.authz.SetDeleteIdentityEntitlements(ctx, ID)
-
-	return data, err
+	return data, nil
 }
 
-func NewService(kratos kClient.IdentityAPI, authz AuthorizerInterface, email mail.EmailServiceInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+func NewService(kratos kClient.IdentityAPI, authz AuthorizerInterface, email mail.EmailServiceInterface, wpool pool.WorkerPoolInterface, auditor audit.AuditorInterface, search *SearchConfig, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
 	s := new(Service)
 
 	s.kratos = kratos
 	s.authz = authz
 	s.email = email
+	s.wpool = wpool
+	s.auditor = auditor
+	s.search = search
+
+	if s.search == nil {
+		s.search = NewSearchConfig(nil, 0)
+	}
+
+	s.schemas = make(map[string]cachedSchema)
 
 	s.monitor = monitor
 	s.tracer = tracer
@@ -307,20 +1559,54 @@ func NewService(kratos kClient.IdentityAPI, authz AuthorizerInterface, email mai
 	return s
 }
 
+const (
+	PermissionSourceDirect = "direct"
+	PermissionSourceGroup  = "group"
+	PermissionSourceRole   = "role"
+)
+
+// PermissionSource is where an effective permission comes from, ID is empty for direct assignments
+type PermissionSource struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+}
+
+// EffectivePermission is a permission held by an identity, directly or through its groups and roles
+type EffectivePermission struct {
+	Relation string             `json:"relation"`
+	Object   string             `json:"object"`
+	Sources  []PermissionSource `json:"sources"`
+}
+
+type sourcePermissionsResult struct {
+	source      PermissionSource
+	permissions []ofga.Permission
+	err         error
+}
+
 type V1Service struct {
 	cmName      string
 	cmNamespace string
 
-	k8s   coreV1.CoreV1Interface
-	store OpenFGAStoreInterface
+	k8s    coreV1.CoreV1Interface
+	store  OpenFGAStoreInterface
+	wpool  pool.WorkerPoolInterface
+	traits *TraitsMapping
+
+	defaultSchema *defaultSchemaCache
 
 	core *Service
 }
 
+// getDefaultSchema returns the default schema ID, the ConfigMap is read at most once per SchemaCacheTTL
 func (s *V1Service) getDefaultSchema(ctx context.Context) (string, error) {
 	ctx, span := s.core.tracer.Start(ctx, "identities.V1Service.getDefaultSchema")
 	defer span.End()
 
+	return s.defaultSchema.get(ctx, s.readDefaultSchema)
+}
+
+func (s *V1Service) readDefaultSchema(ctx context.Context) (string, error) {
 	cm, err := s.k8s.ConfigMaps(s.cmNamespace).Get(ctx, s.cmName, metaV1.GetOptions{})
 
 	if err != nil {
@@ -337,6 +1623,51 @@ func (s *V1Service) getDefaultSchema(ctx context.Context) (string, error) {
 	return ID, nil
 }
 
+// InvalidateSchemaCache drops the cached default schema ID and identity schemas
+func (s *V1Service) InvalidateSchemaCache(ctx context.Context) {
+	_, span := s.core.tracer.Start(ctx, "identities.V1Service.InvalidateSchemaCache")
+	defer span.End()
+
+	s.defaultSchema.invalidate()
+
+	s.core.schemasMu.Lock()
+	s.core.schemas = make(map[string]cachedSchema)
+	s.core.schemasMu.Unlock()
+}
+
+// parseListFilter reads params.Filter as a comma separated list of key=value pairs
+// supported keys are schema_id and state, e.g. "schema_id=default,state=active"
+func (s *V1Service) parseListFilter(params *resources.GetIdentitiesParams) (ListIdentitiesFilter, error) {
+	filter := ListIdentitiesFilter{}
+
+	if params == nil || params.Filter == nil || *params.Filter == "" {
+		return filter, nil
+	}
+
+	for _, pair := range strings.Split(*params.Filter, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+
+		if !found || value == "" {
+			return filter, v1.NewValidationError(fmt.Sprintf("invalid filter %s, expected key=value", pair))
+		}
+
+		switch key {
+		case "schema_id":
+			filter.SchemaID = value
+		case "state":
+			if !IsValidIdentityState(value) {
+				return filter, v1.NewValidationError(fmt.Sprintf("invalid state %s, allowed values are %s and %s", value, IdentityStateActive, IdentityStateInactive))
+			}
+
+			filter.State = value
+		default:
+			return filter, v1.NewValidationError(fmt.Sprintf("unsupported filter %s", key))
+		}
+	}
+
+	return filter, nil
+}
+
 // ListIdentities returns a page of Identity objects of at least `size` elements if available
 func (s *V1Service) ListIdentities(ctx context.Context, params *resources.GetIdentitiesParams) (*resources.PaginatedResponse[resources.Identity], error) {
 	ctx, span := s.core.tracer.Start(ctx, "identities.V1Service.ListIdentities")
@@ -353,8 +1684,13 @@ func (s *V1Service) ListIdentities(ctx context.Context, params *resources.GetIde
 		token = *params.NextToken
 	}
 
-	// TODO @shipperizer use params.Filter to fetch credID
-	ids, err := s.core.ListIdentities(ctx, int64(size), token, "")
+	filter, err := s.parseListFilter(params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.core.ListIdentities(ctx, int64(size), token, filter)
 
 	if err != nil {
 		return nil, v1.NewUnknownError(err.Error())
@@ -365,41 +1701,10 @@ func (s *V1Service) ListIdentities(ctx context.Context, params *resources.GetIde
 	r.Meta = resources.ResponseMeta{Size: len(ids.Identities), PageToken: &token}
 	r.Next = resources.Next{PageToken: &ids.Tokens.Next}
 	for _, id := range ids.Identities {
-		traits, ok := id.Traits.(map[string]string)
-
-		if !ok {
-			traits = make(map[string]string)
-		}
-
 		// TODO @shipperizer enhance Identity resource with Permissions and Roles on the next iteration
 		// this requires calls to openfga in here unless we enhance the PrincipalContext and let that do
 		// the calls
-		i := resources.Identity{
-			Id: &id.Id,
-		}
-
-		if email, ok := traits["email"]; ok {
-			i.Email = email
-		}
-
-		fullname, ok := traits["name"]
-
-		if !ok {
-			r.Data = append(r.Data, i)
-			continue
-		}
-
-		surnameIndex := strings.LastIndex(fullname, " ")
-
-		if surnameIndex > 0 {
-			name := strings.Trim(fullname[0:surnameIndex], " ")
-			surname := strings.Trim(fullname[surnameIndex:], " ")
-
-			i.FirstName = &name
-			i.LastName = &surname
-		}
-
-		r.Data = append(r.Data, i)
+		r.Data = append(r.Data, s.traits.ToIdentity(id.Id, id.Traits))
 	}
 
 	return r, nil
@@ -421,13 +1726,7 @@ func (s *V1Service) CreateIdentity(ctx context.Context, identity *resources.Iden
 		return nil, v1.NewRequestBodyValidationError("bad identity payload")
 	}
 
-	traits := make(map[string]interface{})
-
-	traits["email"] = identity.Email
-
-	if identity.FirstName != nil && identity.LastName != nil {
-		traits["name"] = fmt.Sprintf("%s %s", *identity.FirstName, *identity.LastName)
-	}
+	traits := s.traits.ToTraits(identity)
 
 	ids, err := s.core.CreateIdentity(ctx,
 		&kClient.CreateIdentityBody{
@@ -442,6 +1741,10 @@ func (s *V1Service) CreateIdentity(ctx context.Context, identity *resources.Iden
 	// TODO @shipperizer enhance Identity resource with Permissions and Roles on the next iteration
 	// this requires calls to openfga in here unless we enhance the PrincipalContext and let that do
 	// the calls
+	if errors.Is(err, ErrSchemaNotAllowed) {
+		return nil, v1.NewRequestBodyValidationError(err.Error())
+	}
+
 	if err != nil {
 		return nil, v1.NewUnknownError(err.Error())
 	}
@@ -475,39 +1778,12 @@ func (s *V1Service) GetIdentity(ctx context.Context, identityId string) (*resour
 
 	id := ids.Identities[0]
 
-	traits, ok := id.Traits.(map[string]string)
-
-	if !ok {
-		traits = make(map[string]string)
-	}
-
 	// TODO @shipperizer enhance Identity resource with Permissions and Roles on the next iteration
 	// this requires calls to openfga in here unless we enhance the PrincipalContext and let that do
 	// the calls
-	i := new(resources.Identity)
-
-	i.Id = &id.Id
-
-	if email, ok := traits["email"]; ok {
-		i.Email = email
-	}
+	i := s.traits.ToIdentity(id.Id, id.Traits)
 
-	fullname, ok := traits["name"]
-	if !ok {
-		return i, nil
-	}
-
-	surnameIndex := strings.LastIndex(fullname, " ")
-
-	if surnameIndex > 0 {
-		name := strings.Trim(fullname[0:surnameIndex], " ")
-		surname := strings.Trim(fullname[surnameIndex:], " ")
-
-		i.FirstName = &name
-		i.LastName = &surname
-	}
-
-	return i, nil
+	return &i, nil
 }
 
 // UpdateIdentity updates an Identity.
@@ -519,15 +1795,8 @@ func (s *V1Service) UpdateIdentity(ctx context.Context, identity *resources.Iden
 		return nil, v1.NewRequestBodyValidationError("bad identity payload")
 	}
 
-	traits := make(map[string]interface{})
-
-	traits["email"] = identity.Email
-	if identity.FirstName != nil && identity.LastName != nil {
-		traits["name"] = fmt.Sprintf("%s %s", *identity.FirstName, *identity.LastName)
-	}
-
 	body := kClient.NewUpdateIdentityBodyWithDefaults()
-	body.SetTraits(traits)
+	body.SetTraits(s.traits.ToTraits(identity))
 
 	ids, err := s.core.UpdateIdentity(
 		ctx,
@@ -535,6 +1804,7 @@ func (s *V1Service) UpdateIdentity(ctx context.Context, identity *resources.Iden
 		// TODO @shipperizer the code below assumes each schema has name and email
 		// needs to be validated as schemas might differ
 		body,
+		"",
 	)
 
 	if err != nil {
@@ -547,40 +1817,12 @@ func (s *V1Service) UpdateIdentity(ctx context.Context, identity *resources.Iden
 
 	id := ids.Identities[0]
 
-	ts, ok := id.GetTraits().(map[string]string)
-
-	if !ok {
-		ts = make(map[string]string)
-	}
-
 	// TODO @shipperizer enhance Identity resource with Permissions and Roles on the next iteration
 	// this requires calls to openfga in here unless we enhance the PrincipalContext and let that do
 	// the calls
-	i := new(resources.Identity)
-
-	i.Id = &id.Id
-
-	if email, ok := ts["email"]; ok {
-		i.Email = email
-	}
-
-	fullname, ok := ts["name"]
-	if !ok {
-		return i, nil
-	}
-
-	surnameIndex := strings.LastIndex(fullname, " ")
-
-	if surnameIndex > 0 {
-		name := strings.Trim(fullname[0:surnameIndex], " ")
-		surname := strings.Trim(fullname[surnameIndex:], " ")
-
-		i.FirstName = &name
-		i.LastName = &surname
-	}
-
-	return i, nil
+	i := s.traits.ToIdentity(id.Id, id.GetTraits())
 
+	return &i, nil
 }
 
 // DeleteIdentity deletes an Identity
@@ -799,11 +2041,132 @@ func (s *V1Service) PatchIdentityEntitlements(ctx context.Context, identityId st
 	return true, nil
 }
 
+// GetEffectivePermissions returns the union of the permissions identity `identityId` holds directly
+// and through its groups and roles, sorted by object and relation, each annotated with its sources
+func (s *V1Service) GetEffectivePermissions(ctx context.Context, identityId string) ([]EffectivePermission, error) {
+	ctx, span := s.core.tracer.Start(ctx, "identities.V1Service.GetEffectivePermissions")
+	defer span.End()
+
+	user := fmt.Sprintf("user:%s", identityId)
+
+	groups, err := s.store.ListAssignedGroups(ctx, user)
+	if err != nil {
+		return nil, v1.NewUnknownError(err.Error())
+	}
+
+	roles, err := s.store.ListAssignedRoles(ctx, user)
+	if err != nil {
+		return nil, v1.NewUnknownError(err.Error())
+	}
+
+	sources := []PermissionSource{{Type: PermissionSourceDirect}}
+
+	for _, group := range groups {
+		sources = append(sources, PermissionSource{Type: PermissionSourceGroup, ID: group})
+	}
+
+	for _, role := range roles {
+		sources = append(sources, PermissionSource{Type: PermissionSourceRole, ID: role})
+	}
+
+	// buffered so workers never block on sending, see OpenFGAStore.ListPermissions
+	results := make(chan *pool.Result[any], len(sources))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(sources))
+
+	submitErrors := make([]error, 0)
+
+	for _, source := range sources {
+		if _, err := s.wpool.Submit(s.sourcePermissionsFunc(ctx, user, source), results, &wg); err != nil {
+			wg.Done()
+			submitErrors = append(submitErrors, err)
+		}
+	}
+
+	wg.Wait()
+	close(results)
+
+	if len(submitErrors) > 0 {
+		s.core.logger.Errorf("failed expanding effective permissions of %s: %s", identityId, submitErrors[0])
+		return nil, v1.NewUnknownError(submitErrors[0].Error())
+	}
+
+	aggregated := make(map[ofga.Permission]*EffectivePermission)
+
+	for r := range results {
+		v := r.Value.(sourcePermissionsResult)
+
+		if v.err != nil {
+			s.core.logger.Errorf("failed listing permissions of %s %s: %s", v.source.Type, v.source.ID, v.err)
+			return nil, v1.NewUnknownError(v.err.Error())
+		}
+
+		for _, permission := range v.permissions {
+			p, ok := aggregated[permission]
+
+			if !ok {
+				p = &EffectivePermission{Relation: permission.Relation, Object: permission.Object, Sources: make([]PermissionSource, 0)}
+				aggregated[permission] = p
+			}
+
+			p.Sources = append(p.Sources, v.source)
+		}
+	}
+
+	permissions := make([]EffectivePermission, 0, len(aggregated))
+
+	for _, p := range aggregated {
+		// results come back in completion order, keep the sources stable across calls
+		sort.Slice(p.Sources, func(i, j int) bool {
+			if p.Sources[i].Type != p.Sources[j].Type {
+				return p.Sources[i].Type < p.Sources[j].Type
+			}
+
+			return p.Sources[i].ID < p.Sources[j].ID
+		})
+
+		permissions = append(permissions, *p)
+	}
+
+	sort.Slice(permissions, func(i, j int) bool {
+		if permissions[i].Object != permissions[j].Object {
+			return permissions[i].Object < permissions[j].Object
+		}
+
+		return permissions[i].Relation < permissions[j].Relation
+	})
+
+	return permissions, nil
+}
+
+func (s *V1Service) sourcePermissionsFunc(ctx context.Context, user string, source PermissionSource) func() any {
+	return func() any {
+		ID := user
+
+		switch source.Type {
+		case PermissionSourceGroup:
+			ID = fmt.Sprintf("group:%s#%s", source.ID, ofga.MEMBER_RELATION)
+		case PermissionSourceRole:
+			ID = fmt.Sprintf("role:%s#%s", source.ID, ofga.ASSIGNEE_RELATION)
+		}
+
+		permissions, err := s.store.ListAllPermissions(ctx, ID)
+
+		return sourcePermissionsResult{source: source, permissions: permissions, err: err}
+	}
+}
+
 type Config struct {
 	Name         string
 	Namespace    string
 	K8s          coreV1.CoreV1Interface
 	OpenFGAStore OpenFGAStoreInterface
+	WorkerPool   pool.WorkerPoolInterface
+	// TraitsMapping maps kratos traits to resources.Identity, DefaultTraitsMapping is used if nil
+	TraitsMapping *TraitsMapping
+	// SchemaCacheTTL is how long the default schema ID read from the ConfigMap is reused, 0 disables caching
+	SchemaCacheTTL time.Duration
 }
 
 func NewV1Service(config *Config, svc *Service) *V1Service {
@@ -814,6 +2177,13 @@ func NewV1Service(config *Config, svc *Service) *V1Service {
 	s.cmName = config.Name
 	s.cmNamespace = config.Namespace
 	s.store = config.OpenFGAStore
+	s.wpool = config.WorkerPool
+	s.traits = config.TraitsMapping
+	s.defaultSchema = newDefaultSchemaCache(config.SchemaCacheTTL)
+
+	if s.traits == nil {
+		s.traits = DefaultTraitsMapping()
+	}
 
 	return s
 }
@@ -6,11 +6,15 @@ package identities
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	reflect "reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	v1 "github.com/canonical/rebac-admin-ui-handlers/v1"
 	"github.com/canonical/rebac-admin-ui-handlers/v1/interfaces"
@@ -21,16 +25,20 @@ import (
 	gomock "go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
 	"github.com/canonical/identity-platform-admin-ui/internal/mail"
 	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_audit.go -source=../../internal/audit/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_interfaces.go -source=./interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_corev1.go k8s.io/client-go/kubernetes/typed/core/v1 CoreV1Interface,ConfigMapInterface
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
 //go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_kratos.go github.com/ory/kratos-client-go IdentityAPI
+//go:generate mockgen -build_flags=--mod=mod -package identities -destination ./mock_pool.go -source=../../internal/pool/interfaces.go
 
 func TestListIdentitiesSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -81,7 +89,7 @@ func TestListIdentitiesSuccess(t *testing.T) {
 		},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).ListIdentities(ctx, 10, "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ", "")
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentities(ctx, 10, "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ", ListIdentitiesFilter{})
 
 	if !reflect.DeepEqual(ids.Identities, identities) {
 		t.Fatalf("expected identities to be %v not  %v", identities, ids.Identities)
@@ -99,87 +107,140 @@ func TestListIdentitiesSuccess(t *testing.T) {
 	}
 }
 
-func TestListIdentitiesFails(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockLogger := NewMockLoggerInterface(ctrl)
-	mockTracer := NewMockTracer(ctrl)
-	mockMonitor := NewMockMonitorInterface(ctrl)
-	mockAuthz := NewMockAuthorizerInterface(ctrl)
-	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
-	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
-
-	ctx := context.Background()
+func TestListIdentitiesTotalCount(t *testing.T) {
+	total := int64(42)
 
-	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
-		ApiService: mockKratosIdentityAPI,
+	tests := []struct {
+		name     string
+		header   string
+		expected *int64
+	}{
+		{name: "with header", header: "42", expected: &total},
+		{name: "without header", header: "", expected: nil},
 	}
 
-	identities := make([]kClient.Identity, 0)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
-	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
 
-			// use reflect as attributes are private, also are pointers so need to cast it multiple times
-			if pageToken := (*string)(reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer()); *pageToken != "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ" {
-				t.Fatalf("expected pageToken as eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ, got %v", *pageToken)
-			}
+			ctx := context.Background()
 
-			if pageSize := (*int64)(reflect.ValueOf(r).FieldByName("pageSize").UnsafePointer()); *pageSize != 10 {
-				t.Fatalf("expected page size as 10, got %v", *pageSize)
+			identityRequest := kClient.IdentityAPIListIdentitiesRequest{
+				ApiService: mockKratosIdentityAPI,
 			}
 
-			if credID := (*string)(reflect.ValueOf(r).FieldByName("credentialsIdentifier").UnsafePointer()); *credID != "test" {
-				t.Fatalf("expected credential id to be test, got %v", *credID)
-			}
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(1).Return(identityRequest)
+			mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(1).DoAndReturn(
+				func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+					rr := httptest.NewRecorder()
 
-			rr := httptest.NewRecorder()
-			rr.Header().Set("Content-Type", "application/json")
-			rr.WriteHeader(http.StatusInternalServerError)
+					if test.header != "" {
+						rr.Header().Set("X-Total-Count", test.header)
+					}
 
-			json.NewEncoder(rr).Encode(
-				map[string]interface{}{
-					"error": map[string]interface{}{
-						"code":    http.StatusInternalServerError,
-						"debug":   "--------",
-						"details": map[string]interface{}{},
-						"id":      "string",
-						"message": "error",
-						"reason":  "error",
-						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
-						"status":  "Not Found",
-					},
+					rr.WriteHeader(http.StatusOK)
+
+					return make([]kClient.Identity, 0), rr.Result(), nil
 				},
 			)
 
-			return identities, rr.Result(), fmt.Errorf("error")
-		},
-	)
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentities(ctx, 10, "", ListIdentitiesFilter{})
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).ListIdentities(ctx, 10, "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ", "test")
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
 
-	if !reflect.DeepEqual(ids.Identities, identities) {
-		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
+			if !reflect.DeepEqual(ids.Total, test.expected) {
+				t.Fatalf("expected total to be %v not %v", test.expected, ids.Total)
+			}
+		})
 	}
+}
 
-	if ids.Error == nil {
-		t.Fatal("expected ids.Error to be not nil")
+func TestCountIdentities(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		pages    []int
+		expected int64
+	}{
+		{name: "with header", header: "42", pages: []int{1}, expected: 42},
+		{name: "without header", pages: []int{1, 500, 500, 3}, expected: 1003},
 	}
 
-	if *ids.Error.Code != http.StatusInternalServerError {
-		t.Fatalf("expected code to be %v not  %v", http.StatusInternalServerError, *ids.Error.Code)
-	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	if err == nil {
-		t.Fatal("expected error to be not nil")
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+			call := 0
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(len(test.pages)).Return(kClient.IdentityAPIListIdentitiesRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(len(test.pages)).DoAndReturn(
+				func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+					// use reflect as attributes are private, also are pointers so need to cast it multiple times
+					pageSize := *(*int64)(reflect.ValueOf(r).FieldByName("pageSize").UnsafePointer())
+
+					// a single identity page is enough to read the header, the scan uses bigger pages
+					expected := int64(500)
+					if call == 0 {
+						expected = 1
+					}
+
+					if pageSize != expected {
+						t.Fatalf("expected page size %d got %d", expected, pageSize)
+					}
+
+					rr := new(http.Response)
+					rr.Header = make(http.Header)
+
+					if test.header != "" {
+						rr.Header.Set("X-Total-Count", test.header)
+					}
+
+					if call > 0 && call < len(test.pages)-1 {
+						rr.Header.Set("Link", fmt.Sprintf(`<http://kratos/identities?page_size=500&page_token=page-%d>; rel="next"`, call+1))
+					}
+
+					identities := make([]kClient.Identity, test.pages[call])
+					call++
+
+					return identities, rr, nil
+				},
+			)
+
+			count, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CountIdentities(ctx)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+
+			if count != test.expected {
+				t.Fatalf("expected count to be %d not %d", test.expected, count)
+			}
+		})
 	}
 }
 
-func TestGetIdentitySuccess(t *testing.T) {
+func TestListIdentitiesFiltersBySchemaAndState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -191,29 +252,97 @@ func TestGetIdentitySuccess(t *testing.T) {
 	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
 
 	ctx := context.Background()
-	credID := "test-1"
 
-	identityRequest := kClient.IdentityAPIGetIdentityRequest{
+	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
-	identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	// 3 pages of 4 identities, only odd ones match the filter
+	pages := make(map[string][]kClient.Identity)
+	expected := make([]kClient.Identity, 0)
 
-	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+	for p, pageToken := range []string{"page-0", "page-1", "page-2"} {
+		pages[pageToken] = make([]kClient.Identity, 0)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).GetIdentity(ctx, credID)
+		for i := 0; i < 4; i++ {
+			schemaID := "other.json"
+			state := IdentityStateInactive
 
-	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
-		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
+			if i%2 == 1 {
+				schemaID = "test.json"
+				state = IdentityStateActive
+			}
+
+			identity := kClient.NewIdentity(fmt.Sprintf("test-%v-%v", p, i), schemaID, fmt.Sprintf("https://test.com/%s", schemaID), map[string]string{"name": "name"})
+			identity.SetState(state)
+
+			pages[pageToken] = append(pages[pageToken], *identity)
+
+			// size is 3, fetching stops after the second page, both of its matches are returned
+			if schemaID == "test.json" && p < 2 {
+				expected = append(expected, *identity)
+			}
+		}
+	}
+
+	linkHeader := func(prev, next string) string {
+		return fmt.Sprintf(
+			`<http://kratos-admin.default.svc.cluster.local/identities?page_size=3&page_token=%s&per_page=3>; rel="prev",<http://kratos-admin.default.svc.cluster.local/identities?page_size=3&page_token=%s&per_page=3>; rel="next"`,
+			prev,
+			next,
+		)
 	}
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(2).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(2).DoAndReturn(
+		func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+			// use reflect as attributes are private, also are pointers so need to cast it multiple times
+			pageToken := *(*string)(reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer())
+
+			if pageSize := (*int64)(reflect.ValueOf(r).FieldByName("pageSize").UnsafePointer()); *pageSize != 3 {
+				t.Fatalf("expected page size as 3, got %v", *pageSize)
+			}
+
+			rr := new(http.Response)
+			rr.Header = make(http.Header)
+			rr.Header.Set("X-Total-Count", "12")
+
+			switch pageToken {
+			case "page-0":
+				rr.Header.Set("Link", linkHeader("page-prev", "page-1"))
+			case "page-1":
+				rr.Header.Set("Link", linkHeader("page-0", "page-2"))
+			default:
+				t.Fatalf("unexpected page token %s", pageToken)
+			}
+
+			return pages[pageToken], rr, nil
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentities(
+		ctx, 3, "page-0", ListIdentitiesFilter{SchemaID: "test.json", State: IdentityStateActive},
+	)
+
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
+
+	if !reflect.DeepEqual(ids.Identities, expected) {
+		t.Fatalf("expected identities to be %v not  %v", expected, ids.Identities)
+	}
+
+	if ids.Tokens.Prev != "page-prev" || ids.Tokens.Next != "page-2" {
+		t.Fatalf("expected tokens to point around the fetched pages, not %v", ids.Tokens)
+	}
+
+	if ids.Total != nil {
+		t.Fatalf("expected total to be nil when filtering, not %v", *ids.Total)
+	}
 }
 
-func TestGetIdentityFails(t *testing.T) {
+func TestListIdentitiesFiltersPagesWithoutDuplicates(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -225,60 +354,76 @@ func TestGetIdentityFails(t *testing.T) {
 	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
 
 	ctx := context.Background()
-	credID := "test"
 
-	identityRequest := kClient.IdentityAPIGetIdentityRequest{
+	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
-	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	// 3 pages of 2 identities, all of them match so every page has more matches than fit after the first one
+	pages := map[string][]kClient.Identity{}
+	next := map[string]string{"": "page-1", "page-1": "page-2", "page-2": ""}
+
+	for p, pageToken := range []string{"", "page-1", "page-2"} {
+		for i := 0; i < 2; i++ {
+			identity := kClient.NewIdentity(fmt.Sprintf("test-%v-%v", p, i), "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+			pages[pageToken] = append(pages[pageToken], *identity)
+		}
+	}
+
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
-			rr := httptest.NewRecorder()
-			rr.Header().Set("Content-Type", "application/json")
-			rr.WriteHeader(http.StatusNotFound)
+	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).AnyTimes().Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).AnyTimes().DoAndReturn(
+		func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+			pageToken := ""
 
-			json.NewEncoder(rr).Encode(
-				map[string]interface{}{
-					"error": map[string]interface{}{
-						"code":    http.StatusNotFound,
-						"debug":   "--------",
-						"details": map[string]interface{}{},
-						"id":      "string",
-						"message": "error",
-						"reason":  "error",
-						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
-						"status":  "Not Found",
-					},
-				},
-			)
+			if ptr := reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer(); ptr != nil {
+				pageToken = *(*string)(ptr)
+			}
 
-			return nil, rr.Result(), fmt.Errorf("error")
+			rr := new(http.Response)
+			rr.Header = make(http.Header)
+
+			if next[pageToken] != "" {
+				rr.Header.Set("Link", fmt.Sprintf(`<http://kratos-admin.default.svc.cluster.local/identities?page_size=3&page_token=%s&per_page=3>; rel="next"`, next[pageToken]))
+			}
+
+			return pages[pageToken], rr, nil
 		},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).GetIdentity(ctx, credID)
+	svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
 
-	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
-		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
-	}
+	seen := make(map[string]int)
+	token := ""
 
-	if ids.Error == nil {
-		t.Fatal("expected ids.Error to be not nil")
+	for calls := 0; calls < 10; calls++ {
+		ids, err := svc.ListIdentities(ctx, 3, token, ListIdentitiesFilter{SchemaID: "test.json"})
+
+		if err != nil {
+			t.Fatalf("expected error to be nil not  %v", err)
+		}
+
+		for _, identity := range ids.Identities {
+			seen[identity.Id]++
+		}
+
+		if token = ids.Tokens.Next; token == "" {
+			break
+		}
 	}
 
-	if *ids.Error.Code != int64(http.StatusNotFound) {
-		t.Fatalf("expected code to be %v not  %v", http.StatusNotFound, *ids.Error.Code)
+	if len(seen) != 6 {
+		t.Fatalf("expected all 6 identities to be listed, got %v", seen)
 	}
 
-	if err == nil {
-		t.Fatal("expected error to be not nil")
+	for id, count := range seen {
+		if count != 1 {
+			t.Fatalf("expected %s to be listed once, got %v", id, count)
+		}
 	}
 }
 
-func TestCreateIdentitySuccess(t *testing.T) {
+func TestListIdentitiesFiltersStopsAtMaxPages(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -291,42 +436,45 @@ func TestCreateIdentitySuccess(t *testing.T) {
 
 	ctx := context.Background()
 
-	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
-	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "name", "email": "test@example.com"})
-	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
-	identityBody := kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name", "email": "test@example.com"})
-	identityBody.SetCredentials(*credentials)
+	page := 0
 
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id)
-	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPICreateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(2).Return(identityRequest)
+	// every page has a next one and no identity ever matches
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(2).DoAndReturn(
+		func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+			page++
 
-			// use reflect as attributes are private, also are pointers so need to cast it multiple times
-			if IDBody := (*kClient.CreateIdentityBody)(reflect.ValueOf(r).FieldByName("createIdentityBody").UnsafePointer()); !reflect.DeepEqual(*IDBody, *identityBody) {
-				t.Fatalf("expected body to be %v, got %v", identityBody, IDBody)
-			}
+			rr := new(http.Response)
+			rr.Header = make(http.Header)
+			rr.Header.Set("Link", fmt.Sprintf(`<http://kratos-admin.default.svc.cluster.local/identities?page_size=10&page_token=page-%v>; rel="next"`, page))
 
-			return identity, new(http.Response), nil
+			return []kClient.Identity{*kClient.NewIdentity(fmt.Sprintf("test-%v", page), "other.json", "https://test.com/other.json", nil)}, rr, nil
 		},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, identityBody)
-
-	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
-		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
-	}
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), NewSearchConfig(nil, 2), mockTracer, mockMonitor, mockLogger).ListIdentities(
+		ctx, 10, "", ListIdentitiesFilter{SchemaID: "test.json"},
+	)
 
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
+
+	if len(ids.Identities) != 0 {
+		t.Fatalf("expected no identities, got %v", ids.Identities)
+	}
+
+	if ids.Tokens.Next != "page-2" {
+		t.Fatalf("expected next token to be the one of the last page scanned, got %v", ids.Tokens.Next)
+	}
 }
 
-func TestCreateIdentityFails(t *testing.T) {
+func TestListIdentitiesFiltersStopsWithoutNextPage(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -339,110 +487,95 @@ func TestCreateIdentityFails(t *testing.T) {
 
 	ctx := context.Background()
 
-	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
-	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
-	identityBody := kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name"})
-	identityBody.SetCredentials(*credentials)
+	identities := []kClient.Identity{
+		*kClient.NewIdentity("test-0", "other.json", "https://test.com/other.json", map[string]string{"name": "name"}),
+	}
 
-	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPICreateIdentityRequest) (*kClient.Identity, *http.Response, error) {
-			rr := httptest.NewRecorder()
-			rr.Header().Set("Content-Type", "application/json")
-			rr.WriteHeader(http.StatusInternalServerError)
+	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(1).Return(identities, &http.Response{Header: make(http.Header)}, nil)
 
-			json.NewEncoder(rr).Encode(
-				map[string]interface{}{
-					"error": map[string]interface{}{
-						"code":    http.StatusInternalServerError,
-						"debug":   "--------",
-						"details": map[string]interface{}{},
-						"id":      "string",
-						"message": "error",
-						"reason":  "error",
-						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
-						"status":  "Internal Server Error",
-					},
-				},
-			)
-
-			return nil, rr.Result(), fmt.Errorf("error")
-		},
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentities(
+		ctx, 10, "", ListIdentitiesFilter{SchemaID: "test.json"},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, identityBody)
-
-	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
-		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
-	}
-
-	if ids.Error == nil {
-		t.Fatal("expected ids.Error to be not nil")
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
 	}
 
-	if *ids.Error.Code != int64(http.StatusInternalServerError) {
-		t.Fatalf("expected code to be %v not  %v", http.StatusInternalServerError, *ids.Error.Code)
+	if len(ids.Identities) != 0 {
+		t.Fatalf("expected no identities, got %v", ids.Identities)
 	}
 
-	if err == nil {
-		t.Fatal("expected error to be not nil")
+	if ids.Tokens.Next != "" {
+		t.Fatalf("expected next token to be empty, got %v", ids.Tokens.Next)
 	}
 }
 
-func TestUpdateIdentitySuccess(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockLogger := NewMockLoggerInterface(ctrl)
-	mockTracer := NewMockTracer(ctrl)
-	mockMonitor := NewMockMonitorInterface(ctrl)
-	mockAuthz := NewMockAuthorizerInterface(ctrl)
-	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
-	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
-
-	ctx := context.Background()
-
-	identityRequest := kClient.IdentityAPIUpdateIdentityRequest{
-		ApiService: mockKratosIdentityAPI,
+func TestProjectTraits(t *testing.T) {
+	identities := []kClient.Identity{
+		*kClient.NewIdentity("joe", "test.json", "https://test.com/test.json", map[string]interface{}{
+			"email": "joe@example.com",
+			"name":  map[string]interface{}{"first": "Joe", "last": "Doe"},
+		}),
+		*kClient.NewIdentity("no-traits", "test.json", "https://test.com/test.json", nil),
 	}
 
-	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
-	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
-	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
-	identityBody.SetTraits(map[string]interface{}{"name": "name"})
-	identityBody.SetCredentials(*credentials)
-
-	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, identity.Id).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
-
-			// use reflect as attributes are private, also are pointers so need to cast it multiple times
-			if IDBody := (*kClient.UpdateIdentityBody)(reflect.ValueOf(r).FieldByName("updateIdentityBody").UnsafePointer()); !reflect.DeepEqual(*IDBody, *identityBody) {
-				t.Fatalf("expected body to be %v, got %v", identityBody, IDBody)
-			}
-
-			return identity, new(http.Response), nil
+	tests := []struct {
+		name     string
+		fields   []string
+		expected []interface{}
+	}{
+		{
+			name:     "no fields",
+			fields:   nil,
+			expected: []interface{}{identities[0].Traits, nil},
 		},
-	)
+		{
+			name:     "top level",
+			fields:   []string{"email"},
+			expected: []interface{}{map[string]interface{}{"email": "joe@example.com"}, nil},
+		},
+		{
+			name:     "nested",
+			fields:   []string{"name.last"},
+			expected: []interface{}{map[string]interface{}{"name": map[string]interface{}{"last": "Doe"}}, nil},
+		},
+		{
+			name:     "parent wins over nested",
+			fields:   []string{"name", "name.first"},
+			expected: []interface{}{map[string]interface{}{"name": map[string]interface{}{"first": "Joe", "last": "Doe"}}, nil},
+		},
+		{
+			name:     "unknown fields",
+			fields:   []string{"phone", "email.domain", "name.middle"},
+			expected: []interface{}{map[string]interface{}{}, nil},
+		},
+	}
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, identity.Id, identityBody)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projected := ProjectTraits(identities, test.fields)
 
-	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
-		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
+			for i, identity := range projected {
+				if !reflect.DeepEqual(identity.Traits, test.expected[i]) {
+					t.Errorf("expected traits of %s to be %v got %v", identity.Id, test.expected[i], identity.Traits)
+				}
+			}
+		})
 	}
 
-	if err != nil {
-		t.Fatalf("expected error to be nil not  %v", err)
+	// the input must be left untouched
+	if _, ok := identities[0].Traits.(map[string]interface{})["name"].(map[string]interface{})["last"]; !ok {
+		t.Errorf("expected the original traits not to be modified")
 	}
 }
 
-func TestUpdateIdentityFails(t *testing.T) {
+func TestListIdentitiesFails(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -455,48 +588,57 @@ func TestUpdateIdentityFails(t *testing.T) {
 
 	ctx := context.Background()
 
-	credID := "test"
-
-	identityRequest := kClient.IdentityAPIUpdateIdentityRequest{
+	identityRequest := kClient.IdentityAPIListIdentitiesRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
-	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
-	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
-	identityBody.SetTraits(map[string]interface{}{"name": "name"})
-	identityBody.SetCredentials(*credentials)
+	identities := make([]kClient.Identity, 0)
 
 	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, credID).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+	mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+
+			// use reflect as attributes are private, also are pointers so need to cast it multiple times
+			if pageToken := (*string)(reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer()); *pageToken != "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ" {
+				t.Fatalf("expected pageToken as eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ, got %v", *pageToken)
+			}
+
+			if pageSize := (*int64)(reflect.ValueOf(r).FieldByName("pageSize").UnsafePointer()); *pageSize != 10 {
+				t.Fatalf("expected page size as 10, got %v", *pageSize)
+			}
+
+			if credID := (*string)(reflect.ValueOf(r).FieldByName("credentialsIdentifier").UnsafePointer()); *credID != "test" {
+				t.Fatalf("expected credential id to be test, got %v", *credID)
+			}
+
 			rr := httptest.NewRecorder()
 			rr.Header().Set("Content-Type", "application/json")
-			rr.WriteHeader(http.StatusConflict)
+			rr.WriteHeader(http.StatusInternalServerError)
 
 			json.NewEncoder(rr).Encode(
 				map[string]interface{}{
 					"error": map[string]interface{}{
-						"code":    http.StatusConflict,
+						"code":    http.StatusInternalServerError,
 						"debug":   "--------",
 						"details": map[string]interface{}{},
 						"id":      "string",
 						"message": "error",
 						"reason":  "error",
 						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
-						"status":  "Conflict",
+						"status":  "Not Found",
 					},
 				},
 			)
 
-			return nil, rr.Result(), fmt.Errorf("error")
+			return identities, rr.Result(), fmt.Errorf("error")
 		},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, credID, identityBody)
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).ListIdentities(ctx, 10, "eyJvZmZzZXQiOiIyNTAiLCJ2IjoyfQ", ListIdentitiesFilter{CredID: "test"})
 
-	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
+	if !reflect.DeepEqual(ids.Identities, identities) {
 		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
 	}
 
@@ -504,8 +646,8 @@ func TestUpdateIdentityFails(t *testing.T) {
 		t.Fatal("expected ids.Error to be not nil")
 	}
 
-	if *ids.Error.Code != int64(http.StatusConflict) {
-		t.Fatalf("expected code to be %v not  %v", http.StatusConflict, *ids.Error.Code)
+	if *ids.Error.Code != http.StatusInternalServerError {
+		t.Fatalf("expected code to be %v not  %v", http.StatusInternalServerError, *ids.Error.Code)
 	}
 
 	if err == nil {
@@ -513,7 +655,7 @@ func TestUpdateIdentityFails(t *testing.T) {
 	}
 }
 
-func TestDeleteIdentitySuccess(t *testing.T) {
+func TestGetIdentitySuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -527,27 +669,170 @@ func TestDeleteIdentitySuccess(t *testing.T) {
 	ctx := context.Background()
 	credID := "test-1"
 
-	identityRequest := kClient.IdentityAPIDeleteIdentityRequest{
+	identityRequest := kClient.IdentityAPIGetIdentityRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
+	identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockAuthz.EXPECT().SetDeleteIdentityEntitlements(gomock.Any(), credID)
-	mockKratosIdentityAPI.EXPECT().DeleteIdentity(ctx, credID).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().DeleteIdentityExecute(gomock.Any()).Times(1).Return(new(http.Response), nil)
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).DeleteIdentity(ctx, credID)
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).GetIdentity(ctx, credID)
 
-	if len(ids.Identities) > 0 {
-		t.Fatalf("invalid result, expected no identities, got %v", ids.Identities)
+	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
+		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
 	}
-
 	if err != nil {
 		t.Fatalf("expected error to be nil not  %v", err)
 	}
 }
 
-func TestDeleteIdentityFails(t *testing.T) {
+func TestGetIdentities(t *testing.T) {
+	tests := []struct {
+		name      string
+		IDs       []string
+		submitErr bool
+		found     []string
+		missing   map[string]int
+		err       bool
+	}{
+		{
+			name:    "found and missing",
+			IDs:     []string{"joe", "jane", "unknown", "joe"},
+			found:   []string{"jane", "joe"},
+			missing: map[string]int{"unknown": http.StatusNotFound},
+		},
+		{
+			name:      "pool full",
+			IDs:       []string{"joe", "jane"},
+			submitErr: true,
+			found:     []string{},
+			missing:   map[string]int{"joe": http.StatusServiceUnavailable, "jane": http.StatusServiceUnavailable},
+		},
+		{
+			name: "no IDs",
+			IDs:  []string{},
+			err:  true,
+		},
+		{
+			name: "too many IDs",
+			IDs:  make([]string, MaxBatchSize+1),
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.submitErr {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(test.missing)).Return("", fmt.Errorf("WorkerPool queue is full"))
+			} else if !test.err {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(test.found) + len(test.missing)).DoAndReturn(
+					func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+						defer wg.Done()
+
+						results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+
+						return "", nil
+					},
+				)
+
+				mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, gomock.Any()).AnyTimes().DoAndReturn(
+					func(ctx context.Context, ID string) kClient.IdentityAPIGetIdentityRequest {
+						// build the request through the real client so the ID is carried along
+						return new(kClient.IdentityAPIService).GetIdentity(ctx, ID)
+					},
+				)
+				mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).AnyTimes().DoAndReturn(
+					func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
+						// use reflect as attributes are private
+						ID := reflect.ValueOf(r).FieldByName("id").String()
+
+						if ID == "unknown" {
+							rr := httptest.NewRecorder()
+							rr.Header().Set("Content-Type", "application/json")
+							rr.WriteHeader(http.StatusNotFound)
+
+							json.NewEncoder(rr).Encode(
+								map[string]interface{}{
+									"error": map[string]interface{}{
+										"code":    http.StatusNotFound,
+										"message": "Unable to locate the resource",
+										"reason":  "identity not found",
+									},
+								},
+							)
+
+							return nil, rr.Result(), fmt.Errorf("error")
+						}
+
+						return kClient.NewIdentity(ID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"}), new(http.Response), nil
+					},
+				)
+			}
+
+			identities, errs, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).GetIdentities(ctx, test.IDs)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not to be nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+
+			found := make([]string, 0)
+
+			for ID, identity := range identities {
+				if identity.Id != ID {
+					t.Errorf("expected identity %s to be keyed by its ID, got %s", identity.Id, ID)
+				}
+
+				found = append(found, ID)
+			}
+
+			sort.Strings(found)
+
+			if !reflect.DeepEqual(found, test.found) {
+				t.Errorf("expected identities %v got %v", test.found, found)
+			}
+
+			if len(errs) != len(test.missing) {
+				t.Fatalf("expected errors for %v got %v", test.missing, errs)
+			}
+
+			for ID, status := range test.missing {
+				if e, ok := errs[ID]; !ok || int(*e.Code) != status {
+					t.Errorf("expected error with code %v for %s got %v", status, ID, e)
+				}
+			}
+		})
+	}
+}
+
+func TestGetIdentityFails(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
@@ -559,17 +844,17 @@ func TestDeleteIdentityFails(t *testing.T) {
 	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
 
 	ctx := context.Background()
-	credID := "test-1"
+	credID := "test"
 
-	identityRequest := kClient.IdentityAPIDeleteIdentityRequest{
+	identityRequest := kClient.IdentityAPIGetIdentityRequest{
 		ApiService: mockKratosIdentityAPI,
 	}
 
 	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
 	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-	mockKratosIdentityAPI.EXPECT().DeleteIdentity(ctx, credID).Times(1).Return(identityRequest)
-	mockKratosIdentityAPI.EXPECT().DeleteIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
-		func(r kClient.IdentityAPIDeleteIdentityRequest) (*http.Response, error) {
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
 			rr := httptest.NewRecorder()
 			rr.Header().Set("Content-Type", "application/json")
 			rr.WriteHeader(http.StatusNotFound)
@@ -589,11 +874,11 @@ func TestDeleteIdentityFails(t *testing.T) {
 				},
 			)
 
-			return rr.Result(), fmt.Errorf("error")
+			return nil, rr.Result(), fmt.Errorf("error")
 		},
 	)
 
-	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger).DeleteIdentity(ctx, credID)
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).GetIdentity(ctx, credID)
 
 	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
 		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
@@ -612,29 +897,1779 @@ func TestDeleteIdentityFails(t *testing.T) {
 	}
 }
 
-func TestV1ServiceImplementsRebacServiceInterface(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	var svc interface{} = new(V1Service)
-
-	if _, ok := svc.(interfaces.IdentitiesService); !ok {
-		t.Fatalf("V1Service doesnt implement interfaces.IdentitiesService")
-	}
-}
-
-func TestV1ServiceListIdentities(t *testing.T) {
-	type input struct {
-		size  int
-		token string
+func TestGetIdentityKratosUnavailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		reason     string
+	}{
+		{
+			name:       "rate limited with kratos error",
+			status:     http.StatusTooManyRequests,
+			body:       `{"error": {"code": 429, "message": "too many requests", "reason": "slow down"}}`,
+			retryAfter: "30",
+			reason:     "slow down",
+		},
+		{
+			name:       "rate limited by a proxy",
+			status:     http.StatusTooManyRequests,
+			body:       "Too Many Requests",
+			retryAfter: "Wed, 21 Oct 2026 07:28:00 GMT",
+			reason:     "kratos: Too Many Requests",
+		},
+		{
+			name:   "unavailable without retry after",
+			status: http.StatusServiceUnavailable,
+			body:   "upstream connect error",
+			reason: "kratos: Service Unavailable",
+		},
 	}
 
-	type expected struct {
-		err        error
-		identities []resources.Identity
-	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	kIdentities := make([]kClient.Identity, 0)
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, "test").Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+				func(r kClient.IdentityAPIGetIdentityRequest) (*kClient.Identity, *http.Response, error) {
+					rr := httptest.NewRecorder()
+
+					if test.retryAfter != "" {
+						rr.Header().Set("Retry-After", test.retryAfter)
+					}
+
+					rr.WriteHeader(test.status)
+					rr.WriteString(test.body)
+
+					return nil, rr.Result(), fmt.Errorf("error")
+				},
+			)
+
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).GetIdentity(ctx, "test")
+
+			if err == nil {
+				t.Fatal("expected error to be not nil")
+			}
+
+			if ids.Error.GetCode() != int64(test.status) {
+				t.Fatalf("expected code to be %v not %v", test.status, ids.Error.GetCode())
+			}
+
+			if ids.Error.GetReason() != test.reason {
+				t.Fatalf("expected reason to be %q not %q", test.reason, ids.Error.GetReason())
+			}
+
+			if retryAfter, _ := ids.Error.Details[retryAfterDetail].(string); retryAfter != test.retryAfter {
+				t.Fatalf("expected retry after to be %q not %q", test.retryAfter, retryAfter)
+			}
+		})
+	}
+}
+
+// expectTraitsSchema makes kratos serve an identity schema accepting any traits object
+func expectTraitsSchema(mockKratosIdentityAPI *MockIdentityAPI) {
+	schema := map[string]interface{}{"properties": map[string]interface{}{"traits": map[string]interface{}{"type": "object"}}}
+
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchema(gomock.Any(), gomock.Any()).AnyTimes().Return(kClient.IdentityAPIGetIdentitySchemaRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).AnyTimes().Return(schema, new(http.Response), nil)
+}
+
+func TestCreateIdentitySuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	expectTraitsSchema(mockKratosIdentityAPI)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "name", "email": "test@example.com"})
+	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
+	identityBody := kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name", "email": "test@example.com"})
+	identityBody.SetCredentials(*credentials)
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id)
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPICreateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+
+			// use reflect as attributes are private, also are pointers so need to cast it multiple times
+			if IDBody := (*kClient.CreateIdentityBody)(reflect.ValueOf(r).FieldByName("createIdentityBody").UnsafePointer()); !reflect.DeepEqual(*IDBody, *identityBody) {
+				t.Fatalf("expected body to be %v, got %v", identityBody, IDBody)
+			}
+
+			return identity, new(http.Response), nil
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, identityBody)
+
+	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
+		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestCreateIdentityFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	expectTraitsSchema(mockKratosIdentityAPI)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
+	identityBody := kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name"})
+	identityBody.SetCredentials(*credentials)
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPICreateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusInternalServerError)
+
+			json.NewEncoder(rr).Encode(
+				map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    http.StatusInternalServerError,
+						"debug":   "--------",
+						"details": map[string]interface{}{},
+						"id":      "string",
+						"message": "error",
+						"reason":  "error",
+						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
+						"status":  "Internal Server Error",
+					},
+				},
+			)
+
+			return nil, rr.Result(), fmt.Errorf("error")
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, identityBody)
+
+	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
+		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
+	}
+
+	if ids.Error == nil {
+		t.Fatal("expected ids.Error to be not nil")
+	}
+
+	if *ids.Error.Code != int64(http.StatusInternalServerError) {
+		t.Fatalf("expected code to be %v not  %v", http.StatusInternalServerError, *ids.Error.Code)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
+func TestCreateIdentityInvalidTraits(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"traits": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"email": map[string]interface{}{"type": "string"},
+					"name":  map[string]interface{}{"type": "string"},
+					"age":   map[string]interface{}{"type": "integer"},
+				},
+				"required": []interface{}{"email"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		traits   map[string]interface{}
+		expected []TraitsError
+	}{
+		{
+			name:     "missing required field",
+			traits:   map[string]interface{}{"name": "name"},
+			expected: []TraitsError{{Path: "/traits/email", Message: "is required"}},
+		},
+		{
+			name:   "type mismatch",
+			traits: map[string]interface{}{"email": "test@example.com", "age": "ten", "name": 1.0},
+			expected: []TraitsError{
+				{Path: "/traits/age", Message: "must be of type integer"},
+				{Path: "/traits/name", Message: "must be of type string"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentitySchema(ctx, "test.json").Times(1).Return(kClient.IdentityAPIGetIdentitySchemaRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).Times(1).Return(schema, new(http.Response), nil)
+			mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, kClient.NewCreateIdentityBody("test.json", test.traits))
+
+			if !errors.Is(err, ErrTraitsSchemaMismatch) {
+				t.Fatalf("expected error to be ErrTraitsSchemaMismatch not %v", err)
+			}
+
+			if *ids.Error.Code != int64(http.StatusBadRequest) {
+				t.Fatalf("expected code to be %v not %v", http.StatusBadRequest, *ids.Error.Code)
+			}
+
+			if failures := ids.Error.Details[traitsErrorsDetail]; !reflect.DeepEqual(failures, test.expected) {
+				t.Fatalf("expected failures to be %v not %v", test.expected, failures)
+			}
+		})
+	}
+}
+
+func TestCreateIdentityTraitsSchemaFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "name"})
+	identityBody := kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name"})
+
+	mockLogger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id)
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchema(ctx, "test.json").Times(1).Return(kClient.IdentityAPIGetIdentitySchemaRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).Times(1).Return(nil, new(http.Response), fmt.Errorf("error"))
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(kClient.IdentityAPICreateIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+
+	_, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentity(ctx, identityBody)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+}
+
+func TestCreateIdentityAllowedSchemas(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		schema  string
+		err     error
+	}{
+		{
+			name:    "allowed schema",
+			allowed: []string{"employees", "test.json"},
+			schema:  "test.json",
+		},
+		{
+			name:   "no allowed schemas means all of them",
+			schema: "test.json",
+		},
+		{
+			name:    "disallowed schema",
+			allowed: []string{"employees"},
+			schema:  "test.json",
+			err:     ErrSchemaNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			expectTraitsSchema(mockKratosIdentityAPI)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			identity := kClient.NewIdentity("test", test.schema, "https://test.com/test.json", map[string]interface{}{"name": "name"})
+			identityBody := kClient.NewCreateIdentityBody(test.schema, map[string]interface{}{"name": "name"})
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+
+			if test.err == nil {
+				mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id)
+				mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(kClient.IdentityAPICreateIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+			} else {
+				mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+			}
+
+			svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+			svc.SetAllowedSchemas(test.allowed)
+
+			ids, err := svc.CreateIdentity(ctx, identityBody)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v not %v", test.err, err)
+			}
+
+			if test.err != nil && *ids.Error.Code != int64(http.StatusBadRequest) {
+				t.Fatalf("expected code to be %v not %v", http.StatusBadRequest, *ids.Error.Code)
+			}
+		})
+	}
+}
+
+func TestV1ServiceCreateIdentityDisallowedSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	cfg := new(Config)
+	cfg.K8s = mockCoreV1
+	cfg.Name = "schemas"
+	cfg.Namespace = "default"
+
+	cm := new(corev1.ConfigMap)
+	cm.Data = map[string]string{DEFAULT_SCHEMA: "test"}
+
+	ctx := context.Background()
+
+	mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockCoreV1.EXPECT().ConfigMaps(cfg.Namespace).Times(1).Return(mockConfigMapV1)
+	mockConfigMapV1.EXPECT().Get(ctx, cfg.Name, gomock.Any()).Times(1).Return(cm, nil)
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+
+	svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+	svc.SetAllowedSchemas([]string{"employees"})
+
+	_, err := NewV1Service(cfg, svc).CreateIdentity(ctx, &resources.Identity{Email: "test@gmail.com"})
+
+	expected := v1.NewRequestBodyValidationError(fmt.Sprintf("%s: \"test\", allowed schemas are employees", ErrSchemaNotAllowed))
+
+	if err == nil || err.Error() != expected.Error() {
+		t.Fatalf("expected error to be %v not %v", expected, err)
+	}
+}
+
+func TestCreateIdentityTraitsSchemaCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "name"})
+	schema := map[string]interface{}{"properties": map[string]interface{}{"traits": map[string]interface{}{"type": "object"}}}
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id).Times(2)
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchema(ctx, "test.json").Times(1).Return(kClient.IdentityAPIGetIdentitySchemaRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).Times(1).Return(schema, new(http.Response), nil)
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(2).Return(kClient.IdentityAPICreateIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(2).Return(identity, new(http.Response), nil)
+
+	svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.CreateIdentity(ctx, kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"name": "name"})); err != nil {
+			t.Fatalf("expected error to be nil not %v", err)
+		}
+	}
+}
+
+func TestCreateIdentitiesPartialFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	expectTraitsSchema(mockKratosIdentityAPI)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+	mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+		func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+			defer wg.Done()
+
+			results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+
+			return "", nil
+		},
+	)
+
+	ctx := context.Background()
+
+	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	bodies := []kClient.CreateIdentityBody{
+		*kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"email": "ok@example.com"}),
+		*kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"email": "conflict@example.com"}),
+	}
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "ok@example.com"})
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id).Times(1).Return(nil)
+	mockKratosIdentityAPI.EXPECT().CreateRecoveryCodeForIdentity(ctx).Times(1).Return(
+		kClient.IdentityAPICreateRecoveryCodeForIdentityRequest{ApiService: mockKratosIdentityAPI},
+	)
+	mockKratosIdentityAPI.EXPECT().CreateRecoveryCodeForIdentityExecute(gomock.Any()).Times(1).Return(
+		kClient.NewRecoveryCodeForIdentity("code", "https://test.com/recovery"),
+		&http.Response{StatusCode: http.StatusCreated},
+		nil,
+	)
+	mockEmail.EXPECT().SendTemplate(gomock.Any(), "ok@example.com", mail.UserCreationInviteTemplate, gomock.Any()).Times(1).Return(nil)
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(2).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(2).DoAndReturn(
+		func(r kClient.IdentityAPICreateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			body := (*kClient.CreateIdentityBody)(reflect.ValueOf(r).FieldByName("createIdentityBody").UnsafePointer())
+
+			if body.Traits["email"] == "ok@example.com" {
+				return identity, new(http.Response), nil
+			}
+
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusConflict)
+
+			json.NewEncoder(rr).Encode(
+				map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    http.StatusConflict,
+						"message": "identity already exists",
+						"reason":  "conflict",
+					},
+				},
+			)
+
+			return nil, rr.Result(), fmt.Errorf("error")
+		},
+	)
+
+	results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, bodies)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v", len(results))
+	}
+
+	if results[0].Error != nil || results[0].Identity == nil || results[0].Identity.Id != identity.Id {
+		t.Fatalf("expected first result to be a success, got %v", results[0])
+	}
+
+	if results[1].Error == nil || *results[1].Error.Code != int64(http.StatusConflict) {
+		t.Fatalf("expected second result to be a conflict, got %v", results[1])
+	}
+}
+
+func TestCreateIdentitiesReportsEntitlementsFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	expectTraitsSchema(mockKratosIdentityAPI)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+	mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+			defer wg.Done()
+
+			results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+
+			return "", nil
+		},
+	)
+
+	ctx := context.Background()
+
+	identityRequest := kClient.IdentityAPICreateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	bodies := []kClient.CreateIdentityBody{
+		*kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"email": "ok@example.com"}),
+	}
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "ok@example.com"})
+
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(ctx).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().CreateIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+	mockAuthz.EXPECT().SetCreateIdentityEntitlements(gomock.Any(), identity.Id).Times(1).Return(fmt.Errorf("WorkerPool queue is full"))
+	mockEmail.EXPECT().SendTemplate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, bodies)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	if results[0].Identity == nil || results[0].Identity.Id != identity.Id {
+		t.Fatalf("expected identity to be reported, got %v", results[0])
+	}
+
+	if results[0].Error == nil || *results[0].Error.Code != int64(http.StatusInternalServerError) {
+		t.Fatalf("expected entitlements error to be reported, got %v", results[0])
+	}
+}
+
+func TestCreateIdentitiesReportsSubmitFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+	mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+	ctx := context.Background()
+
+	bodies := []kClient.CreateIdentityBody{
+		*kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"email": "ok@example.com"}),
+	}
+
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", fmt.Errorf("WorkerPool queue is full"))
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+
+	results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, bodies)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	if results[0].Identity != nil || results[0].Error == nil || *results[0].Error.Code != int64(http.StatusServiceUnavailable) {
+		t.Fatalf("expected submit failure to be reported, got %v", results[0])
+	}
+}
+
+func TestCreateIdentitiesReportsDroppedJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+	mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+	ctx := context.Background()
+
+	bodies := []kClient.CreateIdentityBody{
+		*kClient.NewCreateIdentityBody("test.json", map[string]interface{}{"email": "ok@example.com"}),
+	}
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	// the job is accepted then dropped by a shutdown, it never runs nor sends a result
+	mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+		func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+			wg.Done()
+
+			return "", nil
+		},
+	)
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+
+	results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, bodies)
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+
+	if results[0].Identity != nil || results[0].Error == nil || *results[0].Error.Code != int64(http.StatusServiceUnavailable) {
+		t.Fatalf("expected dropped job to be reported as failed, got %v", results[0])
+	}
+}
+
+func TestRunBatchBoundsConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		peak     int
+	)
+
+	jobs := make([]func() any, MaxBatchSize)
+
+	for i := range jobs {
+		i := i
+
+		jobs[i] = func() any {
+			mu.Lock()
+			inFlight++
+			peak = max(peak, inFlight)
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+
+			return i
+		}
+	}
+
+	// every job runs on its own goroutine, as on a pool with plenty of workers
+	mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(MaxBatchSize).DoAndReturn(
+		func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+			go func() {
+				defer wg.Done()
+
+				results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+			}()
+
+			return "", nil
+		},
+	)
+
+	svc := NewService(nil, nil, nil, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+
+	results := svc.runBatch(jobs, func(i int, err error) { t.Errorf("unexpected rejection of job %d: %v", i, err) })
+
+	if len(results) != MaxBatchSize {
+		t.Fatalf("expected %d results got %d", MaxBatchSize, len(results))
+	}
+
+	if peak > BatchConcurrency {
+		t.Fatalf("expected at most %d jobs in flight got %d", BatchConcurrency, peak)
+	}
+}
+
+func TestCreateIdentitiesFailsWithTooManyIdentities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	bodies := make([]kClient.CreateIdentityBody, MaxBatchSize+1)
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().CreateIdentity(gomock.Any()).Times(0)
+
+	_, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, bodies)
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
+func TestCreateIdentitiesFailsWithNoData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+
+	results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).CreateIdentities(ctx, nil)
+
+	if results != nil {
+		t.Fatalf("expected results to be nil not  %v", results)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
+func TestUpdateIdentitySuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	identityRequest := kClient.IdentityAPIUpdateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SetTraits(map[string]interface{}{"name": "name"})
+	identityBody.SetCredentials(*credentials)
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, identity.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(identity, new(http.Response), nil)
+	mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, identity.Id).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+
+			// use reflect as attributes are private, also are pointers so need to cast it multiple times
+			if IDBody := (*kClient.UpdateIdentityBody)(reflect.ValueOf(r).FieldByName("updateIdentityBody").UnsafePointer()); !reflect.DeepEqual(*IDBody, *identityBody) {
+				t.Fatalf("expected body to be %v, got %v", identityBody, IDBody)
+			}
+
+			return identity, new(http.Response), nil
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, identity.Id, identityBody, "")
+
+	if !reflect.DeepEqual(ids.Identities, []kClient.Identity{*identity}) {
+		t.Fatalf("expected identities to be %v not  %v", *identity, ids.Identities)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestUpdateIdentityTraitChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+
+	current := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "Joe", "team": "identity", "email": "joe@example.com"})
+	updated := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"name": "Joe", "team": "platform", "email": "joe.doe@example.com"})
+
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SetTraits(updated.Traits.(map[string]interface{}))
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(current, new(http.Response), nil)
+	mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIUpdateIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).Return(updated, new(http.Response), nil)
+
+	svc := NewService(mockKratosIdentityAPI, NewMockAuthorizerInterface(ctrl), mail.NewMockEmailServiceInterface(ctrl), nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger)
+	svc.SetPIIKeys([]string{"email"})
+
+	ids, err := svc.UpdateIdentity(ctx, current.Id, identityBody, "")
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not %v", err)
+	}
+
+	// name is unchanged and left out, the email value is masked
+	expected := []TraitChange{
+		{Key: "email", Op: TraitChanged, Old: redactedTrait, New: redactedTrait},
+		{Key: "team", Op: TraitChanged, Old: "identity", New: "platform"},
+	}
+
+	if !reflect.DeepEqual(ids.Changes, expected) {
+		t.Fatalf("expected changes to be %v not %v", expected, ids.Changes)
+	}
+}
+
+func TestUpdateIdentityMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		admin    interface{}
+		public   interface{}
+		expected kClient.UpdateIdentityBody
+	}{
+		{
+			name: "metadata missing from the body is carried over",
+			expected: kClient.UpdateIdentityBody{
+				MetadataAdmin:  map[string]interface{}{"ticket": "INC-1"},
+				MetadataPublic: map[string]interface{}{"team": "identity"},
+			},
+		},
+		{
+			name:   "metadata set in the body replaces the current one",
+			admin:  map[string]interface{}{"ticket": "INC-2"},
+			public: map[string]interface{}{"team": "platform"},
+			expected: kClient.UpdateIdentityBody{
+				MetadataAdmin:  map[string]interface{}{"ticket": "INC-2"},
+				MetadataPublic: map[string]interface{}{"team": "platform"},
+			},
+		},
+		{
+			name:  "only metadata_public carried over",
+			admin: map[string]interface{}{},
+			expected: kClient.UpdateIdentityBody{
+				MetadataAdmin:  map[string]interface{}{},
+				MetadataPublic: map[string]interface{}{"team": "identity"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			current := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+			current.MetadataAdmin = map[string]interface{}{"ticket": "INC-1"}
+			current.MetadataPublic = map[string]interface{}{"team": "identity"}
+
+			identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+			identityBody.MetadataAdmin = test.admin
+			identityBody.MetadataPublic = test.public
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+
+			mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(current, new(http.Response), nil)
+
+			mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIUpdateIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+				func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+					IDBody := (*kClient.UpdateIdentityBody)(reflect.ValueOf(r).FieldByName("updateIdentityBody").UnsafePointer())
+
+					if !reflect.DeepEqual(IDBody.MetadataAdmin, test.expected.MetadataAdmin) {
+						t.Fatalf("expected metadata_admin to be %v, got %v", test.expected.MetadataAdmin, IDBody.MetadataAdmin)
+					}
+
+					if !reflect.DeepEqual(IDBody.MetadataPublic, test.expected.MetadataPublic) {
+						t.Fatalf("expected metadata_public to be %v, got %v", test.expected.MetadataPublic, IDBody.MetadataPublic)
+					}
+
+					return current, new(http.Response), nil
+				},
+			)
+
+			_, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, current.Id, identityBody, "")
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+
+			if identityBody.MetadataAdmin != nil && test.admin == nil {
+				t.Fatalf("expected the caller body not to be modified")
+			}
+		})
+	}
+}
+
+func TestUpdateIdentityIfMatch(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	current := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	current.SetUpdatedAt(updatedAt)
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		expected int
+	}{
+		{
+			name:     "matching ETag",
+			ifMatch:  IdentityETag(current),
+			expected: http.StatusOK,
+		},
+		{
+			name:     "wildcard",
+			ifMatch:  "*",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "stale ETag",
+			ifMatch:  `W/"stale"`,
+			expected: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+			identityBody.SetTraits(map[string]interface{}{"name": "new name"})
+
+			updated := *current
+			updated.SetUpdatedAt(updatedAt.Add(time.Minute))
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(current, new(http.Response), nil)
+
+			if test.expected == http.StatusOK {
+				mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIUpdateIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).Return(&updated, new(http.Response), nil)
+			} else {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+				mockKratosIdentityAPI.EXPECT().UpdateIdentity(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, current.Id, identityBody, test.ifMatch)
+
+			if test.expected == http.StatusOK {
+				if err != nil {
+					t.Fatalf("expected error to be nil not %v", err)
+				}
+
+				if IdentityETag(&ids.Identities[0]) == IdentityETag(current) {
+					t.Fatalf("expected ETag to change after the update")
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error not to be nil")
+			}
+
+			if *ids.Error.Code != int64(test.expected) {
+				t.Fatalf("expected code to be %v not %v", test.expected, *ids.Error.Code)
+			}
+		})
+	}
+}
+
+func TestIdentityETagWithoutUpdatedAt(t *testing.T) {
+	identity := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+	other := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]string{"name": "other"})
+
+	if IdentityETag(identity) != IdentityETag(identity) {
+		t.Fatalf("expected ETag to be stable")
+	}
+
+	if IdentityETag(identity) == IdentityETag(other) {
+		t.Fatalf("expected ETag to change with the identity")
+	}
+}
+
+func TestUpdateIdentityFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	credID := "test"
+
+	identityRequest := kClient.IdentityAPIUpdateIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	credentials := kClient.NewIdentityWithCredentialsWithDefaults()
+	identityBody := kClient.NewUpdateIdentityBodyWithDefaults()
+	identityBody.SetTraits(map[string]interface{}{"name": "name"})
+	identityBody.SetCredentials(*credentials)
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, credID).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+	mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"}), new(http.Response), nil)
+	mockKratosIdentityAPI.EXPECT().UpdateIdentity(ctx, credID).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusConflict)
+
+			json.NewEncoder(rr).Encode(
+				map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    http.StatusConflict,
+						"debug":   "--------",
+						"details": map[string]interface{}{},
+						"id":      "string",
+						"message": "error",
+						"reason":  "error",
+						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
+						"status":  "Conflict",
+					},
+				},
+			)
+
+			return nil, rr.Result(), fmt.Errorf("error")
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).UpdateIdentity(ctx, credID, identityBody, "")
+
+	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
+		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
+	}
+
+	if ids.Error == nil {
+		t.Fatal("expected ids.Error to be not nil")
+	}
+
+	if *ids.Error.Code != int64(http.StatusConflict) {
+		t.Fatalf("expected code to be %v not  %v", http.StatusConflict, *ids.Error.Code)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
+func TestDeleteIdentitySuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+	mockAuditor := NewMockAuditorInterface(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	identityRequest := kClient.IdentityAPIDeleteIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockAuditor.EXPECT().Record(ctx, audit.IdentityDelete, audit.IdentityResource, credID, audit.OutcomeSuccess).Times(1)
+	mockAuthz.EXPECT().SetDeleteIdentityEntitlements(gomock.Any(), credID)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentity(ctx, credID).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityExecute(gomock.Any()).Times(1).Return(new(http.Response), nil)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, mockAuditor, nil, mockTracer, mockMonitor, mockLogger).DeleteIdentity(ctx, credID)
+
+	if len(ids.Identities) > 0 {
+		t.Fatalf("invalid result, expected no identities, got %v", ids.Identities)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestDeleteIdentityFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+	mockAuditor := NewMockAuditorInterface(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	identityRequest := kClient.IdentityAPIDeleteIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockAuditor.EXPECT().Record(ctx, audit.IdentityDelete, audit.IdentityResource, credID, audit.OutcomeFailure).Times(1)
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().DeleteIdentity(ctx, credID).Times(1).Return(identityRequest)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIDeleteIdentityRequest) (*http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusNotFound)
+
+			json.NewEncoder(rr).Encode(
+				map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    http.StatusNotFound,
+						"debug":   "--------",
+						"details": map[string]interface{}{},
+						"id":      "string",
+						"message": "error",
+						"reason":  "error",
+						"request": "d7ef54b1-ec15-46e6-bccb-524b82c035e6",
+						"status":  "Not Found",
+					},
+				},
+			)
+
+			return rr.Result(), fmt.Errorf("error")
+		},
+	)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, mockAuditor, nil, mockTracer, mockMonitor, mockLogger).DeleteIdentity(ctx, credID)
+
+	if !reflect.DeepEqual(ids.Identities, make([]kClient.Identity, 0)) {
+		t.Fatalf("expected identities to be empty not  %v", ids.Identities)
+	}
+
+	if ids.Error == nil {
+		t.Fatal("expected ids.Error to be not nil")
+	}
+
+	if *ids.Error.Code != int64(http.StatusNotFound) {
+		t.Fatalf("expected code to be %v not  %v", http.StatusNotFound, *ids.Error.Code)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
+func TestDeleteIdentities(t *testing.T) {
+	tests := []struct {
+		name      string
+		IDs       []string
+		submitErr bool
+		dropped   bool
+		deleted   []string
+		failed    map[string]int
+		err       bool
+	}{
+		{
+			name:    "deleted and failed",
+			IDs:     []string{"joe", "unknown", "jane", "joe"},
+			deleted: []string{"jane", "joe"},
+			failed:  map[string]int{"unknown": http.StatusNotFound, "jane": http.StatusInternalServerError},
+		},
+		{
+			name:      "pool full",
+			IDs:       []string{"joe", "jane"},
+			submitErr: true,
+			deleted:   []string{},
+			failed:    map[string]int{"joe": http.StatusServiceUnavailable, "jane": http.StatusServiceUnavailable},
+		},
+		{
+			name:    "dropped by the pool",
+			IDs:     []string{"joe", "jane"},
+			dropped: true,
+			deleted: []string{},
+			failed:  map[string]int{"joe": http.StatusServiceUnavailable, "jane": http.StatusServiceUnavailable},
+		},
+		{
+			name: "no IDs",
+			IDs:  []string{},
+			err:  true,
+		},
+		{
+			name: "too many IDs",
+			IDs:  make([]string, MaxBatchSize+1),
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+
+			ctx := context.Background()
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+			if test.submitErr {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(test.failed)).Return("", fmt.Errorf("WorkerPool queue is full"))
+			} else if test.dropped {
+				// accepted then dropped by a shutdown, the jobs never run nor send a result
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(len(test.failed)).DoAndReturn(
+					func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+						wg.Done()
+
+						return "", nil
+					},
+				)
+			} else if !test.err {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+						defer wg.Done()
+
+						results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+
+						return "", nil
+					},
+				)
+
+				mockKratosIdentityAPI.EXPECT().DeleteIdentity(ctx, gomock.Any()).Times(3).DoAndReturn(
+					func(ctx context.Context, ID string) kClient.IdentityAPIDeleteIdentityRequest {
+						// build the request through the real client so the ID is carried along
+						return new(kClient.IdentityAPIService).DeleteIdentity(ctx, ID)
+					},
+				)
+				mockKratosIdentityAPI.EXPECT().DeleteIdentityExecute(gomock.Any()).Times(3).DoAndReturn(
+					func(r kClient.IdentityAPIDeleteIdentityRequest) (*http.Response, error) {
+						// use reflect as attributes are private
+						if ID := reflect.ValueOf(r).FieldByName("id").String(); ID != "unknown" {
+							return new(http.Response), nil
+						}
+
+						rr := httptest.NewRecorder()
+						rr.Header().Set("Content-Type", "application/json")
+						rr.WriteHeader(http.StatusNotFound)
+
+						json.NewEncoder(rr).Encode(
+							map[string]interface{}{
+								"error": map[string]interface{}{
+									"code":    http.StatusNotFound,
+									"message": "Unable to locate the resource",
+									"reason":  "identity not found",
+								},
+							},
+						)
+
+						return rr.Result(), fmt.Errorf("error")
+					},
+				)
+
+				// cleanup runs for every deleted identity, the failed deletion doesn't stop it
+				mockAuthz.EXPECT().SetDeleteIdentityEntitlements(gomock.Any(), "joe").Times(1).Return(nil)
+				mockAuthz.EXPECT().SetDeleteIdentityEntitlements(gomock.Any(), "jane").Times(1).Return(fmt.Errorf("openfga unavailable"))
+			}
+
+			results, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).DeleteIdentities(ctx, test.IDs)
+
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected error not to be nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+
+			deleted := make([]string, 0)
+
+			for ID, result := range results {
+				if result.Deleted {
+					deleted = append(deleted, ID)
+				}
+			}
+
+			sort.Strings(deleted)
+
+			if !reflect.DeepEqual(deleted, test.deleted) {
+				t.Errorf("expected deleted identities %v got %v", test.deleted, deleted)
+			}
+
+			for ID, status := range test.failed {
+				if e := results[ID].Error; e == nil || int(*e.Code) != status {
+					t.Errorf("expected error with code %v for %s got %v", status, ID, e)
+				}
+			}
+
+			if e := results["joe"].Error; !test.submitErr && !test.dropped && e != nil {
+				t.Errorf("expected no error for joe got %v", e)
+			}
+		})
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		outcome audit.Outcome
+	}{
+		{
+			name:    "session disabled",
+			status:  http.StatusNoContent,
+			outcome: audit.OutcomeSuccess,
+		},
+		{
+			name:    "unknown session",
+			status:  http.StatusNotFound,
+			outcome: audit.OutcomeFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockAuditor := NewMockAuditorInterface(ctrl)
+
+			ctx := context.Background()
+			sessionID := "session-1"
+
+			sessionRequest := kClient.IdentityAPIDisableSessionRequest{
+				ApiService: mockKratosIdentityAPI,
+			}
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockAuditor.EXPECT().Record(ctx, audit.SessionDisable, audit.SessionResource, sessionID, test.outcome).Times(1)
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().DisableSession(ctx, sessionID).Times(1).Return(sessionRequest)
+			mockKratosIdentityAPI.EXPECT().DisableSessionExecute(gomock.Any()).Times(1).DoAndReturn(
+				func(r kClient.IdentityAPIDisableSessionRequest) (*http.Response, error) {
+					rr := httptest.NewRecorder()
+
+					if test.status != http.StatusNotFound {
+						rr.WriteHeader(test.status)
+
+						return rr.Result(), nil
+					}
+
+					rr.Header().Set("Content-Type", "application/json")
+					rr.WriteHeader(http.StatusNotFound)
+
+					json.NewEncoder(rr).Encode(
+						map[string]interface{}{
+							"error": map[string]interface{}{
+								"code":    http.StatusNotFound,
+								"message": "Unable to locate the resource",
+								"reason":  "session not found",
+							},
+						},
+					)
+
+					return rr.Result(), fmt.Errorf("error")
+				},
+			)
+
+			data, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, mockAuditor, nil, mockTracer, mockMonitor, mockLogger).DeleteSession(ctx, sessionID)
+
+			if test.status != http.StatusNotFound {
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error to be not nil")
+			}
+
+			if data.Error == nil || *data.Error.Code != int64(http.StatusNotFound) {
+				t.Fatalf("expected error code to be %v got %v", http.StatusNotFound, data.Error)
+			}
+		})
+	}
+}
+
+func TestDeleteIdentityCredentialSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	credentialRequest := kClient.IdentityAPIDeleteIdentityCredentialsRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	rr := new(http.Response)
+	rr.StatusCode = http.StatusNoContent
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityCredentials(ctx, credID, CredentialTypeTOTP).Times(1).Return(credentialRequest)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityCredentialsExecute(gomock.Any()).Times(1).Return(rr, nil)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).DeleteIdentityCredential(ctx, credID, CredentialTypeTOTP)
+
+	if len(ids.Identities) > 0 {
+		t.Fatalf("invalid result, expected no identities, got %v", ids.Identities)
+	}
+
+	if err != nil {
+		t.Fatalf("expected error to be nil not  %v", err)
+	}
+}
+
+func TestDeleteIdentityCredentialFailsWithUnknownType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityCredentials(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentityCredentialsExecute(gomock.Any()).Times(0)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).DeleteIdentityCredential(ctx, credID, "fingerprint")
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+
+	if ids.Error == nil || ids.Error.GetCode() != http.StatusBadRequest {
+		t.Fatalf("expected error code to be %v, got %v", http.StatusBadRequest, ids.Error)
+	}
+}
+
+func TestSetIdentityStateSuccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		state          string
+		revokeSessions bool
+		sessionsStatus int
+		revoked        bool
+	}{
+		{
+			name:  "deactivate",
+			state: IdentityStateInactive,
+		},
+		{
+			name:           "deactivate and revoke sessions",
+			state:          IdentityStateInactive,
+			revokeSessions: true,
+			sessionsStatus: http.StatusNoContent,
+			revoked:        true,
+		},
+		{
+			name:           "deactivate and revoke missing sessions",
+			state:          IdentityStateInactive,
+			revokeSessions: true,
+			sessionsStatus: http.StatusNotFound,
+			revoked:        true,
+		},
+		{
+			name:           "reactivate ignores revoke sessions",
+			state:          IdentityStateActive,
+			revokeSessions: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+			credID := "test-1"
+
+			patchRequest := kClient.IdentityAPIPatchIdentityRequest{
+				ApiService: mockKratosIdentityAPI,
+			}
+
+			mockTracer.EXPECT().Start(ctx, "identities.Service.SetIdentityState").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().PatchIdentity(ctx, credID).Times(1).Return(patchRequest)
+			mockKratosIdentityAPI.EXPECT().PatchIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+				func(r kClient.IdentityAPIPatchIdentityRequest) (*kClient.Identity, *http.Response, error) {
+					patches := (*[]kClient.JsonPatch)(reflect.ValueOf(r).FieldByName("jsonPatch").UnsafePointer())
+
+					if len(*patches) != 1 || (*patches)[0].Op != "replace" || (*patches)[0].Path != "/state" || (*patches)[0].Value != test.state {
+						t.Fatalf("expected a single replace patch on /state with %s, got %v", test.state, *patches)
+					}
+
+					identity := kClient.NewIdentity(credID, "test.json", "https://test.com/test.json", map[string]string{"name": "name"})
+					identity.SetState(test.state)
+
+					return identity, new(http.Response), nil
+				},
+			)
+
+			if test.revoked {
+				sessionsRequest := kClient.IdentityAPIDeleteIdentitySessionsRequest{
+					ApiService: mockKratosIdentityAPI,
+				}
+
+				rr := httptest.NewRecorder()
+				rr.WriteHeader(test.sessionsStatus)
+
+				var err error
+				if test.sessionsStatus != http.StatusNoContent {
+					err = fmt.Errorf("error")
+				}
+
+				mockKratosIdentityAPI.EXPECT().DeleteIdentitySessions(ctx, credID).Times(1).Return(sessionsRequest)
+				mockKratosIdentityAPI.EXPECT().DeleteIdentitySessionsExecute(gomock.Any()).Times(1).Return(rr.Result(), err)
+			} else {
+				mockKratosIdentityAPI.EXPECT().DeleteIdentitySessions(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).SetIdentityState(ctx, credID, test.state, test.revokeSessions)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+
+			if len(ids.Identities) != 1 || ids.Identities[0].GetState() != test.state {
+				t.Fatalf("expected identity in state %s, got %v", test.state, ids.Identities)
+			}
+		})
+	}
+}
+
+func TestSetIdentityStateFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+	credID := "test-1"
+
+	patchRequest := kClient.IdentityAPIPatchIdentityRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	mockTracer.EXPECT().Start(ctx, "identities.Service.SetIdentityState").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockKratosIdentityAPI.EXPECT().PatchIdentity(ctx, credID).Times(1).Return(patchRequest)
+	mockKratosIdentityAPI.EXPECT().PatchIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+		func(r kClient.IdentityAPIPatchIdentityRequest) (*kClient.Identity, *http.Response, error) {
+			rr := httptest.NewRecorder()
+			rr.Header().Set("Content-Type", "application/json")
+			rr.WriteHeader(http.StatusNotFound)
+
+			json.NewEncoder(rr).Encode(
+				map[string]interface{}{
+					"error": map[string]interface{}{
+						"code":    http.StatusNotFound,
+						"message": "Unable to locate the resource",
+						"reason":  "identity not found",
+						"status":  "Not Found",
+					},
+				},
+			)
+
+			return nil, rr.Result(), fmt.Errorf("error")
+		},
+	)
+	mockKratosIdentityAPI.EXPECT().DeleteIdentitySessions(gomock.Any(), gomock.Any()).Times(0)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).SetIdentityState(ctx, credID, IdentityStateInactive, true)
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+
+	if ids.Error == nil || ids.Error.GetCode() != http.StatusNotFound {
+		t.Fatalf("expected error code to be %v, got %v", http.StatusNotFound, ids.Error)
+	}
+}
+
+func TestSetIdentityStateFailsWithUnknownState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+	ctx := context.Background()
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockKratosIdentityAPI.EXPECT().PatchIdentity(gomock.Any(), gomock.Any()).Times(0)
+
+	ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).SetIdentityState(ctx, "test-1", "suspended", false)
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+
+	if ids.Error == nil || ids.Error.GetCode() != http.StatusBadRequest {
+		t.Fatalf("expected error code to be %v, got %v", http.StatusBadRequest, ids.Error)
+	}
+}
+
+func TestV1ServiceImplementsRebacServiceInterface(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var svc interface{} = new(V1Service)
+
+	if _, ok := svc.(interfaces.IdentitiesService); !ok {
+		t.Fatalf("V1Service doesnt implement interfaces.IdentitiesService")
+	}
+}
+
+func TestV1ServiceListIdentities(t *testing.T) {
+	type input struct {
+		size  int
+		token string
+	}
+
+	type expected struct {
+		err        error
+		identities []resources.Identity
+	}
+
+	kIdentities := make([]kClient.Identity, 0)
 	identities := make([]resources.Identity, 0)
 
 	for i := 0; i < 10; i++ {
@@ -792,7 +2827,7 @@ func TestV1ServiceListIdentities(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			r, err := svc.ListIdentities(
@@ -833,6 +2868,45 @@ func TestV1ServiceListIdentities(t *testing.T) {
 	}
 }
 
+func TestV1ServiceListIdentitiesFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   string
+		expected ListIdentitiesFilter
+		err      bool
+	}{
+		{name: "schema and state", filter: "schema_id=test.json,state=active", expected: ListIdentitiesFilter{SchemaID: "test.json", State: IdentityStateActive}},
+		{name: "empty", filter: "", expected: ListIdentitiesFilter{}},
+		{name: "invalid state", filter: "state=suspended", err: true},
+		{name: "unsupported key", filter: "email=test@gmail.com", err: true},
+		{name: "malformed", filter: "schema_id", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			svc := NewV1Service(new(Config), new(Service))
+
+			filter, err := svc.parseListFilter(&resources.GetIdentitiesParams{Filter: &test.filter})
+
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error to be not nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not  %v", err)
+			}
+
+			if filter != test.expected {
+				t.Fatalf("expected filter to be %v not %v", test.expected, filter)
+			}
+		})
+	}
+}
+
 func TestV1ServiceCreateIdentity(t *testing.T) {
 	type input struct {
 		identity *resources.Identity
@@ -902,6 +2976,7 @@ func TestV1ServiceCreateIdentity(t *testing.T) {
 			mockConfigMapV1 := NewMockConfigMapInterface(ctrl)
 			mockAuthz := NewMockAuthorizerInterface(ctrl)
 			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			expectTraitsSchema(mockKratosIdentityAPI)
 			mockOpenFGAStore := NewMockOpenFGAStoreInterface(ctrl)
 			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
 
@@ -974,7 +3049,7 @@ func TestV1ServiceCreateIdentity(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			newIdentity, err := svc.CreateIdentity(ctx, test.input.identity)
@@ -1118,7 +3193,7 @@ func TestV1ServiceGetIdentity(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			identity, err := svc.GetIdentity(ctx, test.input)
@@ -1248,6 +3323,8 @@ func TestV1ServiceUpdateIdentity(t *testing.T) {
 
 			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
 			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentity(gomock.Any(), *test.input.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(kClient.NewIdentity(*test.input.Id, "test.json", "https://test.com/test.json", map[string]string{"name": "name"}), new(http.Response), nil)
 			mockKratosIdentityAPI.EXPECT().UpdateIdentity(gomock.Any(), *test.input.Id).Times(1).Return(identityRequest)
 			mockKratosIdentityAPI.EXPECT().UpdateIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
 				func(r kClient.IdentityAPIUpdateIdentityRequest) (*kClient.Identity, *http.Response, error) {
@@ -1286,7 +3363,7 @@ func TestV1ServiceUpdateIdentity(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			identity, err := svc.UpdateIdentity(ctx, test.input)
@@ -1413,7 +3490,7 @@ func TestV1ServiceDeleteIdentity(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			ok, err := svc.DeleteIdentity(ctx, test.input)
@@ -1506,7 +3583,7 @@ func TestV1ServiceGetIdentityGroups(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
@@ -1619,28 +3696,203 @@ func TestV1ServiceGetIdentityRoles(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
+			)
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockOpenFGAStore.EXPECT().ListAssignedRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input)).DoAndReturn(
+				func(ctx context.Context, ID string) ([]string, error) {
+					if test.expected.err != nil {
+						return nil, fmt.Errorf("error")
+					}
+
+					roles := make([]string, 0)
+
+					for _, r := range test.expected.roles {
+						roles = append(roles, r.Name)
+					}
+
+					return roles, nil
+				},
+			)
+
+			r, err := svc.GetIdentityRoles(context.Background(), test.input, nil)
+
+			if test.expected.err != nil && err == nil {
+				t.Errorf("expected error to be %v got %v", test.expected.err, err)
+			}
+
+			if test.expected.err != nil {
+				return
+			}
+
+			for i, role := range r.Data {
+				if role.Name != test.expected.roles[i].Name {
+					t.Errorf("invalid result, expected: %v, got: %v", test.expected.roles[i].Name, role.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestV1ServicePatchIdentityRoles(t *testing.T) {
+	type input struct {
+		patches []resources.IdentityRolesPatchItem
+		id      string
+	}
+	type expected struct {
+		ok  bool
+		err error
+	}
+
+	additions := []resources.IdentityRolesPatchItem{
+		{Op: "add", Role: "test1"},
+		{Op: "add", Role: "test2"},
+	}
+	removals := []resources.IdentityRolesPatchItem{
+		{Op: "remove", Role: "test1"},
+	}
+
+	tests := []struct {
+		name     string
+		input    input
+		expected expected
+	}{
+		{
+			name: "empty payload",
+			input: input{
+				id:      uuid.NewString(),
+				patches: []resources.IdentityRolesPatchItem{},
+			},
+			expected: expected{
+				ok:  true,
+				err: nil,
+			},
+		},
+		{
+			name: "error assign",
+			input: input{
+				id:      uuid.NewString(),
+				patches: additions,
+			},
+			expected: expected{
+				err: fmt.Errorf("error"),
+				ok:  false,
+			},
+		},
+		{
+			name: "error unassign",
+			input: input{
+				id:      uuid.NewString(),
+				patches: removals,
+			},
+			expected: expected{
+				err: fmt.Errorf("error"),
+				ok:  false,
+			},
+		},
+		{
+			name: "success",
+			input: input{
+				id:      uuid.NewString(),
+				patches: append(removals, additions...),
+			},
+			expected: expected{
+				ok:  true,
+				err: nil,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockCoreV1 := NewMockCoreV1Interface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockOpenFGAStore := NewMockOpenFGAStoreInterface(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			cfg := new(Config)
+			cfg.K8s = mockCoreV1
+			cfg.Name = "schemas"
+			cfg.Namespace = "default"
+			cfg.OpenFGAStore = mockOpenFGAStore
+
+			cm := new(corev1.ConfigMap)
+			cm.Data = make(map[string]string)
+			cm.Data[DEFAULT_SCHEMA] = "test"
+
+			svc := NewV1Service(
+				cfg,
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
+			)
+
+			// AssignRoles(context.Context, string, ...string) error
+			// UnassignRoles(context.Context, string, ...string) error
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockOpenFGAStore.EXPECT().AssignRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, roles ...string) error {
+					if ID != fmt.Sprintf("user:%s", test.input.id) {
+						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
+					}
+
+					if test.expected.err != nil {
+						return fmt.Errorf("error")
+					}
+
+					rs := make([]string, 0)
+
+					for _, r := range test.input.patches {
+						if r.Op == "add" {
+							rs = append(rs, fmt.Sprintf("role:%s", r.Role))
+						}
+					}
+
+					if !reflect.DeepEqual(rs, roles) {
+						t.Errorf("expected roles to be %v got %v", rs, roles)
+					}
+
+					return nil
+				},
 			)
 
-			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
-			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-			mockOpenFGAStore.EXPECT().ListAssignedRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input)).DoAndReturn(
-				func(ctx context.Context, ID string) ([]string, error) {
+			mockOpenFGAStore.EXPECT().UnassignRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, roles ...string) error {
+					if ID != fmt.Sprintf("user:%s", test.input.id) {
+						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
+					}
+
 					if test.expected.err != nil {
-						return nil, fmt.Errorf("error")
+						return fmt.Errorf("error")
 					}
 
-					roles := make([]string, 0)
+					rs := make([]string, 0)
 
-					for _, r := range test.expected.roles {
-						roles = append(roles, r.Name)
+					for _, r := range test.input.patches {
+						if r.Op == "remove" {
+							rs = append(rs, fmt.Sprintf("role:%s", r.Role))
+						}
 					}
 
-					return roles, nil
+					if !reflect.DeepEqual(rs, roles) {
+						t.Errorf("expected roles to be %v got %v", rs, roles)
+					}
+
+					return nil
 				},
 			)
 
-			r, err := svc.GetIdentityRoles(context.Background(), test.input, nil)
+			ok, err := svc.PatchIdentityRoles(context.Background(), test.input.id, test.input.patches)
 
 			if test.expected.err != nil && err == nil {
 				t.Errorf("expected error to be %v got %v", test.expected.err, err)
@@ -1650,18 +3902,16 @@ func TestV1ServiceGetIdentityRoles(t *testing.T) {
 				return
 			}
 
-			for i, role := range r.Data {
-				if role.Name != test.expected.roles[i].Name {
-					t.Errorf("invalid result, expected: %v, got: %v", test.expected.roles[i].Name, role.Name)
-				}
+			if ok != test.expected.ok {
+				t.Errorf("invalid result, expected: %v, got: %v", test.expected.ok, ok)
 			}
 		})
 	}
 }
 
-func TestV1ServicePatchIdentityRoles(t *testing.T) {
+func TestV1ServicePatchIdentityGroups(t *testing.T) {
 	type input struct {
-		patches []resources.IdentityRolesPatchItem
+		patches []resources.IdentityGroupsPatchItem
 		id      string
 	}
 	type expected struct {
@@ -1669,12 +3919,12 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 		err error
 	}
 
-	additions := []resources.IdentityRolesPatchItem{
-		{Op: "add", Role: "test1"},
-		{Op: "add", Role: "test2"},
+	additions := []resources.IdentityGroupsPatchItem{
+		{Op: "add", Group: "test1"},
+		{Op: "add", Group: "test2"},
 	}
-	removals := []resources.IdentityRolesPatchItem{
-		{Op: "remove", Role: "test1"},
+	removals := []resources.IdentityGroupsPatchItem{
+		{Op: "remove", Group: "test1"},
 	}
 
 	tests := []struct {
@@ -1686,7 +3936,7 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 			name: "empty payload",
 			input: input{
 				id:      uuid.NewString(),
-				patches: []resources.IdentityRolesPatchItem{},
+				patches: []resources.IdentityGroupsPatchItem{},
 			},
 			expected: expected{
 				ok:  true,
@@ -1756,15 +4006,15 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
-			// AssignRoles(context.Context, string, ...string) error
-			// UnassignRoles(context.Context, string, ...string) error
+			// AssignGroups(context.Context, string, ...string) error
+			// UnassignGroups(context.Context, string, ...string) error
 			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
 			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-			mockOpenFGAStore.EXPECT().AssignRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, roles ...string) error {
+			mockOpenFGAStore.EXPECT().AssignGroups(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, groups ...string) error {
 					if ID != fmt.Sprintf("user:%s", test.input.id) {
 						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
 					}
@@ -1773,24 +4023,24 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 						return fmt.Errorf("error")
 					}
 
-					rs := make([]string, 0)
+					gs := make([]string, 0)
 
-					for _, r := range test.input.patches {
-						if r.Op == "add" {
-							rs = append(rs, fmt.Sprintf("role:%s", r.Role))
+					for _, g := range test.input.patches {
+						if g.Op == "add" {
+							gs = append(gs, fmt.Sprintf("group:%s", g.Group))
 						}
 					}
 
-					if !reflect.DeepEqual(rs, roles) {
-						t.Errorf("expected roles to be %v got %v", rs, roles)
+					if !reflect.DeepEqual(gs, groups) {
+						t.Errorf("expected groups to be %v got %v", gs, groups)
 					}
 
 					return nil
 				},
 			)
 
-			mockOpenFGAStore.EXPECT().UnassignRoles(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, roles ...string) error {
+			mockOpenFGAStore.EXPECT().UnassignGroups(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, groups ...string) error {
 					if ID != fmt.Sprintf("user:%s", test.input.id) {
 						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
 					}
@@ -1799,23 +4049,23 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 						return fmt.Errorf("error")
 					}
 
-					rs := make([]string, 0)
+					gs := make([]string, 0)
 
-					for _, r := range test.input.patches {
-						if r.Op == "remove" {
-							rs = append(rs, fmt.Sprintf("role:%s", r.Role))
+					for _, g := range test.input.patches {
+						if g.Op == "remove" {
+							gs = append(gs, fmt.Sprintf("group:%s", g.Group))
 						}
 					}
 
-					if !reflect.DeepEqual(rs, roles) {
-						t.Errorf("expected roles to be %v got %v", rs, roles)
+					if !reflect.DeepEqual(gs, groups) {
+						t.Errorf("expected groups to be %v got %v", gs, groups)
 					}
 
 					return nil
 				},
 			)
 
-			ok, err := svc.PatchIdentityRoles(context.Background(), test.input.id, test.input.patches)
+			ok, err := svc.PatchIdentityGroups(context.Background(), test.input.id, test.input.patches)
 
 			if test.expected.err != nil && err == nil {
 				t.Errorf("expected error to be %v got %v", test.expected.err, err)
@@ -1832,9 +4082,149 @@ func TestV1ServicePatchIdentityRoles(t *testing.T) {
 	}
 }
 
-func TestV1ServicePatchIdentityGroups(t *testing.T) {
+func TestV1ServiceGetIdentityEntitlements(t *testing.T) {
 	type input struct {
-		patches []resources.IdentityGroupsPatchItem
+		params *resources.GetIdentitiesItemEntitlementsParams
+		id     string
+	}
+	type expected struct {
+		permissions []resources.EntityEntitlement
+		err         error
+	}
+
+	permissions := []resources.EntityEntitlement{
+		{
+			Entitlement: "can_view",
+			EntityId:    "okta",
+			EntityType:  "client",
+		},
+		{
+			Entitlement: "can_delete",
+			EntityId:    "github",
+			EntityType:  "client",
+		},
+		{
+			Entitlement: "can_create",
+			EntityId:    "github",
+			EntityType:  "client",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		input    input
+		expected expected
+	}{
+		{
+			name: "empty payload",
+			input: input{
+				id: uuid.NewString(),
+			},
+			expected: expected{
+				permissions: []resources.EntityEntitlement{},
+				err:         nil,
+			},
+		},
+		{
+			name: "error",
+			input: input{
+				id: uuid.NewString(),
+			},
+			expected: expected{
+				err: fmt.Errorf("error"),
+			},
+		},
+		{
+			name: "success",
+			input: input{
+				id: uuid.NewString(),
+			},
+			expected: expected{
+				permissions: permissions,
+				err:         nil,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockCoreV1 := NewMockCoreV1Interface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockOpenFGAStore := NewMockOpenFGAStoreInterface(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			cfg := new(Config)
+			cfg.K8s = mockCoreV1
+			cfg.Name = "schemas"
+			cfg.Namespace = "default"
+			cfg.OpenFGAStore = mockOpenFGAStore
+
+			cm := new(corev1.ConfigMap)
+			cm.Data = make(map[string]string)
+			cm.Data[DEFAULT_SCHEMA] = "test"
+
+			svc := NewV1Service(
+				cfg,
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
+			)
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockOpenFGAStore.EXPECT().ListPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).Times(1).DoAndReturn(
+				func(ctx context.Context, ID string, tokens map[string]string) ([]ofga.Permission, map[string]string, error) {
+					if ID != fmt.Sprintf("user:%s", test.input.id) {
+						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
+					}
+
+					if test.expected.err != nil {
+						return nil, nil, fmt.Errorf("error")
+					}
+
+					ps := make([]ofga.Permission, 0)
+
+					for _, p := range test.expected.permissions {
+						ps = append(
+							ps,
+							ofga.Permission{
+								Relation: p.Entitlement,
+								Object:   fmt.Sprintf("%s:%s", p.EntityType, p.EntityId),
+							},
+						)
+					}
+					return ps, map[string]string{}, nil
+				},
+			)
+
+			r, err := svc.GetIdentityEntitlements(context.Background(), test.input.id, test.input.params)
+
+			if test.expected.err != nil && err == nil {
+				t.Errorf("expected error to be %v got %v", test.expected.err, err)
+			}
+
+			if test.expected.err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(r.Data, test.expected.permissions) {
+				t.Errorf("invalid result, expected: %v, got: %v", test.expected.permissions, r.Data)
+			}
+		})
+	}
+}
+
+func TestV1ServicePatchIdentityEntitlements(t *testing.T) {
+	type input struct {
+		patches []resources.IdentityEntitlementsPatchItem
 		id      string
 	}
 	type expected struct {
@@ -1842,12 +4232,34 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 		err error
 	}
 
-	additions := []resources.IdentityGroupsPatchItem{
-		{Op: "add", Group: "test1"},
-		{Op: "add", Group: "test2"},
+	additions := []resources.IdentityEntitlementsPatchItem{
+		{
+			Op: "add",
+			Entitlement: resources.EntityEntitlement{
+				Entitlement: "can_view",
+				EntityId:    "okta",
+				EntityType:  "client",
+			},
+		},
+		{
+			Op: "add",
+			Entitlement: resources.EntityEntitlement{
+				Entitlement: "can_delete",
+				EntityId:    "github",
+				EntityType:  "client",
+			},
+		},
 	}
-	removals := []resources.IdentityGroupsPatchItem{
-		{Op: "remove", Group: "test1"},
+
+	removals := []resources.IdentityEntitlementsPatchItem{
+		{
+			Op: "remove",
+			Entitlement: resources.EntityEntitlement{
+				Entitlement: "can_create",
+				EntityId:    "github",
+				EntityType:  "client",
+			},
+		},
 	}
 
 	tests := []struct {
@@ -1859,7 +4271,7 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 			name: "empty payload",
 			input: input{
 				id:      uuid.NewString(),
-				patches: []resources.IdentityGroupsPatchItem{},
+				patches: []resources.IdentityEntitlementsPatchItem{},
 			},
 			expected: expected{
 				ok:  true,
@@ -1929,15 +4341,15 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
 			// AssignGroups(context.Context, string, ...string) error
 			// UnassignGroups(context.Context, string, ...string) error
 			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
 			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-			mockOpenFGAStore.EXPECT().AssignGroups(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, groups ...string) error {
+			mockOpenFGAStore.EXPECT().AssignPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, permissions ...ofga.Permission) error {
 					if ID != fmt.Sprintf("user:%s", test.input.id) {
 						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
 					}
@@ -1946,24 +4358,30 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 						return fmt.Errorf("error")
 					}
 
-					gs := make([]string, 0)
+					ps := make([]ofga.Permission, 0)
 
-					for _, g := range test.input.patches {
-						if g.Op == "add" {
-							gs = append(gs, fmt.Sprintf("group:%s", g.Group))
+					for _, p := range test.input.patches {
+						if p.Op == "add" {
+							ps = append(
+								ps,
+								ofga.Permission{
+									Relation: p.Entitlement.Entitlement,
+									Object:   fmt.Sprintf("%s:%s", p.Entitlement.EntityType, p.Entitlement.EntityId),
+								},
+							)
 						}
 					}
 
-					if !reflect.DeepEqual(gs, groups) {
-						t.Errorf("expected groups to be %v got %v", gs, groups)
+					if !reflect.DeepEqual(ps, permissions) {
+						t.Errorf("expected permissions to be %v got %v", ps, permissions)
 					}
 
 					return nil
 				},
 			)
 
-			mockOpenFGAStore.EXPECT().UnassignGroups(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, groups ...string) error {
+			mockOpenFGAStore.EXPECT().UnassignPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
+				func(ctx context.Context, ID string, permissions ...ofga.Permission) error {
 					if ID != fmt.Sprintf("user:%s", test.input.id) {
 						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
 					}
@@ -1972,23 +4390,29 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 						return fmt.Errorf("error")
 					}
 
-					gs := make([]string, 0)
+					ps := make([]ofga.Permission, 0)
 
-					for _, g := range test.input.patches {
-						if g.Op == "remove" {
-							gs = append(gs, fmt.Sprintf("group:%s", g.Group))
+					for _, p := range test.input.patches {
+						if p.Op == "remove" {
+							ps = append(
+								ps,
+								ofga.Permission{
+									Relation: p.Entitlement.Entitlement,
+									Object:   fmt.Sprintf("%s:%s", p.Entitlement.EntityType, p.Entitlement.EntityId),
+								},
+							)
 						}
 					}
 
-					if !reflect.DeepEqual(gs, groups) {
-						t.Errorf("expected groups to be %v got %v", gs, groups)
+					if !reflect.DeepEqual(ps, permissions) {
+						t.Errorf("expected permissions to be %v got %v", ps, permissions)
 					}
 
 					return nil
 				},
 			)
 
-			ok, err := svc.PatchIdentityGroups(context.Background(), test.input.id, test.input.patches)
+			ok, err := svc.PatchIdentityEntitlements(context.Background(), test.input.id, test.input.patches)
 
 			if test.expected.err != nil && err == nil {
 				t.Errorf("expected error to be %v got %v", test.expected.err, err)
@@ -2005,67 +4429,429 @@ func TestV1ServicePatchIdentityGroups(t *testing.T) {
 	}
 }
 
-func TestV1ServiceGetIdentityEntitlements(t *testing.T) {
-	type input struct {
-		params *resources.GetIdentitiesItemEntitlementsParams
-		id     string
-	}
-	type expected struct {
-		permissions []resources.EntityEntitlement
-		err         error
+func TestPatchIdentityTraits(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"traits": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"email":    map[string]interface{}{"type": "string"},
+					"name":     map[string]interface{}{"type": "string"},
+					"nickname": map[string]interface{}{"type": "string"},
+				},
+				"required":             []interface{}{"email"},
+				"additionalProperties": false,
+			},
+		},
 	}
 
-	permissions := []resources.EntityEntitlement{
+	tests := []struct {
+		name    string
+		patches []kClient.JsonPatch
+		traits  map[string]interface{}
+		// identity is not fetched when a path is protected, schema only once the patch applies cleanly
+		protected     bool
+		fetchesSchema bool
+		expected      int
+	}{
 		{
-			Entitlement: "can_view",
-			EntityId:    "okta",
-			EntityType:  "client",
+			name:          "add",
+			fetchesSchema: true,
+			patches:       []kClient.JsonPatch{jsonPatch("add", "/traits/nickname", "jd")},
+			traits:        map[string]interface{}{"email": "joe@example.com", "name": "Joe", "nickname": "jd"},
+			expected:      http.StatusOK,
 		},
 		{
-			Entitlement: "can_delete",
-			EntityId:    "github",
-			EntityType:  "client",
+			name:          "replace",
+			fetchesSchema: true,
+			patches:       []kClient.JsonPatch{jsonPatch("replace", "/traits/name", "Jane")},
+			traits:        map[string]interface{}{"email": "joe@example.com", "name": "Jane"},
+			expected:      http.StatusOK,
 		},
 		{
-			Entitlement: "can_create",
-			EntityId:    "github",
-			EntityType:  "client",
+			name:          "remove",
+			fetchesSchema: true,
+			patches:       []kClient.JsonPatch{jsonPatch("remove", "/traits/name", nil)},
+			traits:        map[string]interface{}{"email": "joe@example.com"},
+			expected:      http.StatusOK,
+		},
+		{
+			name:      "protected path",
+			protected: true,
+			patches:   []kClient.JsonPatch{jsonPatch("replace", "/credentials/password", "secret")},
+			expected:  http.StatusBadRequest,
+		},
+		{
+			name:     "invalid path",
+			patches:  []kClient.JsonPatch{jsonPatch("replace", "/traits/missing/key", "value")},
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:          "schema mismatch",
+			fetchesSchema: true,
+			patches:       []kClient.JsonPatch{jsonPatch("remove", "/traits/email", nil)},
+			expected:      http.StatusBadRequest,
 		},
 	}
 
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			current := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "joe@example.com", "name": "Joe"})
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+
+			if !test.protected {
+				mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(current, new(http.Response), nil)
+			}
+
+			if test.fetchesSchema {
+				mockKratosIdentityAPI.EXPECT().GetIdentitySchema(ctx, current.SchemaId).Times(1).Return(kClient.IdentityAPIGetIdentitySchemaRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).Times(1).Return(schema, new(http.Response), nil)
+			}
+
+			if test.expected == http.StatusOK {
+				mockKratosIdentityAPI.EXPECT().PatchIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIPatchIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().PatchIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+					func(r kClient.IdentityAPIPatchIdentityRequest) (*kClient.Identity, *http.Response, error) {
+						patches := (*[]kClient.JsonPatch)(reflect.ValueOf(r).FieldByName("jsonPatch").UnsafePointer())
+
+						if len(*patches) != 1 || (*patches)[0].Op != "replace" || (*patches)[0].Path != "/traits" {
+							t.Fatalf("expected a single replace of /traits got %v", *patches)
+						}
+
+						if !reflect.DeepEqual((*patches)[0].Value, test.traits) {
+							t.Fatalf("expected traits to be %v got %v", test.traits, (*patches)[0].Value)
+						}
+
+						patched := *current
+						patched.Traits = test.traits
+
+						return &patched, new(http.Response), nil
+					},
+				)
+			} else {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+				mockKratosIdentityAPI.EXPECT().PatchIdentity(gomock.Any(), gomock.Any()).Times(0)
+			}
+
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger).PatchIdentityTraits(ctx, current.Id, test.patches)
+
+			if test.expected == http.StatusOK {
+				if err != nil {
+					t.Fatalf("expected error to be nil not %v", err)
+				}
+
+				if !reflect.DeepEqual(ids.Identities[0].Traits, test.traits) {
+					t.Fatalf("expected traits to be %v not %v", test.traits, ids.Identities[0].Traits)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error not to be nil")
+			}
+
+			if *ids.Error.Code != int64(test.expected) {
+				t.Fatalf("expected code to be %v not %v", test.expected, *ids.Error.Code)
+			}
+		})
+	}
+}
+
+func TestSearchIdentities(t *testing.T) {
 	tests := []struct {
 		name     string
-		input    input
-		expected expected
+		query    string
+		fields   []string
+		maxPages int
+		expected []string
+		next     string
+		pages    int
+	}{
+		{
+			name:     "email substring is case insensitive",
+			query:    "EXAMPLE.ORG",
+			maxPages: 10,
+			expected: []string{"test-1", "test-3"},
+			next:     "page-2",
+			pages:    2,
+		},
+		{
+			name:     "nested trait field",
+			query:    "doe",
+			fields:   []string{"name.last"},
+			maxPages: 10,
+			expected: []string{"test-0", "test-2"},
+			next:     "page-2",
+			pages:    2,
+		},
+		{
+			name:     "pages scanned are capped",
+			query:    "nobody",
+			maxPages: 2,
+			expected: []string{},
+			next:     "page-2",
+			pages:    2,
+		},
+		{
+			name:     "not configured traits are ignored",
+			query:    "admin",
+			maxPages: 10,
+			expected: []string{},
+			next:     "",
+			pages:    3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+
+			ctx := context.Background()
+
+			// 2 identities per page over 3 pages, odd ones have an example.org email
+			pages := make(map[string][]kClient.Identity)
+			for i := 0; i < 6; i++ {
+				domain := "example.com"
+				if i%2 == 1 {
+					domain = "example.org"
+				}
+
+				traits := map[string]interface{}{
+					"email": fmt.Sprintf("user%d@%s", i, domain),
+					"name":  map[string]interface{}{"first": "Joe", "last": "Doe"},
+					"role":  "admin",
+				}
+
+				if i%2 == 1 {
+					traits["name"] = map[string]interface{}{"first": "Jane", "last": "Smith"}
+				}
+
+				page := fmt.Sprintf("page-%d", i/2)
+				pages[page] = append(pages[page], *kClient.NewIdentity(fmt.Sprintf("test-%d", i), "default", "https://test.com/default.json", traits))
+			}
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(test.pages).Return(kClient.IdentityAPIListIdentitiesRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(test.pages).DoAndReturn(
+				func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+					pageToken := *(*string)(reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer())
+
+					if pageToken == "" {
+						pageToken = "page-0"
+					}
+
+					rr := &http.Response{Header: make(http.Header)}
+
+					if next := fmt.Sprintf("page-%c", pageToken[len(pageToken)-1]+1); pages[next] != nil {
+						rr.Header.Set("Link", fmt.Sprintf(`<http://kratos/identities?page_size=2&page_token=%s&per_page=2>; rel="next"`, next))
+					}
+
+					return pages[pageToken], rr, nil
+				},
+			)
+
+			svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), NewSearchConfig(test.fields, test.maxPages), mockTracer, mockMonitor, mockLogger)
+
+			ids, err := svc.SearchIdentities(ctx, test.query, 2, "")
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+
+			found := make([]string, 0)
+			for _, identity := range ids.Identities {
+				found = append(found, identity.Id)
+			}
+
+			if !reflect.DeepEqual(found, test.expected) {
+				t.Fatalf("expected identities to be %v not %v", test.expected, found)
+			}
+
+			if ids.Tokens.Next != test.next {
+				t.Fatalf("expected next token to be %q not %q", test.next, ids.Tokens.Next)
+			}
+		})
+	}
+}
+
+func TestCreateRecoveryLink(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		kratosErr bool
+		expected  string
+		status    int
+	}{
+		{
+			name: "default expiry",
+		},
+		{
+			name:      "custom expiry",
+			expiresIn: 2 * time.Hour,
+			expected:  "2h0m0s",
+		},
+		{
+			name:      "expiry too short",
+			expiresIn: 30 * time.Second,
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "expiry too long",
+			expiresIn: 48 * time.Hour,
+			status:    http.StatusBadRequest,
+		},
+		{
+			name:      "kratos error",
+			kratosErr: true,
+			status:    http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockAuditor := NewMockAuditorInterface(ctrl)
+
+			ctx := context.Background()
+			identityID := "test-1"
+
+			link := kClient.NewRecoveryLinkForIdentity("https://kratos/self-service/recovery?token=secret")
+
+			mockTracer.EXPECT().Start(ctx, "identities.Service.CreateRecoveryLink").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+
+			if test.status == http.StatusBadRequest {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+				mockKratosIdentityAPI.EXPECT().CreateRecoveryLinkForIdentity(gomock.Any()).Times(0)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			} else {
+				outcome := audit.OutcomeSuccess
+
+				if test.kratosErr {
+					outcome = audit.OutcomeFailure
+					mockLogger.EXPECT().Errorf(gomock.Any(), identityID, gomock.Any()).Times(1)
+				}
+
+				mockAuditor.EXPECT().Record(ctx, audit.IdentityCreateRecoveryLink, audit.IdentityResource, identityID, outcome).Times(1)
+				mockKratosIdentityAPI.EXPECT().CreateRecoveryLinkForIdentity(ctx).Times(1).Return(kClient.IdentityAPICreateRecoveryLinkForIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().CreateRecoveryLinkForIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+					func(r kClient.IdentityAPICreateRecoveryLinkForIdentityRequest) (*kClient.RecoveryLinkForIdentity, *http.Response, error) {
+						body := (*kClient.CreateRecoveryLinkForIdentityBody)(reflect.ValueOf(r).FieldByName("createRecoveryLinkForIdentityBody").UnsafePointer())
+
+						if body.IdentityId != identityID {
+							t.Fatalf("expected identity id to be %s got %s", identityID, body.IdentityId)
+						}
+
+						if body.GetExpiresIn() != test.expected {
+							t.Fatalf("expected expires_in to be %q got %q", test.expected, body.GetExpiresIn())
+						}
+
+						if !test.kratosErr {
+							return link, new(http.Response), nil
+						}
+
+						rr := httptest.NewRecorder()
+						rr.Header().Set("Content-Type", "application/json")
+						rr.WriteHeader(http.StatusNotFound)
+
+						json.NewEncoder(rr).Encode(
+							map[string]interface{}{
+								"error": map[string]interface{}{
+									"code":    http.StatusNotFound,
+									"message": "error",
+									"reason":  "error",
+									"status":  "Not Found",
+								},
+							},
+						)
+
+						return nil, rr.Result(), fmt.Errorf("error")
+					},
+				)
+			}
+
+			data, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, mockAuditor, nil, mockTracer, mockMonitor, mockLogger).CreateRecoveryLink(ctx, identityID, test.expiresIn)
+
+			if test.status != 0 {
+				if err == nil {
+					t.Fatal("expected error to be not nil")
+				}
+
+				if data.Error == nil || data.Error.GetCode() != int64(test.status) {
+					t.Fatalf("expected error code to be %v got %v", test.status, data.Error)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
+
+			if !reflect.DeepEqual(data.RecoveryLink, link) {
+				t.Fatalf("expected recovery link to be %v not %v", link, data.RecoveryLink)
+			}
+		})
+	}
+}
+
+func TestVerifyAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		address   string
+		verified  bool
+		kratosErr bool
+		status    int
 	}{
 		{
-			name: "empty payload",
-			input: input{
-				id: uuid.NewString(),
-			},
-			expected: expected{
-				permissions: []resources.EntityEntitlement{},
-				err:         nil,
-			},
+			name:    "unverified address",
+			address: "Joe@Example.com",
 		},
 		{
-			name: "error",
-			input: input{
-				id: uuid.NewString(),
-			},
-			expected: expected{
-				err: fmt.Errorf("error"),
-			},
+			name:     "already verified",
+			address:  "joe@example.com",
+			verified: true,
 		},
 		{
-			name: "success",
-			input: input{
-				id: uuid.NewString(),
-			},
-			expected: expected{
-				permissions: permissions,
-				err:         nil,
-			},
+			name:    "address of another identity",
+			address: "jane@example.com",
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:      "kratos error",
+			address:   "joe@example.com",
+			kratosErr: true,
+			status:    http.StatusInternalServerError,
 		},
 	}
 
@@ -2077,162 +4863,151 @@ func TestV1ServiceGetIdentityEntitlements(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := NewMockMonitorInterface(ctrl)
-			mockCoreV1 := NewMockCoreV1Interface(ctrl)
 			mockAuthz := NewMockAuthorizerInterface(ctrl)
 			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
-			mockOpenFGAStore := NewMockOpenFGAStoreInterface(ctrl)
 			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockAuditor := NewMockAuditorInterface(ctrl)
 
 			ctx := context.Background()
 
-			cfg := new(Config)
-			cfg.K8s = mockCoreV1
-			cfg.Name = "schemas"
-			cfg.Namespace = "default"
-			cfg.OpenFGAStore = mockOpenFGAStore
+			current := kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "joe@example.com"})
+			current.VerifiableAddresses = []kClient.VerifiableIdentityAddress{
+				*kClient.NewVerifiableIdentityAddress("pending", "joe@example.com", test.verified, "email"),
+			}
 
-			cm := new(corev1.ConfigMap)
-			cm.Data = make(map[string]string)
-			cm.Data[DEFAULT_SCHEMA] = "test"
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockKratosIdentityAPI.EXPECT().GetIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIGetIdentityRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().GetIdentityExecute(gomock.Any()).Times(1).Return(current, new(http.Response), nil)
 
-			svc := NewV1Service(
-				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
-			)
+			if test.verified || test.status == http.StatusBadRequest {
+				mockKratosIdentityAPI.EXPECT().PatchIdentity(gomock.Any(), gomock.Any()).Times(0)
+				mockAuditor.EXPECT().Record(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			} else {
+				outcome := audit.OutcomeSuccess
 
-			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
-			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
-			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-			mockOpenFGAStore.EXPECT().ListPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).Times(1).DoAndReturn(
-				func(ctx context.Context, ID string, tokens map[string]string) ([]ofga.Permission, map[string]string, error) {
-					if ID != fmt.Sprintf("user:%s", test.input.id) {
-						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
-					}
+				if test.kratosErr {
+					outcome = audit.OutcomeFailure
+				}
 
-					if test.expected.err != nil {
-						return nil, nil, fmt.Errorf("error")
-					}
+				mockAuditor.EXPECT().Record(ctx, audit.IdentityVerifyAddress, audit.IdentityResource, current.Id, outcome).Times(1)
+				mockKratosIdentityAPI.EXPECT().PatchIdentity(ctx, current.Id).Times(1).Return(kClient.IdentityAPIPatchIdentityRequest{ApiService: mockKratosIdentityAPI})
+				mockKratosIdentityAPI.EXPECT().PatchIdentityExecute(gomock.Any()).Times(1).DoAndReturn(
+					func(r kClient.IdentityAPIPatchIdentityRequest) (*kClient.Identity, *http.Response, error) {
+						patches := (*[]kClient.JsonPatch)(reflect.ValueOf(r).FieldByName("jsonPatch").UnsafePointer())
 
-					ps := make([]ofga.Permission, 0)
+						paths := make([]string, 0)
+						for _, p := range *patches {
+							paths = append(paths, p.Path)
+						}
 
-					for _, p := range test.expected.permissions {
-						ps = append(
-							ps,
-							ofga.Permission{
-								Relation: p.Entitlement,
-								Object:   fmt.Sprintf("%s:%s", p.EntityType, p.EntityId),
-							},
-						)
-					}
-					return ps, map[string]string{}, nil
-				},
-			)
+						expected := []string{"/verifiable_addresses/0/verified", "/verifiable_addresses/0/verified_at", "/verifiable_addresses/0/status"}
 
-			r, err := svc.GetIdentityEntitlements(context.Background(), test.input.id, test.input.params)
+						if !reflect.DeepEqual(paths, expected) {
+							t.Fatalf("expected patch paths to be %v got %v", expected, paths)
+						}
 
-			if test.expected.err != nil && err == nil {
-				t.Errorf("expected error to be %v got %v", test.expected.err, err)
+						if test.kratosErr {
+							rr := httptest.NewRecorder()
+							rr.Header().Set("Content-Type", "application/json")
+							rr.WriteHeader(http.StatusInternalServerError)
+
+							json.NewEncoder(rr).Encode(
+								map[string]interface{}{
+									"error": map[string]interface{}{
+										"code":    http.StatusInternalServerError,
+										"message": "error",
+										"reason":  "error",
+										"status":  "Internal Server Error",
+									},
+								},
+							)
+
+							return nil, rr.Result(), fmt.Errorf("error")
+						}
+
+						now := time.Now()
+
+						patched := *current
+						patched.VerifiableAddresses = []kClient.VerifiableIdentityAddress{current.VerifiableAddresses[0]}
+						patched.VerifiableAddresses[0].Verified = true
+						patched.VerifiableAddresses[0].VerifiedAt = &now
+
+						return &patched, new(http.Response), nil
+					},
+				)
 			}
 
-			if test.expected.err != nil {
-				return
+			if test.status != 0 {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
 			}
 
-			if !reflect.DeepEqual(r.Data, test.expected.permissions) {
-				t.Errorf("invalid result, expected: %v, got: %v", test.expected.permissions, r.Data)
+			ids, err := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, mockAuditor, nil, mockTracer, mockMonitor, mockLogger).VerifyAddress(ctx, current.Id, test.address)
+
+			if test.status != 0 {
+				if err == nil {
+					t.Fatal("expected error to be not nil")
+				}
+
+				if ids.Error == nil || ids.Error.GetCode() != int64(test.status) {
+					t.Fatalf("expected error code to be %v got %v", test.status, ids.Error)
+				}
+
+				return
 			}
-		})
-	}
-}
 
-func TestV1ServicePatchIdentityEntitlements(t *testing.T) {
-	type input struct {
-		patches []resources.IdentityEntitlementsPatchItem
-		id      string
-	}
-	type expected struct {
-		ok  bool
-		err error
-	}
+			if err != nil {
+				t.Fatalf("expected error to be nil not %v", err)
+			}
 
-	additions := []resources.IdentityEntitlementsPatchItem{
-		{
-			Op: "add",
-			Entitlement: resources.EntityEntitlement{
-				Entitlement: "can_view",
-				EntityId:    "okta",
-				EntityType:  "client",
-			},
-		},
-		{
-			Op: "add",
-			Entitlement: resources.EntityEntitlement{
-				Entitlement: "can_delete",
-				EntityId:    "github",
-				EntityType:  "client",
-			},
-		},
-	}
+			verification, ok := ids.Identities[0].AdditionalProperties[verificationProperty].([]AddressVerification)
 
-	removals := []resources.IdentityEntitlementsPatchItem{
-		{
-			Op: "remove",
-			Entitlement: resources.EntityEntitlement{
-				Entitlement: "can_create",
-				EntityId:    "github",
-				EntityType:  "client",
-			},
-		},
+			if !ok || len(verification) != 1 || !verification[0].Verified || verification[0].Value != "joe@example.com" {
+				t.Fatalf("expected the address to be verified got %v", ids.Identities[0].AdditionalProperties)
+			}
+		})
 	}
+}
 
+func TestV1ServiceGetEffectivePermissions(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    input
-		expected expected
+		name      string
+		storeErr  bool
+		submitErr bool
+		expected  []EffectivePermission
 	}{
 		{
-			name: "empty payload",
-			input: input{
-				id:      uuid.NewString(),
-				patches: []resources.IdentityEntitlementsPatchItem{},
-			},
-			expected: expected{
-				ok:  true,
-				err: nil,
-			},
-		},
-		{
-			name: "error assign",
-			input: input{
-				id:      uuid.NewString(),
-				patches: additions,
-			},
-			expected: expected{
-				err: fmt.Errorf("error"),
-				ok:  false,
+			name: "union across sources",
+			expected: []EffectivePermission{
+				{
+					Relation: "can_view",
+					Object:   "client:okta",
+					Sources:  []PermissionSource{{Type: PermissionSourceRole, ID: "viewer"}},
+				},
+				{
+					Relation: "can_edit",
+					Object:   "group:devops",
+					Sources: []PermissionSource{
+						{Type: PermissionSourceDirect},
+						{Type: PermissionSourceGroup, ID: "it-admin"},
+					},
+				},
+				{
+					Relation: "can_view",
+					Object:   "group:devops",
+					Sources: []PermissionSource{
+						{Type: PermissionSourceGroup, ID: "it-admin"},
+						{Type: PermissionSourceRole, ID: "viewer"},
+					},
+				},
 			},
 		},
 		{
-			name: "error unassign",
-			input: input{
-				id:      uuid.NewString(),
-				patches: removals,
-			},
-			expected: expected{
-				err: fmt.Errorf("error"),
-				ok:  false,
-			},
+			name:     "store error",
+			storeErr: true,
 		},
 		{
-			name: "success",
-			input: input{
-				id:      uuid.NewString(),
-				patches: append(removals, additions...),
-			},
-			expected: expected{
-				ok:  true,
-				err: nil,
-			},
+			name:      "worker pool full",
+			submitErr: true,
 		},
 	}
 
@@ -2244,109 +5019,90 @@ func TestV1ServicePatchIdentityEntitlements(t *testing.T) {
 			mockLogger := NewMockLoggerInterface(ctrl)
 			mockTracer := NewMockTracer(ctrl)
 			mockMonitor := NewMockMonitorInterface(ctrl)
-			mockCoreV1 := NewMockCoreV1Interface(ctrl)
 			mockAuthz := NewMockAuthorizerInterface(ctrl)
 			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
 			mockOpenFGAStore := NewMockOpenFGAStoreInterface(ctrl)
 			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
 
 			ctx := context.Background()
+			identityID := "joe"
 
 			cfg := new(Config)
-			cfg.K8s = mockCoreV1
-			cfg.Name = "schemas"
-			cfg.Namespace = "default"
 			cfg.OpenFGAStore = mockOpenFGAStore
-
-			cm := new(corev1.ConfigMap)
-			cm.Data = make(map[string]string)
-			cm.Data[DEFAULT_SCHEMA] = "test"
+			cfg.WorkerPool = mockWorkerPool
 
 			svc := NewV1Service(
 				cfg,
-				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockTracer, mockMonitor, mockLogger),
+				NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, nil, audit.NewNoopAuditor(), nil, mockTracer, mockMonitor, mockLogger),
 			)
 
-			// AssignGroups(context.Context, string, ...string) error
-			// UnassignGroups(context.Context, string, ...string) error
-			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
 			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
-			mockOpenFGAStore.EXPECT().AssignPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, permissions ...ofga.Permission) error {
-					if ID != fmt.Sprintf("user:%s", test.input.id) {
-						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
-					}
+			mockOpenFGAStore.EXPECT().ListAssignedGroups(gomock.Any(), "user:joe").Return([]string{"it-admin"}, nil)
+			mockOpenFGAStore.EXPECT().ListAssignedRoles(gomock.Any(), "user:joe").Return([]string{"viewer"}, nil)
 
-					if test.expected.err != nil {
-						return fmt.Errorf("error")
-					}
+			if test.submitErr {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).Return("", fmt.Errorf("WorkerPool queue is full"))
+			} else {
+				mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(
+					func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+						defer wg.Done()
 
-					ps := make([]ofga.Permission, 0)
+						results <- pool.NewResult[any](uuid.New(), command.(func() any)())
 
-					for _, p := range test.input.patches {
-						if p.Op == "add" {
-							ps = append(
-								ps,
-								ofga.Permission{
-									Relation: p.Entitlement.Entitlement,
-									Object:   fmt.Sprintf("%s:%s", p.Entitlement.EntityType, p.Entitlement.EntityId),
-								},
-							)
-						}
-					}
+						return "", nil
+					},
+				)
+			}
 
-					if !reflect.DeepEqual(ps, permissions) {
-						t.Errorf("expected permissions to be %v got %v", ps, permissions)
-					}
+			if test.storeErr || test.submitErr {
+				mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			}
 
-					return nil
+			permissions := map[string][]ofga.Permission{
+				"user:joe": {
+					{Relation: "can_edit", Object: "group:devops"},
 				},
-			)
-
-			mockOpenFGAStore.EXPECT().UnassignPermissions(gomock.Any(), fmt.Sprintf("user:%s", test.input.id), gomock.Any()).MinTimes(0).MaxTimes(1).DoAndReturn(
-				func(ctx context.Context, ID string, permissions ...ofga.Permission) error {
-					if ID != fmt.Sprintf("user:%s", test.input.id) {
-						t.Errorf("expected ID to be user:%s got %s", test.input.id, ID)
-					}
-
-					if test.expected.err != nil {
-						return fmt.Errorf("error")
-					}
-
-					ps := make([]ofga.Permission, 0)
+				"group:it-admin#member": {
+					{Relation: "can_edit", Object: "group:devops"},
+					{Relation: "can_view", Object: "group:devops"},
+				},
+				"role:viewer#assignee": {
+					{Relation: "can_view", Object: "group:devops"},
+					{Relation: "can_view", Object: "client:okta"},
+				},
+			}
 
-					for _, p := range test.input.patches {
-						if p.Op == "remove" {
-							ps = append(
-								ps,
-								ofga.Permission{
-									Relation: p.Entitlement.Entitlement,
-									Object:   fmt.Sprintf("%s:%s", p.Entitlement.EntityType, p.Entitlement.EntityId),
-								},
-							)
-						}
+			mockOpenFGAStore.EXPECT().ListAllPermissions(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, ID string) ([]ofga.Permission, error) {
+					if _, ok := permissions[ID]; !ok {
+						t.Errorf("unexpected source %s", ID)
 					}
 
-					if !reflect.DeepEqual(ps, permissions) {
-						t.Errorf("expected permissions to be %v got %v", ps, permissions)
+					if test.storeErr && ID == "role:viewer#assignee" {
+						return nil, fmt.Errorf("error")
 					}
 
-					return nil
+					return permissions[ID], nil
 				},
 			)
 
-			ok, err := svc.PatchIdentityEntitlements(context.Background(), test.input.id, test.input.patches)
+			result, err := svc.GetEffectivePermissions(ctx, identityID)
 
-			if test.expected.err != nil && err == nil {
-				t.Errorf("expected error to be %v got %v", test.expected.err, err)
-			}
+			if test.storeErr || test.submitErr {
+				if err == nil {
+					t.Fatal("expected error to be not nil")
+				}
 
-			if test.expected.err != nil {
 				return
 			}
 
-			if ok != test.expected.ok {
-				t.Errorf("invalid result, expected: %v, got: %v", test.expected.ok, ok)
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expected effective permissions to be %v got %v", test.expected, result)
 			}
 		})
 	}
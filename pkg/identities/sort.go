@@ -0,0 +1,89 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	kClient "github.com/ory/kratos-client-go"
+)
+
+// ErrInvalidSort is returned when the sort parameter names a field identities can't be sorted by
+var ErrInvalidSort = errors.New("invalid sort")
+
+// IdentitySortFields are the fields accepted by the sort parameter of the identities list
+var IdentitySortFields = []string{"id", "email", "state", "schema_id", "created_at", "updated_at"}
+
+// IdentitySort orders a page of identities by Field, Kratos doesn't sort so only the identities of
+// the fetched page are ordered, the split of the identities across pages stays the one of Kratos
+type IdentitySort struct {
+	Field      string
+	Descending bool
+
+	traits *TraitsMapping
+}
+
+// ParseIdentitySort reads a sort parameter such as email or -created_at, a leading - sorts in
+// descending order, an empty value returns a nil IdentitySort keeping the Kratos order
+// the email field is read from the trait set in traits, DefaultTraitsMapping is used when nil
+func ParseIdentitySort(value string, traits *TraitsMapping) (*IdentitySort, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	s := new(IdentitySort)
+	s.Field, s.Descending = strings.CutPrefix(value, "-")
+
+	if !slices.Contains(IdentitySortFields, s.Field) {
+		return nil, fmt.Errorf("%w %s, allowed fields are %s", ErrInvalidSort, s.Field, strings.Join(IdentitySortFields, ", "))
+	}
+
+	s.traits = traits
+
+	if s.traits == nil {
+		s.traits = DefaultTraitsMapping()
+	}
+
+	return s, nil
+}
+
+// Sort orders identities in place, identities with equal values keep their relative order,
+// a nil IdentitySort leaves identities untouched
+func (s *IdentitySort) Sort(identities []kClient.Identity) {
+	if s == nil {
+		return
+	}
+
+	sort.SliceStable(identities, func(i, j int) bool {
+		if s.Descending {
+			return s.compare(identities[j], identities[i]) < 0
+		}
+
+		return s.compare(identities[i], identities[j]) < 0
+	})
+}
+
+func (s *IdentitySort) compare(a, b kClient.Identity) int {
+	switch s.Field {
+	case "email":
+		return strings.Compare(
+			strings.ToLower(stringTraits(a.Traits)[s.traits.Email]),
+			strings.ToLower(stringTraits(b.Traits)[s.traits.Email]),
+		)
+	case "state":
+		return strings.Compare(a.GetState(), b.GetState())
+	case "schema_id":
+		return strings.Compare(a.SchemaId, b.SchemaId)
+	case "created_at":
+		return a.GetCreatedAt().Compare(b.GetCreatedAt())
+	case "updated_at":
+		return a.GetUpdatedAt().Compare(b.GetUpdatedAt())
+	default:
+		return strings.Compare(a.Id, b.Id)
+	}
+}
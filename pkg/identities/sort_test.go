@@ -0,0 +1,78 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	kClient "github.com/ory/kratos-client-go"
+)
+
+func TestIdentitySort(t *testing.T) {
+	identities := func() []kClient.Identity {
+		values := make([]kClient.Identity, 0)
+
+		for _, i := range []struct {
+			id     string
+			mail   string
+			state  string
+			schema string
+		}{
+			{"1", "zed@example.com", "active", "staff"},
+			{"2", "amy@example.com", "inactive", "admin"},
+			{"3", "kim@example.com", "active", "admin"},
+		} {
+			identity := kClient.NewIdentity(i.id, i.schema, "", map[string]interface{}{"mail": i.mail})
+			identity.SetState(i.state)
+			values = append(values, *identity)
+		}
+
+		return values
+	}
+
+	mapping, _ := NewTraitsMapping("mail", "", "", "", "")
+
+	tests := []struct {
+		sort     string
+		expected []string
+		err      error
+	}{
+		{sort: "", expected: []string{"1", "2", "3"}},
+		{sort: "-id", expected: []string{"3", "2", "1"}},
+		{sort: "email", expected: []string{"2", "3", "1"}},
+		{sort: "-email", expected: []string{"1", "3", "2"}},
+		{sort: "state", expected: []string{"1", "3", "2"}},
+		{sort: "-schema_id", expected: []string{"1", "2", "3"}},
+		{sort: "traits", err: ErrInvalidSort},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			order, err := ParseIdentitySort(test.sort, mapping)
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error to be %v got %v", test.err, err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			values := identities()
+			order.Sort(values)
+
+			ids := make([]string, 0, len(values))
+
+			for _, identity := range values {
+				ids = append(ids, identity.Id)
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("expected identities to be %v got %v", test.expected, ids)
+			}
+		})
+	}
+}
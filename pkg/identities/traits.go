@@ -0,0 +1,177 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ErrTraitsSchemaMismatch is returned when the traits don't satisfy the identity schema
+var ErrTraitsSchemaMismatch = errors.New("traits don't match the identity schema")
+
+// traitsErrorsDetail is the GenericError details key holding the []TraitsError of a failed validation
+const traitsErrorsDetail = "traits_errors"
+
+// retryAfterDetail is the GenericError details key holding the Retry-After header sent by kratos
+const retryAfterDetail = "retry_after"
+
+// TraitsError is a single failure found validating traits, Path is a JSON pointer into the identity
+type TraitsError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// TraitsValidationError lists every failure found validating traits, it wraps ErrTraitsSchemaMismatch
+type TraitsValidationError struct {
+	Errors []TraitsError
+}
+
+func (e *TraitsValidationError) Error() string {
+	failures := make([]string, 0, len(e.Errors))
+
+	for _, f := range e.Errors {
+		failures = append(failures, fmt.Sprintf("%s %s", f.Path, f.Message))
+	}
+
+	return fmt.Sprintf("%s: %s", ErrTraitsSchemaMismatch, strings.Join(failures, "; "))
+}
+
+func (e *TraitsValidationError) Unwrap() error {
+	return ErrTraitsSchemaMismatch
+}
+
+// validateTraits checks traits against the traits property of a kratos identity schema, only the
+// type, properties, required, additionalProperties, items and enum keywords are enforced, kratos
+// still runs the full JSON schema validation on update
+// all the failures are collected in a *TraitsValidationError rather than stopping at the first one
+func validateTraits(schema map[string]interface{}, traits interface{}) error {
+	properties, _ := schema["properties"].(map[string]interface{})
+	traitsSchema, ok := properties["traits"].(map[string]interface{})
+
+	if !ok {
+		return fmt.Errorf("%w: schema has no traits", ErrTraitsSchemaMismatch)
+	}
+
+	v := new(TraitsValidationError)
+	v.validateValue(traitsSchema, traits, "/traits")
+
+	if len(v.Errors) > 0 {
+		return v
+	}
+
+	return nil
+}
+
+func (e *TraitsValidationError) fail(path, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, TraitsError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *TraitsValidationError) validateValue(schema map[string]interface{}, value interface{}, path string) {
+	if !matchesType(schema["type"], value) {
+		e.fail(path, "must be of type %v", schema["type"])
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !slices.Contains(enum, value) {
+		e.fail(path, "must be one of %v", enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		e.validateObject(schema, v, path)
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+
+		if !ok {
+			return
+		}
+
+		for i, item := range v {
+			e.validateValue(items, item, fmt.Sprintf("%s/%d", path, i))
+		}
+	}
+}
+
+func (e *TraitsValidationError) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) {
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, key := range required {
+			if _, ok := object[fmt.Sprint(key)]; !ok {
+				e.fail(fmt.Sprintf("%s/%v", path, key), "is required")
+			}
+		}
+	}
+
+	// sorted so failures are reported in a stable order
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		propertySchema, ok := properties[key].(map[string]interface{})
+
+		if !ok {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				e.fail(fmt.Sprintf("%s/%s", path, key), "is not allowed")
+			}
+
+			continue
+		}
+
+		e.validateValue(propertySchema, object[key], fmt.Sprintf("%s/%s", path, key))
+	}
+}
+
+// matchesType checks value against the type keyword, which is either a single type or a list of them
+func matchesType(schemaType interface{}, value interface{}) bool {
+	switch t := schemaType.(type) {
+	case string:
+		return isType(t, value)
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok && isType(name, value) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return true
+	}
+}
+
+func isType(name string, value interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
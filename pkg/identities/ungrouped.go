@@ -0,0 +1,164 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	kClient "github.com/ory/kratos-client-go"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+)
+
+// ErrGroupMembershipsNotSet is returned by ListUngroupedIdentities when no OpenFGA client was set
+var ErrGroupMembershipsNotSet = errors.New("OpenFGA client to read group memberships is not set")
+
+type groupMembershipResult struct {
+	index   int
+	grouped bool
+	err     error
+}
+
+// SetOpenFGAClient sets the client used by ListUngroupedIdentities to read the group memberships
+func (s *Service) SetOpenFGAClient(c OpenFGAClientInterface) {
+	s.ofga = c
+}
+
+// ListUngroupedIdentities returns the identities that are not a member of any group, kratos pages
+// are scanned like SearchIdentities, at most SearchConfig.MaxPages per call, until size identities
+// are found, keep following the next token for more results
+// every identity read costs an OpenFGA request, the checks of a page run on the worker pool
+func (s *Service) ListUngroupedIdentities(ctx context.Context, size int64, token string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.ListUngroupedIdentities")
+	defer span.End()
+
+	data := new(IdentityData)
+	data.Identities = make([]kClient.Identity, 0)
+
+	if s.ofga == nil {
+		data.Error = s.parseError(nil)
+		data.Error.SetMessage(ErrGroupMembershipsNotSet.Error())
+
+		return data, ErrGroupMembershipsNotSet
+	}
+
+	for page := 0; page < s.search.MaxPages; page++ {
+		identities, rr, err := s.kratos.ListIdentitiesExecute(
+			s.buildListRequest(ctx, size, token, ""),
+		)
+
+		if err != nil {
+			s.logger.Error(err)
+			data.Error = s.parseError(rr)
+
+			return data, err
+		}
+
+		navTokens, err := types.ParseLinkTokens(rr.Header)
+
+		if err != nil {
+			s.logger.Warnf("failed parsing link header: %s", err)
+		}
+
+		if page == 0 {
+			data.Tokens.Prev = navTokens.Prev
+		}
+
+		data.Tokens.Next = navTokens.Next
+
+		ungrouped, err := s.filterUngrouped(ctx, identities)
+
+		if err != nil {
+			data.Error = s.parseError(nil)
+			data.Error.SetMessage(err.Error())
+
+			return data, err
+		}
+
+		data.Identities = append(data.Identities, ungrouped...)
+
+		if int64(len(data.Identities)) >= size || navTokens.Next == "" {
+			break
+		}
+
+		token = navTokens.Next
+	}
+
+	data.Identities = withVerification(data.Identities)
+
+	return data, nil
+}
+
+// filterUngrouped keeps the identities with no member tuple on a group, the order is preserved
+func (s *Service) filterUngrouped(ctx context.Context, identities []kClient.Identity) ([]kClient.Identity, error) {
+	// buffered so workers never block on sending, see OpenFGAStore.ListPermissions
+	results := make(chan *pool.Result[any], len(identities))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(identities))
+
+	var submitErr error
+
+	for i, identity := range identities {
+		if _, err := s.wpool.Submit(s.groupMembershipFunc(ctx, i, identity.Id), results, &wg); err != nil {
+			// job never made it to the pool, wg won't be released by it
+			wg.Done()
+
+			s.logger.Errorf("failed submitting group membership check of identity %s: %s", identity.Id, err)
+
+			if submitErr == nil {
+				submitErr = fmt.Errorf("failed checking the group memberships of identity %s: %w", identity.Id, err)
+			}
+		}
+	}
+
+	wg.Wait()
+	close(results)
+
+	if submitErr != nil {
+		return nil, submitErr
+	}
+
+	grouped := make([]bool, len(identities))
+
+	for r := range results {
+		v := r.Value.(groupMembershipResult)
+
+		if v.err != nil {
+			return nil, fmt.Errorf("failed checking the group memberships of identity %s: %w", identities[v.index].Id, v.err)
+		}
+
+		grouped[v.index] = v.grouped
+	}
+
+	ungrouped := make([]kClient.Identity, 0)
+
+	for i, identity := range identities {
+		if !grouped[i] {
+			ungrouped = append(ungrouped, identity)
+		}
+	}
+
+	return ungrouped, nil
+}
+
+func (s *Service) groupMembershipFunc(ctx context.Context, index int, ID string) func() any {
+	return func() any {
+		// a single tuple is enough to tell the identity belongs to a group
+		r, err := s.ofga.ReadTuples(ctx, fmt.Sprintf("user:%s", ID), ofga.MEMBER_RELATION, "group:", "")
+
+		if err != nil {
+			s.logger.Error(err.Error())
+
+			return groupMembershipResult{index: index, err: err}
+		}
+
+		return groupMembershipResult{index: index, grouped: len(r.GetTuples()) > 0}
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	kClient "github.com/ory/kratos-client-go"
+	"go.opentelemetry.io/otel/trace"
+	gomock "go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+)
+
+func TestListUngroupedIdentities(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxPages int
+		grouped  []string
+		readErr  error
+		expected []string
+		next     string
+		pages    int
+	}{
+		{
+			name:     "grouped identities are left out",
+			maxPages: 10,
+			grouped:  []string{"test-0", "test-3"},
+			expected: []string{"test-1", "test-2"},
+			next:     "page-2",
+			pages:    2,
+		},
+		{
+			name:     "pages scanned are capped",
+			maxPages: 2,
+			grouped:  []string{"test-0", "test-1", "test-2", "test-3", "test-4", "test-5"},
+			expected: []string{},
+			next:     "page-2",
+			pages:    2,
+		},
+		{
+			name:     "last page",
+			maxPages: 10,
+			grouped:  []string{"test-0", "test-1", "test-2", "test-3", "test-4"},
+			expected: []string{"test-5"},
+			next:     "",
+			pages:    3,
+		},
+		{
+			name:     "membership check fails",
+			maxPages: 10,
+			readErr:  fmt.Errorf("openfga unavailable"),
+			pages:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthz := NewMockAuthorizerInterface(ctrl)
+			mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+			mockEmail := mail.NewMockEmailServiceInterface(ctrl)
+			mockWorkerPool := NewMockWorkerPoolInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			ctx := context.Background()
+
+			// 2 identities per page over 3 pages
+			pages := make(map[string][]kClient.Identity)
+			for i := 0; i < 6; i++ {
+				page := fmt.Sprintf("page-%d", i/2)
+				pages[page] = append(pages[page], *kClient.NewIdentity(fmt.Sprintf("test-%d", i), "default", "https://test.com/default.json", map[string]interface{}{"email": "test@example.com"}))
+			}
+
+			mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockKratosIdentityAPI.EXPECT().ListIdentities(ctx).Times(test.pages).Return(kClient.IdentityAPIListIdentitiesRequest{ApiService: mockKratosIdentityAPI})
+			mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(test.pages).DoAndReturn(
+				func(r kClient.IdentityAPIListIdentitiesRequest) ([]kClient.Identity, *http.Response, error) {
+					pageToken := *(*string)(reflect.ValueOf(r).FieldByName("pageToken").UnsafePointer())
+
+					if pageToken == "" {
+						pageToken = "page-0"
+					}
+
+					rr := &http.Response{Header: make(http.Header)}
+
+					if next := fmt.Sprintf("page-%c", pageToken[len(pageToken)-1]+1); pages[next] != nil {
+						rr.Header.Set("Link", fmt.Sprintf(`<http://kratos/identities?page_size=2&page_token=%s&per_page=2>; rel="next"`, next))
+					}
+
+					return pages[pageToken], rr, nil
+				},
+			)
+			mockWorkerPool.EXPECT().Submit(gomock.Any(), gomock.Any(), gomock.Any()).Times(2 * test.pages).DoAndReturn(
+				func(command any, results chan *pool.Result[any], wg *sync.WaitGroup) (string, error) {
+					defer wg.Done()
+
+					results <- pool.NewResult[any](uuid.New(), command.(func() any)())
+
+					return "", nil
+				},
+			)
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), "member", "group:", "").Times(2 * test.pages).DoAndReturn(
+				func(ctx context.Context, user, relation, object, token string) (*client.ClientReadResponse, error) {
+					if test.readErr != nil {
+						return nil, test.readErr
+					}
+
+					r := new(client.ClientReadResponse)
+					tuples := []openfga.Tuple{}
+
+					for _, ID := range test.grouped {
+						if user == fmt.Sprintf("user:%s", ID) {
+							tuples = append(tuples, *openfga.NewTuple(*openfga.NewTupleKey(user, relation, "group:admins"), time.Now()))
+						}
+					}
+
+					r.SetTuples(tuples)
+
+					return r, nil
+				},
+			)
+
+			svc := NewService(mockKratosIdentityAPI, mockAuthz, mockEmail, mockWorkerPool, audit.NewNoopAuditor(), NewSearchConfig(nil, test.maxPages), mockTracer, mockMonitor, mockLogger)
+			svc.SetOpenFGAClient(mockOpenFGA)
+
+			ids, err := svc.ListUngroupedIdentities(ctx, 2, "")
+
+			if !errors.Is(err, test.readErr) {
+				t.Fatalf("expected error to be %v not %v", test.readErr, err)
+			}
+
+			if test.readErr != nil {
+				if ids.Error == nil || !strings.Contains(ids.Error.GetMessage(), test.readErr.Error()) {
+					t.Fatalf("expected the error to be reported, got %v", ids.Error)
+				}
+
+				return
+			}
+
+			found := make([]string, 0)
+			for _, identity := range ids.Identities {
+				found = append(found, identity.Id)
+			}
+
+			if !reflect.DeepEqual(found, test.expected) {
+				t.Fatalf("expected identities to be %v not %v", test.expected, found)
+			}
+
+			if ids.Tokens.Next != test.next {
+				t.Fatalf("expected next token to be %q not %q", test.next, ids.Tokens.Next)
+			}
+		})
+	}
+}
+
+func TestListUngroupedIdentitiesWithoutOpenFGAClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTracer := NewMockTracer(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+
+	ctx := context.Background()
+
+	mockTracer.EXPECT().Start(ctx, gomock.Any()).AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().ListIdentitiesExecute(gomock.Any()).Times(0)
+
+	svc := NewService(mockKratosIdentityAPI, nil, nil, nil, audit.NewNoopAuditor(), nil, mockTracer, NewMockMonitorInterface(ctrl), NewMockLoggerInterface(ctrl))
+
+	if _, err := svc.ListUngroupedIdentities(ctx, 2, ""); !errors.Is(err, ErrGroupMembershipsNotSet) {
+		t.Fatalf("expected error to be %v not %v", ErrGroupMembershipsNotSet, err)
+	}
+}
+
+func TestHandleListUngrouped(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{name: "ungrouped", query: "?ungrouped=true&size=10", status: http.StatusOK},
+		{name: "combined with search", query: "?ungrouped=true&q=joe", status: http.StatusBadRequest},
+		{name: "combined with filter", query: "?ungrouped=true&state=active", status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/identities"+test.query, nil)
+
+			mockService.EXPECT().ListIdentities(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockService.EXPECT().SearchIdentities(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			if test.status == http.StatusOK {
+				mockService.EXPECT().ListUngroupedIdentities(gomock.Any(), int64(10), "").Return(
+					&IdentityData{Identities: []kClient.Identity{*kClient.NewIdentity("test", "test.json", "https://test.com/test.json", map[string]interface{}{"email": "test@example.com"})}},
+					nil,
+				)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			rr := struct {
+				Data []kClient.Identity `json:"data"`
+			}{}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(rr.Data) != 1 || rr.Data[0].Id != "test" {
+				t.Errorf("expected the ungrouped identity to be returned got %v", rr.Data)
+			}
+		})
+	}
+}
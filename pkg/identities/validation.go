@@ -44,6 +44,12 @@ func (p *PayloadValidator) setupValidator() {
 }
 
 func (p *PayloadValidator) NeedsValidation(req *http.Request) bool {
+	// CSV imports, recovery link, group move and verify address requests are parsed and bounded by the handler itself,
+	// recovery codes requests carry no payload
+	if strings.HasSuffix(req.URL.Path, "/identities/import") || strings.HasSuffix(req.URL.Path, "/recovery-link") || strings.HasSuffix(req.URL.Path, "/recovery-codes") || strings.HasSuffix(req.URL.Path, "/move-group") || strings.HasSuffix(req.URL.Path, "/verify-address") {
+		return false
+	}
+
 	return req.Method == http.MethodPost || req.Method == http.MethodPut
 }
 
@@ -61,6 +67,26 @@ func (p *PayloadValidator) Validate(ctx context.Context, method, endpoint string
 		err = p.validator.Struct(createIdentity)
 		validated = true
 
+	} else if p.isCreateIdentities(method, endpoint) {
+		createIdentities := make([]CreateIdentityRequest, 0)
+		if err := json.Unmarshal(body, &createIdentities); err != nil {
+			p.logger.Error("Json parsing error: ", err)
+			return ctx, nil, fmt.Errorf("failed to parse JSON body")
+		}
+
+		err = p.validator.Var(createIdentities, fmt.Sprintf("required,max=%v,dive", MaxBatchSize))
+		validated = true
+
+	} else if p.isGetIdentities(method, endpoint) || p.isDeleteIdentities(method, endpoint) {
+		IDs := make([]string, 0)
+		if err := json.Unmarshal(body, &IDs); err != nil {
+			p.logger.Error("Json parsing error: ", err)
+			return ctx, nil, fmt.Errorf("failed to parse JSON body")
+		}
+
+		err = p.validator.Var(IDs, fmt.Sprintf("required,max=%v,dive,required", MaxBatchSize))
+		validated = true
+
 	} else if p.isUpdateIdentity(method, endpoint) {
 		updateIdentity := new(UpdateIdentityRequest)
 		if err := json.Unmarshal(body, updateIdentity); err != nil {
@@ -88,6 +114,18 @@ func (p *PayloadValidator) isCreateIdentity(method, endpoint string) bool {
 	return endpoint == "" && method == http.MethodPost
 }
 
+func (p *PayloadValidator) isCreateIdentities(method, endpoint string) bool {
+	return endpoint == "/batch" && method == http.MethodPost
+}
+
+func (p *PayloadValidator) isGetIdentities(method, endpoint string) bool {
+	return endpoint == "/batch-get" && method == http.MethodPost
+}
+
+func (p *PayloadValidator) isDeleteIdentities(method, endpoint string) bool {
+	return endpoint == "/batch-delete" && method == http.MethodPost
+}
+
 func (p *PayloadValidator) isUpdateIdentity(method, endpoint string) bool {
 	return strings.HasPrefix(endpoint, "/") && method == http.MethodPut
 }
@@ -37,6 +37,31 @@ func TestNeedsValidation(t *testing.T) {
 			req:            httptest.NewRequest(http.MethodPut, "/", nil),
 			expectedResult: true,
 		},
+		{
+			name:           "CSV import",
+			req:            httptest.NewRequest(http.MethodPost, "/api/v0/identities/import", nil),
+			expectedResult: false,
+		},
+		{
+			name:           "Recovery link",
+			req:            httptest.NewRequest(http.MethodPost, "/api/v0/identities/test-1/recovery-link", nil),
+			expectedResult: false,
+		},
+		{
+			name:           "Recovery codes",
+			req:            httptest.NewRequest(http.MethodPost, "/api/v0/identities/test-1/recovery-codes", nil),
+			expectedResult: false,
+		},
+		{
+			name:           "Move group",
+			req:            httptest.NewRequest(http.MethodPost, "/api/v0/identities/test-1/move-group", nil),
+			expectedResult: false,
+		},
+		{
+			name:           "Verify address",
+			req:            httptest.NewRequest(http.MethodPost, "/api/v0/identities/test-1/verify-address", nil),
+			expectedResult: false,
+		},
 		{
 			name:           http.MethodGet,
 			req:            httptest.NewRequest(http.MethodGet, "/", nil),
@@ -129,6 +154,21 @@ func TestValidate(t *testing.T) {
 			expectedResult: nil,
 			expectedError:  nil,
 		},
+		{
+			name:     "CreateIdentitiesSuccess",
+			method:   http.MethodPost,
+			endpoint: "/batch",
+			body: func() []byte {
+				identity := client.NewCreateIdentityBodyWithDefaults()
+				identity.Credentials = &client.IdentityWithCredentials{
+					Password: &client.IdentityWithCredentialsPassword{},
+				}
+				marshal, _ := json.Marshal([]*client.CreateIdentityBody{identity})
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
 		{
 			name:     "UpdateIdentitySuccessOidc",
 			method:   http.MethodPut,
@@ -185,6 +225,50 @@ func TestValidate(t *testing.T) {
 			expectedResult: validator.ValidationErrors{},
 			expectedError:  nil,
 		},
+		{
+			name:     "GetIdentitiesSuccess",
+			method:   http.MethodPost,
+			endpoint: "/batch-get",
+			body: func() []byte {
+				marshal, _ := json.Marshal([]string{"id-1", "id-2"})
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:     "GetIdentitiesValidationError",
+			method:   http.MethodPost,
+			endpoint: "/batch-get",
+			body: func() []byte {
+				marshal, _ := json.Marshal(make([]string, MaxBatchSize+1))
+				return marshal
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
+		{
+			name:     "DeleteIdentitiesSuccess",
+			method:   http.MethodPost,
+			endpoint: "/batch-delete",
+			body: func() []byte {
+				marshal, _ := json.Marshal([]string{"id-1", "id-2"})
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:     "DeleteIdentitiesValidationError",
+			method:   http.MethodPost,
+			endpoint: "/batch-delete",
+			body: func() []byte {
+				marshal, _ := json.Marshal(make([]string, MaxBatchSize+1))
+				return marshal
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
 		{
 			name:     "UpdateIdentityValidationError",
 			method:   http.MethodPut,
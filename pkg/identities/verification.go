@@ -0,0 +1,133 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package identities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kClient "github.com/ory/kratos-client-go"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+)
+
+// verificationProperty is the identity attribute listing the verification status of its addresses
+const verificationProperty = "verification"
+
+// ErrAddressNotFound is returned when verifying an address that doesn't belong to the identity
+var ErrAddressNotFound = errors.New("address doesn't belong to the identity")
+
+// AddressVerification is the verification status of one of the verifiable addresses of an identity
+type AddressVerification struct {
+	Value      string     `json:"value"`
+	Via        string     `json:"via"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// VerificationStatus returns the verification status of each verifiable address of the identity
+func VerificationStatus(identity kClient.Identity) []AddressVerification {
+	status := make([]AddressVerification, 0, len(identity.VerifiableAddresses))
+
+	for _, address := range identity.VerifiableAddresses {
+		status = append(
+			status,
+			AddressVerification{
+				Value:      address.Value,
+				Via:        address.Via,
+				Verified:   address.Verified,
+				VerifiedAt: address.VerifiedAt,
+			},
+		)
+	}
+
+	return status
+}
+
+// withVerification sets the verification attribute on the identities having verifiable addresses,
+// it is serialized next to the kratos fields of the identity
+func withVerification(identities []kClient.Identity) []kClient.Identity {
+	for i, identity := range identities {
+		if len(identity.VerifiableAddresses) == 0 {
+			continue
+		}
+
+		// the map can be shared with other copies of the identity, don't write to it
+		properties := make(map[string]interface{}, len(identity.AdditionalProperties)+1)
+
+		for k, v := range identity.AdditionalProperties {
+			properties[k] = v
+		}
+
+		properties[verificationProperty] = VerificationStatus(identity)
+		identities[i].AdditionalProperties = properties
+	}
+
+	return identities
+}
+
+// VerifyAddress marks address as verified on the identity through the kratos admin API, the address
+// must be one of the verifiable addresses of the identity, verifying an address twice is a no-op
+func (s *Service) VerifyAddress(ctx context.Context, ID, address string) (*IdentityData, error) {
+	ctx, span := s.tracer.Start(ctx, "identities.Service.VerifyAddress")
+	defer span.End()
+
+	current, err := s.GetIdentity(ctx, ID)
+
+	if err != nil {
+		return current, err
+	}
+
+	identity := current.Identities[0]
+	index := -1
+
+	for i, a := range identity.VerifiableAddresses {
+		if strings.EqualFold(a.Value, address) {
+			index = i
+			break
+		}
+	}
+
+	if index < 0 {
+		return s.badRequest(fmt.Errorf("%w: %s", ErrAddressNotFound, address)), ErrAddressNotFound
+	}
+
+	if identity.VerifiableAddresses[index].Verified {
+		return current, nil
+	}
+
+	prefix := fmt.Sprintf("/verifiable_addresses/%d", index)
+
+	verified := kClient.NewJsonPatch("replace", prefix+"/verified")
+	verified.SetValue(true)
+
+	verifiedAt := kClient.NewJsonPatch("replace", prefix+"/verified_at")
+	verifiedAt.SetValue(time.Now().UTC().Format(time.RFC3339))
+
+	status := kClient.NewJsonPatch("replace", prefix+"/status")
+	status.SetValue("completed")
+
+	patched, rr, err := s.kratos.PatchIdentityExecute(
+		s.kratos.PatchIdentity(ctx, ID).JsonPatch([]kClient.JsonPatch{*verified, *verifiedAt, *status}),
+	)
+
+	s.auditor.Record(ctx, audit.IdentityVerifyAddress, audit.IdentityResource, ID, audit.OutcomeFromError(err))
+
+	data := new(IdentityData)
+	data.Identities = []kClient.Identity{}
+
+	if err != nil {
+		s.logger.Error(err)
+		data.Error = s.parseError(rr)
+
+		return data, err
+	}
+
+	data.Identities = withVerification([]kClient.Identity{*patched})
+
+	return data, nil
+}
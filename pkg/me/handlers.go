@@ -0,0 +1,146 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package me
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+const (
+	PrincipalTypeUser    = "user"
+	PrincipalTypeService = "service"
+)
+
+// capabilityTypes are the object types reported in the permissions summary
+var capabilityTypes = []string{
+	authorization.IDENTITY_TYPE,
+	authorization.GROUP_TYPE,
+	authorization.ROLE_TYPE,
+	authorization.CLIENT_TYPE,
+	authorization.PROVIDER_TYPE,
+	authorization.RULE_TYPE,
+	authorization.SCHEME_TYPE,
+}
+
+// Principal is the current principal as seen by the frontend, Permissions maps each
+// relation to the object types it's granted on
+type Principal struct {
+	Identifier  string              `json:"identifier"`
+	Email       string              `json:"email,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Type        string              `json:"type"`
+	Admin       bool                `json:"admin"`
+	Permissions map[string][]string `json:"permissions"`
+}
+
+// API exposes the principal of the current session, it needs no permission on top of authentication
+type API struct {
+	authorizer AuthorizerInterface
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (a *API) RegisterEndpoints(mux *chi.Mux) {
+	mux.Get("/api/v0/me", a.handleMe)
+}
+
+func (a *API) handleMe(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "me.API.handleMe")
+	defer span.End()
+
+	principal := authentication.PrincipalFromContext(ctx)
+
+	if principal == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "no authenticated principal",
+				Status:  http.StatusUnauthorized,
+				Code:    types.CodeUnauthorized,
+			},
+		)
+
+		return
+	}
+
+	me := Principal{
+		Identifier:  principal.Identifier(),
+		Type:        PrincipalTypeService,
+		Admin:       authorization.IsAdminFromContext(ctx),
+		Permissions: map[string][]string{authorization.CAN_CREATE: {}},
+	}
+
+	if user, ok := principal.(*authentication.UserPrincipal); ok {
+		me.Type = PrincipalTypeUser
+		me.Email = user.Email
+		me.Name = user.Name
+	}
+
+	ID := fmt.Sprintf("user:%s", principal.Identifier())
+	scoped := authorization.ScopedTuples(ID, principal)
+
+	// creation is checked on the global object of each type, the same the authorization middleware uses
+	for _, t := range capabilityTypes {
+		object := fmt.Sprintf("%s:%s", t, authorization.GLOBAL_ACCESS_OBJECT_NAME)
+		tuples := append([]openfga.Tuple{*openfga.NewTuple(authorization.ADMIN_OBJECT, authorization.PRIVILEGED_RELATION, object)}, scoped...)
+
+		allowed, err := a.authorizer.Check(ctx, ID, authorization.CAN_CREATE, object, tuples...)
+
+		if err != nil {
+			a.logger.Errorf("failed checking %s on %s: %s", authorization.CAN_CREATE, object, err)
+
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(
+				types.Response{
+					Message: "failed connecting with OpenFGA",
+					Status:  http.StatusInternalServerError,
+					Code:    types.CodeInternal,
+				},
+			)
+
+			return
+		}
+
+		if allowed {
+			me.Permissions[authorization.CAN_CREATE] = append(me.Permissions[authorization.CAN_CREATE], t)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    me,
+			Message: "Current principal",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func NewAPI(authorizer AuthorizerInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
+	a := new(API)
+
+	a.authorizer = authorizer
+
+	a.tracer = tracer
+	a.monitor = monitor
+	a.logger = logger
+
+	return a
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package me
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package me -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package me -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package me -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package me -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestHandleMe(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal authentication.PrincipalInterface
+		admin     bool
+		creatable []string
+		err       error
+		status    int
+		expected  *Principal
+	}{
+		{
+			name:   "no principal",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:      "user",
+			principal: &authentication.UserPrincipal{Subject: "abc", Name: "Joe", Email: "joe@example.com"},
+			admin:     true,
+			creatable: []string{authorization.GROUP_TYPE, authorization.ROLE_TYPE},
+			status:    http.StatusOK,
+			expected: &Principal{
+				Identifier:  "joe@example.com",
+				Email:       "joe@example.com",
+				Name:        "Joe",
+				Type:        PrincipalTypeUser,
+				Admin:       true,
+				Permissions: map[string][]string{authorization.CAN_CREATE: {authorization.GROUP_TYPE, authorization.ROLE_TYPE}},
+			},
+		},
+		{
+			name:      "service",
+			principal: &authentication.ServicePrincipal{Subject: "ci-pipeline"},
+			status:    http.StatusOK,
+			expected: &Principal{
+				Identifier:  "ci-pipeline",
+				Type:        PrincipalTypeService,
+				Permissions: map[string][]string{authorization.CAN_CREATE: {}},
+			},
+		},
+		{
+			name:      "openfga unreachable",
+			principal: &authentication.ServicePrincipal{Subject: "ci-pipeline"},
+			err:       fmt.Errorf("connection refused"),
+			status:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockAuthorizer := NewMockAuthorizerInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/me", nil)
+
+			if test.principal != nil {
+				ctx := authentication.PrincipalContext(req.Context(), test.principal)
+				req = req.WithContext(authorization.IsAdminContext(ctx, test.admin))
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "me.API.handleMe").Times(1).DoAndReturn(
+				func(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+					return ctx, trace.SpanFromContext(ctx)
+				},
+			)
+
+			if test.principal != nil {
+				ID := fmt.Sprintf("user:%s", test.principal.Identifier())
+
+				mockAuthorizer.EXPECT().Check(gomock.Any(), ID, authorization.CAN_CREATE, gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+					func(ctx context.Context, user, relation, object string, tuples ...openfga.Tuple) (bool, error) {
+						if test.err != nil {
+							return false, test.err
+						}
+
+						for _, t := range test.creatable {
+							if object == fmt.Sprintf("%s:%s", t, authorization.GLOBAL_ACCESS_OBJECT_NAME) {
+								return true, nil
+							}
+						}
+
+						return false, nil
+					},
+				)
+			}
+
+			if test.err != nil {
+				mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockAuthorizer, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.expected == nil {
+				return
+			}
+
+			me := new(Principal)
+			rr := types.Response{Data: me}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(me, test.expected) {
+				t.Fatalf("expected principal to be %v got %v", test.expected, me)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package me
+
+import (
+	"context"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+type AuthorizerInterface interface {
+	Check(context.Context, string, string, string, ...openfga.Tuple) (bool, error)
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package permissions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+)
+
+const SUBJECTS_TOKEN_KEY = "subjects"
+
+// API is the core HTTP object that implements all the HTTP and business logic for the permissions
+// HTTP API functionality
+type API struct {
+	service ServiceInterface
+	cursors *types.CursorConfig
+
+	logger  logging.LoggerInterface
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+}
+
+// SetCursorConfig makes the paginated endpoints swap long pagination tokens for a cursor
+func (a *API) SetCursorConfig(c *types.CursorConfig) {
+	a.cursors = c
+}
+
+// RegisterEndpoints hooks up all the endpoints to the server mux passed via the arg
+func (a *API) RegisterEndpoints(mux *chi.Mux) {
+	mux.Get("/api/v0/permissions/{object}/{relation}/subjects", a.handleListSubjects)
+	mux.Post("/api/v0/check", a.handleCheck)
+}
+
+func (a *API) handleListSubjects(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	object, errObject := url.PathUnescape(chi.URLParam(r, "object"))
+	relation, errRelation := url.PathUnescape(chi.URLParam(r, "relation"))
+
+	if errObject != nil || errRelation != nil {
+		a.badRequest(w, "Error parsing permission")
+		return
+	}
+
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	subjects, pageToken, err := a.service.ListGrantingSubjects(
+		r.Context(),
+		relation,
+		object,
+		paginator.GetToken(r.Context(), SUBJECTS_TOKEN_KEY),
+	)
+
+	if errors.Is(err, ofga.ErrInvalidObject) || errors.Is(err, ErrInvalidRelation) {
+		a.badRequest(w, err.Error())
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	paginator.SetToken(r.Context(), SUBJECTS_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    subjects,
+			Message: fmt.Sprintf("List of subjects granted %s on %s", relation, object),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	check := new(CheckRequest)
+
+	if err := json.NewDecoder(r.Body).Decode(check); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing request payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	allowed, err := a.service.CheckPermission(r.Context(), check.Subject, check.Relation, check.Object, check.Consistency)
+
+	if errors.Is(err, ErrInvalidSubject) || errors.Is(err, ErrInvalidRelation) || errors.Is(err, ofga.ErrInvalidObject) || errors.Is(err, ofga.ErrInvalidConsistency) {
+		a.badRequest(w, err.Error())
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    CheckResponse{Allowed: allowed},
+			Message: fmt.Sprintf("Check of %s on %s for %s", check.Relation, check.Object, check.Subject),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) badRequest(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: message,
+			Status:  http.StatusBadRequest,
+			Code:    types.CodeInvalidParameter,
+		},
+	)
+}
+
+func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
+	a := new(API)
+
+	a.service = service
+
+	a.logger = logger
+	a.tracer = tracer
+	a.monitor = monitor
+
+	return a
+}
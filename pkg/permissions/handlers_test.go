@@ -0,0 +1,218 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	trace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestHandleListSubjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		subjects []Subject
+		err      error
+		status   int
+		code     string
+	}{
+		{
+			name:     "subjects",
+			subjects: []Subject{{Type: "group", ID: "it-admin"}, {Type: "role", ID: "viewer"}},
+			status:   http.StatusOK,
+		},
+		{
+			name:   "invalid object",
+			err:    fmt.Errorf("%w \"clientokta\"", ofga.ErrInvalidObject),
+			status: http.StatusBadRequest,
+			code:   types.CodeInvalidParameter,
+		},
+		{
+			name:   "invalid relation",
+			err:    fmt.Errorf("%w \"can edit\"", ErrInvalidRelation),
+			status: http.StatusBadRequest,
+			code:   types.CodeInvalidParameter,
+		},
+		{
+			name:   "error",
+			err:    fmt.Errorf("error"),
+			status: http.StatusInternalServerError,
+			code:   types.CodeInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListGrantingSubjects(gomock.Any(), "can_edit", "client:okta", "").Return(test.subjects, "", test.err)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/permissions/client:okta/can_edit/subjects", nil)
+			w := httptest.NewRecorder()
+
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data   []Subject `json:"data"`
+				Status int       `json:"status"`
+				Code   string    `json:"code"`
+			}
+
+			rr := new(Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %v got %v", test.code, rr.Code)
+			}
+
+			if test.err == nil && !reflect.DeepEqual(rr.Data, test.subjects) {
+				t.Errorf("expected subjects to be %v got %v", test.subjects, rr.Data)
+			}
+		})
+	}
+}
+
+func TestHandleCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		allowed bool
+		err     error
+		called  bool
+		status  int
+		code    string
+	}{
+		{
+			name:    "allowed",
+			body:    `{"subject": "user:joe", "relation": "can_view", "object": "client:okta"}`,
+			allowed: true,
+			called:  true,
+			status:  http.StatusOK,
+		},
+		{
+			name:   "denied",
+			body:   `{"subject": "user:joe", "relation": "can_view", "object": "client:okta"}`,
+			called: true,
+			status: http.StatusOK,
+		},
+		{
+			name:   "malformed payload",
+			body:   `{"subject": `,
+			status: http.StatusBadRequest,
+			code:   types.CodeInvalidPayload,
+		},
+		{
+			name:   "invalid subject",
+			body:   `{"subject": "user:joe", "relation": "can_view", "object": "client:okta"}`,
+			err:    fmt.Errorf("%w \"userjoe\"", ErrInvalidSubject),
+			called: true,
+			status: http.StatusBadRequest,
+			code:   types.CodeInvalidParameter,
+		},
+		{
+			name:   "invalid consistency",
+			body:   `{"subject": "user:joe", "relation": "can_view", "object": "client:okta"}`,
+			err:    fmt.Errorf("%w \"STRONG\"", ofga.ErrInvalidConsistency),
+			called: true,
+			status: http.StatusBadRequest,
+			code:   types.CodeInvalidParameter,
+		},
+		{
+			name:   "error",
+			body:   `{"subject": "user:joe", "relation": "can_view", "object": "client:okta"}`,
+			err:    fmt.Errorf("error"),
+			called: true,
+			status: http.StatusInternalServerError,
+			code:   types.CodeInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			if test.called {
+				mockService.EXPECT().CheckPermission(gomock.Any(), "user:joe", "can_view", "client:okta", "").Return(test.allowed, test.err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/check", strings.NewReader(test.body))
+			w := httptest.NewRecorder()
+
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data   CheckResponse `json:"data"`
+				Status int           `json:"status"`
+				Code   string        `json:"code"`
+			}
+
+			rr := new(Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code to be %v got %v", test.code, rr.Code)
+			}
+
+			if rr.Data.Allowed != test.allowed {
+				t.Errorf("expected allowed to be %v got %v", test.allowed, rr.Data.Allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package permissions
+
+import (
+	"context"
+
+	"github.com/openfga/go-sdk/client"
+
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// ServiceInterface is the interface that each business logic service needs to implement
+type ServiceInterface interface {
+	ListGrantingSubjects(context.Context, string, string, string) ([]Subject, string, error) // subjects, continuation token, error
+	CheckPermission(context.Context, string, string, string, string) (bool, error)
+}
+
+// OpenFGAClientInterface is the interface used to decouple the OpenFGA store implementation
+type OpenFGAClientInterface interface {
+	ReadTuples(context.Context, string, string, string, string) (*client.ClientReadResponse, error)
+	Check(context.Context, string, string, string, ...ofga.Tuple) (bool, error)
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	authz "github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// ErrInvalidRelation is returned when the relation is not a valid OpenFGA relation name
+var ErrInvalidRelation = errors.New("invalid relation")
+
+// ErrInvalidSubject is returned when the subject of a check is not a user or a group/role userset
+var ErrInvalidSubject = errors.New("invalid subject")
+
+var relationRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// objectTypes are the object types permissions can be granted on, same as the groups and roles ones
+var objectTypes = []string{
+	authz.ROLE_TYPE,
+	authz.GROUP_TYPE,
+	authz.IDENTITY_TYPE,
+	authz.SCHEME_TYPE,
+	authz.PROVIDER_TYPE,
+	authz.CLIENT_TYPE,
+}
+
+// Subject is a group or role granted a permission
+type Subject struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// CheckRequest is a single permission check on behalf of an arbitrary subject
+type CheckRequest struct {
+	Subject     string `json:"subject"`
+	Relation    string `json:"relation"`
+	Object      string `json:"object"`
+	Consistency string `json:"consistency,omitempty"`
+}
+
+// CheckResponse is the outcome of a CheckRequest
+type CheckResponse struct {
+	Allowed bool `json:"allowed"`
+}
+
+// Service is the reverse of the groups and roles permission listing, it answers which
+// subjects hold a given permission
+type Service struct {
+	ofga OpenFGAClientInterface
+
+	tracer  trace.Tracer
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+// ListGrantingSubjects returns the groups and roles directly granted relation on object, tuples are
+// read one page at a time so a page can hold fewer subjects than tuples, e.g. when users are
+// assigned the permission directly, keep following the continuation token until it's empty
+func (s *Service) ListGrantingSubjects(ctx context.Context, relation, object, continuationToken string) ([]Subject, string, error) {
+	ctx, span := s.tracer.Start(ctx, "permissions.Service.ListGrantingSubjects")
+	defer span.End()
+
+	if !relationRegex.MatchString(relation) {
+		return nil, "", fmt.Errorf("%w %q", ErrInvalidRelation, relation)
+	}
+
+	if err := ofga.ValidateObject(object, objectTypes...); err != nil {
+		return nil, "", err
+	}
+
+	r, err := s.ofga.ReadTuples(ctx, "", relation, object, continuationToken)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, "", err
+	}
+
+	subjects := make([]Subject, 0)
+	seen := make(map[Subject]bool)
+
+	for _, t := range r.GetTuples() {
+		subject, ok := s.subject(t.Key.User)
+
+		if !ok || seen[subject] {
+			continue
+		}
+
+		seen[subject] = true
+		subjects = append(subjects, subject)
+	}
+
+	return subjects, r.GetContinuationToken(), nil
+}
+
+// CheckPermission tells whether subject holds relation on object, consistency is one of the OpenFGA
+// preferences, HIGHER_CONSISTENCY bypasses the check cache
+func (s *Service) CheckPermission(ctx context.Context, subject, relation, object, consistency string) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "permissions.Service.CheckPermission")
+	defer span.End()
+
+	if err := validateSubject(subject); err != nil {
+		return false, err
+	}
+
+	if !relationRegex.MatchString(relation) {
+		return false, fmt.Errorf("%w %q", ErrInvalidRelation, relation)
+	}
+
+	if err := ofga.ValidateObject(object, objectTypes...); err != nil {
+		return false, err
+	}
+
+	c, err := ofga.ParseConsistency(consistency)
+
+	if err != nil {
+		return false, err
+	}
+
+	allowed, err := s.ofga.Check(ofga.ConsistencyContext(ctx, c), subject, relation, object)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return false, err
+	}
+
+	return allowed, nil
+}
+
+// validateSubject accepts user:<id>, group:<id>#member and role:<id>#assignee
+func validateSubject(subject string) error {
+	object, relation, hasRelation := strings.Cut(subject, "#")
+	oType, ID, found := strings.Cut(object, ":")
+
+	if !found || ID == "" || strings.ContainsAny(subject, " \t\n") {
+		return fmt.Errorf("%w %q, expected <type>:<id>[#<relation>]", ErrInvalidSubject, subject)
+	}
+
+	switch {
+	case oType == authz.USER_TYPE && !hasRelation:
+		return nil
+	case oType == authz.GROUP_TYPE && relation == authz.MEMBER_RELATION:
+		return nil
+	case oType == authz.ROLE_TYPE && relation == authz.ASSIGNEE_RELATION:
+		return nil
+	default:
+		return fmt.Errorf("%w %q, expected user:<id>, group:<id>#member or role:<id>#assignee", ErrInvalidSubject, subject)
+	}
+}
+
+// subject parses group:<id>#member and role:<id>#assignee usersets, anything else is not a subject
+func (s *Service) subject(user string) (Subject, bool) {
+	object, relation, _ := strings.Cut(user, "#")
+	oType, ID, _ := strings.Cut(object, ":")
+
+	switch {
+	case oType == authz.GROUP_TYPE && relation == authz.MEMBER_RELATION:
+		return Subject{Type: oType, ID: ID}, true
+	case oType == authz.ROLE_TYPE && relation == authz.ASSIGNEE_RELATION:
+		return Subject{Type: oType, ID: ID}, true
+	default:
+		return Subject{}, false
+	}
+}
+
+// NewService returns the implementation of the business logic for the permissions API
+func NewService(ofga OpenFGAClientInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+	s := new(Service)
+
+	s.ofga = ofga
+
+	s.monitor = monitor
+	s.tracer = tracer
+	s.logger = logger
+
+	return s
+}
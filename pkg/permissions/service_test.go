@@ -0,0 +1,251 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	trace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package permissions -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestServiceListGrantingSubjects(t *testing.T) {
+	type input struct {
+		relation string
+		object   string
+		token    string
+	}
+
+	type expected struct {
+		tuples []string
+		token  string
+		err    error
+	}
+
+	tests := []struct {
+		name     string
+		input    input
+		expected expected
+		output   []Subject
+	}{
+		{
+			name:     "empty result",
+			input:    input{relation: "can_edit", object: "client:okta"},
+			expected: expected{tuples: []string{}},
+			output:   []Subject{},
+		},
+		{
+			name:     "error",
+			input:    input{relation: "can_edit", object: "client:okta"},
+			expected: expected{err: fmt.Errorf("error")},
+		},
+		{
+			name:  "groups and roles only",
+			input: input{relation: "can_edit", object: "client:okta", token: "test"},
+			expected: expected{
+				tuples: []string{
+					"group:it-admin#member",
+					"role:viewer#assignee",
+					"user:joe",
+					"group:it-admin#member",
+					"role:viewer#other",
+				},
+				token: "next",
+			},
+			output: []Subject{
+				{Type: "group", ID: "it-admin"},
+				{Type: "role", ID: "viewer"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			r := new(client.ClientReadResponse)
+
+			tuples := []openfga.Tuple{}
+			for _, user := range test.expected.tuples {
+				tuples = append(tuples, *openfga.NewTuple(*openfga.NewTupleKey(user, test.input.relation, test.input.object), time.Now()))
+			}
+
+			r.SetContinuationToken(test.expected.token)
+			r.SetTuples(tuples)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "permissions.Service.ListGrantingSubjects").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), "", test.input.relation, test.input.object, test.input.token).Return(r, test.expected.err)
+
+			if test.expected.err != nil {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+			}
+
+			subjects, token, err := NewService(mockOpenFGA, mockTracer, mockMonitor, mockLogger).ListGrantingSubjects(context.Background(), test.input.relation, test.input.object, test.input.token)
+
+			if err != test.expected.err {
+				t.Fatalf("expected error to be %v got %v", test.expected.err, err)
+			}
+
+			if test.expected.err != nil {
+				return
+			}
+
+			if token != test.expected.token {
+				t.Errorf("invalid token, expected: %v, got: %v", test.expected.token, token)
+			}
+
+			if !reflect.DeepEqual(subjects, test.output) {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output, subjects)
+			}
+		})
+	}
+}
+
+func TestServiceListGrantingSubjectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		relation string
+		object   string
+		err      error
+	}{
+		{name: "missing colon", relation: "can_edit", object: "clientokta", err: ofga.ErrInvalidObject},
+		{name: "unknown type", relation: "can_edit", object: "rule:allow", err: ofga.ErrInvalidObject},
+		{name: "empty relation", relation: "", object: "client:okta", err: ErrInvalidRelation},
+		{name: "malformed relation", relation: "can edit", object: "client:okta", err: ErrInvalidRelation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "permissions.Service.ListGrantingSubjects").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, _, err := NewService(mockOpenFGA, mockTracer, mockMonitor, mockLogger).ListGrantingSubjects(context.Background(), test.relation, test.object, "")
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error to be %v got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestServiceCheckPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		subject     string
+		consistency string
+		allowed     bool
+		err         error
+	}{
+		{name: "allowed", subject: "user:joe", allowed: true},
+		{name: "denied", subject: "group:it-admin#member", consistency: "MINIMIZE_LATENCY"},
+		{name: "higher consistency", subject: "role:viewer#assignee", consistency: "HIGHER_CONSISTENCY", allowed: true},
+		{name: "error", subject: "user:joe", err: fmt.Errorf("error")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "permissions.Service.CheckPermission").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGA.EXPECT().Check(gomock.Any(), test.subject, "can_view", "client:okta").Times(1).DoAndReturn(
+				func(ctx context.Context, user, relation, object string, tuples ...ofga.Tuple) (bool, error) {
+					if c := ofga.ConsistencyFromContext(ctx); string(c) != test.consistency {
+						t.Errorf("expected consistency to be %q got %q", test.consistency, c)
+					}
+
+					return test.allowed, test.err
+				},
+			)
+
+			if test.err != nil {
+				mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+			}
+
+			allowed, err := NewService(mockOpenFGA, mockTracer, mockMonitor, mockLogger).CheckPermission(context.Background(), test.subject, "can_view", "client:okta", test.consistency)
+
+			if err != test.err {
+				t.Errorf("expected error to be %v got %v", test.err, err)
+			}
+
+			if allowed != test.allowed {
+				t.Errorf("expected allowed to be %v got %v", test.allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestServiceCheckPermissionInvalidInput(t *testing.T) {
+	tests := []struct {
+		name        string
+		subject     string
+		relation    string
+		object      string
+		consistency string
+		err         error
+	}{
+		{name: "empty subject", subject: "", relation: "can_view", object: "client:okta", err: ErrInvalidSubject},
+		{name: "subject without id", subject: "user:", relation: "can_view", object: "client:okta", err: ErrInvalidSubject},
+		{name: "user userset", subject: "user:joe#member", relation: "can_view", object: "client:okta", err: ErrInvalidSubject},
+		{name: "group without member", subject: "group:it-admin", relation: "can_view", object: "client:okta", err: ErrInvalidSubject},
+		{name: "unknown subject type", subject: "client:okta", relation: "can_view", object: "client:okta", err: ErrInvalidSubject},
+		{name: "malformed relation", subject: "user:joe", relation: "can view", object: "client:okta", err: ErrInvalidRelation},
+		{name: "malformed object", subject: "user:joe", relation: "can_view", object: "clientokta", err: ofga.ErrInvalidObject},
+		{name: "unknown consistency", subject: "user:joe", relation: "can_view", object: "client:okta", consistency: "STRONG", err: ofga.ErrInvalidConsistency},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "permissions.Service.CheckPermission").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockOpenFGA.EXPECT().Check(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			_, err := NewService(mockOpenFGA, mockTracer, mockMonitor, mockLogger).CheckPermission(context.Background(), test.subject, test.relation, test.object, test.consistency)
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected error to be %v got %v", test.err, err)
+			}
+		})
+	}
+}
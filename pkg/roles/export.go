@@ -0,0 +1,141 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+)
+
+// RoleDefinition is the portable form of a role, Entitlements are <relation>::<type>:<id> URNs
+// such as can_view::client:okta, sorted so that exports of the same role compare equal
+type RoleDefinition struct {
+	Name         string   `json:"name" validate:"required,notblank"`
+	Entitlements []string `json:"entitlements" validate:"dive,required"`
+}
+
+// ExportRole returns the definition of a role with its permissions across all the object types,
+// fails with ErrRoleNotFound if no tuple references the role
+func (s *Service) ExportRole(ctx context.Context, ID string) (*RoleDefinition, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ExportRole")
+	defer span.End()
+
+	r, err := s.ofga.ReadTuples(ctx, "", "", authorization.RoleForTuple(ID), "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(r.GetTuples()) == 0 {
+		return nil, ErrRoleNotFound
+	}
+
+	results := make(chan *pool.Result[any], len(s.permissionTypes()))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(s.permissionTypes()))
+
+	for _, t := range s.permissionTypes() {
+		s.wpool.Submit(s.readPermissionsFunc(ctx, ID, t), results, &wg)
+	}
+
+	wg.Wait()
+	close(results)
+
+	definition := new(RoleDefinition)
+	definition.Name = ID
+	definition.Entitlements = make([]string, 0)
+
+	for r := range results {
+		v := r.Value.(readPermissionsResult)
+
+		if v.err != nil {
+			return nil, fmt.Errorf("failed to read %s permissions of role %s: %w", v.ofgaType, ID, v.err)
+		}
+
+		for _, p := range v.permissions {
+			definition.Entitlements = append(definition.Entitlements, authorization.NewURN(p.Relation, p.Object).ID())
+		}
+	}
+
+	slices.Sort(definition.Entitlements)
+
+	return definition, nil
+}
+
+// ImportRole creates the role described by definition with owner as its creator and assigns it
+// the entitlements, an existing role fails the import with ErrRoleExists unless overwrite is set
+// in which case tuples already in place are left alone, so importing the same definition again
+// is a no-op, and entitlements the role holds outside of the definition are kept
+// every entitlement is validated before anything is written, an invalid one fails the import
+// with ErrInvalidPermission, the tuples are written in chunks and a failed chunk rolls back the others
+func (s *Service) ImportRole(ctx context.Context, definition *RoleDefinition, owner string, overwrite bool) (*Role, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ImportRole")
+	defer span.End()
+
+	role := authorization.RoleForTuple(definition.Name)
+	user := authorization.UserForTuple(owner)
+
+	// keep in sync with CreateRole
+	tuples := []ofga.Tuple{
+		*ofga.NewTuple(user, ASSIGNEE_RELATION, role),
+		*ofga.NewTuple(user, CAN_VIEW_RELATION, role),
+	}
+
+	for _, e := range definition.Entitlements {
+		urn := authorization.NewURNFromURLParam(e)
+
+		if urn == nil {
+			return nil, fmt.Errorf("%w: malformed entitlement %q, expected <relation>::<type>:<id>", ErrInvalidPermission, e)
+		}
+
+		p := Permission{Relation: urn.Relation(), Object: urn.Object()}
+
+		if err := s.validatePermission(p); err != nil {
+			return nil, err
+		}
+
+		tuples = append(tuples, *ofga.NewTuple(s.getRoleAssigneeUser(definition.Name), p.Relation, p.Object))
+	}
+
+	if !overwrite {
+		exists, err := s.roleExists(ctx, definition.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			return nil, ErrRoleExists
+		}
+	}
+
+	missing, err := s.missingTuples(ctx, tuples...)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		err = ofga.WriteTuplesInChunks(ctx, s.ofga, missing...)
+
+		s.auditor.Record(ctx, audit.RoleImport, audit.RoleResource, definition.Name, audit.OutcomeFromError(err))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err
+		}
+	}
+
+	return &Role{ID: definition.Name, Name: definition.Name}, nil
+}
@@ -0,0 +1,268 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// readStoredTuples mimics OpenFGA reads over stored, an object ending with : matches every
+// object of that type
+func readStoredTuples(stored []ofga.Tuple) func(context.Context, string, string, string, string) (*client.ClientReadResponse, error) {
+	return func(ctx context.Context, user, relation, object, cToken string) (*client.ClientReadResponse, error) {
+		ts := []openfga.Tuple{}
+
+		for _, tuple := range stored {
+			if strings.HasSuffix(object, ":") && !strings.HasPrefix(tuple.Object, object) {
+				continue
+			}
+
+			if !strings.HasSuffix(object, ":") && tuple.Object != object {
+				continue
+			}
+
+			if (user != "" && tuple.User != user) || (relation != "" && tuple.Relation != relation) {
+				continue
+			}
+
+			ts = append(ts, *openfga.NewTuple(*openfga.NewTupleKey(tuple.Values()), time.Now()))
+		}
+
+		r := new(client.ClientReadResponse)
+		r.SetTuples(ts)
+		r.SetContinuationToken("")
+
+		return r, nil
+	}
+}
+
+func TestServiceExportRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      []ofga.Tuple
+		readErr     error
+		expected    *RoleDefinition
+		expectedErr error
+	}{
+		{
+			name: "entitlements across types are sorted",
+			stored: []ofga.Tuple{
+				*ofga.NewTuple("user:joe", ASSIGNEE_RELATION, "role:viewer"),
+				*ofga.NewTuple("role:viewer#assignee", "can_view", "group:c-level"),
+				*ofga.NewTuple("role:viewer#assignee", "can_edit", "client:okta"),
+				*ofga.NewTuple("role:viewer#assignee", "can_view", "client:okta"),
+			},
+			expected: &RoleDefinition{
+				Name:         "viewer",
+				Entitlements: []string{"can_edit::client:okta", "can_view::client:okta", "can_view::group:c-level"},
+			},
+		},
+		{
+			name:        "role not found",
+			expectedErr: ErrRoleNotFound,
+		},
+		{
+			name: "read fails",
+			stored: []ofga.Tuple{
+				*ofga.NewTuple("user:joe", ASSIGNEE_RELATION, "role:viewer"),
+			},
+			readErr:     fmt.Errorf("error"),
+			expectedErr: fmt.Errorf("failed to read"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			workerPool := NewMockWorkerPoolInterface(ctrl)
+			setupMockSubmit(workerPool, nil)
+
+			svc := NewService(mockOpenFGA, workerPool, audit.NewNoopAuditor(), mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.ExportRole").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.readPermissionsByType").AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+			read := readStoredTuples(test.stored)
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, cToken string) (*client.ClientReadResponse, error) {
+					if test.readErr != nil && object == "group:" {
+						return nil, test.readErr
+					}
+
+					return read(ctx, user, relation, object, cToken)
+				},
+			)
+
+			definition, err := svc.ExportRole(context.Background(), "viewer")
+
+			if test.expectedErr != nil {
+				if err == nil || (!errors.Is(err, test.expectedErr) && !strings.HasPrefix(err.Error(), test.expectedErr.Error())) {
+					t.Fatalf("expected error to be %v got %v", test.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(definition, test.expected) {
+				t.Fatalf("expected definition to be %v got %v", test.expected, definition)
+			}
+		})
+	}
+}
+
+func TestServiceImportRole(t *testing.T) {
+	assignee := *ofga.NewTuple("user:admin", ASSIGNEE_RELATION, "role:viewer")
+	viewer := *ofga.NewTuple("user:admin", CAN_VIEW_RELATION, "role:viewer")
+	client := *ofga.NewTuple("role:viewer#assignee", "can_view", "client:okta")
+	group := *ofga.NewTuple("role:viewer#assignee", "can_edit", "group:c-level")
+
+	tests := []struct {
+		name         string
+		entitlements []string
+		stored       []ofga.Tuple
+		overwrite    bool
+		writeErr     error
+		expected     []ofga.Tuple
+		expectedErr  error
+	}{
+		{
+			name:         "new role",
+			entitlements: []string{"can_view::client:okta", "can_edit::group:c-level"},
+			expected:     []ofga.Tuple{assignee, viewer, client, group},
+		},
+		{
+			name:         "re-run only writes what is missing",
+			entitlements: []string{"can_view::client:okta", "can_edit::group:c-level"},
+			stored:       []ofga.Tuple{assignee, viewer, client},
+			overwrite:    true,
+			expected:     []ofga.Tuple{group},
+		},
+		{
+			name:         "re-run of a complete import is a no-op",
+			entitlements: []string{"can_view::client:okta", "can_edit::group:c-level"},
+			stored:       []ofga.Tuple{assignee, viewer, client, group},
+			overwrite:    true,
+		},
+		{
+			name:         "existing role without overwrite",
+			entitlements: []string{"can_view::client:okta", "can_edit::group:c-level"},
+			stored:       []ofga.Tuple{assignee, viewer},
+			expectedErr:  ErrRoleExists,
+		},
+		{
+			name:         "malformed entitlement",
+			entitlements: []string{"can_view::client:okta", "client:okta"},
+			expectedErr:  ErrInvalidPermission,
+		},
+		{
+			name:         "unknown entitlement type",
+			entitlements: []string{"can_view::spaceship:enterprise"},
+			expectedErr:  ErrInvalidPermission,
+		},
+		{
+			name:         "write fails",
+			entitlements: []string{"can_view::client:okta"},
+			writeErr:     fmt.Errorf("error"),
+			expectedErr:  fmt.Errorf("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			svc := NewService(mockOpenFGA, NewMockWorkerPoolInterface(ctrl), audit.NewNoopAuditor(), mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.ImportRole").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").AnyTimes().DoAndReturn(readStoredTuples(test.stored))
+
+			if test.writeErr != nil || len(test.expected) > 0 {
+				// the few tuples of these imports fit in a single chunk
+				mockOpenFGA.EXPECT().WriteTuples(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(ctx context.Context, tuples ...ofga.Tuple) error {
+						if test.writeErr != nil {
+							return test.writeErr
+						}
+
+						expected := tupleStrings(test.expected)
+						actual := tupleStrings(tuples)
+
+						if !reflect.DeepEqual(actual, expected) {
+							t.Errorf("expected tuples to be %v got %v", expected, actual)
+						}
+
+						return nil
+					},
+				)
+			}
+
+			role, err := svc.ImportRole(context.Background(), &RoleDefinition{Name: "viewer", Entitlements: test.entitlements}, "admin", test.overwrite)
+
+			if test.expectedErr != nil {
+				if err == nil || (errors.Is(test.expectedErr, ErrInvalidPermission) && !errors.Is(err, ErrInvalidPermission)) || (errors.Is(test.expectedErr, ErrRoleExists) && !errors.Is(err, ErrRoleExists)) {
+					t.Fatalf("expected error to be %v got %v", test.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if role.ID != "viewer" {
+				t.Errorf("expected role to be viewer got %v", role.ID)
+			}
+		})
+	}
+}
+
+func tupleStrings(tuples []ofga.Tuple) []string {
+	s := make([]string, 0, len(tuples))
+
+	for _, t := range tuples {
+		s = append(s, fmt.Sprintf("%s %s %s", t.User, t.Relation, t.Object))
+	}
+
+	sort.Strings(s)
+
+	return s
+}
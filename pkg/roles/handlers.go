@@ -4,15 +4,20 @@
 package roles
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 	"github.com/canonical/identity-platform-admin-ui/internal/validation"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
@@ -21,14 +26,31 @@ import (
 )
 
 const (
-	ROLE_TOKEN_KEY = "roles"
+	ROLE_TOKEN_KEY     = "roles"
+	IDENTITY_TOKEN_KEY = "identities"
+
+	// streamFlushLines is the number of lines after which a streamed response is flushed
+	streamFlushLines = 100
 )
 
+// EntitlementLine is a single line of a streamed entitlements list
+type EntitlementLine struct {
+	Entitlement string `json:"entitlement"`
+}
+
 type Permission struct {
 	Relation string `json:"relation" validate:"required"`
 	Object   string `json:"object" validate:"required"`
 }
 
+// PermissionPatch adds or removes a single permission, a PATCH on the role entitlements with a list
+// of them applies additions and removals in one request
+type PermissionPatch struct {
+	Op       string `json:"op" validate:"required,oneof=add remove"`
+	Relation string `json:"relation" validate:"required"`
+	Object   string `json:"object" validate:"required"`
+}
+
 type UpdatePermissionsRequest struct {
 	// validate slice is not nil, and each item is not nil
 	Permissions []Permission `json:"permissions" validate:"required,dive,required"`
@@ -39,29 +61,84 @@ type Role struct {
 	Name string `json:"name,omitempty" validate:"required,notblank"`
 }
 
+// RoleSubject is an OpenFGA subject assigned a role, e.g. group:c-level#member is
+// {group, c-level, member}, Relation is empty for plain users
+type RoleSubject struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// AccessibleObject is an object a role holds permissions on, Relations lists every relation granted
+type AccessibleObject struct {
+	Object    string   `json:"object"`
+	Relations []string `json:"relations"`
+}
+
+// ParseRoleSubject splits a <type>:<id>[#<relation>] subject, a subject without a type is
+// returned as the ID
+func ParseRoleSubject(subject string) RoleSubject {
+	s := RoleSubject{}
+
+	oType, rest, found := strings.Cut(subject, ":")
+
+	if !found {
+		s.ID = subject
+		return s
+	}
+
+	s.Type = oType
+	s.ID, s.Relation, _ = strings.Cut(rest, "#")
+
+	return s
+}
+
+// DeletePreview lists the tuples a delete would remove, returned when dry_run is set
+type DeletePreview struct {
+	Tuples []ofga.Tuple `json:"tuples"`
+	Count  int          `json:"count"`
+}
+
 // API is the core HTTP object that implements all the HTTP and business logic for the roles
 // HTTP API functionality
 type API struct {
 	apiKey           string
 	service          ServiceInterface
 	payloadValidator validation.PayloadValidatorInterface
+	pageSize         *types.PageSizeConfig
+	cursors          *types.CursorConfig
 
 	logger  logging.LoggerInterface
 	tracer  tracing.TracingInterface
 	monitor monitoring.MonitorInterface
 }
 
+// SetPageSizeConfig sets the default and maximum page size of the list endpoint
+func (a *API) SetPageSizeConfig(c *types.PageSizeConfig) {
+	a.pageSize = c
+}
+
+// SetCursorConfig makes the paginated endpoints swap long pagination tokens for a cursor
+func (a *API) SetCursorConfig(c *types.CursorConfig) {
+	a.cursors = c
+}
+
 // RegisterEndpoints hooks up all the endpoints to the server mux passed via the arg
 func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/roles", a.handleList)
 	mux.Get("/api/v0/roles/{id:.+}", a.handleDetail)
 	mux.Post("/api/v0/roles", a.handleCreate)
+	mux.Post("/api/v0/roles/{id:.+}/clone", a.handleClone)
+	mux.Post("/api/v0/roles/import", a.handleImport)
+	mux.Get("/api/v0/roles/{id:.+}/export", a.handleExport)
 	mux.Patch("/api/v0/roles/{id:.+}", a.handleUpdate)
 	mux.Delete("/api/v0/roles/{id:.+}", a.handleRemove)
 	mux.Get("/api/v0/roles/{id:.+}/entitlements", a.handleListPermission)
 	mux.Patch("/api/v0/roles/{id:.+}/entitlements", a.handleAssignPermission) // this can only work for assignment unless payload includes add and remove
 	mux.Delete("/api/v0/roles/{id:.+}/entitlements/{e_id:.+}", a.handleRemovePermission)
 	mux.Get("/api/v0/roles/{id:.+}/groups", a.handleListRoleGroup)
+	mux.Get("/api/v0/roles/{id:.+}/identities", a.handleListRoleIdentities)
+	mux.Get("/api/v0/roles/{id:.+}/access/{type}", a.handleListAccessibleObjects)
 }
 
 func (a *API) RegisterValidation(v validation.ValidationRegistryInterface) {
@@ -76,14 +153,44 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 
 	principal := authentication.PrincipalFromContext(r.Context())
 
-	roles, err := a.service.ListRoles(
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	size, err := a.pageSize.ParseSize(r.URL.Query())
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
+			},
+		)
+
+		return
+	}
+
+	if assignable, _ := strconv.ParseBool(r.URL.Query().Get("assignable")); assignable {
+		a.handleListAssignable(w, r, principal.Identifier())
+		return
+	}
+
+	roles, pageToken, err := a.service.ListRoles(
 		r.Context(),
 		principal.Identifier(),
+		size,
+		paginator.GetToken(r.Context(), ROLE_TOKEN_KEY),
 	)
 
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -93,6 +200,16 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	paginator.SetToken(r.Context(), ROLE_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
 	w.WriteHeader(http.StatusOK)
 
 	json.NewEncoder(w).Encode(
@@ -104,6 +221,33 @@ func (a *API) handleList(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+// handleListAssignable returns all the roles the principal can assign, the list is not paginated
+func (a *API) handleListAssignable(w http.ResponseWriter, r *http.Request, principal string) {
+	roles, err := a.service.ListAssignableRoles(r.Context(), principal)
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    roles,
+			Message: "List of assignable roles",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
 func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -115,6 +259,7 @@ func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -130,6 +275,7 @@ func (a *API) handleDetail(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Role not found",
 				Status:  http.StatusNotFound,
+				Code:    types.CodeRoleNotFound,
 			},
 		)
 		return
@@ -157,6 +303,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -170,6 +317,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -183,6 +331,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Role ID field is not allowed to be passed in",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidParameter,
 			},
 		)
 
@@ -196,6 +345,7 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -215,6 +365,209 @@ func (a *API) handleCreate(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (a *API) handleClone(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ID := chi.URLParam(r, "id")
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing request payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	role := new(Role)
+	if err := json.Unmarshal(body, role); err != nil || role.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	principal := authentication.PrincipalFromContext(r.Context())
+	role, err = a.service.CloneRole(r.Context(), ID, role.Name, principal.Identifier())
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		code := types.CodeInternal
+
+		switch {
+		case errors.Is(err, ErrRoleNotFound):
+			status = http.StatusNotFound
+			code = types.CodeRoleNotFound
+		case errors.Is(err, ErrRoleExists):
+			status = http.StatusConflict
+			code = types.CodeRoleNameConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  status,
+				Code:    code,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    []Role{*role},
+			Message: fmt.Sprintf("Cloned role %s into %s", ID, role.Name),
+			Status:  http.StatusCreated,
+		},
+	)
+}
+
+func (a *API) handleExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ID := chi.URLParam(r, "id")
+
+	definition, err := a.service.ExportRole(r.Context(), ID)
+
+	if errors.Is(err, ErrRoleNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Role not found",
+				Status:  http.StatusNotFound,
+				Code:    types.CodeRoleNotFound,
+			},
+		)
+
+		return
+	}
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    definition,
+			Message: fmt.Sprintf("Exported role %s", ID),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing request payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	definition := new(RoleDefinition)
+	if err := json.Unmarshal(body, definition); err != nil || definition.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	overwrite, _ := strconv.ParseBool(r.URL.Query().Get("overwrite"))
+
+	principal := authentication.PrincipalFromContext(r.Context())
+	role, err := a.service.ImportRole(r.Context(), definition, principal.Identifier(), overwrite)
+
+	if errors.Is(err, ErrRoleExists) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: fmt.Sprintf("Role %s already exists, pass overwrite=true to import over it", definition.Name),
+				Status:  http.StatusConflict,
+				Code:    types.CodeRoleNameConflict,
+			},
+		)
+
+		return
+	}
+
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeRoleInvalidPermission,
+			},
+		)
+
+		return
+	}
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    []Role{*role},
+			Message: fmt.Sprintf("Imported role %s", role.Name),
+			Status:  http.StatusCreated,
+		},
+	)
+}
+
 // handleUpdate is not implemented by choice, product might decide to do it to enhcance
 // role metadata, we do not support anything on top of simple ID attribute and this is
 // not changeable right now due to coupled implementation with OpenFGA
@@ -228,6 +581,7 @@ func (a *API) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		types.Response{
 			Message: fmt.Sprintf("use /api/v0/roles/%s/entitlements to assign permissions", ID),
 			Status:  http.StatusNotImplemented,
+			Code:    types.CodeNotImplemented,
 		},
 	)
 }
@@ -238,12 +592,18 @@ func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
 
 	ID := chi.URLParam(r, "id")
 
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run")); dryRun {
+		a.handlePreviewRemove(w, r, ID)
+		return
+	}
+
 	err := a.service.DeleteRole(r.Context(), ID)
 
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -262,26 +622,104 @@ func (a *API) handleRemove(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (a *API) handlePreviewRemove(w http.ResponseWriter, r *http.Request, ID string) {
+	tuples, err := a.service.PreviewDeleteRole(r.Context(), ID)
+
+	if err != nil {
+
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    DeletePreview{Tuples: tuples, Count: len(tuples)},
+			Message: fmt.Sprintf("Dry run, deleting role %s would remove %d tuples", ID, len(tuples)),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
 func (a *API) handleListPermission(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	ID := chi.URLParam(r, "id")
 
 	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
 
 	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
 		a.logger.Error(err)
 	}
 
+	// all=true drains every per type page in a single call
+	autoPaginate, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+
+	// types=client,group only reads the listed object types
+	pTypes := make([]string, 0)
+
+	if filter := r.URL.Query().Get("types"); filter != "" {
+		pTypes = strings.Split(filter, ",")
+	}
+
+	// relation=can_edit only reads the permissions granted through that relation
+	relation := r.URL.Query().Get("relation")
+
+	// stream=true sends every permission as they are read, pagination parameters are ignored
+	if stream, _ := strconv.ParseBool(r.URL.Query().Get("stream")); stream {
+		a.handleStreamPermissions(w, r, ID, pTypes, relation)
+
+		return
+	}
+
 	permissions, pageTokens, err := a.service.ListPermissions(
 		r.Context(),
 		ID,
 		paginator.GetAllTokens(r.Context()),
+		autoPaginate,
+		pTypes,
+		relation,
 	)
 
+	if errors.Is(err, ErrInvalidPermissionRelation) {
+		rr := types.Response{
+			Status:  http.StatusBadRequest,
+			Code:    types.CodePermissionInvalidRelation,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	if errors.Is(err, ErrInvalidPermissionType) {
+		rr := types.Response{
+			Status:  http.StatusBadRequest,
+			Code:    types.CodePermissionInvalidType,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -312,26 +750,128 @@ func (a *API) handleListPermission(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
-func (a *API) handleListRoleGroup(w http.ResponseWriter, r *http.Request) {
+// handleStreamPermissions writes the role permissions as NDJSON, one {"entitlement": <urn>} object
+// per line, the response is flushed every streamFlushLines lines and after each object type
+// once lines have been sent the status can't be changed anymore, a failure is then reported by a
+// trailing error object and the stream ends
+func (a *API) handleStreamPermissions(w http.ResponseWriter, r *http.Request, ID string, pTypes []string, relation string) {
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	started := false
+	lines := 0
+
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	err := a.service.StreamPermissions(
+		r.Context(),
+		ID,
+		pTypes,
+		relation,
+		func(permissions []string) error {
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.WriteHeader(http.StatusOK)
+				started = true
+			}
+
+			for _, p := range permissions {
+				// Encode terminates every value with a newline
+				if err := enc.Encode(EntitlementLine{Entitlement: p}); err != nil {
+					return err
+				}
+
+				if lines++; lines%streamFlushLines == 0 {
+					flush()
+				}
+			}
+
+			flush()
+
+			return nil
+		},
+	)
+
+	if err == nil && !started {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	if err == nil {
+		return
+	}
+
+	rr := types.Response{
+		Status:  http.StatusInternalServerError,
+		Code:    types.CodeInternal,
+		Message: err.Error(),
+	}
+
+	switch {
+	case errors.Is(err, ErrInvalidPermissionRelation):
+		rr.Status = http.StatusBadRequest
+		rr.Code = types.CodePermissionInvalidRelation
+	case errors.Is(err, ErrInvalidPermissionType):
+		rr.Status = http.StatusBadRequest
+		rr.Code = types.CodePermissionInvalidType
+	}
+
+	if started {
+		a.logger.Errorf("entitlements stream of role %s interrupted: %s", ID, err)
+		enc.Encode(rr)
+		flush()
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rr.Status)
+	enc.Encode(rr)
+}
+
+func (a *API) handleListAccessibleObjects(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	ID := chi.URLParam(r, "id")
+	objectType := chi.URLParam(r, "type")
 
 	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
 
 	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
 		a.logger.Error(err)
 	}
 
-	roles, pageToken, err := a.service.ListRoleGroups(
+	objects, pageToken, err := a.service.ListAccessibleObjects(
 		r.Context(),
 		ID,
+		objectType,
 		paginator.GetToken(r.Context(), ROLE_TOKEN_KEY),
 	)
 
+	if errors.Is(err, ErrInvalidPermissionType) {
+		rr := types.Response{
+			Status:  http.StatusBadRequest,
+			Code:    types.CodePermissionInvalidType,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
 	if err != nil {
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -355,13 +895,130 @@ func (a *API) handleListRoleGroup(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(
 		types.Response{
-			Data:    roles,
+			Data:    objects,
+			Message: "List of accessible objects",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func (a *API) handleListRoleGroup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ID := chi.URLParam(r, "id")
+
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	var groups interface{}
+	var pageToken string
+	var err error
+
+	// typed groups are split in type, id and relation, pagination is the same
+	if typed, _ := strconv.ParseBool(r.URL.Query().Get("typed")); typed {
+		groups, pageToken, err = a.service.ListRoleGroupsTyped(
+			r.Context(),
+			ID,
+			paginator.GetToken(r.Context(), ROLE_TOKEN_KEY),
+		)
+	} else {
+		groups, pageToken, err = a.service.ListRoleGroups(
+			r.Context(),
+			ID,
+			paginator.GetToken(r.Context(), ROLE_TOKEN_KEY),
+		)
+	}
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	paginator.SetToken(r.Context(), ROLE_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    groups,
 			Message: "List of groups",
 			Status:  http.StatusOK,
 		},
 	)
 }
 
+func (a *API) handleListRoleIdentities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ID := chi.URLParam(r, "id")
+
+	paginator := types.NewTokenPaginator(a.tracer, a.logger)
+	paginator.SetCursorConfig(a.cursors)
+
+	if err := paginator.LoadFromRequest(r.Context(), r); err != nil {
+		a.logger.Error(err)
+	}
+
+	identities, pageToken, err := a.service.ListRoleIdentities(
+		r.Context(),
+		ID,
+		paginator.GetToken(r.Context(), IDENTITY_TOKEN_KEY),
+	)
+
+	if err != nil {
+		rr := types.Response{
+			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
+			Message: err.Error(),
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(rr)
+
+		return
+	}
+
+	paginator.SetToken(r.Context(), IDENTITY_TOKEN_KEY, pageToken)
+
+	pageHeader, err := paginator.PaginationHeader(r.Context())
+
+	if err != nil {
+		a.logger.Errorf("error producing pagination header: %s", err)
+		pageHeader = ""
+	}
+
+	w.Header().Add(types.PAGINATION_HEADER, pageHeader)
+	w.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    identities,
+			Message: "List of identities",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
 func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -376,13 +1033,19 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing request payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
 		return
 	}
 
-	// we might want to switch to an UpdatePermissionsRequest with additions and removals
+	// a list of operations patches the permissions, an object assigns them
+	if isPermissionPatch(body) {
+		a.handlePatchPermissions(w, r, ID, body)
+		return
+	}
+
 	permissions := new(UpdatePermissionsRequest)
 	if err := json.Unmarshal(body, permissions); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -390,6 +1053,7 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -399,10 +1063,24 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 
 	err = a.service.AssignPermissions(r.Context(), ID, permissions.Permissions...)
 
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeRoleInvalidPermission,
+			},
+		)
+
+		return
+	}
+
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -421,6 +1099,91 @@ func (a *API) handleAssignPermission(w http.ResponseWriter, r *http.Request) {
 	)
 }
 
+func (a *API) handlePatchPermissions(w http.ResponseWriter, r *http.Request, ID string, body []byte) {
+	patches := make([]PermissionPatch, 0)
+
+	if err := json.Unmarshal(body, &patches); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
+			},
+		)
+
+		return
+	}
+
+	additions := make([]Permission, 0)
+	removals := make([]Permission, 0)
+
+	for _, patch := range patches {
+		permission := Permission{Relation: patch.Relation, Object: patch.Object}
+
+		switch patch.Op {
+		case "add":
+			additions = append(additions, permission)
+		case "remove":
+			removals = append(removals, permission)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(
+				types.Response{
+					Message: fmt.Sprintf("Unsupported operation %s", patch.Op),
+					Status:  http.StatusBadRequest,
+					Code:    types.CodeInvalidParameter,
+				},
+			)
+
+			return
+		}
+	}
+
+	err := a.service.PatchPermissions(r.Context(), ID, additions, removals)
+
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeRoleInvalidPermission,
+			},
+		)
+
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Message: fmt.Sprintf("Updated permissions for role %s", ID),
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+// isPermissionPatch tells a list of PermissionPatch apart from an UpdatePermissionsRequest
+func isPermissionPatch(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
 func (a *API) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -433,6 +1196,7 @@ func (a *API) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
 			types.Response{
 				Message: "Error parsing entitlement ID",
 				Status:  http.StatusBadRequest,
+				Code:    types.CodeInvalidPayload,
 			},
 		)
 
@@ -445,10 +1209,24 @@ func (a *API) handleRemovePermission(w http.ResponseWriter, r *http.Request) {
 		Permission{Relation: permissionURN.Relation(), Object: permissionURN.Object()},
 	)
 
+	if errors.Is(err, ErrInvalidPermission) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusBadRequest,
+				Code:    types.CodeRoleInvalidPermission,
+			},
+		)
+
+		return
+	}
+
 	if err != nil {
 
 		rr := types.Response{
 			Status:  http.StatusInternalServerError,
+			Code:    types.CodeInternal,
 			Message: err.Error(),
 		}
 
@@ -23,6 +23,7 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 )
 
@@ -112,7 +113,8 @@ func TestHandleList(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/api/v0/roles", nil)
 			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
 
-			mockService.EXPECT().ListRoles(gomock.Any(), gomock.Any()).Return(test.expected.roles, test.expected.err)
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListRoles(gomock.Any(), gomock.Any(), types.DefaultPageSize, "").Return(test.expected.roles, "", test.expected.err)
 
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
@@ -177,6 +179,109 @@ func TestHandleList(t *testing.T) {
 //     "status": 200
 // }
 
+func TestHandleListAssignable(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		err    error
+		status int
+	}{
+		{name: "assignable roles", roles: []string{"viewer", "editor"}, status: http.StatusOK},
+		{name: "error", err: fmt.Errorf("error"), status: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/roles?assignable=true", nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListAssignableRoles(gomock.Any(), "test-user").Times(1).Return(test.roles, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data []string `json:"data"`
+			}
+
+			rr := new(Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if test.err == nil && !reflect.DeepEqual(rr.Data, test.roles) {
+				t.Errorf("expected roles to be %v got %v", test.roles, rr.Data)
+			}
+		})
+	}
+}
+
+func TestHandleListPaginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	paginator := types.NewTokenPaginator(mockTracer, mockLogger)
+	paginator.SetToken(context.TODO(), ROLE_TOKEN_KEY, "administrator")
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+	pageHeader, _ := paginator.PaginationHeader(context.TODO())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/roles?size=2", nil)
+	req.Header.Set(types.PAGINATION_HEADER, pageHeader)
+	req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+	mockService.EXPECT().ListRoles(gomock.Any(), "test-user", int64(2), "administrator").Return([]string{"devops", "global"}, "global", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	next := types.NewTokenPaginator(mockTracer, mockLogger)
+
+	if err := next.LoadFromString(context.TODO(), res.Header.Get(types.PAGINATION_HEADER)); err != nil {
+		t.Fatalf("expected continuation token in headers, got %v", err)
+	}
+
+	if token := next.GetToken(context.TODO(), ROLE_TOKEN_KEY); token != "global" {
+		t.Errorf("expected continuation token to be global got %s", token)
+	}
+}
+
 func TestHandleDetail(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -441,7 +546,7 @@ func TestHandleListPermissionsSuccess(t *testing.T) {
 			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.LoadFromRequest").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 			mockTracer.EXPECT().Start(gomock.Any(), "types.TokenPaginator.PaginationHeader").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
 
-			mockService.EXPECT().ListPermissions(gomock.Any(), roleID, map[string]string{}).Return(test.expected.permissions, test.expected.cTokens, nil)
+			mockService.EXPECT().ListPermissions(gomock.Any(), roleID, map[string]string{}, false, []string{}, "").Return(test.expected.permissions, test.expected.cTokens, nil)
 
 			w := httptest.NewRecorder()
 			mux := chi.NewMux()
@@ -522,6 +627,194 @@ func TestHandleListPermissionsSuccess(t *testing.T) {
 //     "status": 200
 // }
 
+func TestHandleListPermissionsFilterTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		types    []string
+		relation string
+		err      error
+		status   int
+	}{
+		{
+			name:   "filtered",
+			query:  "types=client,provider",
+			types:  []string{"client", "provider"},
+			status: http.StatusOK,
+		},
+		{
+			name:   "unknown type",
+			query:  "types=unknown",
+			types:  []string{"unknown"},
+			err:    fmt.Errorf("%w unknown", ErrInvalidPermissionType),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "filtered relation",
+			query:    "relation=can_edit",
+			types:    []string{},
+			relation: "can_edit",
+			status:   http.StatusOK,
+		},
+		{
+			name:     "unknown relation",
+			query:    "relation=owner",
+			types:    []string{},
+			relation: "owner",
+			err:      fmt.Errorf("%w owner", ErrInvalidPermissionRelation),
+			status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			roleID := "administrator"
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/roles/%s/entitlements?%s", roleID, test.query), nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListPermissions(gomock.Any(), roleID, map[string]string{}, false, test.types, test.relation).Return([]string{}, map[string]string{}, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			if w.Result().StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleStreamPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		types       []string
+		relation    string
+		batches     [][]string
+		err         error
+		status      int
+		contentType string
+		expected    string
+	}{
+		{
+			name:        "permissions are streamed as lines",
+			query:       "stream=true",
+			types:       []string{},
+			batches:     [][]string{{"can_view::client:okta", "can_edit::client:okta"}, {"can_view::group:c-level"}},
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson",
+			expected:    "{\"entitlement\":\"can_view::client:okta\"}\n{\"entitlement\":\"can_edit::client:okta\"}\n{\"entitlement\":\"can_view::group:c-level\"}\n",
+		},
+		{
+			name:        "filters are passed through",
+			query:       "stream=true&types=client&relation=can_view",
+			types:       []string{"client"},
+			relation:    "can_view",
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson",
+		},
+		{
+			name:        "error mid stream is a trailing line",
+			query:       "stream=true",
+			types:       []string{},
+			batches:     [][]string{{"can_view::client:okta"}},
+			err:         fmt.Errorf("error"),
+			status:      http.StatusOK,
+			contentType: "application/x-ndjson",
+			expected:    "{\"entitlement\":\"can_view::client:okta\"}\n{\"data\":null,\"message\":\"error\",\"status\":500,\"code\":\"internal.error\",\"_meta\":null}\n",
+		},
+		{
+			name:        "unknown type",
+			query:       "stream=true&types=unknown",
+			types:       []string{"unknown"},
+			err:         fmt.Errorf("%w unknown", ErrInvalidPermissionType),
+			status:      http.StatusBadRequest,
+			contentType: "application/json",
+		},
+		{
+			name:        "error before any line",
+			query:       "stream=true",
+			types:       []string{},
+			err:         fmt.Errorf("error"),
+			status:      http.StatusInternalServerError,
+			contentType: "application/json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			roleID := "administrator"
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v0/roles/%s/entitlements?%s", roleID, test.query), nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+			mockService.EXPECT().StreamPermissions(gomock.Any(), roleID, test.types, test.relation, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, ID string, pTypes []string, relation string, write func([]string) error) error {
+					for _, batch := range test.batches {
+						if err := write(batch); err != nil {
+							return err
+						}
+					}
+
+					return test.err
+				},
+			)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if ct := res.Header.Get("Content-Type"); ct != test.contentType {
+				t.Errorf("expected content type %v got %v", test.contentType, ct)
+			}
+
+			if !w.Flushed && test.status == http.StatusOK && len(test.batches) > 0 {
+				t.Errorf("expected response to be flushed")
+			}
+
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if test.expected != "" && string(data) != test.expected {
+				t.Errorf("expected body to be %q got %q", test.expected, string(data))
+			}
+		})
+	}
+}
+
 func TestHandleListRoleGroupsSuccess(t *testing.T) {
 	type expected struct {
 		groups  []string
@@ -645,6 +938,66 @@ func TestHandleListRoleGroupsSuccess(t *testing.T) {
 	}
 }
 
+func TestHandleListRoleGroupsTyped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+	mockService := NewMockServiceInterface(ctrl)
+
+	groups := []RoleSubject{
+		{Type: "group", ID: "c-level", Relation: "member"},
+		{Type: "group", ID: "it-admin", Relation: "member"},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/roles/administrator/groups?typed=true", nil)
+	req.Header.Set(types.PAGINATION_HEADER, base64.StdEncoding.EncodeToString([]byte(`{"roles":"page-2"}`)))
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+	mockService.EXPECT().ListRoleGroups(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockService.EXPECT().ListRoleGroupsTyped(gomock.Any(), "administrator", "page-2").Return(groups, "page-3", nil)
+
+	w := httptest.NewRecorder()
+	mux := chi.NewMux()
+	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected HTTP status code 200 got %v", res.StatusCode)
+	}
+
+	tokenMap, err := base64.StdEncoding.DecodeString(res.Header.Get(types.PAGINATION_HEADER))
+
+	if err != nil {
+		t.Fatalf("expected continuation token in headers")
+	}
+
+	tokens := map[string]string{}
+	_ = json.Unmarshal(tokenMap, &tokens)
+
+	if !reflect.DeepEqual(tokens, map[string]string{"roles": "page-3"}) {
+		t.Errorf("expected the next token to be page-3 got %v", tokens)
+	}
+
+	rr := struct {
+		Data []RoleSubject `json:"data"`
+	}{}
+
+	if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	if !reflect.DeepEqual(rr.Data, groups) {
+		t.Errorf("expected groups to be %v got %v", groups, rr.Data)
+	}
+}
+
 // + http DELETE :8000/api/v0/roles/administrator/entitlements/can_edit::client:okta X-Authorization:c2hpcHBlcml6ZXI=
 // HTTP/1.1 200 OK
 // Content-Length: 116
@@ -927,6 +1280,23 @@ func TestHandleAssignPermissions(t *testing.T) {
 				Status:  http.StatusInternalServerError,
 			},
 		},
+		{
+			name:     "malformed object",
+			expected: fmt.Errorf("%w: %w", ErrInvalidPermission, ofga.ErrInvalidObject),
+			input: input{
+				roleID: "administrator",
+				permissions: []Permission{
+					{
+						Relation: "can_view",
+						Object:   "clientokta",
+					},
+				},
+			},
+			output: &types.Response{
+				Message: "invalid permission: invalid object",
+				Status:  http.StatusBadRequest,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -996,6 +1366,105 @@ func TestHandleAssignPermissions(t *testing.T) {
 	}
 }
 
+func TestHandlePatchPermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		additions []Permission
+		removals  []Permission
+		err       error
+		status    int
+		code      string
+	}{
+		{
+			name:      "additions and removals",
+			payload:   `[{"op": "add", "relation": "can_view", "object": "client:okta"}, {"op": "remove", "relation": "can_edit", "object": "group:admin"}]`,
+			additions: []Permission{{Relation: "can_view", Object: "client:okta"}},
+			removals:  []Permission{{Relation: "can_edit", Object: "group:admin"}},
+			status:    http.StatusOK,
+		},
+		{
+			name:    "unsupported operation",
+			payload: `[{"op": "add", "relation": "can_view", "object": "client:okta"}, {"op": "replace", "relation": "can_edit", "object": "group:admin"}]`,
+			status:  http.StatusBadRequest,
+			code:    types.CodeInvalidParameter,
+		},
+		{
+			name:      "malformed object",
+			payload:   `[{"op": "add", "relation": "can_view", "object": "okta"}]`,
+			additions: []Permission{{Relation: "can_view", Object: "okta"}},
+			removals:  []Permission{},
+			err:       fmt.Errorf("%w: can_view on okta", ErrInvalidPermission),
+			status:    http.StatusBadRequest,
+			code:      types.CodeRoleInvalidPermission,
+		},
+		{
+			name:      "service error",
+			payload:   `[{"op": "remove", "relation": "can_edit", "object": "group:admin"}]`,
+			additions: []Permission{},
+			removals:  []Permission{{Relation: "can_edit", Object: "group:admin"}},
+			err:       fmt.Errorf("error"),
+			status:    http.StatusInternalServerError,
+			code:      types.CodeInternal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/v0/roles/administrator/entitlements", strings.NewReader(test.payload))
+
+			if test.additions != nil || test.removals != nil {
+				mockService.EXPECT().PatchPermissions(gomock.Any(), "administrator", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, ID string, additions, removals []Permission) error {
+						if len(additions) != len(test.additions) || (len(additions) > 0 && !reflect.DeepEqual(additions, test.additions)) {
+							t.Errorf("expected additions to be %v got %v", test.additions, additions)
+						}
+
+						if len(removals) != len(test.removals) || (len(removals) > 0 && !reflect.DeepEqual(removals, test.removals)) {
+							t.Errorf("expected removals to be %v got %v", test.removals, removals)
+						}
+
+						return test.err
+					},
+				)
+			}
+
+			mockService.EXPECT().AssignPermissions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			rr := new(types.Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.status || rr.Status != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if rr.Code != test.code {
+				t.Errorf("expected code %v got %v", test.code, rr.Code)
+			}
+		})
+	}
+}
+
 func TestHandleAssignPermissionsBadPermissionFormat(t *testing.T) {
 
 	tests := []struct {
@@ -1276,8 +1745,7 @@ func TestHandleCreate(t *testing.T) {
 	}
 }
 
-func TestHandleCreateBadRoleFormat(t *testing.T) {
-
+func TestHandleClone(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
@@ -1285,11 +1753,352 @@ func TestHandleCreateBadRoleFormat(t *testing.T) {
 		output   *types.Response
 	}{
 		{
-			name:     "no permissions",
+			name:     "success",
 			expected: nil,
-			input:    "administrator",
+			input:    "editor",
 			output: &types.Response{
-				Message: "Error parsing JSON payload",
+				Message: "Cloned role viewer into editor",
+				Status:  http.StatusCreated,
+			},
+		},
+		{
+			name:     "fail",
+			expected: fmt.Errorf("error"),
+			input:    "editor",
+			output: &types.Response{
+				Message: "error",
+				Status:  http.StatusInternalServerError,
+			},
+		},
+		{
+			name:     "source not found",
+			expected: ErrRoleNotFound,
+			input:    "editor",
+			output: &types.Response{
+				Message: ErrRoleNotFound.Error(),
+				Status:  http.StatusNotFound,
+				Code:    types.CodeRoleNotFound,
+			},
+		},
+		{
+			name:     "target exists",
+			expected: ErrRoleExists,
+			input:    "editor",
+			output: &types.Response{
+				Message: ErrRoleExists.Error(),
+				Status:  http.StatusConflict,
+				Code:    types.CodeRoleNameConflict,
+			},
+		},
+		{
+			name:  "missing name",
+			input: "",
+			output: &types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			upr := new(Role)
+			upr.Name = test.input
+			payload, _ := json.Marshal(upr)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v0/roles/viewer/clone", bytes.NewReader(payload))
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			if test.input != "" {
+				var role *Role = nil
+				if test.expected == nil {
+					role = &Role{ID: test.input, Name: test.input}
+				}
+				mockService.EXPECT().CloneRole(gomock.Any(), "viewer", test.input, "test-user").Return(role, test.expected)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.output.Status {
+				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
+			}
+
+			// duplicate types.Response attribute we care and assign the proper type instead of interface{}
+			type Response struct {
+				Data    []Role `json:"data"`
+				Message string `json:"message"`
+				Status  int    `json:"status"`
+				Code    string `json:"code"`
+			}
+
+			rr := new(Response)
+
+			if err := json.Unmarshal(data, rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if test.output.Code != "" && rr.Code != test.output.Code {
+				t.Errorf("invalid result, expected code: %v, got: %v", test.output.Code, rr.Code)
+			}
+
+			if test.expected == nil && test.input != "" && (len(rr.Data) != 1 || rr.Data[0].ID != test.input) {
+				t.Errorf("invalid result, expected: %v, got: %v", test.input, rr.Data)
+			}
+
+			if rr.Message != test.output.Message {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Message, rr.Message)
+			}
+
+			if rr.Status != test.output.Status {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Status, rr.Status)
+			}
+		})
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition *RoleDefinition
+		err        error
+		output     *types.Response
+	}{
+		{
+			name:       "success",
+			definition: &RoleDefinition{Name: "viewer", Entitlements: []string{"can_view::client:okta"}},
+			output: &types.Response{
+				Message: "Exported role viewer",
+				Status:  http.StatusOK,
+			},
+		},
+		{
+			name: "not found",
+			err:  ErrRoleNotFound,
+			output: &types.Response{
+				Message: "Role not found",
+				Status:  http.StatusNotFound,
+			},
+		},
+		{
+			name: "fail",
+			err:  fmt.Errorf("error"),
+			output: &types.Response{
+				Message: "error",
+				Status:  http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/roles/viewer/export", nil)
+
+			mockService.EXPECT().ExportRole(gomock.Any(), "viewer").Return(test.definition, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.output.Status {
+				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data    *RoleDefinition `json:"data"`
+				Message string          `json:"message"`
+				Status  int             `json:"status"`
+			}
+
+			rr := new(Response)
+
+			if err := json.Unmarshal(data, rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if test.definition != nil && !reflect.DeepEqual(rr.Data, test.definition) {
+				t.Errorf("invalid result, expected: %v, got: %v", test.definition, rr.Data)
+			}
+
+			if rr.Message != test.output.Message {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Message, rr.Message)
+			}
+		})
+	}
+}
+
+func TestHandleImport(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition *RoleDefinition
+		overwrite  bool
+		err        error
+		output     *types.Response
+	}{
+		{
+			name:       "success",
+			definition: &RoleDefinition{Name: "viewer", Entitlements: []string{"can_view::client:okta"}},
+			output: &types.Response{
+				Message: "Imported role viewer",
+				Status:  http.StatusCreated,
+			},
+		},
+		{
+			name:       "overwrite",
+			definition: &RoleDefinition{Name: "viewer", Entitlements: []string{"can_view::client:okta"}},
+			overwrite:  true,
+			output: &types.Response{
+				Message: "Imported role viewer",
+				Status:  http.StatusCreated,
+			},
+		},
+		{
+			name:       "existing role",
+			definition: &RoleDefinition{Name: "viewer", Entitlements: []string{"can_view::client:okta"}},
+			err:        ErrRoleExists,
+			output: &types.Response{
+				Message: "Role viewer already exists, pass overwrite=true to import over it",
+				Status:  http.StatusConflict,
+				Code:    types.CodeRoleNameConflict,
+			},
+		},
+		{
+			name:       "invalid entitlement",
+			definition: &RoleDefinition{Name: "viewer", Entitlements: []string{"client:okta"}},
+			err:        fmt.Errorf("%w: malformed entitlement", ErrInvalidPermission),
+			output: &types.Response{
+				Message: "invalid permission: malformed entitlement",
+				Status:  http.StatusBadRequest,
+			},
+		},
+		{
+			name:       "fail",
+			definition: &RoleDefinition{Name: "viewer"},
+			err:        fmt.Errorf("error"),
+			output: &types.Response{
+				Message: "error",
+				Status:  http.StatusInternalServerError,
+			},
+		},
+		{
+			name:       "missing name",
+			definition: &RoleDefinition{},
+			output: &types.Response{
+				Message: "Error parsing JSON payload",
+				Status:  http.StatusBadRequest,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			payload, _ := json.Marshal(test.definition)
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v0/roles/import?overwrite=%v", test.overwrite), bytes.NewReader(payload))
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			if test.definition.Name != "" {
+				var role *Role = nil
+				if test.err == nil {
+					role = &Role{ID: test.definition.Name, Name: test.definition.Name}
+				}
+				mockService.EXPECT().ImportRole(gomock.Any(), test.definition, "test-user", test.overwrite).Return(role, test.err)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			data, err := io.ReadAll(res.Body)
+
+			if err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if res.StatusCode != test.output.Status {
+				t.Errorf("expected HTTP status code %v got %v", test.output.Status, res.StatusCode)
+			}
+
+			rr := new(types.Response)
+
+			if err := json.Unmarshal(data, rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if rr.Message != test.output.Message {
+				t.Errorf("invalid result, expected: %v, got: %v", test.output.Message, rr.Message)
+			}
+
+			if test.output.Code != "" && rr.Code != test.output.Code {
+				t.Errorf("expected code to be %v got %v", test.output.Code, rr.Code)
+			}
+		})
+	}
+}
+
+func TestHandleCreateBadRoleFormat(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    string
+		expected error
+		output   *types.Response
+	}{
+		{
+			name:     "no permissions",
+			expected: nil,
+			input:    "administrator",
+			output: &types.Response{
+				Message: "Error parsing JSON payload",
 				Status:  http.StatusBadRequest,
 			},
 		},
@@ -1378,3 +2187,285 @@ func TestRegisterValidation(t *testing.T) {
 	// second registration of `apiKey` causes logger.Fatal invocation
 	NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterValidation(mockValidationRegistry)
 }
+
+func TestHandleRemoveDryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		tuples   []ofga.Tuple
+		expected error
+		status   int
+	}{
+		{
+			name:     "error",
+			expected: fmt.Errorf("error"),
+			status:   http.StatusInternalServerError,
+		},
+		{
+			name: "preview",
+			tuples: []ofga.Tuple{
+				*ofga.NewTuple("user:joe", "assignee", "role:administrator"),
+				*ofga.NewTuple("role:administrator#assignee", "can_view", "client:okta"),
+			},
+			status: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v0/roles/administrator?dry_run=true", nil)
+
+			mockService.EXPECT().PreviewDeleteRole(gomock.Any(), "administrator").Return(test.tuples, test.expected)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			type Response struct {
+				Data    DeletePreview `json:"data"`
+				Message string        `json:"message"`
+				Status  int           `json:"status"`
+			}
+
+			rr := new(Response)
+
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Errorf("expected error to be nil got %v", err)
+			}
+
+			if test.expected != nil {
+				return
+			}
+
+			if rr.Data.Count != len(test.tuples) || !reflect.DeepEqual(rr.Data.Tuples, test.tuples) {
+				t.Errorf("expected preview to be %v got %v", test.tuples, rr.Data)
+			}
+		})
+	}
+}
+
+func TestHandleListPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int64
+	}{
+		{name: "missing size uses the default", query: "", expected: 10},
+		{name: "size below max", query: "size=20", expected: 20},
+		{name: "size equal to max", query: "size=50", expected: 50},
+		{name: "size above max is capped", query: "size=51", expected: 50},
+		{name: "zero size", query: "size=0"},
+		{name: "negative size", query: "size=-1"},
+		{name: "non numeric size", query: "size=ten"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/roles?"+test.query, nil)
+			req = req.WithContext(authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "test-user"}))
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+
+			if test.expected > 0 {
+				mockService.EXPECT().ListRoles(gomock.Any(), "test-user", test.expected, "").Return([]string{}, "", nil)
+			}
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			api := NewAPI(mockService, mockTracer, mockMonitor, mockLogger)
+			api.SetPageSizeConfig(types.NewPageSizeConfig(10, 50))
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			expectedStatus := http.StatusOK
+			if test.expected == 0 {
+				expectedStatus = http.StatusBadRequest
+			}
+
+			if res.StatusCode != expectedStatus {
+				t.Fatalf("expected HTTP status code %v got %v", expectedStatus, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHandleListRoleIdentities(t *testing.T) {
+	tests := []struct {
+		name       string
+		identities []string
+		token      string
+		err        error
+		status     int
+	}{
+		{
+			name:       "identities with continuation token",
+			identities: []string{"user:ceo", "user:joe"},
+			token:      "user:joe",
+			status:     http.StatusOK,
+		},
+		{
+			name:   "error",
+			err:    fmt.Errorf("error"),
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/roles/administrator/identities", nil)
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListRoleIdentities(gomock.Any(), "administrator", "").Return(test.identities, test.token, test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if len(rr.Data.([]interface{})) != len(test.identities) {
+				t.Errorf("expected %v identities got %v", test.identities, rr.Data)
+			}
+
+			next := types.NewTokenPaginator(mockTracer, mockLogger)
+
+			if err := next.LoadFromString(context.TODO(), res.Header.Get(types.PAGINATION_HEADER)); err != nil {
+				t.Fatalf("expected continuation token in headers, got %v", err)
+			}
+
+			if token := next.GetToken(context.TODO(), IDENTITY_TOKEN_KEY); token != test.token {
+				t.Errorf("expected continuation token to be %s got %s", test.token, token)
+			}
+		})
+	}
+}
+
+func TestHandleListAccessibleObjects(t *testing.T) {
+	objects := []AccessibleObject{
+		{Object: "client:okta", Relations: []string{"can_view", "can_edit"}},
+	}
+
+	for _, test := range []struct {
+		name     string
+		path     string
+		objects  []AccessibleObject
+		err      error
+		expected int
+	}{
+		{name: "ok", path: "/api/v0/roles/administrator/access/client", objects: objects, expected: http.StatusOK},
+		{name: "invalid type", path: "/api/v0/roles/administrator/access/user", err: fmt.Errorf("%w user", ErrInvalidPermissionType), expected: http.StatusBadRequest},
+		{name: "error", path: "/api/v0/roles/administrator/access/client", err: fmt.Errorf("error"), expected: http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set(types.PAGINATION_HEADER, base64.StdEncoding.EncodeToString([]byte(`{"roles":"page-2"}`)))
+
+			objectType := test.path[strings.LastIndex(test.path, "/")+1:]
+
+			mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockService.EXPECT().ListAccessibleObjects(gomock.Any(), "administrator", objectType, "page-2").Return(test.objects, "page-3", test.err)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != test.expected {
+				t.Fatalf("expected HTTP status code %v got %v", test.expected, res.StatusCode)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			tokenMap, err := base64.StdEncoding.DecodeString(res.Header.Get(types.PAGINATION_HEADER))
+
+			if err != nil {
+				t.Fatalf("expected continuation token in headers")
+			}
+
+			tokens := map[string]string{}
+			_ = json.Unmarshal(tokenMap, &tokens)
+
+			if !reflect.DeepEqual(tokens, map[string]string{"roles": "page-3"}) {
+				t.Errorf("expected the next token to be page-3 got %v", tokens)
+			}
+
+			rr := struct {
+				Data []AccessibleObject `json:"data"`
+			}{}
+
+			if err := json.NewDecoder(res.Body).Decode(&rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(rr.Data, test.objects) {
+				t.Errorf("expected objects to be %v got %v", test.objects, rr.Data)
+			}
+		})
+	}
+}
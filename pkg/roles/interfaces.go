@@ -13,14 +13,29 @@ import (
 
 // ServiceInterface is the interface that each business logic service needs to implement
 type ServiceInterface interface {
-	ListRoles(context.Context, string) ([]string, error)
+	ListRoles(context.Context, string, int64, string) ([]string, string, error)
+	ListAssignableRoles(context.Context, string) ([]string, error)
 	GetRole(context.Context, string, string) (*Role, error)
 	CreateRole(context.Context, string, string) (*Role, error)
+	CloneRole(context.Context, string, string, string) (*Role, error)
 	DeleteRole(context.Context, string) error
+	PreviewDeleteRole(context.Context, string) ([]ofga.Tuple, error)
 	ListRoleGroups(context.Context, string, string) ([]string, string, error)
-	ListPermissions(context.Context, string, map[string]string) ([]string, map[string]string, error)
+	ListRoleGroupsTyped(context.Context, string, string) ([]RoleSubject, string, error)
+	ListRoleIdentities(context.Context, string, string) ([]string, string, error)
+	ListAccessibleObjects(context.Context, string, string, string) ([]AccessibleObject, string, error)
+	ListPermissions(context.Context, string, map[string]string, bool, []string, string) ([]string, map[string]string, error)
+	StreamPermissions(context.Context, string, []string, string, func([]string) error) error
 	AssignPermissions(context.Context, string, ...Permission) error
 	RemovePermissions(context.Context, string, ...Permission) error
+	PatchPermissions(context.Context, string, []Permission, []Permission) error
+	ExportRole(context.Context, string) (*RoleDefinition, error)
+	ImportRole(context.Context, *RoleDefinition, string, bool) (*Role, error)
+}
+
+// GroupExpanderInterface resolves the identities belonging to a group, nested groups included
+type GroupExpanderInterface interface {
+	ListIdentitiesTransitive(context.Context, string) ([]string, error)
 }
 
 // OpenFGAClientInterface is the interface used to decouple the OpenFGA store implementation
@@ -30,4 +45,5 @@ type OpenFGAClientInterface interface {
 	WriteTuples(context.Context, ...ofga.Tuple) error
 	DeleteTuples(context.Context, ...ofga.Tuple) error
 	Check(context.Context, string, string, string, ...ofga.Tuple) (bool, error)
+	BatchCheckDetailed(context.Context, ...ofga.Tuple) ([]ofga.CheckResult, error)
 }
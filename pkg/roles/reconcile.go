@@ -0,0 +1,103 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"errors"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+var (
+	// ErrRoleNotFound is returned when no tuple references the role
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when the target name of a clone or import is already in use
+	ErrRoleExists = errors.New("role already exists")
+)
+
+// roleExists reports whether any tuple has the role as object, every role gets the tuples of its
+// creator on creation
+func (s *Service) roleExists(ctx context.Context, ID string) (bool, error) {
+	r, err := s.ofga.ReadTuples(ctx, "", "", authorization.RoleForTuple(ID), "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return false, err
+	}
+
+	return len(r.GetTuples()) > 0, nil
+}
+
+// ReconcileRole writes back the tuples CreateRole gives owner on the role, e.g. after a creation
+// that failed half way, tuples still in place are left alone so reconciling a consistent role is
+// a no-op, the restored tuples are returned
+// fails with ErrRoleNotFound if no tuple references the role
+func (s *Service) ReconcileRole(ctx context.Context, ID, owner string) ([]ofga.Tuple, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ReconcileRole")
+	defer span.End()
+
+	role := authorization.RoleForTuple(ID)
+
+	r, err := s.ofga.ReadTuples(ctx, "", "", role, "")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(r.GetTuples()) == 0 {
+		return nil, ErrRoleNotFound
+	}
+
+	user := authorization.UserForTuple(owner)
+
+	// keep in sync with CreateRole
+	missing, err := s.missingTuples(
+		ctx,
+		*ofga.NewTuple(user, ASSIGNEE_RELATION, role),
+		*ofga.NewTuple(user, CAN_VIEW_RELATION, role),
+	)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	if len(missing) == 0 {
+		return missing, nil
+	}
+
+	err = s.ofga.WriteTuples(ctx, missing...)
+
+	s.auditor.Record(ctx, audit.RoleReconcile, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+// missingTuples returns the tuples not stored in OpenFGA, writing an existing tuple fails
+func (s *Service) missingTuples(ctx context.Context, tuples ...ofga.Tuple) ([]ofga.Tuple, error) {
+	missing := make([]ofga.Tuple, 0, len(tuples))
+
+	for _, t := range tuples {
+		r, err := s.ofga.ReadTuples(ctx, t.User, t.Relation, t.Object, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(r.GetTuples()) == 0 {
+			missing = append(missing, t)
+		}
+	}
+
+	return missing, nil
+}
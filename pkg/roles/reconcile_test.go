@@ -0,0 +1,124 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+func TestServiceReconcileRole(t *testing.T) {
+	assignee := *ofga.NewTuple("user:joe", ASSIGNEE_RELATION, "role:viewer")
+	viewer := *ofga.NewTuple("user:joe", CAN_VIEW_RELATION, "role:viewer")
+	group := *ofga.NewTuple("group:administrator#member", ASSIGNEE_RELATION, "role:viewer")
+
+	tests := []struct {
+		name        string
+		stored      []ofga.Tuple
+		writeErr    error
+		expected    []ofga.Tuple
+		expectedErr error
+	}{
+		{
+			name:     "missing creator tuples are restored",
+			stored:   []ofga.Tuple{viewer, group},
+			expected: []ofga.Tuple{assignee},
+		},
+		{
+			name:     "consistent role is left alone",
+			stored:   []ofga.Tuple{assignee, viewer},
+			expected: []ofga.Tuple{},
+		},
+		{
+			name:        "role not found",
+			expectedErr: ErrRoleNotFound,
+		},
+		{
+			name:        "write fails",
+			stored:      []ofga.Tuple{group},
+			writeErr:    fmt.Errorf("error"),
+			expectedErr: fmt.Errorf("error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			svc := NewService(mockOpenFGA, NewMockWorkerPoolInterface(ctrl), audit.NewNoopAuditor(), mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.ReconcileRole").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "").AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, cToken string) (*client.ClientReadResponse, error) {
+					ts := []openfga.Tuple{}
+
+					for _, tuple := range test.stored {
+						if tuple.Object != object || (user != "" && tuple.User != user) || (relation != "" && tuple.Relation != relation) {
+							continue
+						}
+
+						ts = append(ts, *openfga.NewTuple(*openfga.NewTupleKey(tuple.Values()), time.Now()))
+					}
+
+					r := new(client.ClientReadResponse)
+					r.SetTuples(ts)
+					r.SetContinuationToken("")
+
+					return r, nil
+				},
+			)
+
+			if test.writeErr != nil || len(test.expected) > 0 {
+				mockOpenFGA.EXPECT().WriteTuples(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(
+					func(ctx context.Context, tuples ...ofga.Tuple) error {
+						if test.writeErr == nil && !reflect.DeepEqual(tuples, test.expected) {
+							t.Errorf("expected tuples to be %v got %v", test.expected, tuples)
+						}
+
+						return test.writeErr
+					},
+				)
+			}
+
+			restored, err := svc.ReconcileRole(context.Background(), "viewer", "joe")
+
+			if test.expectedErr != nil {
+				if err == nil || (errors.Is(test.expectedErr, ErrRoleNotFound) && !errors.Is(err, ErrRoleNotFound)) {
+					t.Fatalf("expected error to be %v got %v", test.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(restored, test.expected) {
+				t.Fatalf("expected restored tuples to be %v got %v", test.expected, restored)
+			}
+		})
+	}
+}
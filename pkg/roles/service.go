@@ -5,7 +5,9 @@ package roles
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
@@ -14,6 +16,7 @@ import (
 	v1 "github.com/canonical/rebac-admin-ui-handlers/v1"
 	"github.com/canonical/rebac-admin-ui-handlers/v1/resources"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
 	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
@@ -27,8 +30,26 @@ const (
 	ASSIGNEE_RELATION = "assignee"
 	CAN_VIEW_RELATION = "can_view"
 	ALL_USERS         = "user:*"
+
+	// MaxAutoPaginatePermissions caps the permissions collected by an auto paginated ListPermissions
+	MaxAutoPaginatePermissions = 10000
+
+	// assignableRolesBatchSize is the number of roles checked by each BatchCheck of ListAssignableRoles
+	assignableRolesBatchSize = 50
 )
 
+// ErrInvalidPermission is returned when a permission has no relation or its object is not a <type>:<id> reference
+var ErrInvalidPermission = errors.New("invalid permission")
+
+// ErrInvalidPermissionType is returned when filtering permissions on an unknown object type
+var ErrInvalidPermissionType = errors.New("invalid permission type")
+
+// ErrInvalidPermissionRelation is returned when filtering permissions on an unknown relation
+var ErrInvalidPermissionRelation = errors.New("invalid permission relation")
+
+// ErrGroupExpanderNotSet is returned when listing the identities of a role without a GroupExpanderInterface
+var ErrGroupExpanderNotSet = errors.New("group expander not set")
+
 type listPermissionsResult struct {
 	permissions []string
 	token       string
@@ -36,30 +57,135 @@ type listPermissionsResult struct {
 	err         error
 }
 
+type expandGroupResult struct {
+	group      string
+	identities []string
+	err        error
+}
+
+type checkRolesResult struct {
+	allowed []string
+	err     error
+}
+
+type readPermissionsResult struct {
+	permissions []ofga.Tuple
+	ofgaType    string
+	err         error
+}
+
 // Service contains the business logic to deal with roles on the Admin UI OpenFGA model
 type Service struct {
 	ofga OpenFGAClientInterface
 
-	wpool pool.WorkerPoolInterface
+	wpool   pool.WorkerPoolInterface
+	auditor audit.AuditorInterface
+	groups  GroupExpanderInterface
 
 	tracer  trace.Tracer
 	monitor monitoring.MonitorInterface
 	logger  logging.LoggerInterface
 }
 
-// ListRoles returns all the roles a specific user can see (using "can_view" OpenFGA relation)
-func (s *Service) ListRoles(ctx context.Context, userID string) ([]string, error) {
+// ListRoles returns the roles a specific user can see (using "can_view" OpenFGA relation), a page of
+// at most size roles following the continuation token is returned if size is positive, all of them otherwise
+func (s *Service) ListRoles(ctx context.Context, userID string, size int64, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "roles.Service.ListRoles")
 	defer span.End()
 
 	roles, err := s.ofga.ListObjects(ctx, fmt.Sprintf("user:%s", userID), "can_view", "role")
 
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, "", err
+	}
+
+	roles, token := ofga.PaginateObjects(roles, size, continuationToken)
+
+	return roles, token, nil
+}
+
+// CountRoles returns the number of roles a specific user can see
+func (s *Service) CountRoles(ctx context.Context, userID string) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.CountRoles")
+	defer span.End()
+
+	roles, err := s.ofga.ListObjects(ctx, fmt.Sprintf("user:%s", userID), "can_view", "role")
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return 0, err
+	}
+
+	return int64(len(roles)), nil
+}
+
+// ListAssignableRoles returns the roles userID is allowed to assign to groups, the roles listed for
+// the user are only candidates as ListObjects results can be truncated or stale, each of them is
+// confirmed with the same can_view check groups.Service.CanAssignRoles runs on assignment
+// checks are run in batches on the worker pool, roles keep the ListObjects order
+func (s *Service) ListAssignableRoles(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ListAssignableRoles")
+	defer span.End()
+
+	roles, err := s.ofga.ListObjects(ctx, authorization.UserForTuple(userID), CAN_VIEW_RELATION, "role")
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return nil, err
 	}
 
-	return roles, nil
+	batches := (len(roles) + assignableRolesBatchSize - 1) / assignableRolesBatchSize
+	results := make(chan *pool.Result[any], batches)
+
+	wg := sync.WaitGroup{}
+	wg.Add(batches)
+
+	var submitErr error
+
+	for start := 0; start < len(roles); start += assignableRolesBatchSize {
+		batch := roles[start:min(start+assignableRolesBatchSize, len(roles))]
+
+		// a rejected task never runs, release it here or Wait would block forever
+		if _, err := s.wpool.Submit(s.checkRolesFunc(ctx, userID, batch), results, &wg); err != nil {
+			wg.Done()
+
+			s.logger.Errorf("failed submitting roles check: %s", err)
+			submitErr = err
+		}
+	}
+
+	wg.Wait()
+	close(results)
+
+	if submitErr != nil {
+		return nil, submitErr
+	}
+
+	allowed := make(map[string]bool, len(roles))
+
+	for r := range results {
+		v := r.Value.(checkRolesResult)
+
+		if v.err != nil {
+			s.logger.Error(v.err.Error())
+			return nil, v.err
+		}
+
+		for _, role := range v.allowed {
+			allowed[role] = true
+		}
+	}
+
+	assignable := make([]string, 0, len(allowed))
+
+	for _, role := range roles {
+		if allowed[role] {
+			assignable = append(assignable, role)
+		}
+	}
+
+	return assignable, nil
 }
 
 // ListRoleGroups returns all the groups associated to a specific role
@@ -91,6 +217,133 @@ func (s *Service) ListRoleGroups(ctx context.Context, ID, continuationToken stri
 	return groups, r.GetContinuationToken(), nil
 }
 
+// ListRoleGroupsTyped is ListRoleGroups with the subjects split in type, id and relation,
+// pages and continuation tokens are the same as ListRoleGroups
+func (s *Service) ListRoleGroupsTyped(ctx context.Context, ID, continuationToken string) ([]RoleSubject, string, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ListRoleGroupsTyped")
+	defer span.End()
+
+	groups, token, err := s.ListRoleGroups(ctx, ID, continuationToken)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	subjects := make([]RoleSubject, 0, len(groups))
+
+	for _, group := range groups {
+		subjects = append(subjects, ParseRoleSubject(group))
+	}
+
+	return subjects, token, nil
+}
+
+// ListRoleIdentities returns a page of the users holding a role, either directly or through the
+// membership of one of the groups the role is assigned to, each user is returned only once
+// groups are expanded in parallel on the worker pool, see groups.MaxExpansionDepth for nesting
+func (s *Service) ListRoleIdentities(ctx context.Context, ID, continuationToken string) ([]string, string, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ListRoleIdentities")
+	defer span.End()
+
+	if s.groups == nil {
+		return nil, "", ErrGroupExpanderNotSet
+	}
+
+	assignees, err := s.readAllTuples(ctx, "", ASSIGNEE_RELATION, fmt.Sprintf("role:%s", ID))
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, "", err
+	}
+
+	seen := make(map[string]bool)
+	identities := make([]string, 0)
+	groups := make([]string, 0)
+
+	for _, t := range assignees {
+		if strings.HasPrefix(t.User, "user:") && !seen[t.User] {
+			seen[t.User] = true
+			identities = append(identities, t.User)
+
+			continue
+		}
+
+		if group, ok := strings.CutPrefix(t.User, "group:"); ok && strings.HasSuffix(group, fmt.Sprintf("#%s", authorization.MEMBER_RELATION)) {
+			groups = append(groups, strings.TrimSuffix(group, fmt.Sprintf("#%s", authorization.MEMBER_RELATION)))
+		}
+	}
+
+	results := make(chan *pool.Result[any], len(groups))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(groups))
+
+	for _, group := range groups {
+		s.wpool.Submit(s.expandGroupFunc(ctx, group), results, &wg)
+	}
+
+	wg.Wait()
+	close(results)
+
+	for r := range results {
+		v := r.Value.(expandGroupResult)
+
+		if v.err != nil {
+			s.logger.Errorf("failed expanding group %s: %s", v.group, v.err)
+			return nil, "", v.err
+		}
+
+		for _, identity := range v.identities {
+			if !seen[identity] {
+				seen[identity] = true
+				identities = append(identities, identity)
+			}
+		}
+	}
+
+	identities, token := ofga.PaginateObjects(identities, types.DefaultPageSize, continuationToken)
+
+	return identities, token, nil
+}
+
+// ListAccessibleObjects returns a page of the objects of objectType the role holds any permission on,
+// the relations granted on an object are aggregated, tuples of an object split across two pages make
+// it show up on both pages with the relations read on each
+func (s *Service) ListAccessibleObjects(ctx context.Context, ID, objectType, continuationToken string) ([]AccessibleObject, string, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.ListAccessibleObjects")
+	defer span.End()
+
+	if !slices.Contains(s.permissionTypes(), objectType) {
+		return nil, "", fmt.Errorf("%w %s", ErrInvalidPermissionType, objectType)
+	}
+
+	r, err := s.ofga.ReadTuples(ctx, s.getRoleAssigneeUser(ID), "", fmt.Sprintf("%s:", objectType), continuationToken)
+
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, "", err
+	}
+
+	objects := make([]AccessibleObject, 0)
+	positions := make(map[string]int)
+
+	for _, t := range r.GetTuples() {
+		i, ok := positions[t.Key.Object]
+
+		if !ok {
+			i = len(objects)
+			positions[t.Key.Object] = i
+			objects = append(objects, AccessibleObject{Object: t.Key.Object, Relations: make([]string, 0)})
+		}
+
+		if !slices.Contains(objects[i].Relations, t.Key.Relation) {
+			objects[i].Relations = append(objects[i].Relations, t.Key.Relation)
+		}
+	}
+
+	return objects, r.GetContinuationToken(), nil
+}
+
 // GetRole returns the specified role using the ID argument, userID is used to validate the visibility by the user
 // making the call
 func (s *Service) GetRole(ctx context.Context, userID, ID string) (*Role, error) {
@@ -138,6 +391,8 @@ func (s *Service) CreateRole(ctx context.Context, userID, ID string) (*Role, err
 		*ofga.NewTuple(user, CAN_VIEW_RELATION, role),
 	)
 
+	s.auditor.Record(ctx, audit.RoleCreate, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return nil, err
@@ -149,6 +404,99 @@ func (s *Service) CreateRole(ctx context.Context, userID, ID string) (*Role, err
 	}, nil
 }
 
+// CloneRole creates a new role owned by userID and copies over all the permissions of the source role
+// if copying the permissions fails the newly created role is removed
+// fails with ErrRoleNotFound if the source role doesn't exist and with ErrRoleExists if ID is taken
+func (s *Service) CloneRole(ctx context.Context, sourceID, ID, userID string) (*Role, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.CloneRole")
+	defer span.End()
+
+	if exists, err := s.roleExists(ctx, sourceID); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, ErrRoleNotFound
+	}
+
+	if exists, err := s.roleExists(ctx, ID); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, ErrRoleExists
+	}
+
+	// keep it a buffered channel, if set to unbuffered we would need a goroutine
+	// to consume from it before pushing to it
+	// https://go.dev/ref/spec#Send_statements
+	// A send on an unbuffered channel can proceed if a receiver is ready.
+	// A send on a buffered channel can proceed if there is room in the buffer
+	results := make(chan *pool.Result[any], len(s.permissionTypes()))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(s.permissionTypes()))
+
+	for _, t := range s.permissionTypes() {
+		s.wpool.Submit(
+			s.readPermissionsFunc(ctx, sourceID, t),
+			results,
+			&wg,
+		)
+	}
+
+	// wait for tasks to finish
+	wg.Wait()
+
+	// close result channel
+	close(results)
+
+	permissions := make([]ofga.Tuple, 0)
+
+	for r := range results {
+		v := r.Value.(readPermissionsResult)
+
+		if v.err != nil {
+			return nil, fmt.Errorf("failed to read %s permissions of role %s: %w", v.ofgaType, sourceID, v.err)
+		}
+
+		for _, p := range v.permissions {
+			permissions = append(permissions, *ofga.NewTuple(s.getRoleAssigneeUser(ID), p.Relation, p.Object))
+		}
+	}
+
+	role, err := s.CreateRole(ctx, userID, ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(permissions) == 0 {
+		s.auditor.Record(ctx, audit.RoleClone, audit.RoleResource, ID, audit.OutcomeSuccess)
+
+		return role, nil
+	}
+
+	if err := ofga.WriteTuplesInChunks(ctx, s.ofga, permissions...); err != nil {
+		s.logger.Error(err.Error())
+
+		// chunks already written are rolled back, only the tuples created by CreateRole need to go
+		rerr := s.ofga.DeleteTuples(
+			ctx,
+			*ofga.NewTuple(fmt.Sprintf("user:%s", userID), ASSIGNEE_RELATION, fmt.Sprintf("role:%s", ID)),
+			*ofga.NewTuple(fmt.Sprintf("user:%s", userID), CAN_VIEW_RELATION, fmt.Sprintf("role:%s", ID)),
+		)
+
+		if rerr != nil {
+			s.logger.Errorf("failed rolling back clone of role %s into %s: %s", sourceID, ID, rerr)
+		}
+
+		s.auditor.Record(ctx, audit.RoleClone, audit.RoleResource, ID, audit.OutcomeFailure)
+
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, audit.RoleClone, audit.RoleResource, ID, audit.OutcomeSuccess)
+
+	return role, nil
+}
+
 // AssignPermissions assigns permissions to a role
 // TODO @shipperizer see if it's worth using only one between Permission and ofga.Tuple
 func (s *Service) AssignPermissions(ctx context.Context, ID string, permissions ...Permission) error {
@@ -161,11 +509,17 @@ func (s *Service) AssignPermissions(ctx context.Context, ID string, permissions
 	ps := make([]ofga.Tuple, 0)
 
 	for _, p := range permissions {
+		if err := s.validatePermission(p); err != nil {
+			return err
+		}
+
 		ps = append(ps, *ofga.NewTuple(s.getRoleAssigneeUser(ID), p.Relation, p.Object))
 	}
 
 	err := s.ofga.WriteTuples(ctx, ps...)
 
+	s.auditor.Record(ctx, audit.RoleAssignPermissions, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -186,11 +540,17 @@ func (s *Service) RemovePermissions(ctx context.Context, ID string, permissions
 	ps := make([]ofga.Tuple, 0)
 
 	for _, p := range permissions {
+		if err := s.validatePermission(p); err != nil {
+			return err
+		}
+
 		ps = append(ps, *ofga.NewTuple(s.getRoleAssigneeUser(ID), p.Relation, p.Object))
 	}
 
 	err := s.ofga.DeleteTuples(ctx, ps...)
 
+	s.auditor.Record(ctx, audit.RoleRemovePermissions, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -199,25 +559,130 @@ func (s *Service) RemovePermissions(ctx context.Context, ID string, permissions
 	return nil
 }
 
-// ListPermissions returns all the permissions associated to a specific role
-func (s *Service) ListPermissions(ctx context.Context, ID string, continuationTokens map[string]string) ([]string, map[string]string, error) {
+// PatchPermissions assigns additions and removes removals from a role in a single operation,
+// every permission is validated upfront and nothing is written if any of them is malformed
+func (s *Service) PatchPermissions(ctx context.Context, ID string, additions, removals []Permission) error {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.PatchPermissions")
+	defer span.End()
+
+	for _, p := range append(append([]Permission{}, additions...), removals...) {
+		if err := s.validatePermission(p); err != nil {
+			return err
+		}
+	}
+
+	writes := make([]ofga.Tuple, 0, len(additions))
+	deletes := make([]ofga.Tuple, 0, len(removals))
+
+	for _, p := range additions {
+		writes = append(writes, *ofga.NewTuple(s.getRoleAssigneeUser(ID), p.Relation, p.Object))
+	}
+
+	for _, p := range removals {
+		deletes = append(deletes, *ofga.NewTuple(s.getRoleAssigneeUser(ID), p.Relation, p.Object))
+	}
+
+	if len(writes) > 0 {
+		err := s.ofga.WriteTuples(ctx, writes...)
+
+		s.auditor.Record(ctx, audit.RoleAssignPermissions, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return err
+		}
+	}
+
+	if len(deletes) > 0 {
+		err := s.ofga.DeleteTuples(ctx, deletes...)
+
+		s.auditor.Record(ctx, audit.RoleRemovePermissions, audit.RoleResource, ID, audit.OutcomeFromError(err))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePermission checks the object is a <type>:<id> reference to a known type and the relation is set
+func (s *Service) validatePermission(p Permission) error {
+	if p.Relation == "" {
+		return fmt.Errorf("%w: missing relation on %s", ErrInvalidPermission, p.Object)
+	}
+
+	if err := ofga.ValidateObject(p.Object, s.permissionTypes()...); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPermission, err)
+	}
+
+	return nil
+}
+
+// ListPermissions returns all the permissions associated to a specific role, if autoPaginate is set
+// every per type continuation token is drained until exhaustion or until MaxAutoPaginatePermissions
+// permissions are collected, in which case the remaining tokens are returned
+// pTypes restricts the lookup to a subset of the object types, all of them are read when empty,
+// relation restricts it to a single permission relation, all of them are read when empty
+func (s *Service) ListPermissions(ctx context.Context, ID string, continuationTokens map[string]string, autoPaginate bool, pTypes []string, relation string) ([]string, map[string]string, error) {
 	ctx, span := s.tracer.Start(ctx, "roles.Service.ListPermissions")
 	defer span.End()
 
+	pTypes, err := s.filterPermissionTypes(pTypes)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if relation != "" && !slices.Contains(s.permissionRelations(), relation) {
+		return nil, nil, fmt.Errorf("%w %s", ErrInvalidPermissionRelation, relation)
+	}
+
+	permissions, tMap, err := s.listPermissions(ctx, ID, pTypes, relation, continuationTokens)
+
+	for autoPaginate && err == nil && len(permissions) < MaxAutoPaginatePermissions {
+		pending := make([]string, 0)
+
+		for t, token := range tMap {
+			if token != "" {
+				pending = append(pending, t)
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+
+		var p []string
+		var tokens map[string]string
+
+		p, tokens, err = s.listPermissions(ctx, ID, pending, relation, tMap)
+		permissions = append(permissions, p...)
+
+		for t, token := range tokens {
+			tMap[t] = token
+		}
+	}
+
+	return permissions, tMap, err
+}
+
+func (s *Service) listPermissions(ctx context.Context, ID string, pTypes []string, relation string, continuationTokens map[string]string) ([]string, map[string]string, error) {
 	// keep it a buffered channel, if set to unbuffered we would need a goroutine
 	// to consume from it before pushing to it
 	// https://go.dev/ref/spec#Send_statements
 	// A send on an unbuffered channel can proceed if a receiver is ready.
 	// A send on a buffered channel can proceed if there is room in the buffer
-	results := make(chan *pool.Result[any], len(s.permissionTypes()))
+	results := make(chan *pool.Result[any], len(pTypes))
 
 	wg := sync.WaitGroup{}
-	wg.Add(len(s.permissionTypes()))
+	wg.Add(len(pTypes))
 
 	// TODO @shipperizer use a background operator
-	for _, t := range s.permissionTypes() {
+	for _, t := range pTypes {
 		s.wpool.Submit(
-			s.listPermissionsFunc(ctx, ID, t, continuationTokens[t]),
+			s.listPermissionsFunc(ctx, ID, t, relation, continuationTokens[t]),
 			results,
 			&wg,
 		)
@@ -277,21 +742,22 @@ func (s *Service) DeleteRole(ctx context.Context, ID string) error {
 	wg := sync.WaitGroup{}
 	wg.Add(jobs)
 
+	submitErrs := make([]error, 0)
+
 	// TODO @shipperizer use a background operator
 	for _, t := range permissionTypes {
-		s.wpool.Submit(
-			s.removePermissionsFunc(ctx, ID, t),
-			results,
-			&wg,
-		)
+		// a rejected task never runs, release it here or Wait would block forever
+		if _, err := s.wpool.Submit(s.removePermissionsFunc(ctx, ID, t), results, &wg); err != nil {
+			wg.Done()
+			submitErrs = append(submitErrs, err)
+		}
 	}
 
 	for _, t := range directRelations {
-		s.wpool.Submit(
-			s.removeDirectAssociationsFunc(ctx, ID, t),
-			results,
-			&wg,
-		)
+		if _, err := s.wpool.Submit(s.removeDirectAssociationsFunc(ctx, ID, t), results, &wg); err != nil {
+			wg.Done()
+			submitErrs = append(submitErrs, err)
+		}
 	}
 
 	// wait for tasks to finish
@@ -300,18 +766,82 @@ func (s *Service) DeleteRole(ctx context.Context, ID string) error {
 	// close result channel
 	close(results)
 
-	// TODO: @barco collect errors from results chan and return composite error or single summing up
+	if err := errors.Join(append(submitErrs, cascadeError(results))...); err != nil {
+		s.logger.Errorf("role %s deletion failed, tuples may be left behind, %s", ID, err)
+		s.auditor.Record(ctx, audit.RoleDelete, audit.RoleResource, ID, audit.OutcomeFailure)
+
+		return err
+	}
+
+	s.auditor.Record(ctx, audit.RoleDelete, audit.RoleResource, ID, audit.OutcomeSuccess)
+
 	return nil
 }
 
+// PreviewDeleteRole returns the tuples DeleteRole would remove without deleting any of them
+func (s *Service) PreviewDeleteRole(ctx context.Context, ID string) ([]ofga.Tuple, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.PreviewDeleteRole")
+	defer span.End()
+
+	tuples := make([]ofga.Tuple, 0)
+
+	for _, t := range s.permissionTypes() {
+		ts, err := s.readAllTuples(ctx, s.getRoleAssigneeUser(ID), "", fmt.Sprintf("%s:", t))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err
+		}
+
+		tuples = append(tuples, ts...)
+	}
+
+	for _, relation := range s.directRelations() {
+		ts, err := s.readAllTuples(ctx, "", relation, fmt.Sprintf("role:%s", ID))
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err
+		}
+
+		tuples = append(tuples, ts...)
+	}
+
+	return tuples, nil
+}
+
+func (s *Service) readAllTuples(ctx context.Context, user, relation, object string) ([]ofga.Tuple, error) {
+	cToken := ""
+	tuples := make([]ofga.Tuple, 0)
+
+	for {
+		r, err := s.ofga.ReadTuples(ctx, user, relation, object, cToken)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range r.GetTuples() {
+			tuples = append(tuples, *ofga.NewTuple(t.Key.User, t.Key.Relation, t.Key.Object))
+		}
+
+		// if there are more pages, keep going with the loop
+		if cToken = r.GetContinuationToken(); cToken == "" {
+			break
+		}
+	}
+
+	return tuples, nil
+}
+
 // TODO @shipperizer make this more scalable by pushing to a channel and using goroutine pool
 // potentially create a background operator that can pipe results to an on demand channel and works off a
 // set amount of goroutines
-func (s *Service) listPermissionsByType(ctx context.Context, roleIDAssignee, pType, continuationToken string) ([]string, string, error) {
+func (s *Service) listPermissionsByType(ctx context.Context, roleIDAssignee, pType, relation, continuationToken string) ([]string, string, error) {
 	ctx, span := s.tracer.Start(ctx, "roles.Service.listPermissionsByType")
 	defer span.End()
 
-	r, err := s.ofga.ReadTuples(ctx, roleIDAssignee, "", fmt.Sprintf("%s:", pType), continuationToken)
+	r, err := s.ofga.ReadTuples(ctx, roleIDAssignee, relation, fmt.Sprintf("%s:", pType), continuationToken)
 
 	if err != nil {
 		s.logger.Error(err.Error())
@@ -327,7 +857,39 @@ func (s *Service) listPermissionsByType(ctx context.Context, roleIDAssignee, pTy
 	return permissions, r.GetContinuationToken(), nil
 }
 
-func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string) {
+func (s *Service) readPermissionsByType(ctx context.Context, ID, pType string) ([]ofga.Tuple, error) {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.readPermissionsByType")
+	defer span.End()
+
+	cToken := ""
+	assigneeRelation := s.getRoleAssigneeUser(ID)
+	permissions := make([]ofga.Tuple, 0)
+
+	for {
+		r, err := s.ofga.ReadTuples(ctx, assigneeRelation, "", fmt.Sprintf("%s:", pType), cToken)
+
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err
+		}
+
+		for _, t := range r.Tuples {
+			permissions = append(permissions, *ofga.NewTuple(assigneeRelation, t.Key.Relation, t.Key.Object))
+		}
+
+		// if there are more pages, keep going with the loop
+		if cToken = r.ContinuationToken; cToken == "" {
+			break
+		}
+	}
+
+	return permissions, nil
+}
+
+// removePermissionsByType reads all the permissions of the given type before deleting them in a
+// single write, a cancelled context stops the reads and skips the write, an issued write is not
+// cancelled so the permissions of a type are either all removed or all kept
+func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string) error {
 	ctx, span := s.tracer.Start(ctx, "roles.Service.removePermissionsByType")
 	defer span.End()
 
@@ -335,11 +897,15 @@ func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string)
 	assigneeRelation := s.getRoleAssigneeUser(ID)
 	permissions := make([]ofga.Tuple, 0)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		r, err := s.ofga.ReadTuples(ctx, assigneeRelation, "", fmt.Sprintf("%s:", pType), cToken)
 
 		if err != nil {
 			s.logger.Errorf("error when retrieving tuples for %s %s", assigneeRelation, pType)
-			return
+			return err
 		}
 
 		for _, t := range r.Tuples {
@@ -356,26 +922,38 @@ func (s *Service) removePermissionsByType(ctx context.Context, ID, pType string)
 	}
 
 	if len(permissions) == 0 {
-		return
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	if err := s.ofga.DeleteTuples(ctx, permissions...); err != nil {
+	if err := s.ofga.DeleteTuples(context.WithoutCancel(ctx), permissions...); err != nil {
 		s.logger.Error(err.Error())
+		return err
 	}
+
+	return nil
 }
 
-func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation string) {
+// removeDirectAssociations follows the same all or nothing approach as removePermissionsByType
+func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation string) error {
 	ctx, span := s.tracer.Start(ctx, "roles.Service.removeDirectAssociations")
 	defer span.End()
 
 	cToken := ""
 	directs := make([]ofga.Tuple, 0)
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		r, err := s.ofga.ReadTuples(ctx, "", relation, fmt.Sprintf("role:%s", ID), cToken)
 
 		if err != nil {
 			s.logger.Errorf("error when retrieving tuples for %s role, %s relation", relation, ID)
-			return
+			return err
 		}
 
 		for _, t := range r.Tuples {
@@ -391,20 +969,28 @@ func (s *Service) removeDirectAssociations(ctx context.Context, ID, relation str
 	}
 
 	if len(directs) == 0 {
-		return
+		return nil
 	}
 
-	if err := s.ofga.DeleteTuples(ctx, directs...); err != nil {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.ofga.DeleteTuples(context.WithoutCancel(ctx), directs...); err != nil {
 		s.logger.Error(err.Error())
+		return err
 	}
+
+	return nil
 }
 
-func (s *Service) listPermissionsFunc(ctx context.Context, roleID, ofgaType, cToken string) func() any {
+func (s *Service) listPermissionsFunc(ctx context.Context, roleID, ofgaType, relation, cToken string) func() any {
 	return func() any {
 		p, token, err := s.listPermissionsByType(
 			ctx,
 			s.getRoleAssigneeUser(roleID),
 			ofgaType,
+			relation,
 			cToken,
 		)
 
@@ -417,22 +1003,112 @@ func (s *Service) listPermissionsFunc(ctx context.Context, roleID, ofgaType, cTo
 	}
 }
 
-func (s *Service) removePermissionsFunc(ctx context.Context, roleID, ofgaType string) func() {
-	return func() {
-		s.removePermissionsByType(ctx, roleID, ofgaType)
+func (s *Service) checkRolesFunc(ctx context.Context, userID string, roles []string) func() any {
+	return func() any {
+		tuples := make([]ofga.Tuple, 0, len(roles))
+
+		for _, role := range roles {
+			tuples = append(tuples, *ofga.NewTuple(authorization.UserForTuple(userID), CAN_VIEW_RELATION, authorization.RoleForTuple(role)))
+		}
+
+		checks, err := s.ofga.BatchCheckDetailed(ctx, tuples...)
+
+		if err != nil {
+			return checkRolesResult{err: err}
+		}
+
+		allowed := make([]string, 0, len(checks))
+
+		for i, check := range checks {
+			if check.Allowed {
+				allowed = append(allowed, roles[i])
+			}
+		}
+
+		return checkRolesResult{allowed: allowed}
+	}
+}
+
+func (s *Service) expandGroupFunc(ctx context.Context, group string) func() any {
+	return func() any {
+		identities, err := s.groups.ListIdentitiesTransitive(ctx, group)
+
+		return expandGroupResult{
+			group:      group,
+			identities: identities,
+			err:        err,
+		}
+	}
+}
+
+func (s *Service) readPermissionsFunc(ctx context.Context, roleID, ofgaType string) func() any {
+	return func() any {
+		p, err := s.readPermissionsByType(ctx, roleID, ofgaType)
+
+		return readPermissionsResult{
+			permissions: p,
+			ofgaType:    ofgaType,
+			err:         err,
+		}
+	}
+}
+
+func (s *Service) removePermissionsFunc(ctx context.Context, roleID, ofgaType string) func() any {
+	return func() any {
+		return s.removePermissionsByType(ctx, roleID, ofgaType)
+	}
+}
+
+func (s *Service) removeDirectAssociationsFunc(ctx context.Context, roleID, relation string) func() any {
+	return func() any {
+		return s.removeDirectAssociations(ctx, roleID, relation)
 	}
 }
 
-func (s *Service) removeDirectAssociationsFunc(ctx context.Context, roleID, relation string) func() {
-	return func() {
-		s.removeDirectAssociations(ctx, roleID, relation)
+// cascadeError joins the errors reported by the cascade jobs, nil if all of them succeeded, a
+// failed job doesn't stop the others so the deletion goes as far as it can
+func cascadeError(results chan *pool.Result[any]) error {
+	errs := make([]error, 0)
+
+	for r := range results {
+		if err, ok := r.Value.(error); ok {
+			errs = append(errs, err)
+		}
 	}
+
+	return errors.Join(errs...)
 }
 
 func (s *Service) permissionTypes() []string {
 	return []string{"role", "group", "identity", "scheme", "provider", "client"}
 }
 
+// permissionRelations are the relations a role can be granted on the permission types
+func (s *Service) permissionRelations() []string {
+	return []string{authorization.CAN_CREATE, authorization.CAN_DELETE, authorization.CAN_EDIT, authorization.CAN_VIEW}
+}
+
+// filterPermissionTypes validates pTypes against the known object types, duplicates are dropped
+func (s *Service) filterPermissionTypes(pTypes []string) ([]string, error) {
+	if len(pTypes) == 0 {
+		return s.permissionTypes(), nil
+	}
+
+	filtered := make([]string, 0, len(pTypes))
+
+	for _, t := range pTypes {
+		if !slices.Contains(s.permissionTypes(), t) {
+			return nil, fmt.Errorf("%w %s", ErrInvalidPermissionType, t)
+		}
+
+		if !slices.Contains(filtered, t) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered, nil
+}
+
 func (s *Service) directRelations() []string {
 	return []string{"privileged", "assignee", "can_create", "can_delete", "can_edit", "can_view"}
 }
@@ -441,12 +1117,18 @@ func (s *Service) getRoleAssigneeUser(roleID string) string {
 	return fmt.Sprintf("role:%s#%s", roleID, ASSIGNEE_RELATION)
 }
 
+// SetGroupExpander sets the service used to resolve the members of the groups a role is assigned to
+func (s *Service) SetGroupExpander(groups GroupExpanderInterface) {
+	s.groups = groups
+}
+
 // NewService returns the implementtation of the business logic for the roles API
-func NewService(ofga OpenFGAClientInterface, wpool pool.WorkerPoolInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+func NewService(ofga OpenFGAClientInterface, wpool pool.WorkerPoolInterface, auditor audit.AuditorInterface, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
 	s := new(Service)
 
 	s.ofga = ofga
 	s.wpool = wpool
+	s.auditor = auditor
 
 	s.monitor = monitor
 	s.tracer = tracer
@@ -470,7 +1152,7 @@ func (s *V1Service) ListRoles(ctx context.Context, params *resources.GetRolesPar
 	if principal == nil {
 		return nil, v1.NewAuthorizationError("unauthorized")
 	}
-	roles, err := s.core.ListRoles(ctx, principal.Identifier())
+	roles, _, err := s.core.ListRoles(ctx, principal.Identifier(), 0, "")
 
 	if err != nil {
 		return nil, v1.NewUnknownError(err.Error())
@@ -487,6 +1169,28 @@ func (s *V1Service) ListRoles(ctx context.Context, params *resources.GetRolesPar
 	return r, nil
 }
 
+// ListAssignableRoles returns the roles principal is allowed to assign, see Service.ListAssignableRoles
+func (s *V1Service) ListAssignableRoles(ctx context.Context, principal string) (*resources.PaginatedResponse[resources.Role], error) {
+	ctx, span := s.core.tracer.Start(ctx, "roles.V1Service.ListAssignableRoles")
+	defer span.End()
+
+	roles, err := s.core.ListAssignableRoles(ctx, principal)
+
+	if err != nil {
+		return nil, v1.NewUnknownError(err.Error())
+	}
+
+	r := new(resources.PaginatedResponse[resources.Role])
+	r.Data = make([]resources.Role, 0, len(roles))
+	r.Meta = resources.ResponseMeta{Size: len(roles)}
+
+	for _, role := range roles {
+		r.Data = append(r.Data, resources.Role{Id: &role, Name: role})
+	}
+
+	return r, nil
+}
+
 // CreateRole creates a single Role.
 func (s *V1Service) CreateRole(ctx context.Context, role *resources.Role) (*resources.Role, error) {
 	ctx, span := s.core.tracer.Start(ctx, "roles.V1Service.CreateRole")
@@ -587,7 +1291,7 @@ func (s *V1Service) GetRoleEntitlements(ctx context.Context, roleId string, para
 		s.core.logger.Error(err)
 	}
 
-	permissions, pageTokens, err := s.core.ListPermissions(ctx, roleId, paginator.GetAllTokens(ctx))
+	permissions, pageTokens, err := s.core.ListPermissions(ctx, roleId, paginator.GetAllTokens(ctx), false, nil, "")
 
 	if err != nil {
 		return nil, v1.NewUnknownError(err.Error())
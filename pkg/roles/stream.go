@@ -0,0 +1,109 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+)
+
+type streamPermissionsResult struct {
+	permissions []string
+	pType       string
+	err         error
+}
+
+// StreamPermissions reads every page of the role permissions of each of pTypes and calls write
+// with the URNs of a type as soon as its reads complete, types are read concurrently on the worker
+// pool while write is only ever called from the calling goroutine
+// pTypes and relation are validated as in ListPermissions before anything is read, the first
+// failed read stops the stream and is returned
+func (s *Service) StreamPermissions(ctx context.Context, ID string, pTypes []string, relation string, write func([]string) error) error {
+	ctx, span := s.tracer.Start(ctx, "roles.Service.StreamPermissions")
+	defer span.End()
+
+	pTypes, err := s.filterPermissionTypes(pTypes)
+
+	if err != nil {
+		return err
+	}
+
+	if relation != "" && !slices.Contains(s.permissionRelations(), relation) {
+		return fmt.Errorf("%w %s", ErrInvalidPermissionRelation, relation)
+	}
+
+	// buffered so that workers never block on a consumer that stopped early
+	results := make(chan *pool.Result[any], len(pTypes))
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(pTypes))
+
+	var submitErr error
+
+	for _, t := range pTypes {
+		// a rejected task never runs, release it here or Wait would block forever
+		if _, err := s.wpool.Submit(s.streamPermissionsFunc(ctx, ID, t, relation), results, &wg); err != nil {
+			wg.Done()
+
+			s.logger.Errorf("failed submitting read of %s permissions: %s", t, err)
+			submitErr = err
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	if submitErr != nil {
+		return submitErr
+	}
+
+	for r := range results {
+		v := r.Value.(streamPermissionsResult)
+
+		if v.err != nil {
+			return fmt.Errorf("failed to read %s permissions of role %s: %w", v.pType, ID, v.err)
+		}
+
+		if len(v.permissions) == 0 {
+			continue
+		}
+
+		if err := write(v.permissions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) streamPermissionsFunc(ctx context.Context, ID, pType, relation string) func() any {
+	return func() any {
+		permissions := make([]string, 0)
+		token := ""
+
+		for {
+			p, next, err := s.listPermissionsByType(ctx, s.getRoleAssigneeUser(ID), pType, relation, token)
+
+			if err != nil {
+				return streamPermissionsResult{pType: pType, err: err}
+			}
+
+			permissions = append(permissions, p...)
+
+			if next == "" {
+				break
+			}
+
+			token = next
+		}
+
+		return streamPermissionsResult{permissions: permissions, pType: pType}
+	}
+}
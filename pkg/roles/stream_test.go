@@ -0,0 +1,167 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package roles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	openfga "github.com/openfga/go-sdk"
+	"github.com/openfga/go-sdk/client"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+)
+
+func TestServiceStreamPermissions(t *testing.T) {
+	tests := []struct {
+		name        string
+		types       []string
+		relation    string
+		readErr     error
+		writeErr    error
+		expected    []string
+		expectedErr error
+	}{
+		{
+			name:     "every page of every type is streamed",
+			expected: []string{"can_edit::client:okta", "can_view::client:okta", "can_view::group:c-level"},
+		},
+		{
+			name:     "filtered types",
+			types:    []string{"client"},
+			expected: []string{"can_edit::client:okta", "can_view::client:okta"},
+		},
+		{
+			name:     "filtered relation",
+			relation: "can_view",
+			expected: []string{"can_view::client:okta", "can_view::group:c-level"},
+		},
+		{
+			name:        "unknown type",
+			types:       []string{"spaceship"},
+			expectedErr: ErrInvalidPermissionType,
+		},
+		{
+			name:        "unknown relation",
+			relation:    "owner",
+			expectedErr: ErrInvalidPermissionRelation,
+		},
+		{
+			name:        "read fails",
+			types:       []string{"group"},
+			readErr:     fmt.Errorf("error"),
+			expectedErr: fmt.Errorf("error"),
+		},
+		{
+			name:        "write fails",
+			types:       []string{"client"},
+			writeErr:    fmt.Errorf("closed"),
+			expectedErr: fmt.Errorf("closed"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := monitoring.NewMockMonitorInterface(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			workerPool := NewMockWorkerPoolInterface(ctrl)
+			setupMockSubmit(workerPool, nil)
+
+			svc := NewService(mockOpenFGA, workerPool, audit.NewNoopAuditor(), mockTracer, mockMonitor, mockLogger)
+
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.StreamPermissions").Times(1).Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockTracer.EXPECT().Start(gomock.Any(), "roles.Service.listPermissionsByType").AnyTimes().Return(context.TODO(), trace.SpanFromContext(context.TODO()))
+			mockLogger.EXPECT().Error(gomock.Any()).AnyTimes()
+
+			// client permissions are split over two pages
+			pages := map[string][][]openfga.TupleKey{
+				"client:": {
+					{*openfga.NewTupleKey("role:viewer#assignee", "can_edit", "client:okta")},
+					{*openfga.NewTupleKey("role:viewer#assignee", "can_view", "client:okta")},
+				},
+				"group:": {
+					{*openfga.NewTupleKey("role:viewer#assignee", "can_view", "group:c-level")},
+				},
+			}
+
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), "role:viewer#assignee", test.relation, gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, user, relation, object, cToken string) (*client.ClientReadResponse, error) {
+					if test.readErr != nil && object == "group:" {
+						return nil, test.readErr
+					}
+
+					page := 0
+
+					if cToken != "" {
+						page = 1
+					}
+
+					ts := []openfga.Tuple{}
+
+					if page < len(pages[object]) {
+						for _, key := range pages[object][page] {
+							if relation == "" || key.Relation == relation {
+								ts = append(ts, *openfga.NewTuple(key, time.Now()))
+							}
+						}
+					}
+
+					r := new(client.ClientReadResponse)
+					r.SetTuples(ts)
+					r.SetContinuationToken("")
+
+					if page+1 < len(pages[object]) {
+						r.SetContinuationToken("next")
+					}
+
+					return r, nil
+				},
+			)
+
+			streamed := make([]string, 0)
+
+			err := svc.StreamPermissions(context.Background(), "viewer", test.types, test.relation, func(permissions []string) error {
+				if test.writeErr != nil {
+					return test.writeErr
+				}
+
+				streamed = append(streamed, permissions...)
+
+				return nil
+			})
+
+			if test.expectedErr != nil {
+				if err == nil || (!errors.Is(err, test.expectedErr) && !errors.Is(err, test.readErr) && !errors.Is(err, test.writeErr)) {
+					t.Fatalf("expected error to be %v got %v", test.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			slices.Sort(streamed)
+
+			if !reflect.DeepEqual(streamed, test.expected) {
+				t.Fatalf("expected permissions to be %v got %v", test.expected, streamed)
+			}
+		})
+	}
+}
@@ -40,7 +40,7 @@ func (p *PayloadValidator) Validate(ctx context.Context, method, endpoint string
 	validated := false
 	var err error
 
-	if p.isCreateRole(method, endpoint) {
+	if p.isCreateRole(method, endpoint) || p.isCloneRole(method, endpoint) {
 		roleRequest := new(Role)
 		if err := json.Unmarshal(body, roleRequest); err != nil {
 			p.logger.Error("Json parsing error: ", err)
@@ -51,12 +51,32 @@ func (p *PayloadValidator) Validate(ctx context.Context, method, endpoint string
 		validated = true
 	}
 
+	if p.isImportRole(method, endpoint) {
+		definition := new(RoleDefinition)
+		if err := json.Unmarshal(body, definition); err != nil {
+			p.logger.Error("Json parsing error: ", err)
+			return ctx, nil, fmt.Errorf("failed to parse JSON body")
+		}
+
+		err = p.validator.Struct(definition)
+		validated = true
+	}
+
 	if p.isUpdateRole(method, endpoint) {
 		// TODO: @barco to implement when the UpdateGroup is implemented
 		validated = true
 	}
 
-	if p.isAssignPermissions(method, endpoint) {
+	if p.isAssignPermissions(method, endpoint) && isPermissionPatch(body) {
+		patches := make([]PermissionPatch, 0)
+		if err := json.Unmarshal(body, &patches); err != nil {
+			p.logger.Error("Json parsing error: ", err)
+			return ctx, nil, fmt.Errorf("failed to parse JSON body")
+		}
+
+		err = p.validator.Var(patches, "required,dive")
+		validated = true
+	} else if p.isAssignPermissions(method, endpoint) {
 		updatePermissions := new(UpdatePermissionsRequest)
 		if err := json.Unmarshal(body, updatePermissions); err != nil {
 			p.logger.Error("Json parsing error: ", err)
@@ -82,6 +102,14 @@ func (p *PayloadValidator) isCreateRole(method, endpoint string) bool {
 	return method == http.MethodPost && endpoint == ""
 }
 
+func (p *PayloadValidator) isCloneRole(method, endpoint string) bool {
+	return method == http.MethodPost && strings.HasSuffix(endpoint, "/clone")
+}
+
+func (p *PayloadValidator) isImportRole(method, endpoint string) bool {
+	return method == http.MethodPost && endpoint == "/import"
+}
+
 func (p *PayloadValidator) isUpdateRole(method, endpoint string) bool {
 	return method == http.MethodPatch && strings.HasPrefix(endpoint, "/")
 }
@@ -112,6 +112,20 @@ func TestValidate(t *testing.T) {
 			expectedResult: nil,
 			expectedError:  nil,
 		},
+		{
+			name:     "CloneRoleSuccess",
+			method:   http.MethodPost,
+			endpoint: "/mock-role-id/clone",
+			body: func() []byte {
+				role := new(Role)
+				role.Name = "mock-new-role-id"
+
+				marshal, _ := json.Marshal(role)
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
 		{
 			name:     "UpdateRoleSuccess",
 			method:   http.MethodPatch,
@@ -159,6 +173,28 @@ func TestValidate(t *testing.T) {
 			expectedResult: nil,
 			expectedError:  nil,
 		},
+		{
+			name:     "ImportRoleSuccess",
+			method:   http.MethodPost,
+			endpoint: "/import",
+			body: func() []byte {
+				marshal, _ := json.Marshal(RoleDefinition{Name: "mock-role-id", Entitlements: []string{"can_view::client:okta"}})
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:     "ImportRoleValidationError",
+			method:   http.MethodPost,
+			endpoint: "/import",
+			body: func() []byte {
+				marshal, _ := json.Marshal(RoleDefinition{Name: " ", Entitlements: []string{""}})
+				return marshal
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
 		{
 			name:     "NoMatch",
 			method:   http.MethodPost,
@@ -182,6 +218,19 @@ func TestValidate(t *testing.T) {
 			expectedResult: validator.ValidationErrors{},
 			expectedError:  nil,
 		},
+		{
+			name:     "CloneRoleValidationError",
+			method:   http.MethodPost,
+			endpoint: "/mock-role-id/clone",
+			body: func() []byte {
+				role := new(Role)
+
+				marshal, _ := json.Marshal(role)
+				return marshal
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
 		{
 			name:     "UpdateRoleValidationError",
 			method:   http.MethodPatch,
@@ -214,6 +263,33 @@ func TestValidate(t *testing.T) {
 			expectedResult: validator.ValidationErrors{},
 			expectedError:  nil,
 		},
+		{
+			name:     "PatchPermissionsSuccess",
+			method:   http.MethodPatch,
+			endpoint: "/mock-role-id/entitlements",
+			body: func() []byte {
+				marshal, _ := json.Marshal([]PermissionPatch{
+					{Op: "add", Relation: "mock-relation", Object: "mock-type:mock-object"},
+					{Op: "remove", Relation: "mock-relation", Object: "mock-type:mock-object"},
+				})
+				return marshal
+			},
+			expectedResult: nil,
+			expectedError:  nil,
+		},
+		{
+			name:     "PatchPermissionsValidationError",
+			method:   http.MethodPatch,
+			endpoint: "/mock-role-id/entitlements",
+			body: func() []byte {
+				marshal, _ := json.Marshal([]PermissionPatch{
+					{Op: "replace", Relation: "mock-relation", Object: "mock-type:mock-object"},
+				})
+				return marshal
+			},
+			expectedResult: validator.ValidationErrors{},
+			expectedError:  nil,
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
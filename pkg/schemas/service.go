@@ -76,6 +76,15 @@ func (s *Service) parseError(ctx context.Context, r *http.Response) *kClient.Gen
 
 	gerr := KratosError{Error: kClient.NewGenericErrorWithDefaults()}
 
+	// no response means kratos was never reached, e.g. connection refused or timeout
+	if r == nil {
+		gerr.Error.SetMessage("unable to reach kratos")
+		gerr.Error.SetReason("unable to reach kratos")
+		gerr.Error.SetCode(http.StatusServiceUnavailable)
+
+		return gerr.Error
+	}
+
 	defer r.Body.Close()
 	body, _ := io.ReadAll(r.Body)
 
@@ -102,10 +111,12 @@ func (s *Service) ListSchemas(ctx context.Context, size int64, token string) (*I
 		data.Error = s.parseError(ctx, rr)
 	}
 
-	if navTokens, err := types.ParseLinkTokens(rr.Header); err != nil {
-		s.logger.Warnf("failed parsing link header: %s", err)
-	} else {
-		data.Tokens = navTokens
+	if rr != nil {
+		if navTokens, err := types.ParseLinkTokens(rr.Header); err != nil {
+			s.logger.Warnf("failed parsing link header: %s", err)
+		} else {
+			data.Tokens = navTokens
+		}
 	}
 
 	data.IdentitySchemas = schemas
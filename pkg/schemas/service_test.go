@@ -223,6 +223,53 @@ func TestListSchemasFails(t *testing.T) {
 	}
 }
 
+func TestListSchemasFailsUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	ctx := context.Background()
+
+	cfg := new(Config)
+	cfg.K8s = mockCoreV1
+	cfg.Kratos = mockKratosIdentityAPI
+	cfg.Name = "schemas"
+	cfg.Namespace = "default"
+
+	identitySchemaRequest := kClient.IdentityAPIListIdentitySchemasRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, "schemas.Service.ListSchemas").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockTracer.EXPECT().Start(ctx, "schemas.Service.parseError").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().ListIdentitySchemas(ctx).Times(1).Return(identitySchemaRequest)
+	mockKratosIdentityAPI.EXPECT().ListIdentitySchemasExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("connection refused"))
+
+	is, err := NewService(cfg, mockAuthz, mockTracer, mockMonitor, mockLogger).ListSchemas(ctx, 10, "")
+
+	if is.Error == nil {
+		t.Fatal("expected is.Error to be not nil")
+	}
+
+	if *is.Error.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected code to be %v not  %v", http.StatusServiceUnavailable, *is.Error.Code)
+	}
+
+	if !reflect.DeepEqual(is.IdentitySchemas, make([]kClient.IdentitySchemaContainer, 0)) {
+		t.Fatalf("expected schemas to be empty not  %v", is.IdentitySchemas)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
 func TestListSchemasSuccessButEmpty(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -450,6 +497,53 @@ func TestGetSchemaFails(t *testing.T) {
 	}
 }
 
+func TestGetSchemaFailsUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockAuthz := NewMockAuthorizerInterface(ctrl)
+	mockCoreV1 := NewMockCoreV1Interface(ctrl)
+	mockKratosIdentityAPI := NewMockIdentityAPI(ctrl)
+	ctx := context.Background()
+
+	cfg := new(Config)
+	cfg.K8s = mockCoreV1
+	cfg.Kratos = mockKratosIdentityAPI
+	cfg.Name = "schemas"
+	cfg.Namespace = "default"
+
+	identitySchemaRequest := kClient.IdentityAPIGetIdentitySchemaRequest{
+		ApiService: mockKratosIdentityAPI,
+	}
+
+	mockLogger.EXPECT().Error(gomock.Any()).Times(1)
+	mockTracer.EXPECT().Start(ctx, "schemas.Service.GetSchema").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockTracer.EXPECT().Start(ctx, "schemas.Service.parseError").Times(1).Return(ctx, trace.SpanFromContext(ctx))
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchema(ctx, "fake").Times(1).Return(identitySchemaRequest)
+	mockKratosIdentityAPI.EXPECT().GetIdentitySchemaExecute(gomock.Any()).Times(1).Return(nil, nil, fmt.Errorf("connection refused"))
+
+	is, err := NewService(cfg, mockAuthz, mockTracer, mockMonitor, mockLogger).GetSchema(ctx, "fake")
+
+	if is.Error == nil {
+		t.Fatal("expected is.Error to be not nil")
+	}
+
+	if *is.Error.Code != int64(http.StatusServiceUnavailable) {
+		t.Fatalf("expected code to be %v not  %v", http.StatusServiceUnavailable, *is.Error.Code)
+	}
+
+	if *is.Error.Reason != "unable to reach kratos" {
+		t.Fatalf("expected reason to be %v not  %v", "unable to reach kratos", *is.Error.Reason)
+	}
+
+	if err == nil {
+		t.Fatal("expected error to be not nil")
+	}
+}
+
 func TestEdiSchemaSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
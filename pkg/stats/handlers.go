@@ -0,0 +1,88 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+// API exposes the dashboard counts, groups and roles are counted among the ones the caller can see
+type API struct {
+	service ServiceInterface
+
+	tracer  tracing.TracingInterface
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+func (a *API) RegisterEndpoints(mux *chi.Mux) {
+	mux.Get("/api/v0/stats", a.handleStats)
+}
+
+func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx, span := a.tracer.Start(r.Context(), "stats.API.handleStats")
+	defer span.End()
+
+	principal := authentication.PrincipalFromContext(ctx)
+
+	if principal == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: "no authenticated principal",
+				Status:  http.StatusUnauthorized,
+				Code:    types.CodeUnauthorized,
+			},
+		)
+
+		return
+	}
+
+	stats, err := a.service.GetStats(ctx, principal.Identifier())
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(
+			types.Response{
+				Message: err.Error(),
+				Status:  http.StatusInternalServerError,
+				Code:    types.CodeInternal,
+			},
+		)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Data:    stats,
+			Message: "Stats",
+			Status:  http.StatusOK,
+		},
+	)
+}
+
+func NewAPI(service ServiceInterface, tracer tracing.TracingInterface, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
+	a := new(API)
+
+	a.service = service
+
+	a.tracer = tracer
+	a.monitor = monitor
+	a.logger = logger
+
+	return a
+}
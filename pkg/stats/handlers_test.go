@@ -0,0 +1,111 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+//go:generate mockgen -build_flags=--mod=mod -package stats -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package stats -destination ./mock_interfaces.go -source=./interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package stats -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package stats -destination ./mock_tracing.go go.opentelemetry.io/otel/trace Tracer
+
+func TestHandleStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal authentication.PrincipalInterface
+		stats     *Stats
+		err       error
+		status    int
+	}{
+		{
+			name:   "no principal",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:      "counts",
+			principal: &authentication.UserPrincipal{Subject: "abc", Email: "joe@example.com"},
+			stats:     &Stats{Identities: 120, Groups: 7, Roles: 3},
+			status:    http.StatusOK,
+		},
+		{
+			name:      "openfga unreachable",
+			principal: &authentication.UserPrincipal{Subject: "abc", Email: "joe@example.com"},
+			err:       fmt.Errorf("connection refused"),
+			status:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockService := NewMockServiceInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/stats", nil)
+
+			if test.principal != nil {
+				req = req.WithContext(authentication.PrincipalContext(req.Context(), test.principal))
+
+				mockService.EXPECT().GetStats(gomock.Any(), test.principal.Identifier()).Times(1).Return(test.stats, test.err)
+			}
+
+			mockTracer.EXPECT().Start(gomock.Any(), "stats.API.handleStats").Times(1).DoAndReturn(
+				func(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+					return ctx, trace.SpanFromContext(ctx)
+				},
+			)
+
+			w := httptest.NewRecorder()
+			mux := chi.NewMux()
+			NewAPI(mockService, mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+
+			res := w.Result()
+
+			if res.StatusCode != test.status {
+				t.Fatalf("expected HTTP status code %v got %v", test.status, res.StatusCode)
+			}
+
+			if test.stats == nil {
+				return
+			}
+
+			rr := new(types.Response)
+			if err := json.NewDecoder(res.Body).Decode(rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			data, _ := json.Marshal(rr.Data)
+			stats := new(Stats)
+
+			if err := json.Unmarshal(data, stats); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			if !reflect.DeepEqual(stats, test.stats) {
+				t.Fatalf("expected stats to be %v got %v", test.stats, stats)
+			}
+		})
+	}
+}
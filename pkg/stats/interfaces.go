@@ -0,0 +1,28 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package stats
+
+import (
+	"context"
+)
+
+// ServiceInterface is the interface that each business logic service needs to implement
+type ServiceInterface interface {
+	GetStats(context.Context, string) (*Stats, error)
+}
+
+// IdentitiesCounterInterface counts the identities in kratos
+type IdentitiesCounterInterface interface {
+	CountIdentities(context.Context) (int64, error)
+}
+
+// GroupsCounterInterface counts the groups a user can see
+type GroupsCounterInterface interface {
+	CountGroups(context.Context, string) (int64, error)
+}
+
+// RolesCounterInterface counts the roles a user can see
+type RolesCounterInterface interface {
+	CountRoles(context.Context, string) (int64, error)
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package stats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+)
+
+// identitiesKey is the cache key of the identities count, the same for every user
+const identitiesKey = "identities"
+
+// Stats are the headline counts of the dashboard, groups and roles are the ones visible to the user
+type Stats struct {
+	Identities int64 `json:"identities"`
+	Groups     int64 `json:"groups"`
+	Roles      int64 `json:"roles"`
+}
+
+type cachedCount struct {
+	value   int64
+	expires time.Time
+}
+
+// Service counts identities, groups and roles, counts are cached for ttl, a ttl of 0 disables caching
+type Service struct {
+	identities IdentitiesCounterInterface
+	groups     GroupsCounterInterface
+	roles      RolesCounterInterface
+
+	ttl time.Duration
+
+	mu     sync.Mutex
+	counts map[string]cachedCount
+
+	now func() time.Time
+
+	tracer  trace.Tracer
+	monitor monitoring.MonitorInterface
+	logger  logging.LoggerInterface
+}
+
+// GetStats returns the counts for userID
+func (s *Service) GetStats(ctx context.Context, userID string) (*Stats, error) {
+	ctx, span := s.tracer.Start(ctx, "stats.Service.GetStats")
+	defer span.End()
+
+	stats := new(Stats)
+
+	var err error
+
+	stats.Identities, err = s.count(ctx, identitiesKey, s.identities.CountIdentities)
+
+	if err != nil {
+		s.logger.Errorf("failed counting identities: %s", err)
+		return nil, err
+	}
+
+	stats.Groups, err = s.count(
+		ctx,
+		"groups:"+userID,
+		func(ctx context.Context) (int64, error) { return s.groups.CountGroups(ctx, userID) },
+	)
+
+	if err != nil {
+		s.logger.Errorf("failed counting groups: %s", err)
+		return nil, err
+	}
+
+	stats.Roles, err = s.count(
+		ctx,
+		"roles:"+userID,
+		func(ctx context.Context) (int64, error) { return s.roles.CountRoles(ctx, userID) },
+	)
+
+	if err != nil {
+		s.logger.Errorf("failed counting roles: %s", err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// count returns the cached value of key or the one returned by counter, errors are not cached
+func (s *Service) count(ctx context.Context, key string, counter func(context.Context) (int64, error)) (int64, error) {
+	s.mu.Lock()
+	cached, ok := s.counts[key]
+	s.mu.Unlock()
+
+	if ok && s.now().Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	value, err := counter(ctx)
+
+	if err != nil || s.ttl <= 0 {
+		return value, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	// per user entries pile up, drop the expired ones on write
+	for k, c := range s.counts {
+		if !now.Before(c.expires) {
+			delete(s.counts, k)
+		}
+	}
+
+	s.counts[key] = cachedCount{value: value, expires: now.Add(s.ttl)}
+
+	return value, nil
+}
+
+func NewService(identities IdentitiesCounterInterface, groups GroupsCounterInterface, roles RolesCounterInterface, ttl time.Duration, tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *Service {
+	s := new(Service)
+
+	s.identities = identities
+	s.groups = groups
+	s.roles = roles
+
+	s.ttl = ttl
+	s.counts = make(map[string]cachedCount)
+	s.now = time.Now
+
+	s.monitor = monitor
+	s.tracer = tracer
+	s.logger = logger
+
+	return s
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Canonical Ltd.
+// SPDX-License-Identifier: AGPL-3.0
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServiceGetStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		elapsed  time.Duration
+		users    []string
+		reads    int
+		expected *Stats
+	}{
+		{
+			name:     "cached",
+			ttl:      time.Minute,
+			users:    []string{"joe", "joe"},
+			reads:    1,
+			expected: &Stats{Identities: 120, Groups: 7, Roles: 3},
+		},
+		{
+			name:     "expired",
+			ttl:      time.Minute,
+			elapsed:  time.Minute,
+			users:    []string{"joe", "joe"},
+			reads:    2,
+			expected: &Stats{Identities: 120, Groups: 7, Roles: 3},
+		},
+		{
+			name:     "caching disabled",
+			users:    []string{"joe", "joe"},
+			reads:    2,
+			expected: &Stats{Identities: 120, Groups: 7, Roles: 3},
+		},
+		{
+			name:     "groups and roles are cached per user",
+			ttl:      time.Minute,
+			users:    []string{"joe", "ann"},
+			reads:    1,
+			expected: &Stats{Identities: 120, Groups: 7, Roles: 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockIdentities := NewMockIdentitiesCounterInterface(ctrl)
+			mockGroups := NewMockGroupsCounterInterface(ctrl)
+			mockRoles := NewMockRolesCounterInterface(ctrl)
+
+			ctx := context.Background()
+			now := time.Now()
+
+			// the identities count is shared, groups and roles are read once per distinct user and read
+			userReads := test.reads
+			if test.users[0] != test.users[1] {
+				userReads = 2
+			}
+
+			mockTracer.EXPECT().Start(ctx, "stats.Service.GetStats").AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+			mockIdentities.EXPECT().CountIdentities(ctx).Times(test.reads).Return(test.expected.Identities, nil)
+			mockGroups.EXPECT().CountGroups(ctx, gomock.Any()).Times(userReads).Return(test.expected.Groups, nil)
+			mockRoles.EXPECT().CountRoles(ctx, gomock.Any()).Times(userReads).Return(test.expected.Roles, nil)
+
+			svc := NewService(mockIdentities, mockGroups, mockRoles, test.ttl, mockTracer, mockMonitor, mockLogger)
+			svc.now = func() time.Time { return now }
+
+			for _, user := range test.users {
+				stats, err := svc.GetStats(ctx, user)
+
+				if err != nil {
+					t.Fatalf("expected error to be nil got %v", err)
+				}
+
+				if !reflect.DeepEqual(stats, test.expected) {
+					t.Fatalf("expected stats to be %v got %v", test.expected, stats)
+				}
+
+				now = now.Add(test.elapsed)
+			}
+		})
+	}
+}
+
+func TestServiceGetStatsErrorsAreNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockIdentities := NewMockIdentitiesCounterInterface(ctrl)
+	mockGroups := NewMockGroupsCounterInterface(ctrl)
+	mockRoles := NewMockRolesCounterInterface(ctrl)
+
+	ctx := context.Background()
+
+	mockTracer.EXPECT().Start(ctx, "stats.Service.GetStats").AnyTimes().Return(ctx, trace.SpanFromContext(ctx))
+	mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any()).Times(1)
+
+	gomock.InOrder(
+		mockIdentities.EXPECT().CountIdentities(ctx).Return(int64(0), fmt.Errorf("kratos unavailable")),
+		mockIdentities.EXPECT().CountIdentities(ctx).Return(int64(120), nil),
+	)
+	mockGroups.EXPECT().CountGroups(ctx, "joe").Times(1).Return(int64(7), nil)
+	mockRoles.EXPECT().CountRoles(ctx, "joe").Times(1).Return(int64(3), nil)
+
+	svc := NewService(mockIdentities, mockGroups, mockRoles, time.Minute, mockTracer, mockMonitor, mockLogger)
+
+	if _, err := svc.GetStats(ctx, "joe"); err == nil {
+		t.Fatal("expected error not to be nil")
+	}
+
+	if stats, err := svc.GetStats(ctx, "joe"); err != nil || stats.Identities != 120 {
+		t.Fatalf("expected identities to be counted again got %v, %v", stats, err)
+	}
+}
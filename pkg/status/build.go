@@ -4,6 +4,7 @@
 package status
 
 import (
+	"runtime"
 	"runtime/debug"
 
 	"github.com/canonical/identity-platform-admin-ui/internal/version"
@@ -39,3 +40,28 @@ func gitRevision(settings []debug.BuildSetting) string {
 
 	return "n/a"
 }
+
+// VersionInfo describes the running build, values not injected at build time are reported as unknown
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+func versionInfo() *VersionInfo {
+	info := new(VersionInfo)
+	info.Version = version.Version
+	info.Commit = version.Commit
+	info.BuildDate = version.BuildDate
+	info.GoVersion = runtime.Version()
+
+	// fall back on the VCS metadata stamped by the go toolchain for builds without ldflags
+	if buildInfo, ok := debug.ReadBuildInfo(); ok && info.Commit == unknownValue {
+		if revision := gitRevision(buildInfo.Settings); revision != "n/a" {
+			info.Commit = revision
+		}
+	}
+
+	return info
+}
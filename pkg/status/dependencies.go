@@ -0,0 +1,100 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package status
+
+import (
+	"context"
+
+	hClient "github.com/ory/hydra-client-go/v2"
+	kClient "github.com/ory/kratos-client-go"
+)
+
+const (
+	KratosDependency  = "kratos"
+	HydraDependency   = "hydra"
+	OpenFGADependency = "openfga"
+)
+
+// Config holds the names of the dependencies that need to be reachable for the
+// application to be considered ready, the others are reported but don't affect readiness
+type Config struct {
+	RequiredDependencies []string
+}
+
+func NewConfig(required []string) *Config {
+	c := new(Config)
+
+	c.RequiredDependencies = required
+
+	return c
+}
+
+// KratosChecker pings the Kratos admin readiness endpoint
+type KratosChecker struct {
+	kratos kClient.MetadataAPI
+}
+
+func (c *KratosChecker) Check(ctx context.Context) error {
+	_, _, err := c.kratos.IsReady(ctx).Execute()
+
+	return err
+}
+
+func NewKratosChecker(kratos kClient.MetadataAPI) *KratosChecker {
+	c := new(KratosChecker)
+
+	c.kratos = kratos
+
+	return c
+}
+
+// HydraChecker pings the Hydra admin readiness endpoint
+type HydraChecker struct {
+	hydra hClient.MetadataApi
+}
+
+func (c *HydraChecker) Check(ctx context.Context) error {
+	_, _, err := c.hydra.IsReady(ctx).Execute()
+
+	return err
+}
+
+func NewHydraChecker(hydra hClient.MetadataApi) *HydraChecker {
+	c := new(HydraChecker)
+
+	c.hydra = hydra
+
+	return c
+}
+
+// OpenFGAChecker performs a tuple read on the configured store, the response
+// is discarded as only reachability matters
+type OpenFGAChecker struct {
+	ofga OpenFGAClientInterface
+}
+
+func (c *OpenFGAChecker) Check(ctx context.Context) error {
+	_, err := c.ofga.ReadTuples(ctx, "", "", "", "")
+
+	return err
+}
+
+// Details reads the authorization model in use, reporting the store and model IDs
+func (c *OpenFGAChecker) Details(ctx context.Context) (interface{}, error) {
+	info, err := c.ofga.AuthzInfo(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func NewOpenFGAChecker(ofga OpenFGAClientInterface) *OpenFGAChecker {
+	c := new(OpenFGAChecker)
+
+	c.ofga = ofga
+
+	return c
+}
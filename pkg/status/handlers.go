@@ -4,8 +4,11 @@
 package status
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.opentelemetry.io/otel/trace"
@@ -14,14 +17,44 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
 )
 
-const okValue = "ok"
+const (
+	okValue          = "ok"
+	unavailableValue = "unavailable"
+	unknownValue     = "unknown"
+
+	readinessTimeout = 5 * time.Second
+)
 
 type Status struct {
 	Status    string     `json:"status"`
 	BuildInfo *BuildInfo `json:"buildInfo"`
 }
 
+// DependencyStatus is the outcome of a single dependency check
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+	// Details is set by the dependencies implementing DependencyDetailerInterface
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Readiness aggregates the dependency checks, Status is unavailable if any
+// required dependency is down
+type Readiness struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+type dependency struct {
+	name     string
+	required bool
+	checker  DependencyCheckerInterface
+}
+
 type API struct {
+	dependencies []dependency
+
 	tracer trace.Tracer
 
 	monitor monitoring.MonitorInterface
@@ -30,10 +63,18 @@ type API struct {
 
 func (a *API) RegisterEndpoints(mux *chi.Mux) {
 	mux.Get("/api/v0/status", a.alive)
+	mux.Get("/api/v0/status/live", a.alive)
+	mux.Get("/api/v0/status/ready", a.ready)
 	mux.Get("/api/v0/version", a.version)
 
 }
 
+// RegisterDependency adds a dependency to the readiness checks, only required
+// dependencies make the readiness endpoint return 503 when down
+func (a *API) RegisterDependency(name string, checker DependencyCheckerInterface, required bool) {
+	a.dependencies = append(a.dependencies, dependency{name: name, required: required, checker: checker})
+}
+
 func (a *API) alive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -54,17 +95,73 @@ func (a *API) alive(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (a *API) version(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+func (a *API) ready(w http.ResponseWriter, r *http.Request) {
+	ctx, span := a.tracer.Start(r.Context(), "status.API.ready")
+	defer span.End()
 
-	info := new(BuildInfo)
-	if buildInfo := buildInfo(); buildInfo != nil {
-		info = buildInfo
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	rr := Readiness{
+		Status:       okValue,
+		Dependencies: make(map[string]DependencyStatus, len(a.dependencies)),
 	}
 
-	json.NewEncoder(w).Encode(info)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	wg.Add(len(a.dependencies))
+
+	for _, d := range a.dependencies {
+		go func(d dependency) {
+			defer wg.Done()
+
+			status := DependencyStatus{Status: okValue, Required: d.required}
+
+			var err error
+
+			if detailer, ok := d.checker.(DependencyDetailerInterface); ok {
+				status.Details, err = detailer.Details(ctx)
+			} else {
+				err = d.checker.Check(ctx)
+			}
+
+			if err != nil {
+				a.logger.Errorf("dependency %s is not ready: %s", d.name, err)
+
+				status.Status = unavailableValue
+				status.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			rr.Dependencies[d.name] = status
+
+			if status.Status != okValue && d.required {
+				rr.Status = unavailableValue
+			}
+		}(d)
+	}
+
+	wg.Wait()
+
+	statusCode := http.StatusOK
+	if rr.Status != okValue {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	json.NewEncoder(w).Encode(rr)
+}
+
+func (a *API) version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
+	json.NewEncoder(w).Encode(versionInfo())
 }
 
 func NewAPI(tracer trace.Tracer, monitor monitoring.MonitorInterface, logger logging.LoggerInterface) *API {
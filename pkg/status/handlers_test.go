@@ -6,20 +6,26 @@ package status
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
+
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/version"
 )
 
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_logger.go -source=../../internal/logging/interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_monitor.go -source=../../internal/monitoring/interfaces.go
+//go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_interfaces.go -source=./interfaces.go
 //go:generate mockgen -build_flags=--mod=mod -package status -destination ./mock_tracer.go 	go.opentelemetry.io/otel/trace Tracer
 
 func TestAliveOK(t *testing.T) {
@@ -51,3 +57,242 @@ func TestAliveOK(t *testing.T) {
 	}
 	assert.Equalf(t, "ok", receivedStatus.Status, "Expected %s, got %s", "ok", receivedStatus.Status)
 }
+
+func TestLiveOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/status/live", nil)
+	w := httptest.NewRecorder()
+
+	mockTracer.EXPECT().Start(gomock.Any(), gomock.Any()).Times(1).Return(context.TODO(), trace.SpanFromContext(req.Context()))
+
+	mux := chi.NewMux()
+	NewAPI(mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	receivedStatus := new(Status)
+	if err := json.NewDecoder(res.Body).Decode(receivedStatus); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "ok", receivedStatus.Status)
+}
+
+func TestReady(t *testing.T) {
+	type dependency struct {
+		name     string
+		required bool
+		err      error
+	}
+
+	tests := []struct {
+		name         string
+		dependencies []dependency
+		expected     int
+		status       string
+	}{
+		{
+			name: "all dependencies up",
+			dependencies: []dependency{
+				{name: KratosDependency, required: true},
+				{name: HydraDependency, required: true},
+				{name: OpenFGADependency, required: true},
+			},
+			expected: http.StatusOK,
+			status:   "ok",
+		},
+		{
+			name: "required dependency down",
+			dependencies: []dependency{
+				{name: KratosDependency, required: true, err: fmt.Errorf("connection refused")},
+				{name: HydraDependency, required: true},
+				{name: OpenFGADependency, required: true},
+			},
+			expected: http.StatusServiceUnavailable,
+			status:   "unavailable",
+		},
+		{
+			name: "optional dependency down",
+			dependencies: []dependency{
+				{name: KratosDependency, required: true},
+				{name: HydraDependency, required: false, err: fmt.Errorf("connection refused")},
+				{name: OpenFGADependency, required: true},
+			},
+			expected: http.StatusOK,
+			status:   "ok",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/status/ready", nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "status.API.ready").Times(1).Return(context.TODO(), trace.SpanFromContext(req.Context()))
+
+			api := NewAPI(mockTracer, mockMonitor, mockLogger)
+
+			for _, d := range test.dependencies {
+				mockChecker := NewMockDependencyCheckerInterface(ctrl)
+				mockChecker.EXPECT().Check(gomock.Any()).Times(1).Return(d.err)
+
+				if d.err != nil {
+					mockLogger.EXPECT().Errorf(gomock.Any(), d.name, d.err).Times(1)
+				}
+
+				api.RegisterDependency(d.name, mockChecker, d.required)
+			}
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+			res := w.Result()
+			defer res.Body.Close()
+
+			readiness := new(Readiness)
+			if err := json.NewDecoder(res.Body).Decode(readiness); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			assert.Equal(t, test.expected, res.StatusCode)
+			assert.Equal(t, test.status, readiness.Status)
+			assert.Len(t, readiness.Dependencies, len(test.dependencies))
+
+			for _, d := range test.dependencies {
+				s := readiness.Dependencies[d.name]
+
+				assert.Equal(t, d.required, s.Required)
+
+				if d.err != nil {
+					assert.Equal(t, "unavailable", s.Status)
+					assert.Equal(t, d.err.Error(), s.Error)
+				} else {
+					assert.Equal(t, "ok", s.Status)
+					assert.Empty(t, s.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyOpenFGADetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     *ofga.AuthzInfo
+		err      error
+		expected int
+	}{
+		{
+			name:     "model found",
+			info:     &ofga.AuthzInfo{StoreID: "01HQ2J8G3X4D8ZK7N2Y5V6W9QA", ModelID: "01HQ2JMD9F5RJ0S3Y9C4QX7V8T"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "model gone",
+			err:      ofga.ErrModelNotFound,
+			expected: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockLogger := NewMockLoggerInterface(ctrl)
+			mockMonitor := NewMockMonitorInterface(ctrl)
+			mockTracer := NewMockTracer(ctrl)
+			mockOpenFGA := NewMockOpenFGAClientInterface(ctrl)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v0/status/ready", nil)
+			w := httptest.NewRecorder()
+
+			mockTracer.EXPECT().Start(gomock.Any(), "status.API.ready").Times(1).Return(context.TODO(), trace.SpanFromContext(req.Context()))
+			mockOpenFGA.EXPECT().ReadTuples(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			mockOpenFGA.EXPECT().AuthzInfo(gomock.Any()).Times(1).Return(test.info, test.err)
+
+			if test.err != nil {
+				mockLogger.EXPECT().Errorf(gomock.Any(), OpenFGADependency, test.err).Times(1)
+			}
+
+			api := NewAPI(mockTracer, mockMonitor, mockLogger)
+			api.RegisterDependency(OpenFGADependency, NewOpenFGAChecker(mockOpenFGA), true)
+
+			mux := chi.NewMux()
+			api.RegisterEndpoints(mux)
+
+			mux.ServeHTTP(w, req)
+			res := w.Result()
+			defer res.Body.Close()
+
+			readiness := new(Readiness)
+			if err := json.NewDecoder(res.Body).Decode(readiness); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			assert.Equal(t, test.expected, res.StatusCode)
+
+			details := readiness.Dependencies[OpenFGADependency].Details
+
+			if test.err != nil {
+				assert.Nil(t, details)
+				return
+			}
+
+			assert.Equal(
+				t,
+				map[string]interface{}{"store_id": test.info.StoreID, "model_id": test.info.ModelID, "model_created_at": "0001-01-01T00:00:00Z"},
+				details,
+			)
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockLogger := NewMockLoggerInterface(ctrl)
+	mockMonitor := NewMockMonitorInterface(ctrl)
+	mockTracer := NewMockTracer(ctrl)
+
+	commit, buildDate := version.Commit, version.BuildDate
+	version.Commit, version.BuildDate = "0f1e2d3c", "2024-06-01T10:00:00Z"
+	defer func() { version.Commit, version.BuildDate = commit, buildDate }()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/version", nil)
+	w := httptest.NewRecorder()
+
+	mux := chi.NewMux()
+	NewAPI(mockTracer, mockMonitor, mockLogger).RegisterEndpoints(mux)
+
+	mux.ServeHTTP(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	info := new(VersionInfo)
+	if err := json.NewDecoder(res.Body).Decode(info); err != nil {
+		t.Fatalf("expected error to be nil got %v", err)
+	}
+
+	assert.Equal(t, VersionInfo{Version: version.Version, Commit: "0f1e2d3c", BuildDate: "2024-06-01T10:00:00Z", GoVersion: runtime.Version()}, *info)
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package status
+
+import (
+	"context"
+
+	openfga "github.com/openfga/go-sdk"
+
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+)
+
+// DependencyCheckerInterface is implemented by every dependency the readiness endpoint probes
+type DependencyCheckerInterface interface {
+	Check(context.Context) error
+}
+
+// DependencyDetailerInterface is implemented by the checkers reporting more than reachability,
+// Details replaces Check and its result is added to the readiness response
+type DependencyDetailerInterface interface {
+	Details(context.Context) (interface{}, error)
+}
+
+type OpenFGAClientInterface interface {
+	ReadTuples(context.Context, string, string, string, string) (*openfga.ReadResponse, error)
+	AuthzInfo(context.Context) (*ofga.AuthzInfo, error)
+}
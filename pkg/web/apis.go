@@ -0,0 +1,83 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIsConfig switches whole API groups on and off, the endpoints of a disabled group are not
+// registered on either the v0 or the v1 API so they answer 404 like any unknown route
+type APIsConfig struct {
+	Groups       bool
+	Roles        bool
+	Entitlements bool
+}
+
+// GroupsEnabled reports if the groups endpoints are served, they are if c is nil
+func (c *APIsConfig) GroupsEnabled() bool {
+	return c == nil || c.Groups
+}
+
+// RolesEnabled reports if the roles endpoints are served, they are if c is nil
+func (c *APIsConfig) RolesEnabled() bool {
+	return c == nil || c.Roles
+}
+
+// EntitlementsEnabled reports if the entitlements and permissions endpoints are served, they are if c is nil
+func (c *APIsConfig) EntitlementsEnabled() bool {
+	return c == nil || c.Entitlements
+}
+
+// disabledRoutes lists the patterns of the disabled groups, they need to be caught explicitly as
+// the v1 API is mounted on /api/ and would otherwise run authentication and authorization first
+func (c *APIsConfig) disabledRoutes() []string {
+	routes := make([]string, 0)
+
+	if !c.GroupsEnabled() {
+		routes = append(
+			routes,
+			"/api/v0/groups",
+			"/api/v0/groups/*",
+			"/api/v0/identities/{id:.+}/move-group",
+			"/api/v1/groups",
+			"/api/v1/groups/*",
+		)
+	}
+
+	if !c.RolesEnabled() {
+		routes = append(routes, "/api/v0/roles", "/api/v0/roles/*", "/api/v1/roles", "/api/v1/roles/*")
+	}
+
+	if !c.EntitlementsEnabled() {
+		routes = append(
+			routes,
+			"/api/v0/permissions/*",
+			"/api/v0/check",
+			"/api/v1/entitlements",
+			"/api/v1/entitlements/*",
+		)
+	}
+
+	return routes
+}
+
+// registerDisabled answers 404 on the routes of the disabled groups
+func (c *APIsConfig) registerDisabled(mux *chi.Mux) {
+	for _, route := range c.disabledRoutes() {
+		mux.Handle(route, http.NotFoundHandler())
+	}
+}
+
+func NewAPIsConfig(groups, roles, entitlements bool) *APIsConfig {
+	c := new(APIsConfig)
+
+	c.Groups = groups
+	c.Roles = roles
+	c.Entitlements = entitlements
+
+	return c
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	ih "github.com/canonical/identity-platform-admin-ui/internal/hydra"
+	ik "github.com/canonical/identity-platform-admin-ui/internal/kratos"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/internal/mail"
+	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
+	ofga "github.com/canonical/identity-platform-admin-ui/internal/openfga"
+	"github.com/canonical/identity-platform-admin-ui/internal/pool"
+	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+	"github.com/canonical/identity-platform-admin-ui/pkg/idp"
+	"github.com/canonical/identity-platform-admin-ui/pkg/rules"
+	"github.com/canonical/identity-platform-admin-ui/pkg/schemas"
+	"github.com/canonical/identity-platform-admin-ui/pkg/ui"
+)
+
+func TestNewRouterDisabledAPIs(t *testing.T) {
+	kratos := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer kratos.Close()
+
+	logger := logging.NewNoopLogger()
+	tracer := tracing.NewNoopTracer()
+	monitor := monitoring.NewNoopMonitor("test", logger)
+
+	wpool := pool.NewWorkerPool(1, tracer, monitor, logger)
+	defer wpool.Stop()
+
+	noop := ofga.NewNoopClient(tracer, monitor, logger)
+
+	external := NewExternalClientsConfig(
+		ih.NewClient("http://hydra.invalid", false, time.Second, time.Second, nil),
+		ik.NewClient(kratos.URL, false, time.Second, time.Second, nil),
+		ik.NewClient(kratos.URL, false, time.Second, time.Second, nil),
+		nil,
+		noop,
+		authorization.NewAuthorizer(noop, wpool, tracer, monitor, logger),
+	)
+
+	config := NewRouterConfig(
+		"",
+		false,
+		&idp.Config{},
+		&schemas.Config{},
+		&rules.Config{},
+		&ui.Config{DistFS: fstest.MapFS{}},
+		external,
+		&authentication.Config{},
+		mail.NewConfig("localhost", 25, "", "", "admin@example.com", 1, ""),
+		NewO11yConfig(tracer, monitor, logger),
+	)
+	config.SetAPIsConfig(NewAPIsConfig(false, true, true))
+
+	router := NewRouter(config, wpool)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{name: "groups list", method: http.MethodGet, path: "/api/v0/groups", expected: http.StatusNotFound},
+		{name: "group detail", method: http.MethodGet, path: "/api/v0/groups/admins", expected: http.StatusNotFound},
+		{name: "group creation", method: http.MethodPost, path: "/api/v0/groups", expected: http.StatusNotFound},
+		{name: "move group", method: http.MethodPost, path: "/api/v0/identities/joe/move-group", expected: http.StatusNotFound},
+		{name: "v1 groups", method: http.MethodGet, path: "/api/v1/groups", expected: http.StatusNotFound},
+		{name: "identities", method: http.MethodGet, path: "/api/v0/identities", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expected, w.Code)
+		})
+	}
+}
+
+func TestAPIsConfigNil(t *testing.T) {
+	var c *APIsConfig
+
+	assert.True(t, c.GroupsEnabled())
+	assert.True(t, c.RolesEnabled())
+	assert.True(t, c.EntitlementsEnabled())
+	assert.Empty(t, c.disabledRoutes())
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+)
+
+func TestMiddlewareBodyLimit(t *testing.T) {
+	config := NewBodyLimitConfig(16).WithOverride(http.MethodPost, "/api/v0/identities/import", 64)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		chunked bool
+		reached bool
+	}{
+		{
+			name:    "under limit",
+			method:  http.MethodPatch,
+			path:    "/api/v0/groups/admin/entitlements",
+			body:    strings.Repeat("a", 16),
+			reached: true,
+		},
+		{
+			name:   "over limit",
+			method: http.MethodPatch,
+			path:   "/api/v0/groups/admin/entitlements",
+			body:   strings.Repeat("a", 17),
+		},
+		{
+			name:    "chunked over limit",
+			method:  http.MethodPatch,
+			path:    "/api/v0/groups/admin/entitlements",
+			body:    strings.Repeat("a", 17),
+			chunked: true,
+		},
+		{
+			name:    "chunked under limit",
+			method:  http.MethodPatch,
+			path:    "/api/v0/groups/admin/entitlements",
+			body:    "abc",
+			chunked: true,
+			reached: true,
+		},
+		{
+			name:    "route override",
+			method:  http.MethodPost,
+			path:    "/api/v0/identities/import",
+			body:    strings.Repeat("a", 64),
+			reached: true,
+		},
+		{
+			name:   "over route override",
+			method: http.MethodPost,
+			path:   "/api/v0/identities/import",
+			body:   strings.Repeat("a", 65),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reached := false
+			received := ""
+
+			handler := middlewareBodyLimit(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+
+				body, _ := io.ReadAll(r.Body)
+				received = string(body)
+			}))
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.chunked {
+				req.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.reached, reached, "handler reached")
+
+			if test.reached {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, test.body, received)
+				return
+			}
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+			rr := new(types.Response)
+			if err := json.Unmarshal(w.Body.Bytes(), rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			assert.Equal(t, types.CodePayloadTooLarge, rr.Code)
+			assert.Contains(t, rr.Message, "exceeds")
+		})
+	}
+}
+
+func TestBodyLimitConfigDisabled(t *testing.T) {
+	var config *BodyLimitConfig
+
+	assert.False(t, config.Enabled())
+	assert.False(t, NewBodyLimitConfig(0).Enabled())
+	assert.True(t, NewBodyLimitConfig(1024).Enabled())
+}
@@ -21,14 +21,19 @@ import (
 type OpenFGAClientInterface interface {
 	ReadModel(context.Context) (*fga.AuthorizationModel, error)
 	CompareModel(context.Context, fga.AuthorizationModel) (bool, error)
+	ReloadModel(context.Context, string) error
+	AuthzInfo(context.Context) (*ofga.AuthzInfo, error)
 	WriteTuple(context.Context, string, string, string) error
 	DeleteTuple(context.Context, string, string, string) error
 	Check(context.Context, string, string, string, ...ofga.Tuple) (bool, error)
 	ListObjects(context.Context, string, string, string) ([]string, error)
 	WriteTuples(context.Context, ...ofga.Tuple) error
 	DeleteTuples(context.Context, ...ofga.Tuple) error
+	WriteAndDeleteTuples(context.Context, []ofga.Tuple, []ofga.Tuple) error
 	BatchCheck(context.Context, ...ofga.Tuple) (bool, error)
+	BatchCheckDetailed(context.Context, ...ofga.Tuple) ([]ofga.CheckResult, error)
 	ReadTuples(context.Context, string, string, string, string) (*openfga.ReadResponse, error)
+	ReadTuplesPage(context.Context, string, string, string, string, int32) (*openfga.ReadResponse, error)
 }
 
 type AuthorizerClientInterface = *authorization.Authorizer
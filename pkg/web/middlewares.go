@@ -4,27 +4,276 @@
 package web
 
 import (
+	"bytes"
+	"compress/gzip"
 	"net/http"
+	"strconv"
+	"strings"
 
 	cors "github.com/go-chi/cors"
 )
 
-func middlewareCORS(origins []string) func(http.Handler) http.Handler {
+// CORSConfig holds the cross origin policy of the API, with no AllowedOrigins
+// no CORS headers are sent and browsers only allow same origin requests
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+}
+
+// Enabled reports if any cross origin is allowed
+func (c *CORSConfig) Enabled() bool {
+	return c != nil && len(c.AllowedOrigins) > 0
+}
+
+func NewCORSConfig(origins, methods, headers []string, credentials bool) *CORSConfig {
+	c := new(CORSConfig)
+
+	c.AllowedOrigins = origins
+	c.AllowedMethods = methods
+	c.AllowedHeaders = headers
+	c.AllowCredentials = credentials
+
+	return c
+}
+
+// middlewareCORS answers preflight requests itself, they never reach the handlers
+func middlewareCORS(config *CORSConfig) func(http.Handler) http.Handler {
 	return cors.Handler(
 		cors.Options{
-			AllowedOrigins: origins,
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-				http.MethodPut,
-				http.MethodPatch,
-				http.MethodDelete,
-				http.MethodOptions,
-			},
-			AllowedHeaders:   []string{"*"},
-			AllowCredentials: true,
+			AllowedOrigins:   config.AllowedOrigins,
+			AllowedMethods:   config.AllowedMethods,
+			AllowedHeaders:   config.AllowedHeaders,
+			AllowCredentials: config.AllowCredentials,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		},
 	)
 }
+
+// GzipConfig holds the response compression settings, responses smaller than
+// MinSize bytes are sent uncompressed as gzip would barely shrink them
+type GzipConfig struct {
+	MinSize int
+
+	enabled bool
+}
+
+// Enabled reports if responses can be compressed
+func (c *GzipConfig) Enabled() bool {
+	return c != nil && c.enabled
+}
+
+func NewGzipConfig(enabled bool, minSize int) *GzipConfig {
+	c := new(GzipConfig)
+
+	c.enabled = enabled
+	c.MinSize = max(minSize, 0)
+
+	return c
+}
+
+// content types that are already compressed, gzipping them wastes cpu for no gain
+var gzipSkipContentTypes = []string{
+	"application/gzip",
+	"application/zip",
+	"application/x-gzip",
+	"application/zstd",
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+}
+
+// paths never compressed, the prometheus scraper negotiates its own encoding
+var gzipSkipPaths = []string{
+	"/api/v0/metrics",
+}
+
+// middlewareGzip compresses responses for clients accepting gzip once the body
+// grows past the configured threshold
+func middlewareGzip(config *GzipConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, path := range gzipSkipPaths {
+				if strings.HasSuffix(r.URL.Path, path) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			// the response depends on the header even when it ends up uncompressed
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: config.MinSize}
+			defer gw.Close()
+
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+
+			// gzip;q=0 explicitly refuses the encoding
+			if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+				weight, err := strconv.ParseFloat(q, 64)
+				return err == nil && weight > 0
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// gzipResponseWriter buffers the body until it either reaches minSize, at which point
+// compression starts, or the handler returns, at which point it is sent as is
+type gzipResponseWriter struct {
+	http.ResponseWriter
+
+	minSize int
+	status  int
+	buf     bytes.Buffer
+
+	// decided is set once the response has been committed, compressed or not
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf.Write(b)
+
+	if w.buf.Len() < w.minSize {
+		return len(b), nil
+	}
+
+	if err := w.commit(w.compressible()); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// Flush commits the response so streaming handlers are not held back by the buffer
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		w.commit(w.buf.Len() >= w.minSize && w.compressible())
+	}
+
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close sends whatever is left in the buffer and terminates the gzip stream
+func (w *gzipResponseWriter) Close() error {
+	if !w.decided {
+		// below threshold, send the body uncompressed
+		if err := w.commit(false); err != nil {
+			return err
+		}
+	}
+
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+
+	return nil
+}
+
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *gzipResponseWriter) compressible() bool {
+	header := w.Header()
+
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	if w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(w.buf.Bytes())
+	}
+
+	for _, skip := range gzipSkipContentTypes {
+		if strings.HasPrefix(contentType, skip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w *gzipResponseWriter) commit(compress bool) error {
+	w.decided = true
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if compress {
+		header := w.Header()
+		header.Set("Content-Encoding", "gzip")
+		// the length set by the handler refers to the uncompressed body
+		header.Del("Content-Length")
+
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+
+	w.buf.Reset()
+
+	return err
+}
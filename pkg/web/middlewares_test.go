@@ -0,0 +1,211 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareCORS(t *testing.T) {
+	config := NewCORSConfig(
+		[]string{"https://admin.example.com"},
+		[]string{http.MethodGet, http.MethodPatch},
+		[]string{"Content-Type"},
+		true,
+	)
+
+	tests := []struct {
+		name    string
+		method  string
+		origin  string
+		headers map[string]string
+		reached bool
+		allowed bool
+	}{
+		{
+			name:    "allowed origin",
+			method:  http.MethodGet,
+			origin:  "https://admin.example.com",
+			reached: true,
+			allowed: true,
+		},
+		{
+			name:    "disallowed origin",
+			method:  http.MethodGet,
+			origin:  "https://evil.example.com",
+			reached: true,
+		},
+		{
+			name:    "preflight",
+			method:  http.MethodOptions,
+			origin:  "https://admin.example.com",
+			headers: map[string]string{"Access-Control-Request-Method": http.MethodPatch, "Access-Control-Request-Headers": "Content-Type"},
+			allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reached := false
+			handler := middlewareCORS(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+
+			req := httptest.NewRequest(test.method, "/api/v0/identities", nil)
+			req.Header.Set("Origin", test.origin)
+
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, test.reached, reached, "handler reached")
+
+			if !test.allowed {
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+				return
+			}
+
+			assert.Equal(t, test.origin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+			if test.method == http.MethodOptions {
+				assert.Equal(t, http.MethodPatch, w.Header().Get("Access-Control-Allow-Methods"))
+			}
+		})
+	}
+}
+
+func TestCORSConfigDisabledByDefault(t *testing.T) {
+	var config *CORSConfig
+
+	assert.False(t, config.Enabled())
+	assert.False(t, NewCORSConfig(nil, []string{http.MethodGet}, nil, false).Enabled())
+	assert.True(t, NewCORSConfig([]string{"https://admin.example.com"}, nil, nil, false).Enabled())
+}
+
+func TestMiddlewareGzip(t *testing.T) {
+	config := NewGzipConfig(true, 64)
+
+	large := strings.Repeat(`{"id":"identity"}`, 20)
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		compressed     bool
+	}{
+		{
+			name:           "gzip accepted",
+			path:           "/api/v0/identities",
+			acceptEncoding: "gzip, deflate, br",
+			contentType:    "application/json",
+			body:           large,
+			compressed:     true,
+		},
+		{
+			name:        "no accept encoding",
+			path:        "/api/v0/identities",
+			contentType: "application/json",
+			body:        large,
+		},
+		{
+			name:           "gzip refused",
+			path:           "/api/v0/identities",
+			acceptEncoding: "gzip;q=0, br",
+			contentType:    "application/json",
+			body:           large,
+		},
+		{
+			name:           "below threshold",
+			path:           "/api/v0/identities",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           `{"id":"identity"}`,
+		},
+		{
+			name:           "already compressed content type",
+			path:           "/ui/logo.png",
+			acceptEncoding: "gzip",
+			contentType:    "image/png",
+			body:           large,
+		},
+		{
+			name:           "metrics endpoint",
+			path:           "/api/v0/metrics",
+			acceptEncoding: "gzip",
+			contentType:    "text/plain",
+			body:           large,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := middlewareGzip(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", test.contentType)
+				w.WriteHeader(http.StatusCreated)
+
+				// write in chunks so the threshold is crossed mid response
+				for i := 0; i < len(test.body); i += 10 {
+					w.Write([]byte(test.body[i:min(i+10, len(test.body))]))
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+
+			if test.path != "/api/v0/metrics" {
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			}
+
+			if !test.compressed {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+				assert.Equal(t, test.body, w.Body.String())
+				return
+			}
+
+			assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+			assert.Less(t, w.Body.Len(), len(test.body))
+
+			r, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("expected gzip body, got error %v", err)
+			}
+
+			body, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed reading gzip body: %v", err)
+			}
+
+			assert.Equal(t, test.body, string(body))
+		})
+	}
+}
+
+func TestGzipConfigDisabledByDefault(t *testing.T) {
+	var config *GzipConfig
+
+	assert.False(t, config.Enabled())
+	assert.False(t, NewGzipConfig(false, 1024).Enabled())
+	assert.True(t, NewGzipConfig(true, 1024).Enabled())
+}
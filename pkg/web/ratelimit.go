@@ -0,0 +1,180 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/internal/logging"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+const (
+	// visitors not seen for this long get their bucket dropped
+	rateLimitIdleTimeout = 10 * time.Minute
+	rateLimitCleanupFreq = time.Minute
+)
+
+// RateLimitConfig holds the token bucket parameters applied to every client,
+// a RequestsPerSecond of 0 disables rate limiting
+type RateLimitConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+func NewRateLimitConfig(rps float64, burst int) *RateLimitConfig {
+	c := new(RateLimitConfig)
+
+	c.RequestsPerSecond = rps
+	c.Burst = burst
+
+	return c
+}
+
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter is a token bucket rate limiting middleware keyed by the authenticated
+// principal, falling back to the remote IP for unauthenticated requests
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	// exemptEndpoints are never limited, matched against the whole request path
+	exemptEndpoints map[string]bool
+	// keyByIP ignores the principal, set when authentication is disabled as every request
+	// then carries the same principal
+	keyByIP bool
+
+	visitors    map[string]*visitor
+	lastCleanup time.Time
+	mu          sync.Mutex
+
+	logger logging.LoggerInterface
+}
+
+// SetExemptEndpoints lets requests to endpoints through without consuming tokens
+func (l *RateLimiter) SetExemptEndpoints(endpoints ...string) {
+	for _, endpoint := range endpoints {
+		l.exemptEndpoints[endpoint] = true
+	}
+}
+
+// SetKeyByIP keys the buckets by remote IP only, principals are ignored
+func (l *RateLimiter) SetKeyByIP(keyByIP bool) {
+	l.keyByIP = keyByIP
+}
+
+func (l *RateLimiter) key(r *http.Request) string {
+	if principal := authentication.PrincipalFromContext(r.Context()); principal != nil && !l.keyByIP {
+		return fmt.Sprintf("principal:%s", principal.Identifier())
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return fmt.Sprintf("ip:%s", host)
+}
+
+func (l *RateLimiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastCleanup) > rateLimitCleanupFreq {
+		for k, v := range l.visitors {
+			if now.Sub(v.lastSeen) > rateLimitIdleTimeout {
+				delete(l.visitors, k)
+			}
+		}
+
+		l.lastCleanup = now
+	}
+
+	v, ok := l.visitors[key]
+
+	if !ok {
+		v = &visitor{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.visitors[key] = v
+	}
+
+	v.lastSeen = now
+
+	return v.limiter
+}
+
+func (l *RateLimiter) error(retryAfter time.Duration, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	json.NewEncoder(w).Encode(
+		types.Response{
+			Status:  http.StatusTooManyRequests,
+			Message: "rate limit exceeded",
+			Code:    types.CodeRateLimited,
+		},
+	)
+}
+
+// RateLimit returns the middleware, mount it after authentication so the principal is known
+// and before authorization so rejected requests don't cost any OpenFGA call
+func (l *RateLimiter) RateLimit() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if l.exemptEndpoints[r.URL.Path] {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				key := l.key(r)
+				reservation := l.limiter(key).Reserve()
+
+				if delay := reservation.Delay(); delay > 0 {
+					// give the token back as the request is not going to be served
+					reservation.Cancel()
+
+					l.logger.Debugf("rate limit exceeded for %s", key)
+					l.error(delay, w)
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+			},
+		)
+	}
+}
+
+func NewRateLimiter(config *RateLimitConfig, logger logging.LoggerInterface) *RateLimiter {
+	l := new(RateLimiter)
+
+	l.limit = rate.Limit(config.RequestsPerSecond)
+	// a bucket needs at least one token or every request is rejected
+	l.burst = max(config.Burst, 1)
+	l.visitors = make(map[string]*visitor)
+	l.exemptEndpoints = make(map[string]bool)
+	l.lastCleanup = time.Now()
+
+	l.logger = logger
+
+	return l
+}
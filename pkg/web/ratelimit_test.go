@@ -0,0 +1,113 @@
+// Copyright 2024 Canonical Ltd
+// SPDX-License-Identifier: AGPL-3.0
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
+	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
+)
+
+func TestRateLimiterRejectsOverBurst(t *testing.T) {
+	limiter := NewRateLimiter(NewRateLimitConfig(1, 2), zap.NewNop().Sugar())
+	handler := limiter.RateLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		codes = append(codes, w.Code)
+
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+			rr := new(types.Response)
+			if err := json.Unmarshal(w.Body.Bytes(), rr); err != nil {
+				t.Fatalf("expected error to be nil got %v", err)
+			}
+
+			assert.Equal(t, types.CodeRateLimited, rr.Code)
+		}
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestRateLimiterKeysByPrincipal(t *testing.T) {
+	limiter := NewRateLimiter(NewRateLimitConfig(1, 1), zap.NewNop().Sugar())
+	handler := limiter.RateLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, principal := range []string{"joe@example.com", "jane@example.com"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		req = req.WithContext(
+			authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: principal}),
+		)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "principal %s should have its own bucket", principal)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "unauthenticated request should fall back to the remote IP bucket")
+}
+
+func TestRateLimiterExemptEndpoints(t *testing.T) {
+	limiter := NewRateLimiter(NewRateLimitConfig(1, 1), zap.NewNop().Sugar())
+	limiter.SetExemptEndpoints("/api/v0/status")
+	handler := limiter.RateLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/status", nil)
+		req.RemoteAddr = "10.0.0.1:4321"
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "exempt endpoint should never be limited")
+	}
+}
+
+func TestRateLimiterKeyByIP(t *testing.T) {
+	limiter := NewRateLimiter(NewRateLimitConfig(1, 1), zap.NewNop().Sugar())
+	limiter.SetKeyByIP(true)
+	handler := limiter.RateLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	codes := make([]int, 0)
+
+	// with authentication disabled every request carries the same principal
+	for _, remoteAddr := range []string{"10.0.0.1:4321", "10.0.0.2:4321", "10.0.0.1:4322"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v0/identities", nil)
+		req.RemoteAddr = remoteAddr
+		req = req.WithContext(
+			authentication.PrincipalContext(req.Context(), &authentication.UserPrincipal{Email: "anonymous"}),
+		)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
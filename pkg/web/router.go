@@ -5,6 +5,8 @@ package web
 
 import (
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/go-chi/chi/v5"
@@ -12,7 +14,11 @@ import (
 
 	v1 "github.com/canonical/rebac-admin-ui-handlers/v1"
 
+	"github.com/canonical/identity-platform-admin-ui/internal/audit"
 	"github.com/canonical/identity-platform-admin-ui/internal/authorization"
+	"github.com/canonical/identity-platform-admin-ui/internal/events"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/requestid"
+	"github.com/canonical/identity-platform-admin-ui/internal/http/types"
 	"github.com/canonical/identity-platform-admin-ui/internal/logging"
 	"github.com/canonical/identity-platform-admin-ui/internal/mail"
 	"github.com/canonical/identity-platform-admin-ui/internal/monitoring"
@@ -20,17 +26,21 @@ import (
 	"github.com/canonical/identity-platform-admin-ui/internal/pool"
 	"github.com/canonical/identity-platform-admin-ui/internal/tracing"
 	"github.com/canonical/identity-platform-admin-ui/internal/validation"
+	"github.com/canonical/identity-platform-admin-ui/pkg/admin"
 	"github.com/canonical/identity-platform-admin-ui/pkg/authentication"
 	"github.com/canonical/identity-platform-admin-ui/pkg/clients"
 	"github.com/canonical/identity-platform-admin-ui/pkg/entitlements"
 	"github.com/canonical/identity-platform-admin-ui/pkg/groups"
 	"github.com/canonical/identity-platform-admin-ui/pkg/identities"
 	"github.com/canonical/identity-platform-admin-ui/pkg/idp"
+	"github.com/canonical/identity-platform-admin-ui/pkg/me"
 	"github.com/canonical/identity-platform-admin-ui/pkg/metrics"
+	"github.com/canonical/identity-platform-admin-ui/pkg/permissions"
 	"github.com/canonical/identity-platform-admin-ui/pkg/resources"
 	"github.com/canonical/identity-platform-admin-ui/pkg/roles"
 	"github.com/canonical/identity-platform-admin-ui/pkg/rules"
 	"github.com/canonical/identity-platform-admin-ui/pkg/schemas"
+	"github.com/canonical/identity-platform-admin-ui/pkg/stats"
 	"github.com/canonical/identity-platform-admin-ui/pkg/status"
 	"github.com/canonical/identity-platform-admin-ui/pkg/ui"
 )
@@ -38,6 +48,8 @@ import (
 type RouterConfig struct {
 	contextPath              string
 	payloadValidationEnabled bool
+	redactPII                bool
+	piiKeys                  []string
 	idp                      *idp.Config
 	schemas                  *schemas.Config
 	rules                    *rules.Config
@@ -45,9 +57,107 @@ type RouterConfig struct {
 	external                 ExternalClientsConfigInterface
 	oauth2                   *authentication.Config
 	mail                     *mail.Config
+	status                   *status.Config
+	rateLimit                *RateLimitConfig
+	cors                     *CORSConfig
+	gzip                     *GzipConfig
+	bodyLimit                *BodyLimitConfig
+	dispatcher               events.DispatcherInterface
+	identitySearch           *identities.SearchConfig
+	identityTraits           *identities.TraitsMapping
+	identitySchemaTTL        time.Duration
+	identityAllowedSchemas   []string
+	pageSize                 *types.PageSizeConfig
+	cursors                  *types.CursorConfig
+	apis                     *APIsConfig
+	statsTTL                 time.Duration
+	groupsTrash              *groups.TrashConfig
+	groupsPatchRollback      bool
+	logSampling              *logging.SamplingConfig
+	auditLogSize             int
 	olly                     O11yConfigInterface
 }
 
+// SetPIIRedaction redacts piiKeys, on top of the default ones, from the upstream payloads logged by the services
+func (c *RouterConfig) SetPIIRedaction(redact bool, piiKeys []string) {
+	c.redactPII = redact
+	c.piiKeys = piiKeys
+}
+
+// SetStatusConfig sets the dependencies failing the readiness check, none are required by default
+func (c *RouterConfig) SetStatusConfig(status *status.Config) {
+	c.status = status
+}
+
+// SetRateLimitConfig rate limits the API, requests aren't limited by default
+func (c *RouterConfig) SetRateLimitConfig(rateLimit *RateLimitConfig) {
+	c.rateLimit = rateLimit
+}
+
+// SetCORSConfig sets the CORS policy, CORS headers aren't sent by default
+func (c *RouterConfig) SetCORSConfig(cors *CORSConfig) {
+	c.cors = cors
+}
+
+// SetGzipConfig compresses the responses, they aren't by default
+func (c *RouterConfig) SetGzipConfig(gzip *GzipConfig) {
+	c.gzip = gzip
+}
+
+// SetBodyLimitConfig bounds the request payloads, they aren't by default
+func (c *RouterConfig) SetBodyLimitConfig(bodyLimit *BodyLimitConfig) {
+	c.bodyLimit = bodyLimit
+}
+
+// SetDispatcher sets where group events are sent, they are dropped by default
+func (c *RouterConfig) SetDispatcher(dispatcher events.DispatcherInterface) {
+	c.dispatcher = dispatcher
+}
+
+// SetIdentitiesConfig sets the search, traits mapping, schema cache TTL and allowed schemas of the identities API
+func (c *RouterConfig) SetIdentitiesConfig(search *identities.SearchConfig, traits *identities.TraitsMapping, schemaTTL time.Duration, allowedSchemas []string) {
+	c.identitySearch = search
+	c.identityTraits = traits
+	c.identitySchemaTTL = schemaTTL
+	c.identityAllowedSchemas = allowedSchemas
+}
+
+// SetPageSizeConfig sets the default and max page sizes of the paginated endpoints
+func (c *RouterConfig) SetPageSizeConfig(pageSize *types.PageSizeConfig) {
+	c.pageSize = pageSize
+}
+
+// SetCursorConfig sets the opaque pagination cursors, tokens are sent as they are by default
+func (c *RouterConfig) SetCursorConfig(cursors *types.CursorConfig) {
+	c.cursors = cursors
+}
+
+// SetAPIsConfig turns off the groups, roles or entitlements APIs, all are served by default
+func (c *RouterConfig) SetAPIsConfig(apis *APIsConfig) {
+	c.apis = apis
+}
+
+// SetStatsTTL caches the stats for ttl
+func (c *RouterConfig) SetStatsTTL(ttl time.Duration) {
+	c.statsTTL = ttl
+}
+
+// SetGroupsConfig sets the trash deleted groups are stashed in and if failed group patches are rolled back
+func (c *RouterConfig) SetGroupsConfig(trash *groups.TrashConfig, patchRollback bool) {
+	c.groupsTrash = trash
+	c.groupsPatchRollback = patchRollback
+}
+
+// SetLogSamplingConfig samples the errors logged by the services
+func (c *RouterConfig) SetLogSamplingConfig(logSampling *logging.SamplingConfig) {
+	c.logSampling = logSampling
+}
+
+// SetAuditLogSize keeps the last size audit records in memory for the admin API, none are kept by default
+func (c *RouterConfig) SetAuditLogSize(size int) {
+	c.auditLogSize = size
+}
+
 func NewRouterConfig(contextPath string, payloadValidationEnabled bool, idp *idp.Config, schemas *schemas.Config, rules *rules.Config, ui *ui.Config, external ExternalClientsConfigInterface, oauth2 *authentication.Config, mail *mail.Config, olly O11yConfigInterface) *RouterConfig {
 	return &RouterConfig{
 		contextPath:              contextPath,
@@ -59,6 +169,8 @@ func NewRouterConfig(contextPath string, payloadValidationEnabled bool, idp *idp
 		external:                 external,
 		oauth2:                   oauth2,
 		mail:                     mail,
+		status:                   status.NewConfig(nil),
+		rateLimit:                NewRateLimitConfig(0, 0),
 		olly:                     olly,
 	}
 }
@@ -82,10 +194,24 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 	middlewares := make(chi.Middlewares, 0)
 	middlewares = append(
 		middlewares,
-		middleware.RequestID,
+		requestid.Middleware,
 		monitoring.NewMiddleware(monitor, logger).ResponseTime(),
-		middlewareCORS([]string{"*"}),
 	)
+
+	if config.cors.Enabled() {
+		middlewares = append(middlewares, middlewareCORS(config.cors))
+	}
+
+	if config.gzip.Enabled() {
+		middlewares = append(middlewares, middlewareGzip(config.gzip))
+	}
+
+	if config.bodyLimit.Enabled() {
+		// the CSV import bounds its own payload, which is bigger than any JSON one
+		config.bodyLimit.WithOverride(http.MethodPost, "/api/v0/identities/import", identities.MaxImportSize)
+
+		middlewares = append(middlewares, middlewareBodyLimit(config.bodyLimit))
+	}
 	authorizationMiddleware := authorization.NewMiddleware(config.external.Authorizer(), monitor, logger).Authorize()
 
 	// TODO @shipperizer add a proper configuration to enable http logger middleware as it's expensive
@@ -98,25 +224,88 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 
 	mailService := mail.NewEmailService(mailConfig, tracer, monitor, logger)
 
-	identitiesSvc := identities.NewService(externalConfig.KratosAdmin().IdentityAPI(), externalConfig.Authorizer(), mailService, tracer, monitor, logger)
-	idpSvc := idp.NewService(idpConfig, externalConfig.Authorizer(), tracer, monitor, logger)
-	rolesSvc := roles.NewService(externalConfig.OpenFGA(), wpool, tracer, monitor, logger)
-	groupsSvc := groups.NewService(externalConfig.OpenFGA(), wpool, tracer, monitor, logger)
+	// services log the same upstream error on every request while a dependency is down, sample them
+	serviceLogger := logging.NewSamplingLogger(logger, config.logSampling)
+
+	// identities and groups services log upstream error payloads which can carry personal data
+	piiLogger := serviceLogger
+	if config.redactPII {
+		piiLogger = logging.NewRedactingLogger(serviceLogger, config.piiKeys...)
+	}
+
+	// audit events are logged at info level, keep them out of the LOG_LEVEL setting
+	// audit records keep the JSON format whatever LOG_FORMAT is, they are parsed downstream
+	auditor := audit.NewAuditor(logging.NewLogger("info", logging.FormatJSON))
+
+	var auditLog *audit.MemoryStore
+	if config.auditLogSize > 0 {
+		auditLog = audit.NewMemoryStore(config.auditLogSize)
+		auditor.SetStore(auditLog)
+	}
+
+	var dispatcher events.DispatcherInterface = events.NewNoopDispatcher()
+	if config.dispatcher != nil {
+		dispatcher = config.dispatcher
+	}
+
+	identitiesSvc := identities.NewService(externalConfig.KratosAdmin().IdentityAPI(), externalConfig.Authorizer(), mailService, wpool, auditor, config.identitySearch, tracer, monitor, piiLogger)
+	identitiesSvc.SetOpenFGAClient(externalConfig.OpenFGA())
+	identitiesSvc.SetPIIKeys(config.piiKeys)
+	identitiesSvc.SetAllowedSchemas(config.identityAllowedSchemas)
+	idpSvc := idp.NewService(idpConfig, externalConfig.Authorizer(), tracer, monitor, serviceLogger)
+	rolesSvc := roles.NewService(externalConfig.OpenFGA(), wpool, auditor, tracer, monitor, serviceLogger)
+	groupsSvc := groups.NewService(externalConfig.OpenFGA(), wpool, auditor, dispatcher, tracer, monitor, piiLogger)
+	rolesSvc.SetGroupExpander(groupsSvc)
+
+	if config.groupsTrash.Enabled() {
+		groupsSvc.SetTrash(groups.NewConfigMapTrash(config.groupsTrash, tracer, monitor, logger))
+	}
 
 	router.Use(middlewares...)
 
 	statusAPI := status.NewAPI(tracer, monitor, logger)
+	statusAPI.RegisterDependency(
+		status.KratosDependency,
+		status.NewKratosChecker(externalConfig.KratosAdmin().MetadataAPI()),
+		slices.Contains(config.status.RequiredDependencies, status.KratosDependency),
+	)
+	statusAPI.RegisterDependency(
+		status.HydraDependency,
+		status.NewHydraChecker(externalConfig.HydraAdmin().MetadataApi()),
+		slices.Contains(config.status.RequiredDependencies, status.HydraDependency),
+	)
+	statusAPI.RegisterDependency(
+		status.OpenFGADependency,
+		status.NewOpenFGAChecker(externalConfig.OpenFGA()),
+		slices.Contains(config.status.RequiredDependencies, status.OpenFGADependency),
+	)
 	metricsAPI := metrics.NewAPI(logger)
 
+	identitiesV1Svc := identities.NewV1Service(
+		&identities.Config{
+			Name:           idpConfig.Name,
+			Namespace:      idpConfig.Namespace,
+			K8s:            idpConfig.K8s,
+			OpenFGAStore:   store,
+			WorkerPool:     wpool,
+			TraitsMapping:  config.identityTraits,
+			SchemaCacheTTL: config.identitySchemaTTL,
+		},
+		identitiesSvc,
+	)
+
 	identitiesAPI := identities.NewAPI(
 		identitiesSvc,
 		tracer,
 		monitor,
 		logger,
 	)
+	identitiesAPI.SetEffectivePermissionsService(identitiesV1Svc)
+	identitiesAPI.SetPageSizeConfig(config.pageSize)
+	identitiesAPI.SetTraitsMapping(config.identityTraits)
 
 	clientsAPI := clients.NewAPI(
-		clients.NewService(externalConfig.HydraAdmin(), externalConfig.Authorizer(), tracer, monitor, logger),
+		clients.NewService(externalConfig.HydraAdmin(), externalConfig.Authorizer(), tracer, monitor, serviceLogger),
 		tracer,
 		monitor,
 		logger,
@@ -130,14 +319,14 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 	)
 
 	schemasAPI := schemas.NewAPI(
-		schemas.NewService(schemasConfig, externalConfig.Authorizer(), tracer, monitor, logger),
+		schemas.NewService(schemasConfig, externalConfig.Authorizer(), tracer, monitor, serviceLogger),
 		tracer,
 		monitor,
 		logger,
 	)
 
 	rulesAPI := rules.NewAPI(
-		rules.NewService(rulesConfig, externalConfig.Authorizer(), tracer, monitor, logger),
+		rules.NewService(rulesConfig, externalConfig.Authorizer(), tracer, monitor, serviceLogger),
 		tracer,
 		monitor,
 		logger,
@@ -149,6 +338,8 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 		monitor,
 		logger,
 	)
+	rolesAPI.SetPageSizeConfig(config.pageSize)
+	rolesAPI.SetCursorConfig(config.cursors)
 
 	groupsAPI := groups.NewAPI(
 		groupsSvc,
@@ -156,9 +347,46 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 		monitor,
 		logger,
 	)
+	groupsAPI.SetPageSizeConfig(config.pageSize)
+	groupsAPI.SetCursorConfig(config.cursors)
 
 	uiAPI := ui.NewAPI(uiConfig, tracer, monitor, logger)
 
+	adminAPI := admin.NewAPI(externalConfig.Authorizer(), tracer, monitor, logger)
+	adminAPI.SetSchemaCache(identitiesV1Svc)
+	adminAPI.SetReconcilers(groupsSvc, rolesSvc)
+	adminAPI.SetGroupPurger(groupsSvc)
+	adminAPI.SetPageSizeConfig(config.pageSize)
+	if auditLog != nil {
+		adminAPI.SetAuditLog(auditLog)
+	}
+
+	if n := mailConfig.TestRateLimitPerMinute; n > 0 {
+		mailLimiter := NewRateLimiter(NewRateLimitConfig(float64(n)/60, n), logger)
+		mailLimiter.SetKeyByIP(!oauth2Config.Enabled)
+
+		adminAPI.SetMailService(mailService, mailLimiter.RateLimit())
+	} else {
+		adminAPI.SetMailService(mailService)
+	}
+
+	meAPI := me.NewAPI(externalConfig.Authorizer(), tracer, monitor, logger)
+
+	statsAPI := stats.NewAPI(
+		stats.NewService(identitiesSvc, groupsSvc, rolesSvc, config.statsTTL, tracer, monitor, serviceLogger),
+		tracer,
+		monitor,
+		logger,
+	)
+
+	permissionsAPI := permissions.NewAPI(
+		permissions.NewService(externalConfig.OpenFGA(), tracer, monitor, serviceLogger),
+		tracer,
+		monitor,
+		logger,
+	)
+	permissionsAPI.SetCursorConfig(config.cursors)
+
 	// Create a new router for the API so that we can add extra middlewares
 	apiRouter := router.Group(nil).(*chi.Mux)
 
@@ -180,13 +408,37 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 			"/api/v0/auth",
 			"/api/v0/auth/callback",
 			"/api/v0/status",
+			"/api/v0/status/live",
+			"/api/v0/status/ready",
+			"/api/v0/version",
 			"/api/v0/metrics",
 		)
+
+		if len(oauth2Config.APIKeys) > 0 {
+			authenticationMiddleware.SetAPIKeyVerifier(authentication.NewAPIKeyVerifier(oauth2Config.APIKeys, tracer, logger))
+		}
+
 		apiRouter.Use(authenticationMiddleware.OAuth2AuthenticationChain()...)
 	} else {
 		apiRouter.Use(authentication.AuthenticationDisabledMiddleware)
 	}
 
+	// rate limit before authorization so throttled requests don't reach OpenFGA, status and
+	// metrics endpoints are exempt
+	if config.rateLimit.RequestsPerSecond > 0 {
+		limiter := NewRateLimiter(config.rateLimit, logger)
+		limiter.SetExemptEndpoints(
+			"/api/v0/status",
+			"/api/v0/status/live",
+			"/api/v0/status/ready",
+			"/api/v0/version",
+			"/api/v0/metrics",
+		)
+		limiter.SetKeyByIP(!oauth2Config.Enabled)
+
+		apiRouter.Use(limiter.RateLimit())
+	}
+
 	// register authorizationMiddleware after authentication so Principal is available if necessary
 	apiRouter.Use(authorizationMiddleware)
 
@@ -199,8 +451,14 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 		idpAPI.RegisterValidation(validationRegistry)
 		schemasAPI.RegisterValidation(validationRegistry)
 		rulesAPI.RegisterValidation(validationRegistry)
-		rolesAPI.RegisterValidation(validationRegistry)
-		groupsAPI.RegisterValidation(validationRegistry)
+
+		if config.apis.RolesEnabled() {
+			rolesAPI.RegisterValidation(validationRegistry)
+		}
+
+		if config.apis.GroupsEnabled() {
+			groupsAPI.RegisterValidation(validationRegistry)
+		}
 	}
 
 	// register endpoints as last step
@@ -212,8 +470,21 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 	idpAPI.RegisterEndpoints(apiRouter)
 	schemasAPI.RegisterEndpoints(apiRouter)
 	rulesAPI.RegisterEndpoints(apiRouter)
-	rolesAPI.RegisterEndpoints(apiRouter)
-	groupsAPI.RegisterEndpoints(apiRouter)
+	adminAPI.RegisterEndpoints(apiRouter)
+	meAPI.RegisterEndpoints(apiRouter)
+	statsAPI.RegisterEndpoints(apiRouter)
+
+	if config.apis.RolesEnabled() {
+		rolesAPI.RegisterEndpoints(apiRouter)
+	}
+
+	if config.apis.GroupsEnabled() {
+		groupsAPI.RegisterEndpoints(apiRouter)
+	}
+
+	if config.apis.EntitlementsEnabled() {
+		permissionsAPI.RegisterEndpoints(apiRouter)
+	}
 
 	if oauth2Config.Enabled {
 
@@ -228,24 +499,32 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 		login.RegisterEndpoints(apiRouter)
 	}
 
-	rebacAPI, err := v1.NewReBACAdminBackend(
-		v1.ReBACAdminBackendParams{
-			Resources: resources.NewV1Service(store, tracer, monitor, logger),
-			Roles:     roles.NewV1Service(rolesSvc),
-			Groups:    groups.NewV1Service(groupsSvc, tracer, monitor, logger),
-			Identities: identities.NewV1Service(
-				&identities.Config{
-					Name:         idpConfig.Name,
-					Namespace:    idpConfig.Namespace,
-					K8s:          idpConfig.K8s,
-					OpenFGAStore: store,
-				},
-				identitiesSvc,
-			),
-			Entitlements:      entitlements.NewV1Service(externalConfig.OpenFGA(), tracer, monitor, logger),
-			IdentityProviders: idp.NewV1Service(idpSvc),
-		},
-	)
+	groupsV1Svc := groups.NewV1Service(groupsSvc, tracer, monitor, piiLogger)
+	groupsV1Svc.SetPatchRollback(config.groupsPatchRollback)
+
+	rebacParams := v1.ReBACAdminBackendParams{
+		Resources:         resources.NewV1Service(store, tracer, monitor, logger),
+		Roles:             roles.NewV1Service(rolesSvc),
+		Groups:            groupsV1Svc,
+		Identities:        identitiesV1Svc,
+		Entitlements:      entitlements.NewV1Service(externalConfig.OpenFGA(), tracer, monitor, logger),
+		IdentityProviders: idp.NewV1Service(idpSvc),
+	}
+
+	// left out services are not advertised by the capabilities endpoint
+	if !config.apis.RolesEnabled() {
+		rebacParams.Roles = nil
+	}
+
+	if !config.apis.GroupsEnabled() {
+		rebacParams.Groups = nil
+	}
+
+	if !config.apis.EntitlementsEnabled() {
+		rebacParams.Entitlements = nil
+	}
+
+	rebacAPI, err := v1.NewReBACAdminBackend(rebacParams)
 
 	if err != nil {
 		panic(err)
@@ -253,6 +532,8 @@ func NewRouter(config *RouterConfig, wpool pool.WorkerPoolInterface) http.Handle
 
 	apiRouter.Mount("/api/", rebacAPI.Handler(""))
 
+	config.apis.registerDisabled(router)
+
 	uiAPI.RegisterEndpoints(router)
 
 	return tracing.NewMiddleware(monitor, logger).OpenTelemetry(router)